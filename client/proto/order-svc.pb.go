@@ -9,6 +9,7 @@ package pb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -76,10 +77,69 @@ func (PurchaseResponse_Status) EnumDescriptor() ([]byte, []int) {
 	return file_order_svc_proto_rawDescGZIP(), []int{1, 0}
 }
 
+type Order_Status int32
+
+const (
+	Order_PENDING   Order_Status = 0
+	Order_CONFIRMED Order_Status = 1
+	Order_CANCELLED Order_Status = 2
+	Order_REFUNDED  Order_Status = 3
+	Order_FAILED    Order_Status = 4
+)
+
+// Enum value maps for Order_Status.
+var (
+	Order_Status_name = map[int32]string{
+		0: "PENDING",
+		1: "CONFIRMED",
+		2: "CANCELLED",
+		3: "REFUNDED",
+		4: "FAILED",
+	}
+	Order_Status_value = map[string]int32{
+		"PENDING":   0,
+		"CONFIRMED": 1,
+		"CANCELLED": 2,
+		"REFUNDED":  3,
+		"FAILED":    4,
+	}
+)
+
+func (x Order_Status) Enum() *Order_Status {
+	p := new(Order_Status)
+	*p = x
+	return p
+}
+
+func (x Order_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Order_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_order_svc_proto_enumTypes[1].Descriptor()
+}
+
+func (Order_Status) Type() protoreflect.EnumType {
+	return &file_order_svc_proto_enumTypes[1]
+}
+
+func (x Order_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Order_Status.Descriptor instead.
+func (Order_Status) EnumDescriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{2, 0}
+}
+
 type PurchaseRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	EventId       string                 `protobuf:"bytes,1,opt,name=eventId,proto3" json:"eventId,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=userId,proto3" json:"userId,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=eventId,proto3" json:"eventId,omitempty"`
+	UserId  string                 `protobuf:"bytes,2,opt,name=userId,proto3" json:"userId,omitempty"`
+	// Specific seats to purchase; empty for general admission
+	SeatIds       []string `protobuf:"bytes,3,rep,name=seatIds,proto3" json:"seatIds,omitempty"`
+	Quantity      int32    `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Tier          string   `protobuf:"bytes,5,opt,name=tier,proto3" json:"tier,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -128,6 +188,27 @@ func (x *PurchaseRequest) GetUserId() string {
 	return ""
 }
 
+func (x *PurchaseRequest) GetSeatIds() []string {
+	if x != nil {
+		return x.SeatIds
+	}
+	return nil
+}
+
+func (x *PurchaseRequest) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *PurchaseRequest) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
 type PurchaseResponse struct {
 	state         protoimpl.MessageState  `protogen:"open.v1"`
 	Status        PurchaseResponse_Status `protobuf:"varint,1,opt,name=status,proto3,enum=order.PurchaseResponse_Status" json:"status,omitempty"`
@@ -172,14 +253,458 @@ func (x *PurchaseResponse) GetStatus() PurchaseResponse_Status {
 	return PurchaseResponse_QUEUED
 }
 
+// Order represents a ticket order placed by a user
+type Order struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	EventId       string                 `protobuf:"bytes,2,opt,name=eventId,proto3" json:"eventId,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=userId,proto3" json:"userId,omitempty"`
+	Status        Order_Status           `protobuf:"varint,4,opt,name=status,proto3,enum=order.Order_Status" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_order_svc_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Order) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *Order) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Order) GetStatus() Order_Status {
+	if x != nil {
+		return x.Status
+	}
+	return Order_PENDING
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListOrdersRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
+	// Optional status filter
+	Statuses  []Order_Status `protobuf:"varint,2,rep,packed,name=statuses,proto3,enum=order.Order_Status" json:"statuses,omitempty"`
+	PageSize  int32          `protobuf:"varint,3,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken string         `protobuf:"bytes,4,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	// Comma-separated sort fields, each optionally followed by " desc", e.g. "createdAt desc"
+	OrderBy       string `protobuf:"bytes,5,opt,name=orderBy,proto3" json:"orderBy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersRequest) Reset() {
+	*x = ListOrdersRequest{}
+	mi := &file_order_svc_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersRequest) ProtoMessage() {}
+
+func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListOrdersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetStatuses() []Order_Status {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListOrdersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+type ListOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_order_svc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *ListOrdersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=userId,proto3" json:"userId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_order_svc_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *GetOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderResponse) Reset() {
+	*x = GetOrderResponse{}
+	mi := &file_order_svc_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderResponse) ProtoMessage() {}
+
+func (x *GetOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderResponse.ProtoReflect.Descriptor instead.
+func (*GetOrderResponse) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type CancelOrderRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	OrderId string                 `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	UserId  string                 `protobuf:"bytes,2,opt,name=userId,proto3" json:"userId,omitempty"`
+	Reason  string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// force skips the ownership and refund window checks; admin only
+	Force         bool   `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`
+	ActorId       string `protobuf:"bytes,5,opt,name=actorId,proto3" json:"actorId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_order_svc_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CancelOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *CancelOrderRequest) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+type CancelOrderResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Order *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	// Whether a refund was issued for the cancelled order
+	Refunded      bool `protobuf:"varint,2,opt,name=refunded,proto3" json:"refunded,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderResponse) Reset() {
+	*x = CancelOrderResponse{}
+	mi := &file_order_svc_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderResponse) ProtoMessage() {}
+
+func (x *CancelOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_svc_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderResponse.ProtoReflect.Descriptor instead.
+func (*CancelOrderResponse) Descriptor() ([]byte, []int) {
+	return file_order_svc_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CancelOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *CancelOrderResponse) GetRefunded() bool {
+	if x != nil {
+		return x.Refunded
+	}
+	return false
+}
+
 var File_order_svc_proto protoreflect.FileDescriptor
 
 const file_order_svc_proto_rawDesc = "" +
 	"\n" +
-	"\x0forder-svc.proto\x12\x05order\"C\n" +
+	"\x0forder-svc.proto\x12\x05order\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8d\x01\n" +
 	"\x0fPurchaseRequest\x12\x18\n" +
 	"\aeventId\x18\x01 \x01(\tR\aeventId\x12\x16\n" +
-	"\x06userId\x18\x02 \x01(\tR\x06userId\"\x9f\x01\n" +
+	"\x06userId\x18\x02 \x01(\tR\x06userId\x12\x18\n" +
+	"\aseatIds\x18\x03 \x03(\tR\aseatIds\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\x12\x12\n" +
+	"\x04tier\x18\x05 \x01(\tR\x04tier\"\x9f\x01\n" +
 	"\x10PurchaseResponse\x126\n" +
 	"\x06status\x18\x01 \x01(\x0e2\x1e.order.PurchaseResponse.StatusR\x06status\"S\n" +
 	"\x06Status\x12\n" +
@@ -189,9 +714,50 @@ const file_order_svc_proto_rawDesc = "" +
 	"\x10ALREADY_IN_QUEUE\x10\x02\x12\t\n" +
 	"\x05ERROR\x10\x03\x12\x0e\n" +
 	"\n" +
-	"QUEUE_FULL\x10\x042Q\n" +
+	"QUEUE_FULL\x10\x04\"\xb9\x02\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aeventId\x18\x02 \x01(\tR\aeventId\x12\x16\n" +
+	"\x06userId\x18\x03 \x01(\tR\x06userId\x12+\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x13.order.Order.StatusR\x06status\x128\n" +
+	"\tcreatedAt\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"M\n" +
+	"\x06Status\x12\v\n" +
+	"\aPENDING\x10\x00\x12\r\n" +
+	"\tCONFIRMED\x10\x01\x12\r\n" +
+	"\tCANCELLED\x10\x02\x12\f\n" +
+	"\bREFUNDED\x10\x03\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x04\"\xb0\x01\n" +
+	"\x11ListOrdersRequest\x12\x16\n" +
+	"\x06userId\x18\x01 \x01(\tR\x06userId\x12/\n" +
+	"\bstatuses\x18\x02 \x03(\x0e2\x13.order.Order.StatusR\bstatuses\x12\x1a\n" +
+	"\bpageSize\x18\x03 \x01(\x05R\bpageSize\x12\x1c\n" +
+	"\tpageToken\x18\x04 \x01(\tR\tpageToken\x12\x18\n" +
+	"\aorderBy\x18\x05 \x01(\tR\aorderBy\"`\n" +
+	"\x12ListOrdersResponse\x12$\n" +
+	"\x06orders\x18\x01 \x03(\v2\f.order.OrderR\x06orders\x12$\n" +
+	"\rnextPageToken\x18\x02 \x01(\tR\rnextPageToken\"C\n" +
+	"\x0fGetOrderRequest\x12\x18\n" +
+	"\aorderId\x18\x01 \x01(\tR\aorderId\x12\x16\n" +
+	"\x06userId\x18\x02 \x01(\tR\x06userId\"6\n" +
+	"\x10GetOrderResponse\x12\"\n" +
+	"\x05order\x18\x01 \x01(\v2\f.order.OrderR\x05order\"\x8e\x01\n" +
+	"\x12CancelOrderRequest\x12\x18\n" +
+	"\aorderId\x18\x01 \x01(\tR\aorderId\x12\x16\n" +
+	"\x06userId\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05force\x18\x04 \x01(\bR\x05force\x12\x18\n" +
+	"\aactorId\x18\x05 \x01(\tR\aactorId\"U\n" +
+	"\x13CancelOrderResponse\x12\"\n" +
+	"\x05order\x18\x01 \x01(\v2\f.order.OrderR\x05order\x12\x1a\n" +
+	"\brefunded\x18\x02 \x01(\bR\brefunded2\x97\x02\n" +
 	"\fOrderService\x12A\n" +
-	"\x0ePurchaseTicket\x12\x16.order.PurchaseRequest\x1a\x17.order.PurchaseResponseB\x0eZ\forder-svc/pbb\x06proto3"
+	"\x0ePurchaseTicket\x12\x16.order.PurchaseRequest\x1a\x17.order.PurchaseResponse\x12A\n" +
+	"\n" +
+	"ListOrders\x12\x18.order.ListOrdersRequest\x1a\x19.order.ListOrdersResponse\x12;\n" +
+	"\bGetOrder\x12\x16.order.GetOrderRequest\x1a\x17.order.GetOrderResponse\x12D\n" +
+	"\vCancelOrder\x12\x19.order.CancelOrderRequest\x1a\x1a.order.CancelOrderResponseB\x0eZ\forder-svc/pbb\x06proto3"
 
 var (
 	file_order_svc_proto_rawDescOnce sync.Once
@@ -205,22 +771,44 @@ func file_order_svc_proto_rawDescGZIP() []byte {
 	return file_order_svc_proto_rawDescData
 }
 
-var file_order_svc_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_order_svc_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_order_svc_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_order_svc_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_order_svc_proto_goTypes = []any{
-	(PurchaseResponse_Status)(0), // 0: order.PurchaseResponse.Status
-	(*PurchaseRequest)(nil),      // 1: order.PurchaseRequest
-	(*PurchaseResponse)(nil),     // 2: order.PurchaseResponse
+	(PurchaseResponse_Status)(0),  // 0: order.PurchaseResponse.Status
+	(Order_Status)(0),             // 1: order.Order.Status
+	(*PurchaseRequest)(nil),       // 2: order.PurchaseRequest
+	(*PurchaseResponse)(nil),      // 3: order.PurchaseResponse
+	(*Order)(nil),                 // 4: order.Order
+	(*ListOrdersRequest)(nil),     // 5: order.ListOrdersRequest
+	(*ListOrdersResponse)(nil),    // 6: order.ListOrdersResponse
+	(*GetOrderRequest)(nil),       // 7: order.GetOrderRequest
+	(*GetOrderResponse)(nil),      // 8: order.GetOrderResponse
+	(*CancelOrderRequest)(nil),    // 9: order.CancelOrderRequest
+	(*CancelOrderResponse)(nil),   // 10: order.CancelOrderResponse
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
 }
 var file_order_svc_proto_depIdxs = []int32{
-	0, // 0: order.PurchaseResponse.status:type_name -> order.PurchaseResponse.Status
-	1, // 1: order.OrderService.PurchaseTicket:input_type -> order.PurchaseRequest
-	2, // 2: order.OrderService.PurchaseTicket:output_type -> order.PurchaseResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: order.PurchaseResponse.status:type_name -> order.PurchaseResponse.Status
+	1,  // 1: order.Order.status:type_name -> order.Order.Status
+	11, // 2: order.Order.createdAt:type_name -> google.protobuf.Timestamp
+	11, // 3: order.Order.updatedAt:type_name -> google.protobuf.Timestamp
+	1,  // 4: order.ListOrdersRequest.statuses:type_name -> order.Order.Status
+	4,  // 5: order.ListOrdersResponse.orders:type_name -> order.Order
+	4,  // 6: order.GetOrderResponse.order:type_name -> order.Order
+	4,  // 7: order.CancelOrderResponse.order:type_name -> order.Order
+	2,  // 8: order.OrderService.PurchaseTicket:input_type -> order.PurchaseRequest
+	5,  // 9: order.OrderService.ListOrders:input_type -> order.ListOrdersRequest
+	7,  // 10: order.OrderService.GetOrder:input_type -> order.GetOrderRequest
+	9,  // 11: order.OrderService.CancelOrder:input_type -> order.CancelOrderRequest
+	3,  // 12: order.OrderService.PurchaseTicket:output_type -> order.PurchaseResponse
+	6,  // 13: order.OrderService.ListOrders:output_type -> order.ListOrdersResponse
+	8,  // 14: order.OrderService.GetOrder:output_type -> order.GetOrderResponse
+	10, // 15: order.OrderService.CancelOrder:output_type -> order.CancelOrderResponse
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_order_svc_proto_init() }
@@ -233,8 +821,8 @@ func file_order_svc_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_order_svc_proto_rawDesc), len(file_order_svc_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   2,
+			NumEnums:      2,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
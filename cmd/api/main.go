@@ -1,17 +1,17 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
+	"apigw/internal/app/buildinfo"
 	"apigw/internal/app/config"
-	"apigw/internal/app/router"
-	"apigw/internal/client"
+	"apigw/internal/app/gateway"
+	"apigw/internal/contract"
 	"apigw/pkg/utils/crypt/token"
 	logutils "apigw/pkg/utils/log"
 
@@ -25,103 +25,130 @@ func main() {
 	}
 	logger := logutils.GetLogger()
 
-	// Load configuration
-	cfg, err := config.LoadConfig("config.yaml")
+	// Load configuration; without a file the gateway runs on defaults and APIGW_* variables
+	configFlag := flag.String("config", "", "path to the configuration file (default: $APIGW_CONFIG, then config.yaml if present)")
+	printEnv := flag.Bool("print-env", false, "print the environment variables that override settings and exit")
+	printVersion := flag.Bool("version", false, "print the build information and exit")
+	devStub := flag.Bool("dev-stub", false, "answer backend calls from fixtures instead of dialing the services (same as dev_stub.enabled)")
+	flag.Parse()
+
+	if *printVersion {
+		build := buildinfo.Get()
+		fmt.Printf("apigw %s (commit %s, built %s, %s %s)\n",
+			build.Version, build.ShortCommit(), build.Date, build.GoVersion, build.Platform)
+		return
+	}
+
+	if *printEnv {
+		for _, name := range config.EnvVars() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	// The flag is applied as its environment variable so that reloaded configurations
+	// keep it too
+	if *devStub {
+		os.Setenv("APIGW_DEV_STUB_ENABLED", "true")
+	}
+
+	configPath := config.ResolvePath(*configFlag)
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
+		var report *config.ValidationError
+		if errors.As(err, &report) {
+			for _, problem := range report.Errors {
+				logger.WithFields(logrus.Fields{
+					"field":   problem.Field,
+					"problem": problem.Message,
+				}).Error("Invalid configuration")
+			}
+		}
 		logger.Fatalf("Configuration validation failed: %v", err)
 	}
-
-	// Create clients
-	userClient, err := client.NewUserServiceClient(&cfg.Services.UserService)
-	if err != nil {
-		logger.Fatalf("Failed to create user client: %v", err)
-	}
-	orderClient, err := client.NewOrderServiceClient(&cfg.Services.OrderService)
+	logger.WithFields(logrus.Fields{
+		"environment": cfg.App.Environment,
+		"files":       cfg.Files,
+	}).Info("Configuration loaded")
+
+	// Apply the configured log level, backend, format and outputs
+	logFiles, err := logutils.Configure(logutils.Options{
+		Level:       cfg.Log.Level,
+		Backend:     cfg.Log.Backend,
+		Format:      cfg.Log.Format,
+		Service:     cfg.App.Name,
+		Output:      cfg.Log.Output,
+		File:        cfg.Log.File.Path,
+		ErrorOutput: cfg.Log.ErrorOutput,
+		ErrorFile:   cfg.Log.File.ErrorPath,
+		Rotation: logutils.Rotation{
+			MaxSizeMB:  cfg.Log.Rotate.MaxSizeMB,
+			MaxAgeDays: cfg.Log.Rotate.MaxAgeDays,
+			MaxBackups: cfg.Log.Rotate.MaxBackups,
+			Compress:   cfg.Log.Rotate.Compress,
+			LocalTime:  cfg.Log.Rotate.LocalTime,
+		},
+		Sampling: logSampling(cfg.Log.Sampling),
+		Modules:  cfg.Log.Modules,
+	})
 	if err != nil {
-		logger.Fatalf("Failed to create order client: %v", err)
+		logger.Fatalf("Failed to configure logging: %v", err)
 	}
+	defer logFiles.Close()
 
-	// Initialize Redis client for rate limiting
-	var redisClient *client.RedisClient
-	if cfg.Redis.Enabled {
-		redisClient, err = client.NewRedisClient(&cfg.Redis, logger)
+	// In dev stub mode the services are faked in process and answer from fixtures
+	opts := []gateway.Option{gateway.WithConfigPath(configPath)}
+	if cfg.DevStub.Enabled {
+		scenario, err := contract.DevScenario(cfg.DevStub.Fixtures...)
 		if err != nil {
-			logger.Fatalf("Failed to create Redis client: %v", err)
-		}
-		defer redisClient.Close()
-		logger.Info("Redis client initialized for rate limiting")
-	} else {
-		logger.Info("Redis is disabled, rate limiting will not be available")
-	}
-
-	// Ensure clients are properly closed on exit
-	defer func() {
-		if userClient != nil {
-			if err := userClient.Close(); err != nil {
-				logger.WithError(err).Error("Failed to close user client")
-			}
+			logger.Fatalf("Failed to load the dev stub fixtures: %v", err)
 		}
-		if orderClient != nil {
-			if err := orderClient.Close(); err != nil {
-				logger.WithError(err).Error("Failed to close order client")
-			}
+		stubTokens, err := token.NewJWTTokenMaker(cfg.JWT.SecretKey)
+		if err != nil {
+			logger.Fatalf("Failed to create the dev stub token maker: %v", err)
 		}
-	}()
+		stub := contract.NewBackend(scenario)
+		// Tokens of a working day, so a frontend session does not need refreshing
+		stub.IssueTokens(func(userID string) (string, error) {
+			return stubTokens.CreateToken(userID, cfg.DevStub.Role, 24*time.Hour)
+		})
+		defer stub.Close()
+		opts = append(opts,
+			gateway.WithDialOptions(stub.DialOption()),
+			gateway.WithServices(contract.StubServices(cfg.Services)),
+		)
+		logger.WithField("fixtures", cfg.DevStub.Fixtures).Warn("Dev stub mode: backend calls are answered from fixtures")
+	}
 
-	// Initialize token maker
-	tokenMaker, err := token.NewJWTTokenMaker(cfg.JWT.SecretKey)
+	// Connect the backends and Redis and build the routers, then serve until shutdown
+	app, err := gateway.New(cfg, logger, opts...)
 	if err != nil {
-		logger.Fatalf("Failed to create token maker: %v", err)
+		logger.Fatalf("Failed to start API Gateway: %v", err)
 	}
-
-	// Setup router
-	router := router.SetupRouter(cfg, userClient, orderClient, redisClient, tokenMaker, logger)
-
-	// Create HTTP server
-	serverAddr := fmt.Sprintf("%s:%d", cfg.Server.HTTP.Host, cfg.Server.HTTP.Port)
-	server := &http.Server{
-		Addr:         serverAddr,
-		Handler:      router,
-		ReadTimeout:  cfg.Server.HTTP.ReadTimeout,
-		WriteTimeout: cfg.Server.HTTP.WriteTimeout,
-		IdleTimeout:  cfg.Server.HTTP.IdleTimeout,
+	err = app.Run()
+	app.Close()
+	if err != nil {
+		logger.Fatalf("API Gateway failed: %v", err)
 	}
 
-	// Start server in a goroutine
-	go func() {
-		logger.WithFields(logrus.Fields{
-			"address":     serverAddr,
-			"port":        cfg.Server.HTTP.Port,
-			"host":        cfg.Server.HTTP.Host,
-			"environment": cfg.App.Environment,
-			"version":     cfg.App.Version,
-		}).Info("API Gateway server starting")
-
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.WithError(err).Fatal("Failed to start server")
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down API Gateway server...")
-
-	// Create a deadline for server shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.HTTP.GracefulShutdownTimeout)
-	defer cancel()
+	logger.Info("API Gateway server exited")
+}
 
-	// Attempt graceful shutdown
-	if err := server.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("Server forced to shutdown")
+// logSampling returns the sampling of log lines, none when it is disabled
+func logSampling(cfg config.LogSamplingConfig) logutils.Sampling {
+	if !cfg.Enabled {
+		return logutils.Sampling{}
+	}
+	return logutils.Sampling{
+		Interval:   cfg.Interval,
+		Initial:    cfg.Initial,
+		Thereafter: cfg.Thereafter,
+		RateLimit:  cfg.RateLimit,
+		KeyFields:  cfg.KeyFields,
 	}
-
-	logger.Info("API Gateway server exited")
 }
@@ -0,0 +1,227 @@
+// Command loadgen drives HTTP load against the gateway and reports throughput, status
+// codes and latency percentiles. With -backend it also serves fake gRPC backends, so a
+// locally running gateway can be load tested without the real services:
+//
+//	loadgen -backend 127.0.0.1:50050 -hold      # fake backends only
+//	loadgen -url http://localhost:8080 -c 100 -d 30s \
+//	    -jwt-secret "$APIGW_JWT_SECRET_KEY" \
+//	    -r "GET /api/v1/events/evt_1" \
+//	    -r 'POST /api/v1/orders/purchase {"eventId":"evt_1"}'
+//
+// Requests are issued in the order given, round robin, so runs are reproducible.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"apigw/internal/fakebackend"
+	"apigw/pkg/utils/crypt/token"
+)
+
+// request is one of the requests a run cycles through
+type request struct {
+	method string
+	path   string
+	body   string
+}
+
+// result is the outcome of a single request
+type result struct {
+	status  int // 0 when the request failed
+	latency time.Duration
+}
+
+// requestList collects the repeated -r flag
+type requestList []request
+
+func (l *requestList) String() string {
+	parts := make([]string, len(*l))
+	for i, r := range *l {
+		parts[i] = r.method + " " + r.path
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (l *requestList) Set(value string) error {
+	method, rest, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return fmt.Errorf("expected \"METHOD /path [body]\", got %q", value)
+	}
+	path, body, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path must start with /, got %q", path)
+	}
+	*l = append(*l, request{method: strings.ToUpper(method), path: path, body: strings.TrimSpace(body)})
+	return nil
+}
+
+func main() {
+	var requests requestList
+	baseURL := flag.String("url", "http://localhost:8080", "gateway base URL")
+	concurrency := flag.Int("c", 50, "concurrent workers")
+	duration := flag.Duration("d", 30*time.Second, "run duration")
+	rate := flag.Int("rps", 0, "total requests per second, 0 for as fast as possible")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-request timeout")
+	bearer := flag.String("token", "", "bearer token sent with every request")
+	jwtSecret := flag.String("jwt-secret", "", "sign a bearer token with the gateway's JWT secret instead of -token")
+	userID := flag.String("user", "loadtest-user", "user ID of the token signed with -jwt-secret")
+	backend := flag.String("backend", "", "also serve fake gRPC backends on this address")
+	latency := flag.Duration("backend-latency", 5*time.Millisecond, "processing time of the fake backends")
+	hold := flag.Bool("hold", false, "only serve the fake backends until interrupted, without generating load")
+	flag.Var(&requests, "r", "request as \"METHOD /path [json body]\" (repeatable, default \"GET /api/v1/events\")")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *backend != "" {
+		fake := fakebackend.New(*latency)
+		if err := fake.Start(*backend); err != nil {
+			fatalf("%v", err)
+		}
+		defer fake.Stop()
+		fmt.Printf("Fake backends listening on %s (latency %s); point every services.*.host/port there\n", fake.Addr(), *latency)
+	}
+	if *hold {
+		<-ctx.Done()
+		return
+	}
+
+	if len(requests) == 0 {
+		requests = requestList{{method: http.MethodGet, path: "/api/v1/events"}}
+	}
+	if *jwtSecret != "" {
+		maker, err := token.NewJWTTokenMaker(*jwtSecret)
+		if err != nil {
+			fatalf("invalid -jwt-secret: %v", err)
+		}
+		if *bearer, err = maker.CreateToken(*userID, "", *duration+time.Hour); err != nil {
+			fatalf("failed to sign token: %v", err)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = *concurrency
+	client := &http.Client{Transport: transport, Timeout: *timeout}
+
+	runCtx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	// A shared ticker paces the workers when a rate is set
+	var ticks <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	fmt.Printf("Running %s against %s with %d workers\n", *duration, *baseURL, *concurrency)
+	results := make([][]result, *concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; ; i += *concurrency {
+				if ticks != nil {
+					select {
+					case <-ticks:
+					case <-runCtx.Done():
+						return
+					}
+				}
+				if runCtx.Err() != nil {
+					return
+				}
+				results[w] = append(results[w], send(ctx, client, *baseURL, *bearer, requests[i%len(requests)]))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	report(slices.Concat(results...), time.Since(start))
+}
+
+// send issues a request and measures its latency
+func send(ctx context.Context, client *http.Client, baseURL, bearer string, r request) result {
+	var body io.Reader
+	if r.body != "" {
+		body = strings.NewReader(r.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, baseURL+r.path, body)
+	if err != nil {
+		return result{}
+	}
+	if r.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{latency: time.Since(start)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return result{status: resp.StatusCode, latency: time.Since(start)}
+}
+
+// report prints throughput, status codes and latency percentiles
+func report(results []result, elapsed time.Duration) {
+	if len(results) == 0 {
+		fmt.Println("No requests completed")
+		return
+	}
+
+	statuses := make(map[int]int)
+	latencies := make([]time.Duration, 0, len(results))
+	for _, r := range results {
+		statuses[r.status]++
+		latencies = append(latencies, r.latency)
+	}
+	slices.Sort(latencies)
+	percentile := func(p float64) time.Duration {
+		return latencies[int(float64(len(latencies)-1)*p)]
+	}
+
+	fmt.Printf("\nRequests:   %d in %s (%.1f req/s)\n", len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds())
+	fmt.Printf("Latency:    p50 %s  p90 %s  p99 %s  max %s\n",
+		percentile(0.50), percentile(0.90), percentile(0.99), latencies[len(latencies)-1])
+
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	fmt.Print("Status:    ")
+	for _, code := range codes {
+		if code == 0 {
+			fmt.Printf(" errors=%d", statuses[code])
+		} else {
+			fmt.Printf(" %d=%d", code, statuses[code])
+		}
+	}
+	fmt.Println()
+}
+
+// fatalf prints an error and exits
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "loadgen: "+format+"\n", args...)
+	os.Exit(1)
+}
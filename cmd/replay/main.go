@@ -0,0 +1,312 @@
+// Command replay re-sends the exchanges recorded by the gateway (recording.enabled)
+// against another gateway, e.g. staging, and reports the responses whose status, or
+// optionally body, differs from the recorded one:
+//
+//	replay -url https://staging.example.com -jwt-secret "$APIGW_JWT_SECRET_KEY" \
+//	    -partner-secret "$ACME_SIGNING_SECRET" -compare-body recordings/
+//
+// Exchanges are replayed one at a time in the order they were recorded. Authenticated
+// requests carry a token for -user instead of the redacted one and signed requests are
+// signed again; requests whose body or query had values redacted cannot be sent as they
+// were and are skipped. The exit status is 1 when a response differs or a request fails.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"apigw/internal/app/recording"
+	"apigw/internal/app/signing"
+	"apigw/pkg/utils/crypt/token"
+)
+
+// droppedHeaders are recorded headers that are not sent again: they describe the
+// original connection or are set anew
+var droppedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"X-Request-Id":      true,
+	"Accept-Encoding":   true,
+}
+
+// options are the settings of a replay
+type options struct {
+	baseURL       string
+	bearer        string
+	partnerSecret string
+	compareBody   bool
+	ignore        map[string]bool
+	verbose       bool
+}
+
+// summary counts the outcomes of the replayed exchanges
+type summary struct {
+	matched, differed, failed, skipped int
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.baseURL, "url", "http://localhost:8080", "base URL of the gateway replayed against")
+	flag.StringVar(&opts.bearer, "token", "", "bearer token sent with the requests that were authenticated")
+	jwtSecret := flag.String("jwt-secret", "", "sign a bearer token with the gateway's JWT secret instead of -token")
+	userID := flag.String("user", "replay-user", "user ID of the token signed with -jwt-secret")
+	role := flag.String("role", "", "role of the token signed with -jwt-secret, e.g. admin")
+	flag.StringVar(&opts.partnerSecret, "partner-secret", "", "signing secret of the partner of signed requests")
+	flag.BoolVar(&opts.compareBody, "compare-body", false, "also compare JSON response bodies")
+	ignore := flag.String("ignore", "request_id,requestId,timestamp", "comma-separated response fields left out of body comparisons")
+	rate := flag.Int("rps", 0, "requests per second, 0 for as fast as possible")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-request timeout")
+	flag.BoolVar(&opts.verbose, "v", false, "print every exchange, not only those that differ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: replay [flags] recording.jsonl|directory...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	files, err := recordingFiles(flag.Args())
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if len(files) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.baseURL = strings.TrimSuffix(opts.baseURL, "/")
+	opts.ignore = make(map[string]bool)
+	for _, field := range strings.Split(*ignore, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opts.ignore[field] = true
+		}
+	}
+	if *jwtSecret != "" {
+		maker, err := token.NewJWTTokenMaker(*jwtSecret)
+		if err != nil {
+			fatalf("invalid -jwt-secret: %v", err)
+		}
+		if opts.bearer, err = maker.CreateToken(*userID, *role, 24*time.Hour); err != nil {
+			fatalf("failed to sign token: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var ticks <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	var sum summary
+	for _, file := range files {
+		err := replayFile(file, func(exchange recording.Exchange) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !exchange.Request.Replayable() {
+				sum.skipped++
+				return nil
+			}
+			if ticks != nil {
+				select {
+				case <-ticks:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			diff, err := replay(ctx, client, opts, exchange)
+			label := exchange.Request.Method + " " + exchange.Request.URI
+			switch {
+			case err != nil:
+				sum.failed++
+				fmt.Printf("FAILED   %s: %v\n", label, err)
+			case diff != "":
+				sum.differed++
+				fmt.Printf("DIFFERS  %s: %s\n", label, diff)
+			default:
+				sum.matched++
+				if opts.verbose {
+					fmt.Printf("MATCHES  %s\n", label)
+				}
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			fatalf("%s: %v", file, err)
+		}
+	}
+
+	fmt.Printf("\nReplayed %d exchanges: %d matched, %d differed, %d failed; %d skipped as not replayable\n",
+		sum.matched+sum.differed+sum.failed, sum.matched, sum.differed, sum.failed, sum.skipped)
+	if sum.differed > 0 || sum.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// recordingFiles expands directories into the recording files they hold, oldest first
+func recordingFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "exchanges-*.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		// The names sort by the hour they were recorded
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// replayFile calls fn for each exchange of a recording file
+func replayFile(file string, fn func(recording.Exchange) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return recording.Read(f, fn)
+}
+
+// replay sends a recorded request again and describes how the response differs from
+// the recorded one; the description is empty when it does not
+func replay(ctx context.Context, client *http.Client, opts options, exchange recording.Exchange) (string, error) {
+	recorded := exchange.Request
+	body := []byte(recorded.Body)
+	req, err := http.NewRequestWithContext(ctx, recorded.Method, opts.baseURL+recorded.URI, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	for name, values := range recorded.Header {
+		if droppedHeaders[http.CanonicalHeaderKey(name)] || (len(values) == 1 && values[0] == recording.Redacted) {
+			continue
+		}
+		req.Header[name] = values
+	}
+	if authorization := recorded.Header.Get("Authorization"); authorization != "" && opts.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.bearer)
+	}
+	if partnerID := recorded.Header.Get(signing.HeaderPartnerID); partnerID != "" && opts.partnerSecret != "" {
+		for name, value := range signing.SignRequest(partnerID, opts.partnerSecret, recorded.Method, recorded.URI, body) {
+			req.Header.Set(name, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	want := exchange.Response
+	if resp.StatusCode != want.Status {
+		return fmt.Sprintf("status %d, recorded %d", resp.StatusCode, want.Status), nil
+	}
+	if !opts.compareBody || want.BodyOmitted || len(want.Body) == 0 {
+		return "", nil
+	}
+	var got, recordedBody interface{}
+	if err := json.Unmarshal(respBody, &got); err != nil {
+		return "response body is not JSON", nil
+	}
+	if err := json.Unmarshal(want.Body, &recordedBody); err != nil {
+		return "", fmt.Errorf("invalid recorded body: %w", err)
+	}
+	if path := firstDifference(got, recordedBody, "", opts.ignore); path != "" {
+		return "body differs at " + path, nil
+	}
+	return "", nil
+}
+
+// firstDifference returns the path of the first value of got differing from the recorded
+// one, or an empty string; redacted and ignored fields match anything
+func firstDifference(got, recorded interface{}, path string, ignore map[string]bool) string {
+	if recorded == recording.Redacted {
+		return ""
+	}
+	switch want := recorded.(type) {
+	case map[string]interface{}:
+		have, ok := got.(map[string]interface{})
+		if !ok {
+			return orRoot(path)
+		}
+		keys := make([]string, 0, len(want)+len(have))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		for key := range have {
+			if _, ok := want[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ignore[key] {
+				continue
+			}
+			if diff := firstDifference(have[key], want[key], path+"."+key, ignore); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case []interface{}:
+		have, ok := got.([]interface{})
+		if !ok || len(have) != len(want) {
+			return orRoot(path)
+		}
+		for i := range want {
+			if diff := firstDifference(have[i], want[i], fmt.Sprintf("%s[%d]", path, i), ignore); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	default:
+		if !reflect.DeepEqual(got, recorded) {
+			return orRoot(path)
+		}
+		return ""
+	}
+}
+
+// orRoot names the root of a document for an empty path
+func orRoot(path string) string {
+	if path == "" {
+		return "the root"
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+// fatalf prints an error and exits
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "replay: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,100 @@
+// Package acl implements the network access control lists evaluated before authentication
+package acl
+
+import (
+	"net/netip"
+	"strings"
+
+	"apigw/internal/app/config"
+)
+
+// Sources of a denial, reported in logs and metrics
+const (
+	SourceBlocklist = "blocklist"
+	SourceDeny      = "deny"
+	SourceRule      = "rule"
+)
+
+// List is the compiled static part of the access control lists
+type List struct {
+	deny  []netip.Prefix
+	rules []rule
+}
+
+// rule is a compiled route group rule
+type rule struct {
+	pathPrefix string
+	allow      []netip.Prefix
+	deny       []netip.Prefix
+}
+
+// Compile parses the networks of the configured lists; the configuration is validated
+// beforehand, so invalid networks are only reported as an error here
+func Compile(cfg config.ACLConfig) (*List, error) {
+	deny, err := parseNetworks(cfg.Deny)
+	if err != nil {
+		return nil, err
+	}
+	l := &List{deny: deny}
+	for _, rc := range cfg.Rules {
+		r := rule{pathPrefix: rc.PathPrefix}
+		if r.allow, err = parseNetworks(rc.Allow); err != nil {
+			return nil, err
+		}
+		if r.deny, err = parseNetworks(rc.Deny); err != nil {
+			return nil, err
+		}
+		l.rules = append(l.rules, r)
+	}
+	return l, nil
+}
+
+// Check reports whether a client address may access path, and otherwise the source of
+// the denial. The global deny list is checked first, then the rule of the longest
+// path prefix matching path: its deny list, then its allow list if it has one.
+func (l *List) Check(path string, addr netip.Addr) (bool, string) {
+	if contains(l.deny, addr) {
+		return false, SourceDeny
+	}
+
+	var match *rule
+	for i := range l.rules {
+		r := &l.rules[i]
+		if strings.HasPrefix(path, r.pathPrefix) && (match == nil || len(r.pathPrefix) > len(match.pathPrefix)) {
+			match = r
+		}
+	}
+	if match == nil {
+		return true, ""
+	}
+	if contains(match.deny, addr) {
+		return false, SourceRule
+	}
+	if len(match.allow) > 0 && !contains(match.allow, addr) {
+		return false, SourceRule
+	}
+	return true, ""
+}
+
+// contains reports whether any of the networks contains the address
+func contains(networks []netip.Prefix, addr netip.Addr) bool {
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetworks parses a list of CIDR ranges or addresses
+func parseNetworks(networks []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		prefix, err := config.ParseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
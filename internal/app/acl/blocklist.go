@@ -0,0 +1,176 @@
+package acl
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is a network blocked at runtime through the admin API
+type Entry struct {
+	Network   string    `json:"network"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero for entries that do not expire
+}
+
+// expired reports whether the entry no longer applies
+func (e Entry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Blocklist holds the networks blocked at runtime, on top of the configured lists. With
+// Redis the entries are stored in a hash shared by every replica and reloaded
+// periodically; without it they only apply to this instance until it restarts.
+type Blocklist struct {
+	redis  redis.UniversalClient
+	key    string
+	logger *logrus.Logger
+
+	mu      sync.RWMutex
+	entries map[netip.Prefix]Entry
+}
+
+// NewBlocklist creates a blocklist stored under keyPrefix in Redis; redisClient may be
+// nil to keep the entries in memory
+func NewBlocklist(redisClient redis.UniversalClient, keyPrefix string, logger *logrus.Logger) *Blocklist {
+	return &Blocklist{
+		redis:   redisClient,
+		key:     keyPrefix + "blocklist",
+		logger:  logger,
+		entries: make(map[netip.Prefix]Entry),
+	}
+}
+
+// Blocked returns the entry blocking an address, if any
+func (b *Blocklist) Blocked(addr netip.Addr) (Entry, bool) {
+	now := time.Now()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for network, entry := range b.entries {
+		if network.Contains(addr) && !entry.expired(now) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Entries returns the entries in effect, ordered by network
+func (b *Blocklist) Entries() []Entry {
+	now := time.Now()
+	b.mu.RLock()
+	entries := make([]Entry, 0, len(b.entries))
+	for _, entry := range b.entries {
+		if !entry.expired(now) {
+			entries = append(entries, entry)
+		}
+	}
+	b.mu.RUnlock()
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Network, b.Network)
+	})
+	return entries
+}
+
+// Add blocks the entry's network, replacing an existing entry for the same network
+func (b *Blocklist) Add(ctx context.Context, network netip.Prefix, entry Entry) error {
+	entry.Network = network.String()
+	if b.redis != nil {
+		data, err := codec.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := b.redis.HSet(ctx, b.key, entry.Network, data).Err(); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	b.entries[network] = entry
+	b.mu.Unlock()
+	return nil
+}
+
+// Remove unblocks a network, reporting whether it was blocked
+func (b *Blocklist) Remove(ctx context.Context, network netip.Prefix) (bool, error) {
+	removed := false
+	if b.redis != nil {
+		n, err := b.redis.HDel(ctx, b.key, network.String()).Result()
+		if err != nil {
+			return false, err
+		}
+		removed = n > 0
+	}
+
+	b.mu.Lock()
+	if _, ok := b.entries[network]; ok {
+		delete(b.entries, network)
+		removed = true
+	}
+	b.mu.Unlock()
+	return removed, nil
+}
+
+// Start loads the blocklist from Redis and reloads it every interval until the context
+// is cancelled, dropping expired entries from the shared hash
+func (b *Blocklist) Start(ctx context.Context, interval time.Duration) {
+	if b.redis == nil {
+		return
+	}
+	b.refresh(ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.refresh(ctx)
+			}
+		}
+	}()
+}
+
+// refresh replaces the entries with the ones stored in Redis
+func (b *Blocklist) refresh(ctx context.Context) {
+	stored, err := b.redis.HGetAll(ctx, b.key).Result()
+	if err != nil {
+		b.logger.WithError(err).Warn("Failed to reload the ACL blocklist, keeping the current entries")
+		return
+	}
+
+	now := time.Now()
+	entries := make(map[netip.Prefix]Entry, len(stored))
+	for field, data := range stored {
+		var entry Entry
+		network, err := netip.ParsePrefix(field)
+		if err == nil {
+			err = codec.Unmarshal([]byte(data), &entry)
+		}
+		if err != nil {
+			b.logger.WithError(err).WithField("network", field).Warn("Ignoring malformed ACL blocklist entry")
+			continue
+		}
+		if entry.expired(now) {
+			b.redis.HDel(ctx, b.key, field)
+			continue
+		}
+		entries[network] = entry
+	}
+
+	b.mu.Lock()
+	b.entries = entries
+	b.mu.Unlock()
+}
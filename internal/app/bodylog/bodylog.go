@@ -0,0 +1,91 @@
+// Package bodylog holds the routes whose request and response bodies are logged at
+// runtime, enabled by operators through the admin API to debug partner integrations. The
+// routes expire, so a forgotten debugging session does not keep logging bodies.
+package bodylog
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"apigw/pkg/utils/clock"
+)
+
+// CredentialFields are the body fields whose values are redacted from every body logged,
+// whatever the configuration; a field matches when its name contains an entry, ignoring
+// case and underscores
+var CredentialFields = []string{
+	"password", "passwd", "passphrase", "secret", "token", "authorization",
+	"apikey", "credential", "privatekey", "card", "cvv",
+}
+
+// Route is a route whose bodies are logged
+type Route struct {
+	Route     string // "METHOD /pattern" or "/pattern"
+	Reason    string
+	EnabledBy string
+	EnabledAt time.Time
+	ExpiresAt time.Time
+}
+
+// Switch holds the routes enabled at runtime; it is shared by every router built on
+// reload, so enabled routes outlive configuration changes
+type Switch struct {
+	clock clock.Clock
+
+	mu     sync.RWMutex
+	routes map[string]Route
+}
+
+// NewSwitch creates a switch with no route enabled
+func NewSwitch(clk clock.Clock) *Switch {
+	return &Switch{clock: clk, routes: make(map[string]Route)}
+}
+
+// Enable enables a route until it expires, replacing its previous entry
+func (s *Switch) Enable(route Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[route.Route] = route
+}
+
+// Disable disables a route and reports whether it was enabled
+func (s *Switch) Disable(route string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.routes[route]
+	delete(s.routes, route)
+	return ok && s.clock.Now().Before(entry.ExpiresAt)
+}
+
+// Routes returns the enabled routes, by name; expired ones are forgotten
+func (s *Switch) Routes() []Route {
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	routes := make([]Route, 0, len(s.routes))
+	for name, route := range s.routes {
+		if !now.Before(route.ExpiresAt) {
+			delete(s.routes, name)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Route < routes[j].Route })
+	return routes
+}
+
+// Enabled reports whether the bodies of a request to a route pattern, as registered, are
+// logged
+func (s *Switch) Enabled(method, pattern string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.routes) == 0 {
+		return false
+	}
+	route, ok := s.routes[method+" "+pattern]
+	if !ok {
+		route, ok = s.routes[pattern]
+	}
+	return ok && s.clock.Now().Before(route.ExpiresAt)
+}
@@ -0,0 +1,84 @@
+// Package buildinfo exposes the version, commit and build date of the binary. They are
+// injected at build time:
+//
+//	go build -ldflags "-X apigw/internal/app/buildinfo.Version=v1.4.0 \
+//	  -X apigw/internal/app/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X apigw/internal/app/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/api
+//
+// Without them, the VCS information Go stamps into binaries built from a checkout is used.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set through -ldflags -X at build time
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// unknown is reported for information that was neither injected nor stamped
+const unknown = "unknown"
+
+// Info describes the running binary
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string // RFC 3339 build date, or the commit date when not injected
+	Modified  bool   // Built from a checkout with uncommitted changes
+	GoVersion string
+	Platform  string // GOOS/GOARCH
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = unknown
+	}
+	if info.Date == "" {
+		info.Date = unknown
+	}
+	return info
+}
+
+// ShortCommit returns the first 12 characters of the commit
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
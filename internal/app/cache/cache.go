@@ -0,0 +1,182 @@
+// Package cache implements the tiered response cache used for idempotent GET routes
+package cache
+
+import (
+	"context"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Invalidation tags attached to cached responses and invalidated by the handlers that
+// change the underlying data; route tag templates in the configuration should use them
+const (
+	// TagEvents covers every response listing events
+	TagEvents = "events"
+)
+
+// EventTag covers the responses describing a single event, its availability and seats
+func EventTag(eventID string) string {
+	return "event:" + eventID
+}
+
+// UserTag covers the responses specific to a single user, such as their orders
+func UserTag(userID string) string {
+	return "user:" + userID
+}
+
+// Entry is a cached response
+type Entry struct {
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	ETag        string    `json:"etag"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// Tags index the entry for invalidation
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Store is a single cache tier
+type Store interface {
+	// Get returns the entry stored under key, if it has not expired
+	Get(ctx context.Context, key string) (*Entry, bool)
+	// Set stores the entry until it expires, indexing it under its tags
+	Set(ctx context.Context, key string, entry *Entry)
+	// Invalidate removes every entry indexed under one of the tags
+	Invalidate(ctx context.Context, tags ...string)
+}
+
+var (
+	_ Store       = (*MemoryStore)(nil)
+	_ Store       = (*RedisStore)(nil)
+	_ Invalidator = (*Cache)(nil)
+)
+
+// Invalidator removes cached responses affected by a change
+type Invalidator interface {
+	Invalidate(ctx context.Context, tags ...string)
+}
+
+// NopInvalidator is used by handlers when response caching is disabled
+type NopInvalidator struct{}
+
+// Invalidate implements Invalidator
+func (NopInvalidator) Invalidate(context.Context, ...string) {}
+
+// Tier names reported by Get
+const (
+	TierMemory = "memory"
+	TierRedis  = "redis"
+)
+
+// Cache looks entries up in the in-memory tier first and the shared Redis tier second,
+// copying Redis hits into memory. Invalidations are broadcast to the other gateway
+// replicas through Redis so their in-memory tiers do not keep serving stale entries.
+type Cache struct {
+	memory  *MemoryStore
+	redis   *RedisStore
+	channel string
+	logger  *logrus.Logger
+}
+
+// New creates the cache tiers enabled in the configuration; redisClient may be nil
+// when the Redis tier is disabled
+func New(cfg config.CacheConfig, redisClient redis.UniversalClient, logger *logrus.Logger) *Cache {
+	c := &Cache{
+		channel: cfg.KeyPrefix + "invalidate",
+		logger:  logger,
+	}
+	if cfg.MemoryMaxEntries > 0 {
+		c.memory = NewMemoryStore(cfg.MemoryMaxEntries)
+	}
+	if cfg.Redis && redisClient != nil {
+		c.redis = NewRedisStore(redisClient, cfg.KeyPrefix, logger)
+	}
+	return c
+}
+
+// Get returns the entry stored under key and the tier it was found in
+func (c *Cache) Get(ctx context.Context, key string) (*Entry, string, bool) {
+	if c.memory != nil {
+		if entry, ok := c.memory.Get(ctx, key); ok {
+			return entry, TierMemory, true
+		}
+	}
+	if c.redis != nil {
+		if entry, ok := c.redis.Get(ctx, key); ok {
+			if c.memory != nil {
+				c.memory.Set(ctx, key, entry)
+			}
+			return entry, TierRedis, true
+		}
+	}
+	return nil, "", false
+}
+
+// Set stores the entry in every tier
+func (c *Cache) Set(ctx context.Context, key string, entry *Entry) {
+	if c.memory != nil {
+		c.memory.Set(ctx, key, entry)
+	}
+	if c.redis != nil {
+		c.redis.Set(ctx, key, entry)
+	}
+}
+
+// Invalidate removes the tagged entries from every tier and notifies the other replicas
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+	if c.memory != nil {
+		c.memory.Invalidate(ctx, tags...)
+	}
+	if c.redis == nil {
+		return
+	}
+	c.redis.Invalidate(ctx, tags...)
+
+	payload, _ := codec.Marshal(tags)
+	if err := c.redis.client.Publish(ctx, c.channel, payload).Err(); err != nil {
+		c.logger.WithError(err).WithField("tags", tags).Warn("Failed to broadcast cache invalidation")
+	}
+}
+
+// Start applies invalidations broadcast by other replicas to the in-memory tier
+// until the context is cancelled
+func (c *Cache) Start(ctx context.Context) {
+	if c.memory == nil || c.redis == nil {
+		return
+	}
+
+	pubsub := c.redis.client.Subscribe(ctx, c.channel)
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var tags []string
+				if err := codec.Unmarshal([]byte(msg.Payload), &tags); err != nil {
+					c.logger.WithError(err).Warn("Ignoring malformed cache invalidation message")
+					continue
+				}
+				c.memory.Invalidate(ctx, tags...)
+			}
+		}
+	}()
+}
+
+// fresh reports whether the entry can still be served
+func (e *Entry) fresh(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a size-bounded, least recently used in-process cache tier
+type MemoryStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{}
+}
+
+// memoryItem is an element of the LRU list
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStore creates an in-memory tier holding up to maxEntries responses
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*memoryItem)
+	if !item.entry.fresh(time.Now()) {
+		s.remove(elem)
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return item.entry, true
+}
+
+// Set implements Store, evicting the least recently used entries beyond the size limit
+func (s *MemoryStore) Set(_ context.Context, key string, entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	s.entries[key] = s.lru.PushFront(&memoryItem{key: key, entry: entry})
+	for _, tag := range entry.Tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+
+	for s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+}
+
+// Invalidate implements Store
+func (s *MemoryStore) Invalidate(_ context.Context, tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if elem, ok := s.entries[key]; ok {
+				s.remove(elem)
+			}
+		}
+		delete(s.tags, tag)
+	}
+}
+
+// remove drops an element and its tag index entries; the lock must be held
+func (s *MemoryStore) remove(elem *list.Element) {
+	item := elem.Value.(*memoryItem)
+	s.lru.Remove(elem)
+	delete(s.entries, item.key)
+	for _, tag := range item.entry.Tags {
+		if keys := s.tags[tag]; keys != nil {
+			delete(keys, item.key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// tagSetTTL bounds how long an unused tag index lingers; it is refreshed on every write
+// and only needs to outlive the entries it points to
+const tagSetTTL = 24 * time.Hour
+
+// RedisStore is the cache tier shared by every gateway replica. Entries are stored as
+// JSON documents and each tag is a set of the keys indexed under it.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	logger *logrus.Logger
+}
+
+// NewRedisStore creates a Redis tier storing its keys under prefix
+func NewRedisStore(client redis.UniversalClient, prefix string, logger *logrus.Logger) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+// entryKey returns the Redis key of a cache entry
+func (s *RedisStore) entryKey(key string) string {
+	return s.prefix + "entry:" + key
+}
+
+// tagKey returns the Redis key of a tag's index set
+func (s *RedisStore) tagKey(tag string) string {
+	return s.prefix + "tag:" + tag
+}
+
+// Get implements Store; Redis errors are logged and treated as misses
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, bool) {
+	data, err := s.client.Get(ctx, s.entryKey(key)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			s.logger.WithError(err).WithField("key", key).Warn("Response cache read failed")
+		}
+		return nil, false
+	}
+
+	var entry Entry
+	if err := codec.Unmarshal(data, &entry); err != nil {
+		s.logger.WithError(err).WithField("key", key).Warn("Ignoring malformed response cache entry")
+		return nil, false
+	}
+	if !entry.fresh(time.Now()) {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Set implements Store
+func (s *RedisStore) Set(ctx context.Context, key string, entry *Entry) {
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+	data, err := codec.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	pipe := s.client.Pipeline()
+	pipe.Set(ctx, s.entryKey(key), data, ttl)
+	for _, tag := range entry.Tags {
+		pipe.SAdd(ctx, s.tagKey(tag), key)
+		pipe.Expire(ctx, s.tagKey(tag), tagSetTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.WithError(err).WithField("key", key).Warn("Response cache write failed")
+	}
+}
+
+// Invalidate implements Store. Keys are deleted one by one so they may live in
+// different Redis Cluster slots.
+func (s *RedisStore) Invalidate(ctx context.Context, tags ...string) {
+	for _, tag := range tags {
+		keys, err := s.client.SMembers(ctx, s.tagKey(tag)).Result()
+		if err != nil {
+			s.logger.WithError(err).WithField("tag", tag).Warn("Response cache invalidation failed")
+			continue
+		}
+
+		pipe := s.client.Pipeline()
+		for _, key := range keys {
+			pipe.Del(ctx, s.entryKey(key))
+		}
+		pipe.Del(ctx, s.tagKey(tag))
+		if _, err := pipe.Exec(ctx); err != nil {
+			s.logger.WithError(err).WithField("tag", tag).Warn("Response cache invalidation failed")
+		}
+	}
+}
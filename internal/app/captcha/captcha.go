@@ -0,0 +1,117 @@
+// Package captcha verifies the challenge tokens of the supported CAPTCHA providers
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"apigw/internal/app/config"
+	"apigw/pkg/utils/codec"
+)
+
+// Supported providers
+const (
+	ProviderReCAPTCHA = "recaptcha"
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderTurnstile = "turnstile"
+)
+
+// verifyURLs are the siteverify endpoints of the providers
+var verifyURLs = map[string]string{
+	ProviderReCAPTCHA: "https://www.google.com/recaptcha/api/siteverify",
+	ProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	ProviderTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Verifier checks the token a client obtained by solving a challenge
+type Verifier interface {
+	// Verify reports whether a token is valid; an error means the provider could not be
+	// asked, not that the token is invalid
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// New creates the verifier of the configured provider
+func New(cfg config.CaptchaConfig) (Verifier, error) {
+	verifyURL := cfg.VerifyURL
+	if verifyURL == "" {
+		var ok bool
+		if verifyURL, ok = verifyURLs[cfg.Provider]; !ok {
+			return nil, fmt.Errorf("unknown CAPTCHA provider %q", cfg.Provider)
+		}
+	}
+	return &siteVerifier{
+		url:      verifyURL,
+		secret:   cfg.SecretKey,
+		minScore: cfg.MinScore,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// siteVerifier implements the siteverify protocol shared by reCAPTCHA, hCaptcha and
+// Turnstile: the token is posted as a form with the secret key and the provider answers
+// with a JSON document
+type siteVerifier struct {
+	url      string
+	secret   string
+	minScore float64
+	client   *http.Client
+}
+
+// siteVerifyResponse is the answer of a siteverify endpoint
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"` // reCAPTCHA v3 and hCaptcha Enterprise only
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify implements Verifier
+func (v *siteVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("CAPTCHA verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("CAPTCHA verification returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return false, fmt.Errorf("failed to read CAPTCHA verification response: %w", err)
+	}
+
+	var result siteVerifyResponse
+	if err := codec.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("malformed CAPTCHA verification response: %w", err)
+	}
+	if !result.Success {
+		for _, code := range result.ErrorCodes {
+			// A rejected secret is our misconfiguration, not the client's failure
+			if strings.Contains(code, "secret") {
+				return false, fmt.Errorf("CAPTCHA verification rejected the secret key: %s", code)
+			}
+		}
+		return false, nil
+	}
+	// Score-based challenges pass silently and are judged by their score
+	if result.Score != nil && *result.Score < v.minScore {
+		return false, nil
+	}
+	return true, nil
+}
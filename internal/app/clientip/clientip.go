@@ -0,0 +1,157 @@
+// Package clientip resolves the address of the client behind the load balancers and
+// proxies the gateway trusts
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"apigw/internal/app/config"
+)
+
+// forwardedHeader is the standard forwarding header (RFC 7239), parsed for its for= parameters
+const forwardedHeader = "Forwarded"
+
+// Resolver extracts the client address of a request. Forwarding headers are only read
+// when the connection comes from a trusted proxy, and their hops are walked from the
+// nearest one back: the first address that is not a trusted proxy is the client, so
+// entries a client prepends to X-Forwarded-For itself are never used.
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// NewResolver creates a resolver for the trusted proxies and client IP headers of the
+// HTTP server configuration
+func NewResolver(cfg config.HTTPConfig) (*Resolver, error) {
+	r := &Resolver{}
+	for _, network := range cfg.TrustedProxies {
+		prefix, err := config.ParseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+	for _, header := range cfg.ClientIPHeaders {
+		r.headers = append(r.headers, http.CanonicalHeaderKey(header))
+	}
+	return r, nil
+}
+
+// Resolve returns the client address of a request. Connections without an IP peer, i.e.
+// unix sockets, which only local proxies can reach, are treated as trusted; the zero
+// Addr is returned when no address can be determined.
+func (r *Resolver) Resolve(req *http.Request) netip.Addr {
+	peer, hasPeer := peerAddr(req.RemoteAddr)
+	if hasPeer && !r.isTrusted(peer) {
+		return peer
+	}
+
+	for _, header := range r.headers {
+		values := req.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+		var hops []string
+		switch header {
+		case forwardedHeader:
+			hops = forwardedFor(values)
+		case "X-Forwarded-For":
+			hops = splitHops(values)
+		default:
+			// Single value headers set by the proxy itself, such as X-Real-IP
+			hops = []string{strings.TrimSpace(values[len(values)-1])}
+		}
+		if addr, ok := r.walk(hops); ok {
+			return addr
+		}
+	}
+	return peer
+}
+
+// walk returns the nearest hop that is not a trusted proxy. A malformed hop ends the
+// walk, since nothing before it can be attributed reliably; the last valid hop is
+// returned then, as it is when every hop is a trusted proxy.
+func (r *Resolver) walk(hops []string) (netip.Addr, bool) {
+	var last netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		if !r.isTrusted(addr) {
+			return addr, true
+		}
+		last = addr
+	}
+	return last, last.IsValid()
+}
+
+// isTrusted reports whether an address belongs to a trusted proxy
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr returns the IP address of the connection's peer
+func peerAddr(remoteAddr string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// parseHop parses one hop of a forwarding header; ports and the brackets around IPv6
+// addresses are accepted
+func parseHop(hop string) (netip.Addr, bool) {
+	hop = strings.TrimSpace(hop)
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// splitHops returns the comma-separated hops of every line of a header, in order
+func splitHops(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	return hops
+}
+
+// forwardedFor returns the for= parameter of every element of Forwarded headers, in
+// order; elements without one are kept as malformed hops
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitHops(values) {
+		hop := ""
+		for _, pair := range strings.Split(element, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(name, "for") {
+				hop = strings.Trim(value, `"`)
+				break
+			}
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
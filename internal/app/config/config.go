@@ -1,20 +1,870 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Services ServicesConfig `mapstructure:"services"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Redis    RedisConfig    `mapstructure:"redis"`
+	App        AppConfig        `mapstructure:"app"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Services   ServicesConfig   `mapstructure:"services"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	Cache      CacheConfig      `mapstructure:"cache"`
+	Coalescing CoalescingConfig `mapstructure:"coalescing"`
+	XML        XMLConfig        `mapstructure:"xml"`
+	Errors     ErrorsConfig     `mapstructure:"errors"`
+	Validation ValidationConfig `mapstructure:"validation"`
+	Orders     OrdersConfig     `mapstructure:"orders"`
+	Dashboard  DashboardConfig  `mapstructure:"dashboard"`
+	Avatar     AvatarConfig     `mapstructure:"avatar"`
+	ACL        ACLConfig        `mapstructure:"acl"`
+	BruteForce BruteForceConfig `mapstructure:"brute_force"`
+	Captcha    CaptchaConfig    `mapstructure:"captcha"`
+	Signing    SigningConfig    `mapstructure:"signing"`
+	// Fingerprint identifies client software by its TLS handshake and headers
+	Fingerprint FingerprintConfig `mapstructure:"fingerprint"`
+	Tenants     TenantsConfig     `mapstructure:"tenants"`
+	Regions     RegionsConfig     `mapstructure:"regions"`
+	// WaitingRoom holds the waiting room settings; rooms are opened per event through the admin API
+	WaitingRoom  WaitingRoomConfig  `mapstructure:"waiting_room"`
+	OnSale       OnSaleConfig       `mapstructure:"on_sale"`
+	LoadShedding LoadSheddingConfig `mapstructure:"load_shedding"`
+	Quotas       QuotasConfig       `mapstructure:"quotas"`
+	Webhooks     WebhooksConfig     `mapstructure:"webhooks"`
+	Kafka        KafkaConfig        `mapstructure:"kafka"`
+	Startup      StartupConfig      `mapstructure:"startup"`
+	Process      ProcessConfig      `mapstructure:"process"`
+	API          APIConfig          `mapstructure:"api"`
+	Docs         DocsConfig         `mapstructure:"docs"`
+	Faults       FaultsConfig       `mapstructure:"faults"`
+	DevStub      DevStubConfig      `mapstructure:"dev_stub"`
+	Recording    RecordingConfig    `mapstructure:"recording"`
+	Transforms   []TransformRule    `mapstructure:"transforms"`
+	Log          LogConfig          `mapstructure:"log"`
+	SlowRequests SlowRequestsConfig `mapstructure:"slow_requests"`
+	Remote       RemoteConfig       `mapstructure:"remote"`
+	Discovery    DiscoveryConfig    `mapstructure:"discovery"`
+	// Observability holds the integrations with observability services
+	Observability ObservabilityConfig `mapstructure:"observability"`
+
+	// Files lists the configuration files that were loaded, base file first
+	Files []string `mapstructure:"-"`
+	// unknownKeys lists keys from the files that match no setting, reported by Validate
+	unknownKeys []string
+}
+
+// CacheConfig represents the response cache for idempotent GET routes
+type CacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MemoryMaxEntries bounds the in-process tier; 0 disables it
+	MemoryMaxEntries int `mapstructure:"memory_max_entries"`
+	// Redis enables the tier shared by every replica (requires redis.enabled)
+	Redis     bool               `mapstructure:"redis"`
+	KeyPrefix string             `mapstructure:"key_prefix"`
+	Routes    []CacheRouteConfig `mapstructure:"routes"`
+}
+
+// CacheRouteConfig represents the caching of a single GET route. Key and Tags are
+// templates expanding {path}, {query}, {query.<name>}, {param.<name>}, {user} and
+// {tenant}.
+type CacheRouteConfig struct {
+	Path string        `mapstructure:"path"` // Route pattern as registered, e.g. /api/v1/events/:event_id
+	TTL  time.Duration `mapstructure:"ttl"`
+	// Key defaults to "{path}?{query}"; {user} is always added on authenticated routes, and
+	// the tenant to the requests of tenants
+	Key  string   `mapstructure:"key"`
+	Tags []string `mapstructure:"tags"` // Invalidation tags, e.g. event:{param.event_id}
+}
+
+// Route returns the cache settings of a route pattern
+func (c CacheConfig) Route(path string) (CacheRouteConfig, bool) {
+	for _, route := range c.Routes {
+		if route.Path == path {
+			return route, true
+		}
+	}
+	return CacheRouteConfig{}, false
+}
+
+// CoalescingConfig represents the collapsing of identical concurrent GET requests into one upstream call
+type CoalescingConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Routes  []string `mapstructure:"routes"` // Route patterns as registered, e.g. /api/v1/events/:event_id
+}
+
+// XMLConfig represents XML request and response bodies on selected routes, for legacy
+// partner integrations that cannot exchange JSON
+type XMLConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Routes  []string `mapstructure:"routes"` // Route patterns as registered, e.g. /api/v1/orders/purchase
+	// RootElement names the root element of XML responses
+	RootElement string `mapstructure:"root_element"`
+}
+
+// Error response formats
+const (
+	ErrorFormatJSON    = "json"    // The {error, code, message} body
+	ErrorFormatProblem = "problem" // RFC 7807 application/problem+json
+)
+
+// ErrorsConfig represents the format of error responses. Clients listing
+// application/problem+json in their Accept header get problem details whatever the format.
+type ErrorsConfig struct {
+	Format string `mapstructure:"format"` // json or problem
+	// ProblemTypeBase prefixes the error code in the type URI of problem details; empty
+	// leaves the type as about:blank
+	ProblemTypeBase string `mapstructure:"problem_type_base"`
+}
+
+// Event ID formats accepted by the event_id validation rule
+const (
+	EventIDULID = "ulid" // 26 character Crockford base32 ULID
+	EventIDUUID = "uuid" // Hyphenated UUID
+	EventIDSlug = "slug" // Letters, digits, hyphens and underscores
+)
+
+// ValidationConfig represents the domain rules of request validation
+type ValidationConfig struct {
+	// EventIDFormats lists the formats event IDs may take
+	EventIDFormats []string       `mapstructure:"event_id_formats"`
+	Password       PasswordPolicy `mapstructure:"password"`
+}
+
+// PasswordPolicy represents the strength required of new passwords; existing passwords
+// are not checked at login
+type PasswordPolicy struct {
+	MinLength     int  `mapstructure:"min_length"`
+	MaxLength     int  `mapstructure:"max_length"`
+	RequireUpper  bool `mapstructure:"require_upper"`
+	RequireLower  bool `mapstructure:"require_lower"`
+	RequireDigit  bool `mapstructure:"require_digit"`
+	RequireSymbol bool `mapstructure:"require_symbol"`
+}
+
+// ACLConfig represents the network access control lists evaluated before authentication.
+// Networks are CIDR ranges or single addresses.
+type ACLConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Deny    []string `mapstructure:"deny"` // Denied on every route
+	// Rules restrict route groups by path prefix; the longest matching prefix applies
+	Rules []ACLRuleConfig `mapstructure:"rules"`
+	// KeyPrefix namespaces the blocklist managed through the admin API in Redis
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// RefreshInterval is how often the blocklist is reloaded from Redis, picking up
+	// entries added on other replicas
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// BruteForceConfig represents the failure counters guarding login and registration
+// against credential stuffing and account enumeration (requires redis.enabled)
+type BruteForceConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// Window is how long failures are remembered after the last one
+	Window time.Duration `mapstructure:"window"`
+	// Lockout is the first lockout; every further lockout within strike_ttl doubles it
+	Lockout    time.Duration `mapstructure:"lockout"`
+	MaxLockout time.Duration `mapstructure:"max_lockout"`
+	// StrikeTTL is how long past lockouts count towards the next one
+	StrikeTTL time.Duration    `mapstructure:"strike_ttl"`
+	Email     BruteForceLimits `mapstructure:"email"`
+	IP        BruteForceLimits `mapstructure:"ip"`
+}
+
+// BruteForceLimits represents the failure thresholds of one kind of subject
+type BruteForceLimits struct {
+	CaptchaAfter int `mapstructure:"captcha_after"` // Failures after which a CAPTCHA is requested, 0 never does
+	LockoutAfter int `mapstructure:"lockout_after"` // Failures that lock the subject out
+}
+
+// CAPTCHA requirement modes of an action
+const (
+	CaptchaAlways  = "always"   // Every request must carry a valid token
+	CaptchaOnAbuse = "on_abuse" // Only requests flagged by the abuse heuristics
+	CaptchaOff     = "off"
+)
+
+// CaptchaConfig represents the CAPTCHA challenges of abuse-prone endpoints
+type CaptchaConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Provider  string `mapstructure:"provider"` // recaptcha, hcaptcha or turnstile
+	SiteKey   string `mapstructure:"site_key"` // Public key, returned to clients asked for a CAPTCHA
+	SecretKey string `mapstructure:"secret_key" secret:"true"`
+	// VerifyURL overrides the provider's verification endpoint
+	VerifyURL string        `mapstructure:"verify_url"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+	// MinScore is the lowest passing score of score-based challenges (reCAPTCHA v3)
+	MinScore float64 `mapstructure:"min_score"`
+	// FailOpen lets requests through while the provider cannot be reached
+	FailOpen bool   `mapstructure:"fail_open"`
+	Header   string `mapstructure:"header"` // Request header carrying the token
+	// Actions maps login, register, password_reset and purchase to always, on_abuse or off
+	Actions map[string]string  `mapstructure:"actions"`
+	Abuse   CaptchaAbuseConfig `mapstructure:"abuse"`
+	// KeyPrefix namespaces the request counters of the abuse heuristic in Redis
+	KeyPrefix string `mapstructure:"key_prefix"`
+}
+
+// CaptchaAbuseConfig represents the request rate heuristic flagging clients for a CAPTCHA
+// on on_abuse actions, on top of the brute-force protection thresholds
+type CaptchaAbuseConfig struct {
+	Requests int           `mapstructure:"requests"` // Requests of a client per action within window, 0 disables the heuristic
+	Window   time.Duration `mapstructure:"window"`
+}
+
+// CaptchaMode returns the CAPTCHA requirement of an action
+func (c CaptchaConfig) CaptchaMode(action string) string {
+	if !c.Enabled {
+		return CaptchaOff
+	}
+	if mode, ok := c.Actions[action]; ok {
+		return mode
+	}
+	return CaptchaOff
+}
+
+// SigningConfig represents the HMAC request signatures authenticating partner integrations
+type SigningConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PathPrefixes lists the route groups only signed requests may use
+	PathPrefixes []string      `mapstructure:"path_prefixes"`
+	MaxSkew      time.Duration `mapstructure:"max_skew"` // Accepted clock difference, also the replay window
+	// KeyPrefix namespaces the partner keys and seen signatures in Redis
+	KeyPrefix   string        `mapstructure:"key_prefix"`
+	KeyCacheTTL time.Duration `mapstructure:"key_cache_ttl"` // How long keys read from Redis are cached
+	// Partners lists signing keys kept in the configuration, on top of those in Redis
+	Partners []PartnerKeyConfig `mapstructure:"partners"`
+}
+
+// Required reports whether a path belongs to a signed route group
+func (s SigningConfig) Required(path string) bool {
+	for _, prefix := range s.PathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// PartnerKeyConfig represents the signing keys of a partner; several keys allow rotation
+type PartnerKeyConfig struct {
+	ID       string         `mapstructure:"id"`
+	Secrets  []string       `mapstructure:"secrets" secret:"true"`
+	Branding BrandingConfig `mapstructure:"branding"` // Applies to the signed requests of the partner
+}
+
+// FingerprintConfig represents the fingerprinting of the clients of requests, logged with
+// every request and usable to rate limit clients sharing a fingerprint across addresses
+type FingerprintConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TLSHeader carries the JA3 hash computed by the proxy terminating TLS in front of the
+	// gateway, e.g. X-JA3-Hash; handshakes the gateway terminates itself are fingerprinted
+	// without it. Only set it when the proxy overwrites the header.
+	TLSHeader string `mapstructure:"tls_header"`
+	// RateLimit limits the requests of each fingerprint to the routes below, whatever
+	// their address; empty disables it
+	RateLimit TokenBucketConfig `mapstructure:"rate_limit"`
+	// Routes are the route patterns the rate limit applies to, optionally preceded by a
+	// method, e.g. "POST /api/v1/users/login"
+	Routes []string `mapstructure:"routes"`
+}
+
+// TenantsConfig represents the tenants served by the gateway. A request's tenant is
+// resolved from its host, a subdomain of one of BaseDomains or a host of the tenant, then
+// from Header, falling back to Default.
+type TenantsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Header  string `mapstructure:"header"` // Empty ignores the header
+	// BaseDomains resolve <tenant>.<base domain> hosts to the tenant
+	BaseDomains []string `mapstructure:"base_domains"`
+	// Default is the tenant of requests naming none; empty leaves them without a tenant
+	Default string         `mapstructure:"default"`
+	List    []TenantConfig `mapstructure:"list"`
+}
+
+// Active returns the tenants in effect, none when tenancy is disabled
+func (t TenantsConfig) Active() []TenantConfig {
+	if !t.Enabled {
+		return nil
+	}
+	return t.List
+}
+
+// TenantConfig represents a tenant and the settings overriding the gateway's own for its
+// requests
+type TenantConfig struct {
+	ID    string   `mapstructure:"id"`    // Lowercase DNS label, e.g. acme
+	Hosts []string `mapstructure:"hosts"` // Custom domains of the tenant, e.g. tickets.acme.com
+	// RateLimit replaces redis.token_bucket for the clients of the tenant, in buckets of
+	// their own; a zero capacity keeps the global limit
+	RateLimit TokenBucketConfig `mapstructure:"rate_limit"`
+	// Services points backend services at clusters of the tenant, keyed by their name
+	// under services, e.g. order_service
+	Services map[string]ServiceOverrideConfig `mapstructure:"services"`
+	Branding BrandingConfig                   `mapstructure:"branding"`
+}
+
+// BrandingConfig represents the response headers and error bodies of a white-label
+// integration
+type BrandingConfig struct {
+	Headers map[string]string `mapstructure:"headers"` // Set on every response
+	// ErrorTemplate renders the body of error responses as a Go text/template given the
+	// error's .Status, .Code, .Type, .Message, .RequestID, .Tenant and .Partner; json
+	// quotes a value, e.g. {{json .Message}}. Empty keeps the gateway's error bodies.
+	ErrorTemplate    string `mapstructure:"error_template"`
+	ErrorContentType string `mapstructure:"error_content_type"` // Defaults to application/json
+}
+
+// brandingFuncs are the functions of error templates
+var brandingFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseErrorTemplate parses the error template, nil when there is none
+func (b BrandingConfig) ParseErrorTemplate() (*template.Template, error) {
+	if b.ErrorTemplate == "" {
+		return nil, nil
+	}
+	return template.New("error").Funcs(brandingFuncs).Option("missingkey=error").Parse(b.ErrorTemplate)
+}
+
+// ServiceOverrideConfig represents the upstream of a backend service for a tenant or a
+// region; the other settings of the service are shared
+type ServiceOverrideConfig struct {
+	Host          string           `mapstructure:"host"`
+	Port          int              `mapstructure:"port"`
+	Target        string           `mapstructure:"target"`
+	Endpoints     []EndpointConfig `mapstructure:"endpoints"`
+	LoadBalancing string           `mapstructure:"load_balancing"` // Defaults to the service's
+	// TLS replaces the TLS settings of the service when enabled
+	TLS ServiceTLSConfig `mapstructure:"tls"`
+}
+
+// apply returns base with the upstream of the override, named <name>@<owner>. Calls to
+// the upstream of an override are not shadowed.
+func (o ServiceOverrideConfig) apply(base ServiceConfig, owner string) ServiceConfig {
+	svc := base
+	svc.Name = base.Name + "@" + owner
+	svc.Host = o.Host
+	svc.Port = o.Port
+	svc.Target = o.Target
+	svc.Endpoints = o.Endpoints
+	if o.LoadBalancing != "" {
+		svc.LoadBalancing = o.LoadBalancing
+	}
+	if o.TLS.Enabled {
+		svc.TLS = o.TLS
+	}
+	svc.Shadow = ShadowConfig{}
+	return svc
+}
+
+// Service returns the configuration of a backend service for the tenant: base with the
+// upstream of the tenant, if it overrides it
+func (t TenantConfig) Service(name string, base ServiceConfig) (ServiceConfig, bool) {
+	override, ok := t.Services[name]
+	if !ok {
+		return base, false
+	}
+	return override.apply(base, t.ID), true
+}
+
+// RegionsConfig represents the routing of requests to the backend clusters of a region.
+// The region of a request is the one its header names, then the home region of its user,
+// then the region of its country, then the default.
+type RegionsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Header  string `mapstructure:"header"` // Empty ignores the header
+	// CountryHeader carries the ISO 3166 country of the client as geolocated by the CDN or
+	// load balancer, e.g. CF-IPCountry; empty ignores the country
+	CountryHeader string `mapstructure:"country_header"`
+	// Default is the region of requests resolving to none; empty sends them to the
+	// upstreams under services
+	Default string         `mapstructure:"default"`
+	List    []RegionConfig `mapstructure:"list"`
+}
+
+// Active returns the regions in effect, none when regional routing is disabled
+func (r RegionsConfig) Active() []RegionConfig {
+	if !r.Enabled {
+		return nil
+	}
+	return r.List
+}
+
+// RegionConfig represents a region and the clusters serving its requests
+type RegionConfig struct {
+	ID        string   `mapstructure:"id"`        // Lowercase DNS label, e.g. eu
+	Countries []string `mapstructure:"countries"` // ISO 3166 alpha-2 codes of the region, e.g. DE
+	// Failover is the region whose clusters take over while those of the region are
+	// unreachable; empty fails over to the upstreams under services
+	Failover string `mapstructure:"failover"`
+	// Services points backend services at clusters of the region, keyed by their name
+	// under services, e.g. order_service
+	Services map[string]ServiceOverrideConfig `mapstructure:"services"`
+}
+
+// Service returns the configuration of a backend service for the region: base with the
+// upstream of the region, if it overrides it
+func (r RegionConfig) Service(name string, base ServiceConfig) (ServiceConfig, bool) {
+	override, ok := r.Services[name]
+	if !ok {
+		return base, false
+	}
+	return override.apply(base, r.ID), true
+}
+
+// ACLRuleConfig represents the access control list of a route group
+type ACLRuleConfig struct {
+	PathPrefix string   `mapstructure:"path_prefix"`
+	Allow      []string `mapstructure:"allow"` // When set, only these networks are allowed
+	Deny       []string `mapstructure:"deny"`
+}
+
+// ParseNetwork parses a CIDR range or a single IP address, which is treated as a
+// range of one address
+func ParseNetwork(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// WaitingRoomConfig represents the virtual waiting rooms of high-demand on-sales (requires redis.enabled)
+type WaitingRoomConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	KeyPrefix   string `mapstructure:"key_prefix"`
+	DefaultRate int    `mapstructure:"default_rate"` // Passes per second of rooms opened without a rate
+	// PassTTL is how long an admitted user may purchase before having to queue again
+	PassTTL time.Duration `mapstructure:"pass_ttl"`
+	// PassUses is how many purchases a pass lets through; failed purchases give theirs back
+	PassUses int           `mapstructure:"pass_uses"`
+	TokenTTL time.Duration `mapstructure:"token_ttl"` // How long queue state is kept
+	// PollInterval is how often clients are told to poll their position
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+}
+
+// OnSaleConfig represents the stricter profiles switched to during known on-sale windows.
+// A profile is in effect from each time its schedule fires for its duration; when windows
+// overlap, the profile listed first wins.
+type OnSaleConfig struct {
+	Enabled  bool                  `mapstructure:"enabled"`
+	Timezone string                `mapstructure:"timezone"` // IANA zone the schedules are read in, e.g. Europe/Berlin
+	Profiles []OnSaleProfileConfig `mapstructure:"profiles"`
+}
+
+// OnSaleProfileConfig represents the settings in effect during an on-sale window; the
+// settings a profile leaves empty keep their configured values
+type OnSaleProfileConfig struct {
+	Name string `mapstructure:"name"`
+	// Schedule is a cron expression (minute hour day-of-month month day-of-week) of the
+	// times the window opens, e.g. "0 10 * * FRI"
+	Schedule string        `mapstructure:"schedule"`
+	Duration time.Duration `mapstructure:"duration"` // How long the window stays open
+	// RateLimit replaces redis.token_bucket, and Policies the limiter policies of the same
+	// names under redis.policies
+	RateLimit   TokenBucketConfig            `mapstructure:"rate_limit"`
+	Policies    map[string]TokenBucketConfig `mapstructure:"policies"`
+	WaitingRoom OnSaleWaitingRoomConfig      `mapstructure:"waiting_room"`
+}
+
+// OnSaleWaitingRoomConfig represents the waiting rooms of an on-sale window: those of the
+// events are opened when the window opens and closed when it closes
+type OnSaleWaitingRoomConfig struct {
+	Events  []string      `mapstructure:"events"`
+	Rate    int           `mapstructure:"rate"`     // Passes per second; replaces waiting_room.default_rate
+	PassTTL time.Duration `mapstructure:"pass_ttl"` // Replaces waiting_room.pass_ttl
+}
+
+// WithOnSaleProfile returns a copy of the configuration with the settings of an on-sale
+// profile in effect
+func (c *Config) WithOnSaleProfile(p OnSaleProfileConfig) *Config {
+	cfg := *c
+	if p.RateLimit != (TokenBucketConfig{}) {
+		cfg.Redis.TokenBucket = p.RateLimit
+	}
+	if len(p.Policies) > 0 {
+		cfg.Redis.Policies = make(map[string]TokenBucketConfig, len(c.Redis.Policies))
+		for name, policy := range c.Redis.Policies {
+			cfg.Redis.Policies[name] = policy
+		}
+		for name, policy := range p.Policies {
+			cfg.Redis.Policies[name] = policy
+		}
+	}
+	if p.WaitingRoom.Rate > 0 {
+		cfg.WaitingRoom.DefaultRate = p.WaitingRoom.Rate
+	}
+	if p.WaitingRoom.PassTTL > 0 {
+		cfg.WaitingRoom.PassTTL = p.WaitingRoom.PassTTL
+	}
+	return &cfg
+}
+
+// LoadSheddingConfig represents the shedding of requests while the instance is overloaded.
+// Routes are sorted into priority classes, each admitted while the requests in flight stay
+// under its share of max_in_flight, so browsing is turned away before checkout is.
+type LoadSheddingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxInFlight is the number of requests in flight the instance serves at full load
+	MaxInFlight  int                   `mapstructure:"max_in_flight"`
+	DefaultClass string                `mapstructure:"default_class"` // Class of the routes no class lists
+	Classes      []PriorityClassConfig `mapstructure:"classes"`
+	RetryAfter   time.Duration         `mapstructure:"retry_after"` // Sent to the clients of shed requests
+}
+
+// PriorityClassConfig represents a priority class of requests
+type PriorityClassConfig struct {
+	Name string `mapstructure:"name"`
+	// Limit is the percentage of max_in_flight up to which requests of the class are
+	// admitted; the classes shed first have the lowest limits
+	Limit int `mapstructure:"limit"`
+	// Routes are route patterns as registered, optionally preceded by a method, e.g.
+	// "POST /api/v1/orders/purchase"; a pattern with a method wins over one without
+	Routes []string `mapstructure:"routes"`
+}
+
+// UsagePath is the route clients read their quota consumption from
+const UsagePath = "/api/v1/usage"
+
+// QuotasConfig represents the daily and monthly caps on the API calls of each client
+// (requires redis.enabled). Clients are partners signing their requests, then signed-in
+// users, then addresses; windows roll over at midnight UTC and on the first of the month.
+type QuotasConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// Default caps partners and users; a cap of 0 leaves its window uncapped
+	Default   QuotaLimitsConfig `mapstructure:"default"`
+	Anonymous QuotaLimitsConfig `mapstructure:"anonymous"` // Caps clients known by their address only
+	// Clients caps single clients, named partner:<id>, user:<id> or ip:<address>
+	Clients []ClientQuotaConfig `mapstructure:"clients"`
+}
+
+// QuotaLimitsConfig represents the caps of a client
+type QuotaLimitsConfig struct {
+	Daily   int64 `mapstructure:"daily"`
+	Monthly int64 `mapstructure:"monthly"`
+}
+
+// ClientQuotaConfig represents the caps of a single client
+type ClientQuotaConfig struct {
+	Client  string `mapstructure:"client"`
+	Daily   int64  `mapstructure:"daily"`
+	Monthly int64  `mapstructure:"monthly"`
+}
+
+// Limits returns the caps of a client
+func (q QuotasConfig) Limits(client string) QuotaLimitsConfig {
+	for _, c := range q.Clients {
+		if c.Client == client {
+			return QuotaLimitsConfig{Daily: c.Daily, Monthly: c.Monthly}
+		}
+	}
+	if strings.HasPrefix(client, "ip:") {
+		return q.Anonymous
+	}
+	return q.Default
+}
+
+// WebhooksPath is the route group partners manage their webhooks through
+const WebhooksPath = "/api/v1/partner/webhooks"
+
+// WebhooksConfig represents the delivery of order events to the callback URLs partners
+// register (requires redis.enabled and signing of the partner routes)
+type WebhooksConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// MaxEndpoints bounds the callback URLs each partner may register
+	MaxEndpoints int           `mapstructure:"max_endpoints"`
+	Workers      int           `mapstructure:"workers"` // Deliveries sent at once by each replica
+	Timeout      time.Duration `mapstructure:"timeout"` // Deadline of each delivery attempt
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	// InitialBackoff is the delay before the first retry; it doubles with every attempt up
+	// to MaxBackoff
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"` // How often due deliveries are looked for
+	DeliveryTTL    time.Duration `mapstructure:"delivery_ttl"`  // How long delivery statuses are kept
+	// AllowHTTP accepts plain http callback URLs; by default only https is accepted
+	AllowHTTP bool `mapstructure:"allow_http"`
+	// AllowPrivateNetworks lets deliveries reach loopback, private and link-local addresses
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// Kafka start offsets of a new consumer group
+const (
+	KafkaOffsetLatest   = "latest"
+	KafkaOffsetEarliest = "earliest"
+)
+
+// Kafka SASL mechanisms
+const (
+	KafkaSASLPlain       = "plain"
+	KafkaSASLScramSHA256 = "scram-sha-256"
+	KafkaSASLScramSHA512 = "scram-sha-512"
+)
+
+// KafkaConfig represents the consumption of the change events backend services publish,
+// which invalidate cached responses and waiting rooms
+type KafkaConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Brokers []string `mapstructure:"brokers"` // Seed brokers (host:port)
+	// GroupID is the consumer group shared by the replicas
+	GroupID string `mapstructure:"group_id"`
+	// InventoryTopics carry seat inventory changes of events
+	InventoryTopics []string `mapstructure:"inventory_topics"`
+	// EventTopics carry changes to events themselves: updates, closing, cancellation
+	EventTopics []string `mapstructure:"event_topics"`
+	// StartOffset is where a new consumer group starts reading: latest or earliest
+	StartOffset    string          `mapstructure:"start_offset"`
+	MaxWait        time.Duration   `mapstructure:"max_wait"`        // Longest wait for new messages in a fetch
+	CommitInterval time.Duration   `mapstructure:"commit_interval"` // 0 commits every message as it is handled
+	DialTimeout    time.Duration   `mapstructure:"dial_timeout"`
+	TLS            KafkaTLSConfig  `mapstructure:"tls"`
+	SASL           KafkaSASLConfig `mapstructure:"sasl"`
+}
+
+// Topics returns every topic consumed
+func (k KafkaConfig) Topics() []string {
+	return append(append([]string{}, k.InventoryTopics...), k.EventTopics...)
+}
+
+// KafkaTLSConfig represents TLS settings for Kafka connections
+type KafkaTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`   // CA bundle used to verify the brokers; system roots when empty
+	CertFile           string `mapstructure:"cert_file"` // Client certificate for mutual TLS
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// KafkaSASLConfig represents SASL authentication with the brokers
+type KafkaSASLConfig struct {
+	Mechanism string `mapstructure:"mechanism"` // Empty disables SASL; plain, scram-sha-256 or scram-sha-512
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password" secret:"true"`
+}
+
+// AvatarPath is the avatar upload route; its request body limit must fit the largest image
+const AvatarPath = "/api/v1/users/me/avatar"
+
+// AvatarConfig represents the profile picture uploads of PUT /users/me/avatar. Images are
+// streamed to the user service as they are received, never held whole in memory.
+type AvatarConfig struct {
+	MaxBytes int64 `mapstructure:"max_bytes"` // Largest image accepted
+	// ContentTypes lists the image types accepted, as detected from the image content
+	ContentTypes []string `mapstructure:"content_types"`
+	ChunkSize    int      `mapstructure:"chunk_size"` // Bytes sent to the user service per message
+}
+
+// OrdersConfig represents the settings of the order endpoints
+type OrdersConfig struct {
+	BatchPurchase BatchPurchaseConfig `mapstructure:"batch_purchase"`
+	AsyncPurchase AsyncPurchaseConfig `mapstructure:"async_purchase"`
+}
+
+// Async purchase modes
+const (
+	AsyncPurchasePrefer = "prefer" // Only requests sending Prefer: respond-async are queued
+	AsyncPurchaseAlways = "always" // Every purchase is queued
+)
+
+// AsyncPurchaseConfig represents the asynchronous purchase flow: purchases are answered
+// with 202 and a reference, queued in a Redis stream and forwarded to the order service
+// by workers running in every replica
+type AsyncPurchaseConfig struct {
+	Enabled   bool   `mapstructure:"enabled"` // Requires redis.enabled
+	Mode      string `mapstructure:"mode"`    // prefer or always
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// MaxPending is the queue length above which purchases are rejected with 503
+	MaxPending int64 `mapstructure:"max_pending"`
+	Workers    int   `mapstructure:"workers"` // Purchases forwarded at once by each replica
+	// Attempts bounds the calls made for a purchase while the order service is unavailable
+	Attempts     int           `mapstructure:"attempts"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	CallTimeout  time.Duration `mapstructure:"call_timeout"` // Deadline of each order service call
+	// ClaimIdle is how long a purchase may stay with a worker before another replica takes
+	// it over, e.g. after a crash
+	ClaimIdle time.Duration `mapstructure:"claim_idle"`
+	ResultTTL time.Duration `mapstructure:"result_ttl"` // How long the status of a purchase is kept
+	PollAfter time.Duration `mapstructure:"poll_after"` // Polling interval suggested to clients
+}
+
+// BatchPurchaseConfig represents the limits of batch purchases
+type BatchPurchaseConfig struct {
+	MaxItems    int `mapstructure:"max_items"`   // Entries accepted in one batch
+	Concurrency int `mapstructure:"concurrency"` // Purchases of a batch in flight at once
+}
+
+// DashboardConfig represents the aggregated dashboard of the authenticated user
+type DashboardConfig struct {
+	RecentOrders   int `mapstructure:"recent_orders"`   // Orders in the dashboard, newest first
+	UpcomingEvents int `mapstructure:"upcoming_events"` // Events in the dashboard, soonest first
+	// SectionTimeout bounds each backend call; a section that misses it reports an error
+	// while the others are returned
+	SectionTimeout time.Duration `mapstructure:"section_timeout"`
+}
+
+// DiscoveryConfig represents the service discovery backends used by
+// consul:/// and kubernetes:/// service targets
+type DiscoveryConfig struct {
+	Consul     ConsulDiscoveryConfig     `mapstructure:"consul"`
+	Kubernetes KubernetesDiscoveryConfig `mapstructure:"kubernetes"`
+}
+
+// Target schemes served by the discovery resolvers
+const (
+	DiscoverySchemeConsul     = "consul"
+	DiscoverySchemeKubernetes = "kubernetes"
+)
+
+// ConsulDiscoveryConfig represents discovery through the Consul catalog
+type ConsulDiscoveryConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Address    string `mapstructure:"address"` // e.g. http://consul:8500
+	Token      string `mapstructure:"token" secret:"true"`
+	Datacenter string `mapstructure:"datacenter"`
+}
+
+// KubernetesDiscoveryConfig represents discovery through the Kubernetes Endpoints API;
+// the defaults match the in-cluster service account
+type KubernetesDiscoveryConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	APIServer string `mapstructure:"api_server"`
+	TokenFile string `mapstructure:"token_file"`
+	CAFile    string `mapstructure:"ca_file"`
+	Namespace string `mapstructure:"namespace"` // Namespace of targets without one
+}
+
+// LogConfig represents logging configuration
+type LogConfig struct {
+	Level string `mapstructure:"level"`
+	// Modules sets the levels of subsystems, e.g. rate_limiter: debug; modules left out
+	// log at Level
+	Modules map[string]string `mapstructure:"modules"`
+	// Backend is the backend of hot path logging: logrus, or slog for allocation-free lines
+	Backend string `mapstructure:"backend"`
+	Format  string `mapstructure:"format"` // text, json or ecs (Elastic Common Schema JSON)
+	// Output is where log lines and access logs go: stdout, stderr or file
+	Output string `mapstructure:"output"`
+	// ErrorOutput also writes error lines to a stream of their own: stderr or file; empty
+	// keeps them with the other lines only
+	ErrorOutput string            `mapstructure:"error_output"`
+	File        LogFileConfig     `mapstructure:"file"`
+	Rotate      LogRotateConfig   `mapstructure:"rotate"`
+	Sampling    LogSamplingConfig `mapstructure:"sampling"`
+	GRPCCalls   GRPCLogConfig     `mapstructure:"grpc_calls"`
+	Bodies      BodyLogConfig     `mapstructure:"bodies"`
+}
+
+// BodyLogConfig represents the logging of the request and response bodies of selected
+// routes, to debug partner integrations. Routes are logged when listed here or enabled
+// at runtime through the admin API; credential fields are always redacted.
+type BodyLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Routes are logged for as long as they are listed, written as in load shedding
+	// classes: "METHOD /pattern" or "/pattern"
+	Routes []string `mapstructure:"routes"`
+	// MaxBytes bounds the bodies logged; larger bodies are only logged by size
+	MaxBytes int `mapstructure:"max_bytes"`
+	// ContentTypes lists the media types whose bodies are logged: JSON or form types,
+	// whose fields can be redacted; other bodies are only logged by size
+	ContentTypes []string `mapstructure:"content_types"`
+	// RedactFields lists body fields whose values are replaced on top of the credential
+	// fields; a field matches when its name contains an entry, ignoring case and underscores
+	RedactFields []string `mapstructure:"redact_fields"`
+	// DefaultTTL is how long routes enabled at runtime are logged when no TTL is given,
+	// and MaxTTL the longest they may be
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`
+}
+
+// LogSamplingConfig represents the sampling of identical log lines, those with the same
+// level, message and key fields, so an error repeated on every request during an incident
+// does not flood the log pipeline. Logged lines count the identical ones left out before
+// them in a suppressed field.
+type LogSamplingConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Interval   time.Duration `mapstructure:"interval"`   // Counts are reset every interval
+	Initial    int           `mapstructure:"initial"`    // Identical lines logged per interval before sampling
+	Thereafter int           `mapstructure:"thereafter"` // Then one identical line in this many
+	// RateLimit caps the identical warn and error lines logged per interval; 0 leaves
+	// them uncapped
+	RateLimit int      `mapstructure:"rate_limit"`
+	KeyFields []string `mapstructure:"key_fields"` // Fields telling lines with the same message apart, e.g. route
+}
+
+// SlowRequestsConfig represents the logging of requests slower than a threshold, with
+// the time they spent in each stage
+type SlowRequestsConfig struct {
+	Enabled   bool                       `mapstructure:"enabled"`
+	Threshold time.Duration              `mapstructure:"threshold"`
+	Routes    []SlowRouteThresholdConfig `mapstructure:"routes"` // Thresholds of routes slower by design
+}
+
+// SlowRouteThresholdConfig represents the threshold of a route, written as in load
+// shedding classes
+type SlowRouteThresholdConfig struct {
+	Route     string        `mapstructure:"route"` // e.g. "POST /api/v1/orders/purchase"
+	Threshold time.Duration `mapstructure:"threshold"`
+}
+
+// LogFileConfig represents the files logs are written to
+type LogFileConfig struct {
+	Path      string `mapstructure:"path"`       // Written when output is file
+	ErrorPath string `mapstructure:"error_path"` // Written when error_output is file
+}
+
+// LogRotateConfig represents the rotation of log files, which are rotated once they reach
+// MaxSizeMB; rotated files are removed once older than MaxAgeDays or beyond MaxBackups
+type LogRotateConfig struct {
+	MaxSizeMB  int  `mapstructure:"max_size_mb"`
+	MaxAgeDays int  `mapstructure:"max_age_days"` // 0 keeps them whatever their age
+	MaxBackups int  `mapstructure:"max_backups"`  // 0 keeps them whatever their number
+	Compress   bool `mapstructure:"compress"`     // Gzip rotated files
+	LocalTime  bool `mapstructure:"local_time"`   // Name rotated files in local time instead of UTC
+}
+
+// GRPCLogConfig represents logging of backend gRPC calls
+type GRPCLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PayloadSamplePercentage is the share of calls (0-100) whose request and
+	// response are logged at debug level
+	PayloadSamplePercentage float64 `mapstructure:"payload_sample_percentage"`
+	// RedactFields lists payload field names masked in logs; a field matches when its
+	// name contains an entry, ignoring case and underscores
+	RedactFields []string `mapstructure:"redact_fields"`
 }
 
 // AppConfig represents application-level configuration
@@ -26,23 +876,288 @@ type AppConfig struct {
 
 // ServerConfig represents server configuration
 type ServerConfig struct {
-	HTTP HTTPConfig `mapstructure:"http"`
+	HTTP      HTTPConfig       `mapstructure:"http"`
+	GRPCWeb   GRPCWebConfig    `mapstructure:"grpc_web"`
+	GRPC      GRPCServerConfig `mapstructure:"grpc"`
+	Readiness ReadinessConfig  `mapstructure:"readiness"`
+	Admin     AdminConfig      `mapstructure:"admin"`
+	Recovery  RecoveryConfig   `mapstructure:"recovery"`
+	Upgrade   UpgradeConfig    `mapstructure:"upgrade"`
+}
+
+// UpgradeConfig represents zero-downtime binary upgrades. On SIGUSR2 the gateway starts
+// its executable again, hands it the listening sockets and drains once it is ready.
+type UpgradeConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	ReadyTimeout time.Duration `mapstructure:"ready_timeout"` // How long the new process has to take over before it is killed
+}
+
+// RecoveryConfig represents the handling of panics in request handlers
+type RecoveryConfig struct {
+	// AlertWebhook is posted a JSON alert for panics; empty disables alerts
+	AlertWebhook  string        `mapstructure:"alert_webhook" secret:"true"`
+	AlertTimeout  time.Duration `mapstructure:"alert_timeout"`
+	AlertInterval time.Duration `mapstructure:"alert_interval"` // Minimum time between alerts; panics in between are only logged and counted
+}
+
+// Admin listener authentication methods
+const (
+	AdminAuthBasic = "basic"
+	AdminAuthMTLS  = "mtls"
+	AdminAuthNone  = "none" // Only allowed on loopback addresses
+)
+
+// AdminConfig represents the internal listener serving the operational endpoints
+// (/metrics, /admin/*, pprof). When enabled they are no longer served on the public
+// listeners.
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"` // host:port, e.g. an internal interface
+	Auth    string `mapstructure:"auth"`    // basic, mtls or none
+	// Username and Password are the credentials of basic authentication
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" secret:"true"`
+	// TLS serves HTTPS; required for mtls, and for basic outside loopback
+	TLS   AdminTLSConfig `mapstructure:"tls"`
+	Pprof bool           `mapstructure:"pprof"` // Serve the profiler under /debug/pprof
+}
+
+// AdminTLSConfig represents the certificates of the admin listener
+type AdminTLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientCAFile holds the CAs client certificates must be signed by with mtls
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// AllowedClients restricts mtls to these certificate common names; empty allows any
+	// certificate signed by the client CAs
+	AllowedClients []string `mapstructure:"allowed_clients"`
+}
+
+// TLSEnabled reports whether the admin listener serves HTTPS
+func (a AdminConfig) TLSEnabled() bool {
+	return a.TLS.CertFile != "" || a.Auth == AdminAuthMTLS
+}
+
+// ReadinessConfig represents the /readyz probe behaviour
+type ReadinessConfig struct {
+	// RequireBackends keeps the gateway unready until every backend service has been reachable once
+	RequireBackends bool `mapstructure:"require_backends"`
+}
+
+// Startup modes, deciding what happens when a dependency is unavailable at boot
+const (
+	StartupFail     = "fail"     // Exit at once
+	StartupRetry    = "retry"    // Retry with backoff until startup.timeout, then exit
+	StartupDegraded = "degraded" // Retry until startup.timeout, then start without it and keep retrying
+)
+
+// StartupConfig represents how the gateway waits for Redis, service discovery and the
+// backend connection setup at boot, so restarts of dependencies do not crash-loop it
+type StartupConfig struct {
+	Mode    string        `mapstructure:"mode"`    // fail, retry or degraded
+	Timeout time.Duration `mapstructure:"timeout"` // How long a dependency is retried at boot
+	// InitialBackoff doubles after every failed attempt up to MaxBackoff
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// ProcessConfig represents the integration of the gateway process with the host
+type ProcessConfig struct {
+	// PIDFile is written with the process ID at startup and removed at exit; empty
+	// disables it
+	PIDFile string `mapstructure:"pid_file"`
+}
+
+// GRPCWebConfig represents gRPC-Web endpoint configuration
+type GRPCWebConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// GRPCServerConfig represents the gRPC server exposing the gateway operations to internal
+// consumers on its own port, behind the same authentication and rate limits as HTTP
+type GRPCServerConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"` // host:port
+	// CertFile and KeyFile serve TLS; plaintext when empty
+	CertFile       string `mapstructure:"cert_file"`
+	KeyFile        string `mapstructure:"key_file"`
+	MaxRecvMsgSize int    `mapstructure:"max_recv_msg_size"` // Largest request message in bytes
+	Reflection     bool   `mapstructure:"reflection"`        // Serve the reflection service for tools such as grpcurl
 }
 
 // HTTPConfig represents HTTP server configuration
 type HTTPConfig struct {
-	Host                    string        `mapstructure:"host"`
-	Port                    int           `mapstructure:"port"`
-	ReadTimeout             time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout            time.Duration `mapstructure:"write_timeout"`
-	IdleTimeout             time.Duration `mapstructure:"idle_timeout"`
+	Host              string        `mapstructure:"host"`
+	Port              int           `mapstructure:"port"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // 0 falls back to read_timeout
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`
+	// TrustedProxies lists the networks of the load balancers and proxies whose
+	// forwarding headers are believed; requests from any other address are attributed
+	// to that address
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// ClientIPHeaders are the headers the client address is read from when the request
+	// comes from a trusted proxy, in order of preference (X-Forwarded-For, X-Real-IP,
+	// Forwarded or a platform header such as CF-Connecting-IP)
+	ClientIPHeaders         []string      `mapstructure:"client_ip_headers"`
 	GracefulShutdownTimeout time.Duration `mapstructure:"graceful_shutdown_timeout"`
+	// DrainDelay is how long /readyz fails before shutdown starts, giving load balancers
+	// time to stop sending traffic
+	DrainDelay     time.Duration `mapstructure:"drain_delay"`
+	RequestTimeout time.Duration `mapstructure:"request_timeout"` // Deadline for upstream calls made by a request, 0 leaves only the write_timeout bound
+	// ResponseMargin is kept free between the upstream deadline and write_timeout to write the response
+	ResponseMargin time.Duration `mapstructure:"response_margin"`
+	// RouteTimeouts overrides request_timeout for individual routes
+	RouteTimeouts []RouteTimeoutConfig `mapstructure:"route_timeouts"`
+	RequestBody   RequestBodyConfig    `mapstructure:"request_body"`
+	HTTP2         HTTP2Config          `mapstructure:"http2"`
+	TLS           ServerTLSConfig      `mapstructure:"tls"`
+	// Listeners replaces Host/Port with one or more TCP or unix socket listeners
+	Listeners []ListenerConfig `mapstructure:"listeners"`
+}
+
+// HTTP2Config represents HTTP/2 support of the HTTP server
+type HTTP2Config struct {
+	Enabled bool `mapstructure:"enabled"` // HTTP/2 on TLS listeners, negotiated through ALPN
+	// H2C serves HTTP/2 without TLS (prior knowledge) on plaintext listeners, for load
+	// balancers that speak HTTP/2 to their backends
+	H2C                  bool `mapstructure:"h2c"`
+	MaxConcurrentStreams int  `mapstructure:"max_concurrent_streams"` // Per connection
+}
+
+// RequestBodyConfig represents the limits applied to request bodies before they reach the handlers
+type RequestBodyConfig struct {
+	MaxBytes     int64 `mapstructure:"max_bytes"`      // Default size limit, 0 disables it
+	MaxJSONDepth int   `mapstructure:"max_json_depth"` // Nesting limit of JSON bodies, 0 disables it
+	// Groups overrides max_bytes for the routes under a path prefix; the longest matching prefix wins
+	Groups []BodyLimitConfig `mapstructure:"groups"`
+}
+
+// BodyLimitConfig represents the body size limit of a route group
+type BodyLimitConfig struct {
+	PathPrefix string `mapstructure:"path_prefix"`
+	MaxBytes   int64  `mapstructure:"max_bytes"` // 0 disables the limit for the group
+}
+
+// Limit returns the body size limit of a request path
+func (r RequestBodyConfig) Limit(path string) int64 {
+	limit, matched := r.MaxBytes, ""
+	for _, group := range r.Groups {
+		if strings.HasPrefix(path, group.PathPrefix) && len(group.PathPrefix) > len(matched) {
+			limit, matched = group.MaxBytes, group.PathPrefix
+		}
+	}
+	return limit
+}
+
+// RouteTimeoutConfig represents the upstream deadline of a single route
+type RouteTimeoutConfig struct {
+	Method  string        `mapstructure:"method"` // HTTP method; empty matches every method
+	Path    string        `mapstructure:"path"`   // Route pattern as registered, e.g. /api/v1/orders/:order_id
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// CallBudget returns the deadline for the upstream calls made by a request to the route:
+// the route's timeout or request_timeout, capped so that it always ends response_margin
+// before write_timeout and a backend result can still be written to the client
+func (h HTTPConfig) CallBudget(method, route string) time.Duration {
+	budget := h.RequestTimeout
+	for _, rt := range h.RouteTimeouts {
+		if rt.Path == route && (rt.Method == "" || rt.Method == method) {
+			budget = rt.Timeout
+			break
+		}
+	}
+
+	if h.WriteTimeout <= 0 {
+		return budget
+	}
+	limit := h.WriteTimeout - h.ResponseMargin
+	if budget <= 0 || budget > limit {
+		budget = limit
+	}
+	return budget
+}
+
+// Listener networks
+const (
+	NetworkTCP  = "tcp"
+	NetworkUnix = "unix"
+)
+
+// ListenerConfig represents a single listen address of the HTTP server
+type ListenerConfig struct {
+	Network    string `mapstructure:"network"`     // tcp (default) or unix
+	Address    string `mapstructure:"address"`     // host:port, or the socket path for unix listeners
+	TLS        bool   `mapstructure:"tls"`         // Serve HTTPS using server.http.tls
+	SocketMode string `mapstructure:"socket_mode"` // Octal permissions of a unix socket, e.g. "0660"
+}
+
+// ServerTLSConfig represents HTTPS settings of the HTTP server
+type ServerTLSConfig struct {
+	Enabled    bool           `mapstructure:"enabled"` // Serve HTTPS on the default Host/Port listener
+	CertFile   string         `mapstructure:"cert_file"`
+	KeyFile    string         `mapstructure:"key_file"`
+	MinVersion string         `mapstructure:"min_version"` // 1.2 or 1.3
+	Autocert   AutocertConfig `mapstructure:"autocert"`
+}
+
+// AutocertConfig represents automatic certificate management through ACME (Let's Encrypt)
+type AutocertConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`
+	Domains  []string `mapstructure:"domains"`
+	Email    string   `mapstructure:"email"`
+	CacheDir string   `mapstructure:"cache_dir"`
+	// HTTPChallengeAddress serves HTTP-01 challenges, e.g. ":80"; TLS-ALPN-01 is always available
+	HTTPChallengeAddress string `mapstructure:"http_challenge_address"`
+}
+
+// ResolvedListeners returns the configured listeners, falling back to Host:Port
+func (h HTTPConfig) ResolvedListeners() []ListenerConfig {
+	if len(h.Listeners) == 0 {
+		return []ListenerConfig{{
+			Network: NetworkTCP,
+			Address: net.JoinHostPort(h.Host, strconv.Itoa(h.Port)),
+			TLS:     h.TLS.Enabled,
+		}}
+	}
+
+	listeners := make([]ListenerConfig, len(h.Listeners))
+	for i, l := range h.Listeners {
+		if l.Network == "" {
+			l.Network = NetworkTCP
+		}
+		listeners[i] = l
+	}
+	return listeners
 }
 
 // ServicesConfig represents microservices configuration
 type ServicesConfig struct {
-	UserService  ServiceConfig `mapstructure:"user_service"`
-	OrderService ServiceConfig `mapstructure:"order_service"`
+	UserService    ServiceConfig `mapstructure:"user_service"`
+	OrderService   ServiceConfig `mapstructure:"order_service"`
+	EventService   ServiceConfig `mapstructure:"event_service"`
+	PaymentService ServiceConfig `mapstructure:"payment_service"`
+}
+
+// All returns every configured backend service keyed by its name under services;
+// a service is added by adding a ServiceConfig field
+func (s ServicesConfig) All() map[string]ServiceConfig {
+	v := reflect.ValueOf(s)
+	services := make(map[string]ServiceConfig, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		if svc, ok := v.Field(i).Interface().(ServiceConfig); ok {
+			services[v.Type().Field(i).Tag.Get("mapstructure")] = svc
+		}
+	}
+	return services
+}
+
+// Names returns the names of the configured backend services in a stable order
+func (s ServicesConfig) Names() []string {
+	return sortedKeys(s.All())
 }
 
 // UserServiceConfig is an alias for ServiceConfig for user service
@@ -51,12 +1166,82 @@ type UserServiceConfig = ServiceConfig
 // OrderServiceConfig is an alias for ServiceConfig for order service
 type OrderServiceConfig = ServiceConfig
 
+// EventServiceConfig is an alias for ServiceConfig for event service
+type EventServiceConfig = ServiceConfig
+
+// PaymentServiceConfig is an alias for ServiceConfig for payment service
+type PaymentServiceConfig = ServiceConfig
+
 // ServiceConfig represents individual service configuration
 type ServiceConfig struct {
 	Name string     `mapstructure:"name"`
 	Host string     `mapstructure:"host"`
 	Port int        `mapstructure:"port"`
 	GRPC GRPCConfig `mapstructure:"grpc"`
+	// Target is a gRPC target such as dns:///order-service:50052 resolving to every
+	// replica; when set, Host/Port are ignored
+	Target string `mapstructure:"target"`
+	// LoadBalancing is the policy spreading calls across the resolved replicas
+	LoadBalancing string `mapstructure:"load_balancing"` // round_robin or pick_first
+	// Weighted endpoints for canary releases; when set, Host/Port are ignored
+	Endpoints []EndpointConfig `mapstructure:"endpoints"`
+	// StickyRouting pins a user to the same endpoint, and to the same replica of a target,
+	// across requests; it overrides LoadBalancing
+	StickyRouting bool `mapstructure:"sticky_routing"`
+	// Shadow mirrors a share of calls to a secondary upstream
+	Shadow ShadowConfig `mapstructure:"shadow"`
+	// TLS secures the connections to every endpoint and the shadow upstream
+	TLS ServiceTLSConfig `mapstructure:"tls"`
+}
+
+// ServiceTLSConfig represents TLS settings for backend gRPC connections
+type ServiceTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`   // CA bundle used to verify the service; system roots when empty
+	CertFile           string `mapstructure:"cert_file"` // Client certificate for mutual TLS
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"` // Overrides the name verified in the server certificate
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// ShadowConfig represents traffic mirroring to a shadow upstream
+type ShadowConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Host       string        `mapstructure:"host"`
+	Port       int           `mapstructure:"port"`
+	Percentage float64       `mapstructure:"percentage"` // Share of calls mirrored, 0-100
+	Timeout    time.Duration `mapstructure:"timeout"`
+}
+
+// EndpointConfig represents a weighted upstream endpoint
+type EndpointConfig struct {
+	Name   string `mapstructure:"name"`
+	Host   string `mapstructure:"host"`
+	Port   int    `mapstructure:"port"`
+	Target string `mapstructure:"target"` // gRPC target; overrides Host/Port
+	Weight int    `mapstructure:"weight"`
+}
+
+// Address returns the gRPC target of the endpoint
+func (e EndpointConfig) Address() string {
+	if e.Target != "" {
+		return e.Target
+	}
+	return fmt.Sprintf("%s:%d", e.Host, e.Port)
+}
+
+// Load balancing policies for service replicas
+const (
+	LoadBalancingRoundRobin = "round_robin"
+	LoadBalancingPickFirst  = "pick_first"
+)
+
+// ResolvedEndpoints returns the configured endpoints, falling back to Target or Host/Port
+func (s ServiceConfig) ResolvedEndpoints() []EndpointConfig {
+	if len(s.Endpoints) > 0 {
+		return s.Endpoints
+	}
+	return []EndpointConfig{{Name: s.Name, Host: s.Host, Port: s.Port, Target: s.Target, Weight: 1}}
 }
 
 // GRPCConfig represents gRPC client configuration
@@ -64,21 +1249,115 @@ type GRPCConfig struct {
 	KeepaliveTime                time.Duration `mapstructure:"keepalive_time"`
 	KeepaliveTimeout             time.Duration `mapstructure:"keepalive_timeout"`
 	KeepalivePermitWithoutStream bool          `mapstructure:"keepalive_permit_without_stream"`
+	// WaitForReady makes calls wait for a ready connection, up to their deadline,
+	// instead of failing fast while the service is unreachable
+	WaitForReady bool `mapstructure:"wait_for_ready"`
+	// Message size limits in bytes; 0 keeps the gRPC defaults (4 MiB received, unlimited sent)
+	MaxRecvMsgSize int `mapstructure:"max_recv_msg_size"`
+	MaxSendMsgSize int `mapstructure:"max_send_msg_size"`
+	// Compression compresses request messages; gzip or empty for none
+	Compression string `mapstructure:"compression"`
 }
 
+// Compression algorithms for backend calls
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
 // JWTConfig represents JWT configuration
 type JWTConfig struct {
-	SecretKey string `mapstructure:"secret_key"`
+	SecretKey string `mapstructure:"secret_key" secret:"true"`
 }
 
+// Redis deployment modes
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+// What the rate limiters fall back to while Redis is down
+const (
+	LimiterFallbackLocal    = "local"
+	LimiterFallbackDisabled = "disabled"
+)
+
+// Redis nodes the rate limiters read their buckets from
+const (
+	RedisReadFromPrimary  = "primary"
+	RedisReadFromReplicas = "replicas"
+)
+
 // RedisConfig represents Redis configuration
 type RedisConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
+	Mode    string `mapstructure:"mode"` // standalone, sentinel or cluster
 	Host    string `mapstructure:"host"`
 	Port    int    `mapstructure:"port"`
 	DB      int    `mapstructure:"db"`
+	// Addrs lists sentinel or cluster seed addresses (host:port); defaults to Host:Port
+	Addrs      []string `mapstructure:"addrs"`
+	MasterName string   `mapstructure:"master_name"` // Sentinel master name
+	Username   string   `mapstructure:"username"`
+	Password   string   `mapstructure:"password" secret:"true"`
+	// Credentials for the sentinels themselves, when they differ from the data nodes
+	SentinelUsername string         `mapstructure:"sentinel_username"`
+	SentinelPassword string         `mapstructure:"sentinel_password" secret:"true"`
+	TLS              RedisTLSConfig `mapstructure:"tls"`
+	// ReadFrom routes the reads of the rate limiters to the primary or to its replicas:
+	// the replicas listed in standalone mode, those the sentinels know of in sentinel mode
+	// and those of every shard in cluster mode. Writes always go to the primary.
+	ReadFrom string   `mapstructure:"read_from"`
+	Replicas []string `mapstructure:"replicas"` // Replicas of a standalone primary (host:port)
+	// Connection pool, timeouts and retries
+	PoolSize        int           `mapstructure:"pool_size"`
+	MinIdleConns    int           `mapstructure:"min_idle_conns"`
+	PoolTimeout     time.Duration `mapstructure:"pool_timeout"`
+	DialTimeout     time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	MaxRetries      int           `mapstructure:"max_retries"` // -1 disables retries
+	MinRetryBackoff time.Duration `mapstructure:"min_retry_backoff"`
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff"`
+	// Watchdog checks the health of Redis for the rate limiters
+	Watchdog RedisWatchdogConfig `mapstructure:"watchdog"`
+	// LockKeyPrefix namespaces the locks coordinating requests across replicas, such as
+	// those of payments sharing an Idempotency-Key
+	LockKeyPrefix string `mapstructure:"lock_key_prefix"`
 	// Token Bucket Rate Limiting Configuration
 	TokenBucket TokenBucketConfig `mapstructure:"token_bucket"`
+	// Dedicated limiter policies for sensitive endpoints, keyed by policy name
+	Policies map[string]TokenBucketConfig `mapstructure:"policies"`
+}
+
+// RedisTLSConfig represents TLS settings for Redis connections
+type RedisTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`   // CA bundle used to verify the server; system roots when empty
+	CertFile           string `mapstructure:"cert_file"` // Client certificate for mutual TLS
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// RedisWatchdogConfig represents the health checks of Redis. Once failure_threshold
+// checks in a row fail, the rate limiters fall back to buckets local to the instance or
+// stop limiting, and limit through Redis again as soon as a check succeeds.
+type RedisWatchdogConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	Interval         time.Duration `mapstructure:"interval"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	Fallback         string        `mapstructure:"fallback"` // local or disabled
+}
+
+// ResolvedAddrs returns the seed addresses, falling back to Host:Port
+func (r RedisConfig) ResolvedAddrs() []string {
+	if len(r.Addrs) > 0 {
+		return r.Addrs
+	}
+	return []string{fmt.Sprintf("%s:%d", r.Host, r.Port)}
 }
 
 // TokenBucketConfig represents token bucket rate limiting configuration
@@ -88,35 +1367,260 @@ type TokenBucketConfig struct {
 	RefillInterval time.Duration `mapstructure:"refill_interval"`
 }
 
-// LoadConfig loads configuration from file and environment variables
+// APIConfig represents API versioning configuration
+type APIConfig struct {
+	Versions map[string]APIVersionConfig `mapstructure:"versions"`
+}
+
+// APIVersionConfig represents the lifecycle settings of a single API version
+type APIVersionConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Deprecated bool   `mapstructure:"deprecated"`
+	Sunset     string `mapstructure:"sunset"` // RFC3339 date after which the version is retired
+	Link       string `mapstructure:"link"`   // Migration guide for deprecated versions
+	// Envelope wraps successful responses in {data, meta, errors}; off by default so
+	// existing clients keep the bare bodies they parse
+	Envelope bool `mapstructure:"envelope"`
+}
+
+// SunsetTime parses the configured sunset date, returning the zero time if unset
+func (v APIVersionConfig) SunsetTime() (time.Time, error) {
+	if v.Sunset == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v.Sunset)
+}
+
+// DocsConfig represents the API documentation routes: the OpenAPI document generated
+// from the registered routes at /openapi.json and the Swagger UI at /docs
+type DocsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SwaggerUIURL is the base URL the /docs page loads the swagger-ui-dist assets from
+	SwaggerUIURL string `mapstructure:"swagger_ui_url"`
+}
+
+// FaultsConfig represents fault injection for resilience testing: a share of the requests
+// of chosen routes, or of the calls to chosen upstreams, is delayed, failed or cut off, so
+// that timeouts, retries and circuit breakers can be exercised. It cannot be enabled in
+// production.
+type FaultsConfig struct {
+	Enabled bool              `mapstructure:"enabled"`
+	Rules   []FaultRuleConfig `mapstructure:"rules"`
+}
+
+// FaultRuleConfig injects faults into the requests of a route or the calls to an upstream.
+// The latency is added first; the request then fails with the status or code, or has its
+// connection reset, when one is set.
+type FaultRuleConfig struct {
+	// Route is a route pattern as registered, optionally preceded by a method, e.g.
+	// "POST /api/v1/orders/purchase"
+	Route string `mapstructure:"route"`
+	// Upstream is a backend service or one of its methods, e.g. order.OrderService or
+	// order.OrderService/CancelOrder
+	Upstream   string        `mapstructure:"upstream"`
+	Percentage float64       `mapstructure:"percentage"` // Share of the matching requests (0-100) the fault is injected into
+	Latency    time.Duration `mapstructure:"latency"`
+	Status     int           `mapstructure:"status"` // HTTP status route requests fail with
+	Code       string        `mapstructure:"code"`   // gRPC code upstream calls fail with, e.g. UNAVAILABLE
+	// Reset cuts the connection of route requests, or fails upstream calls as if the
+	// connection to the backend was reset
+	Reset bool `mapstructure:"reset"`
+}
+
+// DevStubConfig represents the dev stub mode, in which the backend services are replaced
+// by in-process fakes answering from fixture files, so that the gateway runs without them.
+// It cannot be enabled in production.
+type DevStubConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Fixtures are scenario files mapping gRPC methods to their replies, merged in order
+	// over the built-in fixture
+	Fixtures []string `mapstructure:"fixtures"`
+	// Role is the role of the access tokens the fakes issue on login, e.g. admin; the
+	// tokens are signed with jwt.secret_key so that they authenticate
+	Role string `mapstructure:"role"`
+}
+
+// ObservabilityConfig represents the integrations with observability services
+type ObservabilityConfig struct {
+	Sentry SentryConfig `mapstructure:"sentry"`
+}
+
+// SentryConfig represents the reporting of errors to Sentry or a service speaking its
+// protocol: panics, 5xx responses and upstreams becoming unreachable are reported with
+// the context of their request
+type SentryConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	DSN     string `mapstructure:"dsn" secret:"true"` // e.g. https://<key>@o0.ingest.sentry.io/<project>
+	// Environment and Release tag events; they default to app.environment and the
+	// version of the build
+	Environment string `mapstructure:"environment"`
+	Release     string `mapstructure:"release"`
+	// SampleRate is the share of 5xx responses reported (0-1); panics and upstream
+	// events are always reported
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// UpstreamEvents reports the endpoints of backend services becoming unreachable and
+	// recovering
+	UpstreamEvents bool `mapstructure:"upstream_events"`
+	// UserHashKey keys the HMAC-SHA256 hashes users are reported by; empty hashes user
+	// IDs with plain SHA-256
+	UserHashKey string        `mapstructure:"user_hash_key" secret:"true"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	// Buffer is how many events may wait to be sent; more are dropped
+	Buffer int `mapstructure:"buffer"`
+}
+
+// RecordingConfig represents the traffic recorder: a share of the requests and their
+// responses are written, sanitized, to JSON Lines files that cmd/replay re-sends against
+// another gateway
+type RecordingConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Directory string `mapstructure:"directory"` // Files are named exchanges-<date>-<hour>.jsonl, in UTC
+	// Percentage is the share of the requests (0-100) recorded
+	Percentage float64 `mapstructure:"percentage"`
+	// Routes are the route patterns recorded as registered; empty records every route
+	Routes []string `mapstructure:"routes"`
+	// MaxBodyBytes bounds the bodies recorded; larger bodies, and bodies that are not
+	// JSON, are only recorded by size
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+	// RedactHeaders lists the headers whose values are replaced
+	RedactHeaders []string `mapstructure:"redact_headers"`
+	// RedactFields lists body fields and query parameters whose values are replaced; a
+	// field matches when its name contains an entry, ignoring case and underscores
+	RedactFields []string `mapstructure:"redact_fields"`
+	// Buffer is how many exchanges may wait to be written; more are dropped
+	Buffer int `mapstructure:"buffer"`
+}
+
+// GRPCCode parses the code upstream calls fail with
+func (r FaultRuleConfig) GRPCCode() (codes.Code, error) {
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(r.Code)))); err != nil {
+		return codes.OK, fmt.Errorf("unknown gRPC code %q", r.Code)
+	}
+	return code, nil
+}
+
+// IsVersionEnabled reports whether the given API version should be served
+func (a APIConfig) IsVersionEnabled(version string) bool {
+	v, ok := a.Versions[version]
+	if !ok {
+		return false
+	}
+	return v.Enabled
+}
+
+// TransformRule represents request/response transformations applied to a route group
+type TransformRule struct {
+	PathPrefix         string      `mapstructure:"path_prefix"`         // Requests whose path starts with this prefix are transformed
+	StripPrefix        string      `mapstructure:"strip_prefix"`        // Prefix removed from the request path
+	AddPrefix          string      `mapstructure:"add_prefix"`          // Prefix prepended to the request path
+	RewriteRegex       string      `mapstructure:"rewrite_regex"`       // Regular expression matched against the path
+	RewriteReplacement string      `mapstructure:"rewrite_replacement"` // Replacement for RewriteRegex matches
+	RequestHeaders     HeaderRules `mapstructure:"request_headers"`
+	ResponseHeaders    HeaderRules `mapstructure:"response_headers"`
+}
+
+// HeaderRules represents headers to add or remove
+type HeaderRules struct {
+	Add    map[string]string `mapstructure:"add"`
+	Remove []string          `mapstructure:"remove"`
+}
+
+// LoadConfig loads configuration from file and environment variables.
+// An empty configPath loads defaults and environment variables only.
+// An environment overlay (config.{env}.yaml next to the base file) is merged on
+// top of the base file when present; the environment comes from APP_ENV and
+// falls back to app.environment. When a remote backend is configured, its
+// document is merged last, below environment variables.
 func LoadConfig(configPath string) (*Config, error) {
 	v := viper.New()
+	v.SetConfigType("yaml")
 
 	// Set default values
 	setDefaults(v)
 
-	// Set config file
-	v.SetConfigFile(configPath)
-	v.SetConfigType("yaml")
+	// Enable environment variable support; every setting can be set through
+	// APIGW_<KEY> or, for compatibility, the unprefixed <KEY>. Keys are bound
+	// explicitly so the prefixed variable wins over the unprefixed one.
+	if err := bindEnv(v, settingKeys(reflect.TypeOf(Config{}), "")); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if configPath != "" {
+		// Read config file
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		files = append(files, configPath)
 
-	// Enable environment variable support
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
+		// Merge the environment overlay
+		if env := v.GetString("app.environment"); env != "" {
+			overlay := OverlayPath(configPath, env)
+			if _, err := os.Stat(overlay); err == nil {
+				v.SetConfigFile(overlay)
+				if err := v.MergeInConfig(); err != nil {
+					return nil, fmt.Errorf("failed to merge config overlay %s: %w", overlay, err)
+				}
+				files = append(files, overlay)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to read config overlay %s: %w", overlay, err)
+			}
+		}
+	}
+
+	// Bind keys that only exist in the files, such as map entries
+	if err := bindEnv(v, v.AllKeys()); err != nil {
+		return nil, err
+	}
 
-	// Read config file
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	// Merge the shared remote document
+	// Settings are read one by one since UnmarshalKey ignores environment overrides
+	remote := RemoteConfig{
+		Provider: v.GetString("remote.provider"),
+		Endpoint: v.GetString("remote.endpoint"),
+		Key:      v.GetString("remote.key"),
+		Token:    v.GetString("remote.token"),
+		Username: v.GetString("remote.username"),
+		Password: v.GetString("remote.password"),
+		Timeout:  v.GetDuration("remote.timeout"),
+	}
+	if remote.Enabled() {
+		source, err := newRemoteSource(remote)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fetchRemote(source, remote)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch remote config %s: %w", remote.Location(), err)
+		}
+		if err := v.MergeConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to merge remote config %s: %w", remote.Location(), err)
+		}
+		files = append(files, remote.Location())
 	}
 
-	// Unmarshal config
+	// Unmarshal config, recording keys that match no setting
 	var config Config
-	if err := v.Unmarshal(&config); err != nil {
+	var metadata mapstructure.Metadata
+	if err := v.Unmarshal(&config, func(dc *mapstructure.DecoderConfig) {
+		dc.Metadata = &metadata
+	}); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	config.Files = files
+	config.unknownKeys = metadata.Unused
 
 	return &config, nil
 }
 
+// OverlayPath returns the path of the overlay for an environment, e.g. config.production.yaml
+func OverlayPath(configPath, env string) string {
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + "." + env + ext
+}
+
 // setDefaults sets default configuration values
 func setDefaults(v *viper.Viper) {
 	// App defaults
@@ -131,6 +1635,110 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.http.write_timeout", "30s")
 	v.SetDefault("server.http.idle_timeout", "60s")
 	v.SetDefault("server.http.graceful_shutdown_timeout", "30s")
+	v.SetDefault("server.http.drain_delay", "5s")
+	v.SetDefault("server.http.request_timeout", "25s")
+	v.SetDefault("server.http.response_margin", "1s")
+	v.SetDefault("server.http.read_header_timeout", "10s")
+	v.SetDefault("server.http.max_header_bytes", 1<<20)
+	v.SetDefault("server.http.trusted_proxies", []string{})
+	v.SetDefault("server.http.client_ip_headers", []string{"X-Forwarded-For", "X-Real-IP"})
+	v.SetDefault("server.http.http2.enabled", true)
+	v.SetDefault("server.http.http2.h2c", false)
+	v.SetDefault("server.http.http2.max_concurrent_streams", 250)
+	v.SetDefault("server.http.request_body.max_bytes", 1<<20)
+	v.SetDefault("server.http.request_body.max_json_depth", 32)
+	// Avatar uploads are larger than other bodies; the extra room fits the multipart framing
+	v.SetDefault("server.http.request_body.groups", []map[string]interface{}{
+		{"path_prefix": AvatarPath, "max_bytes": 2<<20 + 64<<10},
+	})
+	v.SetDefault("server.http.tls.enabled", false)
+	v.SetDefault("server.http.tls.cert_file", "")
+	v.SetDefault("server.http.tls.key_file", "")
+	v.SetDefault("server.http.tls.min_version", "1.2")
+	v.SetDefault("server.http.tls.autocert.enabled", false)
+	v.SetDefault("server.http.tls.autocert.cache_dir", "autocert-cache")
+	v.SetDefault("server.grpc_web.enabled", false)
+	v.SetDefault("server.grpc.enabled", false)
+	v.SetDefault("server.grpc.address", "0.0.0.0:9091")
+	v.SetDefault("server.grpc.cert_file", "")
+	v.SetDefault("server.grpc.key_file", "")
+	v.SetDefault("server.grpc.max_recv_msg_size", 4<<20)
+	v.SetDefault("server.grpc.reflection", false)
+	v.SetDefault("server.readiness.require_backends", false)
+	v.SetDefault("startup.mode", StartupRetry)
+	v.SetDefault("startup.timeout", "1m")
+	v.SetDefault("startup.initial_backoff", "500ms")
+	v.SetDefault("startup.max_backoff", "10s")
+	v.SetDefault("process.pid_file", "")
+	v.SetDefault("server.recovery.alert_webhook", "")
+	v.SetDefault("server.recovery.alert_timeout", "5s")
+	v.SetDefault("server.recovery.alert_interval", "1m")
+	v.SetDefault("server.upgrade.enabled", false)
+	v.SetDefault("server.upgrade.ready_timeout", "1m")
+	v.SetDefault("server.admin.enabled", false)
+	v.SetDefault("server.admin.address", "127.0.0.1:9090")
+	v.SetDefault("server.admin.auth", AdminAuthBasic)
+	v.SetDefault("server.admin.username", "admin")
+	v.SetDefault("server.admin.password", "")
+	v.SetDefault("server.admin.tls.cert_file", "")
+	v.SetDefault("server.admin.tls.key_file", "")
+	v.SetDefault("server.admin.tls.client_ca_file", "")
+	v.SetDefault("server.admin.tls.allowed_clients", []string{})
+	v.SetDefault("server.admin.pprof", false)
+
+	// Log defaults
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.modules", map[string]string{})
+	v.SetDefault("log.backend", "logrus")
+	v.SetDefault("log.format", "text")
+	v.SetDefault("log.output", "stdout")
+	v.SetDefault("log.error_output", "")
+	v.SetDefault("log.file.path", "")
+	v.SetDefault("log.file.error_path", "")
+	v.SetDefault("log.rotate.max_size_mb", 100)
+	v.SetDefault("log.rotate.max_age_days", 14)
+	v.SetDefault("log.rotate.max_backups", 10)
+	v.SetDefault("log.rotate.compress", true)
+	v.SetDefault("log.rotate.local_time", false)
+	v.SetDefault("slow_requests.enabled", false)
+	v.SetDefault("slow_requests.threshold", "1s")
+	v.SetDefault("slow_requests.routes", []interface{}{})
+	v.SetDefault("log.sampling.enabled", false)
+	v.SetDefault("log.sampling.interval", "1s")
+	v.SetDefault("log.sampling.initial", 10)
+	v.SetDefault("log.sampling.thereafter", 100)
+	v.SetDefault("log.sampling.rate_limit", 20)
+	v.SetDefault("log.sampling.key_fields", []string{})
+	v.SetDefault("log.grpc_calls.enabled", true)
+	v.SetDefault("log.grpc_calls.payload_sample_percentage", 0)
+	v.SetDefault("log.grpc_calls.redact_fields", []string{"password", "token", "secret", "card", "cvv", "email"})
+	v.SetDefault("log.bodies.enabled", false)
+	v.SetDefault("log.bodies.routes", []string{})
+	v.SetDefault("log.bodies.max_bytes", 4096)
+	v.SetDefault("log.bodies.content_types", []string{"application/json", "application/x-www-form-urlencoded"})
+	v.SetDefault("log.bodies.redact_fields", []string{"email", "phone"})
+	v.SetDefault("log.bodies.default_ttl", "15m")
+	v.SetDefault("log.bodies.max_ttl", "24h")
+
+	// Service discovery defaults
+	v.SetDefault("discovery.consul.enabled", false)
+	v.SetDefault("discovery.consul.address", "http://localhost:8500")
+	v.SetDefault("discovery.consul.token", "")
+	v.SetDefault("discovery.consul.datacenter", "")
+	v.SetDefault("discovery.kubernetes.enabled", false)
+	v.SetDefault("discovery.kubernetes.api_server", "https://kubernetes.default.svc")
+	v.SetDefault("discovery.kubernetes.token_file", "/var/run/secrets/kubernetes.io/serviceaccount/token")
+	v.SetDefault("discovery.kubernetes.ca_file", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt")
+	v.SetDefault("discovery.kubernetes.namespace", "default")
+
+	// Remote config defaults
+	v.SetDefault("remote.provider", "")
+	v.SetDefault("remote.endpoint", "")
+	v.SetDefault("remote.key", "")
+	v.SetDefault("remote.token", "")
+	v.SetDefault("remote.username", "")
+	v.SetDefault("remote.password", "")
+	v.SetDefault("remote.timeout", "5s")
 
 	// JWT defaults
 	v.SetDefault("jwt.secret_key", "booking-tickets-api-gateway-secret-key-2024-development")
@@ -140,57 +1748,328 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("redis.host", "localhost")
 	v.SetDefault("redis.port", 6379)
 	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.mode", RedisModeStandalone)
+	v.SetDefault("redis.username", "")
+	v.SetDefault("redis.password", "")
+	v.SetDefault("redis.master_name", "")
+	v.SetDefault("redis.read_from", RedisReadFromPrimary)
+	v.SetDefault("redis.replicas", []string{})
+	v.SetDefault("redis.sentinel_username", "")
+	v.SetDefault("redis.sentinel_password", "")
+	v.SetDefault("redis.tls.enabled", false)
+	v.SetDefault("redis.pool_size", 10)
+	v.SetDefault("redis.min_idle_conns", 5)
+	v.SetDefault("redis.pool_timeout", "4s")
+	v.SetDefault("redis.dial_timeout", "5s")
+	v.SetDefault("redis.read_timeout", "3s")
+	v.SetDefault("redis.write_timeout", "3s")
+	v.SetDefault("redis.max_retries", 3)
+	v.SetDefault("redis.min_retry_backoff", "8ms")
+	v.SetDefault("redis.max_retry_backoff", "512ms")
 
 	// Token Bucket defaults
+	v.SetDefault("redis.lock_key_prefix", "apigw:lock:")
+	v.SetDefault("redis.watchdog.enabled", true)
+	v.SetDefault("redis.watchdog.interval", "1s")
+	v.SetDefault("redis.watchdog.timeout", "500ms")
+	v.SetDefault("redis.watchdog.failure_threshold", 3)
+	v.SetDefault("redis.watchdog.fallback", LimiterFallbackLocal)
 	v.SetDefault("redis.token_bucket.capacity", 100)
 	v.SetDefault("redis.token_bucket.refill_rate", 1.67) // 100 tokens per minute = 1.67 tokens per second
 	v.SetDefault("redis.token_bucket.refill_interval", "1m")
 
+	// Account recovery limiter policy defaults
+	v.SetDefault("redis.policies.account_recovery_ip.capacity", 10)
+	v.SetDefault("redis.policies.account_recovery_ip.refill_rate", 0.0028) // 10 requests per hour
+	v.SetDefault("redis.policies.account_recovery_ip.refill_interval", "1h")
+	v.SetDefault("redis.policies.account_recovery_email.capacity", 3)
+	v.SetDefault("redis.policies.account_recovery_email.refill_rate", 0.00083) // 3 requests per hour
+	v.SetDefault("redis.policies.account_recovery_email.refill_interval", "1h")
+
+	// Response cache defaults
+	v.SetDefault("cache.enabled", false)
+	v.SetDefault("cache.memory_max_entries", 10000)
+	v.SetDefault("cache.redis", true)
+	v.SetDefault("cache.key_prefix", "apigw:cache:")
+
+	// Request coalescing defaults
+	v.SetDefault("coalescing.enabled", false)
+	v.SetDefault("coalescing.routes", []string{})
+
+	// XML content negotiation defaults
+	v.SetDefault("xml.enabled", false)
+	v.SetDefault("xml.routes", []string{})
+	v.SetDefault("xml.root_element", "response")
+
+	// Error response defaults
+	v.SetDefault("errors.format", ErrorFormatJSON)
+	v.SetDefault("errors.problem_type_base", "")
+
+	// Request validation defaults
+	v.SetDefault("validation.event_id_formats", []string{EventIDULID, EventIDUUID, EventIDSlug})
+	v.SetDefault("validation.password.min_length", 8)
+	v.SetDefault("validation.password.max_length", 128)
+	v.SetDefault("validation.password.require_upper", true)
+	v.SetDefault("validation.password.require_lower", true)
+	v.SetDefault("validation.password.require_digit", true)
+	v.SetDefault("validation.password.require_symbol", false)
+
+	// Network ACL defaults
+	v.SetDefault("acl.enabled", false)
+	v.SetDefault("acl.deny", []string{})
+	v.SetDefault("acl.key_prefix", "apigw:acl:")
+	v.SetDefault("acl.refresh_interval", "10s")
+
+	// Brute-force protection defaults
+	v.SetDefault("brute_force.enabled", true)
+	v.SetDefault("brute_force.key_prefix", "apigw:bruteforce:")
+	v.SetDefault("brute_force.window", "15m")
+	v.SetDefault("brute_force.lockout", "1m")
+	v.SetDefault("brute_force.max_lockout", "1h")
+	v.SetDefault("brute_force.strike_ttl", "24h")
+	v.SetDefault("brute_force.email.captcha_after", 3)
+	v.SetDefault("brute_force.email.lockout_after", 5)
+	v.SetDefault("brute_force.ip.captcha_after", 10)
+	v.SetDefault("brute_force.ip.lockout_after", 30)
+
+	// CAPTCHA defaults
+	v.SetDefault("captcha.enabled", false)
+	v.SetDefault("captcha.provider", "turnstile")
+	v.SetDefault("captcha.site_key", "")
+	v.SetDefault("captcha.secret_key", "")
+	v.SetDefault("captcha.verify_url", "")
+	v.SetDefault("captcha.timeout", "5s")
+	v.SetDefault("captcha.min_score", 0.5)
+	v.SetDefault("captcha.fail_open", true)
+	v.SetDefault("captcha.header", "X-Captcha-Token")
+	v.SetDefault("captcha.actions", map[string]string{
+		"register":       CaptchaOnAbuse,
+		"password_reset": CaptchaOnAbuse,
+		"purchase":       CaptchaOnAbuse,
+	})
+	v.SetDefault("captcha.abuse.requests", 5)
+	v.SetDefault("captcha.abuse.window", "1m")
+	v.SetDefault("captcha.key_prefix", "apigw:captcha:")
+
+	// Request signing defaults
+	v.SetDefault("signing.enabled", false)
+	v.SetDefault("signing.path_prefixes", []string{})
+	v.SetDefault("signing.max_skew", "5m")
+	v.SetDefault("signing.key_prefix", "apigw:signing:")
+	v.SetDefault("signing.key_cache_ttl", "1m")
+
+	// Tenancy defaults
+	v.SetDefault("tenants.enabled", false)
+	v.SetDefault("tenants.header", "X-Tenant-ID")
+	v.SetDefault("tenants.base_domains", []string{})
+	v.SetDefault("tenants.default", "")
+	v.SetDefault("fingerprint.enabled", false)
+	v.SetDefault("fingerprint.tls_header", "")
+	v.SetDefault("regions.enabled", false)
+	v.SetDefault("regions.header", "X-Region")
+	v.SetDefault("regions.country_header", "")
+	v.SetDefault("regions.default", "")
+
+	// Waiting room defaults
+	v.SetDefault("waiting_room.enabled", true)
+	v.SetDefault("waiting_room.key_prefix", "apigw:waitingroom:")
+	v.SetDefault("waiting_room.default_rate", 50)
+	v.SetDefault("waiting_room.pass_ttl", "10m")
+	v.SetDefault("waiting_room.pass_uses", 1)
+	v.SetDefault("waiting_room.token_ttl", "6h")
+	v.SetDefault("waiting_room.poll_interval", "5s")
+	v.SetDefault("on_sale.enabled", false)
+	v.SetDefault("on_sale.timezone", "UTC")
+	v.SetDefault("quotas.enabled", false)
+	v.SetDefault("quotas.key_prefix", "apigw:quota:")
+	v.SetDefault("quotas.default.daily", 10000)
+	v.SetDefault("quotas.default.monthly", 250000)
+	v.SetDefault("quotas.anonymous.daily", 1000)
+	v.SetDefault("quotas.anonymous.monthly", 0)
+	v.SetDefault("load_shedding.enabled", false)
+	v.SetDefault("load_shedding.max_in_flight", 1000)
+	v.SetDefault("load_shedding.default_class", "standard")
+	v.SetDefault("load_shedding.retry_after", "2s")
+	// Checkout is served up to full load, browsing is the first to go
+	v.SetDefault("load_shedding.classes", []map[string]interface{}{
+		{"name": "checkout", "limit": 100, "routes": []string{
+			"POST /api/v1/orders/purchase",
+			"POST /api/v1/orders/purchase-batch",
+			"POST /api/v1/orders/:event_id/purchase",
+			"GET /api/v1/orders/:order_id/status",
+			"POST /api/v1/payments",
+			"POST /api/v1/payments/:payment_id/confirm",
+			"POST /api/v2/orders/purchase",
+			"POST /api/v2/orders/purchase-batch",
+			"POST /api/v2/orders/:event_id/purchase",
+			"GET /api/v2/orders/:order_id/status",
+			"POST /api/v2/payments",
+			"POST /api/v2/payments/:payment_id/confirm",
+		}},
+		{"name": "standard", "limit": 90},
+		{"name": "browsing", "limit": 70, "routes": []string{
+			"GET /api/v1/events",
+			"GET /api/v1/events/:event_id",
+			"GET /api/v1/events/:event_id/seats",
+			"GET /api/v1/me/dashboard",
+			"GET /api/v2/events",
+			"GET /api/v2/events/:event_id",
+			"GET /api/v2/events/:event_id/seats",
+		}},
+	})
+
+	// Order defaults
+	v.SetDefault("dashboard.recent_orders", 5)
+	v.SetDefault("dashboard.upcoming_events", 5)
+	v.SetDefault("dashboard.section_timeout", "2s")
+
+	// Avatar upload defaults
+	v.SetDefault("avatar.max_bytes", 2<<20)
+	v.SetDefault("avatar.content_types", []string{"image/jpeg", "image/png", "image/webp"})
+	v.SetDefault("avatar.chunk_size", 64<<10)
+
+	v.SetDefault("orders.batch_purchase.max_items", 20)
+	v.SetDefault("orders.batch_purchase.concurrency", 4)
+	v.SetDefault("orders.async_purchase.enabled", false)
+	v.SetDefault("orders.async_purchase.mode", AsyncPurchasePrefer)
+	v.SetDefault("orders.async_purchase.key_prefix", "apigw:purchases:")
+	v.SetDefault("orders.async_purchase.max_pending", 100000)
+	v.SetDefault("orders.async_purchase.workers", 16)
+	v.SetDefault("orders.async_purchase.attempts", 3)
+	v.SetDefault("orders.async_purchase.retry_backoff", "1s")
+	v.SetDefault("orders.async_purchase.call_timeout", "10s")
+	v.SetDefault("orders.async_purchase.claim_idle", "1m")
+	v.SetDefault("orders.async_purchase.result_ttl", "24h")
+	v.SetDefault("orders.async_purchase.poll_after", "2s")
+
+	// Webhook defaults
+	v.SetDefault("kafka.enabled", false)
+	v.SetDefault("kafka.brokers", []string{"localhost:9092"})
+	v.SetDefault("kafka.group_id", "apigw")
+	v.SetDefault("kafka.inventory_topics", []string{"inventory.changes"})
+	v.SetDefault("kafka.event_topics", []string{"events.updates"})
+	v.SetDefault("kafka.start_offset", KafkaOffsetLatest)
+	v.SetDefault("kafka.max_wait", "1s")
+	v.SetDefault("kafka.commit_interval", "1s")
+	v.SetDefault("kafka.dial_timeout", "10s")
+	v.SetDefault("kafka.tls.enabled", false)
+	v.SetDefault("kafka.tls.insecure_skip_verify", false)
+	v.SetDefault("kafka.sasl.mechanism", "")
+
+	v.SetDefault("webhooks.enabled", false)
+	v.SetDefault("webhooks.key_prefix", "apigw:webhooks:")
+	v.SetDefault("webhooks.max_endpoints", 10)
+	v.SetDefault("webhooks.workers", 8)
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("webhooks.max_attempts", 8)
+	v.SetDefault("webhooks.initial_backoff", "10s")
+	v.SetDefault("webhooks.max_backoff", "1h")
+	v.SetDefault("webhooks.poll_interval", "1s")
+	v.SetDefault("webhooks.delivery_ttl", "72h")
+	v.SetDefault("webhooks.allow_http", false)
+	v.SetDefault("webhooks.allow_private_networks", false)
+
+	// API version defaults
+	v.SetDefault("api.versions.v1.enabled", true)
+	v.SetDefault("api.versions.v2.enabled", true)
+	v.SetDefault("api.versions.v1.envelope", false)
+	v.SetDefault("api.versions.v2.envelope", false)
+
+	// API documentation defaults
+	v.SetDefault("docs.enabled", true)
+	v.SetDefault("docs.swagger_ui_url", "https://unpkg.com/swagger-ui-dist@5")
+
+	// Fault injection defaults
+	v.SetDefault("faults.enabled", false)
+
+	// Dev stub defaults
+	v.SetDefault("dev_stub.enabled", false)
+	v.SetDefault("dev_stub.fixtures", []string{})
+	v.SetDefault("dev_stub.role", "")
+
+	// Traffic recording defaults
+	v.SetDefault("recording.enabled", false)
+	v.SetDefault("recording.directory", "recordings")
+	v.SetDefault("recording.percentage", 100)
+	v.SetDefault("recording.routes", []string{})
+	v.SetDefault("recording.max_body_bytes", 65536)
+	v.SetDefault("recording.redact_headers", []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Signature", "X-Captcha-Token"})
+	v.SetDefault("recording.redact_fields", []string{"password", "token", "secret", "card", "cvv", "email", "phone"})
+	v.SetDefault("recording.buffer", 1000)
+
+	// Error reporting defaults
+	v.SetDefault("observability.sentry.enabled", false)
+	v.SetDefault("observability.sentry.dsn", "")
+	v.SetDefault("observability.sentry.environment", "")
+	v.SetDefault("observability.sentry.release", "")
+	v.SetDefault("observability.sentry.sample_rate", 1.0)
+	v.SetDefault("observability.sentry.upstream_events", true)
+	v.SetDefault("observability.sentry.user_hash_key", "")
+	v.SetDefault("observability.sentry.timeout", "5s")
+	v.SetDefault("observability.sentry.buffer", 100)
+
 	// Service defaults
 	v.SetDefault("services.user_service.name", "user-service")
 	v.SetDefault("services.user_service.host", "localhost")
 	v.SetDefault("services.user_service.port", 50051)
+	v.SetDefault("services.user_service.load_balancing", LoadBalancingRoundRobin)
 	v.SetDefault("services.user_service.grpc.keepalive_time", "30s")
 	v.SetDefault("services.user_service.grpc.keepalive_timeout", "5s")
 	v.SetDefault("services.user_service.grpc.keepalive_permit_without_stream", true)
+	v.SetDefault("services.user_service.grpc.wait_for_ready", false)
+	v.SetDefault("services.user_service.grpc.max_recv_msg_size", 4<<20)
+	v.SetDefault("services.user_service.grpc.max_send_msg_size", 4<<20)
+	v.SetDefault("services.user_service.grpc.compression", CompressionNone)
+	v.SetDefault("services.user_service.shadow.enabled", false)
+	v.SetDefault("services.user_service.shadow.percentage", 0)
+	v.SetDefault("services.user_service.shadow.timeout", "5s")
+	v.SetDefault("services.user_service.tls.enabled", false)
 
 	v.SetDefault("services.order_service.name", "order-service")
 	v.SetDefault("services.order_service.host", "localhost")
 	v.SetDefault("services.order_service.port", 50052)
+	v.SetDefault("services.order_service.load_balancing", LoadBalancingRoundRobin)
 	v.SetDefault("services.order_service.grpc.keepalive_time", "30s")
 	v.SetDefault("services.order_service.grpc.keepalive_timeout", "5s")
 	v.SetDefault("services.order_service.grpc.keepalive_permit_without_stream", true)
-}
+	v.SetDefault("services.order_service.grpc.wait_for_ready", false)
+	v.SetDefault("services.order_service.grpc.max_recv_msg_size", 4<<20)
+	v.SetDefault("services.order_service.grpc.max_send_msg_size", 4<<20)
+	v.SetDefault("services.order_service.grpc.compression", CompressionNone)
+	v.SetDefault("services.order_service.shadow.enabled", false)
+	v.SetDefault("services.order_service.shadow.percentage", 0)
+	v.SetDefault("services.order_service.shadow.timeout", "5s")
+	v.SetDefault("services.order_service.tls.enabled", false)
 
-// Validate validates the configuration
-func (c *Config) Validate() error {
-	if c.App.Name == "" {
-		return fmt.Errorf("app name is required")
-	}
-
-	if c.Server.HTTP.Port <= 0 || c.Server.HTTP.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", c.Server.HTTP.Port)
-	}
-
-	if c.Server.HTTP.ReadTimeout <= 0 {
-		return fmt.Errorf("read timeout must be positive")
-	}
-
-	if c.Server.HTTP.WriteTimeout <= 0 {
-		return fmt.Errorf("write timeout must be positive")
-	}
-
-	if c.JWT.SecretKey == "" {
-		return fmt.Errorf("JWT secret key must be set")
-	}
-
-	if c.Services.UserService.Host == "" {
-		return fmt.Errorf("user service host is required")
-	}
-
-	if c.Services.OrderService.Host == "" {
-		return fmt.Errorf("order service host is required")
-	}
+	v.SetDefault("services.event_service.name", "event-service")
+	v.SetDefault("services.event_service.host", "localhost")
+	v.SetDefault("services.event_service.port", 50053)
+	v.SetDefault("services.event_service.load_balancing", LoadBalancingRoundRobin)
+	v.SetDefault("services.event_service.grpc.keepalive_time", "30s")
+	v.SetDefault("services.event_service.grpc.keepalive_timeout", "5s")
+	v.SetDefault("services.event_service.grpc.keepalive_permit_without_stream", true)
+	v.SetDefault("services.event_service.grpc.wait_for_ready", false)
+	v.SetDefault("services.event_service.grpc.max_recv_msg_size", 16<<20)
+	v.SetDefault("services.event_service.grpc.max_send_msg_size", 4<<20)
+	v.SetDefault("services.event_service.grpc.compression", CompressionNone)
+	v.SetDefault("services.event_service.shadow.enabled", false)
+	v.SetDefault("services.event_service.shadow.percentage", 0)
+	v.SetDefault("services.event_service.shadow.timeout", "5s")
+	v.SetDefault("services.event_service.tls.enabled", false)
 
-	return nil
+	v.SetDefault("services.payment_service.name", "payment-service")
+	v.SetDefault("services.payment_service.host", "localhost")
+	v.SetDefault("services.payment_service.port", 50054)
+	v.SetDefault("services.payment_service.load_balancing", LoadBalancingRoundRobin)
+	v.SetDefault("services.payment_service.grpc.keepalive_time", "30s")
+	v.SetDefault("services.payment_service.grpc.keepalive_timeout", "5s")
+	v.SetDefault("services.payment_service.grpc.keepalive_permit_without_stream", true)
+	v.SetDefault("services.payment_service.grpc.wait_for_ready", false)
+	v.SetDefault("services.payment_service.grpc.max_recv_msg_size", 4<<20)
+	v.SetDefault("services.payment_service.grpc.max_send_msg_size", 4<<20)
+	v.SetDefault("services.payment_service.grpc.compression", CompressionNone)
+	v.SetDefault("services.payment_service.shadow.enabled", false)
+	v.SetDefault("services.payment_service.shadow.percentage", 0)
+	v.SetDefault("services.payment_service.shadow.timeout", "5s")
+	v.SetDefault("services.payment_service.tls.enabled", false)
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces secret values in configuration dumps
+const redactedValue = "[REDACTED]"
+
+// Redacted returns the effective configuration keyed by setting names, with every
+// field tagged `secret:"true"` replaced so the dump can be shown to operators
+func (c *Config) Redacted() map[string]interface{} {
+	return dumpValue(reflect.ValueOf(*c)).(map[string]interface{})
+}
+
+// dumpValue converts a configuration value into plain maps, slices and scalars
+func dumpValue(v reflect.Value) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return v.Interface().(time.Duration).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" {
+				if !v.Field(i).IsZero() {
+					out[name] = redactedValue
+				} else {
+					out[name] = ""
+				}
+				continue
+			}
+			out[name] = dumpValue(v.Field(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = dumpValue(iter.Value())
+		}
+		return out
+	case reflect.Slice:
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = dumpValue(v.Index(i))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
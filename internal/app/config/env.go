@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of environment variables overriding settings
+const EnvPrefix = "APIGW"
+
+// DefaultConfigPath is loaded when no path is given and the file exists
+const DefaultConfigPath = "config.yaml"
+
+// envAliases lists additional environment variables accepted for a setting
+var envAliases = map[string][]string{
+	"app.environment": {"APP_ENV"},
+}
+
+// ResolvePath returns the configuration file to load: the --config flag value, then
+// APIGW_CONFIG, then config.yaml when it exists. An empty result means the
+// configuration comes from defaults and environment variables only.
+func ResolvePath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if path := os.Getenv(EnvPrefix + "_CONFIG"); path != "" {
+		return path
+	}
+	if _, err := os.Stat(DefaultConfigPath); err == nil {
+		return DefaultConfigPath
+	}
+	return ""
+}
+
+// EnvVarName returns the prefixed environment variable for a setting key,
+// e.g. server.http.port -> APIGW_SERVER_HTTP_PORT
+func EnvVarName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// EnvVars lists the prefixed environment variables of every setting that can be
+// set from the environment, sorted by name
+func EnvVars() []string {
+	keys := settingKeys(reflect.TypeOf(Config{}), "")
+	vars := make([]string, 0, len(keys))
+	for _, key := range keys {
+		vars = append(vars, EnvVarName(key))
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// bindEnv binds each key to its prefixed and unprefixed environment variables,
+// the prefixed ones taking precedence
+func bindEnv(v *viper.Viper, keys []string) error {
+	for _, key := range keys {
+		names := []string{EnvVarName(key)}
+		for _, alias := range envAliases[key] {
+			names = append(names, EnvPrefix+"_"+alias)
+		}
+		names = append(names, strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+		names = append(names, envAliases[key]...)
+
+		if err := v.BindEnv(append([]string{key}, names...)...); err != nil {
+			return fmt.Errorf("failed to bind environment for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// settingKeys returns the dotted keys of the scalar settings of a config struct.
+// Lists of scalars are included (comma separated in the environment); lists of
+// structs and maps are only configurable through files.
+func settingKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		ft := field.Type
+		switch {
+		case ft == reflect.TypeOf(time.Duration(0)):
+			keys = append(keys, key)
+		case ft.Kind() == reflect.Struct:
+			keys = append(keys, settingKeys(ft, key)...)
+		case ft.Kind() == reflect.Map:
+			continue
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			continue
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
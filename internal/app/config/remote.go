@@ -0,0 +1,376 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Remote config providers
+const (
+	RemoteProviderEtcd   = "etcd"
+	RemoteProviderConsul = "consul"
+)
+
+// remoteRetryDelay is the pause before re-establishing a failed watch
+const remoteRetryDelay = 5 * time.Second
+
+// remoteMaxRetryDelay caps the pauses between the first fetches of a watch, doubled after
+// every failure
+const remoteMaxRetryDelay = time.Minute
+
+// consulWaitTime bounds a single Consul blocking query
+const consulWaitTime = 5 * time.Minute
+
+// RemoteConfig represents a remote configuration backend shared by all gateway replicas.
+// The remote document is YAML and is merged on top of the local files.
+type RemoteConfig struct {
+	Provider string        `mapstructure:"provider"`               // etcd or consul; empty disables remote config
+	Endpoint string        `mapstructure:"endpoint"`               // e.g. http://consul:8500 or http://etcd:2379
+	Key      string        `mapstructure:"key"`                    // Key holding the YAML document
+	Token    string        `mapstructure:"token" secret:"true"`    // Consul ACL token
+	Username string        `mapstructure:"username"`               // etcd user
+	Password string        `mapstructure:"password" secret:"true"` // etcd password
+	Timeout  time.Duration `mapstructure:"timeout"`                // Timeout for a single fetch
+}
+
+// Enabled reports whether a remote backend is configured
+func (r RemoteConfig) Enabled() bool {
+	return r.Provider != ""
+}
+
+// Location returns a printable identifier of the remote document
+func (r RemoteConfig) Location() string {
+	return r.Provider + "://" + strings.TrimPrefix(strings.TrimPrefix(r.Endpoint, "http://"), "https://") + "/" + strings.TrimPrefix(r.Key, "/")
+}
+
+// remoteSource fetches and watches a remote configuration document
+type remoteSource interface {
+	// Fetch returns the current document
+	Fetch(ctx context.Context) ([]byte, error)
+	// Watch calls onChange whenever the document changes until the context is cancelled
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// newRemoteSource creates the source for the configured provider
+func newRemoteSource(cfg RemoteConfig) (remoteSource, error) {
+	client := &http.Client{}
+	switch cfg.Provider {
+	case RemoteProviderConsul:
+		return &consulSource{cfg: cfg, client: client}, nil
+	case RemoteProviderEtcd:
+		return &etcdSource{cfg: cfg, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported remote config provider %q", cfg.Provider)
+	}
+}
+
+// fetchRemote fetches the remote document with the configured timeout
+func fetchRemote(source remoteSource, cfg RemoteConfig) ([]byte, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return source.Fetch(ctx)
+}
+
+// consulSource reads the document from the Consul KV store
+type consulSource struct {
+	cfg    RemoteConfig
+	client *http.Client
+}
+
+// get reads the key, blocking until it changes past index when index is positive
+func (s *consulSource) get(ctx context.Context, index uint64) ([]byte, uint64, error) {
+	query := url.Values{"raw": {""}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", consulWaitTime.String())
+	}
+	endpoint := strings.TrimSuffix(s.cfg.Endpoint, "/") + "/v1/kv/" + strings.TrimPrefix(s.cfg.Key, "/") + "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, fmt.Errorf("consul key %q not found", s.cfg.Key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("consul returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read consul response: %w", err)
+	}
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	return body, newIndex, nil
+}
+
+// Fetch implements remoteSource
+func (s *consulSource) Fetch(ctx context.Context) ([]byte, error) {
+	body, _, err := s.get(ctx, 0)
+	return body, err
+}
+
+// Watch implements remoteSource using Consul blocking queries
+func (s *consulSource) Watch(ctx context.Context, onChange func()) error {
+	var body []byte
+	var index uint64
+	missed, err := retryRemote(ctx, func() (err error) {
+		body, index, err = s.get(ctx, 0)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if missed {
+		onChange()
+	}
+	sum := sha256.Sum256(body)
+
+	for ctx.Err() == nil {
+		body, newIndex, err := s.get(ctx, index)
+		if err != nil {
+			if !sleepContext(ctx, remoteRetryDelay) {
+				break
+			}
+			continue
+		}
+		// Consul may reset the index, in which case the watch starts over
+		if newIndex < index {
+			newIndex = 0
+		}
+		index = newIndex
+		// Blocking queries also return on timeouts and index resets, with the same document
+		if newSum := sha256.Sum256(body); newSum != sum {
+			sum = newSum
+			onChange()
+		}
+	}
+	return ctx.Err()
+}
+
+// etcdSource reads the document from etcd through its v3 JSON gateway
+type etcdSource struct {
+	cfg    RemoteConfig
+	client *http.Client
+}
+
+// etcdKeyValue is a key-value pair in etcd gateway responses
+type etcdKeyValue struct {
+	Value       string `json:"value"`
+	ModRevision string `json:"mod_revision"`
+}
+
+// post sends a JSON request to the etcd gateway, authenticating first when credentials are set
+func (s *etcdSource) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.cfg.Endpoint, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.cfg.Username != "" {
+		token, err := s.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("etcd request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("etcd returned status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// authenticate exchanges the configured credentials for an etcd auth token
+func (s *etcdSource) authenticate(ctx context.Context) (string, error) {
+	payload, _ := json.Marshal(map[string]string{"name": s.cfg.Username, "password": s.cfg.Password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.cfg.Endpoint, "/")+"/v3/auth/authenticate", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("etcd authentication failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("etcd authentication returned status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode etcd auth response: %w", err)
+	}
+	return out.Token, nil
+}
+
+// get reads the key and returns its value and modification revision
+func (s *etcdSource) get(ctx context.Context) ([]byte, int64, error) {
+	resp, err := s.post(ctx, "/v3/kv/range", map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(s.cfg.Key)),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Kvs []etcdKeyValue `json:"kvs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	if len(out.Kvs) == 0 {
+		return nil, 0, fmt.Errorf("etcd key %q not found", s.cfg.Key)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(out.Kvs[0].Value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode etcd value: %w", err)
+	}
+	revision, _ := strconv.ParseInt(out.Kvs[0].ModRevision, 10, 64)
+	return value, revision, nil
+}
+
+// Fetch implements remoteSource
+func (s *etcdSource) Fetch(ctx context.Context) ([]byte, error) {
+	value, _, err := s.get(ctx)
+	return value, err
+}
+
+// Watch implements remoteSource using the etcd watch stream
+func (s *etcdSource) Watch(ctx context.Context, onChange func()) error {
+	var revision int64
+	missed, err := retryRemote(ctx, func() (err error) {
+		_, revision, err = s.get(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if missed {
+		onChange()
+	}
+
+	for ctx.Err() == nil {
+		revision, err = s.watchOnce(ctx, revision+1, onChange)
+		if err != nil && !sleepContext(ctx, remoteRetryDelay) {
+			break
+		}
+	}
+	return ctx.Err()
+}
+
+// watchOnce streams watch events from startRevision until the stream ends,
+// returning the last revision seen so the next stream resumes after it
+func (s *etcdSource) watchOnce(ctx context.Context, startRevision int64, onChange func()) (int64, error) {
+	lastRevision := startRevision - 1
+	resp, err := s.post(ctx, "/v3/watch", map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            base64.StdEncoding.EncodeToString([]byte(s.cfg.Key)),
+			"start_revision": strconv.FormatInt(startRevision, 10),
+		},
+	})
+	if err != nil {
+		return lastRevision, err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var msg struct {
+			Result struct {
+				Events []struct {
+					Kv etcdKeyValue `json:"kv"`
+				} `json:"events"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if len(msg.Result.Events) == 0 {
+			continue
+		}
+		for _, event := range msg.Result.Events {
+			if rev, err := strconv.ParseInt(event.Kv.ModRevision, 10, 64); err == nil && rev > lastRevision {
+				lastRevision = rev
+			}
+		}
+		onChange()
+	}
+	if err := scanner.Err(); err != nil {
+		return lastRevision, err
+	}
+	return lastRevision, errors.New("etcd watch stream closed")
+}
+
+// retryRemote calls fetch until it succeeds, pausing between failures from
+// remoteRetryDelay up to remoteMaxRetryDelay, and reports whether it failed first: the
+// document may then have changed since the configuration was loaded
+func retryRemote(ctx context.Context, fetch func() error) (bool, error) {
+	delay := remoteRetryDelay
+	for failed := false; ; failed = true {
+		if err := fetch(); err == nil {
+			return failed, nil
+		}
+		if !sleepContext(ctx, delay) {
+			return failed, ctx.Err()
+		}
+		delay = min(2*delay, remoteMaxRetryDelay)
+	}
+}
+
+// sleepContext waits for the duration, returning false if the context is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
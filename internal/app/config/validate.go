@@ -0,0 +1,1604 @@
+package config
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apigw/pkg/utils/cron"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// minKeepaliveTime is the smallest client keepalive interval gRPC honours
+const minKeepaliveTime = 10 * time.Second
+
+// minJWTSecretLength matches the key size required by the token maker
+const minJWTSecretLength = 32
+
+// minAdminPasswordLength is the shortest basic auth password accepted for the admin listener
+const minAdminPasswordLength = 16
+
+// FieldError describes a single invalid configuration setting
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every problem found in a configuration
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration problem(s):", len(e.Errors))
+	for _, fe := range e.Errors {
+		fmt.Fprintf(&b, "\n  - %s: %s", fe.Field, fe.Message)
+	}
+	return b.String()
+}
+
+// add records a problem with a setting
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate validates every section of the configuration and returns a
+// *ValidationError listing all problems found, or nil when the configuration is valid
+func (c *Config) Validate() error {
+	report := &ValidationError{}
+
+	for _, key := range c.unknownKeys {
+		report.add(key, "unknown setting")
+	}
+
+	// App
+	if c.App.Name == "" {
+		report.add("app.name", "is required")
+	}
+	if c.App.Environment == "" {
+		report.add("app.environment", "is required")
+	}
+
+	// Server
+	http := c.Server.HTTP
+	validatePort(report, "server.http.port", http.Port)
+	validatePositive(report, "server.http.read_timeout", http.ReadTimeout)
+	validatePositive(report, "server.http.write_timeout", http.WriteTimeout)
+	validateNonNegative(report, "server.http.idle_timeout", http.IdleTimeout)
+	validatePositive(report, "server.http.graceful_shutdown_timeout", http.GracefulShutdownTimeout)
+	validateNonNegative(report, "server.http.drain_delay", http.DrainDelay)
+	validateNonNegative(report, "server.http.request_timeout", http.RequestTimeout)
+	validateNonNegative(report, "server.http.read_header_timeout", http.ReadHeaderTimeout)
+	if http.MaxHeaderBytes < 0 {
+		report.add("server.http.max_header_bytes", "must not be negative")
+	}
+	if http.HTTP2.MaxConcurrentStreams < 0 {
+		report.add("server.http.http2.max_concurrent_streams", "must not be negative")
+	}
+	validateCallBudget(report, http)
+	validateRequestBody(report, http.RequestBody)
+	validateNetworks(report, "server.http.trusted_proxies", http.TrustedProxies)
+	for i, header := range http.ClientIPHeaders {
+		if header == "" || strings.ContainsAny(header, " \t:\r\n") {
+			report.add(fmt.Sprintf("server.http.client_ip_headers[%d]", i), "%q is not a header name", header)
+		}
+	}
+	validateListeners(report, http)
+	validateAdmin(report, c.Server.Admin)
+	validateGRPCServer(report, c.Server.GRPC)
+	if c.Server.Recovery.AlertWebhook != "" {
+		if u, err := url.Parse(c.Server.Recovery.AlertWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			report.add("server.recovery.alert_webhook", "must be an http or https URL")
+		}
+		validatePositive(report, "server.recovery.alert_timeout", c.Server.Recovery.AlertTimeout)
+		validateNonNegative(report, "server.recovery.alert_interval", c.Server.Recovery.AlertInterval)
+	}
+	if c.Server.Upgrade.Enabled {
+		validatePositive(report, "server.upgrade.ready_timeout", c.Server.Upgrade.ReadyTimeout)
+	}
+
+	// Startup
+	switch c.Startup.Mode {
+	case StartupFail, StartupRetry, StartupDegraded:
+	default:
+		report.add("startup.mode", "must be %q, %q or %q", StartupFail, StartupRetry, StartupDegraded)
+	}
+	if c.Startup.Mode != StartupFail {
+		validatePositive(report, "startup.timeout", c.Startup.Timeout)
+		validatePositive(report, "startup.initial_backoff", c.Startup.InitialBackoff)
+		if c.Startup.MaxBackoff < c.Startup.InitialBackoff {
+			report.add("startup.max_backoff", "must be at least startup.initial_backoff")
+		}
+	}
+
+	// JWT
+	if c.JWT.SecretKey == "" {
+		report.add("jwt.secret_key", "is required")
+	} else if len(c.JWT.SecretKey) < minJWTSecretLength {
+		report.add("jwt.secret_key", "must be at least %d characters", minJWTSecretLength)
+	}
+
+	// Logging
+	if c.Log.Level != "" {
+		if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+			report.add("log.level", "%v", err)
+		}
+	}
+	for _, module := range sortedKeys(c.Log.Modules) {
+		field := "log.modules." + module
+		if !logutils.KnownModule(module) {
+			report.add(field, "is not a module, expected one of %s", strings.Join(logutils.Modules, ", "))
+		} else if _, err := logrus.ParseLevel(c.Log.Modules[module]); err != nil {
+			report.add(field, "%v", err)
+		}
+	}
+	if p := c.Log.GRPCCalls.PayloadSamplePercentage; p < 0 || p > 100 {
+		report.add("log.grpc_calls.payload_sample_percentage", "must be between 0 and 100")
+	}
+	validateLogOutput(report, c.Log)
+	if c.SlowRequests.Enabled {
+		validateSlowRequests(report, c.SlowRequests)
+	}
+	if c.Log.Bodies.Enabled {
+		validateBodyLog(report, c.Log.Bodies)
+	}
+
+	// Services
+	services := c.Services.All()
+	for _, name := range c.Services.Names() {
+		validateService(report, "services."+name, services[name], c.Discovery)
+	}
+
+	// Redis
+	if c.Redis.Enabled {
+		validateRedis(report, c.Redis)
+		validateTokenBucket(report, "redis.token_bucket", c.Redis.TokenBucket)
+		for _, name := range sortedKeys(c.Redis.Policies) {
+			validateTokenBucket(report, "redis.policies."+name, c.Redis.Policies[name])
+		}
+	}
+
+	// Response cache
+	if c.Cache.Enabled {
+		validateCache(report, c.Cache, c.Redis.Enabled)
+	}
+
+	// Request coalescing
+	for i, route := range c.Coalescing.Routes {
+		if !strings.HasPrefix(route, "/") {
+			report.add(fmt.Sprintf("coalescing.routes[%d]", i), "must be a route pattern starting with /")
+		}
+	}
+
+	// XML content negotiation
+	if c.XML.Enabled {
+		validateXML(report, c.XML)
+	}
+
+	// Error responses
+	if c.Errors.Format != ErrorFormatJSON && c.Errors.Format != ErrorFormatProblem {
+		report.add("errors.format", "must be %q or %q", ErrorFormatJSON, ErrorFormatProblem)
+	}
+	if c.Errors.ProblemTypeBase != "" {
+		if u, err := url.Parse(c.Errors.ProblemTypeBase); err != nil || !u.IsAbs() {
+			report.add("errors.problem_type_base", "must be an absolute URI")
+		}
+	}
+
+	// Request validation
+	validateValidation(report, c.Validation)
+
+	// API documentation
+	if c.Docs.Enabled {
+		if u, err := url.Parse(c.Docs.SwaggerUIURL); err != nil || !u.IsAbs() {
+			report.add("docs.swagger_ui_url", "must be an absolute URL")
+		}
+	}
+
+	// Fault injection
+	if c.Faults.Enabled {
+		validateFaults(report, c.Faults, c.App.Environment)
+	}
+
+	// Dev stub mode
+	if c.DevStub.Enabled {
+		if c.App.Environment == "production" {
+			report.add("dev_stub.enabled", "must not be enabled in production")
+		}
+		for i, file := range c.DevStub.Fixtures {
+			if _, err := os.Stat(file); err != nil {
+				report.add(fmt.Sprintf("dev_stub.fixtures[%d]", i), "cannot read %q: %v", file, err)
+			}
+		}
+	}
+
+	// Traffic recording
+	if c.Recording.Enabled {
+		validateRecording(report, c.Recording)
+	}
+
+	// Error reporting
+	if c.Observability.Sentry.Enabled {
+		validateSentry(report, c.Observability.Sentry)
+	}
+
+	// Network ACLs
+	if c.ACL.Enabled {
+		validateACL(report, c.ACL)
+	}
+
+	// Brute-force protection
+	if c.BruteForce.Enabled {
+		validateBruteForce(report, c.BruteForce)
+	}
+
+	// CAPTCHA
+	if c.Captcha.Enabled {
+		validateCaptcha(report, c.Captcha)
+	}
+
+	// Request signing
+	if c.Signing.Enabled {
+		validateSigning(report, c.Signing)
+	}
+
+	// Client fingerprints
+	if c.Fingerprint.Enabled {
+		validateFingerprint(report, c.Fingerprint, c.Redis.Enabled)
+	}
+
+	// Tenancy
+	if c.Tenants.Enabled {
+		validateTenants(report, c)
+	}
+
+	// Regional routing
+	if c.Regions.Enabled {
+		validateRegions(report, c)
+	}
+
+	// Waiting room
+	if c.WaitingRoom.Enabled {
+		if c.WaitingRoom.DefaultRate < 1 {
+			report.add("waiting_room.default_rate", "must be at least 1")
+		}
+		validatePositive(report, "waiting_room.pass_ttl", c.WaitingRoom.PassTTL)
+		if c.WaitingRoom.PassUses < 1 {
+			report.add("waiting_room.pass_uses", "must be at least 1")
+		}
+		validatePositive(report, "waiting_room.poll_interval", c.WaitingRoom.PollInterval)
+		if c.WaitingRoom.TokenTTL < c.WaitingRoom.PassTTL {
+			report.add("waiting_room.token_ttl", "must be at least waiting_room.pass_ttl")
+		}
+	}
+
+	// On-sale profiles
+	if c.OnSale.Enabled {
+		validateOnSale(report, c)
+	}
+
+	// Quotas
+	if c.Quotas.Enabled {
+		validateQuotas(report, c.Quotas, c.Redis.Enabled)
+	}
+
+	// Load shedding
+	if c.LoadShedding.Enabled {
+		validateLoadShedding(report, c.LoadShedding)
+	}
+
+	// Webhooks
+	if c.Webhooks.Enabled {
+		validateWebhooks(report, c.Webhooks, c.Redis.Enabled, c.Signing)
+	}
+	if c.Kafka.Enabled {
+		validateKafka(report, c.Kafka)
+	}
+
+	// Orders
+	if c.Orders.BatchPurchase.MaxItems < 1 {
+		report.add("orders.batch_purchase.max_items", "must be at least 1")
+	}
+	if c.Orders.BatchPurchase.Concurrency < 1 {
+		report.add("orders.batch_purchase.concurrency", "must be at least 1")
+	}
+	if c.Orders.AsyncPurchase.Enabled {
+		validateAsyncPurchase(report, c.Orders.AsyncPurchase, c.Redis.Enabled)
+	}
+
+	// Dashboard
+	if c.Dashboard.RecentOrders < 1 || c.Dashboard.RecentOrders > 100 {
+		report.add("dashboard.recent_orders", "must be between 1 and 100")
+	}
+	if c.Dashboard.UpcomingEvents < 1 || c.Dashboard.UpcomingEvents > 100 {
+		report.add("dashboard.upcoming_events", "must be between 1 and 100")
+	}
+	validatePositive(report, "dashboard.section_timeout", c.Dashboard.SectionTimeout)
+
+	// Avatar uploads
+	validateAvatar(report, c.Avatar, c.Server.HTTP.RequestBody)
+
+	// Service discovery
+	if c.Discovery.Consul.Enabled && c.Discovery.Consul.Address == "" {
+		report.add("discovery.consul.address", "is required when consul discovery is enabled")
+	}
+	if c.Discovery.Kubernetes.Enabled && c.Discovery.Kubernetes.APIServer == "" {
+		report.add("discovery.kubernetes.api_server", "is required when kubernetes discovery is enabled")
+	}
+
+	// Remote config
+	if c.Remote.Enabled() {
+		if c.Remote.Provider != RemoteProviderEtcd && c.Remote.Provider != RemoteProviderConsul {
+			report.add("remote.provider", "must be %q or %q", RemoteProviderEtcd, RemoteProviderConsul)
+		}
+		if c.Remote.Endpoint == "" {
+			report.add("remote.endpoint", "is required when a remote provider is set")
+		}
+		if c.Remote.Key == "" {
+			report.add("remote.key", "is required when a remote provider is set")
+		}
+	}
+
+	// API versions
+	for _, name := range sortedKeys(c.API.Versions) {
+		if _, err := c.API.Versions[name].SunsetTime(); err != nil {
+			report.add("api.versions."+name+".sunset", "must be an RFC3339 date: %v", err)
+		}
+	}
+
+	// Transforms
+	for i, rule := range c.Transforms {
+		field := fmt.Sprintf("transforms[%d]", i)
+		if rule.PathPrefix == "" {
+			report.add(field+".path_prefix", "is required")
+		} else if !strings.HasPrefix(rule.PathPrefix, "/") {
+			report.add(field+".path_prefix", "must start with /")
+		}
+		if rule.RewriteRegex != "" {
+			if _, err := regexp.Compile(rule.RewriteRegex); err != nil {
+				report.add(field+".rewrite_regex", "invalid regular expression: %v", err)
+			}
+		} else if rule.RewriteReplacement != "" {
+			report.add(field+".rewrite_replacement", "requires rewrite_regex")
+		}
+	}
+
+	if len(report.Errors) == 0 {
+		return nil
+	}
+	return report
+}
+
+// validateListeners validates the listen addresses and HTTPS settings of the HTTP server
+// upstreamPattern matches a backend service or method, e.g. order.OrderService/CancelOrder
+var upstreamPattern = regexp.MustCompile(`^[A-Za-z_][\w.]*\.[A-Za-z_]\w*(/[A-Za-z_]\w*)?$`)
+
+// validateFaults checks the fault injection rules and keeps them out of production
+func validateFaults(report *ValidationError, faults FaultsConfig, environment string) {
+	if environment == "production" {
+		report.add("faults.enabled", "must not be enabled in production")
+	}
+	for i, rule := range faults.Rules {
+		field := fmt.Sprintf("faults.rules[%d]", i)
+		switch {
+		case rule.Route == "" && rule.Upstream == "":
+			report.add(field, "requires route or upstream")
+		case rule.Route != "" && rule.Upstream != "":
+			report.add(field, "must have either route or upstream, not both")
+		case rule.Route != "":
+			pattern := rule.Route
+			if method, rest, ok := strings.Cut(rule.Route, " "); ok && method != "" && method == strings.ToUpper(method) {
+				pattern = rest
+			}
+			if !strings.HasPrefix(pattern, "/") {
+				report.add(field+".route", "must be a route pattern starting with /, optionally preceded by a method")
+			}
+			if rule.Code != "" {
+				report.add(field+".code", "only applies to upstream rules; use status")
+			}
+			if rule.Status != 0 && (rule.Status < 400 || rule.Status > 599) {
+				report.add(field+".status", "must be an HTTP error status between 400 and 599")
+			}
+		default:
+			if !upstreamPattern.MatchString(rule.Upstream) {
+				report.add(field+".upstream", "must be a service or method, e.g. order.OrderService/CancelOrder")
+			}
+			if rule.Status != 0 {
+				report.add(field+".status", "only applies to route rules; use code")
+			}
+			if rule.Code != "" {
+				if code, err := rule.GRPCCode(); err != nil {
+					report.add(field+".code", "%v", err)
+				} else if code == codes.OK {
+					report.add(field+".code", "must be an error code")
+				}
+			}
+		}
+
+		if rule.Percentage <= 0 || rule.Percentage > 100 {
+			report.add(field+".percentage", "must be above 0 and at most 100")
+		}
+		validateNonNegative(report, field+".latency", rule.Latency)
+		failures := 0
+		for _, set := range []bool{rule.Status != 0, rule.Code != "", rule.Reset} {
+			if set {
+				failures++
+			}
+		}
+		if failures > 1 {
+			report.add(field, "status, code and reset are exclusive")
+		}
+		if failures == 0 && rule.Latency <= 0 {
+			report.add(field, "injects nothing; set latency, status, code or reset")
+		}
+	}
+}
+
+// validateRecording checks the traffic recorder settings
+func validateRecording(report *ValidationError, recording RecordingConfig) {
+	if recording.Directory == "" {
+		report.add("recording.directory", "is required")
+	}
+	if recording.Percentage <= 0 || recording.Percentage > 100 {
+		report.add("recording.percentage", "must be above 0 and at most 100")
+	}
+	for i, route := range recording.Routes {
+		if !strings.HasPrefix(route, "/") {
+			report.add(fmt.Sprintf("recording.routes[%d]", i), "must be a route pattern starting with /")
+		}
+	}
+	if recording.MaxBodyBytes < 0 {
+		report.add("recording.max_body_bytes", "must not be negative")
+	}
+	if recording.Buffer <= 0 {
+		report.add("recording.buffer", "must be positive")
+	}
+}
+
+// validateNetworks checks a list of CIDR ranges or addresses
+func validateNetworks(report *ValidationError, field string, networks []string) {
+	for i, network := range networks {
+		if _, err := ParseNetwork(network); err != nil {
+			report.add(fmt.Sprintf("%s[%d]", field, i), "%q is not a CIDR range or IP address", network)
+		}
+	}
+}
+
+// validateBruteForce checks the brute-force protection thresholds
+func validateBruteForce(report *ValidationError, bf BruteForceConfig) {
+	validatePositive(report, "brute_force.window", bf.Window)
+	validatePositive(report, "brute_force.lockout", bf.Lockout)
+	validatePositive(report, "brute_force.strike_ttl", bf.StrikeTTL)
+	if bf.MaxLockout < bf.Lockout {
+		report.add("brute_force.max_lockout", "must be at least brute_force.lockout")
+	}
+	for _, subject := range []struct {
+		name   string
+		limits BruteForceLimits
+	}{{"email", bf.Email}, {"ip", bf.IP}} {
+		if subject.limits.LockoutAfter < 1 {
+			report.add("brute_force."+subject.name+".lockout_after", "must be at least 1")
+		}
+		if subject.limits.CaptchaAfter < 0 {
+			report.add("brute_force."+subject.name+".captcha_after", "must not be negative")
+		}
+	}
+}
+
+// captchaActions are the actions that can require a CAPTCHA
+var captchaActions = map[string]bool{"login": true, "register": true, "password_reset": true, "purchase": true}
+
+// validateCaptcha checks the CAPTCHA provider and the actions requiring it
+func validateCaptcha(report *ValidationError, captcha CaptchaConfig) {
+	switch captcha.Provider {
+	case "recaptcha", "hcaptcha", "turnstile":
+	default:
+		report.add("captcha.provider", "must be recaptcha, hcaptcha or turnstile, got %q", captcha.Provider)
+	}
+	if captcha.SecretKey == "" {
+		report.add("captcha.secret_key", "is required when captcha is enabled")
+	}
+	if captcha.Header == "" {
+		report.add("captcha.header", "is required")
+	}
+	validatePositive(report, "captcha.timeout", captcha.Timeout)
+	if captcha.MinScore < 0 || captcha.MinScore > 1 {
+		report.add("captcha.min_score", "must be between 0 and 1")
+	}
+	for _, action := range sortedKeys(captcha.Actions) {
+		if !captchaActions[action] {
+			report.add("captcha.actions."+action, "is not an action (login, register, password_reset, purchase)")
+			continue
+		}
+		switch captcha.Actions[action] {
+		case CaptchaAlways, CaptchaOnAbuse, CaptchaOff:
+		default:
+			report.add("captcha.actions."+action, "must be always, on_abuse or off")
+		}
+	}
+	if captcha.Abuse.Requests < 0 {
+		report.add("captcha.abuse.requests", "must not be negative")
+	}
+	if captcha.Abuse.Requests > 0 {
+		validatePositive(report, "captcha.abuse.window", captcha.Abuse.Window)
+		if captcha.KeyPrefix == "" {
+			report.add("captcha.key_prefix", "is required with the abuse heuristic")
+		}
+	}
+}
+
+// minSigningSecretLength keeps partner keys at least as long as the HMAC-SHA256 block
+const minSigningSecretLength = 32
+
+// validateSigning checks the signed route groups and the partner keys
+func validateSigning(report *ValidationError, signing SigningConfig) {
+	for i, prefix := range signing.PathPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			report.add(fmt.Sprintf("signing.path_prefixes[%d]", i), "must start with /")
+		}
+	}
+	validatePositive(report, "signing.max_skew", signing.MaxSkew)
+	validatePositive(report, "signing.key_cache_ttl", signing.KeyCacheTTL)
+	seen := make(map[string]bool, len(signing.Partners))
+	for i, partner := range signing.Partners {
+		field := fmt.Sprintf("signing.partners[%d]", i)
+		switch {
+		case partner.ID == "":
+			report.add(field+".id", "is required")
+		case seen[partner.ID]:
+			report.add(field+".id", "duplicates partner %q", partner.ID)
+		}
+		seen[partner.ID] = true
+		if len(partner.Secrets) == 0 {
+			report.add(field+".secrets", "must list at least one key")
+		}
+		validateBranding(report, field+".branding", partner.Branding)
+		for j, secret := range partner.Secrets {
+			if len(secret) < minSigningSecretLength {
+				report.add(fmt.Sprintf("%s.secrets[%d]", field, j), "must be at least %d characters", minSigningSecretLength)
+			}
+		}
+	}
+}
+
+// tenantID matches tenant IDs, which are also the subdomains naming them
+var tenantID = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// validateTenants checks the tenants, their rate limits and the upstreams they override
+func validateTenants(report *ValidationError, c *Config) {
+	tenants := c.Tenants
+	if strings.ContainsAny(tenants.Header, " \t:\r\n") {
+		report.add("tenants.header", "%q is not a header name", tenants.Header)
+	}
+	for i, domain := range tenants.BaseDomains {
+		if domain == "" || strings.HasPrefix(domain, ".") || strings.ContainsAny(domain, "/: ") {
+			report.add(fmt.Sprintf("tenants.base_domains[%d]", i), "%q is not a domain name", domain)
+		}
+	}
+
+	services := c.Services.All()
+	ids := make(map[string]bool, len(tenants.List))
+	hosts := make(map[string]string)
+	for i, tenant := range tenants.List {
+		field := fmt.Sprintf("tenants.list[%d]", i)
+		switch {
+		case !tenantID.MatchString(tenant.ID):
+			report.add(field+".id", "must be a lowercase DNS label")
+		case ids[tenant.ID]:
+			report.add(field+".id", "duplicates tenant %q", tenant.ID)
+		}
+		ids[tenant.ID] = true
+		for j, host := range tenant.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := hosts[host]; ok {
+				report.add(fmt.Sprintf("%s.hosts[%d]", field, j), "%q is already a host of tenant %q", host, other)
+			}
+			hosts[host] = tenant.ID
+		}
+		if tenant.RateLimit != (TokenBucketConfig{}) {
+			if !c.Redis.Enabled {
+				report.add(field+".rate_limit", "requires redis.enabled")
+			}
+			validateTokenBucket(report, field+".rate_limit", tenant.RateLimit)
+		}
+		for _, name := range sortedKeys(tenant.Services) {
+			base, ok := services[name]
+			if !ok {
+				report.add(field+".services."+name, "is not a service under services")
+				continue
+			}
+			svc, _ := tenant.Service(name, base)
+			validateService(report, field+".services."+name, svc, c.Discovery)
+		}
+		validateBranding(report, field+".branding", tenant.Branding)
+	}
+	if tenants.Default != "" && !ids[tenants.Default] {
+		report.add("tenants.default", "%q is not a tenant of tenants.list", tenants.Default)
+	}
+}
+
+// validateLogOutput checks the backend, format, streams, rotation and sampling of logs
+func validateLogOutput(report *ValidationError, log LogConfig) {
+	switch log.Backend {
+	case "", "logrus", "slog":
+	default:
+		report.add("log.backend", "must be logrus or slog")
+	}
+	switch log.Format {
+	case "", "text", "json", "ecs":
+	default:
+		report.add("log.format", "must be text, json or ecs")
+	}
+	switch log.Output {
+	case "", "stdout", "stderr":
+	case "file":
+		if log.File.Path == "" {
+			report.add("log.file.path", "is required when log.output is file")
+		}
+	default:
+		report.add("log.output", "must be stdout, stderr or file")
+	}
+	switch log.ErrorOutput {
+	case "", "stderr":
+	case "file":
+		if log.File.ErrorPath == "" {
+			report.add("log.file.error_path", "is required when log.error_output is file")
+		} else if log.Output == "file" && filepath.Clean(log.File.ErrorPath) == filepath.Clean(log.File.Path) {
+			report.add("log.file.error_path", "must not be log.file.path")
+		}
+	default:
+		report.add("log.error_output", "must be stderr or file")
+	}
+	if log.Rotate.MaxSizeMB <= 0 {
+		report.add("log.rotate.max_size_mb", "must be positive")
+	}
+	if log.Rotate.MaxAgeDays < 0 {
+		report.add("log.rotate.max_age_days", "must not be negative")
+	}
+	if log.Rotate.MaxBackups < 0 {
+		report.add("log.rotate.max_backups", "must not be negative")
+	}
+	if log.Sampling.Enabled {
+		validatePositive(report, "log.sampling.interval", log.Sampling.Interval)
+		if log.Sampling.Initial < 0 {
+			report.add("log.sampling.initial", "must not be negative")
+		}
+		if log.Sampling.Thereafter < 1 {
+			report.add("log.sampling.thereafter", "must be at least 1")
+		}
+		if log.Sampling.RateLimit < 0 {
+			report.add("log.sampling.rate_limit", "must not be negative")
+		}
+	}
+}
+
+// brandingReservedHeaders are the response headers branding may not set, as the gateway
+// or the HTTP server owns them
+var brandingReservedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Retry-After":       true,
+	"X-Request-Id":      true,
+}
+
+// validateBranding checks the response headers and error template of a white-label
+// integration
+func validateBranding(report *ValidationError, field string, branding BrandingConfig) {
+	for _, name := range sortedKeys(branding.Headers) {
+		switch {
+		case name == "" || strings.ContainsAny(name, " \t:\r\n"):
+			report.add(field+".headers", "%q is not a header name", name)
+		case brandingReservedHeaders[http.CanonicalHeaderKey(name)]:
+			report.add(field+".headers."+name, "is set by the gateway")
+		case strings.ContainsAny(branding.Headers[name], "\r\n"):
+			report.add(field+".headers."+name, "must be a single line")
+		}
+	}
+	if _, err := branding.ParseErrorTemplate(); err != nil {
+		report.add(field+".error_template", "%v", err)
+	}
+	if branding.ErrorContentType != "" {
+		if _, _, err := mime.ParseMediaType(branding.ErrorContentType); err != nil {
+			report.add(field+".error_content_type", "%q is not a media type", branding.ErrorContentType)
+		}
+	}
+}
+
+// validateFingerprint checks the header of proxy fingerprints and the routes of the
+// fingerprint rate limit
+func validateFingerprint(report *ValidationError, fingerprint FingerprintConfig, redisEnabled bool) {
+	if strings.ContainsAny(fingerprint.TLSHeader, " \t:\r\n") {
+		report.add("fingerprint.tls_header", "%q is not a header name", fingerprint.TLSHeader)
+	}
+	if fingerprint.RateLimit == (TokenBucketConfig{}) {
+		return
+	}
+	if !redisEnabled {
+		report.add("fingerprint.rate_limit", "requires redis.enabled")
+	}
+	validateTokenBucket(report, "fingerprint.rate_limit", fingerprint.RateLimit)
+	// Everyone on one browser release shares a fingerprint, so the limit is kept to the
+	// routes abuse targets
+	if len(fingerprint.Routes) == 0 {
+		report.add("fingerprint.routes", "at least one route is required with a rate limit")
+	}
+	for i, route := range fingerprint.Routes {
+		pattern := route
+		if method, rest, ok := strings.Cut(route, " "); ok && method != "" && method == strings.ToUpper(method) {
+			pattern = rest
+		}
+		if !strings.HasPrefix(pattern, "/") {
+			report.add(fmt.Sprintf("fingerprint.routes[%d]", i), "must be a route pattern starting with /, optionally preceded by a method")
+		}
+	}
+}
+
+// validateOnSale checks the schedules of on-sale profiles and the settings they replace
+func validateOnSale(report *ValidationError, c *Config) {
+	if !c.Redis.Enabled {
+		report.add("on_sale.enabled", "requires redis.enabled")
+	}
+	if _, err := time.LoadLocation(c.OnSale.Timezone); err != nil {
+		report.add("on_sale.timezone", "unknown time zone %q", c.OnSale.Timezone)
+	}
+
+	names := make(map[string]bool, len(c.OnSale.Profiles))
+	for i, profile := range c.OnSale.Profiles {
+		field := fmt.Sprintf("on_sale.profiles[%d]", i)
+		switch {
+		case profile.Name == "":
+			report.add(field+".name", "is required")
+		case names[profile.Name]:
+			report.add(field+".name", "duplicates profile %q", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if _, err := cron.Parse(profile.Schedule); err != nil {
+			report.add(field+".schedule", "%v", err)
+		}
+		validatePositive(report, field+".duration", profile.Duration)
+		if profile.RateLimit != (TokenBucketConfig{}) {
+			validateTokenBucket(report, field+".rate_limit", profile.RateLimit)
+		}
+		for _, name := range sortedKeys(profile.Policies) {
+			if _, ok := c.Redis.Policies[name]; !ok {
+				report.add(field+".policies."+name, "names no policy under redis.policies")
+				continue
+			}
+			validateTokenBucket(report, field+".policies."+name, profile.Policies[name])
+		}
+
+		room := profile.WaitingRoom
+		if room.Rate < 0 {
+			report.add(field+".waiting_room.rate", "must not be negative")
+		}
+		if room.PassTTL < 0 {
+			report.add(field+".waiting_room.pass_ttl", "must not be negative")
+		}
+		if len(room.Events) > 0 && !c.WaitingRoom.Enabled {
+			report.add(field+".waiting_room.events", "requires waiting_room.enabled")
+		}
+		for j, eventID := range room.Events {
+			if eventID == "" {
+				report.add(fmt.Sprintf("%s.waiting_room.events[%d]", field, j), "must not be empty")
+			}
+		}
+	}
+}
+
+// validateQuotas checks the caps of clients
+func validateQuotas(report *ValidationError, quotas QuotasConfig, redisEnabled bool) {
+	if !redisEnabled {
+		report.add("quotas.enabled", "requires redis.enabled")
+	}
+	if quotas.KeyPrefix == "" {
+		report.add("quotas.key_prefix", "is required")
+	}
+	validateQuotaLimits(report, "quotas.default", quotas.Default)
+	validateQuotaLimits(report, "quotas.anonymous", quotas.Anonymous)
+
+	clients := make(map[string]bool, len(quotas.Clients))
+	for i, client := range quotas.Clients {
+		field := fmt.Sprintf("quotas.clients[%d]", i)
+		kind, id, _ := strings.Cut(client.Client, ":")
+		switch {
+		case (kind != "partner" && kind != "user" && kind != "ip") || id == "":
+			report.add(field+".client", "must be partner:<id>, user:<id> or ip:<address>")
+		case clients[client.Client]:
+			report.add(field+".client", "duplicates client %q", client.Client)
+		}
+		clients[client.Client] = true
+		validateQuotaLimits(report, field, QuotaLimitsConfig{Daily: client.Daily, Monthly: client.Monthly})
+	}
+}
+
+// validateQuotaLimits checks the caps of a client are not negative
+func validateQuotaLimits(report *ValidationError, field string, limits QuotaLimitsConfig) {
+	if limits.Daily < 0 {
+		report.add(field+".daily", "must not be negative")
+	}
+	if limits.Monthly < 0 {
+		report.add(field+".monthly", "must not be negative")
+	}
+}
+
+// validateLoadShedding checks the priority classes and that no route is in two of them
+func validateLoadShedding(report *ValidationError, shedding LoadSheddingConfig) {
+	if shedding.MaxInFlight < 1 {
+		report.add("load_shedding.max_in_flight", "must be at least 1")
+	}
+	if shedding.RetryAfter < 0 {
+		report.add("load_shedding.retry_after", "must not be negative")
+	}
+
+	names := make(map[string]bool, len(shedding.Classes))
+	routes := make(map[string]string)
+	for i, class := range shedding.Classes {
+		field := fmt.Sprintf("load_shedding.classes[%d]", i)
+		switch {
+		case class.Name == "":
+			report.add(field+".name", "is required")
+		case names[class.Name]:
+			report.add(field+".name", "duplicates class %q", class.Name)
+		}
+		names[class.Name] = true
+		if class.Limit < 1 || class.Limit > 100 {
+			report.add(field+".limit", "must be a percentage of load_shedding.max_in_flight between 1 and 100")
+		}
+		for j, route := range class.Routes {
+			pattern := route
+			if method, rest, ok := strings.Cut(route, " "); ok && method != "" && method == strings.ToUpper(method) {
+				pattern = rest
+			}
+			if !strings.HasPrefix(pattern, "/") {
+				report.add(fmt.Sprintf("%s.routes[%d]", field, j), "must be a route pattern starting with /, optionally preceded by a method")
+			}
+			if other, ok := routes[route]; ok {
+				report.add(fmt.Sprintf("%s.routes[%d]", field, j), "%q is already in class %q", route, other)
+			}
+			routes[route] = class.Name
+		}
+	}
+	if !names[shedding.DefaultClass] {
+		report.add("load_shedding.default_class", "%q is not a class of load_shedding.classes", shedding.DefaultClass)
+	}
+}
+
+// validateSlowRequests checks the thresholds of slow requests and the routes they apply to
+func validateSlowRequests(report *ValidationError, slow SlowRequestsConfig) {
+	validatePositive(report, "slow_requests.threshold", slow.Threshold)
+	routes := make(map[string]bool, len(slow.Routes))
+	for i, route := range slow.Routes {
+		field := fmt.Sprintf("slow_requests.routes[%d]", i)
+		switch {
+		case !ValidRoutePattern(route.Route):
+			report.add(field+".route", "must be a route pattern starting with /, optionally preceded by a method")
+		case routes[route.Route]:
+			report.add(field+".route", "duplicates route %q", route.Route)
+		}
+		routes[route.Route] = true
+		validatePositive(report, field+".threshold", route.Threshold)
+	}
+}
+
+// maxLoggedBodyBytes bounds log.bodies.max_bytes, so a debugging session cannot flood
+// the log pipeline
+const maxLoggedBodyBytes = 1 << 20
+
+// validateBodyLog checks the routes whose bodies are logged, the size and media types of
+// the bodies and how long routes may be enabled at runtime
+func validateBodyLog(report *ValidationError, bodies BodyLogConfig) {
+	for i, route := range bodies.Routes {
+		if !ValidRoutePattern(route) {
+			report.add(fmt.Sprintf("log.bodies.routes[%d]", i), "must be a route pattern starting with /, optionally preceded by a method")
+		}
+	}
+	if bodies.MaxBytes <= 0 || bodies.MaxBytes > maxLoggedBodyBytes {
+		report.add("log.bodies.max_bytes", "must be positive and at most %d", maxLoggedBodyBytes)
+	}
+	for i, contentType := range bodies.ContentTypes {
+		if !redactableMediaType(contentType) {
+			report.add(fmt.Sprintf("log.bodies.content_types[%d]", i), "%q is neither a JSON nor a form media type, whose fields can be redacted", contentType)
+		}
+	}
+	validatePositive(report, "log.bodies.default_ttl", bodies.DefaultTTL)
+	if bodies.MaxTTL < bodies.DefaultTTL {
+		report.add("log.bodies.max_ttl", "must be at least log.bodies.default_ttl")
+	}
+}
+
+// ValidRoutePattern reports whether a route is written as in load shedding classes:
+// "METHOD /pattern" or "/pattern"
+func ValidRoutePattern(route string) bool {
+	pattern := route
+	if method, rest, ok := strings.Cut(route, " "); ok && method != "" && method == strings.ToUpper(method) {
+		pattern = rest
+	}
+	return strings.HasPrefix(pattern, "/")
+}
+
+// redactableMediaType reports whether the fields of bodies of a media type can be
+// redacted: JSON types, such as application/json or application/problem+json, and
+// application/x-www-form-urlencoded
+func redactableMediaType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(contentType))
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:i])
+	}
+	return mediaType == "application/json" || mediaType == "application/x-www-form-urlencoded" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// validateSentry checks the DSN events are sent to and the sampling and buffering of
+// events
+func validateSentry(report *ValidationError, sentry SentryConfig) {
+	dsn, err := url.Parse(sentry.DSN)
+	switch {
+	case sentry.DSN == "":
+		report.add("observability.sentry.dsn", "is required")
+	case err != nil || (dsn.Scheme != "http" && dsn.Scheme != "https") || dsn.Host == "":
+		report.add("observability.sentry.dsn", "must be an http or https URL")
+	case dsn.User == nil || dsn.User.Username() == "":
+		report.add("observability.sentry.dsn", "must carry the public key of the project as its user")
+	case strings.Trim(path.Base(dsn.Path), "/") == "":
+		report.add("observability.sentry.dsn", "must end with the ID of the project")
+	}
+	if sentry.SampleRate < 0 || sentry.SampleRate > 1 {
+		report.add("observability.sentry.sample_rate", "must be between 0 and 1")
+	}
+	validatePositive(report, "observability.sentry.timeout", sentry.Timeout)
+	if sentry.Buffer < 1 {
+		report.add("observability.sentry.buffer", "must be at least 1")
+	}
+}
+
+// countryCode matches ISO 3166 alpha-2 country codes
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// validateRegions checks the regions, the countries they serve and the upstreams they
+// override
+func validateRegions(report *ValidationError, c *Config) {
+	regions := c.Regions
+	if strings.ContainsAny(regions.Header, " \t:\r\n") {
+		report.add("regions.header", "%q is not a header name", regions.Header)
+	}
+	if strings.ContainsAny(regions.CountryHeader, " \t:\r\n") {
+		report.add("regions.country_header", "%q is not a header name", regions.CountryHeader)
+	}
+
+	services := c.Services.All()
+	ids := make(map[string]bool, len(regions.List))
+	for _, region := range regions.List {
+		ids[region.ID] = true
+	}
+	seen := make(map[string]bool, len(regions.List))
+	countries := make(map[string]string)
+	for i, region := range regions.List {
+		field := fmt.Sprintf("regions.list[%d]", i)
+		switch {
+		case !tenantID.MatchString(region.ID):
+			report.add(field+".id", "must be a lowercase DNS label")
+		case seen[region.ID]:
+			report.add(field+".id", "duplicates region %q", region.ID)
+		}
+		seen[region.ID] = true
+		for j, country := range region.Countries {
+			if !countryCode.MatchString(country) {
+				report.add(fmt.Sprintf("%s.countries[%d]", field, j), "%q is not an uppercase ISO 3166 alpha-2 code", country)
+			} else if other, ok := countries[country]; ok {
+				report.add(fmt.Sprintf("%s.countries[%d]", field, j), "%q is already a country of region %q", country, other)
+			}
+			countries[country] = region.ID
+		}
+		switch {
+		case region.Failover == region.ID && region.ID != "":
+			report.add(field+".failover", "must be another region")
+		case region.Failover != "" && !ids[region.Failover]:
+			report.add(field+".failover", "%q is not a region of regions.list", region.Failover)
+		}
+		for _, name := range sortedKeys(region.Services) {
+			base, ok := services[name]
+			if !ok {
+				report.add(field+".services."+name, "is not a service under services")
+				continue
+			}
+			svc, _ := region.Service(name, base)
+			validateService(report, field+".services."+name, svc, c.Discovery)
+		}
+	}
+	if regions.Default != "" && !ids[regions.Default] {
+		report.add("regions.default", "%q is not a region of regions.list", regions.Default)
+	}
+}
+
+// validateACL checks the network access control lists
+func validateACL(report *ValidationError, acl ACLConfig) {
+	validateNetworks(report, "acl.deny", acl.Deny)
+	for i, rule := range acl.Rules {
+		field := fmt.Sprintf("acl.rules[%d]", i)
+		if !strings.HasPrefix(rule.PathPrefix, "/") {
+			report.add(field+".path_prefix", "must start with /")
+		}
+		if len(rule.Allow) == 0 && len(rule.Deny) == 0 {
+			report.add(field, "needs allow or deny networks")
+		}
+		validateNetworks(report, field+".allow", rule.Allow)
+		validateNetworks(report, field+".deny", rule.Deny)
+	}
+	validatePositive(report, "acl.refresh_interval", acl.RefreshInterval)
+}
+
+// validateWebhooks checks the webhook dispatcher; partners manage their webhooks through
+// signed requests, so the partner routes must be signed
+func validateWebhooks(report *ValidationError, webhooks WebhooksConfig, redisEnabled bool, signing SigningConfig) {
+	if !redisEnabled {
+		report.add("webhooks.enabled", "requires redis.enabled")
+	}
+	if !signing.Enabled || !signing.Required(WebhooksPath) {
+		report.add("webhooks.enabled", "requires signing.enabled with a signing.path_prefixes entry covering %s", WebhooksPath)
+	}
+	if webhooks.KeyPrefix == "" {
+		report.add("webhooks.key_prefix", "must not be empty")
+	}
+	if webhooks.MaxEndpoints < 1 {
+		report.add("webhooks.max_endpoints", "must be at least 1")
+	}
+	if webhooks.Workers < 1 {
+		report.add("webhooks.workers", "must be at least 1")
+	}
+	if webhooks.MaxAttempts < 1 {
+		report.add("webhooks.max_attempts", "must be at least 1")
+	}
+	validatePositive(report, "webhooks.timeout", webhooks.Timeout)
+	validatePositive(report, "webhooks.initial_backoff", webhooks.InitialBackoff)
+	if webhooks.MaxBackoff < webhooks.InitialBackoff {
+		report.add("webhooks.max_backoff", "must be at least webhooks.initial_backoff")
+	}
+	validatePositive(report, "webhooks.poll_interval", webhooks.PollInterval)
+	validatePositive(report, "webhooks.delivery_ttl", webhooks.DeliveryTTL)
+}
+
+// validateKafka checks the consumer of backend change events
+func validateKafka(report *ValidationError, kafka KafkaConfig) {
+	if len(kafka.Brokers) == 0 {
+		report.add("kafka.brokers", "is required when kafka is enabled")
+	}
+	for i, broker := range kafka.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			report.add(fmt.Sprintf("kafka.brokers[%d]", i), "must be host:port, got %q", broker)
+		}
+	}
+	if kafka.GroupID == "" {
+		report.add("kafka.group_id", "must not be empty")
+	}
+	if len(kafka.Topics()) == 0 {
+		report.add("kafka.inventory_topics", "or kafka.event_topics must list at least one topic")
+	}
+	if kafka.StartOffset != KafkaOffsetLatest && kafka.StartOffset != KafkaOffsetEarliest {
+		report.add("kafka.start_offset", "must be %q or %q", KafkaOffsetLatest, KafkaOffsetEarliest)
+	}
+	validatePositive(report, "kafka.max_wait", kafka.MaxWait)
+	validateNonNegative(report, "kafka.commit_interval", kafka.CommitInterval)
+	validatePositive(report, "kafka.dial_timeout", kafka.DialTimeout)
+	if kafka.TLS.CertFile != "" && kafka.TLS.KeyFile == "" {
+		report.add("kafka.tls.key_file", "is required with kafka.tls.cert_file")
+	}
+	switch kafka.SASL.Mechanism {
+	case "":
+	case KafkaSASLPlain, KafkaSASLScramSHA256, KafkaSASLScramSHA512:
+		if kafka.SASL.Username == "" {
+			report.add("kafka.sasl.username", "is required with kafka.sasl.mechanism")
+		}
+	default:
+		report.add("kafka.sasl.mechanism", "must be %q, %q or %q", KafkaSASLPlain, KafkaSASLScramSHA256, KafkaSASLScramSHA512)
+	}
+}
+
+// validateAsyncPurchase checks the asynchronous purchase queue and its workers
+func validateAsyncPurchase(report *ValidationError, async AsyncPurchaseConfig, redisEnabled bool) {
+	if !redisEnabled {
+		report.add("orders.async_purchase.enabled", "requires redis.enabled")
+	}
+	if async.Mode != AsyncPurchasePrefer && async.Mode != AsyncPurchaseAlways {
+		report.add("orders.async_purchase.mode", "must be %q or %q", AsyncPurchasePrefer, AsyncPurchaseAlways)
+	}
+	if async.KeyPrefix == "" {
+		report.add("orders.async_purchase.key_prefix", "must not be empty")
+	}
+	if async.MaxPending < 1 {
+		report.add("orders.async_purchase.max_pending", "must be at least 1")
+	}
+	if async.Workers < 1 {
+		report.add("orders.async_purchase.workers", "must be at least 1")
+	}
+	if async.Attempts < 1 {
+		report.add("orders.async_purchase.attempts", "must be at least 1")
+	}
+	validateNonNegative(report, "orders.async_purchase.retry_backoff", async.RetryBackoff)
+	validatePositive(report, "orders.async_purchase.call_timeout", async.CallTimeout)
+	if async.ClaimIdle <= (async.CallTimeout+async.RetryBackoff)*time.Duration(async.Attempts) {
+		report.add("orders.async_purchase.claim_idle", "must exceed attempts times call_timeout and retry_backoff, or purchases in progress are taken over")
+	}
+	validatePositive(report, "orders.async_purchase.result_ttl", async.ResultTTL)
+	validatePositive(report, "orders.async_purchase.poll_after", async.PollAfter)
+}
+
+// validateCache checks the cache tiers and the cached routes
+func validateCache(report *ValidationError, cache CacheConfig, redisEnabled bool) {
+	if cache.MemoryMaxEntries < 0 {
+		report.add("cache.memory_max_entries", "must not be negative")
+	}
+	if cache.Redis && !redisEnabled {
+		report.add("cache.redis", "requires redis.enabled")
+	}
+	if cache.MemoryMaxEntries == 0 && !cache.Redis {
+		report.add("cache", "at least one tier (memory_max_entries or redis) is required")
+	}
+
+	seen := map[string]bool{}
+	for i, route := range cache.Routes {
+		field := fmt.Sprintf("cache.routes[%d]", i)
+		if !strings.HasPrefix(route.Path, "/") {
+			report.add(field+".path", "must be a route pattern starting with /")
+		} else if seen[route.Path] {
+			report.add(field+".path", "route %s is configured more than once", route.Path)
+		}
+		seen[route.Path] = true
+		validatePositive(report, field+".ttl", route.TTL)
+		for j, tag := range route.Tags {
+			if tag == "" {
+				report.add(fmt.Sprintf("%s.tags[%d]", field, j), "must not be empty")
+			}
+		}
+	}
+}
+
+// maxAvatarChunkSize keeps avatar messages well below the default gRPC message size limit
+const maxAvatarChunkSize = 1 << 20
+
+// validateAvatar checks the avatar upload settings
+func validateAvatar(report *ValidationError, avatar AvatarConfig, body RequestBodyConfig) {
+	if avatar.MaxBytes <= 0 {
+		report.add("avatar.max_bytes", "must be positive")
+	} else if limit := body.Limit(AvatarPath); limit > 0 && limit < avatar.MaxBytes {
+		report.add("avatar.max_bytes", "exceeds the request body limit of %d bytes for %s; add a server.http.request_body.groups entry for it", limit, AvatarPath)
+	}
+	if len(avatar.ContentTypes) == 0 {
+		report.add("avatar.content_types", "at least one image type is required")
+	}
+	for i, contentType := range avatar.ContentTypes {
+		if !strings.HasPrefix(contentType, "image/") {
+			report.add(fmt.Sprintf("avatar.content_types[%d]", i), "%q is not an image type", contentType)
+		}
+	}
+	if avatar.ChunkSize < 1024 || avatar.ChunkSize > maxAvatarChunkSize {
+		report.add("avatar.chunk_size", "must be between 1024 and %d", maxAvatarChunkSize)
+	}
+}
+
+// maxPasswordLength bounds the longest password the policy may allow
+const maxPasswordLength = 1024
+
+// validateValidation checks the request validation rules
+func validateValidation(report *ValidationError, v ValidationConfig) {
+	if len(v.EventIDFormats) == 0 {
+		report.add("validation.event_id_formats", "at least one format is required")
+	}
+	for i, format := range v.EventIDFormats {
+		if format != EventIDULID && format != EventIDUUID && format != EventIDSlug {
+			report.add(fmt.Sprintf("validation.event_id_formats[%d]", i), "must be %q, %q or %q", EventIDULID, EventIDUUID, EventIDSlug)
+		}
+	}
+	if v.Password.MinLength < 6 {
+		report.add("validation.password.min_length", "must be at least 6")
+	}
+	if v.Password.MaxLength < v.Password.MinLength || v.Password.MaxLength > maxPasswordLength {
+		report.add("validation.password.max_length", "must be between validation.password.min_length and %d", maxPasswordLength)
+	}
+}
+
+// xmlElementName matches the XML names accepted as the root element of XML responses
+var xmlElementName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// validateXML checks the XML content negotiation settings
+func validateXML(report *ValidationError, x XMLConfig) {
+	if len(x.Routes) == 0 {
+		report.add("xml.routes", "at least one route is required")
+	}
+	for i, route := range x.Routes {
+		if !strings.HasPrefix(route, "/") {
+			report.add(fmt.Sprintf("xml.routes[%d]", i), "must be a route pattern starting with /")
+		}
+	}
+	if !xmlElementName.MatchString(x.RootElement) {
+		report.add("xml.root_element", "%q is not a valid XML element name", x.RootElement)
+	}
+}
+
+// validateRequestBody checks the request body limits
+func validateRequestBody(report *ValidationError, body RequestBodyConfig) {
+	if body.MaxBytes < 0 {
+		report.add("server.http.request_body.max_bytes", "must not be negative")
+	}
+	if body.MaxJSONDepth < 0 {
+		report.add("server.http.request_body.max_json_depth", "must not be negative")
+	}
+	for i, group := range body.Groups {
+		field := fmt.Sprintf("server.http.request_body.groups[%d]", i)
+		if !strings.HasPrefix(group.PathPrefix, "/") {
+			report.add(field+".path_prefix", "must start with /")
+		}
+		if group.MaxBytes < 0 {
+			report.add(field+".max_bytes", "must not be negative")
+		}
+	}
+}
+
+// validateCallBudget checks that upstream deadlines end before the HTTP response deadline
+func validateCallBudget(report *ValidationError, http HTTPConfig) {
+	validateNonNegative(report, "server.http.response_margin", http.ResponseMargin)
+	if http.WriteTimeout > 0 && http.ResponseMargin >= http.WriteTimeout {
+		report.add("server.http.response_margin", "must be shorter than server.http.write_timeout")
+		return
+	}
+
+	limit := http.WriteTimeout - http.ResponseMargin
+	if http.WriteTimeout > 0 && http.RequestTimeout > limit {
+		report.add("server.http.request_timeout", "must not exceed server.http.write_timeout minus response_margin (%s)", limit)
+	}
+	for i, rt := range http.RouteTimeouts {
+		field := fmt.Sprintf("server.http.route_timeouts[%d]", i)
+		if !strings.HasPrefix(rt.Path, "/") {
+			report.add(field+".path", "must be a route pattern starting with /")
+		}
+		if rt.Method != "" && strings.ToUpper(rt.Method) != rt.Method {
+			report.add(field+".method", "must be an upper-case HTTP method such as GET")
+		}
+		validatePositive(report, field+".timeout", rt.Timeout)
+		if http.WriteTimeout > 0 && rt.Timeout > limit {
+			report.add(field+".timeout", "must not exceed server.http.write_timeout minus response_margin (%s)", limit)
+		}
+	}
+}
+
+func validateListeners(report *ValidationError, http HTTPConfig) {
+	usesTLS := false
+	for i, l := range http.ResolvedListeners() {
+		field := fmt.Sprintf("server.http.listeners[%d]", i)
+		switch l.Network {
+		case NetworkTCP:
+			if _, _, err := net.SplitHostPort(l.Address); err != nil {
+				report.add(field+".address", "must be host:port: %v", err)
+			}
+		case NetworkUnix:
+			if l.Address == "" {
+				report.add(field+".address", "socket path is required")
+			}
+			if l.SocketMode != "" {
+				if _, err := strconv.ParseUint(l.SocketMode, 8, 32); err != nil {
+					report.add(field+".socket_mode", "must be an octal file mode such as 0660")
+				}
+			}
+		default:
+			report.add(field+".network", "must be %q or %q", NetworkTCP, NetworkUnix)
+		}
+		usesTLS = usesTLS || l.TLS
+	}
+
+	tlsCfg := http.TLS
+	if !usesTLS {
+		return
+	}
+	if tlsCfg.Autocert.Enabled {
+		if len(tlsCfg.Autocert.Domains) == 0 {
+			report.add("server.http.tls.autocert.domains", "at least one domain is required")
+		}
+		if tlsCfg.CertFile != "" || tlsCfg.KeyFile != "" {
+			report.add("server.http.tls", "cert_file/key_file and autocert are mutually exclusive")
+		}
+	} else if tlsCfg.CertFile == "" || tlsCfg.KeyFile == "" {
+		report.add("server.http.tls", "cert_file and key_file are required for TLS listeners unless autocert is enabled")
+	}
+	switch tlsCfg.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		report.add("server.http.tls.min_version", "must be 1.2 or 1.3")
+	}
+}
+
+// validateGRPCServer validates the gRPC server
+func validateGRPCServer(report *ValidationError, grpcServer GRPCServerConfig) {
+	if !grpcServer.Enabled {
+		return
+	}
+	if _, _, err := net.SplitHostPort(grpcServer.Address); err != nil {
+		report.add("server.grpc.address", "must be host:port: %v", err)
+	}
+	if (grpcServer.CertFile == "") != (grpcServer.KeyFile == "") {
+		report.add("server.grpc", "cert_file and key_file must be set together")
+	}
+	if grpcServer.MaxRecvMsgSize < 1 {
+		report.add("server.grpc.max_recv_msg_size", "must be at least 1")
+	}
+}
+
+// validateAdmin validates the admin listener, refusing unauthenticated access from
+// anywhere but the local host
+func validateAdmin(report *ValidationError, admin AdminConfig) {
+	if !admin.Enabled {
+		return
+	}
+	host, _, err := net.SplitHostPort(admin.Address)
+	if err != nil {
+		report.add("server.admin.address", "must be host:port: %v", err)
+	}
+
+	switch admin.Auth {
+	case AdminAuthBasic:
+		if admin.Username == "" {
+			report.add("server.admin.username", "is required with basic auth")
+		}
+		if len(admin.Password) < minAdminPasswordLength {
+			report.add("server.admin.password", "must be at least %d characters with basic auth", minAdminPasswordLength)
+		}
+		// The credentials would cross the network in plaintext
+		if !admin.TLSEnabled() && !isLoopback(host) {
+			report.add("server.admin.tls", "cert_file and key_file are required with basic auth outside a loopback address")
+		}
+	case AdminAuthMTLS:
+		if admin.TLS.ClientCAFile == "" {
+			report.add("server.admin.tls.client_ca_file", "is required with mtls auth")
+		}
+	case AdminAuthNone:
+		if !isLoopback(host) {
+			report.add("server.admin.auth", "none is only allowed on a loopback address")
+		}
+	default:
+		report.add("server.admin.auth", "must be %q, %q or %q", AdminAuthBasic, AdminAuthMTLS, AdminAuthNone)
+	}
+
+	if admin.TLSEnabled() && (admin.TLS.CertFile == "" || admin.TLS.KeyFile == "") {
+		report.add("server.admin.tls", "cert_file and key_file are required for HTTPS and mtls auth")
+	}
+}
+
+// isLoopback reports whether a listen host only accepts connections from the local host
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
+
+// validateService validates the address, gRPC, endpoint and shadow settings of a service
+func validateService(report *ValidationError, field string, svc ServiceConfig, discovery DiscoveryConfig) {
+	if svc.Name == "" {
+		report.add(field+".name", "is required")
+	}
+
+	if len(svc.Endpoints) == 0 && svc.Target == "" {
+		if svc.Host == "" {
+			report.add(field+".host", "is required")
+		}
+		validatePort(report, field+".port", svc.Port)
+	}
+
+	for _, ep := range svc.ResolvedEndpoints() {
+		scheme, _, _ := strings.Cut(ep.Target, "://")
+		if scheme == DiscoverySchemeConsul && !discovery.Consul.Enabled {
+			report.add(field+".target", "%s requires discovery.consul.enabled", ep.Target)
+		}
+		if scheme == DiscoverySchemeKubernetes && !discovery.Kubernetes.Enabled {
+			report.add(field+".target", "%s requires discovery.kubernetes.enabled", ep.Target)
+		}
+	}
+
+	switch svc.LoadBalancing {
+	case "", LoadBalancingRoundRobin, LoadBalancingPickFirst:
+	default:
+		report.add(field+".load_balancing", "must be %q or %q", LoadBalancingRoundRobin, LoadBalancingPickFirst)
+	}
+
+	validateNonNegative(report, field+".grpc.keepalive_time", svc.GRPC.KeepaliveTime)
+	if svc.GRPC.KeepaliveTime > 0 && svc.GRPC.KeepaliveTime < minKeepaliveTime {
+		report.add(field+".grpc.keepalive_time", "must be at least %s", minKeepaliveTime)
+	}
+	validateNonNegative(report, field+".grpc.keepalive_timeout", svc.GRPC.KeepaliveTimeout)
+	if svc.GRPC.MaxRecvMsgSize < 0 {
+		report.add(field+".grpc.max_recv_msg_size", "must not be negative")
+	}
+	if svc.GRPC.MaxSendMsgSize < 0 {
+		report.add(field+".grpc.max_send_msg_size", "must not be negative")
+	}
+	if svc.GRPC.Compression != CompressionNone && svc.GRPC.Compression != CompressionGzip {
+		report.add(field+".grpc.compression", "must be %q or empty", CompressionGzip)
+	}
+
+	totalWeight := 0
+	names := make(map[string]bool, len(svc.Endpoints))
+	for i, ep := range svc.Endpoints {
+		epField := fmt.Sprintf("%s.endpoints[%d]", field, i)
+		if ep.Target == "" {
+			if ep.Host == "" {
+				report.add(epField+".host", "is required")
+			}
+			validatePort(report, epField+".port", ep.Port)
+		}
+		if ep.Weight < 0 {
+			report.add(epField+".weight", "must not be negative")
+		}
+		if ep.Name != "" {
+			if names[ep.Name] {
+				report.add(epField+".name", "duplicate endpoint name %q", ep.Name)
+			}
+			names[ep.Name] = true
+		}
+		totalWeight += ep.Weight
+	}
+	if len(svc.Endpoints) > 0 && totalWeight <= 0 {
+		report.add(field+".endpoints", "must have a positive total weight")
+	}
+
+	if svc.TLS.Enabled && (svc.TLS.CertFile == "") != (svc.TLS.KeyFile == "") {
+		report.add(field+".tls", "cert_file and key_file must be set together")
+	}
+
+	if svc.Shadow.Enabled {
+		if svc.Shadow.Host == "" {
+			report.add(field+".shadow.host", "is required when shadowing is enabled")
+		}
+		validatePort(report, field+".shadow.port", svc.Shadow.Port)
+		if svc.Shadow.Percentage < 0 || svc.Shadow.Percentage > 100 {
+			report.add(field+".shadow.percentage", "must be between 0 and 100")
+		}
+		validatePositive(report, field+".shadow.timeout", svc.Shadow.Timeout)
+	}
+}
+
+// validateRedis validates the Redis connection settings
+func validateRedis(report *ValidationError, r RedisConfig) {
+	switch r.Mode {
+	case RedisModeStandalone, RedisModeSentinel, RedisModeCluster:
+	default:
+		report.add("redis.mode", "must be %q, %q or %q", RedisModeStandalone, RedisModeSentinel, RedisModeCluster)
+	}
+
+	if len(r.Addrs) == 0 {
+		if r.Host == "" {
+			report.add("redis.host", "is required when redis is enabled")
+		}
+		validatePort(report, "redis.port", r.Port)
+	}
+	for i, addr := range r.Addrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			report.add(fmt.Sprintf("redis.addrs[%d]", i), "must be host:port: %v", err)
+		}
+	}
+
+	if r.Mode == RedisModeSentinel && r.MasterName == "" {
+		report.add("redis.master_name", "is required in sentinel mode")
+	}
+	switch r.ReadFrom {
+	case RedisReadFromPrimary:
+	case RedisReadFromReplicas:
+		if r.Mode == RedisModeStandalone && len(r.Replicas) == 0 {
+			report.add("redis.replicas", "is required to read from replicas in standalone mode")
+		}
+	default:
+		report.add("redis.read_from", "must be %q or %q", RedisReadFromPrimary, RedisReadFromReplicas)
+	}
+	if len(r.Replicas) > 0 && r.Mode != RedisModeStandalone {
+		report.add("redis.replicas", "is only used in standalone mode; the replicas are discovered in %s mode", r.Mode)
+	}
+	for i, addr := range r.Replicas {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			report.add(fmt.Sprintf("redis.replicas[%d]", i), "must be host:port: %v", err)
+		}
+	}
+	if r.Mode == RedisModeCluster && r.DB != 0 {
+		report.add("redis.db", "must be 0 in cluster mode")
+	}
+	if r.DB < 0 {
+		report.add("redis.db", "must not be negative")
+	}
+
+	if r.TLS.Enabled && (r.TLS.CertFile == "") != (r.TLS.KeyFile == "") {
+		report.add("redis.tls", "cert_file and key_file must be set together")
+	}
+
+	if r.PoolSize <= 0 {
+		report.add("redis.pool_size", "must be positive")
+	}
+	if r.MinIdleConns < 0 || r.MinIdleConns > r.PoolSize {
+		report.add("redis.min_idle_conns", "must be between 0 and pool_size")
+	}
+	validateNonNegative(report, "redis.pool_timeout", r.PoolTimeout)
+	validatePositive(report, "redis.dial_timeout", r.DialTimeout)
+	validatePositive(report, "redis.read_timeout", r.ReadTimeout)
+	validatePositive(report, "redis.write_timeout", r.WriteTimeout)
+	if r.MaxRetries < -1 {
+		report.add("redis.max_retries", "must be -1 (disabled) or greater")
+	}
+	validateNonNegative(report, "redis.min_retry_backoff", r.MinRetryBackoff)
+	if r.MaxRetryBackoff < r.MinRetryBackoff {
+		report.add("redis.max_retry_backoff", "must not be less than min_retry_backoff")
+	}
+
+	if r.LockKeyPrefix == "" {
+		report.add("redis.lock_key_prefix", "must not be empty")
+	}
+
+	if w := r.Watchdog; w.Enabled {
+		validatePositive(report, "redis.watchdog.interval", w.Interval)
+		validatePositive(report, "redis.watchdog.timeout", w.Timeout)
+		if w.FailureThreshold < 1 {
+			report.add("redis.watchdog.failure_threshold", "must be at least 1")
+		}
+		switch w.Fallback {
+		case LimiterFallbackLocal, LimiterFallbackDisabled:
+		default:
+			report.add("redis.watchdog.fallback", "must be %q or %q", LimiterFallbackLocal, LimiterFallbackDisabled)
+		}
+	}
+}
+
+// validateTokenBucket validates a token bucket limiter configuration
+func validateTokenBucket(report *ValidationError, field string, tb TokenBucketConfig) {
+	if tb.Capacity <= 0 {
+		report.add(field+".capacity", "must be positive")
+	}
+	if tb.RefillRate <= 0 {
+		report.add(field+".refill_rate", "must be positive")
+	}
+	validatePositive(report, field+".refill_interval", tb.RefillInterval)
+}
+
+// validatePort checks that a port is in the valid TCP range
+func validatePort(report *ValidationError, field string, port int) {
+	if port <= 0 || port > 65535 {
+		report.add(field, "invalid port %d", port)
+	}
+}
+
+// validatePositive checks that a duration is greater than zero
+func validatePositive(report *ValidationError, field string, d time.Duration) {
+	if d <= 0 {
+		report.add(field, "must be positive")
+	}
+}
+
+// validateNonNegative checks that a duration is not negative
+func validateNonNegative(report *ValidationError, field string, d time.Duration) {
+	if d < 0 {
+		report.add(field, "must not be negative")
+	}
+}
+
+// sortedKeys returns the keys of a map in a stable order for deterministic reports
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadDebounce coalesces the burst of events editors and config map updates produce
+const reloadDebounce = 250 * time.Millisecond
+
+// ChangeFunc is called with the previous and the newly loaded configuration after a reload
+type ChangeFunc func(oldCfg, newCfg *Config)
+
+// Watcher reloads the configuration file when it changes on disk or on SIGHUP
+type Watcher struct {
+	path   string
+	logger *logrus.Logger
+	// startup is the configuration the process started with; settings only read at startup are compared against it
+	startup *Config
+
+	mu        sync.RWMutex
+	current   *Config
+	callbacks []ChangeFunc
+}
+
+// NewWatcher creates a watcher for the configuration file, starting from an already loaded config
+func NewWatcher(path string, initial *Config, logger *logrus.Logger) *Watcher {
+	return &Watcher{
+		path:    path,
+		startup: initial,
+		current: initial,
+		logger:  logger,
+	}
+}
+
+// Current returns the most recently applied configuration
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// OnChange registers a callback invoked after every successful reload
+func (w *Watcher) OnChange(fn ChangeFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Start watches the configuration file and SIGHUP until the context is cancelled
+func (w *Watcher) Start(ctx context.Context) error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	// Watch the directory rather than the files so atomic renames and
+	// symlink swaps (Kubernetes config maps) are picked up too.
+	// Without a config file only SIGHUP and remote changes trigger reloads.
+	targets := map[string]string{}
+	if w.path != "" {
+		dir := filepath.Dir(w.path)
+		if err := fsWatcher.Add(dir); err != nil {
+			fsWatcher.Close()
+			return fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+		}
+
+		// The environment overlay is watched even when it does not exist yet
+		targets[filepath.Clean(w.path)] = ""
+		if env := w.Current().App.Environment; env != "" {
+			targets[filepath.Clean(OverlayPath(w.path, env))] = ""
+		}
+		for target := range targets {
+			targets[target], _ = filepath.EvalSymlinks(target)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// Remote changes feed the same reload path as file changes
+	remoteChanged := make(chan struct{}, 1)
+	if remote := w.Current().Remote; remote.Enabled() {
+		source, err := newRemoteSource(remote)
+		if err != nil {
+			fsWatcher.Close()
+			signal.Stop(hup)
+			return err
+		}
+		go func() {
+			err := source.Watch(ctx, func() {
+				select {
+				case remoteChanged <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				w.logger.WithError(err).WithField("remote", remote.Location()).Error("Remote config watch stopped")
+			}
+		}()
+	}
+
+	go func() {
+		defer fsWatcher.Close()
+		defer signal.Stop(hup)
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if !targetChanged(targets, event.Name) {
+					continue
+				}
+				debounce = time.After(reloadDebounce)
+			case <-debounce:
+				debounce = nil
+				w.reload("file change")
+			case <-hup:
+				w.reload("SIGHUP")
+			case <-remoteChanged:
+				w.reload("remote change")
+			case err, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+				w.logger.WithError(err).Warn("Config watcher error")
+			}
+		}
+	}()
+
+	w.logger.WithField("files", w.Current().Files).Info("Watching configuration for changes (send SIGHUP to force a reload)")
+	return nil
+}
+
+// targetChanged reports whether an event concerns one of the watched files, either
+// directly or by swapping the file a symlink resolves to; resolutions are updated in place
+func targetChanged(targets map[string]string, name string) bool {
+	changed := false
+	for target, realTarget := range targets {
+		currentTarget, _ := filepath.EvalSymlinks(target)
+		if filepath.Clean(name) == target || currentTarget != realTarget {
+			targets[target] = currentTarget
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Reload loads and validates the configuration file, applying it when valid
+func (w *Watcher) Reload() error {
+	newCfg, err := LoadConfig(w.path)
+	if err != nil {
+		return err
+	}
+	if err := newCfg.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	w.mu.Lock()
+	oldCfg := w.current
+	w.current = newCfg
+	callbacks := append([]ChangeFunc(nil), w.callbacks...)
+	w.mu.Unlock()
+
+	if changed := RestartRequired(w.startup, newCfg); len(changed) > 0 {
+		w.logger.WithField("settings", changed).Warn("Configuration changes require a restart to take effect")
+	}
+
+	for _, fn := range callbacks {
+		fn(oldCfg, newCfg)
+	}
+	return nil
+}
+
+// reload reloads the configuration and logs the outcome; a failed reload keeps the current config
+func (w *Watcher) reload(trigger string) {
+	if err := w.Reload(); err != nil {
+		w.logger.WithError(err).WithField("trigger", trigger).Error("Configuration reload failed, keeping current configuration")
+		return
+	}
+	w.logger.WithField("trigger", trigger).Info("Configuration reloaded")
+}
+
+// RestartRequired lists the changed settings that are only read at startup
+func RestartRequired(oldCfg, newCfg *Config) []string {
+	var changed []string
+	check := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			changed = append(changed, name)
+		}
+	}
+
+	check("server.http.host", oldCfg.Server.HTTP.Host, newCfg.Server.HTTP.Host)
+	check("server.http.port", oldCfg.Server.HTTP.Port, newCfg.Server.HTTP.Port)
+	check("server.http.read_timeout", oldCfg.Server.HTTP.ReadTimeout, newCfg.Server.HTTP.ReadTimeout)
+	check("server.http.write_timeout", oldCfg.Server.HTTP.WriteTimeout, newCfg.Server.HTTP.WriteTimeout)
+	check("server.http.idle_timeout", oldCfg.Server.HTTP.IdleTimeout, newCfg.Server.HTTP.IdleTimeout)
+	check("server.http.read_header_timeout", oldCfg.Server.HTTP.ReadHeaderTimeout, newCfg.Server.HTTP.ReadHeaderTimeout)
+	check("server.http.max_header_bytes", oldCfg.Server.HTTP.MaxHeaderBytes, newCfg.Server.HTTP.MaxHeaderBytes)
+	check("server.http.http2", oldCfg.Server.HTTP.HTTP2, newCfg.Server.HTTP.HTTP2)
+	check("server.http.tls", oldCfg.Server.HTTP.TLS, newCfg.Server.HTTP.TLS)
+	check("server.http.listeners", oldCfg.Server.HTTP.Listeners, newCfg.Server.HTTP.Listeners)
+	check("server.http.graceful_shutdown_timeout", oldCfg.Server.HTTP.GracefulShutdownTimeout, newCfg.Server.HTTP.GracefulShutdownTimeout)
+	check("server.admin.enabled", oldCfg.Server.Admin.Enabled, newCfg.Server.Admin.Enabled)
+	check("server.admin.address", oldCfg.Server.Admin.Address, newCfg.Server.Admin.Address)
+	check("server.admin.auth", oldCfg.Server.Admin.Auth, newCfg.Server.Admin.Auth)
+	check("server.admin.tls", adminCertificates(oldCfg.Server.Admin.TLS), adminCertificates(newCfg.Server.Admin.TLS))
+	check("server.upgrade", oldCfg.Server.Upgrade, newCfg.Server.Upgrade)
+	check("server.grpc", oldCfg.Server.GRPC, newCfg.Server.GRPC)
+	oldServices, newServices := oldCfg.Services.All(), newCfg.Services.All()
+	for _, name := range newCfg.Services.Names() {
+		check("services."+name, oldServices[name], newServices[name])
+	}
+	check("tenants (services)", tenantServices(oldCfg), tenantServices(newCfg))
+	check("regions (services, failover)", regionUpstreams(oldCfg), regionUpstreams(newCfg))
+	check("jwt", oldCfg.JWT, newCfg.JWT)
+	check("log.grpc_calls", oldCfg.Log.GRPCCalls, newCfg.Log.GRPCCalls)
+	check("log (backend, format, outputs, sampling)", logOutputs(oldCfg.Log), logOutputs(newCfg.Log))
+	check("faults (upstream rules)", upstreamFaults(oldCfg), upstreamFaults(newCfg))
+	check("redis.enabled", oldCfg.Redis.Enabled, newCfg.Redis.Enabled)
+	check("redis.connection", redisConnection(oldCfg.Redis), redisConnection(newCfg.Redis))
+	check("cache", cacheTiers(oldCfg.Cache), cacheTiers(newCfg.Cache))
+	check("orders.async_purchase", asyncPurchaseQueue(oldCfg.Orders.AsyncPurchase), asyncPurchaseQueue(newCfg.Orders.AsyncPurchase))
+	check("webhooks", oldCfg.Webhooks, newCfg.Webhooks)
+	check("kafka", oldCfg.Kafka, newCfg.Kafka)
+	check("acl.key_prefix", oldCfg.ACL.KeyPrefix, newCfg.ACL.KeyPrefix)
+	check("acl.refresh_interval", oldCfg.ACL.RefreshInterval, newCfg.ACL.RefreshInterval)
+	check("remote", oldCfg.Remote, newCfg.Remote)
+	check("startup", oldCfg.Startup, newCfg.Startup)
+	check("process", oldCfg.Process, newCfg.Process)
+	check("discovery", oldCfg.Discovery, newCfg.Discovery)
+	check("dev_stub", oldCfg.DevStub, newCfg.DevStub)
+	check("observability.sentry", oldCfg.Observability.Sentry, newCfg.Observability.Sentry)
+	check("recording.enabled", oldCfg.Recording.Enabled, newCfg.Recording.Enabled)
+	check("recording.directory", oldCfg.Recording.Directory, newCfg.Recording.Directory)
+	check("recording.buffer", oldCfg.Recording.Buffer, newCfg.Recording.Buffer)
+
+	return changed
+}
+
+// logOutputs returns the backend, format, streams and sampling of logs, which are set up at
+// startup; the log level is applied live
+func logOutputs(log LogConfig) LogConfig {
+	return LogConfig{
+		Backend:     log.Backend,
+		Format:      log.Format,
+		Output:      log.Output,
+		ErrorOutput: log.ErrorOutput,
+		File:        log.File,
+		Rotate:      log.Rotate,
+		Sampling:    log.Sampling,
+	}
+}
+
+// tenantServices returns the upstreams tenants override, which are connected at startup;
+// the resolution and rate limits of tenants are applied live
+func tenantServices(c *Config) map[string]map[string]ServiceOverrideConfig {
+	services := make(map[string]map[string]ServiceOverrideConfig)
+	for _, tenant := range c.Tenants.Active() {
+		if len(tenant.Services) > 0 {
+			services[tenant.ID] = tenant.Services
+		}
+	}
+	return services
+}
+
+// regionUpstreams returns the upstreams regions override and the regions they fail over
+// to, which are connected at startup; the resolution of regions is applied live
+func regionUpstreams(c *Config) map[string]RegionConfig {
+	upstreams := make(map[string]RegionConfig)
+	for _, region := range c.Regions.Active() {
+		upstreams[region.ID] = RegionConfig{Failover: region.Failover, Services: region.Services}
+	}
+	return upstreams
+}
+
+// upstreamFaults returns the fault injection rules of upstreams in effect, which are
+// applied by the backend connections; the rules of routes are applied live
+func upstreamFaults(c *Config) []FaultRuleConfig {
+	if !c.Faults.Enabled || c.App.Environment == "production" {
+		return nil
+	}
+	var rules []FaultRuleConfig
+	for _, rule := range c.Faults.Rules {
+		if rule.Upstream != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// adminCertificates returns the certificate files of the admin listener, leaving out the
+// allowed clients that are checked per request
+func adminCertificates(t AdminTLSConfig) AdminTLSConfig {
+	t.AllowedClients = nil
+	return t
+}
+
+// redisConnection returns the Redis settings used to establish the connection,
+// leaving out the limiter and lock settings that are applied live
+func redisConnection(r RedisConfig) RedisConfig {
+	r.TokenBucket = TokenBucketConfig{}
+	r.Policies = nil
+	r.LockKeyPrefix = ""
+	return r
+}
+
+// cacheTiers returns the response cache settings used to create the cache tiers,
+// leaving out the cached routes that are applied live
+func cacheTiers(c CacheConfig) CacheConfig {
+	c.Routes = nil
+	return c
+}
+
+// asyncPurchaseQueue returns the settings of the purchase queue and its workers, leaving
+// out those read by the purchase routes, which are rebuilt on reload
+func asyncPurchaseQueue(c AsyncPurchaseConfig) AsyncPurchaseConfig {
+	c.Mode = ""
+	c.PollAfter = 0
+	return c
+}
@@ -0,0 +1,162 @@
+package dto
+
+import "time"
+
+// CreateEventReq represents an admin request to create an event
+type CreateEventReq struct {
+	Name             string    `json:"name" binding:"required,max=200"`
+	Description      string    `json:"description" binding:"omitempty,max=5000"`
+	Category         string    `json:"category" binding:"required,max=50"`
+	Venue            string    `json:"venue" binding:"required,max=200"`
+	StartsAt         time.Time `json:"startsAt" binding:"required"`
+	EndsAt           time.Time `json:"endsAt" binding:"required,gtfield=StartsAt"`
+	AvailableTickets int32     `json:"availableTickets" binding:"min=0"`
+}
+
+// UpdateEventReq represents a partial event update; omitted fields are left unchanged
+type UpdateEventReq struct {
+	Name        *string    `json:"name" binding:"omitempty,max=200"`
+	Description *string    `json:"description" binding:"omitempty,max=5000"`
+	Category    *string    `json:"category" binding:"omitempty,max=50"`
+	Venue       *string    `json:"venue" binding:"omitempty,max=200"`
+	StartsAt    *time.Time `json:"startsAt"`
+	EndsAt      *time.Time `json:"endsAt"`
+}
+
+// CloseEventReq represents an admin request to close sales for an event
+type CloseEventReq struct {
+	Reason string `json:"reason" binding:"omitempty,max=500"`
+}
+
+// AdjustInventoryReq represents an admin inventory adjustment; a negative delta removes tickets
+type AdjustInventoryReq struct {
+	Tier   string `json:"tier" binding:"omitempty,max=50"`
+	Delta  int32  `json:"delta" binding:"required,ne=0"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ForceCancelOrderReq represents an admin order cancellation
+type ForceCancelOrderReq struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ConfigDumpResp represents the effective configuration of the running instance
+type ConfigDumpResp struct {
+	Sources []string               `json:"sources"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+// ACLResp represents the network access control lists in effect
+type ACLResp struct {
+	Enabled   bool                 `json:"enabled"`
+	Deny      []string             `json:"deny"`
+	Rules     []ACLRuleResp        `json:"rules"`
+	Blocklist []BlocklistEntryResp `json:"blocklist"` // Networks blocked at runtime
+}
+
+// BlocklistEntryResp represents a network blocked at runtime
+type BlocklistEntryResp struct {
+	Network   string     `json:"network"`
+	Reason    string     `json:"reason"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ACLRuleResp represents the access control list of a route group
+type ACLRuleResp struct {
+	PathPrefix string   `json:"pathPrefix"`
+	Allow      []string `json:"allow,omitempty"`
+	Deny       []string `json:"deny,omitempty"`
+}
+
+// BlockNetworkReq represents an admin request to block a network at runtime
+type BlockNetworkReq struct {
+	Network string `json:"network" binding:"required,max=64"` // CIDR range or IP address
+	Reason  string `json:"reason" binding:"required,max=500"`
+	TTL     string `json:"ttl" binding:"omitempty,max=32"` // Go duration such as "2h"; the entry is permanent when empty
+}
+
+// BodyLoggingResp represents the routes whose request and response bodies are logged
+type BodyLoggingResp struct {
+	Enabled      bool                   `json:"enabled"`
+	MaxBytes     int                    `json:"maxBytes"`
+	ContentTypes []string               `json:"contentTypes"`
+	Configured   []string               `json:"configured"` // Routes listed in log.bodies.routes
+	Runtime      []BodyLoggingRouteResp `json:"runtime"`    // Routes enabled through the admin API
+}
+
+// BodyLoggingRouteResp represents a route whose bodies are logged until it expires
+type BodyLoggingRouteResp struct {
+	Route     string    `json:"route"`
+	Reason    string    `json:"reason"`
+	EnabledBy string    `json:"enabledBy"`
+	EnabledAt time.Time `json:"enabledAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// EnableBodyLoggingReq represents an admin request to log the bodies of a route
+type EnableBodyLoggingReq struct {
+	Route  string `json:"route" binding:"required,max=256"` // "METHOD /pattern" or "/pattern", as registered
+	Reason string `json:"reason" binding:"required,max=500"`
+	TTL    string `json:"ttl" binding:"omitempty,max=32"` // Go duration such as "30m"; defaults to log.bodies.default_ttl
+}
+
+// OpenWaitingRoomReq represents an admin request to open or retune an event's waiting room
+type OpenWaitingRoomReq struct {
+	Rate int `json:"rate" binding:"omitempty,min=1,max=100000"` // Passes per second; defaults to waiting_room.default_rate
+}
+
+// WaitingRoomResp represents the waiting room of an event
+type WaitingRoomResp struct {
+	EventID  string    `json:"eventId"`
+	Rate     int       `json:"rate"`
+	OpenedAt time.Time `json:"openedAt"`
+	Joined   int64     `json:"joined"`
+	Admitted int64     `json:"admitted"`
+	Queued   int64     `json:"queued"`
+}
+
+// DrainResp represents the drain state of the instance
+type DrainResp struct {
+	Draining  bool       `json:"draining"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	Reason    string     `json:"reason,omitempty"` // signal, admin or upgrade
+	InFlight  int64      `json:"inFlight"`         // Requests being served by the public listeners
+}
+
+// RoutesResp represents the route table of the public router
+type RoutesResp struct {
+	Middleware []string    `json:"middleware"` // Router-wide middleware, run before every route's own
+	Routes     []RouteResp `json:"routes"`
+}
+
+// RouteResp represents a registered route and what applies to its requests
+type RouteResp struct {
+	Method     string               `json:"method"`
+	Path       string               `json:"path"`
+	Handler    string               `json:"handler"`
+	Auth       []string             `json:"auth"` // jwt, role:admin or signature; empty for public routes
+	RateLimits []RouteRateLimitResp `json:"rateLimits,omitempty"`
+	Protection []string             `json:"protection,omitempty"` // brute_force, waiting_room or captcha
+	Timeout    string               `json:"timeout,omitempty"`    // Deadline of the upstream calls
+	CacheTTL   string               `json:"cacheTtl,omitempty"`
+	Coalesced  bool                 `json:"coalesced,omitempty"`
+	XML        bool                 `json:"xml,omitempty"` // Accepts and renders XML bodies
+	Upstreams  []RouteUpstreamResp  `json:"upstreams,omitempty"`
+	Middleware []string             `json:"middleware"` // The route's own middleware
+}
+
+// RouteRateLimitResp represents a token bucket limiting a route
+type RouteRateLimitResp struct {
+	Policy         string  `json:"policy"` // global or the name of a limiter policy
+	Capacity       int     `json:"capacity"`
+	RefillRate     float64 `json:"refillRate"`
+	RefillInterval string  `json:"refillInterval"`
+}
+
+// RouteUpstreamResp represents a backend service a route calls
+type RouteUpstreamResp struct {
+	Service   string   `json:"service"`
+	Endpoints []string `json:"endpoints"`
+}
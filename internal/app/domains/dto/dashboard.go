@@ -0,0 +1,19 @@
+package dto
+
+import "apigw/internal/app/domains/errs"
+
+// DashboardSection is one part of the dashboard: its data, or the error that prevented
+// reading it
+type DashboardSection struct {
+	Data  interface{}     `json:"data,omitempty"`
+	Error *errs.HTTPError `json:"error,omitempty"`
+}
+
+// DashboardResp represents the dashboard of the authenticated user. Partial is set when
+// at least one section failed.
+type DashboardResp struct {
+	Profile        DashboardSection `json:"profile"`        // ProfileResp
+	RecentOrders   DashboardSection `json:"recentOrders"`   // []OrderResp, newest first
+	UpcomingEvents DashboardSection `json:"upcomingEvents"` // []EventResp, soonest first
+	Partial        bool             `json:"partial"`
+}
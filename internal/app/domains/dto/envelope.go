@@ -0,0 +1,26 @@
+package dto
+
+import "apigw/internal/app/domains/errs"
+
+// Envelope is the standard shape of successful responses of API versions with
+// api.versions.<version>.envelope set
+type Envelope struct {
+	Data interface{}  `json:"data"`
+	Meta EnvelopeMeta `json:"meta"`
+	// Errors lists what failed in a partially successful response, e.g. dashboard sections
+	Errors []*errs.HTTPError `json:"errors,omitempty"`
+}
+
+// EnvelopeMeta describes the request and, for list responses, the page
+type EnvelopeMeta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes a page and how to fetch the next one
+type Pagination struct {
+	Limit int32 `json:"limit"`
+	// NextCursor is passed as the cursor parameter to fetch the next page; it is omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	Sort       string `json:"sort,omitempty"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+import "apigw/internal/app/domains/errs"
+
+// ErrorCatalogResp lists the errors the gateway returns
+type ErrorCatalogResp struct {
+	Errors []errs.CatalogEntry `json:"errors"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+// EventResp represents an event in responses
+type EventResp struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Category         string    `json:"category"`
+	Venue            string    `json:"venue"`
+	StartsAt         time.Time `json:"startsAt"`
+	EndsAt           time.Time `json:"endsAt"`
+	AvailableTickets int32     `json:"availableTickets"`
+	Status           string    `json:"status"`
+}
+
+// ListEventsReq represents the query parameters of an event search
+type ListEventsReq struct {
+	Query    string    `form:"q" binding:"omitempty,max=200"`
+	Category string    `form:"category" binding:"omitempty,max=50"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty,gtfield=From"`
+}
+
+// ListEventsResp represents a page of events
+type ListEventsResp struct {
+	Events     interface{} `json:"events"` // []EventResp, or the selected fields of each event
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// SeatResp represents a seat and its availability
+type SeatResp struct {
+	ID         string `json:"id"`
+	Section    string `json:"section"`
+	Row        string `json:"row"`
+	Number     int32  `json:"number"`
+	Tier       string `json:"tier"`
+	Status     string `json:"status"`
+	PriceCents int64  `json:"priceCents"`
+}
+
+// SeatMapResp represents the seat map of an event
+type SeatMapResp struct {
+	EventID string     `json:"eventId"`
+	Seats   []SeatResp `json:"seats"`
+}
+
+// WaitingRoomTicketResp represents a buyer's place in an event's waiting room
+type WaitingRoomTicketResp struct {
+	EventID       string     `json:"eventId"`
+	QueueToken    string     `json:"queueToken"`
+	Status        string     `json:"status"`   // queued, admitted or expired
+	Position      int64      `json:"position"` // Place in the queue, 1 being next
+	PassExpiresAt *time.Time `json:"passExpiresAt,omitempty"`
+	PollAfter     int        `json:"pollAfter"` // Seconds to wait before polling again
+}
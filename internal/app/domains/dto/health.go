@@ -0,0 +1,32 @@
+package dto
+
+// ReadinessResp represents the readiness probe response
+type ReadinessResp struct {
+	Status    string                    `json:"status"`
+	Upstreams map[string]UpstreamStatus `json:"upstreams"`
+	// RateLimiter is the mode of the rate limiters: redis, local or disabled
+	RateLimiter string `json:"rate_limiter"`
+}
+
+// UpstreamStatus represents the connectivity of a backend service
+type UpstreamStatus struct {
+	Ready     bool             `json:"ready"`
+	Endpoints []EndpointStatus `json:"endpoints"`
+}
+
+// EndpointStatus represents the connectivity state of one endpoint of a backend service
+type EndpointStatus struct {
+	Endpoint string `json:"endpoint"`
+	State    string `json:"state"`
+}
+
+// VersionResp represents the build information of the running binary
+type VersionResp struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	Modified  bool   `json:"modified,omitempty"` // Built with uncommitted changes
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+	JSONCodec string `json:"jsonCodec"`
+}
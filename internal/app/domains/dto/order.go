@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"time"
+
+	"apigw/internal/app/domains/errs"
+)
+
+// OrderResp represents an order in responses
+type OrderResp struct {
+	ID        string    `json:"id"`
+	EventID   string    `json:"eventId"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListOrdersReq represents the query parameters of an order list request
+type ListOrdersReq struct {
+	Status []string `form:"status" binding:"omitempty,dive,oneof=pending confirmed cancelled refunded failed"`
+}
+
+// ListOrdersResp represents a page of orders
+type ListOrdersResp struct {
+	Orders     interface{} `json:"orders"` // []OrderResp, or the selected fields of each order
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// CancelOrderReq represents an order cancellation request
+type CancelOrderReq struct {
+	Reason string `json:"reason" xml:"reason" binding:"omitempty,max=500"`
+}
+
+// CancelOrderResp represents an order cancellation response
+type CancelOrderResp struct {
+	Order    OrderResp `json:"order"`
+	Refunded bool      `json:"refunded"`
+}
+
+// PurchaseTicketReq represents a ticket purchase request
+type PurchaseTicketReq struct {
+	EventID  string   `json:"eventId" xml:"eventId" binding:"required,event_id"`
+	SeatIDs  []string `json:"seatIds" xml:"seatIds>seatId" binding:"omitempty,max=10,unique,dive,required,max=64"`
+	Quantity int32    `json:"quantity" xml:"quantity" binding:"omitempty,min=1,max=10"`
+	Tier     string   `json:"tier" xml:"tier" binding:"omitempty,oneof=standard premium vip"`
+}
+
+// PurchaseBatchReq represents a request purchasing tickets for several events or seat sets at once
+type PurchaseBatchReq struct {
+	Items []PurchaseTicketReq `json:"items" xml:"items>item" binding:"required,min=1,dive"`
+}
+
+// PurchaseBatchItemResult represents the outcome of one entry of a batch purchase;
+// exactly one of Status and Error is set
+type PurchaseBatchItemResult struct {
+	Index   int    `json:"index"` // Position of the entry in the request
+	EventID string `json:"eventId"`
+	// Status is the purchase status reported by the order service, e.g. QUEUED or SOLD_OUT
+	Status string          `json:"status,omitempty"`
+	Error  *errs.HTTPError `json:"error,omitempty"`
+}
+
+// PurchaseBatchResp represents the aggregated outcome of a batch purchase, in request order
+type PurchaseBatchResp struct {
+	Results   []PurchaseBatchItemResult `json:"results"`
+	Succeeded int                       `json:"succeeded"`
+	Failed    int                       `json:"failed"`
+}
+
+// PurchaseAcceptedResp represents a purchase queued for asynchronous processing
+type PurchaseAcceptedResp struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	StatusURL string `json:"statusUrl"`
+	PollAfter int    `json:"pollAfter"` // Seconds to wait before polling the status
+}
+
+// PurchaseStatusResp represents the progress of a queued purchase
+type PurchaseStatusResp struct {
+	Reference string `json:"reference"`
+	EventID   string `json:"eventId"`
+	// Status is queued, processing, succeeded or failed
+	Status string `json:"status"`
+	// Result is the purchase status reported by the order service once succeeded
+	Result    string          `json:"result,omitempty"`
+	Error     *errs.HTTPError `json:"error,omitempty"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+// EventPath holds the event ID of event routes
+type EventPath struct {
+	EventID string `uri:"event_id" binding:"required,event_id"`
+}
+
+// OrderPath holds the order ID of order routes; the purchase status route takes the
+// reference of a queued purchase in its place
+type OrderPath struct {
+	OrderID string `uri:"order_id" binding:"required,resource_id"`
+}
+
+// PaymentPath holds the payment ID of payment routes
+type PaymentPath struct {
+	PaymentID string `uri:"payment_id" binding:"required,resource_id"`
+}
+
+// WebhookPath holds the webhook ID of partner webhook routes
+type WebhookPath struct {
+	WebhookID string `uri:"webhook_id" binding:"required,resource_id"`
+}
+
+// WebhookDeliveryPath holds the webhook and delivery IDs of a webhook delivery route
+type WebhookDeliveryPath struct {
+	WebhookID  string `uri:"webhook_id" binding:"required,resource_id"`
+	DeliveryID string `uri:"delivery_id" binding:"required,resource_id"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// CreatePaymentReq represents a payment intent creation request
+type CreatePaymentReq struct {
+	OrderID       string `json:"orderId" binding:"required,max=64"`
+	PaymentMethod string `json:"paymentMethod" binding:"required,oneof=card wallet bank_transfer"`
+	Currency      string `json:"currency" binding:"omitempty,len=3,uppercase"`
+}
+
+// ConfirmPaymentReq represents a payment confirmation request
+type ConfirmPaymentReq struct {
+	PaymentMethodToken string `json:"paymentMethodToken" binding:"required,max=512"`
+}
+
+// PaymentResp represents a payment in responses
+type PaymentResp struct {
+	ID           string    `json:"id"`
+	OrderID      string    `json:"orderId"`
+	AmountCents  int64     `json:"amountCents"`
+	Currency     string    `json:"currency"`
+	Status       string    `json:"status"`
+	ClientSecret string    `json:"clientSecret,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+// UsageResp represents the quota consumption of the calling client
+type UsageResp struct {
+	Client  string        `json:"client"` // partner:<id>, user:<id> or ip:<address>
+	Windows []QuotaWindow `json:"windows"`
+}
+
+// QuotaWindow represents the consumption of a client in one quota window
+type QuotaWindow struct {
+	Window    string    `json:"window"` // daily or monthly
+	Used      int64     `json:"used"`
+	Limit     *int64    `json:"limit"`     // null when the window is uncapped
+	Remaining *int64    `json:"remaining"` // null when the window is uncapped
+	ResetsAt  time.Time `json:"resetsAt"`
+}
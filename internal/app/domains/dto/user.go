@@ -2,9 +2,9 @@ package dto
 
 // RegisterReq represents a user registration request
 type RegisterReq struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=6"`
-	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username" xml:"username" binding:"required,min=3,max=50"`
+	Password string `json:"password" xml:"password" binding:"required,password"`
+	Email    string `json:"email" xml:"email" binding:"required,email"`
 }
 
 // RegisterResp represents a user registration response
@@ -15,8 +15,8 @@ type RegisterResp struct {
 
 // LoginReq represents a user login request
 type LoginReq struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email    string `json:"email" xml:"email" binding:"required,email"`
+	Password string `json:"password" xml:"password" binding:"required,min=6"`
 }
 
 // LoginResp represents a user login response
@@ -27,10 +27,56 @@ type LoginResp struct {
 
 // RefreshTokenReq represents a refresh token request
 type RefreshTokenReq struct {
-	RefreshToken string `json:"refreshToken" binding:"required"`
+	RefreshToken string `json:"refreshToken" xml:"refreshToken" binding:"required"`
 }
 
 // RefreshTokenResp represents a refresh token response
 type RefreshTokenResp struct {
 	AccessToken string `json:"accessToken"`
 }
+
+// ProfileResp represents a user profile response
+type ProfileResp struct {
+	ID          string `json:"id"`
+	Email       string `json:"email"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Phone       string `json:"phone"`
+}
+
+// UpdateProfileReq represents a partial profile update; omitted fields are left unchanged
+type UpdateProfileReq struct {
+	Username    *string `json:"username" binding:"omitempty,min=3,max=50"`
+	Email       *string `json:"email" binding:"omitempty,email"`
+	DisplayName *string `json:"displayName" binding:"omitempty,max=100"`
+	Phone       *string `json:"phone" binding:"omitempty,phone"`
+}
+
+// ChangePasswordReq represents a password change request
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"currentPassword" binding:"required,min=6"`
+	NewPassword     string `json:"newPassword" binding:"required,password,nefield=CurrentPassword"`
+}
+
+// EmailReq represents a request carrying only an email address
+type EmailReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// VerifyEmailReq represents an email verification confirmation
+type VerifyEmailReq struct {
+	Token string `json:"token" binding:"required,max=512"`
+}
+
+// ResetPasswordReq represents a password reset completion
+type ResetPasswordReq struct {
+	Token       string `json:"token" binding:"required,max=512"`
+	NewPassword string `json:"newPassword" binding:"required,password"`
+}
+
+// AvatarResp represents an uploaded avatar
+type AvatarResp struct {
+	AvatarURL   string `json:"avatarUrl"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
@@ -0,0 +1,13 @@
+package v2
+
+import "apigw/internal/app/domains/dto"
+
+// Page is the envelope of every v2 list response
+type Page struct {
+	Data       interface{} `json:"data"` // Items of the page, reduced to the selected fields if any
+	Pagination Pagination  `json:"pagination"`
+}
+
+// Pagination describes a page and how to fetch the next one; it is shared with the
+// standard response envelope
+type Pagination = dto.Pagination
@@ -0,0 +1,50 @@
+package v2
+
+// User represents the public user profile returned by v2 endpoints
+type User struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// Tokens represents an access/refresh token pair
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+}
+
+// RegisterReq represents a user registration request
+type RegisterReq struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Password string `json:"password" binding:"required,password"`
+	Email    string `json:"email" binding:"required,email"`
+}
+
+// RegisterResp represents a user registration response
+type RegisterResp struct {
+	User   User   `json:"user"`
+	Tokens Tokens `json:"tokens"`
+}
+
+// LoginReq represents a user login request
+type LoginReq struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// LoginResp represents a user login response
+type LoginResp struct {
+	User   User   `json:"user"`
+	Tokens Tokens `json:"tokens"`
+}
+
+// RefreshTokenReq represents a refresh token request
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshTokenResp represents a refresh token response
+type RefreshTokenResp struct {
+	Tokens Tokens `json:"tokens"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+// CreateWebhookReq represents a partner registering a callback URL; the signing secret is
+// generated when omitted
+type CreateWebhookReq struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,unique,dive,oneof=order.completed order.cancelled"`
+	Secret string   `json:"secret" binding:"omitempty,min=32,max=128"`
+}
+
+// UpdateWebhookReq represents a partial webhook update; omitted fields are left unchanged
+// and a new secret rotates the signing secret
+type UpdateWebhookReq struct {
+	URL    *string   `json:"url" binding:"omitempty,url,max=2048"`
+	Events *[]string `json:"events" binding:"omitempty,min=1,unique,dive,oneof=order.completed order.cancelled"`
+	Active *bool     `json:"active"`
+	Secret *string   `json:"secret" binding:"omitempty,min=32,max=128"`
+}
+
+// WebhookResp represents a registered webhook; the secret is only returned when created
+// or rotated
+type WebhookResp struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListWebhooksResp represents the webhooks of a partner
+type ListWebhooksResp struct {
+	Webhooks []WebhookResp `json:"webhooks"`
+}
+
+// WebhookDeliveryResp represents the delivery of an event to a webhook
+type WebhookDeliveryResp struct {
+	ID        string `json:"id"`
+	EventID   string `json:"eventId"`
+	EventType string `json:"eventType"`
+	// Status is pending, succeeded or failed
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"responseStatus,omitempty"` // HTTP status of the last attempt
+	LastError      string     `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"` // Set while pending
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// ListWebhookDeliveriesResp represents the most recent deliveries of a webhook, newest first
+type ListWebhookDeliveriesResp struct {
+	Deliveries []WebhookDeliveryResp `json:"deliveries"`
+}
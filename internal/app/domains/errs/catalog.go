@@ -0,0 +1,56 @@
+package errs
+
+import "sort"
+
+// CatalogEntry documents an error the gateway returns. Codes are stable: clients may
+// program against them, while messages may change.
+type CatalogEntry struct {
+	Code   string `json:"code"`
+	Type   string `json:"error"`
+	Status int    `json:"status"`
+	// Retriable tells whether the same request may succeed later, e.g. after Retry-After
+	Retriable bool `json:"retriable"`
+	// Message is the message template; {placeholders} are filled in where the error is returned
+	Message string `json:"message"`
+}
+
+// catalog holds the errors by code; it is filled while the package is initialized and only
+// read afterwards
+var catalog = make(map[string]CatalogEntry)
+
+// define registers an error in the catalog and returns it
+func define(errorType, code, message string, status int, retriable bool) *HTTPError {
+	document(errorType, code, message, status, retriable)
+	return NewHTTPError(errorType, code, message, status)
+}
+
+// document registers an error in the catalog whose message is composed where it is returned
+func document(errorType, code, message string, status int, retriable bool) {
+	if _, exists := catalog[code]; exists {
+		panic("errs: error code " + code + " defined twice")
+	}
+	catalog[code] = CatalogEntry{
+		Code:      code,
+		Type:      errorType,
+		Status:    status,
+		Retriable: retriable,
+		Message:   message,
+	}
+}
+
+// Lookup returns the catalog entry of an error code
+func Lookup(code string) (CatalogEntry, bool) {
+	entry, ok := catalog[code]
+	return entry, ok
+}
+
+// Catalog returns every error the gateway returns, ordered by code
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(catalog))
+	for _, entry := range catalog {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
@@ -0,0 +1,91 @@
+package errs
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// RuleBackend is the rule of field violations reported by backend services
+const RuleBackend = "backend"
+
+// QuotaViolation describes a quota a request exceeded
+type QuotaViolation struct {
+	Subject     string `json:"subject"` // What the quota applies to, e.g. user:<id>
+	Description string `json:"description,omitempty"`
+}
+
+// QuotaDetails are the details of rate limit errors reported by backend services
+type QuotaDetails struct {
+	Violations []QuotaViolation `json:"quota_violations"`
+	RetryAfter int              `json:"retry_after,omitempty"` // Seconds
+}
+
+// withStatusDetails returns the error with the details a backend attached to its status:
+// the field violations of client errors, the quota violations of rate limit errors, and
+// the retry delay of any error. Other details are dropped.
+func withStatusDetails(httpErr *HTTPError, st *status.Status) *HTTPError {
+	var violations []FieldViolation
+	var quota []QuotaViolation
+	var retryAfter time.Duration
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, fv := range d.GetFieldViolations() {
+				violations = append(violations, FieldViolation{
+					Field:   jsonFieldPath(fv.GetField()),
+					Rule:    RuleBackend,
+					Message: fv.GetDescription(),
+				})
+			}
+		case *errdetails.QuotaFailure:
+			for _, v := range d.GetViolations() {
+				quota = append(quota, QuotaViolation{Subject: v.GetSubject(), Description: v.GetDescription()})
+			}
+		case *errdetails.RetryInfo:
+			retryAfter = max(d.GetRetryDelay().AsDuration(), 0)
+		}
+	}
+	if len(violations) == 0 && len(quota) == 0 && retryAfter == 0 {
+		return httpErr
+	}
+
+	withDetails := *httpErr
+	withDetails.RetryAfter = retryAfter
+	switch {
+	case httpErr.Status == http.StatusTooManyRequests && len(quota) > 0:
+		withDetails.Details = QuotaDetails{Violations: quota, RetryAfter: RetryAfterSeconds(retryAfter)}
+	case httpErr.Status < http.StatusInternalServerError && len(violations) > 0:
+		withDetails.Details = violations
+	}
+	return &withDetails
+}
+
+// RetryAfterSeconds rounds a retry delay up to the whole seconds of a Retry-After header
+func RetryAfterSeconds(delay time.Duration) int {
+	return int(math.Ceil(delay.Seconds()))
+}
+
+// jsonFieldPath turns the protobuf field path of a backend violation into the JSON path
+// clients send, e.g. items[0].seat_ids becomes items[0].seatIds
+func jsonFieldPath(path string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range path {
+		switch {
+		case r == '_':
+			upper = b.Len() > 0
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
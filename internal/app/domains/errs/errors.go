@@ -2,7 +2,9 @@ package errs
 
 import (
 	"net/http"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -12,7 +14,13 @@ type HTTPError struct {
 	ErrorType string `json:"error"`
 	Code      string `json:"code"`
 	Message   string `json:"message"`
-	Status    int    `json:"-"`
+	RequestID string `json:"request_id,omitempty"`
+	// Details carries structured information on the error, e.g. the field violations of a
+	// validation error
+	Details interface{} `json:"details,omitempty"`
+	Status  int         `json:"-"`
+	// RetryAfter is the delay after which the request may be retried, sent as Retry-After
+	RetryAfter time.Duration `json:"-"`
 }
 
 // Error implements the error interface
@@ -20,6 +28,22 @@ func (e *HTTPError) Error() string {
 	return e.Message
 }
 
+// WithRequestID returns a copy of the error identifying the request it answers, leaving
+// the shared error values untouched
+func (e *HTTPError) WithRequestID(requestID string) *HTTPError {
+	withID := *e
+	withID.RequestID = requestID
+	return &withID
+}
+
+// WithMessage returns a copy of the error with another message, for errors whose catalog
+// message is a template
+func (e *HTTPError) WithMessage(message string) *HTTPError {
+	withMessage := *e
+	withMessage.Message = message
+	return &withMessage
+}
+
 // NewHTTPError creates a new HTTP error
 func NewHTTPError(errorType, code, message string, status int) *HTTPError {
 	return &HTTPError{
@@ -32,16 +56,118 @@ func NewHTTPError(errorType, code, message string, status int) *HTTPError {
 
 // Common HTTP errors
 var (
-	ErrBadRequest         = NewHTTPError("VALIDATION_ERROR", "BAD_REQUEST", "Invalid request", http.StatusBadRequest)
-	ErrUnauthorized       = NewHTTPError("AUTHENTICATION_ERROR", "UNAUTHORIZED", "Authentication required", http.StatusUnauthorized)
-	ErrForbidden          = NewHTTPError("AUTHORIZATION_ERROR", "FORBIDDEN", "Access denied", http.StatusForbidden)
-	ErrNotFound           = NewHTTPError("NOT_FOUND_ERROR", "RESOURCE_NOT_FOUND", "Resource not found", http.StatusNotFound)
-	ErrConflict           = NewHTTPError("CONFLICT_ERROR", "RESOURCE_CONFLICT", "Resource conflict", http.StatusConflict)
-	ErrInternalServer     = NewHTTPError("INTERNAL_ERROR", "INTERNAL_SERVER_ERROR", "Internal server error", http.StatusInternalServerError)
-	ErrServiceUnavailable = NewHTTPError("SERVICE_ERROR", "SERVICE_UNAVAILABLE", "Service temporarily unavailable", http.StatusServiceUnavailable)
+	ErrBadRequest         = define("VALIDATION_ERROR", "BAD_REQUEST", "Invalid request", http.StatusBadRequest, false)
+	ErrUnauthorized       = define("AUTHENTICATION_ERROR", "UNAUTHORIZED", "Authentication required", http.StatusUnauthorized, false)
+	ErrForbidden          = define("AUTHORIZATION_ERROR", "FORBIDDEN", "Access denied", http.StatusForbidden, false)
+	ErrNotFound           = define("NOT_FOUND_ERROR", "RESOURCE_NOT_FOUND", "Resource not found", http.StatusNotFound, false)
+	ErrConflict           = define("CONFLICT_ERROR", "RESOURCE_CONFLICT", "Resource conflict", http.StatusConflict, false)
+	ErrInternalServer     = define("INTERNAL_ERROR", "INTERNAL_SERVER_ERROR", "Internal server error", http.StatusInternalServerError, false)
+	ErrServiceUnavailable = define("SERVICE_ERROR", "SERVICE_UNAVAILABLE", "Service temporarily unavailable", http.StatusServiceUnavailable, true)
+	ErrOverloaded         = define("SERVICE_ERROR", "OVERLOADED", "The service is overloaded, please try again shortly", http.StatusServiceUnavailable, true)
+)
+
+// Authentication errors
+var (
+	ErrMissingToken       = define("AUTHENTICATION_ERROR", "MISSING_TOKEN", "Authorization header is required", http.StatusUnauthorized, false)
+	ErrInvalidTokenFormat = define("AUTHENTICATION_ERROR", "INVALID_TOKEN_FORMAT", "Token must be in format: Bearer <token>", http.StatusUnauthorized, false)
+	ErrInvalidToken       = define("AUTHENTICATION_ERROR", "INVALID_TOKEN", "Invalid or expired token", http.StatusUnauthorized, false)
+)
+
+// Rate limiting and abuse protection errors
+var (
+	ErrRateLimitExceeded     = define("RATE_LIMIT_ERROR", "RATE_LIMIT_EXCEEDED", "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests, true)
+	ErrTooManyFailedAttempts = define("RATE_LIMIT_ERROR", "TOO_MANY_FAILED_ATTEMPTS", "Too many failed attempts. Please try again later.", http.StatusTooManyRequests, true)
+	ErrCaptchaRequired       = define("CAPTCHA_ERROR", "CAPTCHA_REQUIRED", "Please complete the CAPTCHA challenge", http.StatusPreconditionRequired, true)
+	ErrCaptchaUnavailable    = define("CAPTCHA_ERROR", "CAPTCHA_UNAVAILABLE", "CAPTCHA verification is temporarily unavailable", http.StatusServiceUnavailable, true)
+	ErrCaptchaInvalid        = define("CAPTCHA_ERROR", "CAPTCHA_INVALID", "The CAPTCHA challenge was not solved", http.StatusForbidden, true)
+	ErrDailyQuotaExceeded    = define("QUOTA_ERROR", "DAILY_QUOTA_EXCEEDED", "The daily quota of API calls is used up", http.StatusTooManyRequests, true)
+	ErrMonthlyQuotaExceeded  = define("QUOTA_ERROR", "MONTHLY_QUOTA_EXCEEDED", "The monthly quota of API calls is used up", http.StatusTooManyRequests, true)
+	ErrWaitingRoomQueued     = define("WAITING_ROOM_ERROR", "WAITING_ROOM_QUEUED", "The on-sale is busy, you have been placed in the waiting room", http.StatusTooManyRequests, true)
+)
+
+// Request validation errors; handlers describe the offending value in the message
+var (
+	ErrInvalidRequest   = define("VALIDATION_ERROR", "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, false)
+	ErrInvalidQuery     = define("VALIDATION_ERROR", "INVALID_QUERY", "Invalid query parameters", http.StatusBadRequest, false)
+	ErrInvalidEventID   = define("VALIDATION_ERROR", "INVALID_EVENT_ID", "Invalid event ID", http.StatusBadRequest, false)
+	ErrInvalidOrderID   = define("VALIDATION_ERROR", "INVALID_ORDER_ID", "Invalid order ID", http.StatusBadRequest, false)
+	ErrInvalidPaymentID = define("VALIDATION_ERROR", "INVALID_PAYMENT_ID", "Invalid payment ID", http.StatusBadRequest, false)
+	ErrInvalidWebhookID = define("VALIDATION_ERROR", "INVALID_WEBHOOK_ID", "Invalid webhook or delivery ID", http.StatusBadRequest, false)
+	ErrInvalidQuantity  = define("VALIDATION_ERROR", "INVALID_QUANTITY", "Quantity must match the number of selected seats", http.StatusBadRequest, false)
+	ErrEmptyUpdate      = define("VALIDATION_ERROR", "EMPTY_UPDATE", "At least one field must be provided", http.StatusBadRequest, false)
+	ErrBatchTooLarge    = define("VALIDATION_ERROR", "BATCH_TOO_LARGE", "A batch may contain at most {max_items} entries", http.StatusBadRequest, false)
+	ErrInvalidNetwork   = define("VALIDATION_ERROR", "INVALID_NETWORK", "Network must be a CIDR range or IP address", http.StatusBadRequest, false)
+	ErrInvalidTTL       = define("VALIDATION_ERROR", "INVALID_TTL", "TTL must be a positive duration such as 2h", http.StatusBadRequest, false)
+	ErrUnknownTenant    = define("VALIDATION_ERROR", "UNKNOWN_TENANT", "The tenant of the request is not known", http.StatusBadRequest, false)
+	ErrUnknownRegion    = define("VALIDATION_ERROR", "UNKNOWN_REGION", "The region of the request is not known", http.StatusBadRequest, false)
+)
+
+// API version errors
+var (
+	ErrAPIVersionRetired = define("VERSION_ERROR", "API_VERSION_RETIRED", "API version {version} is no longer available", http.StatusGone, false)
+)
+
+// Request body errors
+var (
+	ErrPayloadTooLarge = define("VALIDATION_ERROR", "PAYLOAD_TOO_LARGE", "Request body is too large", http.StatusRequestEntityTooLarge, false)
+	ErrJSONTooDeep     = define("VALIDATION_ERROR", "JSON_TOO_DEEP", "Request body is nested too deeply", http.StatusBadRequest, false)
+	ErrXMLNotAccepted  = define("VALIDATION_ERROR", "UNSUPPORTED_MEDIA_TYPE", "XML request bodies are not accepted on this route", http.StatusUnsupportedMediaType, false)
+)
+
+// Request signature errors
+var (
+	ErrSignatureMissing  = define("AUTHENTICATION_ERROR", "SIGNATURE_MISSING", "Request signature is required", http.StatusUnauthorized, false)
+	ErrSignatureInvalid  = define("AUTHENTICATION_ERROR", "SIGNATURE_INVALID", "Request signature is invalid", http.StatusUnauthorized, false)
+	ErrSignatureExpired  = define("AUTHENTICATION_ERROR", "SIGNATURE_EXPIRED", "Request timestamp is outside the accepted window", http.StatusUnauthorized, false)
+	ErrSignatureReplayed = define("AUTHENTICATION_ERROR", "SIGNATURE_REPLAYED", "Request has already been received", http.StatusUnauthorized, false)
+)
+
+// Order errors
+var (
+	ErrOrderNotRefundable     = define("ORDER_ERROR", "ORDER_NOT_REFUNDABLE", "Order can no longer be cancelled or refunded", http.StatusConflict, false)
+	ErrPurchaseQueueFull      = define("SERVICE_ERROR", "PURCHASE_QUEUE_FULL", "Too many purchases are waiting, please try again later", http.StatusServiceUnavailable, true)
+	ErrPurchaseOutcomeUnknown = define("ORDER_ERROR", "PURCHASE_OUTCOME_UNKNOWN", "The purchase was interrupted and may have been made, check your orders before purchasing again", http.StatusBadGateway, false)
+)
+
+// Avatar errors
+var (
+	ErrAvatarMissing  = define("VALIDATION_ERROR", "AVATAR_REQUIRED", "The image must be sent in the avatar field of a multipart/form-data body", http.StatusBadRequest, false)
+	ErrAvatarType     = define("VALIDATION_ERROR", "UNSUPPORTED_AVATAR_TYPE", "The image type is not supported", http.StatusUnsupportedMediaType, false)
+	ErrAvatarTooLarge = define("VALIDATION_ERROR", "AVATAR_TOO_LARGE", "The image is too large", http.StatusRequestEntityTooLarge, false)
 )
 
-// GRPCToHTTPError converts a gRPC error to an appropriate HTTP error
+// Webhook errors
+var (
+	ErrWebhookLimitReached = define("WEBHOOK_ERROR", "WEBHOOK_LIMIT_REACHED", "The maximum number of webhooks is registered", http.StatusConflict, false)
+	ErrWebhookInvalidURL   = define("VALIDATION_ERROR", "INVALID_WEBHOOK_URL", "Webhook URL must be an https URL without credentials", http.StatusBadRequest, false)
+)
+
+// Fault injection errors; requests fail with the status of the fault injection rule
+var (
+	ErrFaultInjected = define("FAULT_ERROR", "FAULT_INJECTED", "Fault injected for resilience testing", http.StatusServiceUnavailable, true)
+)
+
+// Errors of backend services. Client errors carry the message of the backend's gRPC
+// status; server errors carry a generic message, the backend's message is only logged.
+var (
+	errInvalidArgument      = define("VALIDATION_ERROR", "INVALID_ARGUMENT", "{backend message}", http.StatusBadRequest, false)
+	errAlreadyExists        = define("CONFLICT_ERROR", "RESOURCE_ALREADY_EXISTS", "{backend message}", http.StatusConflict, false)
+	errPermissionDenied     = define("AUTHORIZATION_ERROR", "PERMISSION_DENIED", "{backend message}", http.StatusForbidden, false)
+	errUnauthenticated      = define("AUTHENTICATION_ERROR", "UNAUTHENTICATED", "{backend message}", http.StatusUnauthorized, false)
+	errPreconditionFailed   = define("PRECONDITION_ERROR", "PRECONDITION_FAILED", "{backend message}", http.StatusBadRequest, false)
+	errOperationAborted     = define("CONFLICT_ERROR", "OPERATION_ABORTED", "{backend message}", http.StatusConflict, true)
+	errOutOfRange           = define("VALIDATION_ERROR", "OUT_OF_RANGE", "{backend message}", http.StatusBadRequest, false)
+	errRequestTimeout       = define("TIMEOUT_ERROR", "REQUEST_TIMEOUT", "{backend message}", http.StatusRequestTimeout, true)
+	errRequestCanceled      = define("CANCELED_ERROR", "REQUEST_CANCELED", "{backend message}", http.StatusRequestTimeout, false)
+	errMethodNotImplemented = define("NOT_IMPLEMENTED_ERROR", "METHOD_NOT_IMPLEMENTED", "The operation is not available", http.StatusNotImplemented, false)
+	errDataLoss             = define("DATA_ERROR", "DATA_LOSS", "Internal server error", http.StatusInternalServerError, false)
+	errUnknown              = define("UNKNOWN_ERROR", "UNKNOWN_ERROR", "Internal server error", http.StatusInternalServerError, false)
+)
+
+// GRPCToHTTPError converts a gRPC error to an appropriate HTTP error. Client errors carry
+// the backend's message, or its localized message when the status has one; server errors
+// carry the generic message of the catalog so internal details never reach clients. The
+// field violations, quota violations and retry delay attached to the status are kept.
 func GRPCToHTTPError(err error) *HTTPError {
 	if err == nil {
 		return nil
@@ -54,42 +180,75 @@ func GRPCToHTTPError(err error) *HTTPError {
 		return ErrInternalServer
 	}
 
-	// Map gRPC codes to HTTP errors
+	httpErr := statusToHTTPError(st)
+	if httpErr == nil {
+		return nil
+	}
+	return withStatusDetails(httpErr, st)
+}
+
+// statusToHTTPError maps the code of a gRPC status to an HTTP error
+func statusToHTTPError(st *status.Status) *HTTPError {
+	message := publicMessage(st)
 	switch st.Code() {
 	case codes.OK:
 		return nil
 	case codes.InvalidArgument:
-		return NewHTTPError("VALIDATION_ERROR", "INVALID_ARGUMENT", st.Message(), http.StatusBadRequest)
+		return errInvalidArgument.WithMessage(message)
 	case codes.NotFound:
-		return NewHTTPError("NOT_FOUND_ERROR", "RESOURCE_NOT_FOUND", st.Message(), http.StatusNotFound)
+		return ErrNotFound.WithMessage(message)
 	case codes.AlreadyExists:
-		return NewHTTPError("CONFLICT_ERROR", "RESOURCE_ALREADY_EXISTS", st.Message(), http.StatusConflict)
+		return errAlreadyExists.WithMessage(message)
 	case codes.PermissionDenied:
-		return NewHTTPError("AUTHORIZATION_ERROR", "PERMISSION_DENIED", st.Message(), http.StatusForbidden)
+		return errPermissionDenied.WithMessage(message)
 	case codes.Unauthenticated:
-		return NewHTTPError("AUTHENTICATION_ERROR", "UNAUTHENTICATED", st.Message(), http.StatusUnauthorized)
+		return errUnauthenticated.WithMessage(message)
 	case codes.ResourceExhausted:
-		return NewHTTPError("RATE_LIMIT_ERROR", "RATE_LIMIT_EXCEEDED", st.Message(), http.StatusTooManyRequests)
+		return ErrRateLimitExceeded.WithMessage(message)
 	case codes.FailedPrecondition:
-		return NewHTTPError("PRECONDITION_ERROR", "PRECONDITION_FAILED", st.Message(), http.StatusBadRequest)
+		return errPreconditionFailed.WithMessage(message)
 	case codes.Aborted:
-		return NewHTTPError("CONFLICT_ERROR", "OPERATION_ABORTED", st.Message(), http.StatusConflict)
+		return errOperationAborted.WithMessage(message)
 	case codes.OutOfRange:
-		return NewHTTPError("VALIDATION_ERROR", "OUT_OF_RANGE", st.Message(), http.StatusBadRequest)
+		return errOutOfRange.WithMessage(message)
+	case codes.DeadlineExceeded:
+		return errRequestTimeout.WithMessage(message)
+	case codes.Canceled:
+		return errRequestCanceled.WithMessage(message)
 	case codes.Unimplemented:
-		return NewHTTPError("NOT_IMPLEMENTED_ERROR", "METHOD_NOT_IMPLEMENTED", st.Message(), http.StatusNotImplemented)
+		return errMethodNotImplemented
 	case codes.Internal:
-		return NewHTTPError("INTERNAL_ERROR", "INTERNAL_SERVER_ERROR", st.Message(), http.StatusInternalServerError)
+		return ErrInternalServer
 	case codes.Unavailable:
-		return NewHTTPError("SERVICE_ERROR", "SERVICE_UNAVAILABLE", st.Message(), http.StatusServiceUnavailable)
+		return ErrServiceUnavailable
 	case codes.DataLoss:
-		return NewHTTPError("DATA_ERROR", "DATA_LOSS", st.Message(), http.StatusInternalServerError)
-	case codes.DeadlineExceeded:
-		return NewHTTPError("TIMEOUT_ERROR", "REQUEST_TIMEOUT", st.Message(), http.StatusRequestTimeout)
-	case codes.Canceled:
-		return NewHTTPError("CANCELED_ERROR", "REQUEST_CANCELED", st.Message(), http.StatusRequestTimeout)
+		return errDataLoss
+	default:
+		return errUnknown
+	}
+}
+
+// publicMessage returns the message of a status meant for end users: its localized
+// message detail when the backend attached one, its message otherwise
+func publicMessage(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if localized, ok := detail.(*errdetails.LocalizedMessage); ok && localized.GetMessage() != "" {
+			return localized.GetMessage()
+		}
+	}
+	return st.Message()
+}
+
+// PublicStatus returns a status safe to return to gRPC clients: the message of server
+// errors, which may describe backend internals, is replaced by a generic one
+func PublicStatus(st *status.Status) *status.Status {
+	switch st.Code() {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		return status.New(st.Code(), "internal error")
+	case codes.Unavailable:
+		return status.New(st.Code(), "service temporarily unavailable")
 	default:
-		return NewHTTPError("UNKNOWN_ERROR", "UNKNOWN_ERROR", st.Message(), http.StatusInternalServerError)
+		return st
 	}
 }
 
@@ -0,0 +1,45 @@
+package errs
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the content type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem represents an error as RFC 7807 problem details. The code, request ID and details
+// of the error are kept as extension members so clients can still tell errors apart.
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	Code      string      `json:"code,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Problem returns the error as problem details. The type URI is typeBase followed by the
+// error code in kebab case, or about:blank without a base; instance identifies the request.
+func (e *HTTPError) Problem(typeBase, instance string) *Problem {
+	problemType := "about:blank"
+	if typeBase != "" && e.Code != "" {
+		problemType = strings.TrimSuffix(typeBase, "/") + "/" + strings.ToLower(strings.ReplaceAll(e.Code, "_", "-"))
+	}
+	title := http.StatusText(e.Status)
+	if title == "" {
+		title = e.ErrorType
+	}
+	return &Problem{
+		Type:      problemType,
+		Title:     title,
+		Status:    e.Status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: e.RequestID,
+		Details:   e.Details,
+	}
+}
@@ -0,0 +1,11 @@
+package errs
+
+// FieldViolation describes a request field that failed validation, so that clients can
+// point at the offending field
+type FieldViolation struct {
+	Field      string `json:"field"`                // JSON path of the field, e.g. items[0].quantity
+	Rule       string `json:"rule"`                 // Failed rule, e.g. required, max or type
+	Constraint string `json:"constraint,omitempty"` // Parameter of the rule, e.g. 100 for max=100
+	Type       string `json:"type,omitempty"`       // JSON type of the provided value
+	Message    string `json:"message,omitempty"`    // Description of the violation, e.g. given by a backend service
+}
@@ -0,0 +1,97 @@
+// Package drain coordinates the graceful drain of the gateway: once started, the
+// readiness probe fails so load balancers stop sending traffic, and the server is shut
+// down after the configured delay, once in-flight requests have completed
+package drain
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apigw/internal/app/metrics"
+)
+
+// Drainer tracks in-flight requests and the drain state of the process
+type Drainer struct {
+	inFlight atomic.Int64
+
+	once      sync.Once
+	mu        sync.Mutex
+	startedAt time.Time
+	reason    string
+	started   chan struct{}
+}
+
+// Status describes the drain state
+type Status struct {
+	Draining  bool
+	StartedAt time.Time
+	Reason    string // signal, admin or upgrade
+	InFlight  int64
+}
+
+// New creates a drainer that is not draining
+func New() *Drainer {
+	return &Drainer{started: make(chan struct{})}
+}
+
+// Start starts draining and reports whether this call started it; later calls are no-ops
+func (d *Drainer) Start(reason string) bool {
+	started := false
+	d.once.Do(func() {
+		d.mu.Lock()
+		d.startedAt = time.Now().UTC()
+		d.reason = reason
+		d.mu.Unlock()
+		close(d.started)
+		metrics.Draining.Set(1)
+		started = true
+	})
+	return started
+}
+
+// Started is closed once draining has started
+func (d *Drainer) Started() <-chan struct{} {
+	return d.started
+}
+
+// Draining reports whether draining has started
+func (d *Drainer) Draining() bool {
+	select {
+	case <-d.started:
+		return true
+	default:
+		return false
+	}
+}
+
+// InFlight returns the number of requests being served
+func (d *Drainer) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+// Status returns the drain state
+func (d *Drainer) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return Status{
+		Draining:  d.Draining(),
+		StartedAt: d.startedAt,
+		Reason:    d.reason,
+		InFlight:  d.InFlight(),
+	}
+}
+
+// Track counts the requests served by next as in flight
+func (d *Drainer) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.inFlight.Add(1)
+		metrics.HTTPRequestsInFlight.Inc()
+		defer func() {
+			d.inFlight.Add(-1)
+			metrics.HTTPRequestsInFlight.Dec()
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
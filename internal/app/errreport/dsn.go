@@ -0,0 +1,41 @@
+package errreport
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// dsn is a parsed Sentry DSN: https://<public key>@<host>/<path>/<project ID>
+type dsn struct {
+	raw       string
+	publicKey string
+	envelope  string // URL events are posted to
+}
+
+// parseDSN parses a DSN into the endpoint of its project's envelopes
+func parseDSN(raw string) (dsn, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return dsn{}, fmt.Errorf("invalid DSN: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return dsn{}, fmt.Errorf("invalid DSN: must be an http or https URL")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return dsn{}, fmt.Errorf("invalid DSN: no public key")
+	}
+	path := strings.Trim(u.Path, "/")
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	if project == "" {
+		return dsn{}, fmt.Errorf("invalid DSN: no project ID")
+	}
+	return dsn{
+		raw:       raw,
+		publicKey: u.User.Username(),
+		envelope:  fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+	}, nil
+}
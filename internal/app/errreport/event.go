@@ -0,0 +1,176 @@
+package errreport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Levels of events
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelInfo    = "info"
+)
+
+// Kinds of events, as counted in apigw_reported_errors_total
+const (
+	KindPanic    = "panic"
+	KindResponse = "response"
+	KindUpstream = "upstream"
+)
+
+// inAppPrefix marks the frames of the gateway's own code
+const inAppPrefix = "apigw/"
+
+// maxFrames bounds the frames of a stack trace
+const maxFrames = 64
+
+// Event is an error reported, with the context it happened in
+type Event struct {
+	Kind    string // panic, response or upstream
+	Level   string // error, warning or info
+	Message string
+	// Exception is the type of the error, e.g. "panic"; the message is its value. Events
+	// without one are reported as messages.
+	Exception string
+	Stack     []Frame
+	Request   *Request
+	UserID    string // Hashed before being sent
+	Tags      map[string]string
+	Extra     map[string]any
+	// Fingerprint groups events into issues; empty leaves grouping to the service
+	Fingerprint []string
+}
+
+// Request is the request an event happened in
+type Request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Frame is a frame of a stack trace
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Line     int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Stack returns the stack of the calling goroutine, skipping skip frames above the
+// caller and the frames of the runtime, e.g. those unwinding a panic. Frames are ordered
+// from the outermost call, as events expect them.
+func Stack(skip int) []Frame {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			module, function := splitFunction(frame.Function)
+			stack = append(stack, Frame{
+				Function: function,
+				Module:   module,
+				Filename: shortPath(frame.File),
+				AbsPath:  frame.File,
+				Line:     frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, inAppPrefix),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return stack
+}
+
+// splitFunction splits a qualified function name into its package and its name, e.g.
+// apigw/internal/app/handler.(*OrderHandler).Purchase
+func splitFunction(qualified string) (module, function string) {
+	slash := strings.LastIndex(qualified, "/")
+	dot := strings.Index(qualified[slash+1:], ".")
+	if dot < 0 {
+		return "", qualified
+	}
+	return qualified[:slash+1+dot], qualified[slash+2+dot:]
+}
+
+// shortPath keeps the last two elements of a file path, e.g. handler/order.go
+func shortPath(file string) string {
+	i := strings.LastIndex(file, "/")
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
+
+// hashUserID hashes a user ID so that events of a user can be told apart without
+// revealing who they are
+func hashUserID(id string, key []byte) string {
+	if len(key) == 0 {
+		sum := sha256.Sum256([]byte(id))
+		return hex.EncodeToString(sum[:16])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// wireEvent is an event as the envelope endpoint takes it
+type wireEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     *wireMessage      `json:"message,omitempty"`
+	Exception   *wireExceptions   `json:"exception,omitempty"`
+	Request     *Request          `json:"request,omitempty"`
+	User        *wireUser         `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+// wireMessage is the message of an event without exception
+type wireMessage struct {
+	Formatted string `json:"formatted"`
+}
+
+// wireExceptions holds the exception of an event
+type wireExceptions struct {
+	Values []wireException `json:"values"`
+}
+
+// wireException is an error with its stack trace
+type wireException struct {
+	Type       string          `json:"type"`
+	Value      string          `json:"value"`
+	Stacktrace *wireStacktrace `json:"stacktrace,omitempty"`
+}
+
+// wireStacktrace is the stack trace of an exception
+type wireStacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// wireUser identifies the user of an event by the hash of their ID
+type wireUser struct {
+	ID string `json:"id"`
+}
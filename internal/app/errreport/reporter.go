@@ -0,0 +1,252 @@
+// Package errreport reports errors to Sentry, or a service speaking its protocol, with the
+// context of the request they happened in: panics, 5xx responses and backend services
+// becoming unreachable. Events are sent in the background; those arriving faster than
+// they are sent are dropped past the buffer.
+package errreport
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"apigw/internal/app/buildinfo"
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// loggerName is the logger events are attributed to
+const loggerName = "apigw"
+
+// Reporter sends events to the envelope endpoint of a DSN. The methods of a nil Reporter
+// do nothing, so errors are reported whether or not reporting is enabled.
+type Reporter struct {
+	dsn         dsn
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	userHashKey []byte
+	sampleRate  float64
+	upstreams   bool
+	client      *http.Client
+	logger      *logrus.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan queuedEvent
+	done   chan struct{}
+
+	// Owned by the sending goroutine: events are dropped until then after a 429
+	retryAt time.Time
+}
+
+// queuedEvent is an event waiting to be sent, with its kind for the metrics
+type queuedEvent struct {
+	kind  string
+	event *wireEvent
+}
+
+// New creates a reporter and starts sending events
+func New(cfg config.SentryConfig, app config.AppConfig, logger *logrus.Logger) (*Reporter, error) {
+	parsed, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	build := buildinfo.Get()
+	r := &Reporter{
+		dsn:         parsed,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=%s/%s", parsed.publicKey, loggerName, build.Version),
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		userHashKey: []byte(cfg.UserHashKey),
+		sampleRate:  cfg.SampleRate,
+		upstreams:   cfg.UpstreamEvents,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		logger:      logger,
+		queue:       make(chan queuedEvent, cfg.Buffer),
+		done:        make(chan struct{}),
+	}
+	if r.environment == "" {
+		r.environment = app.Environment
+	}
+	if r.release == "" {
+		r.release = build.Version
+	}
+	r.serverName, _ = os.Hostname()
+	go r.run()
+	return r, nil
+}
+
+// SampleResponse reports whether a 5xx response is picked by the sample rate
+func (r *Reporter) SampleResponse() bool {
+	return r != nil && rand.Float64() < r.sampleRate
+}
+
+// UpstreamEvents reports whether backend services becoming unreachable are reported
+func (r *Reporter) UpstreamEvents() bool {
+	return r != nil && r.upstreams
+}
+
+// Capture queues an event to be sent, dropping it when the buffer is full
+func (r *Reporter) Capture(event Event) {
+	if r == nil {
+		return
+	}
+	wire := r.wireEvent(event)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- queuedEvent{kind: event.Kind, event: wire}:
+	default:
+		metrics.ReportedErrors.WithLabelValues(event.Kind, "dropped").Inc()
+	}
+}
+
+// Close sends the queued events, giving up on those left once ctx is done
+func (r *Reporter) Close(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.queue)
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		r.logger.Warn("Error reports left unsent at shutdown")
+	}
+}
+
+// wireEvent builds the event sent for a captured one
+func (r *Reporter) wireEvent(event Event) *wireEvent {
+	wire := &wireEvent{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC(),
+		Platform:    "go",
+		Level:       event.Level,
+		Logger:      loggerName,
+		ServerName:  r.serverName,
+		Release:     r.release,
+		Environment: r.environment,
+		Request:     event.Request,
+		Tags:        event.Tags,
+		Extra:       event.Extra,
+		Fingerprint: event.Fingerprint,
+	}
+	if wire.Level == "" {
+		wire.Level = LevelError
+	}
+	if event.Exception != "" {
+		exception := wireException{Type: event.Exception, Value: event.Message}
+		if len(event.Stack) > 0 {
+			exception.Stacktrace = &wireStacktrace{Frames: event.Stack}
+		}
+		wire.Exception = &wireExceptions{Values: []wireException{exception}}
+	} else {
+		wire.Message = &wireMessage{Formatted: event.Message}
+	}
+	if event.UserID != "" {
+		wire.User = &wireUser{ID: hashUserID(event.UserID, r.userHashKey)}
+	}
+	return wire
+}
+
+// run sends the queued events until the reporter is closed
+func (r *Reporter) run() {
+	defer close(r.done)
+	for queued := range r.queue {
+		if time.Now().Before(r.retryAt) {
+			metrics.ReportedErrors.WithLabelValues(queued.kind, "rate_limited").Inc()
+			continue
+		}
+		if err := r.send(queued.event); err != nil {
+			metrics.ReportedErrors.WithLabelValues(queued.kind, "failed").Inc()
+			r.logger.WithError(err).WithField("event_id", queued.event.EventID).Error("Failed to report error")
+			continue
+		}
+		metrics.ReportedErrors.WithLabelValues(queued.kind, "sent").Inc()
+	}
+}
+
+// errRateLimited is returned when the service asks to hold events back
+var errRateLimited = errors.New("rate limited by the error reporting service")
+
+// send posts an event in an envelope
+func (r *Reporter) send(event *wireEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	var body bytes.Buffer
+	header, _ := json.Marshal(map[string]any{
+		"event_id": event.EventID,
+		"sent_at":  time.Now().UTC(),
+		"dsn":      r.dsn.raw,
+	})
+	body.Write(header)
+	fmt.Fprintf(&body, "\n{\"type\":\"event\",\"length\":%d}\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, r.dsn.envelope, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		// The URL embeds the public key, so it is left out of the logged error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if retryAfter <= 0 {
+			retryAfter = 60
+		}
+		r.retryAt = time.Now().Add(time.Duration(retryAfter) * time.Second)
+		return errRateLimited
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("error reporting service answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newEventID returns a random event ID, 32 hex digits
+func newEventID() string {
+	var id [16]byte
+	crand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
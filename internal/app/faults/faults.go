@@ -0,0 +1,111 @@
+// Package faults picks the faults injected into requests and backend calls for resilience
+// testing: a share of the requests of chosen routes, or of the calls to chosen upstreams,
+// is delayed, failed or has its connection reset, as configured under faults
+package faults
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Fault is what is done to a request
+type Fault struct {
+	Latency time.Duration
+	Status  int        // HTTP status a route request fails with; 0 to proceed
+	Code    codes.Code // gRPC code an upstream call fails with; OK to proceed
+	Reset   bool       // The connection is cut instead
+}
+
+// Kind names the failure of the fault for logs and metrics: reset, status, code or latency
+func (f Fault) Kind() string {
+	switch {
+	case f.Reset:
+		return "reset"
+	case f.Status != 0:
+		return "status"
+	case f.Code != codes.OK:
+		return "code"
+	default:
+		return "latency"
+	}
+}
+
+// rule is a configured rule with its target parsed
+type rule struct {
+	method     string // Route rules only; empty for any method
+	target     string // Route pattern, service or service/method
+	percentage float64
+	fault      Fault
+}
+
+// Injector picks the faults of requests from the configured rules
+type Injector struct {
+	routes    []rule
+	upstreams []rule
+	// roll returns a number in [0, 100)
+	roll func() float64
+}
+
+// New creates an injector from the configured rules; it injects nothing when fault
+// injection is disabled. The configuration is expected to be valid.
+func New(cfg config.FaultsConfig) *Injector {
+	inj := &Injector{roll: func() float64 { return rand.Float64() * 100 }}
+	if !cfg.Enabled {
+		return inj
+	}
+	for _, rc := range cfg.Rules {
+		r := rule{percentage: rc.Percentage, fault: Fault{Latency: rc.Latency, Status: rc.Status, Reset: rc.Reset}}
+		if rc.Route != "" {
+			r.target = rc.Route
+			if method, pattern, ok := strings.Cut(rc.Route, " "); ok {
+				r.method, r.target = method, pattern
+			}
+			inj.routes = append(inj.routes, r)
+			continue
+		}
+		if rc.Code != "" {
+			r.fault.Code, _ = rc.GRPCCode()
+		}
+		r.target = rc.Upstream
+		inj.upstreams = append(inj.upstreams, r)
+	}
+	return inj
+}
+
+// Enabled reports whether any rule applies to routes or upstreams
+func (i *Injector) Enabled() bool {
+	return len(i.routes) > 0 || len(i.upstreams) > 0
+}
+
+// Route picks the fault of a request to a route pattern, e.g. /api/v1/events/:event_id
+func (i *Injector) Route(method, route string) (Fault, bool) {
+	return i.pick(i.routes, "route", route, func(r rule) bool {
+		return r.target == route && (r.method == "" || r.method == method)
+	})
+}
+
+// Upstream picks the fault of a call to a backend method, e.g. /order.OrderService/CancelOrder
+func (i *Injector) Upstream(fullMethod string) (Fault, bool) {
+	method := strings.TrimPrefix(fullMethod, "/")
+	service, _, _ := strings.Cut(method, "/")
+	return i.pick(i.upstreams, "upstream", method, func(r rule) bool {
+		return r.target == method || r.target == service
+	})
+}
+
+// pick returns the fault of the first matching rule that fires
+func (i *Injector) pick(rules []rule, scope, target string, matches func(rule) bool) (Fault, bool) {
+	for _, r := range rules {
+		if matches(r) && i.roll() < r.percentage {
+			metrics.FaultsInjected.WithLabelValues(scope, target, r.fault.Kind()).Inc()
+			return r.fault, true
+		}
+	}
+	return Fault{}, false
+}
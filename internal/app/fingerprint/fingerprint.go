@@ -0,0 +1,177 @@
+// Package fingerprint identifies the client software behind requests, whatever address it
+// comes from: by a JA3-style hash of its TLS handshake and a hash of the shape of its HTTP
+// headers. Botnets rotating addresses keep the fingerprint of the tool they run, so
+// fingerprints correlate abuse that addresses alone do not.
+package fingerprint
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// supportedVersionsExtension is the ID of the TLS supported_versions extension
+const supportedVersionsExtension = 43
+
+// httpHeaders are the headers whose values describe the client software; the other
+// headers only count by name
+var httpHeaders = []string{"User-Agent", "Accept", "Accept-Encoding", "Accept-Language"}
+
+// volatileHeaders vary between the requests of one client, or are added by proxies
+var volatileHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Cookie":            true,
+	"Authorization":     true,
+	"If-None-Match":     true,
+	"If-Modified-Since": true,
+	"X-Request-Id":      true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Proto": true,
+	"X-Forwarded-Host":  true,
+	"X-Real-Ip":         true,
+	"Forwarded":         true,
+}
+
+// TLS returns the JA3-style fingerprint of a ClientHello: the MD5 of its version, cipher
+// suites, extensions, curves and point formats, GREASE values left out. The legacy version
+// of the hello is not exposed, so clients offering supported_versions are given 771 (TLS
+// 1.2), which is what they send.
+func TLS(hello *tls.ClientHelloInfo) string {
+	version := uint16(0)
+	for _, v := range hello.SupportedVersions {
+		version = max(version, v)
+	}
+	if slices.Contains(hello.Extensions, supportedVersionsExtension) {
+		version = tls.VersionTLS12
+	}
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(int(version)))
+	b.WriteByte(',')
+	writeList(&b, hello.CipherSuites)
+	b.WriteByte(',')
+	writeList(&b, hello.Extensions)
+	b.WriteByte(',')
+	writeList(&b, hello.SupportedCurves)
+	b.WriteByte(',')
+	writeList(&b, hello.SupportedPoints)
+
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeList writes the values of a ClientHello field joined by dashes, without GREASE
+func writeList[T ~uint8 | ~uint16](b *strings.Builder, values []T) {
+	first := true
+	for _, v := range values {
+		if isGREASE(uint16(v)) {
+			continue
+		}
+		if !first {
+			b.WriteByte('-')
+		}
+		first = false
+		b.WriteString(strconv.Itoa(int(v)))
+	}
+}
+
+// isGREASE reports whether a value is one of the reserved GREASE values (RFC 8701)
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// HTTP returns the fingerprint of the headers of a request: a hash of its protocol, the
+// names of its headers and the values of those describing the client software
+func HTTP(r *http.Request) string {
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		if !volatileHeaders[name] {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	h.Write([]byte(r.Proto))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(names, ",")))
+	for _, name := range httpHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(r.Header.Get(name)))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// connKey is the context key of the connection of a request
+type connKey struct{}
+
+// Store keeps the TLS fingerprints of the open connections of a server
+type Store struct {
+	mu    sync.Mutex
+	conns map[net.Conn]string
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{conns: make(map[net.Conn]string)}
+}
+
+// Capture wraps a server TLS configuration to fingerprint the handshakes made with it
+func (s *Store) Capture(cfg *tls.Config) *tls.Config {
+	cfg = cfg.Clone()
+	next := cfg.GetConfigForClient
+	cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		fingerprint := TLS(hello)
+		s.mu.Lock()
+		s.conns[hello.Conn] = fingerprint
+		s.mu.Unlock()
+		if next != nil {
+			return next(hello)
+		}
+		return nil, nil
+	}
+	return cfg
+}
+
+// ConnContext is the server's ConnContext hook, carrying the connection in the contexts
+// of its requests
+func (s *Store) ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// Forget drops the fingerprint of a connection, once it is closed or hijacked
+func (s *Store) Forget(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, netConn(conn))
+	s.mu.Unlock()
+}
+
+// Lookup returns the TLS fingerprint of the connection a request came over, false for
+// plaintext connections
+func (s *Store) Lookup(r *http.Request) (string, bool) {
+	conn, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fingerprint, ok := s.conns[netConn(conn)]
+	return fingerprint, ok
+}
+
+// netConn returns the connection under a TLS connection, as handshakes report it
+func netConn(conn net.Conn) net.Conn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		return tlsConn.NetConn()
+	}
+	return conn
+}
@@ -0,0 +1,549 @@
+// Package gateway composes the gateway from its configuration: it connects the backend
+// services and Redis, builds the components shared by the routers, and runs the servers
+// until the process is asked to stop. cmd/api only parses flags and loads the
+// configuration.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"apigw/internal/app/acl"
+	"apigw/internal/app/bodylog"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/config"
+	"apigw/internal/app/drain"
+	"apigw/internal/app/errreport"
+	"apigw/internal/app/faults"
+	"apigw/internal/app/fingerprint"
+	"apigw/internal/app/handler"
+	"apigw/internal/app/invalidation"
+	"apigw/internal/app/limiter"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/onsale"
+	"apigw/internal/app/purchasequeue"
+	"apigw/internal/app/recording"
+	"apigw/internal/app/router"
+	"apigw/internal/app/service"
+	"apigw/internal/app/startup"
+	"apigw/internal/app/validation"
+	"apigw/internal/app/waitingroom"
+	"apigw/internal/app/webhook"
+	"apigw/internal/client"
+	"apigw/internal/client/discovery"
+	"apigw/pkg/utils/clock"
+	"apigw/pkg/utils/crypt/token"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// App is the gateway composed from a configuration
+type App struct {
+	cfg         *config.Config
+	configPath  string
+	logger      *logrus.Logger
+	services    config.ServicesConfig
+	dialOptions []grpc.DialOption
+	// servicesReplaced is set by WithServices; the upstreams of tenants and regions are
+	// then ignored
+	servicesReplaced bool
+
+	serviceManager *service.Manager
+	gate           *startup.Gate
+
+	// ctx ends the background work of the components when the app is closed; workersCtx
+	// ends the purchase, webhook and change event workers once the servers have shut down
+	ctx         context.Context
+	cancel      context.CancelFunc
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
+
+	// rebuildMu guards deps.Redis, set when a degraded start connects to Redis, and the
+	// rebuilding of the routers
+	rebuildMu     sync.Mutex
+	deps          router.Dependencies
+	redisDegraded bool
+	changes       *invalidation.Consumer
+
+	// onSale puts the profiles of on-sale windows in effect; routers are built from the
+	// configuration it returns
+	onSale       *onsale.Scheduler
+	routeTable   *router.RouteTable
+	handler      *router.ReloadableHandler
+	adminHandler *router.ReloadableHandler
+
+	// serving is closed once Run serves addrs, the addresses of the public listeners
+	serving chan struct{}
+	addrs   []net.Addr
+
+	// closers release the components in the reverse order of their creation
+	closers []func()
+}
+
+// Option customizes the composition of an App
+type Option func(*App)
+
+// WithConfigPath sets the configuration file watched for live reloads; without one the
+// configuration is only reloaded on SIGHUP and remote changes
+func WithConfigPath(path string) Option {
+	return func(a *App) {
+		a.configPath = path
+	}
+}
+
+// WithClock sets the clock of the routes reporting or comparing times (default: the
+// system clock)
+func WithClock(clk clock.Clock) Option {
+	return func(a *App) {
+		a.deps.Clock = clk
+	}
+}
+
+// WithServices replaces the backend services of the configuration, e.g. with in-process
+// fakes; the upstreams tenants and regions override them with are not connected
+func WithServices(services config.ServicesConfig) Option {
+	return func(a *App) {
+		a.services = services
+		a.servicesReplaced = true
+	}
+}
+
+// WithDialOptions adds options to the connections to the backend services
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(a *App) {
+		a.dialOptions = append(a.dialOptions, opts...)
+	}
+}
+
+// New composes the gateway. Dependencies unavailable at boot are retried as startup.mode
+// asks; the background work of the components starts with them. Close releases them.
+func New(cfg *config.Config, logger *logrus.Logger, opts ...Option) (*App, error) {
+	a := &App{
+		cfg:        cfg,
+		logger:     logger,
+		services:   cfg.Services,
+		routeTable: router.NewRouteTable(),
+		serving:    make(chan struct{}),
+	}
+	a.deps.Clock = clock.System
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	a.workersCtx, a.stopWorkers = context.WithCancel(context.Background())
+	a.onClose(a.cancel)
+	a.onClose(a.stopWorkers)
+
+	// Each step may use the components of the steps before it
+	steps := []func() error{
+		a.connectServiceManager,
+		a.startErrorReporting,
+		a.connectBackends,
+		a.connectRedis,
+		a.startCache,
+		a.startBlocklist,
+		a.startPurchases,
+		a.startWebhooks,
+		a.startRecorder,
+		a.startChangeConsumer,
+		a.setUpRequests,
+		a.buildRouters,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			a.Close()
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Handler returns the handler of the public listeners; it serves the router of the
+// current configuration and tracks in-flight requests for draining
+func (a *App) Handler() http.Handler {
+	return a.deps.Drainer.Track(a.handler)
+}
+
+// AdminHandler returns the handler of the admin listener
+func (a *App) AdminHandler() http.Handler {
+	return a.adminHandler
+}
+
+// Serving is closed once Run serves the listeners
+func (a *App) Serving() <-chan struct{} {
+	return a.serving
+}
+
+// Addrs returns the addresses of the public listeners once Serving is closed, e.g. the
+// ports picked for listeners configured with port 0
+func (a *App) Addrs() []net.Addr {
+	<-a.serving
+	return a.addrs
+}
+
+// Stop makes Run drain and shut the servers down, as POST /admin/drain does
+func (a *App) Stop() {
+	a.deps.Drainer.Start("stop")
+}
+
+// Close stops the background work of the components and releases them
+func (a *App) Close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
+
+// onClose registers the release of a component
+func (a *App) onClose(fn func()) {
+	a.closers = append(a.closers, fn)
+}
+
+// connectServiceManager connects to systemd or the Windows service control manager, to
+// report readiness and shutdown
+func (a *App) connectServiceManager() error {
+	manager, err := service.New(a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	a.serviceManager = manager
+	a.onClose(manager.Close)
+	a.gate = startup.NewGate(a.cfg.Startup, a.logger)
+	return nil
+}
+
+// connectBackends creates the clients of the backend services. Connections are
+// established in the background, so unreachable backends only show as unhealthy in
+// /readyz until they come up.
+func (a *App) connectBackends() error {
+	// Register service discovery resolvers used by consul:/// and kubernetes:/// targets
+	err := a.gate.Connect(a.ctx, "discovery", func() error {
+		return discovery.Register(a.cfg.Discovery, a.logger)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up service discovery: %w", err)
+	}
+
+	// Backend calls are timed outermost, their logging included
+	factory := client.NewClientFactory().UseUnary(client.TimingInterceptor())
+	if a.cfg.Log.GRPCCalls.Enabled {
+		factory.UseUnary(client.LoggingInterceptor(a.cfg.Log.GRPCCalls, a.logger))
+	}
+	// Faults are injected innermost so they are logged like real failures and never reach
+	// the backends; the rules of upstreams are only read at startup
+	if a.cfg.Faults.Enabled && a.cfg.App.Environment != "production" {
+		if injector := faults.New(a.cfg.Faults); injector.Enabled() {
+			factory.UseUnary(client.FaultInterceptor(injector, a.logger))
+			factory.UseStream(client.FaultStreamInterceptor(injector, a.logger))
+		}
+	}
+	// Endpoints becoming unreachable and recovering are reported with the errors
+	if a.deps.Errors.UpstreamEvents() {
+		factory.OnStateChange(a.reportUpstreamState)
+	}
+	factory.WithDialOptions(a.dialOptions...)
+
+	overrides := client.Overrides{Tenants: a.cfg.Tenants.Active(), Regions: a.cfg.Regions.Active()}
+	if a.servicesReplaced {
+		overrides = client.Overrides{}
+	}
+	err = a.gate.Connect(a.ctx, "backends", func() error {
+		var err error
+		a.deps.Clients, err = client.NewRegistry(factory, a.services, overrides)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create service clients: %w", err)
+	}
+	a.onClose(func() {
+		if err := a.deps.Clients.Close(); err != nil {
+			a.logger.WithError(err).Error("Failed to close service clients")
+		}
+	})
+	return nil
+}
+
+// connectRedis connects to Redis for rate limiting. In degraded mode the gateway may
+// start without it; Run keeps retrying and rebuilds the routers once it connects. Once
+// connected, the watchdog checks its health for the rate limiters.
+func (a *App) connectRedis() error {
+	a.deps.Limiter = limiter.NewWatchdog(a.cfg.Redis.Watchdog, a.logger)
+	if !a.cfg.Redis.Enabled {
+		a.logger.Info("Redis is disabled, rate limiting will not be available")
+		return nil
+	}
+	a.onClose(func() {
+		a.rebuildMu.Lock()
+		defer a.rebuildMu.Unlock()
+		if a.deps.Redis != nil {
+			a.deps.Redis.Close()
+		}
+	})
+	if err := a.gate.Connect(a.ctx, "redis", a.dialRedis); err != nil {
+		if !a.gate.Degraded() {
+			return fmt.Errorf("failed to create Redis client: %w", err)
+		}
+		a.redisDegraded = true
+		a.logger.WithError(err).Error("Redis unavailable, starting degraded without rate limiting")
+		return nil
+	}
+	a.logger.Info("Redis client initialized for rate limiting")
+	return nil
+}
+
+// dialRedis creates the Redis client
+func (a *App) dialRedis() error {
+	rc, err := client.NewRedisClient(&a.cfg.Redis, a.logger)
+	if err != nil {
+		return err
+	}
+	a.rebuildMu.Lock()
+	a.deps.Redis = rc
+	a.rebuildMu.Unlock()
+	a.deps.Limiter.Watch(a.ctx, rc.GetClient())
+	return nil
+}
+
+// redisUniversal returns the Redis client the components share, or nil without Redis
+func (a *App) redisUniversal() redis.UniversalClient {
+	a.rebuildMu.Lock()
+	defer a.rebuildMu.Unlock()
+	if a.deps.Redis == nil {
+		return nil
+	}
+	return a.deps.Redis.GetClient()
+}
+
+// redisReads returns the Redis client the rate limiters read from, or nil without Redis
+func (a *App) redisReads() redis.UniversalClient {
+	a.rebuildMu.Lock()
+	defer a.rebuildMu.Unlock()
+	if a.deps.Redis == nil {
+		return nil
+	}
+	return a.deps.Redis.GetReadClient()
+}
+
+// invalidator returns the response cache as an invalidator, or one doing nothing
+func (a *App) invalidator() cache.Invalidator {
+	if a.deps.Cache == nil {
+		return cache.NopInvalidator{}
+	}
+	return a.deps.Cache
+}
+
+// startCache creates the response cache; its tiers are shared by every router built on
+// reload
+func (a *App) startCache() error {
+	if !a.cfg.Cache.Enabled {
+		return nil
+	}
+	a.deps.Cache = cache.New(a.cfg.Cache, a.redisUniversal(), a.logger)
+	a.deps.Cache.Start(a.ctx)
+	a.logger.WithFields(logrus.Fields{
+		"memory_max_entries": a.cfg.Cache.MemoryMaxEntries,
+		"redis":              a.cfg.Cache.Redis,
+		"routes":             len(a.cfg.Cache.Routes),
+	}).Info("Response cache enabled")
+	return nil
+}
+
+// startBlocklist creates the runtime network blocklist, shared by every router built on
+// reload
+func (a *App) startBlocklist() error {
+	a.deps.Blocklist = acl.NewBlocklist(a.redisUniversal(), a.cfg.ACL.KeyPrefix, a.logger)
+	a.deps.Blocklist.Start(a.ctx, a.cfg.ACL.RefreshInterval)
+	return nil
+}
+
+// startPurchases queues purchases in Redis when asynchronous purchases are enabled; the
+// workers forward them to the order service until the servers have shut down
+func (a *App) startPurchases() error {
+	if !a.cfg.Orders.AsyncPurchase.Enabled {
+		return nil
+	}
+	rdb := a.redisUniversal()
+	if rdb == nil {
+		a.logger.Warn("Redis unavailable, async purchases disabled until restart")
+		return nil
+	}
+	purchases := purchasequeue.New(rdb, a.cfg.Orders.AsyncPurchase, a.logger)
+	if err := purchases.Start(a.workersCtx, handler.PurchaseForwarder(a.deps.Clients.Order(), a.invalidator())); err != nil {
+		a.logger.WithError(err).Error("Failed to start async purchase workers, purchasing synchronously")
+		return nil
+	}
+	a.deps.Purchases = purchases
+	return nil
+}
+
+// startWebhooks delivers order events to partner webhooks until the servers have shut
+// down
+func (a *App) startWebhooks() error {
+	if !a.cfg.Webhooks.Enabled {
+		return nil
+	}
+	rdb := a.redisUniversal()
+	if rdb == nil {
+		a.logger.Warn("Redis unavailable, webhooks disabled until restart")
+		return nil
+	}
+	a.deps.Webhooks = webhook.New(rdb, a.cfg.Webhooks, a.logger)
+	a.deps.Webhooks.Start(a.workersCtx)
+	a.logger.WithField("workers", a.cfg.Webhooks.Workers).Info("Webhook dispatcher started")
+	return nil
+}
+
+// startErrorReporting starts reporting errors to Sentry; queued events are sent on close,
+// within the timeout of a request
+func (a *App) startErrorReporting() error {
+	cfg := a.cfg.Observability.Sentry
+	if !cfg.Enabled {
+		return nil
+	}
+	reporter, err := errreport.New(cfg, a.cfg.App, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to start error reporting: %w", err)
+	}
+	a.deps.Errors = reporter
+	a.onClose(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		defer cancel()
+		reporter.Close(ctx)
+	})
+	a.logger.WithField("sample_rate", cfg.SampleRate).Info("Error reporting enabled")
+	return nil
+}
+
+// reportUpstreamState reports an endpoint becoming unreachable, and recovering after it
+// was
+func (a *App) reportUpstreamState(service, endpoint string, from, to connectivity.State) {
+	event := errreport.Event{
+		Kind: errreport.KindUpstream,
+		Tags: map[string]string{
+			"service":  service,
+			"endpoint": endpoint,
+			"from":     from.String(),
+			"to":       to.String(),
+		},
+		Fingerprint: []string{errreport.KindUpstream, service, endpoint, to.String()},
+	}
+	switch {
+	case to == connectivity.TransientFailure:
+		event.Level = errreport.LevelWarning
+		event.Message = fmt.Sprintf("Upstream %s unreachable at %s", service, endpoint)
+	case to == connectivity.Ready && from == connectivity.TransientFailure:
+		event.Level = errreport.LevelInfo
+		event.Message = fmt.Sprintf("Upstream %s recovered at %s", service, endpoint)
+	default:
+		return
+	}
+	a.deps.Errors.Capture(event)
+}
+
+// startRecorder records sanitized traffic for cmd/replay; queued exchanges are written
+// on close
+func (a *App) startRecorder() error {
+	if !a.cfg.Recording.Enabled {
+		return nil
+	}
+	recorder, err := recording.New(a.cfg.Recording, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to start the traffic recorder: %w", err)
+	}
+	a.deps.Recorder = recorder
+	a.onClose(func() {
+		if err := recorder.Close(); err != nil {
+			a.logger.WithError(err).Error("Failed to close the traffic recording")
+		}
+	})
+	a.logger.WithFields(logrus.Fields{
+		"directory":  a.cfg.Recording.Directory,
+		"percentage": a.cfg.Recording.Percentage,
+	}).Info("Traffic recording enabled")
+	return nil
+}
+
+// startChangeConsumer invalidates cached responses and waiting rooms as backend services
+// publish changes
+func (a *App) startChangeConsumer() error {
+	if !a.cfg.Kafka.Enabled {
+		return nil
+	}
+	var rooms invalidation.WaitingRooms
+	if rdb := a.redisUniversal(); a.cfg.WaitingRoom.Enabled && rdb != nil {
+		rooms = waitingroom.New(rdb, a.cfg.WaitingRoom)
+	}
+	changes, err := invalidation.New(a.cfg.Kafka, a.invalidator(), rooms, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	changes.Start(a.workersCtx)
+	a.changes = changes
+	a.logger.WithFields(logrus.Fields{
+		"brokers": a.cfg.Kafka.Brokers,
+		"group":   a.cfg.Kafka.GroupID,
+		"topics":  a.cfg.Kafka.Topics(),
+	}).Info("Kafka change event consumer started")
+	return nil
+}
+
+// setUpRequests creates the token maker and registers the domain validation rules;
+// validation failures name the fields as clients send them
+func (a *App) setUpRequests() error {
+	tokenMaker, err := token.NewJWTTokenMaker(a.cfg.JWT.SecretKey)
+	if err != nil {
+		return fmt.Errorf("failed to create token maker: %w", err)
+	}
+	a.deps.TokenMaker = tokenMaker
+	if err := validation.Setup(a.cfg.Validation); err != nil {
+		return fmt.Errorf("failed to set up request validation: %w", err)
+	}
+	return nil
+}
+
+// buildRouters builds the public and admin routers, with the profile of the open on-sale
+// window in effect; in-flight requests are tracked so shutdown can drain them, and TLS
+// handshakes fingerprinted for the requests made over them
+func (a *App) buildRouters() error {
+	a.deps.Drainer = drain.New()
+	a.deps.BodyLog = bodylog.NewSwitch(a.deps.Clock)
+	a.deps.Fingerprints = fingerprint.NewStore()
+	a.onSale = onsale.NewScheduler(a.cfg, a.deps.Clock, a.waitingRooms, a.rebuild, a.logger)
+	cfg := a.onSale.Config()
+	a.handler = router.NewReloadableHandler(a.publicHandler(cfg))
+	a.adminHandler = router.NewReloadableHandler(router.SetupAdminRouter(cfg, a.deps, a.routeTable, a.logger))
+	return nil
+}
+
+// waitingRooms returns the waiting rooms on-sale profiles open, or nil while Redis is
+// unavailable
+func (a *App) waitingRooms(cfg config.WaitingRoomConfig) *waitingroom.Room {
+	rdb := a.redisUniversal()
+	if !cfg.Enabled || rdb == nil {
+		return nil
+	}
+	return waitingroom.New(rdb, cfg)
+}
+
+// publicHandler builds the public router of a configuration; path rewrite and header
+// manipulation rules are applied ahead of routing. The admin listener lists the routes
+// of the current public router.
+func (a *App) publicHandler(cfg *config.Config) http.Handler {
+	engine := router.SetupRouter(cfg, a.deps, a.logger)
+	a.routeTable.Set(router.DescribeRoutes(engine, cfg))
+	return middleware.NewTransformer(cfg.Transforms, a.logger).Wrap(engine)
+}
+
+// rebuild swaps the routers for ones built from a configuration
+func (a *App) rebuild(cfg *config.Config) {
+	a.rebuildMu.Lock()
+	defer a.rebuildMu.Unlock()
+	a.handler.Swap(a.publicHandler(cfg))
+	a.adminHandler.Swap(router.SetupAdminRouter(cfg, a.deps, a.routeTable, a.logger))
+}
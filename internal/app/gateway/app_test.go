@@ -0,0 +1,75 @@
+package gateway_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/gateway"
+	"apigw/internal/contract"
+	"apigw/pkg/utils/clock"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAppComposesWithFakeBackends composes the gateway on default settings, without
+// Redis, against a contract backend and serves requests through its handler without
+// opening listeners
+func TestAppComposesWithFakeBackends(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+	logger := logutils.GetLogger()
+	logger.SetOutput(io.Discard)
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.JWT.SecretKey = "0123456789abcdef0123456789abcdef"
+	cfg.Redis.Enabled = false
+	cfg.Cache.Redis = false
+
+	scenario, err := contract.DevScenario()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := contract.NewBackend(scenario)
+	t.Cleanup(backend.Close)
+
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	app, err := gateway.New(cfg, logger,
+		gateway.WithServices(contract.StubServices(cfg.Services)),
+		gateway.WithDialOptions(backend.DialOption()),
+		gateway.WithClock(clock.NewFrozen(now)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Close)
+
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/evt_1001", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/events/evt_1001: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if calls := backend.Calls("event.EventService/GetEvent"); len(calls) != 1 {
+		t.Errorf("GetEvent called %d times, want 1", len(calls))
+	}
+
+	rec = httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Timestamp(now); health.Timestamp != want {
+		t.Errorf("health timestamp %q, want %q from the clock option", health.Timestamp, want)
+	}
+}
@@ -0,0 +1,303 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"apigw/internal/app/buildinfo"
+	"apigw/internal/app/config"
+	"apigw/internal/app/grpcapi"
+	"apigw/internal/app/server"
+	"apigw/internal/app/service"
+	"apigw/internal/app/upgrade"
+	"apigw/internal/app/validation"
+	"apigw/internal/app/webhook"
+	"apigw/pkg/utils/codec"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Run serves the listeners until an interrupt signal, POST /admin/drain or SIGUSR2 asks
+// the gateway to stop, then drains and shuts the servers down. Meanwhile the configuration
+// is watched and live-reloadable settings are applied without a restart, and on-sale
+// profiles are switched to as their windows open.
+func (a *App) Run() error {
+	watcher := a.watch()
+	go a.onSale.Run(a.ctx)
+
+	// Open listeners, taking them over from the previous process after a binary upgrade
+	upgrader, err := upgrade.New(a.cfg.Server.Upgrade, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to take over listeners: %w", err)
+	}
+	listeners, challengeServer, err := server.Listen(a.cfg.Server.HTTP, upgrader, a.deps.Fingerprints, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to open listeners: %w", err)
+	}
+
+	httpServer := server.NewHTTPServer(a.cfg.Server.HTTP, a.Handler(), a.deps.Fingerprints)
+
+	build := buildinfo.Get()
+	a.logger.WithFields(logrus.Fields{
+		"build_version": build.Version,
+		"commit":        build.Commit,
+		"build_date":    build.Date,
+		"go_version":    build.GoVersion,
+		"listeners":     len(listeners),
+		"http2":         a.cfg.Server.HTTP.HTTP2.Enabled,
+		"h2c":           a.cfg.Server.HTTP.HTTP2.H2C,
+		"environment":   a.cfg.App.Environment,
+		"version":       a.cfg.App.Version,
+		"json_codec":    codec.Name,
+	}).Info("API Gateway server starting")
+	a.logStartupSummary(listeners)
+
+	// Serve every listener in its own goroutine
+	for _, l := range listeners {
+		go func(l server.Listener) {
+			if err := httpServer.Serve(l); err != nil && err != http.ErrServerClosed {
+				a.logger.WithError(err).WithField("address", l.Config.Address).Fatal("Failed to start server")
+			}
+		}(l)
+	}
+
+	adminServer, err := a.serveAdmin(upgrader)
+	if err != nil {
+		return err
+	}
+	grpcServer, err := a.serveGRPC(upgrader)
+	if err != nil {
+		return err
+	}
+
+	// Keep retrying Redis when started without it, enabling its features on the routers
+	// and the gRPC server once connected; the response cache and the blocklist stay local
+	// to the instance until a restart
+	if a.redisDegraded {
+		a.gate.Recover(a.ctx, "redis", a.dialRedis, func() {
+			a.rebuild(a.onSale.Config())
+			if grpcServer != nil {
+				grpcServer.UseRedis(a.redisUniversal(), a.redisReads())
+			}
+		})
+	}
+
+	// Serve ACME HTTP-01 challenges when autocert is configured for them
+	if challengeServer != nil {
+		challengeListener, err := upgrader.Listen(config.NetworkTCP, challengeServer.Addr, func() (net.Listener, error) {
+			return net.Listen("tcp", challengeServer.Addr)
+		})
+		if err != nil {
+			a.logger.WithError(err).Error("ACME challenge server failed")
+		} else {
+			go func() {
+				if err := challengeServer.Serve(challengeListener); err != nil && err != http.ErrServerClosed {
+					a.logger.WithError(err).Error("ACME challenge server failed")
+				}
+			}()
+			defer challengeServer.Close()
+		}
+	}
+
+	// Let the process this one replaces, if any, drain now that the listeners are served
+	if err := upgrader.Ready(); err != nil {
+		a.logger.WithError(err).Error("Failed to complete the binary upgrade")
+	}
+	if pidFile := a.cfg.Process.PIDFile; pidFile != "" {
+		if err := service.WritePIDFile(pidFile); err != nil {
+			return fmt.Errorf("failed to write PID file: %w", err)
+		}
+		defer func() {
+			if err := service.RemovePIDFile(pidFile); err != nil {
+				a.logger.WithError(err).Error("Failed to remove PID file")
+			}
+		}()
+	}
+	for _, l := range listeners {
+		a.addrs = append(a.addrs, l.Addr())
+	}
+	close(a.serving)
+	a.serviceManager.Ready()
+	watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+	defer stopWatchdog()
+	a.serviceManager.Watchdog(watchdogCtx)
+
+	// Wait for an interrupt signal or POST /admin/drain to drain and shut down the server,
+	// or for SIGUSR2 to hand the listeners to a new binary and drain once it serves them
+	drainer := a.deps.Drainer
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	a.serviceManager.Notify(quit)
+	upgrades := make(chan os.Signal, 1)
+	if a.cfg.Server.Upgrade.Enabled {
+		upgrade.Notify(upgrades)
+	}
+	upgraded := false
+wait:
+	for {
+		select {
+		case sig := <-quit:
+			drainer.Start("signal")
+			a.logger.WithField("signal", sig.String()).Info("Shutdown signal received")
+			break wait
+		case <-drainer.Started():
+			break wait
+		case <-upgrades:
+			pid, err := upgrader.Upgrade()
+			if err != nil {
+				a.logger.WithError(err).Error("Binary upgrade failed, continuing to serve")
+				continue
+			}
+			// The new process is the service now and pings the watchdog itself
+			a.serviceManager.HandOver(pid)
+			stopWatchdog()
+			drainer.Start("upgrade")
+			upgraded = true
+			break wait
+		}
+	}
+	if !upgraded {
+		a.serviceManager.Stopping()
+	}
+
+	// Fail the readiness probe and give load balancers time to stop sending traffic;
+	// a second signal skips the wait. After an upgrade the new process already accepts
+	// on the same sockets, so there is nothing to wait for.
+	drainDelay := watcher.Current().Server.HTTP.DrainDelay
+	if upgraded {
+		drainDelay = 0
+	}
+	a.logger.WithFields(logrus.Fields{
+		"drain_delay": drainDelay,
+		"in_flight":   drainer.InFlight(),
+	}).Info("Draining API Gateway server...")
+	httpServer.SetKeepAlivesEnabled(false)
+	select {
+	case <-time.After(drainDelay):
+	case <-quit:
+		a.logger.Warn("Second signal received, skipping the drain delay")
+	}
+
+	a.logger.WithField("in_flight", drainer.InFlight()).Info("Shutting down API Gateway server...")
+
+	// Create a deadline for server shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.HTTP.GracefulShutdownTimeout)
+	defer cancel()
+
+	// Attempt graceful shutdown; the admin listener stays up until the public one has drained
+	if err := httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+	if grpcServer != nil {
+		grpcServer.Shutdown(ctx)
+	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			a.logger.WithError(err).Error("Admin server forced to shutdown")
+		}
+	}
+
+	// Let the workers finish the calls they are making; queued purchases are forwarded by
+	// the other replicas, or by this one once restarted
+	a.stopWorkers()
+	if a.deps.Purchases != nil {
+		a.deps.Purchases.Wait()
+	}
+	if a.deps.Webhooks != nil {
+		a.deps.Webhooks.Wait()
+	}
+	if a.changes != nil {
+		a.changes.Wait()
+	}
+	return nil
+}
+
+// watch starts watching the configuration, applying live-reloadable settings without a
+// restart until the app is closed
+func (a *App) watch() *config.Watcher {
+	watcher := config.NewWatcher(a.configPath, a.cfg, a.logger)
+	watcher.OnChange(func(oldCfg, newCfg *config.Config) {
+		if err := logutils.SetLevel(newCfg.Log.Level); err != nil {
+			a.logger.WithError(err).Error("Failed to apply log level")
+		}
+		if err := logutils.SetModuleLevels(newCfg.Log.Modules); err != nil {
+			a.logger.WithError(err).Error("Failed to apply log module levels")
+		}
+		validation.Configure(newCfg.Validation)
+		// The admin listener is only opened at startup; keep the operator routes where
+		// they are served until the restart
+		newCfg.Server.Admin.Enabled = oldCfg.Server.Admin.Enabled
+		a.onSale.Update(newCfg)
+	})
+	if err := watcher.Start(a.ctx); err != nil {
+		a.logger.WithError(err).Warn("Configuration hot reload disabled")
+	}
+	return watcher
+}
+
+// serveAdmin serves the operational endpoints on the internal admin listener, when it
+// is enabled
+func (a *App) serveAdmin(upgrader *upgrade.Upgrader) (*http.Server, error) {
+	if !a.cfg.Server.Admin.Enabled {
+		return nil, nil
+	}
+	adminListener, err := server.ListenAdmin(a.cfg.Server.Admin, upgrader, a.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open admin listener: %w", err)
+	}
+	adminServer := server.NewAdminServer(a.cfg.Server.HTTP, a.AdminHandler())
+	go func() {
+		if err := adminServer.Serve(adminListener); err != nil && err != http.ErrServerClosed {
+			a.logger.WithError(err).Fatal("Failed to start admin server")
+		}
+	}()
+	return adminServer, nil
+}
+
+// serveGRPC serves the gateway operations to gRPC clients on their own listener, when
+// it is enabled
+func (a *App) serveGRPC(upgrader *upgrade.Upgrader) (*grpcapi.Server, error) {
+	if !a.cfg.Server.GRPC.Enabled {
+		return nil, nil
+	}
+	deps := grpcapi.Dependencies{
+		Users:       a.deps.Clients.User(),
+		Orders:      a.deps.Clients.Order(),
+		TokenMaker:  a.deps.TokenMaker,
+		Redis:       a.redisUniversal(),
+		RedisReads:  a.redisReads(),
+		Limiter:     a.deps.Limiter,
+		Invalidator: a.invalidator(),
+		Webhooks:    webhook.NopPublisher{},
+		Blocklist:   a.deps.Blocklist,
+	}
+	if a.deps.Webhooks != nil {
+		deps.Webhooks = a.deps.Webhooks
+	}
+	grpcServer, err := grpcapi.New(a.cfg, deps, a.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC server: %w", err)
+	}
+	address := a.cfg.Server.GRPC.Address
+	grpcListener, err := upgrader.Listen(config.NetworkTCP, address, func() (net.Listener, error) {
+		return net.Listen("tcp", address)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gRPC listener: %w", err)
+	}
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			a.logger.WithError(err).Fatal("Failed to start gRPC server")
+		}
+	}()
+	a.logger.WithField("address", address).Info("gRPC server listening")
+	return grpcServer, nil
+}
@@ -0,0 +1,182 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/server"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logStartupSummary logs the configuration the gateway boots with as a single event, so
+// every boot can be audited from the logs alone: the files loaded and a digest of the
+// settings, the routes registered, the limits, the upstreams, the features enabled and
+// the TLS state of every listener. Secrets are never part of it; the digest is taken over
+// the configuration with secrets redacted.
+func (a *App) logStartupSummary(listeners []server.Listener) {
+	cfg := a.onSale.Config()
+	dump := cfg.Redacted()
+
+	a.logger.WithFields(logrus.Fields{
+		"audit":         true,
+		"config_files":  cfg.Files,
+		"config_sha256": configDigest(dump),
+		"environment":   cfg.App.Environment,
+		"version":       cfg.App.Version,
+		"routes":        len(a.routeTable.Routes().Routes),
+		"limits":        startupLimits(cfg),
+		"upstreams":     a.startupUpstreams(cfg),
+		"features":      enabledFeatures(dump, ""),
+		"tls":           startupTLS(cfg, listeners),
+	}).Info("Startup configuration")
+}
+
+// configDigest returns the SHA-256 digest of a configuration dump, telling boots with the
+// same settings apart from others
+func configDigest(dump map[string]interface{}) string {
+	// Maps are encoded with their keys in order, so equal settings give equal digests
+	data, err := json.Marshal(dump)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// startupLimits returns the limits requests are served within
+func startupLimits(cfg *config.Config) map[string]interface{} {
+	httpCfg := cfg.Server.HTTP
+	limits := map[string]interface{}{
+		"request_timeout": httpCfg.RequestTimeout.String(),
+		"write_timeout":   httpCfg.WriteTimeout.String(),
+		"max_body_bytes":  httpCfg.RequestBody.MaxBytes,
+		"max_json_depth":  httpCfg.RequestBody.MaxJSONDepth,
+		"route_timeouts":  len(httpCfg.RouteTimeouts),
+	}
+	if cfg.Redis.Enabled {
+		bucket := cfg.Redis.TokenBucket
+		limits["rate_limit"] = map[string]interface{}{
+			"capacity":        bucket.Capacity,
+			"refill_rate":     bucket.RefillRate,
+			"refill_interval": bucket.RefillInterval.String(),
+			"policies":        len(cfg.Redis.Policies),
+		}
+	}
+	if cfg.LoadShedding.Enabled {
+		limits["max_in_flight"] = cfg.LoadShedding.MaxInFlight
+	}
+	if cfg.Quotas.Enabled {
+		limits["quota"] = map[string]interface{}{
+			"daily":   cfg.Quotas.Default.Daily,
+			"monthly": cfg.Quotas.Default.Monthly,
+			"clients": len(cfg.Quotas.Clients),
+		}
+	}
+	return limits
+}
+
+// startupUpstreams returns the addresses of the backend services and Redis, and whether
+// the connections to them are secured by TLS
+func (a *App) startupUpstreams(cfg *config.Config) map[string]interface{} {
+	services := a.services.All()
+	upstreams := make(map[string]interface{}, len(services)+1)
+	for name, service := range services {
+		endpoints := service.ResolvedEndpoints()
+		addresses := make([]string, 0, len(endpoints)+1)
+		for _, endpoint := range endpoints {
+			addresses = append(addresses, redactUserinfo(endpoint.Address()))
+		}
+		upstream := map[string]interface{}{"addresses": addresses, "tls": service.TLS.Enabled}
+		if service.Shadow.Enabled {
+			upstream["shadow"] = net.JoinHostPort(service.Shadow.Host, strconv.Itoa(service.Shadow.Port))
+		}
+		upstreams[name] = upstream
+	}
+	if cfg.Redis.Enabled {
+		addresses := make([]string, 0, len(cfg.Redis.ResolvedAddrs()))
+		for _, address := range cfg.Redis.ResolvedAddrs() {
+			addresses = append(addresses, redactUserinfo(address))
+		}
+		redis := map[string]interface{}{
+			"addresses": addresses,
+			"mode":      cfg.Redis.Mode,
+			"read_from": cfg.Redis.ReadFrom,
+			"tls":       cfg.Redis.TLS.Enabled,
+			"connected": a.deps.Redis != nil,
+		}
+		if len(cfg.Redis.Replicas) > 0 {
+			redis["replicas"] = cfg.Redis.Replicas
+		}
+		upstreams["redis"] = redis
+	}
+	return upstreams
+}
+
+// redactUserinfo drops the credentials of an address written as a URL
+func redactUserinfo(address string) string {
+	u, err := url.Parse(address)
+	if err != nil || u.User == nil {
+		return address
+	}
+	u.User = nil
+	return u.String()
+}
+
+// enabledFeatures returns the settings enabled in a configuration dump, named after the
+// section of their enabled switch, e.g. redis or observability.sentry, in order
+func enabledFeatures(dump map[string]interface{}, prefix string) []string {
+	features := []string{}
+	for key, value := range dump {
+		switch v := value.(type) {
+		case bool:
+			if key == "enabled" && v && prefix != "" {
+				features = append(features, strings.TrimSuffix(prefix, "."))
+			}
+		case map[string]interface{}:
+			features = append(features, enabledFeatures(v, prefix+key+".")...)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// startupTLS returns the TLS state of the public, admin and gRPC listeners, by address:
+// plaintext, tls (certificate files) or autocert. Public listeners are named after the
+// address they are bound to, e.g. the port picked for port 0.
+func startupTLS(cfg *config.Config, listeners []server.Listener) map[string]string {
+	state := make(map[string]string, len(listeners)+2)
+	for _, l := range listeners {
+		mode := "plaintext"
+		switch {
+		case !l.Config.TLS:
+		case cfg.Server.HTTP.TLS.Autocert.Enabled:
+			mode = "autocert"
+		default:
+			mode = "tls"
+		}
+		state[l.Config.Network+" "+l.Addr().String()] = mode
+	}
+	if admin := cfg.Server.Admin; admin.Enabled {
+		mode := "plaintext"
+		if admin.TLSEnabled() {
+			mode = "tls"
+		}
+		state["admin "+admin.Address] = mode + ", auth " + admin.Auth
+	}
+	if grpcServer := cfg.Server.GRPC; grpcServer.Enabled {
+		mode := "plaintext"
+		if grpcServer.CertFile != "" {
+			mode = "tls"
+		}
+		state["grpc "+grpcServer.Address] = mode
+	}
+	return state
+}
@@ -0,0 +1,215 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/acl"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/middleware"
+	"apigw/internal/client"
+	"apigw/pkg/utils/crypt/token"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// publicMethods can be called without an access token
+var publicMethods = map[string]bool{
+	pb.UserService_Register_FullMethodName:     true,
+	pb.UserService_Login_FullMethodName:        true,
+	pb.UserService_RefreshToken_FullMethodName: true,
+}
+
+// callerKey is the context key of the authenticated caller
+type callerKey struct{}
+
+// caller is the authenticated user of a call
+type caller struct {
+	userID string
+	role   string
+}
+
+// interceptors apply the protections of the HTTP routes to gRPC calls
+type interceptors struct {
+	tokenMaker  *token.JWTMaker
+	protections *atomic.Pointer[protections]
+	// acl and blocklist are nil when the network ACLs are disabled
+	acl       *acl.List
+	blocklist *acl.Blocklist
+	logger    *logrus.Logger
+}
+
+// audit logs every call with its caller and outcome, and turns panics into Internal
+// errors instead of stopping the gateway
+func (i *interceptors) audit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			i.logger.WithFields(logrus.Fields{
+				"grpc_method": info.FullMethod,
+				"panic":       r,
+				"stack":       string(debug.Stack()),
+			}).Error("Panic recovered in gRPC call")
+			err = status.Error(codes.Internal, "internal error")
+		}
+
+		fields := logrus.Fields{
+			"grpc_method": info.FullMethod,
+			"ip":          peerIP(ctx),
+			"grpc_code":   status.Code(err).String(),
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"audit":       true,
+		}
+		if c, ok := ctx.Value(callerKey{}).(caller); ok {
+			fields["user_id"] = c.userID
+		}
+		if err != nil {
+			i.logger.WithFields(fields).WithError(err).Warn("gRPC API request failed")
+			// Server errors are returned without the backend's message, kept in the record
+			err = errs.PublicStatus(status.Convert(err)).Err()
+			return
+		}
+		i.logger.WithFields(fields).Info("gRPC API request served")
+	}()
+
+	// The caller is attached here so the audit record names them
+	if c, ok := i.caller(ctx); ok {
+		ctx = context.WithValue(ctx, callerKey{}, c)
+	}
+	return handler(ctx, req)
+}
+
+// networkACL refuses calls from networks on the runtime blocklist or denied by the
+// network ACLs, like the ACL middleware of the HTTP routes. Rules match the full method
+// name, e.g. /user.UserService/Login, as their path prefix.
+func (i *interceptors) networkACL(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if i.acl == nil {
+		return handler(ctx, req)
+	}
+	addr, err := netip.ParseAddr(peerIP(ctx))
+	if err != nil {
+		// Unix socket clients have no address; only local clients can reach them
+		return handler(ctx, req)
+	}
+	addr = addr.Unmap()
+
+	source := ""
+	if i.blocklist != nil {
+		if _, blocked := i.blocklist.Blocked(addr); blocked {
+			source = acl.SourceBlocklist
+		}
+	}
+	if source == "" {
+		if allowed, denied := i.acl.Check(info.FullMethod, addr); !allowed {
+			source = denied
+		}
+	}
+	if source == "" {
+		return handler(ctx, req)
+	}
+
+	metrics.ACLDenied.WithLabelValues(source).Inc()
+	i.logger.WithFields(logrus.Fields{
+		"grpc_method": info.FullMethod,
+		"ip":          addr.String(),
+		"source":      source,
+	}).Warn("gRPC call denied by network ACL")
+	return nil, status.Error(codes.PermissionDenied, "access denied")
+}
+
+// rateLimit takes a token from the client IP's bucket, the one its HTTP requests use
+func (i *interceptors) rateLimit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	limiter := i.protections.Load().limiter
+	if limiter == nil {
+		return handler(ctx, req)
+	}
+
+	allowed, nextRefill, err := limiter.Allow(ctx, "ip:"+peerIP(ctx))
+	if err != nil {
+		// On Redis error, allow the call but log the error
+		i.logger.WithError(err).Error("Token bucket rate limit check failed")
+		return handler(ctx, req)
+	}
+	if !allowed {
+		return nil, retryError(codes.ResourceExhausted, "rate limit exceeded", errs.ErrRateLimitExceeded.Code, time.Until(nextRefill), nil)
+	}
+	return handler(ctx, req)
+}
+
+// authenticate rejects calls to methods other than the public ones without a valid access
+// token, and routes the caller's upstream calls consistently like the JWT middleware
+func (i *interceptors) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	c, ok := ctx.Value(callerKey{}).(caller)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "a valid access token is required in the authorization metadata")
+	}
+	return handler(client.WithRoutingKey(ctx, c.userID), req)
+}
+
+// caller verifies the access token of a call, if it has one
+func (i *interceptors) caller(ctx context.Context) (caller, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return caller{}, false
+	}
+	token, ok := middleware.BearerToken(values[0])
+	if !ok {
+		return caller{}, false
+	}
+	payload, err := i.tokenMaker.VerifyToken(token)
+	if err != nil {
+		return caller{}, false
+	}
+	return caller{userID: payload.UserID, role: payload.Role}, true
+}
+
+// userID returns the authenticated user of a call
+func userID(ctx context.Context) string {
+	c, _ := ctx.Value(callerKey{}).(caller)
+	return c.userID
+}
+
+// peerIP returns the IP address of the client of a call
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// retryError returns an error telling the client when to retry, with the reason and
+// metadata of the refusal
+func retryError(code codes.Code, message, reason string, retryAfter time.Duration, details map[string]string) error {
+	st := status.New(code, message)
+	withDetails, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: reason, Domain: "apigw", Metadata: details},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(max(retryAfter, 0))},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,176 @@
+// Package grpcapi serves the gateway operations over gRPC for internal consumers that
+// prefer it to HTTP. It exposes the public parts of the user and order services with
+// their own message types, behind the same network ACLs, JWT authentication, token
+// bucket, brute-force guard and waiting room as the HTTP routes, and logs every call for
+// auditing. CAPTCHAs cannot be answered over gRPC, so actions requiring one are refused.
+//
+// Authenticated methods take the access token in the authorization metadata:
+//
+//	authorization: Bearer <access token>
+//
+// User IDs in requests are always replaced by the caller's.
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/acl"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/config"
+	"apigw/internal/app/limiter"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/waitingroom"
+	"apigw/internal/app/webhook"
+	"apigw/internal/client"
+	"apigw/pkg/utils/crypt/token"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// Dependencies are the backends and shared state the gRPC server uses
+type Dependencies struct {
+	Users      client.UserService
+	Orders     client.OrderService
+	TokenMaker *token.JWTMaker
+	// Redis holds the rate limits, lockouts and waiting rooms; nil disables them, as on
+	// the HTTP routes
+	Redis redis.UniversalClient
+	// RedisReads reads the rate limits, from the replicas of Redis; nil reads from Redis
+	RedisReads redis.UniversalClient
+	// Limiter holds the mode of the rate limiter as Redis goes down and recovers
+	Limiter     *limiter.Watchdog
+	Invalidator cache.Invalidator
+	Webhooks    webhook.Publisher
+	// Blocklist holds the networks blocked through the admin API, refused with the
+	// network ACLs; nil refuses only the configured networks
+	Blocklist *acl.Blocklist
+}
+
+// Server is the gRPC server of the gateway
+type Server struct {
+	server *grpc.Server
+	cfg    *config.Config
+	// watchdog holds the mode of the rate limiter as Redis goes down and recovers
+	watchdog    *limiter.Watchdog
+	protections *atomic.Pointer[protections]
+	logger      *logrus.Logger
+}
+
+// protections are the Redis-backed protections of the calls, rebuilt by UseRedis once
+// Redis is connected after a degraded start
+type protections struct {
+	limiter *middleware.TokenBucket     // nil without Redis
+	guard   *middleware.BruteForceGuard // nil without Redis or when disabled
+	room    *waitingroom.Room           // nil without Redis or when disabled
+}
+
+// New creates the gRPC server with the settings of cfg
+func New(cfg *config.Config, deps Dependencies, logger *logrus.Logger) (*Server, error) {
+	s := &Server{
+		cfg:         cfg,
+		watchdog:    deps.Limiter,
+		protections: new(atomic.Pointer[protections]),
+		logger:      logger,
+	}
+	s.UseRedis(deps.Redis, deps.RedisReads)
+
+	interceptors := &interceptors{
+		tokenMaker:  deps.TokenMaker,
+		protections: s.protections,
+		logger:      logger,
+	}
+	if cfg.ACL.Enabled {
+		list, err := acl.Compile(cfg.ACL)
+		if err != nil {
+			return nil, err
+		}
+		interceptors.acl, interceptors.blocklist = list, deps.Blocklist
+	}
+	users := &userServer{
+		users:       deps.Users,
+		protections: s.protections,
+		captcha:     cfg.Captcha,
+		logger:      logger,
+	}
+	orders := &orderServer{
+		orders:      deps.Orders,
+		invalidator: deps.Invalidator,
+		webhooks:    deps.Webhooks,
+		protections: s.protections,
+		roomCfg:     cfg.WaitingRoom,
+		captcha:     cfg.Captcha,
+		logger:      logger,
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.Server.GRPC.MaxRecvMsgSize),
+		grpc.ChainUnaryInterceptor(interceptors.audit, interceptors.networkACL, interceptors.rateLimit, interceptors.authenticate),
+	}
+	if cfg.Server.GRPC.CertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.Server.GRPC.CertFile, cfg.Server.GRPC.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	s.server = grpc.NewServer(opts...)
+	pb.RegisterUserServiceServer(s.server, users)
+	pb.RegisterOrderServiceServer(s.server, orders)
+	if cfg.Server.GRPC.Reflection {
+		reflection.Register(s.server)
+	}
+	return s, nil
+}
+
+// UseRedis rebuilds the rate limiter, brute-force guard and waiting room of the calls on
+// a Redis client, reading the rate limits from reads; a nil client disables them
+func (s *Server) UseRedis(rdb, reads redis.UniversalClient) {
+	p := new(protections)
+	if rdb != nil {
+		p.limiter = middleware.NewTokenBucket(&middleware.TokenBucketConfig{
+			RedisClient:    rdb,
+			ReadClient:     reads,
+			Watchdog:       s.watchdog,
+			Capacity:       s.cfg.Redis.TokenBucket.Capacity,
+			RefillRate:     s.cfg.Redis.TokenBucket.RefillRate,
+			RefillInterval: s.cfg.Redis.TokenBucket.RefillInterval,
+			Logger:         s.logger,
+		})
+		if s.cfg.BruteForce.Enabled {
+			p.guard = middleware.NewBruteForceGuard(rdb, s.cfg.BruteForce, s.logger)
+		}
+		if s.cfg.WaitingRoom.Enabled {
+			p.room = waitingroom.New(rdb, s.cfg.WaitingRoom)
+		}
+	}
+	s.protections.Store(p)
+}
+
+// Serve accepts connections on l until the server is stopped
+func (s *Server) Serve(l net.Listener) error {
+	return s.server.Serve(l)
+}
+
+// Shutdown stops accepting calls and waits for those in progress, cancelling them when ctx
+// is done first
+func (s *Server) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.logger.Warn("gRPC server forced to shutdown")
+		s.server.Stop()
+	}
+}
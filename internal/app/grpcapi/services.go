@@ -0,0 +1,289 @@
+package grpcapi
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/waitingroom"
+	"apigw/internal/app/webhook"
+	"apigw/internal/client"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPageSize bounds the orders listed per call, as on the HTTP routes
+const maxPageSize = 100
+
+// userServer serves registration, login and token refresh
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	users       client.UserService
+	protections *atomic.Pointer[protections]
+	captcha     config.CaptchaConfig
+	logger      *logrus.Logger
+}
+
+// Register creates an account, guarded against repeated failures like the HTTP route
+func (s *userServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	register := dto.RegisterReq{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+		Email:    req.GetEmail(),
+	}
+	if err := binding.Validator.ValidateStruct(&register); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid registration request")
+	}
+
+	var resp *pb.RegisterResponse
+	err := s.guarded(ctx, "register", req.GetEmail(), func() (err error) {
+		resp, err = s.users.Register(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Login issues tokens, guarded against repeated failures like the HTTP route
+func (s *userServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	login := dto.LoginReq{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+	if err := binding.Validator.ValidateStruct(&login); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid login request")
+	}
+
+	var resp *pb.LoginResponse
+	err := s.guarded(ctx, "login", req.GetEmail(), func() (err error) {
+		resp, err = s.users.Login(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// RefreshToken exchanges a refresh token for new tokens
+func (s *userServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	return s.users.RefreshToken(ctx, req)
+}
+
+// guarded makes an attempt unless the email or client IP is locked out or the action
+// requires a CAPTCHA, and records its outcome: rejections the HTTP routes answer with 401,
+// 403, 404 or 409 count as failures
+func (s *userServer) guarded(ctx context.Context, action, email string, attempt func() error) error {
+	var done func(ctx context.Context, rejected bool)
+	if guard := s.protections.Load().guard; guard != nil {
+		bruteForce, recordDone, err := guard.Attempt(ctx, action, email, peerIP(ctx))
+		switch {
+		case err != nil:
+			// Like the token bucket, fail open while Redis is unavailable
+			s.logger.WithError(err).Error("Brute-force check failed")
+		case bruteForce.RetryAfter > 0:
+			return retryError(codes.ResourceExhausted, "too many failed attempts", errs.ErrTooManyFailedAttempts.Code, bruteForce.RetryAfter, nil)
+		default:
+			done = recordDone
+		}
+	}
+	if err := captchaRequired(s.captcha, action); err != nil {
+		return err
+	}
+
+	err := attempt()
+	if done == nil {
+		return err
+	}
+	switch status.Code(err) {
+	case codes.OK:
+		done(ctx, false)
+	case codes.Unauthenticated, codes.PermissionDenied, codes.NotFound, codes.AlreadyExists:
+		done(ctx, true)
+	}
+	return err
+}
+
+// captchaRequired refuses an action requiring a CAPTCHA. They cannot be answered over
+// gRPC, so on_abuse actions are refused as well as always ones, flagged or not.
+func captchaRequired(cfg config.CaptchaConfig, action string) error {
+	if cfg.CaptchaMode(action) == config.CaptchaOff {
+		return nil
+	}
+	return retryError(codes.ResourceExhausted, "a CAPTCHA is required, use the HTTP API", errs.ErrCaptchaRequired.Code, 0, nil)
+}
+
+// orderServer serves purchases and the caller's orders
+type orderServer struct {
+	pb.UnimplementedOrderServiceServer
+	orders      client.OrderService
+	invalidator cache.Invalidator
+	webhooks    webhook.Publisher
+	protections *atomic.Pointer[protections]
+	roomCfg     config.WaitingRoomConfig
+	captcha     config.CaptchaConfig
+	logger      *logrus.Logger
+}
+
+// PurchaseTicket purchases tickets for the caller, holding them in the event's waiting
+// room when it is open. Queued buyers are turned away before being refused for a CAPTCHA,
+// as on the HTTP routes.
+func (s *orderServer) PurchaseTicket(ctx context.Context, req *pb.PurchaseRequest) (*pb.PurchaseResponse, error) {
+	purchase := dto.PurchaseTicketReq{
+		EventID:  req.GetEventId(),
+		SeatIDs:  req.GetSeatIds(),
+		Quantity: req.GetQuantity(),
+		Tier:     req.GetTier(),
+	}
+	if err := binding.Validator.ValidateStruct(&purchase); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid purchase request")
+	}
+	if purchase.Quantity == 0 {
+		purchase.Quantity = 1
+		if len(purchase.SeatIDs) > 0 {
+			purchase.Quantity = int32(len(purchase.SeatIDs))
+		}
+	}
+	if len(purchase.SeatIDs) > 0 && int(purchase.Quantity) != len(purchase.SeatIDs) {
+		return nil, status.Error(codes.InvalidArgument, "quantity must match the number of selected seats")
+	}
+
+	user := userID(ctx)
+	release, err := s.waitingRoom(ctx, purchase.EventID, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := captchaRequired(s.captcha, "purchase"); err != nil {
+		release()
+		return nil, err
+	}
+
+	resp, err := s.orders.PurchaseTicket(ctx, &pb.PurchaseRequest{
+		EventId:  purchase.EventID,
+		UserId:   user,
+		SeatIds:  purchase.SeatIDs,
+		Quantity: purchase.Quantity,
+		Tier:     purchase.Tier,
+	})
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// Availability and seats of the event changed
+	s.invalidator.Invalidate(ctx, cache.TagEvents, cache.EventTag(purchase.EventID), cache.UserTag(user))
+	return resp, nil
+}
+
+// waitingRoom lets a purchase through once the caller holds a pass for the event, or
+// refuses it with the caller's place in the queue. The returned func gives back the pass
+// use spent when the purchase does not go through.
+func (s *orderServer) waitingRoom(ctx context.Context, eventID, user string) (func(), error) {
+	noop := func() {}
+	room := s.protections.Load().room
+	if room == nil {
+		return noop, nil
+	}
+	settings, open, err := room.Settings(ctx, eventID)
+	if err != nil {
+		// Without Redis the on-sale cannot be queued; let the order service arbitrate
+		s.logger.WithError(err).WithField("event_id", eventID).Error("Waiting room check failed")
+		return noop, nil
+	}
+	if !open {
+		return noop, nil
+	}
+
+	ticket, err := room.Admit(ctx, eventID, user, settings)
+	if err != nil {
+		s.logger.WithError(err).WithField("event_id", eventID).Error("Failed to enter waiting room")
+		return nil, status.Error(codes.Unavailable, "service temporarily unavailable")
+	}
+	if ticket.Status == waitingroom.StatusAdmitted {
+		metrics.WaitingRoomPurchases.WithLabelValues("admitted").Inc()
+		return func() {
+			if err := room.Release(context.WithoutCancel(ctx), settings, ticket); err != nil {
+				s.logger.WithError(err).WithField("event_id", eventID).Warn("Failed to release waiting room pass")
+			}
+		}, nil
+	}
+
+	metrics.WaitingRoomPurchases.WithLabelValues("queued").Inc()
+	return nil, retryError(codes.ResourceExhausted, "the on-sale is busy, you have been placed in the waiting room", errs.ErrWaitingRoomQueued.Code, s.roomCfg.PollInterval, map[string]string{
+		"event_id":    eventID,
+		"queue_token": ticket.Token,
+		"position":    strconv.FormatInt(ticket.Position, 10),
+	})
+}
+
+// ListOrders lists the caller's orders
+func (s *orderServer) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", maxPageSize)
+	}
+	return s.orders.ListOrders(ctx, &pb.ListOrdersRequest{
+		UserId:    userID(ctx),
+		Statuses:  req.GetStatuses(),
+		PageSize:  req.GetPageSize(),
+		PageToken: req.GetPageToken(),
+		OrderBy:   req.GetOrderBy(),
+	})
+}
+
+// GetOrder returns an order of the caller
+func (s *orderServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	user := userID(ctx)
+	resp, err := s.orders.GetOrder(ctx, &pb.GetOrderRequest{OrderId: req.GetOrderId(), UserId: user})
+	if err != nil {
+		return nil, err
+	}
+	// Never reveal other users' orders, even if the backend returned one
+	if resp.GetOrder().GetUserId() != user {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":  user,
+			"order_id": req.GetOrderId(),
+		}).Warn("Order ownership mismatch")
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	return resp, nil
+}
+
+// CancelOrder cancels an order of the caller
+func (s *orderServer) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
+	// Verify ownership at the gateway before asking the backend to cancel
+	if _, err := s.GetOrder(ctx, &pb.GetOrderRequest{OrderId: req.GetOrderId()}); err != nil {
+		return nil, err
+	}
+
+	user := userID(ctx)
+	resp, err := s.orders.CancelOrder(ctx, &pb.CancelOrderRequest{
+		OrderId: req.GetOrderId(),
+		UserId:  user,
+		Reason:  req.GetReason(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidator.Invalidate(ctx, cache.TagEvents, cache.EventTag(resp.GetOrder().GetEventId()), cache.UserTag(user))
+	s.webhooks.Publish(ctx, webhook.EventOrderCancelled, webhook.OrderData{
+		OrderID:  resp.GetOrder().GetId(),
+		EventID:  resp.GetOrder().GetEventId(),
+		UserID:   resp.GetOrder().GetUserId(),
+		Status:   strings.ToLower(resp.GetOrder().GetStatus().String()),
+		Refunded: resp.GetRefunded(),
+	})
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  user,
+		"order_id": req.GetOrderId(),
+		"refunded": resp.GetRefunded(),
+		"via":      "grpc",
+	}).Info("Order cancelled")
+	return resp, nil
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/middleware"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestEmailVerification handles sending a new email verification link
+func (h *UserHandler) RequestEmailVerification(c *gin.Context) {
+	var req dto.EmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid email verification request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	_, err := h.userClient.RequestEmailVerification(c.Request.Context(), &pb.RequestEmailVerificationRequest{
+		Email: req.Email,
+	})
+	if err != nil && !isHiddenRecoveryError(err) {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	// Always answer the same way so the endpoint cannot be used to enumerate accounts
+	respond(c, http.StatusAccepted, gin.H{
+		"message": "If the account exists, a verification email has been sent",
+	})
+}
+
+// ConfirmEmailVerification handles confirming an email address with a verification token
+func (h *UserHandler) ConfirmEmailVerification(c *gin.Context) {
+	var req dto.VerifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).Warn("Invalid email verification confirm request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	resp, err := h.userClient.ConfirmEmailVerification(c.Request.Context(), &pb.ConfirmEmailVerificationRequest{
+		Token: req.Token,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).WithField("user_id", resp.GetUser().GetId()).Info("Email verified")
+
+	respond(c, http.StatusOK, toProfileResp(resp.User))
+}
+
+// RequestPasswordReset handles sending a password reset link
+func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
+	var req dto.EmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid password reset request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	_, err := h.userClient.RequestPasswordReset(c.Request.Context(), &pb.RequestPasswordResetRequest{
+		Email: req.Email,
+	})
+	if err != nil && !isHiddenRecoveryError(err) {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	// Always answer the same way so the endpoint cannot be used to enumerate accounts
+	respond(c, http.StatusAccepted, gin.H{
+		"message": "If the account exists, a password reset email has been sent",
+	})
+}
+
+// ResetPassword handles completing a password reset with a reset token
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Never log the binding error itself, it may echo password values
+		logutils.FromContext(c).Warn("Invalid password reset body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	_, err := h.userClient.ResetPassword(c.Request.Context(), &pb.ResetPasswordRequest{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).Info("Password reset completed")
+
+	c.Status(http.StatusNoContent)
+}
+
+// isHiddenRecoveryError reports whether an error reveals account existence
+// or state and must not be surfaced by the recovery request endpoints
+func isHiddenRecoveryError(err error) bool {
+	switch status.Code(err) {
+	case codes.NotFound, codes.FailedPrecondition, codes.AlreadyExists:
+		return true
+	default:
+		return false
+	}
+}
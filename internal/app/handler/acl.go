@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"apigw/internal/app/acl"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/pkg/utils/clock"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ACLHandler exposes the network access control lists and manages the runtime blocklist
+type ACLHandler struct {
+	cfg       config.ACLConfig
+	blocklist *acl.Blocklist
+	clock     clock.Clock
+	logger    *logrus.Logger
+}
+
+// NewACLHandler creates a new ACL handler
+func NewACLHandler(cfg config.ACLConfig, blocklist *acl.Blocklist, clk clock.Clock, logger *logrus.Logger) *ACLHandler {
+	return &ACLHandler{
+		cfg:       cfg,
+		blocklist: blocklist,
+		clock:     clk,
+		logger:    logger,
+	}
+}
+
+// GetACL returns the configured lists and the runtime blocklist
+func (h *ACLHandler) GetACL(c *gin.Context) {
+	resp := dto.ACLResp{
+		Enabled:   h.cfg.Enabled,
+		Deny:      h.cfg.Deny,
+		Rules:     make([]dto.ACLRuleResp, 0, len(h.cfg.Rules)),
+		Blocklist: make([]dto.BlocklistEntryResp, 0),
+	}
+	for _, entry := range h.blocklist.Entries() {
+		resp.Blocklist = append(resp.Blocklist, toBlocklistEntryResp(entry))
+	}
+	for _, rule := range h.cfg.Rules {
+		resp.Rules = append(resp.Rules, dto.ACLRuleResp{
+			PathPrefix: rule.PathPrefix,
+			Allow:      rule.Allow,
+			Deny:       rule.Deny,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// BlockNetwork adds a network to the runtime blocklist
+func (h *ACLHandler) BlockNetwork(c *gin.Context) {
+	var req dto.BlockNetworkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+	network, err := config.ParseNetwork(req.Network)
+	if err != nil {
+		middleware.ValidationErrorHandler(c, "INVALID_NETWORK", "Network must be a CIDR range or IP address", h.logger)
+		return
+	}
+
+	entry := acl.Entry{
+		Reason:    req.Reason,
+		CreatedBy: c.GetString("user_id"),
+		CreatedAt: h.clock.Now().UTC(),
+	}
+	if req.TTL != "" {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			middleware.ValidationErrorHandler(c, "INVALID_TTL", "TTL must be a positive duration such as 2h", h.logger)
+			return
+		}
+		entry.ExpiresAt = entry.CreatedAt.Add(ttl)
+	}
+
+	if err := h.blocklist.Add(c.Request.Context(), network, entry); err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithError(err).Error("Failed to block network")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	entry.Network = network.String()
+	logutils.FromContext(c).WithFields(auditFields(c)).WithFields(logrus.Fields{
+		"network":    entry.Network,
+		"reason":     entry.Reason,
+		"expires_at": entry.ExpiresAt,
+	}).Warn("Network blocked")
+	c.JSON(http.StatusCreated, toBlocklistEntryResp(entry))
+}
+
+// UnblockNetwork removes the network given by the network query parameter from the
+// runtime blocklist
+func (h *ACLHandler) UnblockNetwork(c *gin.Context) {
+	network, err := config.ParseNetwork(c.Query("network"))
+	if err != nil {
+		middleware.ValidationErrorHandler(c, "INVALID_NETWORK", "Network must be a CIDR range or IP address", h.logger)
+		return
+	}
+
+	removed, err := h.blocklist.Remove(c.Request.Context(), network)
+	if err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithError(err).Error("Failed to unblock network")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+	if !removed {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(auditFields(c)).WithField("network", network.String()).Warn("Network unblocked")
+	c.Status(http.StatusNoContent)
+}
+
+// toBlocklistEntryResp converts a blocklist entry to its response DTO
+func toBlocklistEntryResp(entry acl.Entry) dto.BlocklistEntryResp {
+	resp := dto.BlocklistEntryResp{
+		Network:   entry.Network,
+		Reason:    entry.Reason,
+		CreatedBy: entry.CreatedBy,
+		CreatedAt: entry.CreatedAt,
+	}
+	if !entry.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &entry.ExpiresAt
+	}
+	return resp
+}
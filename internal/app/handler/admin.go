@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"net/http"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/webhook"
+	"apigw/internal/client"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AdminHandler handles operator requests for event, inventory and order management
+type AdminHandler struct {
+	eventClient client.EventService
+	orderClient client.OrderService
+	cache       cache.Invalidator
+	webhooks    webhook.Publisher
+	logger      *logrus.Logger
+}
+
+// NewAdminHandler creates a new admin handler; cached catalog responses are
+// invalidated through invalidator after every change, and forced cancellations are
+// published to partner webhooks
+func NewAdminHandler(eventClient client.EventService, orderClient client.OrderService, invalidator cache.Invalidator, webhooks webhook.Publisher, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{
+		eventClient: eventClient,
+		orderClient: orderClient,
+		cache:       invalidator,
+		webhooks:    webhooks,
+		logger:      logger,
+	}
+}
+
+// CreateEvent handles creating a new event
+func (h *AdminHandler) CreateEvent(c *gin.Context) {
+	var req dto.CreateEventReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithField("error", err.Error()).Warn("Invalid create event request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	resp, err := h.eventClient.CreateEvent(c.Request.Context(), &pb.CreateEventRequest{
+		Event: &pb.Event{
+			Name:             req.Name,
+			Description:      req.Description,
+			Category:         req.Category,
+			Venue:            req.Venue,
+			StartsAt:         timestamppb.New(req.StartsAt),
+			EndsAt:           timestamppb.New(req.EndsAt),
+			AvailableTickets: req.AvailableTickets,
+		},
+		ActorId: c.GetString("user_id"),
+	})
+	if err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithError(err).Error("Event creation failed")
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents)
+
+	logutils.FromContext(c).WithFields(auditFields(c)).WithField("event_id", resp.Event.GetId()).Info("Event created")
+
+	respond(c, http.StatusCreated, toEventResp(resp.Event))
+}
+
+// UpdateEvent handles partial updates of an event
+func (h *AdminHandler) UpdateEvent(c *gin.Context) {
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	var req dto.UpdateEventReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithField("error", err.Error()).Warn("Invalid update event request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	event := &pb.Event{}
+	mask := &fieldmaskpb.FieldMask{}
+	if req.Name != nil {
+		event.Name = *req.Name
+		mask.Paths = append(mask.Paths, "name")
+	}
+	if req.Description != nil {
+		event.Description = *req.Description
+		mask.Paths = append(mask.Paths, "description")
+	}
+	if req.Category != nil {
+		event.Category = *req.Category
+		mask.Paths = append(mask.Paths, "category")
+	}
+	if req.Venue != nil {
+		event.Venue = *req.Venue
+		mask.Paths = append(mask.Paths, "venue")
+	}
+	if req.StartsAt != nil {
+		event.StartsAt = timestamppb.New(*req.StartsAt)
+		mask.Paths = append(mask.Paths, "startsAt")
+	}
+	if req.EndsAt != nil {
+		event.EndsAt = timestamppb.New(*req.EndsAt)
+		mask.Paths = append(mask.Paths, "endsAt")
+	}
+
+	if len(mask.Paths) == 0 {
+		middleware.ValidationErrorHandler(c, "EMPTY_UPDATE", "At least one field must be provided", h.logger)
+		return
+	}
+
+	logFields := auditFields(c)
+	logFields["event_id"] = eventID
+	logFields["fields"] = mask.Paths
+
+	resp, err := h.eventClient.UpdateEvent(c.Request.Context(), &pb.UpdateEventRequest{
+		EventId:    eventID,
+		Event:      event,
+		UpdateMask: mask,
+		ActorId:    c.GetString("user_id"),
+	})
+	if err != nil {
+		logutils.FromContext(c).WithFields(logFields).WithError(err).Error("Event update failed")
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents, cache.EventTag(eventID))
+
+	logutils.FromContext(c).WithFields(logFields).Info("Event updated")
+
+	respond(c, http.StatusOK, toEventResp(resp.Event))
+}
+
+// CloseEvent handles closing sales for an event
+func (h *AdminHandler) CloseEvent(c *gin.Context) {
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	var req dto.CloseEventReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+			return
+		}
+	}
+
+	logFields := auditFields(c)
+	logFields["event_id"] = eventID
+
+	resp, err := h.eventClient.CloseEvent(c.Request.Context(), &pb.CloseEventRequest{
+		EventId: eventID,
+		Reason:  req.Reason,
+		ActorId: c.GetString("user_id"),
+	})
+	if err != nil {
+		logutils.FromContext(c).WithFields(logFields).WithError(err).Error("Event close failed")
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents, cache.EventTag(eventID))
+
+	logutils.FromContext(c).WithFields(logFields).WithField("reason", req.Reason).Info("Event closed")
+
+	respond(c, http.StatusOK, toEventResp(resp.Event))
+}
+
+// AdjustInventory handles adding or removing tickets from an event's inventory
+func (h *AdminHandler) AdjustInventory(c *gin.Context) {
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	var req dto.AdjustInventoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithField("error", err.Error()).Warn("Invalid inventory adjustment request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	logFields := auditFields(c)
+	logFields["event_id"] = eventID
+	logFields["tier"] = req.Tier
+	logFields["delta"] = req.Delta
+
+	resp, err := h.eventClient.AdjustInventory(c.Request.Context(), &pb.AdjustInventoryRequest{
+		EventId: eventID,
+		Tier:    req.Tier,
+		Delta:   req.Delta,
+		Reason:  req.Reason,
+		ActorId: c.GetString("user_id"),
+	})
+	if err != nil {
+		logutils.FromContext(c).WithFields(logFields).WithError(err).Error("Inventory adjustment failed")
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents, cache.EventTag(eventID))
+
+	logutils.FromContext(c).WithFields(logFields).WithField("reason", req.Reason).Info("Inventory adjusted")
+
+	respond(c, http.StatusOK, toEventResp(resp.Event))
+}
+
+// ForceCancelOrder handles cancelling any user's order, bypassing ownership and refund window checks
+func (h *AdminHandler) ForceCancelOrder(c *gin.Context) {
+	var path dto.OrderPath
+	if !bindPath(c, &path, errs.ErrInvalidOrderID, h.logger) {
+		return
+	}
+	orderID := path.OrderID
+
+	var req dto.ForceCancelOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "A cancellation reason is required", h.logger)
+		return
+	}
+
+	logFields := auditFields(c)
+	logFields["order_id"] = orderID
+
+	resp, err := h.orderClient.CancelOrder(c.Request.Context(), &pb.CancelOrderRequest{
+		OrderId: orderID,
+		Reason:  req.Reason,
+		Force:   true,
+		ActorId: c.GetString("user_id"),
+	})
+	if err != nil {
+		logutils.FromContext(c).WithFields(logFields).WithError(err).Error("Forced order cancellation failed")
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents, cache.EventTag(resp.Order.GetEventId()), cache.UserTag(resp.Order.GetUserId()))
+	h.webhooks.Publish(c.Request.Context(), webhook.EventOrderCancelled, orderCancelledData(resp))
+
+	logutils.FromContext(c).WithFields(logFields).WithFields(logrus.Fields{
+		"owner_id": resp.Order.GetUserId(),
+		"refunded": resp.Refunded,
+		"reason":   req.Reason,
+	}).Info("Order force-cancelled")
+
+	respond(c, http.StatusOK, dto.CancelOrderResp{
+		Order:    toOrderResp(resp.Order),
+		Refunded: resp.Refunded,
+	})
+}
+
+// auditFields returns the log fields identifying an operator action and its actor
+func auditFields(c *gin.Context) logrus.Fields {
+	return logrus.Fields{
+		"admin_id": c.GetString("user_id"),
+		"audit":    true,
+	}
+}
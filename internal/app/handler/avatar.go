@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/client"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// avatarField is the multipart form field carrying the image
+const avatarField = "avatar"
+
+// sniffLen is the number of leading bytes the image type is detected from
+const sniffLen = 512
+
+// AvatarHandler handles profile image uploads, streaming them to the user service as they
+// are read instead of holding whole files in memory
+type AvatarHandler struct {
+	userClient client.UserService
+	config     config.AvatarConfig
+	logger     *logrus.Logger
+}
+
+// NewAvatarHandler creates a new avatar handler
+func NewAvatarHandler(userClient client.UserService, cfg config.AvatarConfig, logger *logrus.Logger) *AvatarHandler {
+	return &AvatarHandler{
+		userClient: userClient,
+		config:     cfg,
+		logger:     logger,
+	}
+}
+
+// UploadAvatar replaces the authenticated user's profile image with the one in the avatar
+// field of a multipart/form-data body. The image type is detected from its first bytes,
+// and unsupported or oversized images are rejected before the user service stores them.
+func (h *AvatarHandler) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+	logFields := logrus.Fields{}
+
+	part, err := h.avatarPart(c)
+	if err != nil {
+		h.reject(c, logFields, errs.ErrAvatarMissing, err)
+		return
+	}
+	defer part.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		h.reject(c, logFields, errs.ErrAvatarMissing, err)
+		return
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !slices.Contains(h.config.ContentTypes, contentType) {
+		h.reject(c, logFields, errs.ErrAvatarType, nil)
+		return
+	}
+	logFields["content_type"] = contentType
+
+	// Cancelling the call discards the partial upload at the user service
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stream, err := h.userClient.UploadAvatar(ctx)
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+	err = stream.Send(&pb.UploadAvatarRequest{Data: &pb.UploadAvatarRequest_Metadata{Metadata: &pb.AvatarMetadata{
+		UserId:      userID.(string),
+		ContentType: contentType,
+		FileName:    part.FileName(),
+	}}})
+	if err != nil {
+		h.streamFailed(c, stream, err)
+		return
+	}
+
+	size, err := h.sendChunks(stream, io.MultiReader(bytes.NewReader(head), part))
+	if err != nil && (errors.Is(err, io.EOF) || errs.GetGRPCCode(err) != codes.Unknown) {
+		h.streamFailed(c, stream, err)
+		return
+	}
+	if err != nil {
+		// The deferred cancel abandons the call, so the partial image is never stored
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, errs.ErrAvatarTooLarge):
+			h.reject(c, logFields, errs.ErrAvatarTooLarge, nil)
+		case errors.As(err, &tooLarge):
+			h.reject(c, logFields, errs.ErrPayloadTooLarge, err)
+		default:
+			// The client stopped sending the body
+			h.reject(c, logFields, errs.ErrBadRequest, err)
+		}
+		return
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(logFields).WithField("size", size).Info("Avatar uploaded")
+	respond(c, http.StatusOK, dto.AvatarResp{
+		AvatarURL:   resp.GetAvatarUrl(),
+		ContentType: contentType,
+		Size:        size,
+	})
+}
+
+// avatarPart returns the avatar part of the multipart body, skipping the parts before it
+func (h *AvatarHandler) avatarPart(c *gin.Context) (*multipart.Part, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == avatarField && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// sendChunks streams the image in chunks of the configured size and returns its size. It
+// fails with errs.ErrAvatarTooLarge as soon as the image exceeds the size limit, and with
+// io.EOF when the user service ended the call.
+func (h *AvatarHandler) sendChunks(stream pb.AvatarService_UploadAvatarClient, image io.Reader) (int64, error) {
+	buf := make([]byte, h.config.ChunkSize)
+	var size int64
+	for {
+		n, err := io.ReadFull(image, buf)
+		if n > 0 {
+			size += int64(n)
+			if size > h.config.MaxBytes {
+				return size, errs.ErrAvatarTooLarge
+			}
+			if sendErr := stream.Send(&pb.UploadAvatarRequest{Data: &pb.UploadAvatarRequest_Chunk{Chunk: buf[:n]}}); sendErr != nil {
+				return size, sendErr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return size, nil
+		}
+		if err != nil {
+			return size, err
+		}
+	}
+}
+
+// streamFailed answers with the status the user service ended the upload with; Send only
+// reports io.EOF, the status itself is returned by CloseAndRecv
+func (h *AvatarHandler) streamFailed(c *gin.Context, stream pb.AvatarService_UploadAvatarClient, err error) {
+	if errors.Is(err, io.EOF) {
+		_, err = stream.CloseAndRecv()
+	}
+	middleware.GRPCErrorHandler(c, err, h.logger)
+}
+
+// reject answers an upload that cannot be accepted
+func (h *AvatarHandler) reject(c *gin.Context, logFields logrus.Fields, httpErr *errs.HTTPError, err error) {
+	entry := logutils.FromContext(c).WithFields(logFields).WithField("error_code", httpErr.Code)
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn("Avatar upload rejected")
+	c.JSON(httpErr.Status, httpErr)
+}
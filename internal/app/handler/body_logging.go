@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"apigw/internal/app/bodylog"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/pkg/utils/clock"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BodyLoggingHandler exposes the routes whose bodies are logged and enables routes at
+// runtime
+type BodyLoggingHandler struct {
+	cfg    config.BodyLogConfig
+	routes *bodylog.Switch
+	table  RouteLister
+	clock  clock.Clock
+	logger *logrus.Logger
+}
+
+// NewBodyLoggingHandler creates a new body logging handler; table lists the routes that
+// may be enabled
+func NewBodyLoggingHandler(cfg config.BodyLogConfig, routes *bodylog.Switch, table RouteLister, clk clock.Clock, logger *logrus.Logger) *BodyLoggingHandler {
+	return &BodyLoggingHandler{
+		cfg:    cfg,
+		routes: routes,
+		table:  table,
+		clock:  clk,
+		logger: logger,
+	}
+}
+
+// GetBodyLogging returns the configured routes and those enabled at runtime
+func (h *BodyLoggingHandler) GetBodyLogging(c *gin.Context) {
+	resp := dto.BodyLoggingResp{
+		Enabled:      h.cfg.Enabled,
+		MaxBytes:     h.cfg.MaxBytes,
+		ContentTypes: h.cfg.ContentTypes,
+		Configured:   h.cfg.Routes,
+		Runtime:      make([]dto.BodyLoggingRouteResp, 0),
+	}
+	for _, route := range h.routes.Routes() {
+		resp.Runtime = append(resp.Runtime, toBodyLoggingRouteResp(route))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// EnableBodyLogging logs the bodies of a registered route until its TTL expires
+func (h *BodyLoggingHandler) EnableBodyLogging(c *gin.Context) {
+	if !h.cfg.Enabled {
+		c.JSON(errs.ErrConflict.Status, errs.ErrConflict.WithMessage("Body logging is disabled by log.bodies.enabled"))
+		return
+	}
+	var req dto.EnableBodyLoggingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+	if !config.ValidRoutePattern(req.Route) {
+		middleware.ValidationErrorHandler(c, "INVALID_ROUTE", "Route must be a route pattern starting with /, optionally preceded by a method", h.logger)
+		return
+	}
+	if !h.registered(req.Route) {
+		middleware.ValidationErrorHandler(c, "UNKNOWN_ROUTE", "Route matches no registered route", h.logger)
+		return
+	}
+	ttl := h.cfg.DefaultTTL
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 || ttl > h.cfg.MaxTTL {
+			middleware.ValidationErrorHandler(c, "INVALID_TTL", "TTL must be a positive duration such as 30m, at most "+h.cfg.MaxTTL.String(), h.logger)
+			return
+		}
+	}
+
+	route := bodylog.Route{
+		Route:     req.Route,
+		Reason:    req.Reason,
+		EnabledBy: c.GetString("user_id"),
+		EnabledAt: h.clock.Now().UTC(),
+	}
+	route.ExpiresAt = route.EnabledAt.Add(ttl)
+	h.routes.Enable(route)
+
+	logutils.FromContext(c).WithFields(auditFields(c)).WithFields(logrus.Fields{
+		"body_route": route.Route,
+		"reason":     route.Reason,
+		"expires_at": route.ExpiresAt,
+	}).Warn("Body logging enabled")
+	c.JSON(http.StatusCreated, toBodyLoggingRouteResp(route))
+}
+
+// DisableBodyLogging stops logging the bodies of the route given by the route query
+// parameter, enabled at runtime
+func (h *BodyLoggingHandler) DisableBodyLogging(c *gin.Context) {
+	route := c.Query("route")
+	if !config.ValidRoutePattern(route) {
+		middleware.ValidationErrorHandler(c, "INVALID_ROUTE", "Route must be a route pattern starting with /, optionally preceded by a method", h.logger)
+		return
+	}
+	if !h.routes.Disable(route) {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(auditFields(c)).WithField("body_route", route).Warn("Body logging disabled")
+	c.Status(http.StatusNoContent)
+}
+
+// registered reports whether a route, with or without its method, matches a route of the
+// public router
+func (h *BodyLoggingHandler) registered(route string) bool {
+	method, pattern, ok := strings.Cut(route, " ")
+	if !ok {
+		method, pattern = "", route
+	}
+	for _, r := range h.table.Routes().Routes {
+		if r.Path == pattern && (method == "" || r.Method == method) {
+			return true
+		}
+	}
+	return false
+}
+
+// toBodyLoggingRouteResp converts a route enabled at runtime to its response DTO
+func toBodyLoggingRouteResp(route bodylog.Route) dto.BodyLoggingRouteResp {
+	return dto.BodyLoggingRouteResp{
+		Route:     route.Route,
+		Reason:    route.Reason,
+		EnabledBy: route.EnabledBy,
+		EnabledAt: route.EnabledAt,
+		ExpiresAt: route.ExpiresAt,
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigHandler exposes the configuration the running instance loaded
+type ConfigHandler struct {
+	cfg    *config.Config
+	logger *logrus.Logger
+}
+
+// NewConfigHandler creates a new config handler
+func NewConfigHandler(cfg *config.Config, logger *logrus.Logger) *ConfigHandler {
+	return &ConfigHandler{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// GetConfig returns the effective merged configuration with secrets redacted
+func (h *ConfigHandler) GetConfig(c *gin.Context) {
+	logutils.FromContext(c).WithFields(auditFields(c)).Info("Configuration dump requested")
+
+	c.JSON(http.StatusOK, dto.ConfigDumpResp{
+		Sources: h.cfg.Files,
+		Config:  h.cfg.Redacted(),
+	})
+}
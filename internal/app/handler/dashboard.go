@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/client"
+	"apigw/pkg/utils/clock"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DashboardHandler serves the aggregated dashboard of the authenticated user, saving
+// clients the round trips of reading each part separately
+type DashboardHandler struct {
+	userClient  client.UserService
+	orderClient client.OrderService
+	eventClient client.EventService
+	config      config.DashboardConfig
+	clock       clock.Clock
+	logger      *logrus.Logger
+}
+
+// NewDashboardHandler creates a new dashboard handler
+func NewDashboardHandler(userClient client.UserService, orderClient client.OrderService, eventClient client.EventService, cfg config.DashboardConfig, clk clock.Clock, logger *logrus.Logger) *DashboardHandler {
+	return &DashboardHandler{
+		userClient:  userClient,
+		orderClient: orderClient,
+		eventClient: eventClient,
+		config:      cfg,
+		clock:       clk,
+		logger:      logger,
+	}
+}
+
+// GetDashboard reads the profile, recent orders and upcoming events concurrently. A
+// section that fails carries its error while the others are returned; only when every
+// section fails is the request failed.
+func (h *DashboardHandler) GetDashboard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var resp dto.DashboardResp
+	var group errgroup.Group
+	group.Go(func() error {
+		resp.Profile = h.section(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+			profile, err := h.userClient.GetProfile(ctx, &pb.GetProfileRequest{UserId: userID.(string)})
+			if err != nil {
+				return nil, err
+			}
+			return toProfileResp(profile.User), nil
+		})
+		return nil
+	})
+	group.Go(func() error {
+		resp.RecentOrders = h.section(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+			list, err := h.orderClient.ListOrders(ctx, &pb.ListOrdersRequest{
+				UserId:   userID.(string),
+				PageSize: int32(h.config.RecentOrders),
+				OrderBy:  "createdAt desc",
+			})
+			if err != nil {
+				return nil, err
+			}
+			orders := make([]dto.OrderResp, 0, len(list.Orders))
+			for _, order := range list.Orders {
+				orders = append(orders, toOrderResp(order))
+			}
+			return orders, nil
+		})
+		return nil
+	})
+	group.Go(func() error {
+		resp.UpcomingEvents = h.section(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+			list, err := h.eventClient.ListEvents(ctx, &pb.ListEventsRequest{
+				StartsAfter: timestamppb.New(h.clock.Now()),
+				PageSize:    int32(h.config.UpcomingEvents),
+				OrderBy:     "startsAt",
+			})
+			if err != nil {
+				return nil, err
+			}
+			events := make([]dto.EventResp, 0, len(list.Events))
+			for _, event := range list.Events {
+				events = append(events, toEventResp(event))
+			}
+			return events, nil
+		})
+		return nil
+	})
+	group.Wait()
+
+	sections := []dto.DashboardSection{resp.Profile, resp.RecentOrders, resp.UpcomingEvents}
+	var sectionErrors []*errs.HTTPError
+	for _, section := range sections {
+		if section.Error != nil {
+			sectionErrors = append(sectionErrors, section.Error)
+		}
+	}
+	failed := len(sectionErrors)
+	resp.Partial = failed > 0
+
+	if failed == len(sections) {
+		logutils.FromContext(c).Warn("Every dashboard section failed")
+		c.JSON(resp.Profile.Error.Status, resp.Profile.Error)
+		return
+	}
+	if failed > 0 {
+		logutils.FromContext(c).WithField("failed", failed).Info("Dashboard served partially")
+	}
+
+	respondPartial(c, http.StatusOK, resp, sectionErrors)
+}
+
+// section reads one dashboard section within the section timeout
+func (h *DashboardHandler) section(ctx context.Context, read func(context.Context) (interface{}, error)) dto.DashboardSection {
+	ctx, cancel := context.WithTimeout(ctx, h.config.SectionTimeout)
+	defer cancel()
+
+	data, err := read(ctx)
+	if err != nil {
+		// The failed call itself is logged by the client logging interceptor
+		return dto.DashboardSection{Error: errs.GRPCToHTTPError(err)}
+	}
+	return dto.DashboardSection{Data: data}
+}
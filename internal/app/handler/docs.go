@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/openapi"
+	"apigw/pkg/utils/codec"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// OpenAPIPath is where the OpenAPI document is served
+const OpenAPIPath = "/openapi.json"
+
+// DocsHandler serves the OpenAPI document of the gateway and a Swagger UI browsing it.
+// Both are rendered once, when the handler is created.
+type DocsHandler struct {
+	spec   []byte
+	page   []byte
+	logger *logrus.Logger
+}
+
+// NewDocsHandler creates a new docs handler serving doc. Rendering failures are logged
+// and the routes then answer with an internal error.
+func NewDocsHandler(doc *openapi.Document, cfg config.DocsConfig, logger *logrus.Logger) *DocsHandler {
+	h := &DocsHandler{logger: logger}
+
+	spec, err := codec.Marshal(doc)
+	if err != nil {
+		logger.WithError(err).Error("Failed to render the OpenAPI document")
+	} else {
+		h.spec = spec
+	}
+
+	page, err := openapi.SwaggerUI(doc.Info.Title, cfg.SwaggerUIURL, OpenAPIPath)
+	if err != nil {
+		logger.WithError(err).Error("Failed to render the Swagger UI")
+	} else {
+		h.page = page
+	}
+	return h
+}
+
+// OpenAPI serves the OpenAPI document
+func (h *DocsHandler) OpenAPI(c *gin.Context) {
+	h.serve(c, "application/json", h.spec)
+}
+
+// SwaggerUI serves the Swagger UI page
+func (h *DocsHandler) SwaggerUI(c *gin.Context) {
+	h.serve(c, "text/html; charset=utf-8", h.page)
+}
+
+// serve writes a pre-rendered body, or an internal error when it failed to render
+func (h *DocsHandler) serve(c *gin.Context, contentType string, body []byte) {
+	if body == nil {
+		c.JSON(errs.ErrInternalServer.Status, errs.ErrInternalServer)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
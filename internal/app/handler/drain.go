@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/drain"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DrainHandler reports and starts the graceful drain of the instance
+type DrainHandler struct {
+	drainer *drain.Drainer
+	logger  *logrus.Logger
+}
+
+// NewDrainHandler creates a new drain handler
+func NewDrainHandler(drainer *drain.Drainer, logger *logrus.Logger) *DrainHandler {
+	return &DrainHandler{
+		drainer: drainer,
+		logger:  logger,
+	}
+}
+
+// GetDrain returns the drain state and the number of requests in flight
+func (h *DrainHandler) GetDrain(c *gin.Context) {
+	c.JSON(http.StatusOK, toDrainResp(h.drainer.Status()))
+}
+
+// StartDrain starts draining the instance, as on SIGTERM: /readyz fails, and the server
+// shuts down once drain_delay has passed and in-flight requests have completed
+func (h *DrainHandler) StartDrain(c *gin.Context) {
+	if h.drainer.Start("admin") {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithField("in_flight", h.drainer.InFlight()).Warn("Drain started")
+	}
+	c.JSON(http.StatusAccepted, toDrainResp(h.drainer.Status()))
+}
+
+// toDrainResp converts a drain status to its response DTO
+func toDrainResp(status drain.Status) dto.DrainResp {
+	resp := dto.DrainResp{
+		Draining: status.Draining,
+		Reason:   status.Reason,
+		InFlight: status.InFlight,
+	}
+	if status.Draining {
+		resp.StartedAt = &status.StartedAt
+	}
+	return resp
+}
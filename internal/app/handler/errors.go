@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCatalogHandler documents the errors the gateway returns, so clients can program
+// against their stable codes instead of parsing messages
+type ErrorCatalogHandler struct{}
+
+// NewErrorCatalogHandler creates a new error catalog handler
+func NewErrorCatalogHandler() *ErrorCatalogHandler {
+	return &ErrorCatalogHandler{}
+}
+
+// ListErrors returns every error of the catalog, ordered by code
+func (h *ErrorCatalogHandler) ListErrors(c *gin.Context) {
+	respond(c, http.StatusOK, dto.ErrorCatalogResp{Errors: errs.Catalog()})
+}
+
+// GetError returns the catalog entry of an error code
+func (h *ErrorCatalogHandler) GetError(c *gin.Context) {
+	entry, ok := errs.Lookup(c.Param("code"))
+	if !ok {
+		httpErr := errs.ErrNotFound.WithMessage("Unknown error code")
+		c.JSON(httpErr.Status, httpErr)
+		return
+	}
+	respond(c, http.StatusOK, entry)
+}
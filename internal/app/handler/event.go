@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/listing"
+	"apigw/internal/app/middleware"
+	"apigw/internal/client"
+	"apigw/pkg/utils/pool"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultEventsPageSize is used when the client does not specify a limit
+const defaultEventsPageSize = 20
+
+// maxPooledEventsPage is the capacity above which page buffers are left to the GC
+const maxPooledEventsPage = 4 * defaultEventsPageSize
+
+// EventHandler handles HTTP requests for the event catalog
+type EventHandler struct {
+	eventClient client.EventService
+	logger      *logrus.Logger
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(eventClient client.EventService, logger *logrus.Logger) *EventHandler {
+	return &EventHandler{
+		eventClient: eventClient,
+		logger:      logger,
+	}
+}
+
+// eventPagePool reuses the DTO buffers of event search pages; buffers grown by
+// unusually large pages are not kept
+var eventPagePool = pool.New(
+	func() *[]dto.EventResp {
+		events := make([]dto.EventResp, 0, defaultEventsPageSize)
+		return &events
+	},
+	func(events *[]dto.EventResp) bool {
+		if cap(*events) > maxPooledEventsPage {
+			return false
+		}
+		clear(*events)
+		*events = (*events)[:0]
+		return true
+	},
+)
+
+// eventListSpec is the paging, sorting and field selection of event searches
+var eventListSpec = listing.Spec{
+	DefaultLimit: defaultEventsPageSize,
+	MaxLimit:     maxPageSize,
+	Sorts: map[string]string{
+		"startsAt":         "startsAt",
+		"name":             "name",
+		"availableTickets": "availableTickets",
+	},
+	Fields: listing.FieldsOf(dto.EventResp{}),
+}
+
+// ListEvents handles event search with filters and pagination
+func (h *EventHandler) ListEvents(c *gin.Context) {
+	h.listEvents(c, func(events interface{}, nextCursor string, _ listing.Params) interface{} {
+		return dto.ListEventsResp{Events: events, NextCursor: nextCursor}
+	})
+}
+
+// ListEventsV2 handles event search for API v2, responding with the list envelope
+func (h *EventHandler) ListEventsV2(c *gin.Context) {
+	h.listEvents(c, pageV2)
+}
+
+// listEvents runs an event search and renders the page with page
+func (h *EventHandler) listEvents(c *gin.Context, page pageFunc) {
+	params, ok := parseListParams(c, eventListSpec, h.logger)
+	if !ok {
+		return
+	}
+
+	var req dto.ListEventsReq
+	if !bindQuery(c, &req, h.logger) {
+		return
+	}
+
+	grpcReq := &pb.ListEventsRequest{
+		Query:     req.Query,
+		Category:  req.Category,
+		PageSize:  params.Limit,
+		PageToken: params.Cursor,
+		OrderBy:   params.OrderBy(eventListSpec),
+	}
+	if !req.From.IsZero() {
+		grpcReq.StartsAfter = timestamppb.New(req.From)
+	}
+	if !req.To.IsZero() {
+		grpcReq.StartsBefore = timestamppb.New(req.To)
+	}
+
+	resp, err := h.eventClient.ListEvents(c.Request.Context(), grpcReq)
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	// The page is encoded before the handler returns, so its buffer can be reused
+	events := eventPagePool.Get()
+	defer eventPagePool.Put(events)
+	for _, event := range resp.Events {
+		*events = append(*events, toEventResp(event))
+	}
+
+	writePage(c, params, *events, resp.NextPageToken, page, h.logger)
+}
+
+// GetEvent handles fetching a single event
+func (h *EventHandler) GetEvent(c *gin.Context) {
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	resp, err := h.eventClient.GetEvent(c.Request.Context(), &pb.GetEventRequest{
+		EventId: eventID,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	respond(c, http.StatusOK, toEventResp(resp.Event))
+}
+
+// GetSeatMap handles fetching seat availability for an event
+func (h *EventHandler) GetSeatMap(c *gin.Context) {
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	resp, err := h.eventClient.GetSeatMap(c.Request.Context(), &pb.GetSeatMapRequest{
+		EventId: eventID,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	seats := make([]dto.SeatResp, 0, len(resp.Seats))
+	for _, seat := range resp.Seats {
+		seats = append(seats, dto.SeatResp{
+			ID:         seat.GetId(),
+			Section:    seat.GetSection(),
+			Row:        seat.GetRow(),
+			Number:     seat.GetNumber(),
+			Tier:       seat.GetTier(),
+			Status:     strings.ToLower(seat.GetStatus().String()),
+			PriceCents: seat.GetPriceCents(),
+		})
+	}
+
+	respond(c, http.StatusOK, dto.SeatMapResp{
+		EventID: resp.EventId,
+		Seats:   seats,
+	})
+}
+
+// toEventResp converts a protobuf event into the event DTO
+func toEventResp(event *pb.Event) dto.EventResp {
+	return dto.EventResp{
+		ID:               event.GetId(),
+		Name:             event.GetName(),
+		Description:      event.GetDescription(),
+		Category:         event.GetCategory(),
+		Venue:            event.GetVenue(),
+		StartsAt:         event.GetStartsAt().AsTime(),
+		EndsAt:           event.GetEndsAt().AsTime(),
+		AvailableTickets: event.GetAvailableTickets(),
+		Status:           strings.ToLower(event.GetStatus().String()),
+	}
+}
@@ -0,0 +1,69 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/handler"
+	"apigw/internal/client/mocks"
+	"apigw/internal/testutil"
+
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGetEvent(t *testing.T) {
+	startsAt := time.Date(2026, 7, 4, 19, 0, 0, 0, time.UTC)
+	event := &pb.Event{
+		Id:               "evt_1001",
+		Name:             "Summer Open Air",
+		Category:         "concert",
+		Venue:            "Riverside Park",
+		StartsAt:         timestamppb.New(startsAt),
+		EndsAt:           timestamppb.New(startsAt.Add(4 * time.Hour)),
+		AvailableTickets: 120,
+		Status:           pb.Event_ON_SALE,
+	}
+
+	testutil.Run(t, []testutil.Case[*mocks.MockEventService]{
+		{
+			Name:    "found",
+			Request: testutil.Get("/api/v1/events/evt_1001"),
+			Expect: func(events *mocks.MockEventService) {
+				events.EXPECT().GetEvent(gomock.Any(), testutil.ProtoEq(&pb.GetEventRequest{EventId: "evt_1001"})).
+					Return(&pb.GetEventResponse{Event: event}, nil)
+			},
+		},
+		{
+			Name:    "not_found",
+			Request: testutil.Get("/api/v1/events/evt_404"),
+			Expect: func(events *mocks.MockEventService) {
+				events.EXPECT().GetEvent(gomock.Any(), gomock.Any()).
+					Return(nil, status.Error(codes.NotFound, "event not found"))
+			},
+		},
+		{
+			Name:    "invalid_id",
+			Request: testutil.Get("/api/v1/events/evt.1001"),
+		},
+		{
+			Name:    "backend_unavailable",
+			Request: testutil.Get("/api/v1/events/evt_1001"),
+			Expect: func(events *mocks.MockEventService) {
+				events.EXPECT().GetEvent(gomock.Any(), gomock.Any()).
+					Return(nil, status.Error(codes.Unavailable, "connection refused"))
+			},
+			Headers: []string{"Retry-After"},
+		},
+	}, func(t *testing.T) (http.Handler, *mocks.MockEventService) {
+		events := mocks.NewMockEventService(gomock.NewController(t))
+		h := handler.NewEventHandler(events, testutil.Logger())
+		engine := testutil.NewEngine(t)
+		engine.GET("/api/v1/events/:event_id", h.GetEvent)
+		return engine, events
+	})
+}
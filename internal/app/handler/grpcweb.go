@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/client"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// grpcWebTrailerFlag marks a frame carrying trailers instead of a message
+	grpcWebTrailerFlag = 0x80
+	// grpcWebMaxMessageSize bounds the size of a single request message
+	grpcWebMaxMessageSize = 4 << 20
+)
+
+// grpcWebMethod describes a backend RPC exposed over gRPC-Web
+type grpcWebMethod struct {
+	newRequest   func() proto.Message
+	invoke       func(ctx context.Context, c *gin.Context, req proto.Message) (proto.Message, error)
+	authRequired bool
+}
+
+// GRPCWebHandler proxies gRPC-Web requests from browsers to the backend gRPC clients
+type GRPCWebHandler struct {
+	methods map[string]grpcWebMethod
+	logger  *logrus.Logger
+}
+
+// NewGRPCWebHandler creates a new gRPC-Web handler
+func NewGRPCWebHandler(userClient client.UserService, orderClient client.OrderService, logger *logrus.Logger) *GRPCWebHandler {
+	return &GRPCWebHandler{
+		logger: logger,
+		methods: map[string]grpcWebMethod{
+			"user.UserService/Register": {
+				newRequest: func() proto.Message { return &pb.RegisterRequest{} },
+				invoke: func(ctx context.Context, _ *gin.Context, req proto.Message) (proto.Message, error) {
+					return userClient.Register(ctx, req.(*pb.RegisterRequest))
+				},
+			},
+			"user.UserService/Login": {
+				newRequest: func() proto.Message { return &pb.LoginRequest{} },
+				invoke: func(ctx context.Context, _ *gin.Context, req proto.Message) (proto.Message, error) {
+					return userClient.Login(ctx, req.(*pb.LoginRequest))
+				},
+			},
+			"user.UserService/RefreshToken": {
+				newRequest: func() proto.Message { return &pb.RefreshTokenRequest{} },
+				invoke: func(ctx context.Context, _ *gin.Context, req proto.Message) (proto.Message, error) {
+					return userClient.RefreshToken(ctx, req.(*pb.RefreshTokenRequest))
+				},
+			},
+			"order.OrderService/PurchaseTicket": {
+				newRequest: func() proto.Message { return &pb.PurchaseRequest{} },
+				invoke: func(ctx context.Context, c *gin.Context, req proto.Message) (proto.Message, error) {
+					purchase := req.(*pb.PurchaseRequest)
+					// Never trust the user ID sent by the browser
+					purchase.UserId = c.GetString("user_id")
+					return orderClient.PurchaseTicket(ctx, purchase)
+				},
+				authRequired: true,
+			},
+		},
+	}
+}
+
+// Handle serves a unary gRPC-Web call
+func (h *GRPCWebHandler) Handle(c *gin.Context) {
+	fullMethod := c.Param("service") + "/" + c.Param("method")
+	contentType := c.GetHeader("Content-Type")
+	textMode := strings.HasPrefix(contentType, grpcWebTextContentType)
+
+	if !strings.HasPrefix(contentType, grpcWebContentType) {
+		c.AbortWithStatus(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	method, ok := h.methods[fullMethod]
+	if !ok {
+		h.writeStatus(c, textMode, status.New(codes.Unimplemented, "unknown method "+fullMethod))
+		return
+	}
+
+	if method.authRequired {
+		if _, exists := c.Get("user_id"); !exists {
+			h.writeStatus(c, textMode, status.New(codes.Unauthenticated, "authentication required"))
+			return
+		}
+	}
+
+	var body io.Reader = io.LimitReader(c.Request.Body, grpcWebMaxMessageSize+5)
+	if textMode {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	payload, err := readGRPCWebFrame(body)
+	if err != nil {
+		h.writeStatus(c, textMode, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	req := method.newRequest()
+	if err := proto.Unmarshal(payload, req); err != nil {
+		h.writeStatus(c, textMode, status.New(codes.InvalidArgument, "malformed request message"))
+		return
+	}
+
+	resp, err := method.invoke(c.Request.Context(), c, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"method":    fullMethod,
+			"grpc_code": st.Code().String(),
+		}).Error("gRPC-Web call failed")
+		h.writeStatus(c, textMode, errs.PublicStatus(st))
+		return
+	}
+
+	out, err := proto.Marshal(resp)
+	if err != nil {
+		h.writeStatus(c, textMode, status.New(codes.Internal, "failed to encode response"))
+		return
+	}
+
+	var buf bytes.Buffer
+	writeGRPCWebFrame(&buf, 0, out)
+	writeGRPCWebFrame(&buf, grpcWebTrailerFlag, []byte("grpc-status:0\r\ngrpc-message:\r\n"))
+	h.writeBody(c, textMode, buf.Bytes())
+}
+
+// writeStatus writes a trailers-only gRPC-Web response for a failed call
+func (h *GRPCWebHandler) writeStatus(c *gin.Context, textMode bool, st *status.Status) {
+	c.Header("grpc-status", fmt.Sprintf("%d", st.Code()))
+	c.Header("grpc-message", url.PathEscape(st.Message()))
+	h.writeBody(c, textMode, nil)
+}
+
+// writeBody writes the response frames using the negotiated encoding
+func (h *GRPCWebHandler) writeBody(c *gin.Context, textMode bool, frames []byte) {
+	contentType := grpcWebContentType + "+proto"
+	if textMode {
+		contentType = grpcWebTextContentType + "+proto"
+		frames = []byte(base64.StdEncoding.EncodeToString(frames))
+	}
+	c.Data(http.StatusOK, contentType, frames)
+}
+
+// readGRPCWebFrame reads a single length-prefixed message frame
+func readGRPCWebFrame(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("missing message frame")
+	}
+	if header[0] != 0 {
+		return nil, fmt.Errorf("compressed messages are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > grpcWebMaxMessageSize {
+		return nil, fmt.Errorf("message exceeds %d bytes", grpcWebMaxMessageSize)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("truncated message frame")
+	}
+	return payload, nil
+}
+
+// writeGRPCWebFrame appends a length-prefixed frame
+func writeGRPCWebFrame(buf *bytes.Buffer, flag byte, payload []byte) {
+	var header [5]byte
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	buf.Write(header[:])
+	buf.Write(payload)
+}
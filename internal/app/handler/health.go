@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/buildinfo"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/drain"
+	"apigw/internal/app/limiter"
+	"apigw/internal/client"
+	"apigw/pkg/utils/codec"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/connectivity"
+)
+
+// HealthHandler handles readiness probes and reports the running build
+type HealthHandler struct {
+	clients         *client.Registry
+	requireBackends bool
+	drainer         *drain.Drainer
+	limiter         *limiter.Watchdog
+	logger          *logrus.Logger
+}
+
+// NewHealthHandler creates a new health handler; with requireBackends the gateway reports
+// unready until every backend service has been reachable once. The gateway also reports
+// unready while draining. The mode of the rate limiters is reported without affecting
+// readiness, since every instance would be taken out together while Redis is down.
+func NewHealthHandler(clients *client.Registry, requireBackends bool, drainer *drain.Drainer, limiter *limiter.Watchdog, logger *logrus.Logger) *HealthHandler {
+	return &HealthHandler{
+		clients:         clients,
+		requireBackends: requireBackends,
+		drainer:         drainer,
+		limiter:         limiter,
+		logger:          logger,
+	}
+}
+
+// Readyz reports readiness along with the connectivity state of every upstream
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	resp := dto.ReadinessResp{
+		Status:      "ready",
+		Upstreams:   make(map[string]dto.UpstreamStatus),
+		RateLimiter: h.limiter.Mode(),
+	}
+
+	for name, states := range h.clients.States() {
+		upstream := dto.UpstreamStatus{}
+		for _, s := range states {
+			upstream.Endpoints = append(upstream.Endpoints, dto.EndpointStatus{
+				Endpoint: s.Endpoint,
+				State:    s.State,
+			})
+			upstream.Ready = upstream.Ready || s.State == connectivity.Ready.String()
+		}
+		resp.Upstreams[name] = upstream
+	}
+
+	status := http.StatusOK
+	if h.drainer.Draining() {
+		status = http.StatusServiceUnavailable
+		resp.Status = "draining"
+	} else if h.requireBackends && !h.clients.BeenReady() {
+		status = http.StatusServiceUnavailable
+		resp.Status = "waiting_for_backends"
+		logutils.FromContext(c).Debug("Readiness probe failed, backends not reachable yet")
+	}
+
+	c.JSON(status, resp)
+}
+
+// Version returns the build information of the running binary
+func (h *HealthHandler) Version(c *gin.Context) {
+	build := buildinfo.Get()
+	c.JSON(http.StatusOK, dto.VersionResp{
+		Version:   build.Version,
+		Commit:    build.Commit,
+		BuildDate: build.Date,
+		Modified:  build.Modified,
+		GoVersion: build.GoVersion,
+		Platform:  build.Platform,
+		JSONCodec: codec.Name,
+	})
+}
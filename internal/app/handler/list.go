@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"apigw/internal/app/domains/dto"
+	dtov2 "apigw/internal/app/domains/dto/v2"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/listing"
+	"apigw/internal/app/middleware"
+	"apigw/pkg/utils/codec"
+	"apigw/pkg/utils/timing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPageSize bounds the limit parameter of every list endpoint
+const maxPageSize = 100
+
+// pageFunc builds the response body of a list endpoint from a page of items
+type pageFunc func(items interface{}, nextCursor string, params listing.Params) interface{}
+
+// pageV2 wraps a page in the v2 list envelope
+func pageV2(items interface{}, nextCursor string, params listing.Params) interface{} {
+	return dtov2.Page{
+		Data:       items,
+		Pagination: pagination(nextCursor, params),
+	}
+}
+
+// pagination describes a page of a list endpoint
+func pagination(nextCursor string, params listing.Params) dto.Pagination {
+	return dto.Pagination{
+		Limit:      params.Limit,
+		NextCursor: nextCursor,
+		Sort:       params.SortParam(),
+	}
+}
+
+// parseListParams parses the list query parameters of a request, answering invalid
+// ones with 400 and their violation; it returns false when the request was rejected
+func parseListParams(c *gin.Context, spec listing.Spec, logger *logrus.Logger) (listing.Params, bool) {
+	params, err := listing.Parse(c, spec)
+	if err != nil {
+		middleware.BindingErrorHandler(c, err, errs.ErrInvalidQuery.Code, err.Error(), logger)
+		return listing.Params{}, false
+	}
+	return params, true
+}
+
+// writePage applies the field selection to a page of items and renders it with page, or
+// in the standard envelope with the pagination in its meta when the route's API version
+// envelopes responses
+func writePage(c *gin.Context, params listing.Params, items interface{}, nextCursor string, page pageFunc, logger *logrus.Logger) {
+	defer timing.FromContext(c.Request.Context()).Since(timing.Serialization, time.Now())
+	selected, err := params.Select(items)
+	if err != nil {
+		logger.WithError(err).WithFields(logrus.Fields{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		}).Error("Failed to select list fields")
+		c.JSON(errs.ErrInternalServer.Status, errs.ErrInternalServer)
+		return
+	}
+	if middleware.Enveloped(c) {
+		resp := envelope(c, selected)
+		meta := pagination(nextCursor, params)
+		resp.Meta.Pagination = &meta
+		c.Render(http.StatusOK, codec.JSON{Data: resp})
+		return
+	}
+	c.Render(http.StatusOK, codec.JSON{Data: page(selected, nextCursor, params)})
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"net/http"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/domains/dto"
+	dtov2 "apigw/internal/app/domains/dto/v2"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/openapi"
+)
+
+// Operations documents what the handlers bind and return, keyed by the short handler name
+// of the route table. Handlers missing here are left out of the OpenAPI document, so a new
+// handler must be added for its routes to be documented.
+var Operations = map[string]openapi.Operation{
+	// Users
+	"handler.(*UserHandler).Register": {
+		Summary: "Register an account", Body: dto.RegisterReq{},
+		Status: http.StatusCreated, Response: dto.RegisterResp{},
+	},
+	"handler.(*UserHandler).Login": {
+		Summary: "Log in", Body: dto.LoginReq{}, Response: dto.LoginResp{},
+	},
+	"handler.(*UserHandler).RefreshToken": {
+		Summary: "Refresh the access token", Body: dto.RefreshTokenReq{}, Response: dto.RefreshTokenResp{},
+	},
+	"handler.(*UserHandler).RegisterV2": {
+		Summary: "Register an account", Body: dtov2.RegisterReq{},
+		Status: http.StatusCreated, Response: dtov2.RegisterResp{},
+	},
+	"handler.(*UserHandler).LoginV2": {
+		Summary: "Log in", Body: dtov2.LoginReq{}, Response: dtov2.LoginResp{},
+	},
+	"handler.(*UserHandler).RefreshTokenV2": {
+		Summary: "Refresh the access token", Body: dtov2.RefreshTokenReq{}, Response: dtov2.RefreshTokenResp{},
+	},
+	"handler.(*UserHandler).RequestEmailVerification": {
+		Summary: "Send an email verification token", Body: dto.EmailReq{},
+		Status: http.StatusAccepted, Response: map[string]string{},
+	},
+	"handler.(*UserHandler).ConfirmEmailVerification": {
+		Summary: "Verify the email address", Body: dto.VerifyEmailReq{}, Response: dto.ProfileResp{},
+	},
+	"handler.(*UserHandler).RequestPasswordReset": {
+		Summary: "Send a password reset token", Body: dto.EmailReq{},
+		Status: http.StatusAccepted, Response: map[string]string{},
+	},
+	"handler.(*UserHandler).ResetPassword": {
+		Summary: "Reset the password", Body: dto.ResetPasswordReq{}, Status: http.StatusNoContent,
+	},
+	"handler.(*UserHandler).GetProfile": {
+		Summary: "Get the profile", Response: dto.ProfileResp{},
+	},
+	"handler.(*UserHandler).UpdateProfile": {
+		Summary: "Update the profile", Body: dto.UpdateProfileReq{}, Response: dto.ProfileResp{},
+	},
+	"handler.(*UserHandler).ChangePassword": {
+		Summary: "Change the password", Body: dto.ChangePasswordReq{}, Status: http.StatusNoContent,
+	},
+	"handler.(*AvatarHandler).UploadAvatar": {
+		Summary: "Upload the avatar", Upload: "avatar", Response: dto.AvatarResp{},
+	},
+	"handler.(*DashboardHandler).GetDashboard": {
+		Summary: "Get the profile, recent orders and upcoming events at once", Response: dto.DashboardResp{},
+	},
+
+	// Error catalog
+	"handler.(*ErrorCatalogHandler).ListErrors": {
+		Summary: "List the error codes", Response: dto.ErrorCatalogResp{},
+	},
+	"handler.(*ErrorCatalogHandler).GetError": {
+		Summary: "Get an error code", Response: errs.CatalogEntry{},
+	},
+	"handler.(*UsageHandler).GetUsage": {
+		Summary: "Get the quota consumption of the caller", Response: dto.UsageResp{},
+	},
+
+	// Events
+	"handler.(*EventHandler).ListEvents": {
+		Summary: "Search events", Query: dto.ListEventsReq{}, List: &eventListSpec,
+		Response: dto.ListEventsResp{}, Items: dto.EventResp{},
+	},
+	"handler.(*EventHandler).ListEventsV2": {
+		Summary: "Search events", Query: dto.ListEventsReq{}, List: &eventListSpec,
+		Response: dtov2.Page{}, Items: dto.EventResp{},
+	},
+	"handler.(*EventHandler).GetEvent": {
+		Summary: "Get an event", Path: dto.EventPath{}, Response: dto.EventResp{},
+	},
+	"handler.(*EventHandler).GetSeatMap": {
+		Summary: "Get the seat map of an event", Path: dto.EventPath{}, Response: dto.SeatMapResp{},
+	},
+	"handler.(*WaitingRoomHandler).GetTicket": {
+		Summary: "Join or poll the waiting room of an event", Path: dto.EventPath{}, Response: dto.WaitingRoomTicketResp{},
+	},
+
+	// Orders
+	"handler.(*OrderHandler).ListOrders": {
+		Summary: "List the orders", Query: dto.ListOrdersReq{}, List: &orderListSpec,
+		Response: dto.ListOrdersResp{}, Items: dto.OrderResp{},
+	},
+	"handler.(*OrderHandler).ListOrdersV2": {
+		Summary: "List the orders", Query: dto.ListOrdersReq{}, List: &orderListSpec,
+		Response: dtov2.Page{}, Items: dto.OrderResp{},
+	},
+	"handler.(*OrderHandler).GetOrder": {
+		Summary: "Get an order", Path: dto.OrderPath{}, Response: dto.OrderResp{},
+	},
+	"handler.(*OrderHandler).GetPurchaseStatus": {
+		Summary: "Get the status of a purchase", Path: dto.OrderPath{}, Response: dto.PurchaseStatusResp{},
+	},
+	"handler.(*OrderHandler).CancelOrder": {
+		Summary: "Cancel an order", Path: dto.OrderPath{},
+		Body: dto.CancelOrderReq{}, OptionalBody: true, Response: dto.CancelOrderResp{},
+	},
+	"handler.(*OrderHandler).PurchaseTicket": {
+		Summary: "Purchase tickets", Body: dto.PurchaseTicketReq{}, OptionalBody: true,
+		Response: pb.PurchaseResponse{},
+	},
+	"handler.(*OrderHandler).PurchaseBatch": {
+		Summary: "Purchase tickets of several events", Body: dto.PurchaseBatchReq{}, Response: dto.PurchaseBatchResp{},
+	},
+
+	// Payments
+	"handler.(*PaymentHandler).CreatePayment": {
+		Summary: "Create a payment for an order", Body: dto.CreatePaymentReq{},
+		Status: http.StatusCreated, Response: dto.PaymentResp{},
+	},
+	"handler.(*PaymentHandler).GetPayment": {
+		Summary: "Get a payment", Path: dto.PaymentPath{}, Response: dto.PaymentResp{},
+	},
+	"handler.(*PaymentHandler).ConfirmPayment": {
+		Summary: "Confirm a payment", Path: dto.PaymentPath{},
+		Body: dto.ConfirmPaymentReq{}, Response: dto.PaymentResp{},
+	},
+
+	// Admin
+	"handler.(*AdminHandler).CreateEvent": {
+		Summary: "Create an event", Body: dto.CreateEventReq{},
+		Status: http.StatusCreated, Response: dto.EventResp{},
+	},
+	"handler.(*AdminHandler).UpdateEvent": {
+		Summary: "Update an event", Path: dto.EventPath{},
+		Body: dto.UpdateEventReq{}, Response: dto.EventResp{},
+	},
+	"handler.(*AdminHandler).CloseEvent": {
+		Summary: "Close the sales of an event", Path: dto.EventPath{},
+		Body: dto.CloseEventReq{}, OptionalBody: true, Response: dto.EventResp{},
+	},
+	"handler.(*AdminHandler).AdjustInventory": {
+		Summary: "Adjust the inventory of an event", Path: dto.EventPath{},
+		Body: dto.AdjustInventoryReq{}, Response: dto.EventResp{},
+	},
+	"handler.(*AdminHandler).ForceCancelOrder": {
+		Summary: "Cancel any order", Path: dto.OrderPath{},
+		Body: dto.ForceCancelOrderReq{}, Response: dto.CancelOrderResp{},
+	},
+	"handler.(*WaitingRoomHandler).GetWaitingRoom": {
+		Summary: "Get the waiting room of an event", Path: dto.EventPath{}, Response: dto.WaitingRoomResp{},
+	},
+	"handler.(*WaitingRoomHandler).OpenWaitingRoom": {
+		Summary: "Open or reconfigure the waiting room of an event", Path: dto.EventPath{},
+		Body: dto.OpenWaitingRoomReq{}, OptionalBody: true, Response: dto.WaitingRoomResp{},
+	},
+	"handler.(*WaitingRoomHandler).CloseWaitingRoom": {
+		Summary: "Close the waiting room of an event", Path: dto.EventPath{}, Status: http.StatusNoContent,
+	},
+
+	// Partner webhooks
+	"handler.(*WebhookHandler).CreateWebhook": {
+		Summary: "Subscribe a webhook", Body: dto.CreateWebhookReq{},
+		Status: http.StatusCreated, Response: dto.WebhookResp{},
+	},
+	"handler.(*WebhookHandler).ListWebhooks": {
+		Summary: "List the webhooks", Response: dto.ListWebhooksResp{},
+	},
+	"handler.(*WebhookHandler).GetWebhook": {
+		Summary: "Get a webhook", Path: dto.WebhookPath{}, Response: dto.WebhookResp{},
+	},
+	"handler.(*WebhookHandler).UpdateWebhook": {
+		Summary: "Update a webhook", Path: dto.WebhookPath{},
+		Body: dto.UpdateWebhookReq{}, Response: dto.WebhookResp{},
+	},
+	"handler.(*WebhookHandler).DeleteWebhook": {
+		Summary: "Delete a webhook", Path: dto.WebhookPath{}, Status: http.StatusNoContent,
+	},
+	"handler.(*WebhookHandler).ListDeliveries": {
+		Summary: "List the deliveries of a webhook", Path: dto.WebhookPath{}, Response: dto.ListWebhookDeliveriesResp{},
+	},
+	"handler.(*WebhookHandler).GetDelivery": {
+		Summary: "Get a delivery of a webhook", Path: dto.WebhookDeliveryPath{}, Response: dto.WebhookDeliveryResp{},
+	},
+
+	// Health
+	"handler.(*HealthHandler).Readyz": {
+		Summary: "Report whether the gateway is ready to serve", Response: dto.ReadinessResp{},
+	},
+	"handler.(*HealthHandler).Version": {
+		Summary: "Report the build of the gateway", Response: dto.VersionResp{},
+	},
+}
@@ -1,91 +1,567 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	pb "apigw/client/proto"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/listing"
 	"apigw/internal/app/middleware"
+	"apigw/internal/app/purchasequeue"
+	"apigw/internal/app/region"
+	"apigw/internal/app/tenant"
+	"apigw/internal/app/webhook"
 	"apigw/internal/client"
+	"apigw/pkg/utils/codec"
+	"apigw/pkg/utils/log"
+	"apigw/pkg/utils/pool"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
 )
 
+// defaultOrdersPageSize is used when the client does not specify a limit
+const defaultOrdersPageSize = 20
+
 // OrderHandler handles HTTP requests for order operations
 type OrderHandler struct {
-	orderClient *client.OrderServiceClient
+	orderClient client.OrderService
+	cache       cache.Invalidator
+	purchases   *purchasequeue.Queue // nil unless asynchronous purchases are enabled
+	webhooks    webhook.Publisher
+	config      config.OrdersConfig
 	logger      *logrus.Logger
 }
 
-// NewOrderHandler creates a new order handler
-func NewOrderHandler(orderClient *client.OrderServiceClient, logger *logrus.Logger) *OrderHandler {
+// NewOrderHandler creates a new order handler; cached responses affected by
+// purchases and cancellations are invalidated through invalidator, purchases are queued in
+// purchases when asynchronous purchases are enabled, and cancellations are published to
+// partner webhooks
+func NewOrderHandler(orderClient client.OrderService, invalidator cache.Invalidator, purchases *purchasequeue.Queue, webhooks webhook.Publisher, cfg config.OrdersConfig, logger *logrus.Logger) *OrderHandler {
 	return &OrderHandler{
 		orderClient: orderClient,
+		cache:       invalidator,
+		purchases:   purchases,
+		webhooks:    webhooks,
+		config:      cfg,
 		logger:      logger,
 	}
 }
 
-// PurchaseTicket handles ticket purchase
+// purchaseReqPool reuses purchase request DTOs, the busiest binding during an on-sale
+var purchaseReqPool = pool.New(
+	func() *dto.PurchaseTicketReq { return new(dto.PurchaseTicketReq) },
+	func(req *dto.PurchaseTicketReq) bool {
+		*req = dto.PurchaseTicketReq{}
+		return true
+	},
+)
+
+// PurchaseTicket handles ticket purchase. The event may be given either in the
+// request body or, for backward compatibility, as the event_id path parameter.
 func (h *OrderHandler) PurchaseTicket(c *gin.Context) {
-	h.logger.WithFields(logrus.Fields{
-		"method": c.Request.Method,
-		"path":   c.Request.URL.Path,
-		"ip":     c.ClientIP(),
-	}).Info("Ticket purchase request received")
+	// The log fields are pooled and grow with the request's context as it is processed
+	fields := log.AcquireFields()
+	defer log.ReleaseFields(fields)
+	log.FromContext(c).WithFields(fields).Info("Ticket purchase request received")
 
 	// Get user ID from context (set by JWT middleware)
+	userID := c.GetString("user_id")
+	if userID == "" {
+		log.FromContext(c).WithFields(fields).Warn("Authentication failed - user_id not found in context")
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	req := purchaseReqPool.Get()
+	defer purchaseReqPool.Put(req)
+	if c.Request.ContentLength != 0 {
+		if err := codec.NewDecoder(c.Request.Body).Decode(req); err != nil && err != io.EOF {
+			log.FromContext(c).WithFields(fields).WithError(err).Warn("Invalid purchase request body")
+			middleware.ValidationErrorHandler(c, "INVALID_REQUEST", "Invalid request body", h.logger)
+			return
+		}
+	}
+
+	// Get event ID from URL parameter for legacy clients
+	if eventID := c.Param("event_id"); eventID != "" {
+		if req.EventID != "" && req.EventID != eventID {
+			middleware.ValidationErrorHandler(c, "INVALID_EVENT_ID", "Event ID in path and body do not match", h.logger)
+			return
+		}
+		req.EventID = eventID
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		log.FromContext(c).WithFields(fields).WithError(err).Warn("Invalid purchase request")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid purchase request", h.logger)
+		return
+	}
+
+	if !normalizeQuantity(req) {
+		middleware.ValidationErrorHandler(c, "INVALID_QUANTITY", "Quantity must match the number of selected seats", h.logger)
+		return
+	}
+
+	fields["event_id"] = req.EventID
+	fields["quantity"] = req.Quantity
+	fields["tier"] = req.Tier
+
+	if h.respondAsync(c) {
+		job, err := h.purchases.Enqueue(c.Request.Context(), purchasequeue.Purchase{
+			UserID:   userID,
+			EventID:  req.EventID,
+			SeatIDs:  req.SeatIDs,
+			Quantity: req.Quantity,
+			Tier:     req.Tier,
+			Tenant:   middleware.Tenant(c),
+			Region:   middleware.Region(c),
+		})
+		switch {
+		case errors.Is(err, purchasequeue.ErrQueueFull):
+			log.FromContext(c).WithFields(fields).Warn("Purchase queue is full")
+			c.AbortWithStatusJSON(errs.ErrPurchaseQueueFull.Status, errs.ErrPurchaseQueueFull)
+			return
+		case err != nil:
+			// The purchase can still be made while the queue is unreachable
+			log.FromContext(c).WithFields(fields).WithError(err).Warn("Failed to queue purchase, purchasing synchronously")
+		default:
+			fields["reference"] = job.Reference
+			log.FromContext(c).WithFields(fields).Info("Ticket purchase queued")
+			h.accepted(c, job)
+			return
+		}
+	}
+
+	log.FromContext(c).WithFields(fields).Info("Processing ticket purchase")
+
+	resp, err := h.orderClient.PurchaseTicket(c.Request.Context(), &pb.PurchaseRequest{
+		EventId:  req.EventID,
+		UserId:   userID,
+		SeatIds:  req.SeatIDs,
+		Quantity: req.Quantity,
+		Tier:     req.Tier,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	// Availability and seats of the event changed
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents, cache.EventTag(req.EventID), cache.UserTag(userID))
+
+	fields["status"] = resp.Status
+	log.FromContext(c).WithFields(fields).Info("Ticket purchase successful")
+
+	respond(c, http.StatusOK, resp)
+}
+
+// respondAsync reports whether a purchase is queued rather than made during the request:
+// always in the always mode, and in the prefer mode when the client sends
+// Prefer: respond-async (RFC 7240)
+func (h *OrderHandler) respondAsync(c *gin.Context) bool {
+	if h.purchases == nil || !h.config.AsyncPurchase.Enabled {
+		return false
+	}
+	if h.config.AsyncPurchase.Mode == config.AsyncPurchaseAlways {
+		return true
+	}
+	for _, header := range c.Request.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+				c.Header("Preference-Applied", "respond-async")
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// accepted answers a queued purchase with 202, pointing the client to its status
+func (h *OrderHandler) accepted(c *gin.Context, job purchasequeue.Job) {
+	// The status lives under the orders group the purchase was made through
+	prefix, _, _ := strings.Cut(c.Request.URL.Path, "/orders/")
+	resp := dto.PurchaseAcceptedResp{
+		Reference: job.Reference,
+		Status:    job.Status,
+		StatusURL: prefix + "/orders/" + job.Reference + "/status",
+		PollAfter: int(math.Ceil(h.config.AsyncPurchase.PollAfter.Seconds())),
+	}
+	c.Header("Location", resp.StatusURL)
+	c.Header("Retry-After", strconv.Itoa(resp.PollAfter))
+	respond(c, http.StatusAccepted, resp)
+}
+
+// GetPurchaseStatus handles polling the progress of a queued purchase of the
+// authenticated user
+func (h *OrderHandler) GetPurchaseStatus(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+	if h.purchases == nil {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	var path dto.OrderPath
+	if !bindPath(c, &path, errs.ErrInvalidOrderID, h.logger) {
+		return
+	}
+	ref := path.OrderID
+	job, err := h.purchases.Status(c.Request.Context(), ref)
+	if errors.Is(err, purchasequeue.ErrNotFound) {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("reference", ref).Error("Failed to read purchase status")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	// Never reveal other users' purchases
+	if job.UserID != userID {
+		log.FromContext(c).WithField("reference", ref).Warn("Purchase ownership mismatch")
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	if job.Status == purchasequeue.StatusQueued || job.Status == purchasequeue.StatusProcessing {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(h.config.AsyncPurchase.PollAfter.Seconds()))))
+	}
+	respond(c, http.StatusOK, dto.PurchaseStatusResp{
+		Reference: job.Reference,
+		EventID:   job.EventID,
+		Status:    job.Status,
+		Result:    job.Result,
+		Error:     job.Error,
+		Attempts:  job.Attempts,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	})
+}
+
+// PurchaseForwarder returns the function the purchase queue workers forward queued
+// purchases to the order service with, invalidating the cached responses they affect
+func PurchaseForwarder(orderClient client.OrderService, invalidator cache.Invalidator) purchasequeue.ProcessFunc {
+	return func(ctx context.Context, p purchasequeue.Purchase) (string, error) {
+		if p.Tenant != "" {
+			ctx = tenant.NewContext(ctx, p.Tenant)
+		}
+		if p.Region != "" {
+			ctx = region.NewContext(ctx, p.Region)
+		}
+		// Queued purchases reach the instance the buyer's other calls are pinned to
+		ctx = client.WithRoutingKey(ctx, p.UserID)
+		resp, err := orderClient.PurchaseTicket(ctx, &pb.PurchaseRequest{
+			EventId:  p.EventID,
+			UserId:   p.UserID,
+			SeatIds:  p.SeatIDs,
+			Quantity: p.Quantity,
+			Tier:     p.Tier,
+		})
+		if err != nil {
+			return "", err
+		}
+		// Availability and seats of the event changed
+		invalidator.Invalidate(ctx, cache.TagEvents, cache.EventTag(p.EventID), cache.UserTag(p.UserID))
+		return resp.Status.String(), nil
+	}
+}
+
+// normalizeQuantity defaults the quantity of a purchase to its number of seats, or one
+// ticket, and reports whether the quantity matches the selected seats
+func normalizeQuantity(req *dto.PurchaseTicketReq) bool {
+	if req.Quantity == 0 {
+		req.Quantity = 1
+		if len(req.SeatIDs) > 0 {
+			req.Quantity = int32(len(req.SeatIDs))
+		}
+	}
+	return len(req.SeatIDs) == 0 || int(req.Quantity) == len(req.SeatIDs)
+}
+
+// PurchaseBatch handles purchasing tickets for several entries in one request. The
+// entries are validated up front and then purchased concurrently, at most
+// orders.batch_purchase.concurrency at a time over the order service's multiplexed
+// connections; each entry succeeds or fails on its own and the results are returned in
+// request order.
+func (h *OrderHandler) PurchaseBatch(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var req dto.PurchaseBatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.FromContext(c).WithError(err).Warn("Invalid batch purchase request")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid batch purchase request", h.logger)
+		return
+	}
+	if len(req.Items) > h.config.BatchPurchase.MaxItems {
+		middleware.ValidationErrorHandler(c, "BATCH_TOO_LARGE",
+			fmt.Sprintf("A batch may contain at most %d entries", h.config.BatchPurchase.MaxItems), h.logger)
+		return
+	}
+	for i := range req.Items {
+		if !normalizeQuantity(&req.Items[i]) {
+			middleware.ValidationErrorHandler(c, "INVALID_QUANTITY",
+				fmt.Sprintf("Quantity of entry %d must match the number of selected seats", i), h.logger)
+			return
+		}
+	}
+
+	log.FromContext(c).WithField("items", len(req.Items)).Info("Processing batch purchase")
+
+	ctx := c.Request.Context()
+	results := make([]dto.PurchaseBatchItemResult, len(req.Items))
+	var group errgroup.Group
+	group.SetLimit(h.config.BatchPurchase.Concurrency)
+	for i, item := range req.Items {
+		group.Go(func() error {
+			results[i] = dto.PurchaseBatchItemResult{Index: i, EventID: item.EventID}
+			resp, err := h.orderClient.PurchaseTicket(ctx, &pb.PurchaseRequest{
+				EventId:  item.EventID,
+				UserId:   userID,
+				SeatIds:  item.SeatIDs,
+				Quantity: item.Quantity,
+				Tier:     item.Tier,
+			})
+			if err != nil {
+				// The failed call itself is logged by the client logging interceptor
+				results[i].Error = errs.GRPCToHTTPError(err)
+				return nil
+			}
+			results[i].Status = resp.Status.String()
+			return nil
+		})
+	}
+	group.Wait()
+
+	resp := dto.PurchaseBatchResp{Results: results}
+	tags := []string{cache.TagEvents, cache.UserTag(userID)}
+	for _, result := range results {
+		if result.Error != nil {
+			resp.Failed++
+			continue
+		}
+		resp.Succeeded++
+		tags = append(tags, cache.EventTag(result.EventID))
+	}
+	if resp.Succeeded > 0 {
+		// Availability and seats of the purchased events changed
+		h.cache.Invalidate(ctx, tags...)
+	}
+
+	log.FromContext(c).WithFields(logrus.Fields{
+		"succeeded": resp.Succeeded,
+		"failed":    resp.Failed,
+	}).Info("Batch purchase completed")
+
+	respond(c, http.StatusOK, resp)
+}
+
+// orderListSpec is the paging, sorting and field selection of order lists
+var orderListSpec = listing.Spec{
+	DefaultLimit: defaultOrdersPageSize,
+	MaxLimit:     maxPageSize,
+	Sorts: map[string]string{
+		"createdAt": "createdAt",
+		"updatedAt": "updatedAt",
+	},
+	Fields: listing.FieldsOf(dto.OrderResp{}),
+}
+
+// ListOrders handles listing the authenticated user's orders
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	h.listOrders(c, func(orders interface{}, nextCursor string, _ listing.Params) interface{} {
+		return dto.ListOrdersResp{Orders: orders, NextCursor: nextCursor}
+	})
+}
+
+// ListOrdersV2 handles listing the authenticated user's orders for API v2, responding
+// with the list envelope
+func (h *OrderHandler) ListOrdersV2(c *gin.Context) {
+	h.listOrders(c, pageV2)
+}
+
+// listOrders lists the authenticated user's orders and renders the page with page
+func (h *OrderHandler) listOrders(c *gin.Context, page pageFunc) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		h.logger.WithFields(logrus.Fields{
-			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
-		}).Warn("Authentication failed - user_id not found in context")
 		middleware.AuthenticationErrorHandler(c, h.logger)
 		return
 	}
 
-	// Get event ID from URL parameter
-	eventID := c.Param("event_id")
-	if eventID == "" {
-		h.logger.WithFields(logrus.Fields{
-			"method":  c.Request.Method,
-			"path":    c.Request.URL.Path,
-			"user_id": userID,
-		}).Warn("Invalid event ID - event_id parameter is empty")
-		middleware.ValidationErrorHandler(c, "INVALID_EVENT_ID", "Event ID is required", h.logger)
+	params, ok := parseListParams(c, orderListSpec, h.logger)
+	if !ok {
+		return
+	}
+
+	var req dto.ListOrdersReq
+	if !bindQuery(c, &req, h.logger) {
 		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"method":   c.Request.Method,
-		"path":     c.Request.URL.Path,
-		"user_id":  userID,
-		"event_id": eventID,
-	}).Info("Processing ticket purchase")
+	statuses := make([]pb.Order_Status, 0, len(req.Status))
+	for _, s := range req.Status {
+		statuses = append(statuses, pb.Order_Status(pb.Order_Status_value[strings.ToUpper(s)]))
+	}
 
-	resp, err := h.orderClient.PurchaseTicket(c.Request.Context(), &pb.PurchaseRequest{
-		EventId: eventID,
+	resp, err := h.orderClient.ListOrders(c.Request.Context(), &pb.ListOrdersRequest{
+		UserId:    userID.(string),
+		Statuses:  statuses,
+		PageSize:  params.Limit,
+		PageToken: params.Cursor,
+		OrderBy:   params.OrderBy(orderListSpec),
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	orders := make([]dto.OrderResp, 0, len(resp.Orders))
+	for _, order := range resp.Orders {
+		orders = append(orders, toOrderResp(order))
+	}
+
+	writePage(c, params, orders, resp.NextPageToken, page, h.logger)
+}
+
+// GetOrder handles fetching a single order of the authenticated user
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var path dto.OrderPath
+	if !bindPath(c, &path, errs.ErrInvalidOrderID, h.logger) {
+		return
+	}
+	orderID := path.OrderID
+
+	resp, err := h.orderClient.GetOrder(c.Request.Context(), &pb.GetOrderRequest{
+		OrderId: orderID,
 		UserId:  userID.(string),
 	})
 	if err != nil {
-		h.logger.WithFields(logrus.Fields{
-			"method":   c.Request.Method,
-			"path":     c.Request.URL.Path,
-			"user_id":  userID,
-			"event_id": eventID,
-			"error":    err.Error(),
-		}).Error("Ticket purchase failed")
 		middleware.GRPCErrorHandler(c, err, h.logger)
 		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"method":   c.Request.Method,
-		"path":     c.Request.URL.Path,
-		"user_id":  userID,
-		"event_id": eventID,
-		"status":   resp.Status,
-	}).Info("Ticket purchase successful")
+	// Never reveal other users' orders, even if the backend returned one
+	if resp.Order.GetUserId() != userID.(string) {
+		log.FromContext(c).WithField("order_id", orderID).Warn("Order ownership mismatch")
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
 
-	c.JSON(http.StatusOK, resp)
+	respond(c, http.StatusOK, toOrderResp(resp.Order))
+}
+
+// CancelOrder handles cancellation of an order owned by the authenticated user
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var path dto.OrderPath
+	if !bindPath(c, &path, errs.ErrInvalidOrderID, h.logger) {
+		return
+	}
+	orderID := path.OrderID
+
+	var req dto.CancelOrderReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+			return
+		}
+	}
+
+	logFields := logrus.Fields{
+		"order_id": orderID,
+	}
+
+	// Verify ownership at the gateway before asking the backend to cancel
+	current, err := h.orderClient.GetOrder(c.Request.Context(), &pb.GetOrderRequest{
+		OrderId: orderID,
+		UserId:  userID.(string),
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+	if current.Order.GetUserId() != userID.(string) {
+		log.FromContext(c).WithFields(logFields).Warn("Order cancellation rejected - ownership mismatch")
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	resp, err := h.orderClient.CancelOrder(c.Request.Context(), &pb.CancelOrderRequest{
+		OrderId: orderID,
+		UserId:  userID.(string),
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		if errs.GetGRPCCode(err) == codes.FailedPrecondition {
+			log.FromContext(c).WithFields(logFields).WithError(err).Warn("Order is not refundable")
+			c.JSON(errs.ErrOrderNotRefundable.Status, errs.ErrOrderNotRefundable)
+			return
+		}
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	h.cache.Invalidate(c.Request.Context(), cache.TagEvents, cache.EventTag(resp.Order.GetEventId()), cache.UserTag(userID.(string)))
+	h.webhooks.Publish(c.Request.Context(), webhook.EventOrderCancelled, orderCancelledData(resp))
+
+	log.FromContext(c).WithFields(logFields).WithField("refunded", resp.Refunded).Info("Order cancelled")
+
+	respond(c, http.StatusOK, dto.CancelOrderResp{
+		Order:    toOrderResp(resp.Order),
+		Refunded: resp.Refunded,
+	})
+}
+
+// orderCancelledData returns the webhook event data of a cancellation
+func orderCancelledData(resp *pb.CancelOrderResponse) webhook.OrderData {
+	return webhook.OrderData{
+		OrderID:  resp.Order.GetId(),
+		EventID:  resp.Order.GetEventId(),
+		UserID:   resp.Order.GetUserId(),
+		Status:   strings.ToLower(resp.Order.GetStatus().String()),
+		Refunded: resp.Refunded,
+	}
+}
+
+// toOrderResp converts a protobuf order into the order DTO
+func toOrderResp(order *pb.Order) dto.OrderResp {
+	return dto.OrderResp{
+		ID:        order.GetId(),
+		EventID:   order.GetEventId(),
+		Status:    strings.ToLower(order.GetStatus().String()),
+		CreatedAt: order.GetCreatedAt().AsTime(),
+		UpdatedAt: order.GetUpdatedAt().AsTime(),
+	}
 }
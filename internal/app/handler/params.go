@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// bindPath binds and validates the path parameters of a request into path, answering
+// invalid ones with 400 under the code of httpErr and their violations; it returns false
+// when the request was rejected
+func bindPath(c *gin.Context, path interface{}, httpErr *errs.HTTPError, logger *logrus.Logger) bool {
+	if err := validation.BindURI(c, path); err != nil {
+		middleware.BindingErrorHandler(c, err, httpErr.Code, httpErr.Message, logger)
+		return false
+	}
+	return true
+}
+
+// bindQuery binds and validates the query parameters of a request into query, answering
+// invalid ones with 400 and their violations; it returns false when the request was rejected
+func bindQuery(c *gin.Context, query interface{}, logger *logrus.Logger) bool {
+	if err := validation.BindQuery(c, query); err != nil {
+		middleware.BindingErrorHandler(c, err, errs.ErrInvalidQuery.Code, errs.ErrInvalidQuery.Message, logger)
+		return false
+	}
+	return true
+}
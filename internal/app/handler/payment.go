@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/webhook"
+	"apigw/internal/client"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPaymentCurrency is used when the client does not specify a currency
+const defaultPaymentCurrency = "USD"
+
+// idempotencyLockTTL bounds how long a payment request holds the lock of its
+// Idempotency-Key, should its replica stop before releasing it
+const idempotencyLockTTL = time.Minute
+
+// PaymentHandler handles HTTP requests for checkout payments
+type PaymentHandler struct {
+	paymentClient client.PaymentService
+	orderClient   client.OrderService
+	cache         cache.Invalidator
+	webhooks      webhook.Publisher
+	locks         *client.Locker
+	logger        *logrus.Logger
+}
+
+// NewPaymentHandler creates a new payment handler; once a payment is confirmed, cached
+// orders of the user are invalidated through invalidator and the completed order is
+// published to partner webhooks. With locks, requests of a user sharing an
+// Idempotency-Key are processed one at a time across replicas; locks may be nil.
+func NewPaymentHandler(paymentClient client.PaymentService, orderClient client.OrderService, invalidator cache.Invalidator, webhooks webhook.Publisher, locks *client.Locker, logger *logrus.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		paymentClient: paymentClient,
+		orderClient:   orderClient,
+		cache:         invalidator,
+		webhooks:      webhooks,
+		locks:         locks,
+		logger:        logger,
+	}
+}
+
+// CreatePayment handles creating a payment intent for an order of the authenticated user
+func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var req dto.CreatePaymentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid payment request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	if req.Currency == "" {
+		req.Currency = defaultPaymentCurrency
+	}
+
+	logFields := logrus.Fields{
+		"order_id": req.OrderID,
+	}
+
+	// Only the order owner may pay for it
+	order, err := h.orderClient.GetOrder(c.Request.Context(), &pb.GetOrderRequest{
+		OrderId: req.OrderID,
+		UserId:  userID.(string),
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+	if order.Order.GetUserId() != userID.(string) {
+		logutils.FromContext(c).WithFields(logFields).Warn("Payment rejected - order ownership mismatch")
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	// A retry sent while the first request is still in flight is turned away rather than
+	// racing it to the payment service. The lock only serializes the retries: the payment
+	// service deduplicates by key, so a request outliving its lock cannot pay twice and
+	// carries no fencing token
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if h.locks != nil && idempotencyKey != "" {
+		sum := sha256.Sum256([]byte(idempotencyKey))
+		lock, err := h.locks.TryAcquire(c.Request.Context(), userID.(string)+":"+hex.EncodeToString(sum[:]), idempotencyLockTTL)
+		switch {
+		case errors.Is(err, client.ErrLockHeld):
+			logutils.FromContext(c).WithFields(logFields).Warn("Payment rejected - request with the same Idempotency-Key in progress")
+			c.JSON(errs.ErrConflict.Status, errs.ErrConflict.WithMessage("A request with this Idempotency-Key is already in progress"))
+			return
+		case err != nil:
+			// The payment service still deduplicates by key
+			logutils.FromContext(c).WithFields(logFields).WithError(err).Warn("Idempotency lock unavailable, creating payment without it")
+		default:
+			defer lock.Release(context.WithoutCancel(c.Request.Context()))
+		}
+	}
+
+	resp, err := h.paymentClient.CreatePaymentIntent(c.Request.Context(), &pb.CreatePaymentIntentRequest{
+		OrderId:        req.OrderID,
+		UserId:         userID.(string),
+		PaymentMethod:  req.PaymentMethod,
+		Currency:       req.Currency,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(logFields).WithField("payment_id", resp.Payment.GetId()).Info("Payment intent created")
+
+	respond(c, http.StatusCreated, toPaymentResp(resp.Payment))
+}
+
+// ConfirmPayment handles confirming a payment intent
+func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var path dto.PaymentPath
+	if !bindPath(c, &path, errs.ErrInvalidPaymentID, h.logger) {
+		return
+	}
+	paymentID := path.PaymentID
+	var req dto.ConfirmPaymentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	// Verify ownership at the gateway before asking the backend to confirm
+	current, err := h.paymentClient.GetPayment(c.Request.Context(), &pb.GetPaymentRequest{
+		PaymentId: paymentID,
+		UserId:    userID.(string),
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+	if !h.ownsPayment(c, current.Payment, userID.(string)) {
+		return
+	}
+
+	resp, err := h.paymentClient.ConfirmPayment(c.Request.Context(), &pb.ConfirmPaymentRequest{
+		PaymentId:          paymentID,
+		UserId:             userID.(string),
+		PaymentMethodToken: req.PaymentMethodToken,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	// Confirming the payment completes the order
+	h.cache.Invalidate(c.Request.Context(), cache.UserTag(userID.(string)))
+	if resp.Payment.GetStatus() == pb.Payment_SUCCEEDED {
+		h.webhooks.Publish(c.Request.Context(), webhook.EventOrderCompleted, webhook.OrderData{
+			OrderID:   resp.Payment.GetOrderId(),
+			UserID:    userID.(string),
+			Status:    strings.ToLower(pb.Order_CONFIRMED.String()),
+			PaymentID: resp.Payment.GetId(),
+		})
+	}
+
+	respond(c, http.StatusOK, toPaymentResp(resp.Payment))
+}
+
+// GetPayment handles querying the status of a payment
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var path dto.PaymentPath
+	if !bindPath(c, &path, errs.ErrInvalidPaymentID, h.logger) {
+		return
+	}
+	paymentID := path.PaymentID
+	resp, err := h.paymentClient.GetPayment(c.Request.Context(), &pb.GetPaymentRequest{
+		PaymentId: paymentID,
+		UserId:    userID.(string),
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	if !h.ownsPayment(c, resp.Payment, userID.(string)) {
+		return
+	}
+
+	resp.Payment.ClientSecret = ""
+	respond(c, http.StatusOK, toPaymentResp(resp.Payment))
+}
+
+// ownsPayment responds with 404 when the payment belongs to another user
+func (h *PaymentHandler) ownsPayment(c *gin.Context, payment *pb.Payment, userID string) bool {
+	if payment.GetUserId() == userID {
+		return true
+	}
+
+	logutils.FromContext(c).WithField("payment_id", payment.GetId()).Warn("Payment ownership mismatch")
+	c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+	return false
+}
+
+// toPaymentResp converts a protobuf payment into the payment DTO
+func toPaymentResp(payment *pb.Payment) dto.PaymentResp {
+	return dto.PaymentResp{
+		ID:           payment.GetId(),
+		OrderID:      payment.GetOrderId(),
+		AmountCents:  payment.GetAmountCents(),
+		Currency:     payment.GetCurrency(),
+		Status:       strings.ToLower(payment.GetStatus().String()),
+		ClientSecret: payment.GetClientSecret(),
+		CreatedAt:    payment.GetCreatedAt().AsTime(),
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/middleware"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// GetProfile handles fetching the authenticated user's profile
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	resp, err := h.userClient.GetProfile(c.Request.Context(), &pb.GetProfileRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	respond(c, http.StatusOK, toProfileResp(resp.User))
+}
+
+// UpdateProfile handles partial updates of the authenticated user's profile
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var req dto.UpdateProfileReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid profile update request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	user := &pb.User{}
+	mask := &fieldmaskpb.FieldMask{}
+	logFields := logrus.Fields{}
+	if req.Username != nil {
+		user.Username = *req.Username
+		mask.Paths = append(mask.Paths, "username")
+		logFields["username"] = *req.Username
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+		mask.Paths = append(mask.Paths, "email")
+		logFields["email"] = logutils.MaskEmail(*req.Email)
+	}
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+		mask.Paths = append(mask.Paths, "display_name")
+	}
+	if req.Phone != nil {
+		user.Phone = *req.Phone
+		mask.Paths = append(mask.Paths, "phone")
+		logFields["phone"] = logutils.MaskPhone(*req.Phone)
+	}
+
+	if len(mask.Paths) == 0 {
+		middleware.ValidationErrorHandler(c, "EMPTY_UPDATE", "At least one field must be provided", h.logger)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(logFields).WithField("fields", mask.Paths).Info("Processing profile update")
+
+	resp, err := h.userClient.UpdateProfile(c.Request.Context(), &pb.UpdateProfileRequest{
+		UserId:     userID.(string),
+		User:       user,
+		UpdateMask: mask,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	respond(c, http.StatusOK, toProfileResp(resp.User))
+}
+
+// ChangePassword handles changing the authenticated user's password
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		middleware.AuthenticationErrorHandler(c, h.logger)
+		return
+	}
+
+	var req dto.ChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Never log the binding error itself, it may echo password values
+		logutils.FromContext(c).Warn("Invalid change password request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	_, err := h.userClient.ChangePassword(c.Request.Context(), &pb.ChangePasswordRequest{
+		UserId:          userID.(string),
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).Info("Password changed")
+
+	c.Status(http.StatusNoContent)
+}
+
+// toProfileResp converts a protobuf user into the profile DTO
+func toProfileResp(u *pb.User) dto.ProfileResp {
+	return dto.ProfileResp{
+		ID:          u.GetId(),
+		Email:       u.GetEmail(),
+		Username:    u.GetUsername(),
+		DisplayName: u.GetDisplayName(),
+		Phone:       u.GetPhone(),
+	}
+}
@@ -0,0 +1,58 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/handler"
+	"apigw/internal/client/mocks"
+	"apigw/internal/testutil"
+
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetProfile(t *testing.T) {
+	tokens := testutil.NewTokens(t)
+	user := &pb.User{
+		Id:          "usr_1001",
+		Email:       "ada@example.com",
+		Username:    "ada",
+		DisplayName: "Ada Lovelace",
+	}
+
+	testutil.Run(t, []testutil.Case[*mocks.MockUserService]{
+		{
+			Name:    "authenticated",
+			Request: testutil.Get("/api/v1/users/me").Bearer(tokens.User("usr_1001")),
+			Expect: func(users *mocks.MockUserService) {
+				users.EXPECT().GetProfile(gomock.Any(), testutil.ProtoEq(&pb.GetProfileRequest{UserId: "usr_1001"})).
+					Return(&pb.GetProfileResponse{User: user}, nil)
+			},
+		},
+		{
+			Name:    "anonymous",
+			Request: testutil.Get("/api/v1/users/me"),
+		},
+		{
+			Name:    "expired_token",
+			Request: testutil.Get("/api/v1/users/me").Bearer(tokens.Expired("usr_1001")),
+		},
+		{
+			Name:    "deleted_user",
+			Request: testutil.Get("/api/v1/users/me").Bearer(tokens.User("usr_2002")),
+			Expect: func(users *mocks.MockUserService) {
+				users.EXPECT().GetProfile(gomock.Any(), testutil.ProtoEq(&pb.GetProfileRequest{UserId: "usr_2002"})).
+					Return(nil, status.Error(codes.NotFound, "user not found"))
+			},
+		},
+	}, func(t *testing.T) (http.Handler, *mocks.MockUserService) {
+		users := mocks.NewMockUserService(gomock.NewController(t))
+		h := handler.NewUserHandler(users, testutil.Logger())
+		engine := testutil.NewEngine(t)
+		engine.GET("/api/v1/users/me", tokens.Middleware(), h.GetProfile)
+		return engine, users
+	})
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"time"
+
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/pkg/utils/codec"
+	"apigw/pkg/utils/timing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond renders the body of a successful API response, wrapped in the standard envelope
+// when the API version of the route has api.versions.<version>.envelope set
+func respond(c *gin.Context, status int, data interface{}) {
+	respondPartial(c, status, data, nil)
+}
+
+// respondPartial renders a partially successful response; the errors of the failed parts
+// are listed in the envelope, and only there, as the bare body already carries them
+func respondPartial(c *gin.Context, status int, data interface{}, failed []*errs.HTTPError) {
+	defer timing.FromContext(c.Request.Context()).Since(timing.Serialization, time.Now())
+	if !middleware.Enveloped(c) {
+		c.Render(status, codec.JSON{Data: data})
+		return
+	}
+	resp := envelope(c, data)
+	resp.Errors = failed
+	c.Render(status, codec.JSON{Data: resp})
+}
+
+// envelope wraps a response body in the standard envelope
+func envelope(c *gin.Context, data interface{}) *dto.Envelope {
+	return &dto.Envelope{
+		Data: data,
+		Meta: dto.EnvelopeMeta{RequestID: middleware.RequestID(c)},
+	}
+}
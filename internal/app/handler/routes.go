@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/domains/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RouteLister provides the route table of the public router
+type RouteLister interface {
+	Routes() dto.RoutesResp
+}
+
+// RoutesHandler exposes the route table of the running instance
+type RoutesHandler struct {
+	routes RouteLister
+	logger *logrus.Logger
+}
+
+// NewRoutesHandler creates a new routes handler
+func NewRoutesHandler(routes RouteLister, logger *logrus.Logger) *RoutesHandler {
+	return &RoutesHandler{
+		routes: routes,
+		logger: logger,
+	}
+}
+
+// GetRoutes lists the registered routes with their authentication, rate limits, timeouts
+// and upstream services
+func (h *RoutesHandler) GetRoutes(c *gin.Context) {
+	resp := dto.RoutesResp{Middleware: []string{}, Routes: []dto.RouteResp{}}
+	if h.routes != nil {
+		resp = h.routes.Routes()
+	}
+	c.JSON(http.StatusOK, resp)
+}
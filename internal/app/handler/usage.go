@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/quota"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// UsageHandler reports the quota consumption of clients
+type UsageHandler struct {
+	tracker *quota.Tracker
+	logger  *logrus.Logger
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(tracker *quota.Tracker, logger *logrus.Logger) *UsageHandler {
+	return &UsageHandler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// GetUsage returns the consumption of the calling client in each quota window; the call
+// itself is not counted
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	client := middleware.QuotaClient(c)
+	usage, err := h.tracker.Usage(c.Request.Context(), client)
+	if err != nil {
+		h.logger.WithError(err).WithField("quota_client", client).Error("Failed to read quota usage")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	resp := dto.UsageResp{Client: client, Windows: make([]dto.QuotaWindow, 0, len(usage))}
+	for _, u := range usage {
+		window := dto.QuotaWindow{Window: u.Window, Used: u.Used, ResetsAt: u.ResetsAt}
+		if u.Limit > 0 {
+			limit, remaining := u.Limit, u.Remaining()
+			window.Limit, window.Remaining = &limit, &remaining
+		}
+		resp.Windows = append(resp.Windows, window)
+	}
+	respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	pb "apigw/client/proto"
+	dtov2 "apigw/internal/app/domains/dto/v2"
+	"apigw/internal/app/middleware"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenTypeBearer is the token type advertised by v2 auth responses
+const tokenTypeBearer = "Bearer"
+
+// RegisterV2 handles user registration for API v2
+func (h *UserHandler) RegisterV2(c *gin.Context) {
+	var req dtov2.RegisterReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid registration request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	resp, err := h.userClient.Register(c.Request.Context(), &pb.RegisterRequest{
+		Email:    req.Email,
+		Password: req.Password,
+		Username: req.Username,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).WithField("email", req.Email).Info("User registration successful")
+
+	respond(c, http.StatusCreated, dtov2.RegisterResp{
+		User: toUserV2(resp.GetUser()),
+		Tokens: dtov2.Tokens{
+			AccessToken:  resp.AccessToken,
+			RefreshToken: resp.RefreshToken,
+			TokenType:    tokenTypeBearer,
+		},
+	})
+}
+
+// LoginV2 handles user login for API v2
+func (h *UserHandler) LoginV2(c *gin.Context) {
+	var req dtov2.LoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid login request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	resp, err := h.userClient.Login(c.Request.Context(), &pb.LoginRequest{
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	logutils.FromContext(c).WithField("email", req.Email).Info("User login successful")
+
+	respond(c, http.StatusOK, dtov2.LoginResp{
+		User: toUserV2(resp.GetUser()),
+		Tokens: dtov2.Tokens{
+			AccessToken:  resp.AccessToken,
+			RefreshToken: resp.RefreshToken,
+			TokenType:    tokenTypeBearer,
+		},
+	})
+}
+
+// RefreshTokenV2 handles token refresh for API v2
+func (h *UserHandler) RefreshTokenV2(c *gin.Context) {
+	var req dtov2.RefreshTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logutils.FromContext(c).WithError(err).Warn("Invalid refresh token request body")
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+		return
+	}
+
+	resp, err := h.userClient.RefreshToken(c.Request.Context(), &pb.RefreshTokenRequest{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		middleware.GRPCErrorHandler(c, err, h.logger)
+		return
+	}
+
+	respond(c, http.StatusOK, dtov2.RefreshTokenResp{
+		Tokens: dtov2.Tokens{
+			AccessToken: resp.AccessToken,
+			TokenType:   tokenTypeBearer,
+		},
+	})
+}
+
+// toUserV2 converts a protobuf user into the v2 user DTO
+func toUserV2(u *pb.User) dtov2.User {
+	return dtov2.User{
+		ID:       u.GetId(),
+		Email:    u.GetEmail(),
+		Username: u.GetUsername(),
+	}
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/waitingroom"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// WaitingRoomHandler reports buyers' places in event waiting rooms and lets operators
+// open and close them
+type WaitingRoomHandler struct {
+	room   *waitingroom.Room
+	cfg    config.WaitingRoomConfig
+	logger *logrus.Logger
+}
+
+// NewWaitingRoomHandler creates a new waiting room handler; room is nil without Redis,
+// in which case every endpoint answers 503
+func NewWaitingRoomHandler(room *waitingroom.Room, cfg config.WaitingRoomConfig, logger *logrus.Logger) *WaitingRoomHandler {
+	return &WaitingRoomHandler{
+		room:   room,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// GetTicket returns the caller's place in an event's waiting room. Buyers join the queue
+// by attempting a purchase and poll this endpoint until their status is admitted.
+func (h *WaitingRoomHandler) GetTicket(c *gin.Context) {
+	if h.room == nil {
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+	ctx := c.Request.Context()
+
+	settings, open, err := h.room.Settings(ctx, eventID)
+	if err != nil {
+		h.logger.WithError(err).WithField("event_id", eventID).Error("Failed to read waiting room")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+	if !open {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	ticket, err := h.room.Ticket(ctx, eventID, c.GetString("user_id"), settings)
+	if err == waitingroom.ErrNotQueued {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("event_id", eventID).Error("Failed to read waiting room ticket")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	resp := dto.WaitingRoomTicketResp{
+		EventID:    ticket.EventID,
+		QueueToken: ticket.Token,
+		Status:     ticket.Status,
+		Position:   ticket.Position,
+	}
+	if ticket.Status == waitingroom.StatusQueued {
+		resp.PollAfter = int(math.Ceil(h.cfg.PollInterval.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(resp.PollAfter))
+	}
+	if !ticket.PassExpiresAt.IsZero() {
+		resp.PassExpiresAt = &ticket.PassExpiresAt
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+// GetWaitingRoom returns the queue statistics of an event's waiting room
+func (h *WaitingRoomHandler) GetWaitingRoom(c *gin.Context) {
+	if h.room == nil {
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+	ctx := c.Request.Context()
+
+	settings, open, err := h.room.Settings(ctx, eventID)
+	if err != nil {
+		logutils.FromContext(c).WithFields(auditFields(c)).WithError(err).Error("Failed to read waiting room")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+	if !open {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+	h.respondStats(c, eventID, settings)
+}
+
+// OpenWaitingRoom opens an event's waiting room, or changes the pass rate of an open one
+func (h *WaitingRoomHandler) OpenWaitingRoom(c *gin.Context) {
+	if h.room == nil {
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	var req dto.OpenWaitingRoomReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", h.logger)
+			return
+		}
+	}
+	if req.Rate == 0 {
+		req.Rate = h.cfg.DefaultRate
+	}
+
+	logFields := auditFields(c)
+	logFields["event_id"] = eventID
+	logFields["rate"] = req.Rate
+
+	settings, err := h.room.Open(c.Request.Context(), eventID, req.Rate)
+	if err != nil {
+		logutils.FromContext(c).WithFields(logFields).WithError(err).Error("Failed to open waiting room")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(logFields).Info("Waiting room opened")
+	h.respondStats(c, eventID, settings)
+}
+
+// CloseWaitingRoom closes an event's waiting room, letting every purchase through again
+func (h *WaitingRoomHandler) CloseWaitingRoom(c *gin.Context) {
+	if h.room == nil {
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	var path dto.EventPath
+	if !bindPath(c, &path, errs.ErrInvalidEventID, h.logger) {
+		return
+	}
+	eventID := path.EventID
+
+	logFields := auditFields(c)
+	logFields["event_id"] = eventID
+
+	closed, err := h.room.Close(c.Request.Context(), eventID)
+	if err != nil {
+		logutils.FromContext(c).WithFields(logFields).WithError(err).Error("Failed to close waiting room")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+	if !closed {
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+		return
+	}
+
+	logutils.FromContext(c).WithFields(logFields).Info("Waiting room closed")
+	c.Status(http.StatusNoContent)
+}
+
+// respondStats writes the queue statistics of an open waiting room
+func (h *WaitingRoomHandler) respondStats(c *gin.Context, eventID string, settings waitingroom.Settings) {
+	stats, err := h.room.Stats(c.Request.Context(), eventID, settings)
+	if err != nil {
+		h.logger.WithError(err).WithField("event_id", eventID).Error("Failed to read waiting room queue")
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+		return
+	}
+
+	queued := stats.Joined - stats.Admitted
+	if queued < 0 {
+		queued = 0
+	}
+	respond(c, http.StatusOK, dto.WaitingRoomResp{
+		EventID:  eventID,
+		Rate:     stats.Rate,
+		OpenedAt: stats.OpenedAt,
+		Joined:   stats.Joined,
+		Admitted: stats.Admitted,
+		Queued:   queued,
+	})
+}
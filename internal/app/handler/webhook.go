@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/webhook"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookDeliveriesLimit bounds the deliveries listed for a webhook
+const webhookDeliveriesLimit = 100
+
+// WebhookHandler lets partners manage their webhooks and follow the delivery of events
+type WebhookHandler struct {
+	dispatcher *webhook.Dispatcher
+	logger     *logrus.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(dispatcher *webhook.Dispatcher, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// CreateWebhook registers a callback URL for the calling partner
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid webhook", h.logger)
+		return
+	}
+
+	w, err := h.dispatcher.Create(c.Request.Context(), webhook.Webhook{
+		PartnerID: partnerID,
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    req.Secret,
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to create webhook")
+		return
+	}
+
+	logutils.FromContext(c).WithFields(h.logFields(c, partnerID)).WithFields(logrus.Fields{
+		"webhook_id": w.ID,
+		"events":     w.Events,
+	}).Info("Webhook created")
+
+	resp := toWebhookResp(w)
+	resp.Secret = w.Secret
+	respond(c, http.StatusCreated, resp)
+}
+
+// ListWebhooks lists the webhooks of the calling partner
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.dispatcher.List(c.Request.Context(), partnerID)
+	if err != nil {
+		h.fail(c, err, "Failed to list webhooks")
+		return
+	}
+
+	resp := dto.ListWebhooksResp{Webhooks: make([]dto.WebhookResp, 0, len(webhooks))}
+	for _, w := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, toWebhookResp(w))
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+// GetWebhook returns a webhook of the calling partner
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	var path dto.WebhookPath
+	if !bindPath(c, &path, errs.ErrInvalidWebhookID, h.logger) {
+		return
+	}
+
+	w, err := h.dispatcher.Get(c.Request.Context(), partnerID, path.WebhookID)
+	if err != nil {
+		h.fail(c, err, "Failed to read webhook")
+		return
+	}
+	respond(c, http.StatusOK, toWebhookResp(w))
+}
+
+// UpdateWebhook changes the URL, events, state or secret of a webhook of the calling partner
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	var path dto.WebhookPath
+	if !bindPath(c, &path, errs.ErrInvalidWebhookID, h.logger) {
+		return
+	}
+
+	var req dto.UpdateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid webhook update", h.logger)
+		return
+	}
+
+	w, err := h.dispatcher.Update(c.Request.Context(), partnerID, path.WebhookID, func(w *webhook.Webhook) {
+		if req.URL != nil {
+			w.URL = *req.URL
+		}
+		if req.Events != nil {
+			w.Events = *req.Events
+		}
+		if req.Active != nil {
+			w.Active = *req.Active
+		}
+		if req.Secret != nil {
+			w.Secret = *req.Secret
+		}
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to update webhook")
+		return
+	}
+
+	logutils.FromContext(c).WithFields(h.logFields(c, partnerID)).WithFields(logrus.Fields{
+		"webhook_id":     w.ID,
+		"active":         w.Active,
+		"secret_rotated": req.Secret != nil,
+	}).Info("Webhook updated")
+
+	resp := toWebhookResp(w)
+	if req.Secret != nil {
+		resp.Secret = w.Secret
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+// DeleteWebhook removes a webhook of the calling partner
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	var path dto.WebhookPath
+	if !bindPath(c, &path, errs.ErrInvalidWebhookID, h.logger) {
+		return
+	}
+	webhookID := path.WebhookID
+	if err := h.dispatcher.Delete(c.Request.Context(), partnerID, webhookID); err != nil {
+		h.fail(c, err, "Failed to delete webhook")
+		return
+	}
+
+	logutils.FromContext(c).WithFields(h.logFields(c, partnerID)).WithField("webhook_id", webhookID).Info("Webhook deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries lists the most recent deliveries of a webhook of the calling partner
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	var path dto.WebhookPath
+	if !bindPath(c, &path, errs.ErrInvalidWebhookID, h.logger) {
+		return
+	}
+
+	deliveries, err := h.dispatcher.Deliveries(c.Request.Context(), partnerID, path.WebhookID, webhookDeliveriesLimit)
+	if err != nil {
+		h.fail(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	resp := dto.ListWebhookDeliveriesResp{Deliveries: make([]dto.WebhookDeliveryResp, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toWebhookDeliveryResp(delivery))
+	}
+	respond(c, http.StatusOK, resp)
+}
+
+// GetDelivery returns the status of a delivery of a webhook of the calling partner
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	partnerID, ok := h.partnerID(c)
+	if !ok {
+		return
+	}
+
+	var path dto.WebhookDeliveryPath
+	if !bindPath(c, &path, errs.ErrInvalidWebhookID, h.logger) {
+		return
+	}
+
+	delivery, err := h.dispatcher.Delivery(c.Request.Context(), partnerID, path.WebhookID, path.DeliveryID)
+	if err != nil {
+		h.fail(c, err, "Failed to read webhook delivery")
+		return
+	}
+	respond(c, http.StatusOK, toWebhookDeliveryResp(delivery))
+}
+
+// partnerID returns the partner that signed the request, set by the signature middleware
+func (h *WebhookHandler) partnerID(c *gin.Context) (string, bool) {
+	partnerID := c.GetString("partner_id")
+	if partnerID == "" {
+		c.AbortWithStatusJSON(errs.ErrSignatureMissing.Status, errs.ErrSignatureMissing)
+		return "", false
+	}
+	return partnerID, true
+}
+
+// fail answers a failed webhook operation
+func (h *WebhookHandler) fail(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		c.JSON(errs.ErrNotFound.Status, errs.ErrNotFound)
+	case errors.Is(err, webhook.ErrLimitReached):
+		c.JSON(errs.ErrWebhookLimitReached.Status, errs.ErrWebhookLimitReached)
+	case errors.Is(err, webhook.ErrInvalidURL):
+		c.JSON(errs.ErrWebhookInvalidURL.Status, errs.ErrWebhookInvalidURL)
+	default:
+		logutils.FromContext(c).WithFields(h.logFields(c, c.GetString("partner_id"))).WithError(err).Error(message)
+		c.JSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+	}
+}
+
+// logFields returns the log fields identifying a partner request
+func (h *WebhookHandler) logFields(c *gin.Context, partnerID string) logrus.Fields {
+	return logrus.Fields{
+		"partner_id": partnerID,
+	}
+}
+
+// toWebhookResp converts a webhook into the webhook DTO, without its secret
+func toWebhookResp(w webhook.Webhook) dto.WebhookResp {
+	return dto.WebhookResp{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// toWebhookDeliveryResp converts a delivery into the delivery DTO
+func toWebhookDeliveryResp(delivery webhook.Delivery) dto.WebhookDeliveryResp {
+	resp := dto.WebhookDeliveryResp{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.Status == webhook.StatusPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return resp
+}
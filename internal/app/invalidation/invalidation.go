@@ -0,0 +1,260 @@
+// Package invalidation consumes the change events backend services publish to Kafka and
+// applies them to the gateway's state: cached responses describing a changed event are
+// invalidated, and the waiting room of an event that can no longer be bought is closed.
+//
+// Messages are JSON objects naming the event they concern:
+//
+//	{"eventId": "evt-42", "type": "event.cancelled"}
+//
+// The message key is used as the event ID when the body has none. Inventory messages
+// only need the event ID; the type of event messages decides whether the waiting room
+// is closed.
+package invalidation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"apigw/internal/app/cache"
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+	"apigw/pkg/utils/codec"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/sirupsen/logrus"
+)
+
+// Event message types after which an event can no longer be bought
+var closingTypes = []string{"event.closed", "event.cancelled", "event.deleted"}
+
+// Results counted for every message
+const (
+	resultApplied = "applied"
+	resultIgnored = "ignored"
+	resultFailed  = "failed"
+)
+
+// errNoEventID is returned for messages that do not name an event
+var errNoEventID = errors.New("message names no event")
+
+// Message is a change event published by a backend service
+type Message struct {
+	EventID string `json:"eventId"`
+	// Type is the kind of change, e.g. event.updated or event.cancelled; inventory
+	// messages may leave it empty
+	Type string `json:"type"`
+}
+
+// WaitingRooms closes the waiting rooms of events that can no longer be bought
+type WaitingRooms interface {
+	Close(ctx context.Context, eventID string) (bool, error)
+}
+
+// Consumer reads change events from Kafka and invalidates the state they affect
+type Consumer struct {
+	reader      *kafka.Reader
+	cfg         config.KafkaConfig
+	invalidator cache.Invalidator
+	rooms       WaitingRooms // nil when waiting rooms are disabled
+	logger      *logrus.Logger
+	wg          sync.WaitGroup
+}
+
+// New creates a consumer joining cfg.GroupID; rooms may be nil
+func New(cfg config.KafkaConfig, invalidator cache.Invalidator, rooms WaitingRooms, logger *logrus.Logger) (*Consumer, error) {
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	startOffset := kafka.LastOffset
+	if cfg.StartOffset == config.KafkaOffsetEarliest {
+		startOffset = kafka.FirstOffset
+	}
+
+	kafkaLogger := logger.WithField("component", "kafka")
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        cfg.Brokers,
+		GroupID:        cfg.GroupID,
+		GroupTopics:    cfg.Topics(),
+		Dialer:         dialer,
+		MaxWait:        cfg.MaxWait,
+		CommitInterval: cfg.CommitInterval,
+		StartOffset:    startOffset,
+		Logger:         kafka.LoggerFunc(kafkaLogger.Debugf),
+		ErrorLogger:    kafka.LoggerFunc(kafkaLogger.Warnf),
+	})
+	return &Consumer{
+		reader:      reader,
+		cfg:         cfg,
+		invalidator: invalidator,
+		rooms:       rooms,
+		logger:      logger,
+	}, nil
+}
+
+// Start consumes messages until ctx is done, then closes the reader. Wait returns once
+// the message in progress has been handled and its offset committed.
+func (c *Consumer) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() {
+			if err := c.reader.Close(); err != nil {
+				c.logger.WithError(err).Warn("Failed to close Kafka reader")
+			}
+		}()
+
+		for {
+			msg, err := c.reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				c.logger.WithError(err).Error("Failed to read change event")
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+
+			// Invalidations are idempotent, so the message is committed whatever the
+			// outcome; a failure leaves the cached response until its TTL
+			c.handle(context.WithoutCancel(ctx), msg)
+			if err := c.reader.CommitMessages(context.WithoutCancel(ctx), msg); err != nil {
+				c.logger.WithError(err).WithField("topic", msg.Topic).Warn("Failed to commit change event")
+			}
+		}
+	}()
+}
+
+// Wait waits for the consumer to stop
+func (c *Consumer) Wait() {
+	c.wg.Wait()
+}
+
+// handle applies a single message
+func (c *Consumer) handle(ctx context.Context, msg kafka.Message) {
+	logger := c.logger.WithFields(logrus.Fields{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+	})
+
+	m, err := Decode(msg.Key, msg.Value)
+	if err != nil {
+		metrics.ChangeEvents.WithLabelValues(msg.Topic, resultIgnored).Inc()
+		logger.WithError(err).Warn("Ignoring malformed change event")
+		return
+	}
+	logger = logger.WithFields(logrus.Fields{"event_id": m.EventID, "type": m.Type})
+
+	tags := []string{cache.EventTag(m.EventID)}
+	if slices.Contains(c.cfg.EventTopics, msg.Topic) {
+		// Event listings show names, dates and availability
+		tags = append(tags, cache.TagEvents)
+	}
+	c.invalidator.Invalidate(ctx, tags...)
+
+	if c.rooms != nil && slices.Contains(c.cfg.EventTopics, msg.Topic) && m.Closing() {
+		closed, err := c.rooms.Close(ctx, m.EventID)
+		if err != nil {
+			metrics.ChangeEvents.WithLabelValues(msg.Topic, resultFailed).Inc()
+			logger.WithError(err).Error("Failed to close waiting room")
+			return
+		}
+		if closed {
+			logger.Info("Waiting room closed by change event")
+		}
+	}
+
+	metrics.ChangeEvents.WithLabelValues(msg.Topic, resultApplied).Inc()
+	logger.WithField("tags", tags).Debug("Change event applied")
+}
+
+// Decode parses a message, taking the event ID from the key when the body has none
+func Decode(key, value []byte) (Message, error) {
+	var m Message
+	if len(value) > 0 {
+		if err := codec.Unmarshal(value, &m); err != nil {
+			return Message{}, fmt.Errorf("malformed change event: %w", err)
+		}
+	}
+	if m.EventID == "" {
+		m.EventID = string(key)
+	}
+	if m.EventID == "" {
+		return Message{}, errNoEventID
+	}
+	return m, nil
+}
+
+// Closing reports whether the event can no longer be bought after the change
+func (m Message) Closing() bool {
+	return slices.Contains(closingTypes, strings.ToLower(m.Type))
+}
+
+// newDialer creates the dialer of the broker connections
+func newDialer(cfg config.KafkaConfig) (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{
+		Timeout:   cfg.DialTimeout,
+		DualStack: true,
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		}
+		if cfg.TLS.CAFile != "" {
+			pem, err := os.ReadFile(cfg.TLS.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in Kafka CA file %s", cfg.TLS.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.TLS.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		dialer.TLS = tlsConfig
+	}
+
+	var mechanism sasl.Mechanism
+	var err error
+	switch cfg.SASL.Mechanism {
+	case "":
+	case config.KafkaSASLPlain:
+		mechanism = plain.Mechanism{Username: cfg.SASL.Username, Password: cfg.SASL.Password}
+	case config.KafkaSASLScramSHA256:
+		mechanism, err = scram.Mechanism(scram.SHA256, cfg.SASL.Username, cfg.SASL.Password)
+	case config.KafkaSASLScramSHA512:
+		mechanism, err = scram.Mechanism(scram.SHA512, cfg.SASL.Username, cfg.SASL.Password)
+	default:
+		err = fmt.Errorf("unsupported SASL mechanism %q", cfg.SASL.Mechanism)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure Kafka SASL: %w", err)
+	}
+	dialer.SASLMechanism = mechanism
+	return dialer, nil
+}
@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often full buckets are dropped from the local buckets
+const pruneInterval = time.Minute
+
+// LocalBuckets are token buckets kept in the memory of the instance, used while Redis is
+// down. Every instance limits on its own, so a client may take up to the capacity of its
+// bucket from each of them.
+type LocalBuckets struct {
+	mu        sync.Mutex
+	buckets   map[string]*localBucket
+	nextPrune time.Time
+}
+
+// localBucket is the state of a local bucket
+type localBucket struct {
+	tokens     float64
+	lastRefill time.Time
+	capacity   int
+	refillRate float64
+}
+
+// NewLocalBuckets creates empty local buckets
+func NewLocalBuckets() *LocalBuckets {
+	return &LocalBuckets{buckets: make(map[string]*localBucket)}
+}
+
+// Take takes a token from the bucket of key, refilled at refillRate tokens per second up
+// to capacity, and returns whether it had one and the tokens left
+func (b *LocalBuckets) Take(key string, capacity int, refillRate float64, now time.Time) (bool, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Drop the buckets that refilled from time to time, as a full bucket is the same as
+	// none
+	if now.After(b.nextPrune) {
+		for k, bucket := range b.buckets {
+			if bucket.refill(now) >= float64(bucket.capacity) {
+				delete(b.buckets, k)
+			}
+		}
+		b.nextPrune = now.Add(pruneInterval)
+	}
+
+	bucket, ok := b.buckets[key]
+	if !ok {
+		bucket = &localBucket{tokens: float64(capacity), lastRefill: now}
+		b.buckets[key] = bucket
+	}
+	bucket.capacity, bucket.refillRate = capacity, refillRate
+	bucket.tokens = bucket.refill(now)
+	bucket.lastRefill = now
+	if bucket.tokens < 1 {
+		return false, 0
+	}
+	bucket.tokens--
+	return true, int(bucket.tokens)
+}
+
+// Reset drops every bucket
+func (b *LocalBuckets) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buckets = make(map[string]*localBucket)
+}
+
+// refill returns the tokens of the bucket at now
+func (bucket *localBucket) refill(now time.Time) float64 {
+	tokens := bucket.tokens + bucket.refillRate*now.Sub(bucket.lastRefill).Seconds()
+	if tokens > float64(bucket.capacity) {
+		tokens = float64(bucket.capacity)
+	}
+	return tokens
+}
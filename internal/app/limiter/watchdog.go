@@ -0,0 +1,143 @@
+// Package limiter watches the health of the Redis the rate limiters keep their buckets in.
+// While Redis is down the limiters fall back to buckets local to the instance, or stop
+// limiting, instead of waiting on Redis for every request, and they limit through Redis
+// again once it recovers.
+package limiter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Modes of the rate limiters
+const (
+	ModeRedis    = "redis"    // Buckets are kept in Redis, shared by every instance
+	ModeLocal    = "local"    // Buckets are kept in the memory of the instance
+	ModeDisabled = "disabled" // Requests are not limited
+)
+
+// modes lists the modes, for the mode metric
+var modes = []string{ModeRedis, ModeLocal, ModeDisabled}
+
+// Watchdog checks the health of Redis and holds the mode of the rate limiters; it is
+// shared by every router built on reload. Without a Redis connection the limiters are
+// disabled.
+type Watchdog struct {
+	cfg    config.RedisWatchdogConfig
+	logger *logrus.Logger
+	local  *LocalBuckets
+
+	mu   sync.Mutex
+	mode atomic.Value // string
+}
+
+// NewWatchdog creates a watchdog with the rate limiters disabled until Redis is connected
+func NewWatchdog(cfg config.RedisWatchdogConfig, logger *logrus.Logger) *Watchdog {
+	w := &Watchdog{
+		cfg:    cfg,
+		logger: logger,
+		local:  NewLocalBuckets(),
+	}
+	w.mode.Store(ModeDisabled)
+	setModeMetric(ModeDisabled)
+	return w
+}
+
+// Mode returns the mode of the rate limiters
+func (w *Watchdog) Mode() string {
+	return w.mode.Load().(string)
+}
+
+// Local returns the buckets the rate limiters keep in local mode
+func (w *Watchdog) Local() *LocalBuckets {
+	return w.local
+}
+
+// Connected puts the rate limiters in redis mode, without checking the health of Redis
+func (w *Watchdog) Connected() {
+	w.setMode(ModeRedis, nil)
+}
+
+// Watch puts the rate limiters in redis mode and checks the health of client every
+// interval until ctx is done, when the watchdog is enabled
+func (w *Watchdog) Watch(ctx context.Context, client redis.UniversalClient) {
+	w.Connected()
+	if !w.cfg.Enabled {
+		return
+	}
+	go w.run(ctx, client)
+}
+
+// run pings Redis every interval, falling back once failure_threshold pings in a row
+// failed and going back to redis mode on the first ping that succeeds
+func (w *Watchdog) run(ctx context.Context, client redis.UniversalClient) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+		err := client.Ping(pingCtx).Err()
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			failures = 0
+			w.setMode(ModeRedis, nil)
+			continue
+		}
+		failures++
+		if failures >= w.cfg.FailureThreshold {
+			w.setMode(w.cfg.Fallback, err)
+		}
+	}
+}
+
+// setMode changes the mode of the rate limiters, logging and counting the transition;
+// the local buckets are dropped when going back to Redis, so the next outage starts with
+// full buckets
+func (w *Watchdog) setMode(mode string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	from := w.Mode()
+	if from == mode {
+		return
+	}
+	w.mode.Store(mode)
+	setModeMetric(mode)
+	metrics.RateLimiterModeTransitions.WithLabelValues(mode).Inc()
+
+	entry := w.logger.WithFields(logrus.Fields{"from": from, "to": mode})
+	if mode == ModeRedis {
+		w.local.Reset()
+		entry.Info("Rate limiter mode changed, limiting through Redis")
+		return
+	}
+	entry.WithError(err).Warn("Rate limiter mode changed, Redis unavailable")
+}
+
+// setModeMetric exports the mode of the rate limiters
+func setModeMetric(mode string) {
+	for _, m := range modes {
+		value := 0.0
+		if m == mode {
+			value = 1
+		}
+		metrics.RateLimiterMode.WithLabelValues(m).Set(value)
+	}
+}
@@ -0,0 +1,196 @@
+package listing
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/validation"
+	"apigw/pkg/utils/codec"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCursorLength bounds the opaque page cursors accepted from clients
+const maxCursorLength = 512
+
+// Spec describes the paging, sorting and field selection a list endpoint supports
+type Spec struct {
+	DefaultLimit int32
+	MaxLimit     int32
+	// Sorts maps the sort keys accepted in the sort parameter to the field names of the
+	// backend's orderBy; an empty map disables sorting
+	Sorts map[string]string
+	// Fields lists the item fields that may be selected, see FieldsOf
+	Fields []string
+}
+
+// Params are the parsed list query parameters
+type Params struct {
+	Limit  int32
+	Cursor string
+	Sort   []SortField
+	Fields []string // Selected item fields, empty for all fields
+}
+
+// SortField is a single key of the sort parameter
+type SortField struct {
+	Key  string
+	Desc bool
+}
+
+// Parse reads the limit, cursor, sort and fields query parameters of a list request:
+//
+//	?limit=20&cursor=<next_cursor>&sort=-startsAt,name&fields=id,name
+//
+// A leading "-" sorts a key in descending order. The returned error is a
+// *validation.ParamError whose violation describes the first invalid parameter; its
+// message is meant to be shown to the client.
+func Parse(c *gin.Context, spec Spec) (Params, error) {
+	params := Params{Limit: spec.DefaultLimit, Cursor: c.Query("cursor")}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return Params{}, invalid("limit", "type", "number", "limit must be between 1 and %d", spec.MaxLimit)
+		}
+		if limit < 1 {
+			return Params{}, invalid("limit", "min", "1", "limit must be between 1 and %d", spec.MaxLimit)
+		}
+		if int32(limit) > spec.MaxLimit {
+			return Params{}, invalid("limit", "max", strconv.Itoa(int(spec.MaxLimit)), "limit must be between 1 and %d", spec.MaxLimit)
+		}
+		params.Limit = int32(limit)
+	}
+
+	if len(params.Cursor) > maxCursorLength {
+		return Params{}, invalid("cursor", "max", strconv.Itoa(maxCursorLength), "cursor must not exceed %d characters", maxCursorLength)
+	}
+
+	for _, key := range splitList(c.Query("sort")) {
+		field := SortField{Key: key}
+		if strings.HasPrefix(key, "-") {
+			field = SortField{Key: key[1:], Desc: true}
+		}
+		if _, ok := spec.Sorts[field.Key]; !ok {
+			keys := sortedKeys(spec.Sorts)
+			return Params{}, invalid("sort", "oneof", strings.Join(keys, " "), "cannot sort by %q, supported keys: %s", field.Key, strings.Join(keys, ", "))
+		}
+		if slices.ContainsFunc(params.Sort, func(f SortField) bool { return f.Key == field.Key }) {
+			return Params{}, invalid("sort", "unique", "", "sort key %q is given more than once", field.Key)
+		}
+		params.Sort = append(params.Sort, field)
+	}
+
+	for _, field := range splitList(c.Query("fields")) {
+		if !slices.Contains(spec.Fields, field) {
+			return Params{}, invalid("fields", "oneof", strings.Join(spec.Fields, " "), "unknown field %q, selectable fields: %s", field, strings.Join(spec.Fields, ", "))
+		}
+		if !slices.Contains(params.Fields, field) {
+			params.Fields = append(params.Fields, field)
+		}
+	}
+
+	return params, nil
+}
+
+// invalid reports a violation of a list parameter with a message for the client
+func invalid(param, rule, constraint, format string, args ...interface{}) error {
+	return &validation.ParamError{Violations: []errs.FieldViolation{{
+		Field:      param,
+		Rule:       rule,
+		Constraint: constraint,
+		Type:       "string",
+		Message:    fmt.Sprintf(format, args...),
+	}}}
+}
+
+// OrderBy renders the sort keys as the backend's orderBy, e.g. "startsAt desc,name"
+func (p Params) OrderBy(spec Spec) string {
+	parts := make([]string, 0, len(p.Sort))
+	for _, field := range p.Sort {
+		part := spec.Sorts[field.Key]
+		if field.Desc {
+			part += " desc"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// SortParam renders the sort keys back into the sort parameter syntax
+func (p Params) SortParam() string {
+	parts := make([]string, 0, len(p.Sort))
+	for _, field := range p.Sort {
+		if field.Desc {
+			parts = append(parts, "-"+field.Key)
+		} else {
+			parts = append(parts, field.Key)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Select reduces every item to the selected fields. Items are returned unchanged when
+// no fields are selected; otherwise each item becomes an object holding only the
+// selected fields.
+func (p Params) Select(items any) (any, error) {
+	if len(p.Fields) == 0 {
+		return items, nil
+	}
+
+	data, err := codec.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]json.RawMessage
+	if err := codec.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		for name := range object {
+			if !slices.Contains(p.Fields, name) {
+				delete(object, name)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// FieldsOf returns the JSON field names of a struct, for Spec.Fields
+func FieldsOf(item any) []string {
+	t := reflect.TypeOf(item)
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// splitList splits a comma-separated query parameter, dropping empty entries
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
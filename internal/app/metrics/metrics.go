@@ -0,0 +1,309 @@
+// Package metrics defines the gateway's Prometheus metrics and the handler exposing them
+package metrics
+
+import (
+	"net/http"
+
+	"apigw/internal/app/buildinfo"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every gateway metric
+const namespace = "apigw"
+
+// Registry holds every gateway metric along with the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	// UpstreamState is 1 for the current connectivity state of each upstream endpoint and 0 for the others
+	UpstreamState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upstream_connection_state",
+		Help:      "Connectivity state of upstream gRPC endpoints (1 for the current state).",
+	}, []string{"service", "endpoint", "state"})
+
+	// UpstreamStateTransitions counts connectivity state changes of upstream endpoints
+	UpstreamStateTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_connection_state_transitions_total",
+		Help:      "Connectivity state transitions of upstream gRPC endpoints by new state.",
+	}, []string{"service", "endpoint", "state"})
+
+	// CacheRequests counts response cache lookups by route and result
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Response cache lookups by route and result (memory_hit, redis_hit or miss).",
+	}, []string{"route", "result"})
+
+	// CoalescedRequests counts requests answered with the response of an identical concurrent request
+	CoalescedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coalesced_requests_total",
+		Help:      "Requests served with the shared response of an identical in-flight request, by route.",
+	}, []string{"route"})
+
+	// BruteForceEvents counts failed, locked out and lockout-triggering login and
+	// registration attempts
+	BruteForceEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "brute_force_events_total",
+		Help:      "Failed login and registration attempts by action and outcome (failure, lockout, locked).",
+	}, []string{"action", "outcome"})
+
+	// CaptchaVerifications counts the CAPTCHA checks of abuse-prone actions
+	CaptchaVerifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "captcha_verifications_total",
+		Help:      "CAPTCHA checks by action and result (passed, invalid, missing, error).",
+	}, []string{"action", "result"})
+
+	// SignatureVerifications counts the HMAC signature checks of partner requests
+	SignatureVerifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signature_verifications_total",
+		Help:      "Partner request signature checks by result (valid, missing, invalid, expired, replayed).",
+	}, []string{"result"})
+
+	// WaitingRoomPurchases counts purchases of events with an open waiting room
+	WaitingRoomPurchases = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "waiting_room_purchases_total",
+		Help:      "Purchases of events with an open waiting room, by result (admitted, queued).",
+	}, []string{"result"})
+
+	// OnSaleProfileActive reports the on-sale profile in effect
+	OnSaleProfileActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "on_sale_profile_active",
+		Help:      "1 while the window of an on-sale profile is open, 0 otherwise.",
+	}, []string{"profile"})
+
+	// ACLDenied counts requests rejected by the network access control lists
+	ACLDenied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "acl_denied_total",
+		Help:      "Requests rejected by the network ACLs, by source (blocklist, deny, rule).",
+	}, []string{"source"})
+
+	// DependencyUp reports whether the dependencies gated at startup are connected
+	DependencyUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dependency_up",
+		Help:      "1 when a dependency gated at startup (redis, discovery, backends) is connected, 0 while it is retried.",
+	}, []string{"dependency"})
+
+	// Panics counts panics recovered in request handlers
+	Panics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "panics_total",
+		Help:      "Panics recovered in request handlers, by route.",
+	}, []string{"route"})
+
+	// FaultsInjected counts the faults injected for resilience testing
+	FaultsInjected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "faults_injected_total",
+		Help:      "Faults injected into requests and backend calls, by scope (route or upstream), target and kind.",
+	}, []string{"scope", "target", "kind"})
+
+	// ReportedErrors counts the events of the error reporter
+	ReportedErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reported_errors_total",
+		Help:      "Events of the error reporter by kind (panic, response, upstream) and result (sent, dropped, rate_limited or failed).",
+	}, []string{"kind", "result"})
+
+	// RecordedExchanges counts the requests picked by the traffic recorder
+	RecordedExchanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "recorded_exchanges_total",
+		Help:      "Requests picked by the traffic recorder, by result (written, dropped or failed).",
+	}, []string{"result"})
+
+	// BuildInfo is always 1; its labels identify the running build for rollout tracking
+	BuildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Always 1, labelled with the version, commit, build date and Go version of the running build.",
+	}, []string{"version", "commit", "build_date", "go_version"})
+
+	// HTTPConnections is the number of open client connections by state
+	HTTPConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_connections",
+		Help:      "Open client connections by state (new, active, idle).",
+	}, []string{"state"})
+
+	// HTTPConnectionsAccepted counts accepted client connections
+	HTTPConnectionsAccepted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_connections_accepted_total",
+		Help:      "Client connections accepted by the HTTP server.",
+	})
+
+	// HTTPRequestsInFlight is the number of requests being served by the public listeners
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Requests being served by the public listeners.",
+	})
+
+	// RegionRequests counts the requests routed to each region by where it was resolved from
+	RegionRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "region_requests_total",
+		Help:      "Requests routed to a region, by region and source (header, user, country, default).",
+	}, []string{"region", "source"})
+
+	// RegionFailovers counts the backend calls of a region sent elsewhere while its clusters are unreachable
+	RegionFailovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "region_failovers_total",
+		Help:      "Backend calls of a region failed over, by service, region and the region taking over (shared for the upstreams under services).",
+	}, []string{"service", "region", "to"})
+
+	// QuotaChecks counts the checks of client quotas by result
+	QuotaChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_checks_total",
+		Help:      "Client quota checks by client kind (partner, user, ip) and result (allowed, daily_exceeded, monthly_exceeded, error).",
+	}, []string{"kind", "result"})
+
+	// PriorityRequests counts the requests of each priority class admitted or shed
+	PriorityRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "priority_requests_total",
+		Help:      "Requests by priority class and outcome (admitted, shed) while load shedding is enabled.",
+	}, []string{"class", "outcome"})
+
+	// PriorityRequestsInFlight is the number of admitted requests of each priority class being served
+	PriorityRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "priority_requests_in_flight",
+		Help:      "Admitted requests being served by priority class.",
+	}, []string{"class"})
+
+	// Draining is 1 while the gateway drains before shutting down
+	Draining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "draining",
+		Help:      "1 while the gateway drains before shutting down, 0 otherwise.",
+	})
+
+	// HTTPRequestsByProtocol counts requests by HTTP protocol version
+	HTTPRequestsByProtocol = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_by_protocol_total",
+		Help:      "HTTP requests by protocol (HTTP/1.1, HTTP/2.0).",
+	}, []string{"protocol"})
+
+	// AsyncPurchases counts asynchronous purchases by outcome
+	AsyncPurchases = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "async_purchases_total",
+		Help:      "Asynchronous purchases by outcome (queued, rejected, retried, claimed, succeeded, failed).",
+	}, []string{"outcome"})
+
+	// AsyncPurchaseQueueLength is the number of purchases waiting or being forwarded
+	AsyncPurchaseQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "async_purchase_queue_length",
+		Help:      "Purchases waiting in the queue or being forwarded to the order service, as last seen by this instance.",
+	})
+
+	// WebhookDeliveries counts webhook delivery attempts by event type and result
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts by event type and result (succeeded, retried, failed).",
+	}, []string{"event", "result"})
+
+	// SlowRequests counts the requests slower than their threshold
+	SlowRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "slow_requests_total",
+		Help:      "Requests slower than their threshold by route and the stage they spent the most time in (auth, rate_limit, upstream, serialization, other).",
+	}, []string{"route", "stage"})
+
+	// RateLimiterMode is 1 for the mode the rate limiters are in and 0 for the others
+	RateLimiterMode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rate_limiter_mode",
+		Help:      "Mode of the rate limiters (1 for the current mode): redis, local or disabled.",
+	}, []string{"mode"})
+
+	// RateLimiterModeTransitions counts the changes of mode of the rate limiters
+	RateLimiterModeTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limiter_mode_transitions_total",
+		Help:      "Mode changes of the rate limiters by new mode, as Redis goes down and recovers.",
+	}, []string{"mode"})
+
+	// LockAcquisitions counts the attempts to acquire the distributed locks of the
+	// gateway, by lock and result
+	LockAcquisitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lock_acquisitions_total",
+		Help:      "Distributed lock acquisition attempts by lock and result (acquired, contended or failed).",
+	}, []string{"lock", "result"})
+
+	// ChangeEvents counts the change events consumed from Kafka by topic and result
+	ChangeEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "change_events_total",
+		Help:      "Change events consumed from Kafka by topic and result (applied, ignored, failed).",
+	}, []string{"topic", "result"})
+)
+
+func init() {
+	build := buildinfo.Get()
+	BuildInfo.WithLabelValues(build.Version, build.Commit, build.Date, build.GoVersion).Set(1)
+
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		UpstreamState,
+		UpstreamStateTransitions,
+		CacheRequests,
+		CoalescedRequests,
+		ACLDenied,
+		BruteForceEvents,
+		CaptchaVerifications,
+		SignatureVerifications,
+		WaitingRoomPurchases,
+		OnSaleProfileActive,
+		DependencyUp,
+		BuildInfo,
+		Panics,
+		FaultsInjected,
+		RecordedExchanges,
+		ReportedErrors,
+		HTTPConnections,
+		HTTPConnectionsAccepted,
+		HTTPRequestsByProtocol,
+		HTTPRequestsInFlight,
+		RegionRequests,
+		RegionFailovers,
+		QuotaChecks,
+		PriorityRequests,
+		PriorityRequestsInFlight,
+		Draining,
+		AsyncPurchases,
+		AsyncPurchaseQueueLength,
+		WebhookDeliveries,
+		ChangeEvents,
+		SlowRequests,
+		RateLimiterMode,
+		RateLimiterModeTransitions,
+		LockAcquisitions,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
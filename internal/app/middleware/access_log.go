@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ECSAccessLogFormatter returns the formatter of access log lines in the ecs log format:
+// Elastic Common Schema JSON with the request's method, path, status, latency, client,
+// request ID and trace context, like every other line of that format. service is the
+// service.name of the lines.
+func ECSAccessLogFormatter(service string) gin.LogFormatter {
+	formatter := logutils.NewECSFormatter(service)
+	return func(param gin.LogFormatterParams) string {
+		fields := logrus.Fields{
+			"method":                   param.Method,
+			"path":                     param.Path,
+			"status":                   param.StatusCode,
+			"duration_ms":              float64(param.Latency.Microseconds()) / 1000,
+			"ip":                       param.ClientIP,
+			"event.kind":               "event",
+			"http.response.body.bytes": param.BodySize,
+		}
+		if param.Request != nil {
+			fields["user_agent"] = param.Request.UserAgent()
+			if traceID, spanID, ok := TraceContext(param.Request.Header.Get(TraceParentHeader)); ok {
+				fields["trace_id"], fields["span_id"] = traceID, spanID
+			}
+		}
+		for _, key := range []string{requestIDKey, "user_id", "tenant_id", fingerprintKey} {
+			if value, _ := param.Keys[key].(string); value != "" {
+				fields[key] = value
+			}
+		}
+		if param.ErrorMessage != "" {
+			fields[logrus.ErrorKey] = param.ErrorMessage
+		}
+
+		line, err := formatter.Format(&logrus.Entry{
+			Time:    param.TimeStamp,
+			Level:   logrus.InfoLevel,
+			Message: param.Method + " " + param.Path,
+			Data:    fields,
+		})
+		if err != nil {
+			return ""
+		}
+		return string(line)
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/pkg/utils/pool"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPooledBodyBuffer keeps buffers grown by unusually large bodies out of the pool
+const maxPooledBodyBuffer = 64 << 10
+
+// bodyBufferPool reuses the buffers JSON bodies are read into for the depth check
+var bodyBufferPool = pool.New(
+	func() *bytes.Buffer { return new(bytes.Buffer) },
+	func(buf *bytes.Buffer) bool {
+		if buf.Cap() > maxPooledBodyBuffer {
+			return false
+		}
+		buf.Reset()
+		return true
+	},
+)
+
+// BodyLimitMiddleware caps the size of request bodies with 413 Payload Too Large and
+// rejects JSON bodies nested deeper than the configured depth with 400, before any
+// handler starts decoding them
+func BodyLimitMiddleware(cfg config.RequestBodyConfig, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := cfg.Limit(c.Request.URL.Path)
+		if limit > 0 {
+			if c.Request.ContentLength > limit {
+				rejectBody(c, errs.ErrPayloadTooLarge, limit, logger)
+				return
+			}
+			// Chunked bodies have no length up front and are cut off while being read
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		if cfg.MaxJSONDepth > 0 && isJSONContent(c.ContentType()) {
+			// The body is only used until the handlers return, so its buffer is pooled
+			buf := bodyBufferPool.Get()
+			defer bodyBufferPool.Put(buf)
+			if _, err := buf.ReadFrom(c.Request.Body); err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					rejectBody(c, errs.ErrPayloadTooLarge, limit, logger)
+				} else {
+					rejectBody(c, errs.ErrBadRequest, limit, logger)
+				}
+				return
+			}
+			if jsonDepthExceeds(buf.Bytes(), cfg.MaxJSONDepth) {
+				rejectBody(c, errs.ErrJSONTooDeep, limit, logger)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+		}
+
+		c.Next()
+	}
+}
+
+// rejectBody aborts the request with a body error
+func rejectBody(c *gin.Context, httpErr *errs.HTTPError, limit int64, logger *logrus.Logger) {
+	logger.WithFields(logrus.Fields{
+		"method":         c.Request.Method,
+		"path":           c.Request.URL.Path,
+		"ip":             ClientIP(c),
+		"content_length": c.Request.ContentLength,
+		"limit":          limit,
+		"error_code":     httpErr.Code,
+	}).Warn("Request body rejected")
+
+	c.AbortWithStatusJSON(httpErr.Status, httpErr)
+}
+
+// isJSONContent reports whether a content type carries JSON (application/json or +json)
+func isJSONContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// jsonDepthExceeds scans a JSON document and reports whether its objects and arrays
+// nest deeper than maxDepth. It stops at the first level too deep, so pathological
+// documents are rejected without being decoded; syntax errors are left to the decoder.
+func jsonDepthExceeds(data []byte, maxDepth int) bool {
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return false
+}
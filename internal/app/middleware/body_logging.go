@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+
+	"apigw/internal/app/bodylog"
+	"apigw/internal/app/config"
+	"apigw/internal/app/recording"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BodyLoggingMiddleware logs the request and response bodies of the configured routes
+// and of those enabled at runtime, with the logger of the request. Bodies up to max_bytes
+// of the logged content types are logged with their credential and configured fields
+// redacted; larger bodies, other types and bodies that fail to parse are only logged by
+// size, since they could not be redacted.
+func BodyLoggingMiddleware(cfg config.BodyLogConfig, routes *bodylog.Switch) gin.HandlerFunc {
+	configured := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		configured[route] = true
+	}
+	contentTypes := make(map[string]bool, len(cfg.ContentTypes))
+	for _, contentType := range cfg.ContentTypes {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			contentTypes[mediaType] = true
+		}
+	}
+	sanitizer := recording.NewSanitizer(nil, append(append([]string(nil), bodylog.CredentialFields...), cfg.RedactFields...))
+	logged := func(contentType string) string {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !contentTypes[mediaType] {
+			return ""
+		}
+		return mediaType
+	}
+
+	return func(c *gin.Context) {
+		method, route := c.Request.Method, c.FullPath()
+		if route == "" || !(configured[method+" "+route] || configured[route] || routes.Enabled(method, route)) {
+			c.Next()
+			return
+		}
+
+		requestSize := c.Request.ContentLength
+		var requestBody []byte
+		requestType := logged(c.ContentType())
+		if c.Request.Body != nil && requestSize != 0 && requestType != "" && requestSize <= int64(cfg.MaxBytes) {
+			// A byte past the cap tells bodies of unknown length that are too large apart
+			data, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(cfg.MaxBytes)+1))
+			rest := io.Reader(c.Request.Body)
+			if err != nil {
+				rest = errorReader{err}
+			}
+			// The handlers read what was read, then the rest of the body
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(data), rest), c.Request.Body}
+			if err == nil && len(data) <= cfg.MaxBytes {
+				requestBody = data
+				requestSize = int64(len(data))
+			}
+		}
+
+		writer := &teeResponseWriter{ResponseWriter: c.Writer, limit: cfg.MaxBytes}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		fields := logrus.Fields{"status": writer.Status()}
+		redacted := 0
+		if requestSize != 0 {
+			// Bodies of unknown length that were not read are logged without a size
+			if requestSize > 0 {
+				fields["request_body_bytes"] = requestSize
+			}
+			if body, n, ok := redactBody(sanitizer, requestType, requestBody); ok {
+				fields["request_body"] = body
+				redacted += n
+			} else {
+				fields["request_body_omitted"] = true
+			}
+		}
+		if size := writer.Size(); size > 0 {
+			fields["response_body_bytes"] = size
+			var responseBody []byte
+			if !writer.truncated {
+				responseBody = writer.body.Bytes()
+			}
+			if body, n, ok := redactBody(sanitizer, logged(writer.Header().Get("Content-Type")), responseBody); ok {
+				fields["response_body"] = body
+				redacted += n
+			} else {
+				fields["response_body_omitted"] = true
+			}
+		}
+		fields["redacted_fields"] = redacted
+		logutils.FromContext(c).WithFields(fields).Info("Request and response bodies")
+	}
+}
+
+// redactBody returns a body of a logged media type with its sensitive fields redacted,
+// and how many were; ok is false when there is no body to log or it cannot be parsed
+func redactBody(sanitizer *recording.Sanitizer, mediaType string, body []byte) (string, int, bool) {
+	switch {
+	case body == nil || mediaType == "":
+		return "", 0, false
+	case mediaType == "application/x-www-form-urlencoded":
+		return sanitizer.Form(body)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		sanitized, redacted, ok := sanitizer.Body(body)
+		return string(sanitized), redacted, ok
+	default:
+		return "", 0, false
+	}
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"text/template"
+
+	"apigw/internal/app/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// brand is the compiled branding of a tenant or partner
+type brand struct {
+	headers          map[string]string
+	errorTemplate    *template.Template
+	errorContentType string
+}
+
+// brandedError is the data of error templates
+type brandedError struct {
+	Status    int
+	Code      string
+	Type      string
+	Message   string
+	RequestID string
+	Tenant    string
+	Partner   string
+}
+
+// BrandingMiddleware sets the response headers of the tenant or partner of a request on
+// its responses, and renders its error responses with their error template; the branding
+// of a partner wins over that of its tenant. Both are looked up when the response is
+// written, so the middleware may run ahead of those resolving them. It must run ahead of
+// the problem details middleware, so that an error template replaces problem details too.
+func BrandingMiddleware(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
+	tenants := make(map[string]*brand)
+	for _, t := range cfg.Tenants.Active() {
+		if b := newBrand(t.Branding, "tenant", t.ID, logger); b != nil {
+			tenants[t.ID] = b
+		}
+	}
+	partners := make(map[string]*brand)
+	if cfg.Signing.Enabled {
+		for _, p := range cfg.Signing.Partners {
+			if b := newBrand(p.Branding, "partner", p.ID, logger); b != nil {
+				partners[p.ID] = b
+			}
+		}
+	}
+
+	return func(c *gin.Context) {
+		writer := &brandingWriter{ResponseWriter: c.Writer}
+		writer.lookup = func() *brand {
+			if b, ok := partners[c.GetString("partner_id")]; ok {
+				return b
+			}
+			return tenants[Tenant(c)]
+		}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		// Responses without a body are written by the engine once the handlers are done
+		writer.resolve()
+		if !writer.held {
+			return
+		}
+
+		body := writer.body.Bytes()
+		var parsed struct {
+			Error     string `json:"error"`
+			Title     string `json:"title"`
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			Detail    string `json:"detail"`
+			RequestID string `json:"request_id"`
+		}
+		if json.Unmarshal(body, &parsed) != nil || parsed.Code == "" {
+			// Not one of the gateway's errors, e.g. a proxied body
+			c.Writer.Write(body)
+			return
+		}
+		data := brandedError{
+			Status:    c.Writer.Status(),
+			Code:      parsed.Code,
+			Type:      parsed.Error,
+			Message:   parsed.Message,
+			RequestID: parsed.RequestID,
+			Tenant:    Tenant(c),
+			Partner:   c.GetString("partner_id"),
+		}
+		if data.Type == "" {
+			data.Type, data.Message = parsed.Title, parsed.Detail
+		}
+		if data.RequestID == "" {
+			data.RequestID = RequestID(c)
+		}
+
+		var rendered bytes.Buffer
+		if err := writer.brand.errorTemplate.Execute(&rendered, data); err != nil {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"tenant_id":  data.Tenant,
+				"partner_id": data.Partner,
+				"path":       c.Request.URL.Path,
+			}).Error("Failed to render branded error")
+			c.Writer.Write(body)
+			return
+		}
+		header := c.Writer.Header()
+		header.Set("Content-Type", writer.brand.errorContentType)
+		header.Del("Content-Length")
+		c.Writer.Write(rendered.Bytes())
+	}
+}
+
+// newBrand compiles the branding of a tenant or partner, nil when it has none
+func newBrand(cfg config.BrandingConfig, kind, id string, logger *logrus.Logger) *brand {
+	tmpl, err := cfg.ParseErrorTemplate()
+	if err != nil {
+		logger.WithError(err).WithField(kind+"_id", id).Error("Invalid error template, gateway errors are kept")
+	}
+	if len(cfg.Headers) == 0 && tmpl == nil {
+		return nil
+	}
+	b := &brand{
+		headers:          make(map[string]string, len(cfg.Headers)),
+		errorTemplate:    tmpl,
+		errorContentType: cfg.ErrorContentType,
+	}
+	for name, value := range cfg.Headers {
+		b.headers[http.CanonicalHeaderKey(name)] = value
+	}
+	if b.errorContentType == "" {
+		b.errorContentType = "application/json"
+	}
+	return b
+}
+
+// brandingWriter sets the branded headers before the response is written, and holds back
+// the body of error responses with an error template
+type brandingWriter struct {
+	gin.ResponseWriter
+	lookup   func() *brand
+	brand    *brand
+	resolved bool
+	body     bytes.Buffer
+	held     bool
+}
+
+// resolve looks the branding up and sets its headers, once
+func (w *brandingWriter) resolve() {
+	if w.resolved {
+		return
+	}
+	w.resolved = true
+	if w.brand = w.lookup(); w.brand == nil || w.Written() {
+		return
+	}
+	header := w.Header()
+	for name, value := range w.brand.headers {
+		header.Set(name, value)
+	}
+}
+
+// WriteHeaderNow sets the branded headers before the header is written
+func (w *brandingWriter) WriteHeaderNow() {
+	w.resolve()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write buffers the body of error responses with an error template
+func (w *brandingWriter) Write(data []byte) (int, error) {
+	if w.holds() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString buffers the body of error responses with an error template
+func (w *brandingWriter) WriteString(s string) (int, error) {
+	if w.holds() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush sets the branded headers before streamed responses are flushed
+func (w *brandingWriter) Flush() {
+	w.resolve()
+	w.ResponseWriter.Flush()
+}
+
+// holds reports whether the body is held back, deciding it on the first write
+func (w *brandingWriter) holds() bool {
+	w.resolve()
+	if !w.held && w.brand != nil && w.brand.errorTemplate != nil && !w.Written() && w.Status() >= 400 {
+		w.held = true
+	}
+	return w.held
+}
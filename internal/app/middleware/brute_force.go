@@ -0,0 +1,362 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// captchaRequiredHeader tells clients to attach a CAPTCHA to their next attempt
+const captchaRequiredHeader = "X-Captcha-Required"
+
+// BruteForceGuard counts failed login and registration attempts per email and per client
+// IP in Redis. Subjects with too many recent failures are asked for a CAPTCHA and then
+// locked out, for twice as long on every further lockout. It is independent of the token
+// bucket, which limits request rates but not how many of them fail.
+type BruteForceGuard struct {
+	redis  redis.UniversalClient
+	cfg    config.BruteForceConfig
+	logger *logrus.Logger
+}
+
+// bruteForceSubject is an email or IP address whose failures are counted
+type bruteForceSubject struct {
+	key    string // e.g. email:alice@example.com or ip:203.0.113.7
+	limits config.BruteForceLimits
+}
+
+// BruteForceStatus is the state of the subjects of a request
+type BruteForceStatus struct {
+	Failures        int           // Most recent failures of any subject
+	CaptchaRequired bool          // A subject has reached its CAPTCHA threshold
+	RetryAfter      time.Duration // Remaining lockout; zero when no subject is locked out
+}
+
+// NewBruteForceGuard creates a guard storing its counters under cfg.KeyPrefix
+func NewBruteForceGuard(redisClient redis.UniversalClient, cfg config.BruteForceConfig, logger *logrus.Logger) *BruteForceGuard {
+	return &BruteForceGuard{
+		redis:  redisClient,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Middleware guards an endpoint such as login or register. Locked out requests are
+// rejected with 429 before reaching the handler. Handler responses rejecting the attempt
+// (401, 403, 404 and 409) count as failures and a successful one clears the email's
+// failures; requests aborted before the handler, such as by the CAPTCHA guard, count as
+// neither. Responses carry X-Captcha-Required once a CAPTCHA is due.
+func (g *BruteForceGuard) Middleware(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subjects := g.subjects(c)
+		if c.IsAborted() {
+			return
+		}
+		ctx := c.Request.Context()
+
+		status, err := g.check(ctx, subjects)
+		if err != nil {
+			// Like the token bucket, fail open while Redis is unavailable
+			g.logger.WithError(err).Error("Brute-force check failed")
+			c.Next()
+			return
+		}
+		if status.RetryAfter > 0 {
+			metrics.BruteForceEvents.WithLabelValues(action, "locked").Inc()
+			g.reject(c, action, status)
+			return
+		}
+		if status.CaptchaRequired {
+			c.Header(captchaRequiredHeader, "true")
+			flagForCaptcha(c)
+		}
+
+		// The outcome is recorded before the response headers are sent, so the response
+		// of the attempt crossing a threshold already asks for a CAPTCHA
+		writer := &outcomeWriter{ResponseWriter: c.Writer}
+		writer.record = func() { g.record(c, action, subjects) }
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		writer.recordOnce()
+	}
+}
+
+// Attempt guards an attempt made outside the HTTP middleware, such as a login over the
+// gRPC server. It returns the status of the email and IP before the attempt, and done,
+// which records whether the attempt was rejected like the middleware does.
+func (g *BruteForceGuard) Attempt(ctx context.Context, action, email, ip string) (BruteForceStatus, func(ctx context.Context, rejected bool), error) {
+	subjects := make([]bruteForceSubject, 0, 2)
+	if email != "" {
+		subjects = append(subjects, bruteForceSubject{key: "email:" + strings.ToLower(strings.TrimSpace(email)), limits: g.cfg.Email})
+	}
+	subjects = append(subjects, bruteForceSubject{key: "ip:" + ip, limits: g.cfg.IP})
+
+	done := func(ctx context.Context, rejected bool) {
+		if !rejected {
+			g.succeed(ctx, subjects)
+			return
+		}
+		metrics.BruteForceEvents.WithLabelValues(action, "failure").Inc()
+		status, err := g.fail(ctx, subjects)
+		if err != nil {
+			g.logger.WithError(err).Error("Failed to record failed attempt")
+			return
+		}
+		if status.RetryAfter > 0 {
+			metrics.BruteForceEvents.WithLabelValues(action, "lockout").Inc()
+			g.logger.WithFields(logrus.Fields{
+				"ip":          ip,
+				"action":      action,
+				"failures":    status.Failures,
+				"retry_after": status.RetryAfter,
+			}).Warn("Locked out after repeated failed attempts")
+		}
+	}
+
+	status, err := g.check(ctx, subjects)
+	if err != nil {
+		return BruteForceStatus{}, done, err
+	}
+	if status.RetryAfter > 0 {
+		metrics.BruteForceEvents.WithLabelValues(action, "locked").Inc()
+	}
+	return status, done, nil
+}
+
+// record counts a rejected attempt as a failure, or clears the email's failures after a
+// successful one. Requests aborted by a later middleware never reached the handler.
+func (g *BruteForceGuard) record(c *gin.Context, action string, subjects []bruteForceSubject) {
+	if c.IsAborted() {
+		return
+	}
+	ctx := c.Request.Context()
+	switch code := c.Writer.Status(); {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden ||
+		code == http.StatusNotFound || code == http.StatusConflict:
+		metrics.BruteForceEvents.WithLabelValues(action, "failure").Inc()
+		status, err := g.fail(ctx, subjects)
+		if err != nil {
+			g.logger.WithError(err).Error("Failed to record failed attempt")
+			return
+		}
+		if status.CaptchaRequired || status.RetryAfter > 0 {
+			c.Header(captchaRequiredHeader, "true")
+		}
+		if status.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+			metrics.BruteForceEvents.WithLabelValues(action, "lockout").Inc()
+			g.logger.WithFields(g.logFields(c, action, status)).Warn("Locked out after repeated failed attempts")
+		}
+	case code < http.StatusBadRequest:
+		c.Writer.Header().Del(captchaRequiredHeader)
+		g.succeed(ctx, subjects)
+	}
+}
+
+// outcomeWriter runs record once, right before the response headers are written
+type outcomeWriter struct {
+	gin.ResponseWriter
+	record   func()
+	recorded bool
+}
+
+// recordOnce runs record unless it already ran
+func (w *outcomeWriter) recordOnce() {
+	if !w.recorded {
+		w.recorded = true
+		w.record()
+	}
+}
+
+// WriteHeaderNow records the outcome before writing the headers
+func (w *outcomeWriter) WriteHeaderNow() {
+	w.recordOnce()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write records the outcome before writing the headers and body
+func (w *outcomeWriter) Write(data []byte) (int, error) {
+	w.recordOnce()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString records the outcome before writing the headers and body
+func (w *outcomeWriter) WriteString(s string) (int, error) {
+	w.recordOnce()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// subjects returns the email and client IP of a request; it aborts the request when its
+// body cannot be read for the email
+func (g *BruteForceGuard) subjects(c *gin.Context) []bruteForceSubject {
+	subjects := make([]bruteForceSubject, 0, 2)
+	if key, ok := EmailKeyFunc(c); ok {
+		subjects = append(subjects, bruteForceSubject{key: key, limits: g.cfg.Email})
+	} else if c.IsAborted() {
+		return nil
+	}
+	if key, ok := IPKeyFunc(c); ok {
+		subjects = append(subjects, bruteForceSubject{key: key, limits: g.cfg.IP})
+	}
+	return subjects
+}
+
+// failuresKey returns the Redis key counting a subject's recent failures. The keys of a
+// subject share a hash tag, which keeps them in one Redis Cluster slot.
+func (g *BruteForceGuard) failuresKey(s bruteForceSubject) string {
+	return g.cfg.KeyPrefix + "{" + s.key + "}:failures"
+}
+
+// lockKey returns the Redis key whose TTL is a subject's remaining lockout
+func (g *BruteForceGuard) lockKey(s bruteForceSubject) string {
+	return g.cfg.KeyPrefix + "{" + s.key + "}:lock"
+}
+
+// strikesKey returns the Redis key counting a subject's recent lockouts
+func (g *BruteForceGuard) strikesKey(s bruteForceSubject) string {
+	return g.cfg.KeyPrefix + "{" + s.key + "}:strikes"
+}
+
+// check returns the current status of the subjects
+func (g *BruteForceGuard) check(ctx context.Context, subjects []bruteForceSubject) (BruteForceStatus, error) {
+	var status BruteForceStatus
+	pipe := g.redis.Pipeline()
+	failures := make([]*redis.StringCmd, len(subjects))
+	locks := make([]*redis.DurationCmd, len(subjects))
+	for i, s := range subjects {
+		failures[i] = pipe.Get(ctx, g.failuresKey(s))
+		locks[i] = pipe.PTTL(ctx, g.lockKey(s))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return status, fmt.Errorf("redis pipeline execution failed: %w", err)
+	}
+
+	for i, s := range subjects {
+		count, _ := strconv.Atoi(failures[i].Val())
+		status.add(s, count)
+		if ttl := locks[i].Val(); ttl > status.RetryAfter {
+			status.RetryAfter = ttl
+		}
+	}
+	return status, nil
+}
+
+// fail records a failed attempt of every subject, locking out those reaching their
+// threshold, and returns the resulting status
+func (g *BruteForceGuard) fail(ctx context.Context, subjects []bruteForceSubject) (BruteForceStatus, error) {
+	var status BruteForceStatus
+	pipe := g.redis.Pipeline()
+	counts := make([]*redis.IntCmd, len(subjects))
+	for i, s := range subjects {
+		counts[i] = pipe.Incr(ctx, g.failuresKey(s))
+		pipe.Expire(ctx, g.failuresKey(s), g.cfg.Window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return status, fmt.Errorf("redis pipeline execution failed: %w", err)
+	}
+
+	for i, s := range subjects {
+		count := int(counts[i].Val())
+		status.add(s, count)
+		if count < s.limits.LockoutAfter {
+			continue
+		}
+		lockout, err := g.lockOut(ctx, s)
+		if err != nil {
+			return status, err
+		}
+		if lockout > status.RetryAfter {
+			status.RetryAfter = lockout
+		}
+	}
+	return status, nil
+}
+
+// lockOut locks a subject out for a duration doubling with every strike and restarts
+// its failure count
+func (g *BruteForceGuard) lockOut(ctx context.Context, s bruteForceSubject) (time.Duration, error) {
+	strikes, err := g.redis.Incr(ctx, g.strikesKey(s)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redis update failed: %w", err)
+	}
+	lockout := time.Duration(float64(g.cfg.Lockout) * math.Pow(2, float64(strikes-1)))
+	if lockout <= 0 || lockout > g.cfg.MaxLockout {
+		lockout = g.cfg.MaxLockout
+	}
+
+	pipe := g.redis.Pipeline()
+	pipe.Expire(ctx, g.strikesKey(s), g.cfg.StrikeTTL)
+	pipe.Set(ctx, g.lockKey(s), strikes, lockout)
+	pipe.Del(ctx, g.failuresKey(s))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("redis update failed: %w", err)
+	}
+	return lockout, nil
+}
+
+// succeed clears the failures and strikes of the email after a successful attempt. The
+// client IP keeps its count, as one address may be trying many accounts.
+func (g *BruteForceGuard) succeed(ctx context.Context, subjects []bruteForceSubject) {
+	for _, s := range subjects {
+		if !strings.HasPrefix(s.key, "email:") {
+			continue
+		}
+		if err := g.redis.Del(ctx, g.failuresKey(s), g.strikesKey(s)).Err(); err != nil {
+			g.logger.WithError(err).Warn("Failed to clear failed attempts")
+		}
+	}
+}
+
+// add accounts for the failures of a subject
+func (s *BruteForceStatus) add(subject bruteForceSubject, failures int) {
+	if failures > s.Failures {
+		s.Failures = failures
+	}
+	if subject.limits.CaptchaAfter > 0 && failures >= subject.limits.CaptchaAfter {
+		s.CaptchaRequired = true
+	}
+}
+
+// reject aborts a locked out request with 429 Too Many Requests
+func (g *BruteForceGuard) reject(c *gin.Context, action string, status BruteForceStatus) {
+	retryAfter := int(math.Ceil(status.RetryAfter.Seconds()))
+	g.logger.WithFields(g.logFields(c, action, status)).Warn("Locked out attempt rejected")
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.Header(captchaRequiredHeader, "true")
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":   errs.ErrTooManyFailedAttempts.ErrorType,
+		"code":    errs.ErrTooManyFailedAttempts.Code,
+		"message": errs.ErrTooManyFailedAttempts.Message,
+		"details": gin.H{
+			"retry_after":      retryAfter,
+			"captcha_required": true,
+		},
+	})
+	c.Abort()
+}
+
+// logFields returns the log fields of a guarded request
+func (g *BruteForceGuard) logFields(c *gin.Context, action string, status BruteForceStatus) logrus.Fields {
+	return logrus.Fields{
+		"method":      c.Request.Method,
+		"path":        c.Request.URL.Path,
+		"ip":          ClientIP(c),
+		"action":      action,
+		"failures":    status.Failures,
+		"retry_after": status.RetryAfter,
+	}
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"apigw/internal/app/cache"
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCacheKey identifies a response by its path and canonical query string
+const defaultCacheKey = "{path}?{query}"
+
+// cachePlaceholder matches {name} and {name.arg} placeholders of key and tag templates
+var cachePlaceholder = regexp.MustCompile(`\{([a-z]+)(?:\.([^}]+))?\}`)
+
+// ResponseCacheMiddleware serves GET requests to the routes configured under cache.routes
+// from the response cache and stores successful responses on a miss. It must run after
+// authentication so that responses of authenticated routes are cached per user.
+func ResponseCacheMiddleware(store *cache.Cache, cfg config.CacheConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		route, ok := cfg.Route(c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		key := cacheKey(c, route)
+		if entry, tier, ok := store.Get(c.Request.Context(), key); ok {
+			metrics.CacheRequests.WithLabelValues(route.Path, tier+"_hit").Inc()
+			writeCachedResponse(c, entry, "HIT")
+			c.Abort()
+			return
+		}
+		metrics.CacheRequests.WithLabelValues(route.Path, "miss").Inc()
+
+		writer := &bufferedResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		entry := &cache.Entry{
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}
+		if entry.Status != http.StatusOK {
+			c.Writer.Write(entry.Body)
+			return
+		}
+
+		sum := sha256.Sum256(entry.Body)
+		entry.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		entry.ExpiresAt = time.Now().Add(route.TTL)
+		for _, tag := range route.Tags {
+			entry.Tags = append(entry.Tags, expandCacheTemplate(c, tag))
+		}
+		store.Set(c.Request.Context(), key, entry)
+
+		writeCachedResponse(c, entry, "MISS")
+	}
+}
+
+// cacheKey builds the cache key of a request from the route's key template
+func cacheKey(c *gin.Context, route config.CacheRouteConfig) string {
+	template := route.Key
+	if template == "" {
+		template = defaultCacheKey
+	}
+	key := route.Path + "|" + expandCacheTemplate(c, template)
+
+	// Never share responses of authenticated routes between users, nor responses between
+	// tenants or regions
+	if userID := c.GetString("user_id"); userID != "" && !strings.Contains(template, "{user}") {
+		key += "|user=" + userID
+	}
+	if tenant := Tenant(c); tenant != "" {
+		key += "|tenant=" + tenant
+	}
+	if region := Region(c); region != "" {
+		key += "|region=" + region
+	}
+	return key
+}
+
+// expandCacheTemplate replaces the placeholders of a key or tag template with request values
+func expandCacheTemplate(c *gin.Context, template string) string {
+	return cachePlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		parts := cachePlaceholder.FindStringSubmatch(match)
+		switch parts[1] {
+		case "path":
+			return c.Request.URL.Path
+		case "query":
+			if parts[2] != "" {
+				return c.Query(parts[2])
+			}
+			// Encode sorts by name, so equivalent query strings share an entry
+			return c.Request.URL.Query().Encode()
+		case "param":
+			return c.Param(parts[2])
+		case "user":
+			return c.GetString("user_id")
+		case "tenant":
+			return Tenant(c)
+		default:
+			return match
+		}
+	})
+}
+
+// writeCachedResponse writes a cached response, answering conditional requests
+// whose If-None-Match matches the entry's ETag with 304 Not Modified
+func writeCachedResponse(c *gin.Context, entry *cache.Entry, result string) {
+	header := c.Writer.Header()
+	header.Set("X-Cache", result)
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), entry.ETag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+	c.Status(entry.Status)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Write(entry.Body)
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag (RFC 9110 weak comparison)
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter holds the response body back so it can be stored before it is sent
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write buffers the body
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+// WriteString buffers the body
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"apigw/internal/app/captcha"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// captchaDueKey is the context key set when a request has been flagged for a CAPTCHA
+const captchaDueKey = "captcha_due"
+
+// captchaCountScript counts a request of a client, starting the window with the first
+// one; a counter left without expiry gets one, so a client is never flagged for good
+var captchaCountScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 or redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// flagForCaptcha marks a request as needing a CAPTCHA on actions requiring one on abuse
+func flagForCaptcha(c *gin.Context) {
+	c.Set(captchaDueKey, true)
+}
+
+// CaptchaGuard requires a solved CAPTCHA on abuse-prone actions such as registration,
+// password resets and purchases. Depending on the action's mode, every request needs a
+// token or only those flagged as abusive: by the brute-force protection, which runs
+// first on login and registration, or by a client exceeding the request rate heuristic.
+type CaptchaGuard struct {
+	verifier captcha.Verifier
+	redis    redis.UniversalClient
+	cfg      config.CaptchaConfig
+	logger   *logrus.Logger
+}
+
+// NewCaptchaGuard creates a CAPTCHA guard; redisClient may be nil, which disables the
+// request rate heuristic
+func NewCaptchaGuard(verifier captcha.Verifier, redisClient redis.UniversalClient, cfg config.CaptchaConfig, logger *logrus.Logger) *CaptchaGuard {
+	return &CaptchaGuard{
+		verifier: verifier,
+		redis:    redisClient,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Middleware guards an action. Requests needing a CAPTCHA without a token in the
+// configured header are rejected with 428 Precondition Required and invalid tokens with
+// 403, both with X-Captcha-Required so clients know to show the challenge.
+func (g *CaptchaGuard) Middleware(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode := g.cfg.CaptchaMode(action)
+		if mode == config.CaptchaOff || (mode == config.CaptchaOnAbuse && !g.flagged(c, action)) {
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader(g.cfg.Header)
+		if token == "" {
+			metrics.CaptchaVerifications.WithLabelValues(action, "missing").Inc()
+			g.reject(c, action, errs.ErrCaptchaRequired)
+			return
+		}
+
+		ok, err := g.verifier.Verify(c.Request.Context(), token, ClientIP(c))
+		switch {
+		case err != nil:
+			metrics.CaptchaVerifications.WithLabelValues(action, "error").Inc()
+			g.logger.WithError(err).WithField("action", action).Error("CAPTCHA verification failed")
+			if !g.cfg.FailOpen {
+				g.reject(c, action, errs.ErrCaptchaUnavailable)
+				return
+			}
+		case !ok:
+			metrics.CaptchaVerifications.WithLabelValues(action, "invalid").Inc()
+			g.reject(c, action, errs.ErrCaptchaInvalid)
+			return
+		default:
+			metrics.CaptchaVerifications.WithLabelValues(action, "passed").Inc()
+		}
+		c.Next()
+	}
+}
+
+// flagged reports whether a request has been flagged as abusive, counting it towards the
+// request rate heuristic
+func (g *CaptchaGuard) flagged(c *gin.Context, action string) bool {
+	if c.GetBool(captchaDueKey) {
+		return true
+	}
+	if g.redis == nil || g.cfg.Abuse.Requests <= 0 {
+		return false
+	}
+
+	// Key by user on authenticated actions such as purchases, otherwise by client IP
+	clientID := "ip:" + ClientIP(c)
+	if userID := c.GetString("user_id"); userID != "" {
+		clientID = "user:" + userID
+	}
+	key := g.cfg.KeyPrefix + action + ":" + clientID
+
+	count, err := captchaCountScript.Run(c.Request.Context(), g.redis, []string{key}, g.cfg.Abuse.Window.Milliseconds()).Int64()
+	if err != nil {
+		g.logger.WithError(err).Error("CAPTCHA abuse heuristic check failed")
+		return false
+	}
+	return count > int64(g.cfg.Abuse.Requests)
+}
+
+// reject aborts a request whose CAPTCHA is missing or invalid
+func (g *CaptchaGuard) reject(c *gin.Context, action string, httpErr *errs.HTTPError) {
+	g.logger.WithFields(logrus.Fields{
+		"method": c.Request.Method,
+		"path":   c.Request.URL.Path,
+		"ip":     ClientIP(c),
+		"action": action,
+		"code":   httpErr.Code,
+	}).Warn("CAPTCHA challenge rejected request")
+
+	c.Header(captchaRequiredHeader, "true")
+	c.AbortWithStatusJSON(httpErr.Status, gin.H{
+		"error":   httpErr.ErrorType,
+		"code":    httpErr.Code,
+		"message": httpErr.Message,
+		"details": gin.H{
+			"provider": g.cfg.Provider,
+			"site_key": g.cfg.SiteKey,
+			"header":   g.cfg.Header,
+		},
+	})
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/netip"
+
+	"apigw/internal/app/clientip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPKey is the context key of the resolved client address
+const clientIPKey = "client_ip"
+
+// ClientIPMiddleware resolves the client address of every request once, so rate limits,
+// access control lists, logs and audit records all attribute it to the same client
+func ClientIPMiddleware(resolver *clientip.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIPKey, resolver.Resolve(c.Request))
+		c.Next()
+	}
+}
+
+// ClientAddr returns the resolved client address of a request, or the zero Addr when it
+// is unknown. Outside of ClientIPMiddleware it falls back to Gin's resolution.
+func ClientAddr(c *gin.Context) netip.Addr {
+	if value, ok := c.Get(clientIPKey); ok {
+		return value.(netip.Addr)
+	}
+	addr, err := netip.ParseAddr(c.ClientIP())
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// ClientIP returns the resolved client address of a request as a string, empty when it
+// is unknown
+func ClientIP(c *gin.Context) string {
+	addr := ClientAddr(c)
+	if !addr.IsValid() {
+		return ""
+	}
+	return addr.String()
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedResponse is the response of a coalesced request, copied to every waiter
+type sharedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// CoalesceMiddleware collapses identical concurrent GET requests to the routes listed
+// under coalescing.routes: the first request is served by the handler while the others
+// wait for it and receive a copy of its response, so a burst of clients polling the same
+// resource costs one upstream call. Requests are identical when they share the path, the
+// query string and, on authenticated routes, the user.
+func CoalesceMiddleware(cfg config.CoalescingConfig) gin.HandlerFunc {
+	routes := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route] = true
+	}
+	var group singleflight.Group
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || !routes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		key := c.Request.URL.Path + "?" + c.Request.URL.Query().Encode()
+		if userID := c.GetString("user_id"); userID != "" {
+			key += "|user=" + userID
+		}
+		if tenant := Tenant(c); tenant != "" {
+			key += "|tenant=" + tenant
+		}
+		if region := Region(c); region != "" {
+			key += "|region=" + region
+		}
+
+		leader := false
+		result, _, _ := group.Do(key, func() (interface{}, error) {
+			leader = true
+			return serveShared(c), nil
+		})
+		if leader {
+			return
+		}
+
+		metrics.CoalescedRequests.WithLabelValues(c.FullPath()).Inc()
+		resp := result.(*sharedResponse)
+		header := c.Writer.Header()
+		for name, values := range resp.header {
+			if _, ok := header[name]; !ok {
+				header[name] = values
+			}
+		}
+		c.Status(resp.status)
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(resp.body)
+		c.Abort()
+	}
+}
+
+// serveShared runs the handler for the first of a set of identical requests and writes
+// its response, returning a copy for the requests waiting on it
+func serveShared(c *gin.Context) *sharedResponse {
+	// The waiters depend on this request, so a client disconnecting must not cancel the
+	// upstream calls; the deadline still applies
+	ctx := c.Request.Context()
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		detached, cancel = context.WithDeadline(detached, deadline)
+		defer cancel()
+	}
+	c.Request = c.Request.WithContext(detached)
+
+	writer := &bufferedResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+	c.Request = c.Request.WithContext(ctx)
+
+	resp := &sharedResponse{
+		status: c.Writer.Status(),
+		header: c.Writer.Header().Clone(),
+		body:   writer.body.Bytes(),
+	}
+	c.Writer.Write(resp.body)
+	return resp
+}
@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 
 	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -20,15 +22,18 @@ func ErrorHandlerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 			err := c.Errors.Last().Err
 
 			// Convert gRPC error to HTTP error
-			httpErr := errs.GRPCToHTTPError(err)
+			httpErr := errs.GRPCToHTTPError(err).WithRequestID(RequestID(c))
 
 			logger.WithError(err).WithFields(logrus.Fields{
 				"method":     c.Request.Method,
 				"path":       c.Request.URL.Path,
 				"status":     httpErr.Status,
 				"error_code": httpErr.Code,
+				"request_id": httpErr.RequestID,
+				"tenant_id":  Tenant(c),
 			}).Error("Request failed")
 
+			writeRetryAfter(c, httpErr)
 			c.JSON(httpErr.Status, httpErr)
 			return
 		}
@@ -44,18 +49,37 @@ func GRPCErrorHandler(c *gin.Context, err error, logger *logrus.Logger) {
 	// Convert gRPC error to HTTP error
 	httpErr := errs.GRPCToHTTPError(err)
 
-	logger.WithError(err).WithFields(logrus.Fields{
+	// The failed call itself is logged by the client logging interceptor
+	entry := logger.WithError(err).WithFields(logrus.Fields{
 		"method":     c.Request.Method,
 		"path":       c.Request.URL.Path,
 		"status":     httpErr.Status,
 		"error_code": httpErr.Code,
 		"grpc_code":  errs.GetGRPCCode(err).String(),
-	}).Error("gRPC call failed")
+		"tenant_id":  Tenant(c),
+	})
+	if httpErr.Status >= http.StatusInternalServerError {
+		// The backend's message is withheld from the client; the request ID of the
+		// response correlates it with this record
+		httpErr = httpErr.WithRequestID(RequestID(c))
+		entry.WithField("request_id", httpErr.RequestID).Error("Upstream error withheld from response")
+	} else {
+		entry.Debug("gRPC error mapped to HTTP response")
+	}
 
+	writeRetryAfter(c, httpErr)
 	c.JSON(httpErr.Status, httpErr)
 }
 
-// ValidationErrorHandler handles validation errors
+// writeRetryAfter tells the client when to retry, if the backend said so
+func writeRetryAfter(c *gin.Context, httpErr *errs.HTTPError) {
+	if httpErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(errs.RetryAfterSeconds(httpErr.RetryAfter)))
+	}
+}
+
+// ValidationErrorHandler handles validation errors; code must be a validation error of the
+// errs catalog, and message may describe the offending value
 func ValidationErrorHandler(c *gin.Context, code, message string, logger *logrus.Logger) {
 	httpErr := errs.NewHTTPError("VALIDATION_ERROR", code, message, http.StatusBadRequest)
 
@@ -68,6 +92,29 @@ func ValidationErrorHandler(c *gin.Context, code, message string, logger *logrus
 	c.JSON(httpErr.Status, httpErr)
 }
 
+// BindingErrorHandler handles requests whose body or query parameters could not be bound:
+// the response lists the offending fields under details when the error names them, so
+// clients can highlight them. code and message are as for ValidationErrorHandler.
+func BindingErrorHandler(c *gin.Context, err error, code, message string, logger *logrus.Logger) {
+	httpErr := errs.NewHTTPError("VALIDATION_ERROR", code, message, http.StatusBadRequest)
+	violations, ok := validation.Violations(err)
+	if ok {
+		httpErr.Details = violations
+	}
+
+	fields := logrus.Fields{
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+		"error_code": code,
+	}
+	if ok {
+		fields["violations"] = len(violations)
+	}
+	logger.WithFields(fields).Warn("Validation error")
+
+	c.JSON(httpErr.Status, httpErr)
+}
+
 // AuthenticationErrorHandler handles authentication errors
 func AuthenticationErrorHandler(c *gin.Context, logger *logrus.Logger) {
 	httpErr := errs.ErrUnauthorized
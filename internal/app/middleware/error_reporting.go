@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"apigw/internal/app/errreport"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reportedHeaders are the request headers sent with error reports; the others may carry
+// credentials or personal data
+var reportedHeaders = []string{"User-Agent", "Content-Type", "Accept", RequestIDHeader, TraceParentHeader}
+
+// ErrorReportingMiddleware reports the panics of later handlers and a sampled share of
+// 5xx responses with the context of their request: its route, status, request and trace
+// IDs, tenant, region and partner, and the hash of its user. It runs right after the
+// recovery middleware, which still recovers and answers the panics it reports.
+func ErrorReportingMiddleware(reporter *errreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered != http.ErrAbortHandler && !brokenPipe(recovered) {
+				event := requestEvent(c, errreport.KindPanic, http.StatusInternalServerError)
+				event.Exception = "panic"
+				event.Message = fmt.Sprint(recovered)
+				event.Stack = errreport.Stack(1)
+				reporter.Capture(event)
+			}
+			panic(recovered)
+		}()
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError || !reporter.SampleResponse() {
+			return
+		}
+		event := requestEvent(c, errreport.KindResponse, status)
+		event.Exception = http.StatusText(status)
+		event.Message = fmt.Sprintf("%d %s on %s", status, http.StatusText(status), event.Tags["route"])
+		if err := c.Errors.Last(); err != nil {
+			event.Message = err.Error()
+		}
+		// Issues are grouped by route and status, whatever the errors say
+		event.Fingerprint = []string{errreport.KindResponse, event.Tags["route"], strconv.Itoa(status)}
+		reporter.Capture(event)
+	}
+}
+
+// requestEvent returns an event with the context of a request
+func requestEvent(c *gin.Context, kind string, status int) errreport.Event {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	tags := map[string]string{
+		"route":      route,
+		"status":     strconv.Itoa(status),
+		"request_id": RequestID(c),
+	}
+	if tenant := Tenant(c); tenant != "" {
+		tags["tenant_id"] = tenant
+	}
+	if region := Region(c); region != "" {
+		tags["region"] = region
+	}
+	if partner := c.GetString("partner_id"); partner != "" {
+		tags["partner_id"] = partner
+	}
+	if traceID, _, ok := TraceContext(c.GetHeader(TraceParentHeader)); ok {
+		tags["trace_id"] = traceID
+	}
+
+	headers := make(map[string]string, len(reportedHeaders))
+	for _, name := range reportedHeaders {
+		if value := c.GetHeader(name); value != "" {
+			headers[name] = value
+		}
+	}
+	return errreport.Event{
+		Kind:  kind,
+		Level: errreport.LevelError,
+		Request: &errreport.Request{
+			// The query is left out, as it may carry tokens
+			Method:  c.Request.Method,
+			URL:     c.Request.URL.Path,
+			Headers: headers,
+		},
+		UserID: c.GetString("user_id"),
+		Tags:   tags,
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/faults"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// FaultInjectionMiddleware injects the faults of the route rules into a share of the
+// requests: the latency is waited out, then the request fails with the rule's status or
+// has its connection cut, as the real failures would reach clients. Requests abandoned by
+// the client while delayed are not processed further.
+func FaultInjectionMiddleware(injector *faults.Injector, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			c.Next()
+			return
+		}
+		fault, ok := injector.Route(c.Request.Method, route)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"route":      route,
+			"kind":       fault.Kind(),
+			"latency":    fault.Latency.String(),
+			"request_id": RequestID(c),
+		}).Info("Injecting fault")
+
+		if fault.Latency > 0 {
+			timer := time.NewTimer(fault.Latency)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+
+		switch {
+		case fault.Reset:
+			// net/http closes the connection, or resets the stream of HTTP/2 requests,
+			// without answering
+			panic(http.ErrAbortHandler)
+		case fault.Status != 0:
+			injected := *errs.ErrFaultInjected.WithRequestID(RequestID(c))
+			injected.Status = fault.Status
+			c.AbortWithStatusJSON(injected.Status, &injected)
+		default:
+			c.Next()
+		}
+	}
+}
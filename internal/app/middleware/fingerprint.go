@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/fingerprint"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fingerprintKey is the context key of the fingerprint of a request's client
+const fingerprintKey = "fingerprint"
+
+// maxProxyFingerprintLength bounds the fingerprints taken from a proxy header
+const maxProxyFingerprintLength = 64
+
+// FingerprintMiddleware fingerprints the client of every request: by the TLS handshake of
+// its connection, or the hash the proxy terminating TLS sent in cfg.TLSHeader, and by the
+// shape of its headers. The fingerprint is <tls>:<http>, or <http> alone without a TLS one.
+func FingerprintMiddleware(store *fingerprint.Store, cfg config.FingerprintConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tlsFingerprint string
+		if store != nil {
+			tlsFingerprint, _ = store.Lookup(c.Request)
+		}
+		if tlsFingerprint == "" && cfg.TLSHeader != "" {
+			tlsFingerprint = proxyFingerprint(c.GetHeader(cfg.TLSHeader))
+		}
+
+		id := fingerprint.HTTP(c.Request)
+		if tlsFingerprint != "" {
+			id = tlsFingerprint + ":" + id
+		}
+		c.Set(fingerprintKey, id)
+		c.Next()
+	}
+}
+
+// Fingerprint returns the fingerprint of the client of a request, empty when clients are
+// not fingerprinted
+func Fingerprint(c *gin.Context) string {
+	return c.GetString(fingerprintKey)
+}
+
+// proxyFingerprint returns the hash a proxy sent, or empty when it is not a hex hash
+func proxyFingerprint(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || len(value) > maxProxyFingerprintLength {
+		return ""
+	}
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return ""
+		}
+	}
+	return value
+}
+
+// FingerprintKeyFunc keys rate limits by client fingerprint on the routes matching the
+// patterns, which are written as in load shedding classes; other requests are not limited
+func FingerprintKeyFunc(routes []string) ClientKeyFunc {
+	patterns := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		patterns[route] = true
+	}
+	return func(c *gin.Context) (string, bool) {
+		id := Fingerprint(c)
+		if id == "" {
+			return "", false
+		}
+		if !patterns[c.Request.Method+" "+c.FullPath()] && !patterns[c.FullPath()] {
+			return "", false
+		}
+		return "fp:" + id, true
+	}
+}
+
+// AccessLogFormatter formats access log lines as Gin's default formatter does, followed
+// by the fingerprint of the client when it has one
+func AccessLogFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	var suffix string
+	if id, _ := param.Keys[fingerprintKey].(string); id != "" {
+		suffix = " | fp=" + id
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v%s\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		suffix,
+		param.ErrorMessage,
+	)
+}
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apigw/internal/app/bodylog"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	dtov2 "apigw/internal/app/domains/dto/v2"
+	"apigw/internal/app/validation"
+	"apigw/pkg/utils/clock"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// fuzzDeadline bounds the handling of one fuzzed request; longer means the gateway hangs
+const fuzzDeadline = 2 * time.Second
+
+// FuzzBearerToken checks the Authorization header parser only accepts "Bearer <token>"
+// headers and returns their token unchanged
+func FuzzBearerToken(f *testing.F) {
+	for _, seed := range []string{
+		"Bearer eyJhbGciOiJIUzI1NiJ9.e30.sig",
+		"Bearer ",
+		"Bearer",
+		"bearer token",
+		"Basic dXNlcjpwYXNz",
+		"Bearer  token",
+		"Bearer token extra",
+		"",
+		"\x00Bearer token",
+		"Bearer \xff\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, header string) {
+		token, ok := BearerToken(header)
+		if ok != strings.HasPrefix(header, "Bearer ") {
+			t.Fatalf("BearerToken(%q) ok = %v", header, ok)
+		}
+		if ok && "Bearer "+token != header {
+			t.Fatalf("BearerToken(%q) = %q, not the rest of the header", header, token)
+		}
+		if !ok && token != "" {
+			t.Fatalf("BearerToken(%q) returned %q for a rejected header", header, token)
+		}
+	})
+}
+
+// bindingTargets are the request bodies clients send, by route of the fuzzed engine
+var bindingTargets = map[string]func() interface{}{
+	"register":          func() interface{} { return &dto.RegisterReq{} },
+	"login":             func() interface{} { return &dto.LoginReq{} },
+	"refresh":           func() interface{} { return &dto.RefreshTokenReq{} },
+	"update-profile":    func() interface{} { return &dto.UpdateProfileReq{} },
+	"change-password":   func() interface{} { return &dto.ChangePasswordReq{} },
+	"email":             func() interface{} { return &dto.EmailReq{} },
+	"verify-email":      func() interface{} { return &dto.VerifyEmailReq{} },
+	"reset-password":    func() interface{} { return &dto.ResetPasswordReq{} },
+	"purchase":          func() interface{} { return &dto.PurchaseTicketReq{} },
+	"purchase-batch":    func() interface{} { return &dto.PurchaseBatchReq{} },
+	"cancel-order":      func() interface{} { return &dto.CancelOrderReq{} },
+	"create-payment":    func() interface{} { return &dto.CreatePaymentReq{} },
+	"confirm-payment":   func() interface{} { return &dto.ConfirmPaymentReq{} },
+	"create-event":      func() interface{} { return &dto.CreateEventReq{} },
+	"update-event":      func() interface{} { return &dto.UpdateEventReq{} },
+	"close-event":       func() interface{} { return &dto.CloseEventReq{} },
+	"adjust-inventory":  func() interface{} { return &dto.AdjustInventoryReq{} },
+	"force-cancel":      func() interface{} { return &dto.ForceCancelOrderReq{} },
+	"block-network":     func() interface{} { return &dto.BlockNetworkReq{} },
+	"enable-body-log":   func() interface{} { return &dto.EnableBodyLoggingReq{} },
+	"open-waiting-room": func() interface{} { return &dto.OpenWaitingRoomReq{} },
+	"create-webhook":    func() interface{} { return &dto.CreateWebhookReq{} },
+	"update-webhook":    func() interface{} { return &dto.UpdateWebhookReq{} },
+	"v2-register":       func() interface{} { return &dtov2.RegisterReq{} },
+	"v2-login":          func() interface{} { return &dtov2.LoginReq{} },
+	"v2-refresh":        func() interface{} { return &dtov2.RefreshTokenReq{} },
+}
+
+// bindingEngine binds the body of POST /<target> to the request of the target as handlers
+// do: behind the body limits, with validation failures rendered by BindingErrorHandler
+func bindingEngine(f *testing.F) *gin.Engine {
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		f.Fatal(err)
+	}
+	if err := validation.Setup(cfg.Validation); err != nil {
+		f.Fatal(err)
+	}
+	logger := benchmarkLogger()
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(BodyLimitMiddleware(cfg.Server.HTTP.RequestBody, logger))
+	for name, target := range bindingTargets {
+		target := target
+		engine.POST("/"+name, func(c *gin.Context) {
+			req := target()
+			if err := c.ShouldBindJSON(req); err != nil {
+				BindingErrorHandler(c, err, "INVALID_REQUEST", "Invalid request body", logger)
+				return
+			}
+			c.Status(http.StatusNoContent)
+		})
+	}
+	return engine
+}
+
+// FuzzBindJSON checks malformed request bodies are answered with 400 or 413 rather than
+// panicking or hanging the handler
+func FuzzBindJSON(f *testing.F) {
+	engine := bindingEngine(f)
+
+	for _, seed := range []string{
+		`{"username":"ada","email":"ada@example.com","password":"Analytical1"}`,
+		`{"eventId":"evt_1001","quantity":2}`,
+		`{"items":[{"eventId":"evt_1001","quantity":1},{"eventId":"evt_1002","quantity":1}]}`,
+		`{"eventId":"evt_1001","quantity":-1}`,
+		`{"eventId":"evt_1001","quantity":1e400}`,
+		`{"eventId":["evt_1001"],"quantity":"2"}`,
+		`{"items":[null,{}]}`,
+		`{"email":"\ud800@example.com","password":"\u0000\u0000\u0000\u0000\u0000\u0000"}`,
+		`{"username":"` + strings.Repeat("a", 4096) + `"}`,
+		strings.Repeat(`{"a":`, 64) + `1` + strings.Repeat(`}`, 64),
+		strings.Repeat(`[`, 100000),
+		`{"eventId":"evt_1001"`,
+		`{"eventId":"evt_1001"}{"eventId":"evt_1002"}`,
+		`null`,
+		`[]`,
+		`"string"`,
+		``,
+	} {
+		for name := range bindingTargets {
+			f.Add(name, []byte(seed))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, target string, body []byte) {
+		if _, ok := bindingTargets[target]; !ok {
+			return
+		}
+		req := httptest.NewRequest(http.MethodPost, "/"+target, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			engine.ServeHTTP(rec, req)
+		}()
+		select {
+		case <-done:
+		case <-time.After(fuzzDeadline):
+			t.Fatalf("POST /%s with %q did not complete within %s", target, body, fuzzDeadline)
+		}
+
+		switch rec.Code {
+		case http.StatusNoContent, http.StatusRequestEntityTooLarge:
+		case http.StatusBadRequest:
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Fatalf("POST /%s with %q answered 400 with an invalid body: %s", target, body, rec.Body)
+			}
+		default:
+			t.Fatalf("POST /%s with %q answered %d: %s", target, body, rec.Code, rec.Body)
+		}
+	})
+}
+
+// loggedCredential is the password of the bodies FuzzBodyLogging logs
+const loggedCredential = "hunter2-credential"
+
+// FuzzBodyLogging checks the credentials of logged bodies never reach the log, whatever
+// the rest of the body, and that handlers read bodies whole
+func FuzzBodyLogging(f *testing.F) {
+	for _, seed := range []string{
+		`1`,
+		`{"note":"window seat"}`,
+		`{"password":"other"}`,
+		`[{"api_key":"k"},{"Passw0rd":"p"}]`,
+		`{"a":` + strings.Repeat(`[`, 64) + strings.Repeat(`]`, 64) + `}`,
+		`"` + strings.Repeat("x", 8192) + `"`,
+		`1,"password":"again"`,
+		`}`,
+		``,
+	} {
+		f.Add([]byte(seed), "application/json")
+	}
+	f.Add([]byte(`1&password=again`), "application/x-www-form-urlencoded")
+	f.Add([]byte(`1`), "application/problem+json; charset=utf-8")
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		f.Fatal(err)
+	}
+	cfg.Log.Bodies.Enabled = true
+	cfg.Log.Bodies.Routes = []string{"POST /echo"}
+
+	f.Fuzz(func(t *testing.T, data []byte, contentType string) {
+		if bytes.Contains(data, []byte(loggedCredential)) {
+			return
+		}
+		body := `{"password":"` + loggedCredential + `","data":` + string(data) + `}`
+		if strings.HasPrefix(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
+			body = "password=" + loggedCredential + "&data=" + string(data)
+		}
+
+		var out bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&out)
+		gin.SetMode(gin.ReleaseMode)
+		engine := gin.New()
+		engine.Use(func(c *gin.Context) {
+			c.Set(logutils.ContextKey, logrus.NewEntry(logger))
+		}, BodyLoggingMiddleware(cfg.Log.Bodies, bodylog.NewSwitch(clock.System)))
+		var read []byte
+		engine.POST("/echo", func(c *gin.Context) {
+			read, _ = io.ReadAll(c.Request.Body)
+			c.Data(http.StatusOK, contentType, read)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+
+		if string(read) != body {
+			t.Fatalf("handler read %q, want %q", read, body)
+		}
+		if !strings.Contains(out.String(), "Request and response bodies") {
+			t.Fatalf("bodies not logged: %s", out.String())
+		}
+		if strings.Contains(out.String(), loggedCredential) {
+			t.Fatalf("credential logged for %s body %q: %s", contentType, body, out.String())
+		}
+	})
+}
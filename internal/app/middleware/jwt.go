@@ -1,71 +1,109 @@
 package middleware
 
 import (
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/client"
 	"apigw/pkg/utils/crypt/token"
+	logutils "apigw/pkg/utils/log"
+	"apigw/pkg/utils/timing"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
 )
 
 // JWTMiddleware creates JWT authentication middleware
 func JWTMiddleware(
 	jwtMaker *token.JWTMaker,
 	logger *logrus.Logger) gin.HandlerFunc {
+	authenticate := jwtAuthenticator(jwtMaker, logger)
 	return func(c *gin.Context) {
-		// Skip authentication for certain paths
-		if shouldSkipAuth(c.Request.URL.Path) {
+		if httpErr := authenticate(c); httpErr != nil {
+			c.JSON(httpErr.Status, httpErr)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GRPCWebJWTMiddleware is the JWT authentication middleware of the gRPC-Web routes.
+// Failures are answered trailers-only with grpc-status 16 (Unauthenticated), which
+// gRPC-Web clients can parse, instead of the JSON error body.
+func GRPCWebJWTMiddleware(jwtMaker *token.JWTMaker, logger *logrus.Logger) gin.HandlerFunc {
+	authenticate := jwtAuthenticator(jwtMaker, logger)
+	return func(c *gin.Context) {
+		httpErr := authenticate(c)
+		if httpErr == nil {
 			c.Next()
 			return
 		}
 
+		contentType := "application/grpc-web+proto"
+		if strings.HasPrefix(c.GetHeader("Content-Type"), "application/grpc-web-text") {
+			contentType = "application/grpc-web-text+proto"
+		}
+		c.Header("grpc-status", strconv.Itoa(int(codes.Unauthenticated)))
+		c.Header("grpc-message", url.PathEscape(httpErr.Message))
+		c.Data(http.StatusOK, contentType, nil)
+		c.Abort()
+	}
+}
+
+// jwtAuthenticator returns a function verifying the bearer token of a request and
+// setting its user, or returning the error to answer it with
+func jwtAuthenticator(jwtMaker *token.JWTMaker, logger *logrus.Logger) func(c *gin.Context) *errs.HTTPError {
+	logger = logutils.ForModule(logger, logutils.ModuleAuth)
+	return func(c *gin.Context) *errs.HTTPError {
+		// Skip authentication for certain paths
+		if shouldSkipAuth(c.Request.URL.Path) {
+			return nil
+		}
+
 		// Extract token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			logger.Error("Authorization header missing")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "AUTHENTICATION_ERROR",
-				"code":    "MISSING_TOKEN",
-				"message": "Authorization header is required",
-			})
-			c.Abort()
-			return
+			return errs.ErrMissingToken
 		}
 
-		// Check if token starts with "Bearer "
-		if !strings.HasPrefix(authHeader, "Bearer ") {
+		// Extract the token of a "Bearer <token>" header
+		token, ok := BearerToken(authHeader)
+		if !ok {
 			logger.Error("Invalid authorization header format")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "AUTHENTICATION_ERROR",
-				"code":    "INVALID_TOKEN_FORMAT",
-				"message": "Token must be in format: Bearer <token>",
-			})
-			c.Abort()
-			return
+			return errs.ErrInvalidTokenFormat
 		}
 
-		// Extract token
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-
 		// Validate token
+		start := time.Now()
 		user, err := jwtMaker.VerifyToken(token)
+		timing.FromContext(c.Request.Context()).Since(timing.Auth, start)
 		if err != nil {
 			logger.WithError(err).Error("Token validation failed")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "AUTHENTICATION_ERROR",
-				"code":    "INVALID_TOKEN",
-				"message": "Invalid or expired token",
-			})
-			c.Abort()
-			return
+			return errs.ErrInvalidToken
 		}
 
 		// Set user information in context
 		c.Set("user_id", user.UserID)
+		c.Set("user_role", user.Role)
 
-		c.Next()
+		// Route the user's upstream calls consistently when sticky routing is enabled
+		c.Request = c.Request.WithContext(client.WithRoutingKey(c.Request.Context(), user.UserID))
+		return nil
+	}
+}
+
+// BearerToken returns the token of an Authorization header in the "Bearer <token>" format
+func BearerToken(header string) (string, bool) {
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
 	}
+	return strings.TrimPrefix(header, "Bearer "), true
 }
 
 // shouldSkipAuth checks if authentication should be skipped for the given path
@@ -75,6 +113,12 @@ func shouldSkipAuth(path string) bool {
 		"/api/v1/users/register",
 		"/api/v1/users/login",
 		"/api/v1/users/refresh",
+		"/api/v2/users/register",
+		"/api/v2/users/login",
+		"/api/v2/users/refresh",
+		"/grpc/user.UserService/Register",
+		"/grpc/user.UserService/Login",
+		"/grpc/user.UserService/RefreshToken",
 	}
 
 	for _, skipPath := range skipPaths {
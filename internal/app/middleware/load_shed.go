@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// priorityClass is a priority class with its limit resolved to a number of requests
+type priorityClass struct {
+	name  string
+	limit int64
+}
+
+// LoadSheddingMiddleware sorts requests into the configured priority classes and turns
+// a request away with 503 when the requests in flight exceed the limit of its class.
+// Classes with lower limits are shed first as load rises, so checkout keeps being served
+// while browsing is refused. inFlight reports the requests being served, this one
+// included.
+func LoadSheddingMiddleware(cfg config.LoadSheddingConfig, inFlight func() int64, logger *logrus.Logger) gin.HandlerFunc {
+	classes := make(map[string]*priorityClass, len(cfg.Classes))
+	routes := make(map[string]*priorityClass)
+	for _, cc := range cfg.Classes {
+		class := &priorityClass{name: cc.Name, limit: int64(cfg.MaxInFlight) * int64(cc.Limit) / 100}
+		if class.limit < 1 {
+			class.limit = 1
+		}
+		classes[cc.Name] = class
+		for _, route := range cc.Routes {
+			routes[route] = class
+		}
+	}
+	fallback := classes[cfg.DefaultClass]
+	retryAfter := strconv.Itoa(errs.RetryAfterSeconds(cfg.RetryAfter))
+	// Shed requests are logged with the structured logger, as floods of them are expected
+	structured := logutils.Structured(logger)
+
+	return func(c *gin.Context) {
+		class, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			class, ok = routes[c.FullPath()]
+		}
+		if !ok {
+			class = fallback
+		}
+		if class == nil {
+			c.Next()
+			return
+		}
+
+		if load := inFlight(); load > class.limit {
+			metrics.PriorityRequests.WithLabelValues(class.name, "shed").Inc()
+			structured.LogAttrs(c.Request.Context(), logutils.LevelWarn, "Request shed under load",
+				logutils.String("class", class.name),
+				logutils.Int64("in_flight", load),
+				logutils.Int64("limit", class.limit),
+				logutils.String("method", c.Request.Method),
+				logutils.String("path", c.Request.URL.Path),
+				logutils.String("request_id", RequestID(c)),
+			)
+			if cfg.RetryAfter > 0 {
+				c.Header("Retry-After", retryAfter)
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, errs.ErrOverloaded)
+			return
+		}
+
+		metrics.PriorityRequests.WithLabelValues(class.name, "admitted").Inc()
+		gauge := metrics.PriorityRequestsInFlight.WithLabelValues(class.name)
+		gauge.Inc()
+		defer gauge.Dec()
+		c.Next()
+	}
+}
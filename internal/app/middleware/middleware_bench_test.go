@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"apigw/pkg/utils/crypt/token"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// benchmarkLogger discards the logs of the middleware under benchmark
+func benchmarkLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// serveBenchmark runs requests from newRequest through an engine ending in an empty handler
+func serveBenchmark(b *testing.B, middleware gin.HandlerFunc, newRequest func() *http.Request) {
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(middleware)
+	engine.Any("/*path", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, newRequest())
+			if w.Code != http.StatusNoContent {
+				b.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+		}
+	})
+}
+
+func BenchmarkTokenBucketMiddleware(b *testing.B) {
+	server := miniredis.RunT(b)
+	redisClient := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	b.Cleanup(func() { redisClient.Close() })
+
+	limiter := CreateCustomTokenBucketMiddleware(TokenBucketStore{Redis: redisClient}, 1<<30, 1000, time.Second, benchmarkLogger())
+	serveBenchmark(b, limiter, func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+		req.RemoteAddr = "203.0.113.7:40000"
+		return req
+	})
+}
+
+func BenchmarkJWTMiddleware(b *testing.B) {
+	maker, err := token.NewJWTTokenMaker("benchmark-secret-key-of-at-least-32-chars")
+	if err != nil {
+		b.Fatal(err)
+	}
+	bearer, err := maker.CreateToken("bench-user", "", time.Hour)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	serveBenchmark(b, JWTMiddleware(maker, benchmarkLogger()), func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		return req
+	})
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strconv"
+	"strings"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/pkg/utils/codec"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ProblemDetailsMiddleware renders error responses as RFC 7807 problem details, for every
+// client when errors.format is problem and otherwise for clients listing
+// application/problem+json in their Accept header. Only responses of 400 and above are held
+// back, so successful and streamed responses are written as they are produced. It must run
+// ahead of the recovery middleware so that the errors written for panics are rendered too.
+func ProblemDetailsMiddleware(cfg config.ErrorsConfig, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Format != config.ErrorFormatProblem {
+			c.Writer.Header().Add("Vary", "Accept")
+			if !acceptsProblem(c.GetHeader("Accept")) {
+				c.Next()
+				return
+			}
+		}
+
+		writer := &errorResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		if !writer.held {
+			return
+		}
+
+		body := writer.body.Bytes()
+		var httpErr errs.HTTPError
+		if !isJSONContent(c.Writer.Header().Get("Content-Type")) || json.Unmarshal(body, &httpErr) != nil || httpErr.Code == "" {
+			// Not one of the gateway's errors, e.g. a proxied body
+			c.Writer.Write(body)
+			return
+		}
+		httpErr.Status = c.Writer.Status()
+		problem := httpErr.Problem(cfg.ProblemTypeBase, c.Request.URL.Path)
+		if problem.RequestID == "" {
+			problem.RequestID = RequestID(c)
+		}
+		rendered, err := codec.Marshal(problem)
+		if err != nil {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+			}).Error("Failed to render error as problem details")
+			c.Writer.Write(body)
+			return
+		}
+		header := c.Writer.Header()
+		header.Set("Content-Type", errs.ProblemContentType)
+		header.Del("Content-Length")
+		c.Writer.Write(rendered)
+	}
+}
+
+// acceptsProblem reports whether an Accept header explicitly lists problem details;
+// wildcards do not count, so clients keep the JSON errors they know unless they ask
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != errs.ProblemContentType {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+// errorResponseWriter holds back the body of error responses so it can be rendered as
+// problem details, and writes other responses through
+type errorResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	held bool
+}
+
+// Write buffers the body of error responses
+func (w *errorResponseWriter) Write(data []byte) (int, error) {
+	if w.holds() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString buffers the body of error responses
+func (w *errorResponseWriter) WriteString(s string) (int, error) {
+	if w.holds() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// holds reports whether the body is held back, deciding it on the first write
+func (w *errorResponseWriter) holds() bool {
+	if !w.held && !w.Written() && w.Status() >= 400 {
+		w.held = true
+	}
+	return w.held
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/quota"
+	"apigw/pkg/utils/crypt/token"
+	logutils "apigw/pkg/utils/log"
+	"apigw/pkg/utils/timing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// quotaHeaders are the headers telling clients the calls left in each capped window
+var quotaHeaders = map[string]string{
+	quota.WindowDaily:   "X-Quota-Daily-Remaining",
+	quota.WindowMonthly: "X-Quota-Monthly-Remaining",
+}
+
+// QuotaMiddleware counts the calls of each client against its daily and monthly quotas
+// and answers 429 once a quota is used up, until its window rolls over. Clients are the
+// partner of a signed request, then the user of a valid bearer token, then the client
+// address, so it must run after the signature middleware. Calls to the usage route are
+// not counted, and calls are let through when the quotas cannot be read.
+func QuotaMiddleware(tracker *quota.Tracker, tokens *token.JWTMaker, logger *logrus.Logger) gin.HandlerFunc {
+	logger = logutils.ForModule(logger, logutils.ModuleRateLimiter)
+	return func(c *gin.Context) {
+		client := quotaClient(c, tokens)
+		c.Set("quota_client", client)
+		if c.FullPath() == config.UsagePath {
+			c.Next()
+			return
+		}
+
+		kind, _, _ := strings.Cut(client, ":")
+		start := time.Now()
+		usage, exceeded, err := tracker.Consume(c.Request.Context(), client)
+		timing.FromContext(c.Request.Context()).Since(timing.RateLimit, start)
+		if err != nil {
+			metrics.QuotaChecks.WithLabelValues(kind, "error").Inc()
+			logger.WithError(err).WithField("quota_client", client).Error("Quota check failed")
+			c.Next()
+			return
+		}
+
+		if exceeded == nil {
+			metrics.QuotaChecks.WithLabelValues(kind, "allowed").Inc()
+			for _, u := range usage {
+				if u.Limit > 0 {
+					c.Header(quotaHeaders[u.Window], strconv.FormatInt(u.Remaining(), 10))
+				}
+			}
+			c.Next()
+			return
+		}
+
+		metrics.QuotaChecks.WithLabelValues(kind, exceeded.Window+"_exceeded").Inc()
+		httpErr := *errs.ErrDailyQuotaExceeded
+		if exceeded.Window == quota.WindowMonthly {
+			httpErr = *errs.ErrMonthlyQuotaExceeded
+		}
+		httpErr.RetryAfter = time.Until(exceeded.ResetsAt)
+		httpErr.Details = errs.QuotaDetails{
+			Violations: []errs.QuotaViolation{{
+				Subject:     client,
+				Description: fmt.Sprintf("%d calls per %s window, resetting at %s", exceeded.Limit, exceeded.Window, exceeded.ResetsAt.Format(time.RFC3339)),
+			}},
+			RetryAfter: errs.RetryAfterSeconds(httpErr.RetryAfter),
+		}
+
+		logger.WithFields(logrus.Fields{
+			"quota_client": client,
+			"window":       exceeded.Window,
+			"limit":        exceeded.Limit,
+			"path":         c.Request.URL.Path,
+			"request_id":   RequestID(c),
+		}).Warn("Client quota exceeded")
+		writeRetryAfter(c, &httpErr)
+		c.AbortWithStatusJSON(httpErr.Status, &httpErr)
+	}
+}
+
+// QuotaClient returns the client the quotas of a request are counted against
+func QuotaClient(c *gin.Context) string {
+	return c.GetString("quota_client")
+}
+
+// quotaClient identifies the client of a request for its quotas
+func quotaClient(c *gin.Context, tokens *token.JWTMaker) string {
+	if partnerID := c.GetString("partner_id"); partnerID != "" {
+		return "partner:" + partnerID
+	}
+	if tokens != nil {
+		if bearer, ok := BearerToken(c.GetHeader("Authorization")); ok {
+			if payload, err := tokens.VerifyToken(bearer); err == nil {
+				return "user:" + payload.UserID
+			}
+		}
+	}
+	return "ip:" + ClientIP(c)
+}
@@ -1,12 +1,24 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/limiter"
+	"apigw/pkg/utils/codec"
+	"apigw/pkg/utils/log"
+	"apigw/pkg/utils/pool"
+	"apigw/pkg/utils/timing"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
@@ -14,13 +26,28 @@ import (
 
 // TokenBucketConfig holds token bucket rate limiter configuration
 type TokenBucketConfig struct {
-	RedisClient    *redis.Client
+	RedisClient redis.UniversalClient
+	// ReadClient reads the buckets, from the replicas of RedisClient; nil reads from
+	// RedisClient. Replicas lagging behind may let a client take a few tokens more than
+	// its capacity during a burst, in exchange for the load taken off the primary.
+	ReadClient redis.UniversalClient
+	// Watchdog holds the mode of the limiter as Redis goes down and recovers; nil always
+	// limits through Redis
+	Watchdog       *limiter.Watchdog
 	Capacity       int           // Maximum number of tokens in the bucket
 	RefillRate     float64       // Tokens per second
 	RefillInterval time.Duration // How often to refill tokens
 	Logger         *logrus.Logger
+	// Name namespaces the buckets of a dedicated limiter policy
+	Name string
+	// KeyFunc overrides the client identifier; requests without a key are not limited,
+	// unless the key func aborted them
+	KeyFunc func(c *gin.Context) (string, bool)
 }
 
+// ClientKeyFunc extracts a rate limiting key from a request
+type ClientKeyFunc = func(c *gin.Context) (string, bool)
+
 // TokenBucketInfo represents token bucket information
 type TokenBucketInfo struct {
 	RemainingTokens int           `json:"remaining_tokens"`
@@ -30,15 +57,43 @@ type TokenBucketInfo struct {
 	RefillInterval  time.Duration `json:"refill_interval"`
 }
 
+// tokenBucketInfoPool reuses the bucket state reported by every rate limit check
+var tokenBucketInfoPool = pool.New(
+	func() *TokenBucketInfo { return new(TokenBucketInfo) },
+	func(info *TokenBucketInfo) bool {
+		*info = TokenBucketInfo{}
+		return true
+	},
+)
+
+// newTokenBucketInfo returns pooled bucket information about the limiter's bucket
+func (tb *TokenBucket) newTokenBucketInfo(remaining int, nextRefill time.Time) *TokenBucketInfo {
+	info := tokenBucketInfoPool.Get()
+	info.RemainingTokens = remaining
+	info.NextRefill = nextRefill
+	info.Capacity = tb.config.Capacity
+	info.RefillRate = tb.config.RefillRate
+	info.RefillInterval = tb.config.RefillInterval
+	return info
+}
+
 // TokenBucket represents a Redis-based token bucket rate limiter
 type TokenBucket struct {
 	config *TokenBucketConfig
+	// structured logs the requests turned away, by the thousand during floods
+	structured *log.Logger
 }
 
-// NewTokenBucket creates a new token bucket rate limiter instance
+// NewTokenBucket creates a new token bucket rate limiter instance, logging through the
+// logger of the rate limiter module
 func NewTokenBucket(config *TokenBucketConfig) *TokenBucket {
+	config.Logger = log.ForModule(config.Logger, log.ModuleRateLimiter)
+	if config.ReadClient == config.RedisClient {
+		config.ReadClient = nil
+	}
 	return &TokenBucket{
-		config: config,
+		config:     config,
+		structured: log.Structured(config.Logger),
 	}
 }
 
@@ -47,34 +102,57 @@ func (tb *TokenBucket) TokenBucketMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get client identifier (IP address or user ID)
 		clientID := tb.getClientIdentifier(c)
+		if tb.config.KeyFunc != nil {
+			key, ok := tb.config.KeyFunc(c)
+			if !ok {
+				if !c.IsAborted() {
+					c.Next()
+				}
+				return
+			}
+			clientID = key
+		}
 
 		// Check rate limit using token bucket
+		start := time.Now()
 		allowed, info, err := tb.checkTokenBucket(c.Request.Context(), clientID)
+		timing.FromContext(c.Request.Context()).Since(timing.RateLimit, start)
 		if err != nil {
 			tb.config.Logger.WithError(err).Error("Token bucket rate limit check failed")
 			// On Redis error, allow the request but log the error
 			c.Next()
 			return
 		}
+		defer tokenBucketInfoPool.Put(info)
 
 		// Set rate limit headers
 		c.Header("X-RateLimit-Limit", strconv.Itoa(info.Capacity))
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(info.RemainingTokens))
 		c.Header("X-RateLimit-Reset", strconv.FormatInt(info.NextRefill.Unix(), 10))
-		c.Header("X-RateLimit-RefillRate", fmt.Sprintf("%.2f", info.RefillRate))
+		c.Header("X-RateLimit-RefillRate", strconv.FormatFloat(info.RefillRate, 'f', 2, 64))
 
 		if !allowed {
-			tb.config.Logger.WithFields(logrus.Fields{
-				"client_id":        clientID,
-				"remaining_tokens": info.RemainingTokens,
-				"capacity":         info.Capacity,
-				"next_refill":      info.NextRefill,
-			}).Warn("Token bucket rate limit exceeded")
+			if ctx := c.Request.Context(); tb.structured.Enabled(ctx, log.LevelWarn) {
+				attrs := make([]log.Attr, 0, 6)
+				attrs = append(attrs,
+					log.String("client_id", clientID),
+					log.Int("remaining_tokens", info.RemainingTokens),
+					log.Int("capacity", info.Capacity),
+					log.Time("next_refill", info.NextRefill),
+				)
+				if tenant := Tenant(c); tenant != "" {
+					attrs = append(attrs, log.String("tenant_id", tenant))
+				}
+				if fingerprint := Fingerprint(c); fingerprint != "" {
+					attrs = append(attrs, log.String("fingerprint", fingerprint))
+				}
+				tb.structured.LogAttrs(ctx, log.LevelWarn, "Token bucket rate limit exceeded", attrs...)
+			}
 
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "RATE_LIMIT_ERROR",
-				"code":    "RATE_LIMIT_EXCEEDED",
-				"message": "Rate limit exceeded. Please try again later.",
+				"error":   errs.ErrRateLimitExceeded.ErrorType,
+				"code":    errs.ErrRateLimitExceeded.Code,
+				"message": errs.ErrRateLimitExceeded.Message,
 				"details": gin.H{
 					"remaining_tokens": info.RemainingTokens,
 					"next_refill":      info.NextRefill,
@@ -90,37 +168,55 @@ func (tb *TokenBucket) TokenBucketMiddleware() gin.HandlerFunc {
 	}
 }
 
+// Allow takes a token from the bucket of clientID for callers outside the HTTP middleware,
+// such as the gRPC server, returning when the next token is due. Like the middleware,
+// callers should let the request through when the check fails.
+func (tb *TokenBucket) Allow(ctx context.Context, clientID string) (bool, time.Time, error) {
+	allowed, info, err := tb.checkTokenBucket(ctx, clientID)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	defer tokenBucketInfoPool.Put(info)
+	return allowed, info.NextRefill, nil
+}
+
 // checkTokenBucket checks if the request is within rate limits using token bucket algorithm
 func (tb *TokenBucket) checkTokenBucket(ctx context.Context, clientID string) (bool, *TokenBucketInfo, error) {
 	// If Redis client is nil, allow all requests
 	if tb.config.RedisClient == nil {
-		info := &TokenBucketInfo{
-			RemainingTokens: tb.config.Capacity,
-			NextRefill:      time.Now().Add(tb.config.RefillInterval),
-			Capacity:        tb.config.Capacity,
-			RefillRate:      tb.config.RefillRate,
-			RefillInterval:  tb.config.RefillInterval,
-		}
-		return true, info, nil
+		return true, tb.newTokenBucketInfo(tb.config.Capacity, time.Now().Add(tb.config.RefillInterval)), nil
 	}
 
 	now := time.Now()
 
 	// Create keys for this client
-	tokensKey := fmt.Sprintf("token_bucket:tokens:%s", clientID)
-	lastRefillKey := fmt.Sprintf("token_bucket:last_refill:%s", clientID)
-
-	// Use Redis pipeline for atomic operations
-	pipe := tb.config.RedisClient.Pipeline()
-
-	// Get current tokens and last refill time
-	tokensCmd := pipe.Get(ctx, tokensKey)
-	lastRefillCmd := pipe.Get(ctx, lastRefillKey)
+	keyPrefix := "token_bucket"
+	if tb.config.Name != "" {
+		keyPrefix = "token_bucket:" + tb.config.Name
+	}
+	tokensKey := keyPrefix + ":tokens:" + clientID
+
+	// While Redis is down the bucket is kept in memory, or the request let through
+	if tb.config.Watchdog != nil {
+		switch tb.config.Watchdog.Mode() {
+		case limiter.ModeLocal:
+			allowed, remaining := tb.config.Watchdog.Local().Take(tokensKey, tb.config.Capacity, tb.config.RefillRate, now)
+			nextRefill := now.Add(time.Duration(float64(time.Second) * (1.0 / tb.config.RefillRate)))
+			return allowed, tb.newTokenBucketInfo(remaining, nextRefill), nil
+		case limiter.ModeDisabled:
+			return true, tb.newTokenBucketInfo(tb.config.Capacity, now.Add(tb.config.RefillInterval)), nil
+		}
+	}
+	lastRefillKey := keyPrefix + ":last_refill:" + clientID
 
-	// Execute pipeline to get current state
-	_, err := pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		return false, nil, fmt.Errorf("redis pipeline execution failed: %w", err)
+	// Get current tokens and last refill time, from the primary when the replicas fail
+	tokensCmd, lastRefillCmd, err := readTokenBucket(ctx, tb.readClient(), tokensKey, lastRefillKey)
+	if err != nil && tb.config.ReadClient != nil {
+		tb.config.Logger.WithError(err).Debug("Token bucket read from replica failed, reading from the primary")
+		tokensCmd, lastRefillCmd, err = readTokenBucket(ctx, tb.config.RedisClient, tokensKey, lastRefillKey)
+	}
+	if err != nil {
+		return false, nil, err
 	}
 
 	// Parse current tokens
@@ -162,14 +258,7 @@ func (tb *TokenBucket) checkTokenBucket(ctx context.Context, clientID string) (b
 		// No tokens available, calculate next refill time
 		nextRefill := lastRefill.Add(time.Duration(float64(time.Second) * (1.0 / tb.config.RefillRate)))
 
-		info := &TokenBucketInfo{
-			RemainingTokens: 0,
-			NextRefill:      nextRefill,
-			Capacity:        tb.config.Capacity,
-			RefillRate:      tb.config.RefillRate,
-			RefillInterval:  tb.config.RefillInterval,
-		}
-		return false, info, nil
+		return false, tb.newTokenBucketInfo(0, nextRefill), nil
 	}
 
 	// Consume one token
@@ -188,43 +277,64 @@ func (tb *TokenBucket) checkTokenBucket(ctx context.Context, clientID string) (b
 	// Calculate next refill time
 	nextRefill := now.Add(time.Duration(float64(time.Second) * (1.0 / tb.config.RefillRate)))
 
-	info := &TokenBucketInfo{
-		RemainingTokens: newTokens,
-		NextRefill:      nextRefill,
-		Capacity:        tb.config.Capacity,
-		RefillRate:      tb.config.RefillRate,
-		RefillInterval:  tb.config.RefillInterval,
+	return true, tb.newTokenBucketInfo(newTokens, nextRefill), nil
+}
+
+// readClient returns the client the buckets are read from
+func (tb *TokenBucket) readClient() redis.UniversalClient {
+	if tb.config.ReadClient != nil {
+		return tb.config.ReadClient
 	}
+	return tb.config.RedisClient
+}
 
-	return true, info, nil
+// readTokenBucket reads the tokens and last refill time of a bucket in a pipeline
+func readTokenBucket(ctx context.Context, client redis.UniversalClient, tokensKey, lastRefillKey string) (*redis.StringCmd, *redis.StringCmd, error) {
+	pipe := client.Pipeline()
+	tokensCmd := pipe.Get(ctx, tokensKey)
+	lastRefillCmd := pipe.Get(ctx, lastRefillKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, fmt.Errorf("redis pipeline execution failed: %w", err)
+	}
+	return tokensCmd, lastRefillCmd, nil
 }
 
 // getClientIdentifier returns a unique identifier for the client
 func (tb *TokenBucket) getClientIdentifier(c *gin.Context) string {
 	// Try to get user ID from JWT context first
-	if userID, exists := c.Get("user_id"); exists {
-		return fmt.Sprintf("user:%s", userID)
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
 	}
 
 	// Fall back to IP address
-	clientIP := c.ClientIP()
+	clientIP := ClientIP(c)
 	if clientIP == "" {
 		clientIP = "unknown"
 	}
 
-	return fmt.Sprintf("ip:%s", clientIP)
+	return "ip:" + clientIP
+}
+
+// TokenBucketStore is where rate limiters keep their buckets: Redis, read from its
+// replicas when Reads is set, with the mode held by Watchdog when it is set
+type TokenBucketStore struct {
+	Redis    redis.UniversalClient
+	Reads    redis.UniversalClient
+	Watchdog *limiter.Watchdog
 }
 
 // CreateCustomTokenBucketMiddleware creates a token bucket rate limiting middleware with custom configuration
 func CreateCustomTokenBucketMiddleware(
-	redisClient *redis.Client,
+	store TokenBucketStore,
 	capacity int,
 	refillRate float64,
 	refillInterval time.Duration,
 	logger *logrus.Logger,
 ) gin.HandlerFunc {
 	config := &TokenBucketConfig{
-		RedisClient:    redisClient,
+		RedisClient:    store.Redis,
+		ReadClient:     store.Reads,
+		Watchdog:       store.Watchdog,
 		Capacity:       capacity,
 		RefillRate:     refillRate,
 		RefillInterval: refillInterval,
@@ -234,3 +344,94 @@ func CreateCustomTokenBucketMiddleware(
 	limiter := NewTokenBucket(config)
 	return limiter.TokenBucketMiddleware()
 }
+
+// CreatePolicyTokenBucketMiddleware creates a token bucket rate limiting middleware for a
+// named limiter policy whose buckets are kept separate from the global limiter
+func CreatePolicyTokenBucketMiddleware(
+	store TokenBucketStore,
+	name string,
+	policy config.TokenBucketConfig,
+	keyFunc ClientKeyFunc,
+	logger *logrus.Logger,
+) gin.HandlerFunc {
+	limiter := NewTokenBucket(&TokenBucketConfig{
+		RedisClient:    store.Redis,
+		ReadClient:     store.Reads,
+		Watchdog:       store.Watchdog,
+		Capacity:       policy.Capacity,
+		RefillRate:     policy.RefillRate,
+		RefillInterval: policy.RefillInterval,
+		Logger:         logger,
+		Name:           name,
+		KeyFunc:        keyFunc,
+	})
+	return limiter.TokenBucketMiddleware()
+}
+
+// IPKeyFunc keys rate limits by client IP address
+func IPKeyFunc(c *gin.Context) (string, bool) {
+	clientIP := ClientIP(c)
+	if clientIP == "" {
+		clientIP = "unknown"
+	}
+	return "ip:" + clientIP, true
+}
+
+// EmailKeyFunc keys rate limits by the "email" field of a JSON request body,
+// restoring the body so handlers can still bind it. A body that cannot be read or parsed
+// aborts the request: keying it by nothing would let it past the email's limits.
+func EmailKeyFunc(c *gin.Context) (string, bool) {
+	body, err := readKeyBody(c)
+	if err == nil && len(body) == 0 {
+		return "", false
+	}
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err == nil {
+		err = codec.Unmarshal(body, &payload)
+	}
+	if err != nil {
+		httpErr := bodyError(err)
+		c.AbortWithStatusJSON(httpErr.Status, httpErr)
+		return "", false
+	}
+	if payload.Email == "" {
+		return "", false
+	}
+
+	return "email:" + strings.ToLower(strings.TrimSpace(payload.Email)), true
+}
+
+// RequiredEmailKeyFunc keys rate limits by email like EmailKeyFunc, refusing the requests
+// carrying no email with 400 rather than letting them past the email's limits
+func RequiredEmailKeyFunc(c *gin.Context) (string, bool) {
+	key, ok := EmailKeyFunc(c)
+	if !ok && !c.IsAborted() {
+		c.AbortWithStatusJSON(errs.ErrBadRequest.Status, errs.ErrBadRequest)
+	}
+	return key, ok
+}
+
+// readKeyBody reads the whole body to extract a key from it, restoring it so handlers can
+// still bind it; its size is capped by BodyLimitMiddleware
+func readKeyBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// bodyError returns the error answering a request whose body could not be read or parsed
+func bodyError(err error) *errs.HTTPError {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errs.ErrPayloadTooLarge
+	}
+	return errs.ErrBadRequest
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/recording"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordingMiddleware records a share of the requests of the configured routes, with
+// their responses, sanitized. JSON request bodies of a known length up to max_body_bytes
+// are read ahead of the handlers; other bodies are only recorded by size.
+func RecordingMiddleware(recorder *recording.Recorder, cfg config.RecordingConfig) gin.HandlerFunc {
+	routes := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route] = true
+	}
+	sanitizer := recording.NewSanitizer(cfg.RedactHeaders, cfg.RedactFields)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" || (len(routes) > 0 && !routes[route]) || rand.Float64()*100 >= cfg.Percentage {
+			c.Next()
+			return
+		}
+
+		exchange := recording.Exchange{
+			Time:  time.Now(),
+			Route: route,
+			Request: recording.Request{
+				Method: c.Request.Method,
+				Message: recording.Message{
+					Header:   sanitizer.Header(c.Request.Header),
+					BodySize: int(max(c.Request.ContentLength, 0)),
+				},
+			},
+		}
+		exchange.Request.URI, exchange.Request.RedactedFields = sanitizer.URI(c.Request.URL)
+
+		var requestBody []byte
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
+			if c.Request.ContentLength > 0 && c.Request.ContentLength <= int64(cfg.MaxBodyBytes) && isJSONContent(c.ContentType()) {
+				data, err := io.ReadAll(c.Request.Body)
+				// The handlers read what was read, and the read error if there was one
+				c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(data), errorReader{err}), c.Request.Body}
+				if err == nil {
+					requestBody = data
+				}
+			}
+			if requestBody == nil {
+				exchange.Request.BodyOmitted = true
+			}
+		}
+
+		writer := &teeResponseWriter{ResponseWriter: c.Writer, limit: cfg.MaxBodyBytes}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		exchange.RequestID = RequestID(c)
+		exchange.DurationMS = float64(time.Since(exchange.Time).Microseconds()) / 1000
+		if requestBody != nil {
+			body, redacted, ok := sanitizer.Body(requestBody)
+			exchange.Request.Body = body
+			exchange.Request.BodyOmitted = !ok
+			exchange.Request.RedactedFields += redacted
+		}
+
+		exchange.Response = recording.Response{
+			Status: writer.Status(),
+			Message: recording.Message{
+				Header:   sanitizer.Header(writer.Header()),
+				BodySize: max(writer.Size(), 0),
+			},
+		}
+		if writer.Size() > 0 {
+			exchange.Response.BodyOmitted = true
+			if !writer.truncated && isJSONContent(writer.Header().Get("Content-Type")) {
+				if body, redacted, ok := sanitizer.Body(writer.body.Bytes()); ok {
+					exchange.Response.Body = body
+					exchange.Response.RedactedFields = redacted
+					exchange.Response.BodyOmitted = false
+				}
+			}
+		}
+		recorder.Record(exchange)
+	}
+}
+
+// teeResponseWriter keeps a copy of the first limit bytes of the response body
+type teeResponseWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write writes the body and keeps a copy
+func (w *teeResponseWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes the body and keeps a copy
+func (w *teeResponseWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// keep copies written data up to the limit
+func (w *teeResponseWriter) keep(data []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(data) > w.limit {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+// readCloser reads from a reader and closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errorReader returns an error once its data is read, or io.EOF without one
+type errorReader struct{ err error }
+
+func (r errorReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"apigw/internal/app/buildinfo"
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/pkg/utils/codec"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxAlertStackBytes bounds the stack trace sent to the alert webhook
+const maxAlertStackBytes = 8 << 10
+
+// lastAlertAt is when the last panic alert was sent, in Unix nanoseconds. It is shared by
+// every router so rebuilding them on reload does not reset the alert interval.
+var lastAlertAt atomic.Int64
+
+// panicAlert is the JSON body posted to the alert webhook
+type panicAlert struct {
+	Service     string    `json:"service"`
+	Environment string    `json:"environment"`
+	Version     string    `json:"version"`
+	Commit      string    `json:"commit"`
+	Host        string    `json:"host"`
+	RequestID   string    `json:"request_id"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Route       string    `json:"route"`
+	Panic       string    `json:"panic"`
+	Stack       string    `json:"stack"`
+	Time        time.Time `json:"time"`
+}
+
+// RecoveryMiddleware recovers from panics in later handlers. The panic is logged with its
+// stack and the request context, counted in apigw_panics_total, answered with the
+// INTERNAL_ERROR envelope carrying the request ID and, when an alert webhook is
+// configured, reported to it at most once per alert_interval.
+func RecoveryMiddleware(cfg config.RecoveryConfig, app config.AppConfig, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				// Deliberate aborts are handled by net/http, which closes the connection
+				panic(recovered)
+			}
+
+			stack := debug.Stack()
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.Panics.WithLabelValues(route).Inc()
+
+			logger.WithFields(logrus.Fields{
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"route":      route,
+				"ip":         ClientIP(c),
+				"user_id":    c.GetString("user_id"),
+				"tenant_id":  Tenant(c),
+				"request_id": RequestID(c),
+				"panic":      fmt.Sprint(recovered),
+				"stack":      string(stack),
+			}).Error("Panic recovered")
+
+			if cfg.AlertWebhook != "" {
+				alertPanic(cfg, app, c, route, recovered, stack, logger)
+			}
+
+			if brokenPipe(recovered) || c.Writer.Written() {
+				// The client is gone or part of the response was sent; only stop the chain
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(errs.ErrInternalServer.Status, errs.ErrInternalServer.WithRequestID(RequestID(c)))
+		}()
+		c.Next()
+	}
+}
+
+// brokenPipe reports whether a panic was caused by the client closing the connection
+func brokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
+
+// alertPanic posts a panic alert to the webhook in the background, unless one was sent
+// less than alert_interval ago
+func alertPanic(cfg config.RecoveryConfig, app config.AppConfig, c *gin.Context, route string, recovered interface{}, stack []byte, logger *logrus.Logger) {
+	now := time.Now()
+	last := lastAlertAt.Load()
+	if now.UnixNano()-last < cfg.AlertInterval.Nanoseconds() || !lastAlertAt.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	if len(stack) > maxAlertStackBytes {
+		stack = stack[:maxAlertStackBytes]
+	}
+	build := buildinfo.Get()
+	host, _ := os.Hostname()
+	alert := panicAlert{
+		Service:     app.Name,
+		Environment: app.Environment,
+		Version:     build.Version,
+		Commit:      build.Commit,
+		Host:        host,
+		RequestID:   RequestID(c),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		Route:       route,
+		Panic:       fmt.Sprint(recovered),
+		Stack:       string(stack),
+		Time:        now.UTC(),
+	}
+
+	go func() {
+		body, err := codec.Marshal(alert)
+		if err != nil {
+			logger.WithError(err).Error("Failed to encode panic alert")
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.AlertTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.AlertWebhook, bytes.NewReader(body))
+		if err != nil {
+			logger.WithError(err).Error("Failed to create panic alert request")
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			// The URL may embed a token, so it is left out of the logged error
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			logger.WithError(err).Error("Failed to send panic alert")
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			logger.WithField("status", resp.StatusCode).Error("Panic alert webhook rejected the alert")
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/region"
+	"apigw/pkg/utils/crypt/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// regionKey is the context key of the region of a request
+const regionKey = "region"
+
+// RegionMiddleware resolves the region of every request and carries it in the request
+// context, so backend calls are routed to the clusters of the region. The home region of
+// the user is read from a valid bearer token; requests naming an unknown region in the
+// header are rejected with 400.
+func RegionMiddleware(resolver *region.Resolver, tokens *token.JWTMaker, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userRegion string
+		if tokens != nil {
+			if bearer, ok := BearerToken(c.GetHeader("Authorization")); ok {
+				if payload, err := tokens.VerifyToken(bearer); err == nil {
+					userRegion = payload.Region
+				}
+			}
+		}
+
+		id, source, ok := resolver.Resolve(c.Request, userRegion)
+		if !ok {
+			logger.WithFields(logrus.Fields{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"ip":     ClientIP(c),
+				"region": id,
+			}).Warn("Request for an unknown region")
+			c.AbortWithStatusJSON(errs.ErrUnknownRegion.Status, errs.ErrUnknownRegion)
+			return
+		}
+		if id != "" {
+			metrics.RegionRequests.WithLabelValues(id, source).Inc()
+			c.Set(regionKey, id)
+			c.Request = c.Request.WithContext(region.NewContext(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+// Region returns the region of a request, empty when it has none
+func Region(c *gin.Context) string {
+	return c.GetString(regionKey)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request, from the client or a proxy in front of
+// the gateway, and back in the response
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID
+const requestIDKey = "request_id"
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware identifies every request, keeping a well-formed X-Request-ID sent
+// by the client or a proxy and generating one otherwise, and echoes it in the response
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestID returns the ID of a request, or an empty string outside RequestIDMiddleware
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// validRequestID reports whether a client-supplied request ID is short and printable,
+// so it can be logged and echoed safely
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"strings"
+
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TraceParentHeader carries the W3C trace context of a request
+const TraceParentHeader = "traceparent"
+
+// RequestLoggerMiddleware attaches the logger of every request to its context, with the
+// fields identifying it, for handlers to log with logutils.FromContext(c). It runs after
+// the request ID and client address are resolved; the user is added once authenticated.
+// Requests carrying a trace context are logged with its trace and span IDs, so their lines
+// correlate with the traces of the services around the gateway.
+func RequestLoggerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
+	handlers := logutils.ForModule(logger, logutils.ModuleHandlers)
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		entry := handlers.WithFields(logrus.Fields{
+			"request_id": RequestID(c),
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      route,
+			"ip":         ClientIP(c),
+		})
+		if traceID, spanID, ok := TraceContext(c.GetHeader(TraceParentHeader)); ok {
+			entry = entry.WithFields(logrus.Fields{"trace_id": traceID, "span_id": spanID})
+		}
+		c.Set(logutils.ContextKey, entry)
+		c.Next()
+	}
+}
+
+// TraceContext returns the trace and parent span IDs of a traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func TraceContext(traceParent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false
+	}
+	traceID, spanID = parts[1], parts[2]
+	if len(traceID) != 32 || len(spanID) != 16 || !isLowerHex(traceID) || !isLowerHex(spanID) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// isLowerHex reports whether s only holds lowercase hex digits
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
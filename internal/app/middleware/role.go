@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"apigw/internal/app/domains/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RoleAdmin is the role granted to operators
+const RoleAdmin = "admin"
+
+// RequireRole rejects authenticated requests whose token does not carry one of the given roles;
+// it must run after JWTMiddleware
+func RequireRole(logger *logrus.Logger, roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, exists := c.Get("user_id"); !exists {
+			AuthenticationErrorHandler(c, logger)
+			c.Abort()
+			return
+		}
+
+		role := c.GetString("user_role")
+		if _, ok := allowed[role]; !ok {
+			logger.WithFields(logrus.Fields{
+				"method":  c.Request.Method,
+				"path":    c.Request.URL.Path,
+				"user_id": c.GetString("user_id"),
+				"role":    role,
+			}).Warn("Access denied - insufficient role")
+			c.AbortWithStatusJSON(errs.ErrForbidden.Status, errs.ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+	logutils "apigw/pkg/utils/log"
+	"apigw/pkg/utils/timing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// slowStages are the stages a slow request's latency is broken down into, the rest of
+// its time being counted as other
+var slowStages = []string{timing.Auth, timing.RateLimit, timing.Upstream, timing.Serialization}
+
+// SlowRequestMiddleware times every request and logs those slower than the threshold of
+// their route with the time they spent authenticating, rate limiting, waiting on
+// backends and rendering their response. Slow requests are counted by route and by the
+// stage they spent the most time in, for alerts to reach the owners of that stage.
+func SlowRequestMiddleware(cfg config.SlowRequestsConfig, logger *logrus.Logger) gin.HandlerFunc {
+	thresholds := make(map[string]time.Duration, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		thresholds[route.Route] = route.Threshold
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		timings := timing.New()
+		c.Request = c.Request.WithContext(timing.NewContext(c.Request.Context(), timings))
+
+		c.Next()
+
+		threshold, ok := thresholds[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			threshold, ok = thresholds[c.FullPath()]
+		}
+		if !ok {
+			threshold = cfg.Threshold
+		}
+		elapsed := time.Since(start)
+		if elapsed <= threshold {
+			return
+		}
+
+		stages := timings.Stages()
+		fields := logrus.Fields{
+			"status":       c.Writer.Status(),
+			"duration_ms":  milliseconds(elapsed),
+			"threshold_ms": milliseconds(threshold),
+		}
+		other := elapsed
+		slowest, slowestTime := "other", time.Duration(0)
+		for _, stage := range slowStages {
+			d := stages[stage]
+			fields[stage+"_ms"] = milliseconds(d)
+			other -= d
+			if d > slowestTime {
+				slowest, slowestTime = stage, d
+			}
+		}
+		// Concurrent backend calls may add up to more than the request took
+		if other < 0 {
+			other = 0
+		}
+		fields["other_ms"] = milliseconds(other)
+		if other > slowestTime {
+			slowest = "other"
+		}
+		fields["slowest_stage"] = slowest
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.SlowRequests.WithLabelValues(route, slowest).Inc()
+		logutils.FromContext(c).WithFields(fields).Warn("Slow request")
+	}
+}
+
+// milliseconds returns a duration in milliseconds, as logged
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// tenantKey is the context key of the tenant of a request
+const tenantKey = "tenant_id"
+
+// TenantMiddleware resolves the tenant of every request and carries it in the request
+// context, so backend calls are routed to the tenant's upstreams and logged with it.
+// Requests naming an unknown tenant in the header are rejected with 400.
+func TenantMiddleware(resolver *tenant.Resolver, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := resolver.Resolve(c.Request)
+		if !ok {
+			logger.WithFields(logrus.Fields{
+				"method":    c.Request.Method,
+				"path":      c.Request.URL.Path,
+				"ip":        ClientIP(c),
+				"tenant_id": id,
+			}).Warn("Request for an unknown tenant")
+			c.AbortWithStatusJSON(errs.ErrUnknownTenant.Status, errs.ErrUnknownTenant)
+			return
+		}
+		if id != "" {
+			c.Set(tenantKey, id)
+			c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+// Tenant returns the tenant of a request, empty when it has none
+func Tenant(c *gin.Context) string {
+	return c.GetString(tenantKey)
+}
+
+// TenantTokenBucketMiddleware limits the clients of tenants with a rate limit of their own
+// in buckets kept apart from those of other tenants, and the other requests with global
+func TenantTokenBucketMiddleware(
+	store TokenBucketStore,
+	tenants []config.TenantConfig,
+	global gin.HandlerFunc,
+	logger *logrus.Logger,
+) gin.HandlerFunc {
+	limiters := make(map[string]gin.HandlerFunc)
+	for _, t := range tenants {
+		if t.RateLimit.Capacity > 0 {
+			limiters[t.ID] = CreatePolicyTokenBucketMiddleware(store, "tenant:"+t.ID, t.RateLimit, nil, logger)
+		}
+	}
+	if len(limiters) == 0 {
+		return global
+	}
+	return func(c *gin.Context) {
+		if limiter, ok := limiters[Tenant(c)]; ok {
+			limiter(c)
+			return
+		}
+		global(c)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+
+	"apigw/internal/app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware bounds the upstream calls made while serving a request by the route's
+// call budget; gRPC calls inherit the deadline and fail with DeadlineExceeded once it
+// passes, while there is still time left to write the error before the server's write timeout
+func TimeoutMiddleware(cfg config.HTTPConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget := cfg.CallBudget(c.Request.Method, c.FullPath())
+		if budget <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
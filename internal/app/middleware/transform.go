@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"apigw/internal/app/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// transformRule is a compiled transformation rule
+type transformRule struct {
+	config.TransformRule
+	rewrite *regexp.Regexp
+}
+
+// Transformer applies configured path rewrites and header manipulations
+type Transformer struct {
+	rules  []transformRule
+	logger *logrus.Logger
+}
+
+// NewTransformer compiles the transformation rules
+func NewTransformer(rules []config.TransformRule, logger *logrus.Logger) *Transformer {
+	t := &Transformer{logger: logger}
+	for _, rule := range rules {
+		compiled := transformRule{TransformRule: rule}
+		if rule.RewriteRegex != "" {
+			re, err := regexp.Compile(rule.RewriteRegex)
+			if err != nil {
+				logger.WithError(err).WithField("path_prefix", rule.PathPrefix).Error("Skipping transform rule with invalid rewrite regex")
+				continue
+			}
+			compiled.rewrite = re
+		}
+		t.rules = append(t.rules, compiled)
+	}
+	return t
+}
+
+// Wrap returns a handler that transforms requests before they reach the router,
+// since path rewrites have to be applied before route matching
+func (t *Transformer) Wrap(next http.Handler) http.Handler {
+	if len(t.rules) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule := t.match(r.URL.Path)
+		if rule == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		originalPath := r.URL.Path
+		r.URL.Path = rule.rewritePath(r.URL.Path)
+		r.URL.RawPath = ""
+		applyHeaderRules(r.Header, rule.RequestHeaders)
+
+		if originalPath != r.URL.Path {
+			t.logger.WithFields(logrus.Fields{
+				"original_path":  originalPath,
+				"rewritten_path": r.URL.Path,
+			}).Debug("Request path rewritten")
+		}
+
+		next.ServeHTTP(&headerRewriteWriter{ResponseWriter: w, rules: rule.ResponseHeaders}, r)
+	})
+}
+
+// match returns the first rule matching the path
+func (t *Transformer) match(path string) *transformRule {
+	for i := range t.rules {
+		if strings.HasPrefix(path, t.rules[i].PathPrefix) {
+			return &t.rules[i]
+		}
+	}
+	return nil
+}
+
+// rewritePath applies strip, regex rewrite and add prefix operations in order
+func (r *transformRule) rewritePath(path string) string {
+	if r.StripPrefix != "" {
+		path = strings.TrimPrefix(path, r.StripPrefix)
+	}
+	if r.rewrite != nil {
+		path = r.rewrite.ReplaceAllString(path, r.RewriteReplacement)
+	}
+	if r.AddPrefix != "" {
+		path = r.AddPrefix + path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// applyHeaderRules removes and adds headers according to the rules
+func applyHeaderRules(header http.Header, rules config.HeaderRules) {
+	for _, name := range rules.Remove {
+		header.Del(name)
+	}
+	for name, value := range rules.Add {
+		header.Set(name, value)
+	}
+}
+
+// headerRewriteWriter applies response header rules right before the header is written
+type headerRewriteWriter struct {
+	http.ResponseWriter
+	rules   config.HeaderRules
+	written bool
+}
+
+// WriteHeader applies the response header rules and writes the status code
+func (w *headerRewriteWriter) WriteHeader(statusCode int) {
+	if !w.written {
+		w.written = true
+		applyHeaderRules(w.ResponseWriter.Header(), w.rules)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write ensures the header rules are applied for implicit 200 responses
+func (w *headerRewriteWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer supports it
+func (w *headerRewriteWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
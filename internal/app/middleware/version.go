@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// envelopeKey marks requests whose successful responses are wrapped in the standard envelope
+const envelopeKey = "response_envelope"
+
+// APIVersionMiddleware tags requests with their API version and whether their responses
+// are enveloped, and advertises deprecation (RFC 9745) and sunset (RFC 8594) information
+// for old versions
+func APIVersionMiddleware(version string, cfg config.APIVersionConfig, logger *logrus.Logger) gin.HandlerFunc {
+	sunset, err := cfg.SunsetTime()
+	if err != nil {
+		logger.WithError(err).WithField("api_version", version).Warn("Ignoring invalid sunset date")
+		sunset = time.Time{}
+	}
+
+	return func(c *gin.Context) {
+		c.Set("api_version", version)
+		c.Set(envelopeKey, cfg.Envelope)
+		c.Header("API-Version", version)
+
+		if cfg.Deprecated {
+			c.Header("Deprecation", "true")
+			if !sunset.IsZero() {
+				c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if cfg.Link != "" {
+				c.Header("Link", "<"+cfg.Link+">; rel=\"deprecation\"")
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Enveloped reports whether the successful responses of a request are wrapped in the
+// standard envelope
+func Enveloped(c *gin.Context) bool {
+	return c.GetBool(envelopeKey)
+}
+
+// RetiredVersionHandler responds to requests for a disabled API version
+func RetiredVersionHandler(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		httpErr := errs.ErrAPIVersionRetired.WithMessage("API version " + version + " is no longer available")
+		c.JSON(httpErr.Status, httpErr)
+	}
+}
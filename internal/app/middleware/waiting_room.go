@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/waitingroom"
+	"apigw/pkg/utils/codec"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// WaitingRoomMiddleware holds purchases of events whose waiting room is open until the
+// buyer has been granted a pass. Buyers without one join the queue and are answered with
+// 429, their queue token and position; they poll GET /events/:event_id/waiting-room and
+// retry once admitted. Each purchase spends a use of the pass; purchases answered with an
+// error give it back. It must run after authentication, since passes belong to users.
+func WaitingRoomMiddleware(room *waitingroom.Room, cfg config.WaitingRoomConfig, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		var passes []spentPass
+		defer func() {
+			if c.IsAborted() || c.Writer.Status() >= http.StatusBadRequest {
+				releasePasses(context.WithoutCancel(ctx), room, passes, logger)
+			}
+		}()
+
+		eventIDs, err := purchaseEventIDs(c)
+		if err != nil {
+			httpErr := bodyError(err)
+			logger.WithError(err).WithField("path", c.Request.URL.Path).Warn("Purchase body unreadable for the waiting room")
+			c.AbortWithStatusJSON(httpErr.Status, httpErr)
+			return
+		}
+		for _, eventID := range eventIDs {
+			settings, open, err := room.Settings(ctx, eventID)
+			if err != nil {
+				// Without Redis the on-sale cannot be queued; let the order service arbitrate
+				logger.WithError(err).WithField("event_id", eventID).Error("Waiting room check failed")
+				continue
+			}
+			if !open {
+				continue
+			}
+
+			ticket, err := room.Admit(ctx, eventID, userID, settings)
+			if err != nil {
+				logger.WithError(err).WithField("event_id", eventID).Error("Failed to enter waiting room")
+				c.AbortWithStatusJSON(errs.ErrServiceUnavailable.Status, errs.ErrServiceUnavailable)
+				return
+			}
+			if ticket.Status == waitingroom.StatusAdmitted {
+				passes = append(passes, spentPass{settings: settings, ticket: ticket})
+				metrics.WaitingRoomPurchases.WithLabelValues("admitted").Inc()
+				continue
+			}
+
+			metrics.WaitingRoomPurchases.WithLabelValues("queued").Inc()
+			retryAfter := int(math.Ceil(cfg.PollInterval.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   errs.ErrWaitingRoomQueued.ErrorType,
+				"code":    errs.ErrWaitingRoomQueued.Code,
+				"message": errs.ErrWaitingRoomQueued.Message,
+				"details": gin.H{
+					"event_id":    eventID,
+					"queue_token": ticket.Token,
+					"position":    ticket.Position,
+					"poll_after":  retryAfter,
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// spentPass is a pass use spent by a purchase
+type spentPass struct {
+	settings waitingroom.Settings
+	ticket   waitingroom.Ticket
+}
+
+// releasePasses gives back the pass uses of a purchase that did not go through
+func releasePasses(ctx context.Context, room *waitingroom.Room, passes []spentPass, logger *logrus.Logger) {
+	for _, pass := range passes {
+		if err := room.Release(ctx, pass.settings, pass.ticket); err != nil {
+			logger.WithError(err).WithField("event_id", pass.ticket.EventID).Warn("Failed to release waiting room pass")
+		}
+	}
+}
+
+// purchaseEventIDs returns the events a purchase request is for, from the event_id path
+// parameter and the eventId fields of single and batch purchase bodies, restoring the
+// body so handlers can still bind it. A body that cannot be read or parsed is an error:
+// the purchase would otherwise skip the queue of the events it names.
+func purchaseEventIDs(c *gin.Context) ([]string, error) {
+	var eventIDs []string
+	if eventID := c.Param("event_id"); eventID != "" {
+		eventIDs = append(eventIDs, eventID)
+	}
+
+	body, err := readKeyBody(c)
+	if err != nil || len(body) == 0 {
+		return eventIDs, err
+	}
+
+	var payload struct {
+		EventID string `json:"eventId"`
+		Items   []struct {
+			EventID string `json:"eventId"`
+		} `json:"items"`
+	}
+	if err := codec.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(eventIDs)+1+len(payload.Items))
+	for _, eventID := range eventIDs {
+		seen[eventID] = true
+	}
+	add := func(eventID string) {
+		if eventID != "" && !seen[eventID] {
+			seen[eventID] = true
+			eventIDs = append(eventIDs, eventID)
+		}
+	}
+	add(payload.EventID)
+	for _, item := range payload.Items {
+		add(item.EventID)
+	}
+	return eventIDs, nil
+}
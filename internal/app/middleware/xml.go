@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/pkg/utils/codec"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+)
+
+// xmlContentType is the content type of XML responses
+const xmlContentType = "application/xml; charset=utf-8"
+
+// xmlItemElement names the elements of JSON array items in XML responses
+const xmlItemElement = "item"
+
+// XMLBodies maps the routes accepting XML request bodies, as "METHOD /route/pattern", to a
+// constructor of the request DTO their bodies are decoded into
+type XMLBodies map[string]func() interface{}
+
+// XMLNegotiation lets legacy partner integrations exchange XML instead of JSON on the
+// routes listed under xml.routes. Request bodies are decoded into the route's request DTO
+// and handed to the handlers as JSON, so they are validated by the same bindings; JSON
+// responses are rendered as XML when the client prefers it.
+type XMLNegotiation struct {
+	routes map[string]bool
+	bodies XMLBodies
+	root   string
+	logger *logrus.Logger
+}
+
+// NewXMLNegotiation creates the XML negotiation of the routes in cfg; bodies lists the
+// request DTOs of the routes accepting XML bodies
+func NewXMLNegotiation(cfg config.XMLConfig, bodies XMLBodies, logger *logrus.Logger) *XMLNegotiation {
+	routes := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route] = true
+	}
+	return &XMLNegotiation{
+		routes: routes,
+		bodies: bodies,
+		root:   cfg.RootElement,
+		logger: logger,
+	}
+}
+
+// Responses renders the JSON responses of the routes as XML for clients asking for it in
+// their Accept header, or sending XML and accepting any format. It must run ahead of the recovery
+// middleware so that the errors written for panics are rendered too.
+func (x *XMLNegotiation) Responses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !x.routes[c.FullPath()] {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept")
+		if !x.wantsXML(c) {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if len(body) == 0 || !isJSONContent(c.Writer.Header().Get("Content-Type")) {
+			c.Writer.Write(body)
+			return
+		}
+		rendered, err := jsonToXML(body, x.root)
+		if err != nil {
+			x.logger.WithError(err).WithFields(logrus.Fields{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+			}).Error("Failed to render response as XML")
+			c.Writer.Write(body)
+			return
+		}
+		header := c.Writer.Header()
+		header.Set("Content-Type", xmlContentType)
+		header.Del("Content-Length")
+		c.Writer.Write(rendered)
+	}
+}
+
+// Requests decodes XML request bodies of the routes into the route's request DTO and
+// replaces them with the equivalent JSON. It runs after the request signature middleware,
+// which authenticates the body as it was sent.
+func (x *XMLNegotiation) Requests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !x.routes[c.FullPath()] || !isXMLContent(c.ContentType()) || c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		newBody, ok := x.bodies[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			x.reject(c, errs.ErrXMLNotAccepted, nil)
+			return
+		}
+		req := newBody()
+		if err := xml.NewDecoder(c.Request.Body).Decode(req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				x.reject(c, errs.ErrPayloadTooLarge, err)
+			} else {
+				x.reject(c, errs.NewHTTPError("VALIDATION_ERROR", "INVALID_REQUEST", "Invalid XML request body", http.StatusBadRequest), err)
+			}
+			return
+		}
+		body, err := codec.Marshal(req)
+		if err != nil {
+			x.reject(c, errs.ErrInternalServer, err)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Set("Content-Type", binding.MIMEJSON)
+		c.Next()
+	}
+}
+
+// reject aborts a request whose XML body cannot be accepted
+func (x *XMLNegotiation) reject(c *gin.Context, httpErr *errs.HTTPError, err error) {
+	entry := x.logger.WithFields(logrus.Fields{
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+		"error_code": httpErr.Code,
+	})
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn("XML request body rejected")
+
+	c.AbortWithStatusJSON(httpErr.Status, httpErr)
+}
+
+// wantsXML reports whether the response should be XML: the client prefers it over JSON
+// in its Accept header, or sends XML and accepts any format
+func (x *XMLNegotiation) wantsXML(c *gin.Context) bool {
+	if accept := strings.TrimSpace(c.GetHeader("Accept")); accept == "" || accept == "*/*" {
+		return isXMLContent(c.ContentType())
+	}
+	format := c.NegotiateFormat(binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2)
+	return format == binding.MIMEXML || format == binding.MIMEXML2
+}
+
+// isXMLContent reports whether a content type carries XML (application/xml, text/xml or +xml)
+func isXMLContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == binding.MIMEXML || mediaType == binding.MIMEXML2 || strings.HasSuffix(mediaType, "+xml")
+}
+
+// jsonToXML renders a JSON document as XML under a root element: object members become
+// elements named after their keys, in order, array items become item elements, and null
+// becomes an empty element
+func jsonToXML(data []byte, root string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := encodeXMLValue(dec, enc, root); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeXMLValue writes the next JSON value of dec as an element
+func encodeXMLValue(dec *json.Decoder, enc *xml.Encoder, name string) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		for dec.More() {
+			child := xmlItemElement
+			if value == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child = xmlElementName(key.(string))
+			}
+			if err := encodeXMLValue(dec, enc, child); err != nil {
+				return err
+			}
+		}
+		// Consume the closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	case string:
+		err = enc.EncodeToken(xml.CharData(value))
+	case json.Number:
+		err = enc.EncodeToken(xml.CharData(value.String()))
+	case bool:
+		err = enc.EncodeToken(xml.CharData(strconv.FormatBool(value)))
+	}
+	if err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlElementName turns a JSON object key into a valid XML element name, replacing the
+// characters XML names cannot contain with underscores
+func xmlElementName(key string) string {
+	name := []rune(key)
+	for i, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || !(unicode.IsLetter(name[0]) || name[0] == '_') {
+		name = append([]rune{'_'}, name...)
+	}
+	return string(name)
+}
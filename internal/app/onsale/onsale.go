@@ -0,0 +1,230 @@
+// Package onsale switches the gateway to the stricter profiles of known on-sale windows:
+// while the window of a profile is open its rate limits and waiting room settings are in
+// effect and the waiting rooms of its events are open, and the configured settings are
+// restored once it closes
+package onsale
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/waitingroom"
+	"apigw/pkg/utils/clock"
+	"apigw/pkg/utils/cron"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxSleep bounds how long the scheduler sleeps between checks, so wall clock changes are
+// caught up with
+const maxSleep = time.Minute
+
+// Window is the open on-sale window of a profile
+type Window struct {
+	Profile config.OnSaleProfileConfig
+	Until   time.Time
+}
+
+// Active returns the open window at now of the first profile with one, and the next time
+// a window opens or closes; next is zero when no window ever will
+func Active(cfg config.OnSaleConfig, now time.Time) (w Window, open bool, next time.Time) {
+	if loc, err := time.LoadLocation(cfg.Timezone); err == nil {
+		now = now.In(loc)
+	}
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	for _, profile := range cfg.Profiles {
+		schedule, err := cron.Parse(profile.Schedule)
+		if err != nil {
+			continue
+		}
+		earliest(schedule.Next(now))
+
+		// The window is open when the schedule fired within the last duration
+		start := schedule.Next(now.Add(-profile.Duration))
+		if start.IsZero() || start.After(now) {
+			continue
+		}
+		until := start.Add(profile.Duration)
+		earliest(until)
+		if !open {
+			w, open = Window{Profile: profile, Until: until}, true
+		}
+	}
+	return w, open, next
+}
+
+// Scheduler applies the profile of the open on-sale window, if any, to the configuration
+// in effect
+type Scheduler struct {
+	clock  clock.Clock
+	rooms  func(config.WaitingRoomConfig) *waitingroom.Room
+	apply  func(*config.Config)
+	logger *logrus.Logger
+	wake   chan struct{}
+
+	mu      sync.Mutex
+	cfg     *config.Config
+	profile *config.OnSaleProfileConfig // Profile switched to by Run, nil outside windows
+}
+
+// NewScheduler creates a scheduler of the profiles of cfg. apply is called with the
+// configuration to put in effect as windows open and close; rooms returns the waiting
+// rooms of the profiles' events, nil while they cannot be reached.
+func NewScheduler(cfg *config.Config, clk clock.Clock, rooms func(config.WaitingRoomConfig) *waitingroom.Room, apply func(*config.Config), logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		clock:  clk,
+		rooms:  rooms,
+		apply:  apply,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+		cfg:    cfg,
+	}
+}
+
+// Config returns the configuration in effect: the configured one with the profile of the
+// open window, if any
+func (s *Scheduler) Config() *config.Config {
+	s.mu.Lock()
+	cfg := s.cfg
+	s.mu.Unlock()
+	return s.effective(cfg)
+}
+
+// effective returns cfg with the profile of the open window, if any
+func (s *Scheduler) effective(cfg *config.Config) *config.Config {
+	if !cfg.OnSale.Enabled {
+		return cfg
+	}
+	if w, open, _ := Active(cfg.OnSale, s.clock.Now()); open {
+		return cfg.WithOnSaleProfile(w.Profile)
+	}
+	return cfg
+}
+
+// Update replaces the configured configuration, e.g. on reload, and puts it in effect
+// with the profile of the open window
+func (s *Scheduler) Update(cfg *config.Config) {
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	s.apply(s.effective(cfg))
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run switches profiles as their windows open and close, until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		wait := maxSleep
+		if next := s.check(ctx); !next.IsZero() {
+			if d := next.Sub(s.clock.Now()); d < wait {
+				wait = d
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// check switches to the profile of the open window when it changed, and returns when the
+// next window opens or closes
+func (s *Scheduler) check(ctx context.Context) time.Time {
+	s.mu.Lock()
+	cfg, previous := s.cfg, s.profile
+	s.mu.Unlock()
+
+	var w Window
+	var open bool
+	var next time.Time
+	if cfg.OnSale.Enabled {
+		w, open, next = Active(cfg.OnSale, s.clock.Now())
+	}
+	switch {
+	case !open && previous == nil:
+		return next
+	case open && previous != nil && previous.Name == w.Profile.Name:
+		return next
+	}
+
+	if previous != nil {
+		metrics.OnSaleProfileActive.WithLabelValues(previous.Name).Set(0)
+		s.closeRooms(ctx, cfg, *previous)
+		s.logger.WithField("profile", previous.Name).Info("On-sale window closed, profile reverted")
+	}
+	var current *config.OnSaleProfileConfig
+	if open {
+		current = &w.Profile
+		metrics.OnSaleProfileActive.WithLabelValues(current.Name).Set(1)
+		s.openRooms(ctx, cfg, *current)
+		s.logger.WithFields(logrus.Fields{
+			"profile": current.Name,
+			"until":   w.Until.Format(time.RFC3339),
+		}).Info("On-sale window opened, profile in effect")
+	}
+
+	s.mu.Lock()
+	s.profile = current
+	s.mu.Unlock()
+	s.apply(s.effective(cfg))
+	return next
+}
+
+// openRooms opens the waiting rooms of the events of a profile
+func (s *Scheduler) openRooms(ctx context.Context, cfg *config.Config, profile config.OnSaleProfileConfig) {
+	if len(profile.WaitingRoom.Events) == 0 {
+		return
+	}
+	room := s.rooms(cfg.WaitingRoom)
+	if room == nil {
+		s.logger.WithField("profile", profile.Name).Warn("Waiting rooms of on-sale profile not opened, Redis is unavailable")
+		return
+	}
+	rate := profile.WaitingRoom.Rate
+	if rate == 0 {
+		rate = cfg.WaitingRoom.DefaultRate
+	}
+	for _, eventID := range profile.WaitingRoom.Events {
+		if _, err := room.Open(ctx, eventID, rate); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"profile":  profile.Name,
+				"event_id": eventID,
+			}).Error("Failed to open waiting room of on-sale profile")
+		}
+	}
+}
+
+// closeRooms closes the waiting rooms of the events of a profile
+func (s *Scheduler) closeRooms(ctx context.Context, cfg *config.Config, profile config.OnSaleProfileConfig) {
+	if len(profile.WaitingRoom.Events) == 0 {
+		return
+	}
+	room := s.rooms(cfg.WaitingRoom)
+	if room == nil {
+		s.logger.WithField("profile", profile.Name).Warn("Waiting rooms of on-sale profile not closed, Redis is unavailable")
+		return
+	}
+	for _, eventID := range profile.WaitingRoom.Events {
+		if _, err := room.Close(ctx, eventID); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"profile":  profile.Name,
+				"event_id": eventID,
+			}).Error("Failed to close waiting room of on-sale profile")
+		}
+	}
+}
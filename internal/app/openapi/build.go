@@ -0,0 +1,322 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/listing"
+	"apigw/internal/app/signing"
+)
+
+// jsonContent is the media type of every documented body but uploads
+const jsonContent = "application/json"
+
+// Security schemes of the documented routes
+const (
+	bearerAuth       = "bearerAuth"
+	partnerSignature = "partnerSignature"
+)
+
+var (
+	// routeParam matches the parameters and wildcards of a route pattern
+	routeParam = regexp.MustCompile(`[:*]([^/]+)`)
+	// versionPrefix matches the prefix of versioned API routes
+	versionPrefix = regexp.MustCompile(`^/api/(v\d+)/`)
+)
+
+// Operation documents what the handler of a route binds and returns. Routes whose handler
+// has no operation are left out of the document.
+type Operation struct {
+	Summary string
+	// Path is a struct with uri tags describing the path parameters; parameters it does
+	// not list are documented as plain strings
+	Path interface{}
+	// Query is a struct with form tags describing the query parameters
+	Query interface{}
+	// List describes the paging, sorting and field selection parameters of list routes
+	List *listing.Spec
+	// Body is the JSON request body
+	Body interface{}
+	// OptionalBody documents Body as optional
+	OptionalBody bool
+	// Upload names the file part of multipart/form-data request bodies
+	Upload string
+	// Status is the status of successful responses, 200 when zero
+	Status int
+	// Response is the body of successful responses; they have none when it is nil
+	Response interface{}
+	// Items is the item type of list responses, whose interface{} fields hold the items
+	Items interface{}
+}
+
+// Build documents the described routes whose handlers have an operation, keyed by the
+// short handler name of the route table. Versioned routes are marked deprecated and
+// enveloped according to the settings of their API version.
+func Build(info Info, routes []dto.RouteResp, operations map[string]Operation, versions map[string]config.APIVersionConfig) *Document {
+	s := newSchemas()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "Access token from the login or refresh routes",
+				},
+				partnerSignature: {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        signing.HeaderSignature,
+					Description: "HMAC signature of the request; " + signing.HeaderPartnerID + " and " + signing.HeaderTimestamp + " are required too",
+				},
+			},
+		},
+	}
+
+	// Sorted so that component names are stable: the first type of a name keeps it bare
+	sorted := append([]dto.RouteResp(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	errorSchema := s.of(reflect.TypeOf(errs.HTTPError{}))
+	for _, route := range sorted {
+		op, ok := operations[route.Handler]
+		if !ok {
+			continue
+		}
+		var version config.APIVersionConfig
+		if m := versionPrefix.FindStringSubmatch(route.Path); m != nil {
+			version = versions[m[1]]
+		}
+
+		path := routeParam.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = s.operation(route, op, version, errorSchema)
+	}
+	return doc
+}
+
+// operation documents a route
+func (s *schemas) operation(route dto.RouteResp, op Operation, version config.APIVersionConfig, errorSchema *Schema) *OperationObject {
+	obj := &OperationObject{
+		OperationID: operationID(route.Method, route.Path),
+		Summary:     op.Summary,
+		Tags:        []string{tag(route.Path)},
+		Deprecated:  version.Deprecated,
+		Parameters:  s.pathParameters(route.Path, op.Path),
+		Responses:   make(map[string]Response),
+	}
+	if op.Query != nil {
+		obj.Parameters = append(obj.Parameters, s.parameters(reflect.TypeOf(op.Query), "query", "form")...)
+	}
+	if op.List != nil {
+		obj.Parameters = append(obj.Parameters, listParameters(*op.List)...)
+	}
+
+	switch {
+	case op.Body != nil:
+		obj.RequestBody = &RequestBody{
+			Required: !op.OptionalBody,
+			Content:  map[string]MediaType{jsonContent: {Schema: s.of(reflect.TypeOf(op.Body))}},
+		}
+	case op.Upload != "":
+		obj.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{op.Upload: {Type: "string", Format: "binary"}},
+				Required:   []string{op.Upload},
+			}}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = map[string]MediaType{jsonContent: {Schema: s.response(op, version.Envelope, errorSchema)}}
+	}
+	obj.Responses[strconv.Itoa(status)] = success
+
+	// Errors: the ones the route is known to return, then any other
+	failure := func(status int) {
+		obj.Responses[strconv.Itoa(status)] = Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{jsonContent: {Schema: errorSchema}},
+		}
+	}
+	if len(obj.Parameters) > 0 || obj.RequestBody != nil {
+		failure(http.StatusBadRequest)
+	}
+	for _, auth := range route.Auth {
+		switch auth {
+		case "jwt":
+			obj.Security = append(obj.Security, map[string][]string{bearerAuth: {}})
+			failure(http.StatusUnauthorized)
+		case "signature":
+			obj.Security = append(obj.Security, map[string][]string{partnerSignature: {}})
+			failure(http.StatusUnauthorized)
+		default:
+			// role:admin
+			failure(http.StatusForbidden)
+		}
+	}
+	obj.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{jsonContent: {Schema: errorSchema}},
+	}
+	return obj
+}
+
+// response returns the schema of the successful responses of an operation, in the
+// standard envelope when the route's API version envelopes responses
+func (s *schemas) response(op Operation, enveloped bool, errorSchema *Schema) *Schema {
+	t := reflect.TypeOf(op.Response)
+	var body *Schema
+	switch {
+	case op.Items != nil && enveloped:
+		// Enveloped lists carry their items as data and their page in the meta
+		body = &Schema{Type: "array", Items: s.of(reflect.TypeOf(op.Items))}
+	case op.Items != nil:
+		body = s.list(t, reflect.TypeOf(op.Items))
+	default:
+		body = s.of(t)
+	}
+	if !enveloped {
+		return body
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":   body,
+			"meta":   s.of(reflect.TypeOf(dto.EnvelopeMeta{})),
+			"errors": {Type: "array", Items: errorSchema},
+		},
+		Required: []string{"data", "meta"},
+	}
+}
+
+// list returns the schema of a list response, whose interface{} fields hold the items
+func (s *schemas) list(t reflect.Type, items reflect.Type) *Schema {
+	schema := s.object(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Type.Kind() == reflect.Interface && name != "" {
+			schema.Properties[name] = &Schema{Type: "array", Items: s.of(items)}
+		}
+	}
+	return schema
+}
+
+// pathParameters documents the parameters of a route pattern, with the schemas of the
+// fields of path that bind them
+func (s *schemas) pathParameters(route string, path interface{}) []Parameter {
+	bound := make(map[string]Parameter)
+	if path != nil {
+		for _, param := range s.parameters(reflect.TypeOf(path), "path", "uri") {
+			bound[param.Name] = param
+		}
+	}
+
+	var params []Parameter
+	for _, m := range routeParam.FindAllStringSubmatch(route, -1) {
+		param, ok := bound[m[1]]
+		if !ok {
+			param = Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// listParameters documents the paging, sorting and field selection parameters of a list
+func listParameters(spec listing.Spec) []Parameter {
+	minLimit, maxLimit := 1.0, float64(spec.MaxLimit)
+	params := []Parameter{
+		{
+			Name:        "limit",
+			In:          "query",
+			Description: "Page size, " + strconv.Itoa(int(spec.DefaultLimit)) + " by default",
+			Schema:      &Schema{Type: "integer", Format: "int32", Minimum: &minLimit, Maximum: &maxLimit},
+		},
+		{
+			Name:        "cursor",
+			In:          "query",
+			Description: "next_cursor of the previous page",
+			Schema:      &Schema{Type: "string"},
+		},
+	}
+	if len(spec.Sorts) > 0 {
+		keys := make([]string, 0, len(spec.Sorts))
+		for key := range spec.Sorts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		params = append(params, Parameter{
+			Name:        "sort",
+			In:          "query",
+			Description: "Comma separated sort keys, prefixed with - for descending order: " + strings.Join(keys, ", "),
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	if len(spec.Fields) > 0 {
+		params = append(params, Parameter{
+			Name:        "fields",
+			In:          "query",
+			Description: "Comma separated item fields to return: " + strings.Join(spec.Fields, ", "),
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	return params
+}
+
+// operationID derives a unique operation ID from a route, e.g. getApiV1EventsEventIdSeats
+// for GET /api/v1/events/:event_id/seats
+func operationID(method, route string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range route {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// tag groups a route by its first segment after the API version, e.g. events
+func tag(route string) string {
+	rest := versionPrefix.ReplaceAllString(route, "")
+	if rest == route {
+		return "gateway"
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	return segment
+}
@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"apigw/internal/app/validation"
+)
+
+// componentPrefix prefixes the references to component schemas
+const componentPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas builds the schemas of Go types the way encoding/json renders them, registering
+// named structs as components
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+// newSchemas creates an empty schema registry
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// of returns the schema of a type; named structs are referred to as components
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	default:
+		// Interfaces may hold any value
+		return &Schema{}
+	}
+}
+
+// ref registers a named struct as a component and refers to it
+func (s *schemas) ref(t reflect.Type) *Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			// e.g. v2.RegisterReq next to the v1 RegisterReq
+			name = path.Base(t.PkgPath()) + "." + name
+		}
+		s.names[t] = name
+		// Registered before its fields so recursive types refer to it
+		s.components[name] = &Schema{}
+		*s.components[name] = *s.object(t)
+	}
+	return &Schema{Ref: componentPrefix + name}
+}
+
+// object returns the schema of a struct, with the fields encoding/json renders
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the fields of a struct to an object schema, flattening embedded structs
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.of(field.Type)
+		if applyRules(property, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// parameters returns the parameters bound from the fields of a struct with the given tag,
+// form for query parameters and uri for path parameters
+func (s *schemas) parameters(t reflect.Type, in, tag string) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		schema := s.of(field.Type)
+		required := applyRules(schema, field.Tag.Get("binding"))
+		param := Parameter{Name: name, In: in, Required: required || in == "path", Schema: schema}
+		if layout := field.Tag.Get("time_format"); layout != "" && layout != time.RFC3339 {
+			schema.Format = ""
+			param.Description = "Time in the layout " + layout
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// applyRules documents the validation rules of a binding tag on a schema and reports
+// whether the field is required. Rules after dive apply to the items of an array.
+func applyRules(schema *Schema, binding string) bool {
+	if binding == "" {
+		return false
+	}
+	required := false
+	target := schema
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			if target.Items == nil {
+				// Map values are not described
+				break
+			}
+			target = target.Items
+			continue
+		}
+		if name == "required" && target == schema {
+			required = true
+			continue
+		}
+		if target.Ref != "" {
+			// Components keep their own description
+			continue
+		}
+
+		switch name {
+		case "min", "gte":
+			bound(target, param, true, false)
+		case "max", "lte":
+			bound(target, param, false, false)
+		case "gt":
+			bound(target, param, true, true)
+		case "lt":
+			bound(target, param, false, true)
+		case "len":
+			bound(target, param, true, false)
+			bound(target, param, false, false)
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "email":
+			target.Format = "email"
+		case "url", "http_url":
+			target.Format = "uri"
+		case "uuid", "uuid4":
+			target.Format = "uuid"
+		case validation.RulePassword:
+			target.Format = "password"
+			target.Description = "Password policy: " + validation.RuleConstraint(name)
+		case validation.RuleEventID:
+			target.Description = "Event ID in one of the formats " + validation.RuleConstraint(name)
+		case validation.RulePhone, validation.RuleResourceID:
+			target.Pattern = validation.RulePattern(name)
+		}
+	}
+	return required
+}
+
+// bound documents a min or max rule: a length for strings, a count for arrays and a value
+// for numbers
+func bound(schema *Schema, param string, lower, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = count(value, lower, exclusive)
+		} else {
+			schema.MaxLength = count(value, lower, exclusive)
+		}
+	case "array":
+		if lower {
+			schema.MinItems = count(value, lower, exclusive)
+		} else {
+			schema.MaxItems = count(value, lower, exclusive)
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum, schema.ExclusiveMinimum = &value, exclusive
+		} else {
+			schema.Maximum, schema.ExclusiveMaximum = &value, exclusive
+		}
+	}
+}
+
+// count turns a length or count bound into the inclusive bound OpenAPI expects
+func count(value float64, lower, exclusive bool) *int {
+	n := int(value)
+	switch {
+	case exclusive && lower:
+		n++
+	case exclusive:
+		n--
+	}
+	return &n
+}
@@ -0,0 +1,25 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"strings"
+)
+
+//go:embed swagger.html
+var swaggerPage string
+
+var swaggerTemplate = template.Must(template.New("swagger").Parse(swaggerPage))
+
+// SwaggerUI renders the page of the Swagger UI browsing the document at specURL, loading
+// the swagger-ui-dist assets from assetsURL
+func SwaggerUI(title, assetsURL, specURL string) ([]byte, error) {
+	var page bytes.Buffer
+	err := swaggerTemplate.Execute(&page, struct {
+		Title     string
+		AssetsURL string
+		SpecURL   string
+	}{title, strings.TrimSuffix(assetsURL, "/"), specURL})
+	return page.Bytes(), err
+}
@@ -0,0 +1,493 @@
+// Package purchasequeue implements the asynchronous purchase flow of on-sale spikes:
+// purchases are queued in a Redis stream shared by every replica and forwarded to the
+// order service by a consumer group of workers, while their progress is kept for the
+// clients polling it
+package purchasequeue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+	"apigw/internal/app/metrics"
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// Purchase states
+const (
+	StatusQueued     = "queued"
+	StatusProcessing = "processing"
+	StatusSucceeded  = "succeeded"
+	StatusFailed     = "failed"
+)
+
+// group is the consumer group the workers of every replica share
+const group = "workers"
+
+// readBlock bounds how long a worker waits for a purchase before checking for abandoned ones
+const readBlock = 5 * time.Second
+
+// finishAttempts bounds the attempts to record an outcome; one left unrecorded stays
+// processing, and its takeover fails the purchase whatever the outcome was
+const finishAttempts = 3
+
+var (
+	// ErrNotFound is returned for unknown or expired purchase references
+	ErrNotFound = errors.New("purchase not found")
+	// ErrQueueFull is returned when max_pending purchases are already queued
+	ErrQueueFull = errors.New("purchase queue is full")
+)
+
+// Purchase is a queued purchase request
+type Purchase struct {
+	UserID   string   `json:"userId"`
+	EventID  string   `json:"eventId"`
+	SeatIDs  []string `json:"seatIds,omitempty"`
+	Quantity int32    `json:"quantity"`
+	Tier     string   `json:"tier,omitempty"`
+	// Tenant routes the purchase to the order service of the tenant it was made for
+	Tenant string `json:"tenant,omitempty"`
+	// Region routes the purchase to the clusters of the region it was made in
+	Region string `json:"region,omitempty"`
+}
+
+// Job is the progress of a queued purchase
+type Job struct {
+	Reference string
+	UserID    string
+	EventID   string
+	Status    string
+	Result    string          // Purchase status reported by the order service once succeeded
+	Error     *errs.HTTPError // Set once failed
+	Attempts  int             // Calls made to the order service
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ProcessFunc forwards a purchase to the order service and returns the purchase status
+// it reported
+type ProcessFunc func(ctx context.Context, p Purchase) (string, error)
+
+// Queue queues purchases and runs the workers forwarding them
+type Queue struct {
+	redis  redis.UniversalClient
+	cfg    config.AsyncPurchaseConfig
+	logger *logrus.Logger
+	wg     sync.WaitGroup
+}
+
+// New creates a purchase queue storing its state under cfg.KeyPrefix
+func New(redisClient redis.UniversalClient, cfg config.AsyncPurchaseConfig, logger *logrus.Logger) *Queue {
+	return &Queue{redis: redisClient, cfg: cfg, logger: logger}
+}
+
+// streamKey returns the key of the stream of queued purchases
+func (q *Queue) streamKey() string {
+	return q.cfg.KeyPrefix + "stream"
+}
+
+// jobKey returns the key of the progress of a purchase
+func (q *Queue) jobKey(ref string) string {
+	return q.cfg.KeyPrefix + "job:" + ref
+}
+
+// Enqueue queues a purchase and returns its progress, rejecting it with ErrQueueFull when
+// max_pending purchases are waiting
+func (q *Queue) Enqueue(ctx context.Context, p Purchase) (Job, error) {
+	length, err := q.redis.XLen(ctx, q.streamKey()).Result()
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to read queue length: %w", err)
+	}
+	metrics.AsyncPurchaseQueueLength.Set(float64(length))
+	if length >= q.cfg.MaxPending {
+		metrics.AsyncPurchases.WithLabelValues("rejected").Inc()
+		return Job{}, ErrQueueFull
+	}
+
+	ref, err := newReference()
+	if err != nil {
+		return Job{}, err
+	}
+	payload, err := codec.Marshal(p)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode purchase: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := Job{
+		Reference: ref,
+		UserID:    p.UserID,
+		EventID:   p.EventID,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// The progress is written first so a worker always finds it
+	pipe := q.redis.Pipeline()
+	pipe.HSet(ctx, q.jobKey(ref),
+		"user_id", job.UserID,
+		"event_id", job.EventID,
+		"status", job.Status,
+		"attempts", 0,
+		"created_at", now.Format(time.RFC3339Nano),
+		"updated_at", now.Format(time.RFC3339Nano),
+	)
+	pipe.Expire(ctx, q.jobKey(ref), q.cfg.ResultTTL)
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.streamKey(),
+		Values: map[string]interface{}{"ref": ref, "purchase": payload},
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Job{}, fmt.Errorf("failed to queue purchase: %w", err)
+	}
+
+	metrics.AsyncPurchases.WithLabelValues("queued").Inc()
+	return job, nil
+}
+
+// Status returns the progress of a purchase
+func (q *Queue) Status(ctx context.Context, ref string) (Job, error) {
+	values, err := q.redis.HGetAll(ctx, q.jobKey(ref)).Result()
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to read purchase: %w", err)
+	}
+	if len(values) == 0 {
+		return Job{}, ErrNotFound
+	}
+
+	job := Job{
+		Reference: ref,
+		UserID:    values["user_id"],
+		EventID:   values["event_id"],
+		Status:    values["status"],
+		Result:    values["result"],
+	}
+	job.Attempts, _ = strconv.Atoi(values["attempts"])
+	job.CreatedAt, _ = time.Parse(time.RFC3339Nano, values["created_at"])
+	job.UpdatedAt, _ = time.Parse(time.RFC3339Nano, values["updated_at"])
+	if code := values["error_code"]; code != "" {
+		status, _ := strconv.Atoi(values["error_status"])
+		job.Error = errs.NewHTTPError(values["error_type"], code, values["error_message"], status)
+	}
+	return job, nil
+}
+
+// Start starts forwarding queued purchases with process until ctx is done, at most
+// workers at a time. Wait returns once the purchases being forwarded are done.
+func (q *Queue) Start(ctx context.Context, process ProcessFunc) error {
+	err := q.redis.XGroupCreateMkStream(ctx, q.streamKey(), group, "0").Err()
+	if err != nil && !isBusyGroup(err) {
+		return fmt.Errorf("failed to create the worker group: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	consumer := fmt.Sprintf("%s-%d", host, os.Getpid())
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.fetch(ctx, consumer, process)
+	}()
+
+	q.logger.WithFields(logrus.Fields{
+		"workers":  q.cfg.Workers,
+		"stream":   q.streamKey(),
+		"consumer": consumer,
+	}).Info("Async purchase workers started")
+	return nil
+}
+
+// Wait waits for the workers to stop
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// fetch reads purchases for the idle workers, taking over those a stopped replica left
+// behind before waiting for new ones. Only this loop blocks on Redis, so the workers do
+// not hold connections the rest of the gateway needs.
+func (q *Queue) fetch(ctx context.Context, consumer string, process ProcessFunc) {
+	idle := make(chan struct{}, q.cfg.Workers)
+	for i := 0; i < q.cfg.Workers; i++ {
+		idle <- struct{}{}
+	}
+
+	var lastClaim time.Time
+	for {
+		// Wait for an idle worker, then take every other idle one
+		select {
+		case <-ctx.Done():
+			return
+		case <-idle:
+		}
+		free := 1
+	take:
+		for free < q.cfg.Workers {
+			select {
+			case <-idle:
+				free++
+			default:
+				break take
+			}
+		}
+
+		var messages []redis.XMessage
+		var err error
+		if time.Since(lastClaim) >= q.cfg.ClaimIdle/2 {
+			lastClaim = time.Now()
+			messages, err = q.claim(ctx, consumer, free)
+			if err == nil && len(messages) > 0 {
+				metrics.AsyncPurchases.WithLabelValues("claimed").Add(float64(len(messages)))
+				lastClaim = time.Time{} // Look for more on the next round
+			}
+		}
+		if err == nil && len(messages) == 0 {
+			var streams []redis.XStream
+			streams, err = q.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    group,
+				Consumer: consumer,
+				Streams:  []string{q.streamKey(), ">"},
+				Count:    int64(free),
+				Block:    readBlock,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				err = nil
+			}
+			for _, stream := range streams {
+				messages = append(messages, stream.Messages...)
+			}
+		}
+
+		for _, msg := range messages {
+			free--
+			q.wg.Add(1)
+			go func() {
+				defer func() {
+					idle <- struct{}{}
+					q.wg.Done()
+				}()
+				q.handle(ctx, msg, process)
+			}()
+		}
+		for ; free > 0; free-- {
+			idle <- struct{}{}
+		}
+
+		if err != nil && ctx.Err() == nil {
+			q.logger.WithError(err).Error("Failed to read queued purchases")
+			sleep(ctx, time.Second)
+		}
+	}
+}
+
+// claim takes over up to count purchases left with another replica for claim_idle.
+// XPENDING and XCLAIM are used rather than XAUTOCLAIM, whose reply changed in Redis 7.
+func (q *Queue) claim(ctx context.Context, consumer string, count int) ([]redis.XMessage, error) {
+	pending, err := q.redis.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: q.streamKey(),
+		Group:  group,
+		Idle:   q.cfg.ClaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(count),
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	ids := make([]string, len(pending))
+	for i, p := range pending {
+		ids[i] = p.ID
+	}
+	// Another replica may claim them first, in which case they are not returned
+	return q.redis.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   q.streamKey(),
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  q.cfg.ClaimIdle,
+		Messages: ids,
+	}).Result()
+}
+
+// handle forwards one purchase, retrying while the order service is unavailable, and
+// records the outcome. A purchase interrupted by shutdown stays in the queue and is taken
+// over once claim_idle has passed; one left processing, whose call the order service may
+// have received, is failed instead of being sent again.
+func (q *Queue) handle(ctx context.Context, msg redis.XMessage, process ProcessFunc) {
+	ref, _ := msg.Values["ref"].(string)
+	data, _ := msg.Values["purchase"].(string)
+	logger := q.logger.WithField("reference", ref)
+
+	var p Purchase
+	if err := codec.Unmarshal([]byte(data), &p); err != nil || ref == "" {
+		logger.WithError(err).Error("Dropping malformed queued purchase")
+		q.finish(ctx, msg.ID, ref, "", errs.ErrInternalServer)
+		return
+	}
+
+	job, err := q.Status(ctx, ref)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			// Its status expired; nobody is polling it anymore
+			logger.Warn("Dropping queued purchase whose status expired")
+			q.finish(ctx, msg.ID, "", "", nil)
+		} else {
+			logger.WithError(err).Error("Failed to read queued purchase")
+		}
+		return
+	}
+	if job.Status == StatusProcessing {
+		// The replica stopped during the call, so the tickets may already be purchased
+		logger.WithField("attempts", job.Attempts).Warn("Failing queued purchase interrupted during its call")
+		q.finish(ctx, msg.ID, ref, "", errs.ErrPurchaseOutcomeUnknown)
+		return
+	}
+
+	var (
+		result  string
+		callErr error
+	)
+	for attempt := job.Attempts + 1; ; attempt++ {
+		if attempt > q.cfg.Attempts {
+			if callErr == nil {
+				callErr = errors.New("attempts exhausted before the purchase was taken over")
+			}
+			break
+		}
+		err := q.redis.HSet(ctx, q.jobKey(ref),
+			"status", StatusProcessing,
+			"attempts", attempt,
+			"updated_at", time.Now().UTC().Format(time.RFC3339Nano),
+		).Err()
+		if err != nil {
+			// Unmarked, a call interrupted by a stop would be sent again by the takeover.
+			// The order service has not received it yet, so it stays in the queue and is
+			// taken over once Redis answers again.
+			logger.WithError(err).WithField("attempt", attempt).Error("Failed to mark queued purchase processing, leaving it queued")
+			return
+		}
+
+		// Calls are not tied to ctx, so shutting down does not abandon a purchase the
+		// order service may already be processing
+		callCtx, cancel := context.WithTimeout(context.Background(), q.cfg.CallTimeout)
+		result, callErr = process(callCtx, p)
+		cancel()
+		if callErr == nil || !retryable(callErr) || attempt == q.cfg.Attempts {
+			break
+		}
+
+		// The order service did not receive the call, so a takeover may send it again
+		err = q.redis.HSet(ctx, q.jobKey(ref),
+			"status", StatusQueued,
+			"updated_at", time.Now().UTC().Format(time.RFC3339Nano),
+		).Err()
+		if err != nil {
+			// Still marked processing, a takeover during the backoff fails the purchase
+			// rather than sending it again; the next attempt marks it processing anew
+			logger.WithError(err).WithField("attempt", attempt).Warn("Failed to mark queued purchase queued again")
+		}
+		metrics.AsyncPurchases.WithLabelValues("retried").Inc()
+		logger.WithError(callErr).WithField("attempt", attempt).Warn("Order service unavailable, retrying queued purchase")
+		if !sleep(ctx, q.cfg.RetryBackoff) {
+			return
+		}
+	}
+
+	if callErr != nil {
+		// The details of unreachable backends are not the client's business
+		httpErr := errs.GRPCToHTTPError(callErr)
+		if code := errs.GetGRPCCode(callErr); code == codes.Unavailable || code == codes.Unknown {
+			httpErr = errs.ErrServiceUnavailable
+		}
+		logger.WithError(callErr).Warn("Queued purchase failed")
+		q.finish(ctx, msg.ID, ref, "", httpErr)
+		return
+	}
+	q.finish(ctx, msg.ID, ref, result, nil)
+}
+
+// finish records the outcome of a purchase and removes it from the queue
+func (q *Queue) finish(ctx context.Context, id, ref, result string, httpErr *errs.HTTPError) {
+	// Recording the outcome must not be cut short by shutdown
+	ctx = context.WithoutCancel(ctx)
+
+	var fields []interface{}
+	if ref != "" {
+		if httpErr != nil {
+			fields = []interface{}{
+				"status", StatusFailed,
+				"error_type", httpErr.ErrorType,
+				"error_code", httpErr.Code,
+				"error_message", httpErr.Message,
+				"error_status", httpErr.Status,
+			}
+			metrics.AsyncPurchases.WithLabelValues("failed").Inc()
+		} else {
+			fields = []interface{}{"status", StatusSucceeded, "result", result}
+			metrics.AsyncPurchases.WithLabelValues("succeeded").Inc()
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, err := q.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			if ref != "" {
+				pipe.HSet(ctx, q.jobKey(ref), append(fields, "updated_at", time.Now().UTC().Format(time.RFC3339Nano))...)
+				pipe.Expire(ctx, q.jobKey(ref), q.cfg.ResultTTL)
+			}
+			pipe.XAck(ctx, q.streamKey(), group, id)
+			pipe.XDel(ctx, q.streamKey(), id)
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		if attempt == finishAttempts {
+			q.logger.WithError(err).WithField("reference", ref).Error("Failed to record the outcome of a queued purchase")
+			return
+		}
+		time.Sleep(q.cfg.RetryBackoff)
+	}
+}
+
+// retryable reports whether a failed call may succeed when retried: only calls the order
+// service could not receive are, so that a purchase is not made twice
+func retryable(err error) bool {
+	return errs.GetGRPCCode(err) == codes.Unavailable
+}
+
+// isBusyGroup reports whether creating the consumer group failed because it exists
+func isBusyGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP")
+}
+
+// sleep waits for d and reports whether ctx is still live
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// newReference returns a random purchase reference
+func newReference() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate purchase reference: %w", err)
+	}
+	return "pur_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,135 @@
+// Package quota counts the API calls of each client against daily and monthly caps. The
+// counts are shared by every gateway replica through Redis and roll over at midnight UTC
+// and on the first of the month.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/pkg/utils/clock"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Quota windows
+const (
+	WindowDaily   = "daily"
+	WindowMonthly = "monthly"
+)
+
+// Usage is the consumption of a client in one window
+type Usage struct {
+	Window   string
+	Used     int64
+	Limit    int64 // 0 when the window is uncapped
+	ResetsAt time.Time
+}
+
+// Remaining returns the calls left in the window, or -1 when it is uncapped
+func (u Usage) Remaining() int64 {
+	switch {
+	case u.Limit == 0:
+		return -1
+	case u.Used >= u.Limit:
+		return 0
+	default:
+		return u.Limit - u.Used
+	}
+}
+
+// window is a window of a client at a point in time
+type window struct {
+	name     string
+	key      string
+	limit    int64
+	resetsAt time.Time
+}
+
+// Tracker counts the calls of clients
+type Tracker struct {
+	redis redis.UniversalClient
+	cfg   config.QuotasConfig
+	clock clock.Clock
+}
+
+// New creates a tracker storing its counts under cfg.KeyPrefix
+func New(redisClient redis.UniversalClient, cfg config.QuotasConfig, clk clock.Clock) *Tracker {
+	return &Tracker{redis: redisClient, cfg: cfg, clock: clk}
+}
+
+// windows returns the current windows of a client. The keys of a client share a hash
+// tag, which keeps them in one Redis Cluster slot.
+func (t *Tracker) windows(client string) []window {
+	now := t.clock.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	limits := t.cfg.Limits(client)
+	prefix := t.cfg.KeyPrefix + "{" + client + "}:"
+	return []window{
+		{name: WindowDaily, key: prefix + day.Format("2006-01-02"), limit: limits.Daily, resetsAt: day.AddDate(0, 0, 1)},
+		{name: WindowMonthly, key: prefix + month.Format("2006-01"), limit: limits.Monthly, resetsAt: month.AddDate(0, 1, 0)},
+	}
+}
+
+// Consume counts a call of a client and returns its usage. When a capped window is used
+// up the call is not counted, and exceeded is the window the client must wait for, the
+// one resetting last if several are used up.
+func (t *Tracker) Consume(ctx context.Context, client string) (usage []Usage, exceeded *Usage, err error) {
+	windows := t.windows(client)
+	pipe := t.redis.Pipeline()
+	counts := make([]*redis.IntCmd, len(windows))
+	for i, w := range windows {
+		counts[i] = pipe.Incr(ctx, w.key)
+		pipe.ExpireAt(ctx, w.key, w.resetsAt)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, fmt.Errorf("redis pipeline execution failed: %w", err)
+	}
+
+	usage = make([]Usage, len(windows))
+	for i, w := range windows {
+		usage[i] = Usage{Window: w.name, Used: counts[i].Val(), Limit: w.limit, ResetsAt: w.resetsAt}
+		over := w.limit > 0 && usage[i].Used > w.limit
+		if over && (exceeded == nil || usage[i].ResetsAt.After(exceeded.ResetsAt)) {
+			exceeded = &usage[i]
+		}
+	}
+	if exceeded == nil {
+		return usage, nil, nil
+	}
+
+	// Refused calls are not counted, so retrying while the daily quota is used up does not
+	// eat into the monthly one
+	pipe = t.redis.Pipeline()
+	for i, w := range windows {
+		pipe.Decr(ctx, w.key)
+		usage[i].Used--
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, fmt.Errorf("redis update failed: %w", err)
+	}
+	return usage, exceeded, nil
+}
+
+// Usage returns the usage of a client without counting a call
+func (t *Tracker) Usage(ctx context.Context, client string) ([]Usage, error) {
+	windows := t.windows(client)
+	pipe := t.redis.Pipeline()
+	counts := make([]*redis.StringCmd, len(windows))
+	for i, w := range windows {
+		counts[i] = pipe.Get(ctx, w.key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis pipeline execution failed: %w", err)
+	}
+
+	usage := make([]Usage, len(windows))
+	for i, w := range windows {
+		used, _ := counts[i].Int64()
+		usage[i] = Usage{Window: w.name, Used: used, Limit: w.limit, ResetsAt: w.resetsAt}
+	}
+	return usage, nil
+}
@@ -0,0 +1,77 @@
+// Package recording records sanitized requests and their responses to JSON Lines files,
+// so that real traffic shapes can be replayed against another gateway with cmd/replay
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Redacted replaces the values of sanitized headers, fields and query parameters
+const Redacted = "[REDACTED]"
+
+// Exchange is a recorded request and its response, one per line of the files
+type Exchange struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Route      string    `json:"route"` // Route pattern as registered
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+	DurationMS float64   `json:"duration_ms"`
+}
+
+// Request is a recorded request
+type Request struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"` // Path and query
+	Message
+}
+
+// Response is a recorded response
+type Response struct {
+	Status int `json:"status"`
+	Message
+}
+
+// Message is the headers and body of a request or response
+type Message struct {
+	Header http.Header `json:"header,omitempty"`
+	// Body is the JSON body, sanitized
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodySize int             `json:"body_size"`
+	// BodyOmitted tells that the body was not recorded: it is not JSON or too large
+	BodyOmitted bool `json:"body_omitted,omitempty"`
+	// RedactedFields counts the body fields and query parameters whose values were replaced
+	RedactedFields int `json:"redacted_fields,omitempty"`
+}
+
+// Replayable reports whether the request can be sent again as it was: its body was
+// recorded whole and none of its values were redacted. Redacted headers are expected to
+// be supplied again, e.g. the Authorization header.
+func (r Request) Replayable() bool {
+	return !r.BodyOmitted && r.RedactedFields == 0
+}
+
+// Read decodes the exchanges of a recording file, calling fn for each in order until it
+// returns an error
+func Read(r io.Reader, fn func(Exchange) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(exchange); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
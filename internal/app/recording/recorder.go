@@ -0,0 +1,135 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileTimeLayout names the file of each hour, in UTC
+const fileTimeLayout = "20060102-15"
+
+// Recorder writes exchanges to an hourly file of its directory in the background;
+// exchanges arriving faster than they are written are dropped past the buffer
+type Recorder struct {
+	dir    string
+	logger *logrus.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Exchange
+	done   chan struct{}
+
+	// Owned by the writing goroutine
+	file     *os.File
+	writer   *bufio.Writer
+	fileHour string
+}
+
+// New creates the directory of the recordings and starts writing them
+func New(cfg config.RecordingConfig, logger *logrus.Logger) (*Recorder, error) {
+	if err := os.MkdirAll(cfg.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the recording directory: %w", err)
+	}
+	r := &Recorder{
+		dir:    cfg.Directory,
+		logger: logger,
+		queue:  make(chan Exchange, cfg.Buffer),
+		done:   make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+// Record queues an exchange to be written, dropping it when the buffer is full
+func (r *Recorder) Record(exchange Exchange) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- exchange:
+	default:
+		metrics.RecordedExchanges.WithLabelValues("dropped").Inc()
+	}
+}
+
+// Close writes the queued exchanges and closes the current file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.queue)
+	r.mu.Unlock()
+
+	<-r.done
+	return r.closeFile()
+}
+
+// run writes the queued exchanges until the recorder is closed, flushing whenever the
+// queue is empty so that files can be read while recording
+func (r *Recorder) run() {
+	defer close(r.done)
+	for exchange := range r.queue {
+		if err := r.write(exchange); err != nil {
+			metrics.RecordedExchanges.WithLabelValues("failed").Inc()
+			r.logger.WithError(err).Error("Failed to record exchange")
+			continue
+		}
+		metrics.RecordedExchanges.WithLabelValues("written").Inc()
+		if len(r.queue) == 0 {
+			if err := r.writer.Flush(); err != nil {
+				r.logger.WithError(err).Error("Failed to flush recorded exchanges")
+			}
+		}
+	}
+}
+
+// write appends an exchange to the file of its hour
+func (r *Recorder) write(exchange Exchange) error {
+	hour := exchange.Time.UTC().Format(fileTimeLayout)
+	if hour != r.fileHour {
+		if err := r.closeFile(); err != nil {
+			r.logger.WithError(err).Error("Failed to close recording file")
+		}
+		name := filepath.Join(r.dir, "exchanges-"+hour+".jsonl")
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+		if err != nil {
+			return err
+		}
+		r.file, r.writer, r.fileHour = file, bufio.NewWriter(file), hour
+	}
+
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = r.writer.Write(data)
+	return err
+}
+
+// closeFile flushes and closes the current file, if any
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.writer.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file, r.writer, r.fileHour = nil, nil, ""
+	return err
+}
@@ -0,0 +1,158 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Sanitizer replaces the values of sensitive headers, body fields and query parameters
+type Sanitizer struct {
+	headers map[string]bool // Canonical header names
+	fields  []string        // Normalized field names
+}
+
+// NewSanitizer creates a sanitizer of the given headers and fields; a field matches when
+// its name contains an entry, ignoring case and underscores
+func NewSanitizer(headers, fields []string) *Sanitizer {
+	s := &Sanitizer{headers: make(map[string]bool, len(headers))}
+	for _, header := range headers {
+		s.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, field := range fields {
+		s.fields = append(s.fields, normalizeFieldName(field))
+	}
+	return s
+}
+
+// Header returns a copy of the headers with the values of sensitive ones replaced
+func (s *Sanitizer) Header(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	sanitized := make(http.Header, len(header))
+	for name, values := range header {
+		if s.headers[http.CanonicalHeaderKey(name)] {
+			sanitized[name] = []string{Redacted}
+			continue
+		}
+		sanitized[name] = append([]string(nil), values...)
+	}
+	return sanitized
+}
+
+// URI returns the path and query of a URL with the values of sensitive query parameters
+// replaced, and how many were
+func (s *Sanitizer) URI(u *url.URL) (string, int) {
+	if u.RawQuery == "" {
+		return u.RequestURI(), 0
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		// Recorded as the path alone rather than risk leaking what could not be parsed
+		return u.EscapedPath(), 1
+	}
+	redacted := s.redactValues(query)
+	return u.EscapedPath() + "?" + query.Encode(), redacted
+}
+
+// Form returns a form-encoded body with the values of sensitive fields replaced, and how
+// many were. ok is false when the body is not a form, or when it has field names that
+// are not plain names, such as a JSON document sent as a form, which could carry secrets
+// in the names themselves.
+func (s *Sanitizer) Form(body []byte) (sanitized string, redacted int, ok bool) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "", 0, false
+	}
+	for name := range form {
+		if !isPlainFieldName(name) {
+			return "", 0, false
+		}
+	}
+	redacted = s.redactValues(form)
+	return form.Encode(), redacted, true
+}
+
+// isPlainFieldName reports whether a form field name only holds letters, digits and the
+// punctuation of nested names, e.g. items[0].seat_id
+func isPlainFieldName(name string) bool {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.[]", r) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// redactValues replaces the values of sensitive query parameters or form fields, and
+// returns how many were
+func (s *Sanitizer) redactValues(values url.Values) int {
+	redacted := 0
+	for name, list := range values {
+		if s.isSensitive(name) {
+			for i := range list {
+				list[i] = Redacted
+			}
+			redacted++
+		}
+	}
+	return redacted
+}
+
+// Body returns a JSON body with the values of sensitive fields replaced, and how many
+// were. ok is false when the body is not JSON.
+func (s *Sanitizer) Body(body []byte) (sanitized json.RawMessage, redacted int, ok bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, 0, false
+	}
+	value = s.redact(value, &redacted)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, 0, false
+	}
+	return data, redacted, true
+}
+
+// redact replaces the values of sensitive fields of a decoded JSON value, counting them
+func (s *Sanitizer) redact(value interface{}, redacted *int) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if s.isSensitive(key) {
+				v[key] = Redacted
+				*redacted++
+			} else {
+				v[key] = s.redact(field, redacted)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.redact(item, redacted)
+		}
+	}
+	return value
+}
+
+// isSensitive reports whether a field name contains a sanitized field name
+func (s *Sanitizer) isSensitive(name string) bool {
+	normalized := normalizeFieldName(name)
+	for _, field := range s.fields {
+		if strings.Contains(normalized, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFieldName lowercases a field name and drops underscores, so that accessToken,
+// access_token and ACCESS_TOKEN compare equal
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
@@ -0,0 +1,87 @@
+// Package region resolves the region whose backend clusters serve a request and carries
+// it in request contexts, so backend calls can be routed to those clusters
+package region
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"apigw/internal/app/config"
+)
+
+// Sources of the region of a request
+const (
+	SourceHeader  = "header"
+	SourceUser    = "user"
+	SourceCountry = "country"
+	SourceDefault = "default"
+)
+
+// ctxKey is the context key of the region
+type ctxKey struct{}
+
+// NewContext returns a context carrying the ID of a region
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the ID of the region a context carries
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Resolver resolves the region of requests: the one the header names, then the home
+// region of the user, then the region of the country, then the default. A header naming
+// an unknown region is an error; user regions and countries that are not configured are
+// ignored.
+type Resolver struct {
+	header        string
+	countryHeader string
+	countries     map[string]string
+	ids           map[string]bool
+	fallback      string
+}
+
+// NewResolver creates a resolver of the configured regions
+func NewResolver(cfg config.RegionsConfig) *Resolver {
+	r := &Resolver{
+		header:        cfg.Header,
+		countryHeader: cfg.CountryHeader,
+		countries:     make(map[string]string),
+		ids:           make(map[string]bool, len(cfg.List)),
+		fallback:      cfg.Default,
+	}
+	for _, region := range cfg.List {
+		r.ids[region.ID] = true
+		for _, country := range region.Countries {
+			r.countries[country] = region.ID
+		}
+	}
+	return r
+}
+
+// Resolve returns the region of a request made by a user of the given home region, which
+// may be empty, and where it was resolved from; id is empty when the request has none.
+// ok is false when the header names a region that is not configured.
+func (r *Resolver) Resolve(req *http.Request, userRegion string) (id, source string, ok bool) {
+	if r.header != "" {
+		if id := strings.ToLower(strings.TrimSpace(req.Header.Get(r.header))); id != "" {
+			return id, SourceHeader, r.ids[id]
+		}
+	}
+	if r.ids[userRegion] {
+		return userRegion, SourceUser, true
+	}
+	if r.countryHeader != "" {
+		country := strings.ToUpper(strings.TrimSpace(req.Header.Get(r.countryHeader)))
+		if id, found := r.countries[country]; found {
+			return id, SourceCountry, true
+		}
+	}
+	if r.fallback != "" {
+		return r.fallback, SourceDefault, true
+	}
+	return "", "", true
+}
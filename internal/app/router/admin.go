@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http/pprof"
+
+	"apigw/internal/app/acl"
+	"apigw/internal/app/clientip"
+	"apigw/internal/app/config"
+	"apigw/internal/app/handler"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SetupAdminRouter configures the router of the admin listener, serving the metrics,
+// the operator routes and optionally the profiler behind the network ACLs and the
+// listener's authentication. routes describes the public router.
+func SetupAdminRouter(cfg *config.Config, deps Dependencies, routes handler.RouteLister, logger *logrus.Logger) *gin.Engine {
+	deps = deps.withDefaults(cfg, logger)
+
+	router := gin.New()
+	router.Use(gin.LoggerWithConfig(accessLog(cfg, logger)))
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.RequestLoggerMiddleware(logger))
+	router.Use(middleware.RecoveryMiddleware(cfg.Server.Recovery, cfg.App, logger))
+	if deps.Errors != nil {
+		router.Use(middleware.ErrorReportingMiddleware(deps.Errors))
+	}
+	// The network ACLs and blocklist apply to the admin listener too; its clients connect
+	// directly, so forwarding headers are ignored
+	if cfg.ACL.Enabled {
+		if list, err := acl.Compile(cfg.ACL); err != nil {
+			logger.WithError(err).Error("Invalid network ACLs, access control disabled on the admin listener")
+		} else {
+			resolver, _ := clientip.NewResolver(config.HTTPConfig{})
+			router.Use(middleware.ClientIPMiddleware(resolver), middleware.ACLMiddleware(list, deps.Blocklist, logger))
+		}
+	}
+	router.Use(middleware.AdminAuthMiddleware(cfg.Server.Admin, logger))
+
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	registerOperatorRoutes(router.Group("/admin"), cfg, deps, routes, logger)
+
+	if cfg.Server.Admin.Pprof {
+		debug := router.Group("/debug/pprof")
+		{
+			debug.GET("/", gin.WrapF(pprof.Index))
+			debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+			debug.GET("/profile", gin.WrapF(pprof.Profile))
+			debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+			debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+			debug.GET("/trace", gin.WrapF(pprof.Trace))
+			debug.GET("/:profile", func(c *gin.Context) {
+				pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+			})
+		}
+	}
+
+	return router
+}
+
+// registerOperatorRoutes registers the operator routes on a group whose middleware has
+// authenticated an admin
+func registerOperatorRoutes(admin *gin.RouterGroup, cfg *config.Config, deps Dependencies, routes handler.RouteLister, logger *logrus.Logger) {
+	configHandler := handler.NewConfigHandler(cfg, logger)
+	routesHandler := handler.NewRoutesHandler(routes, logger)
+	aclHandler := handler.NewACLHandler(cfg.ACL, deps.Blocklist, deps.Clock, logger)
+	drainHandler := handler.NewDrainHandler(deps.Drainer, logger)
+	bodyLoggingHandler := handler.NewBodyLoggingHandler(cfg.Log.Bodies, deps.BodyLog, routes, deps.Clock, logger)
+
+	admin.GET("/config", configHandler.GetConfig)
+	admin.GET("/routes", routesHandler.GetRoutes)
+	admin.GET("/acl", aclHandler.GetACL)
+	admin.POST("/acl/blocklist", aclHandler.BlockNetwork)
+	admin.DELETE("/acl/blocklist", aclHandler.UnblockNetwork)
+	admin.GET("/drain", drainHandler.GetDrain)
+	admin.POST("/drain", drainHandler.StartDrain)
+	admin.GET("/body-logging", bodyLoggingHandler.GetBodyLogging)
+	admin.POST("/body-logging", bodyLoggingHandler.EnableBodyLogging)
+	admin.DELETE("/body-logging", bodyLoggingHandler.DisableBodyLogging)
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ReloadableHandler serves requests with the most recently built handler,
+// letting a configuration reload swap the router without restarting the server
+type ReloadableHandler struct {
+	current atomic.Value
+}
+
+// handlerBox keeps the stored type stable for atomic.Value
+type handlerBox struct {
+	handler http.Handler
+}
+
+// NewReloadableHandler creates a reloadable handler serving the given handler
+func NewReloadableHandler(handler http.Handler) *ReloadableHandler {
+	r := &ReloadableHandler{}
+	r.Swap(handler)
+	return r
+}
+
+// Swap replaces the handler used for new requests; in-flight requests finish on the old one
+func (r *ReloadableHandler) Swap(handler http.Handler) {
+	r.current.Store(handlerBox{handler: handler})
+}
+
+// ServeHTTP implements http.Handler
+func (r *ReloadableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.current.Load().(handlerBox).handler.ServeHTTP(w, req)
+}
@@ -1,56 +1,100 @@
 package router
 
 import (
+	"slices"
+
+	"apigw/internal/app/acl"
+	"apigw/internal/app/bodylog"
+	"apigw/internal/app/cache"
+	"apigw/internal/app/captcha"
 	"apigw/internal/app/config"
+	"apigw/internal/app/drain"
+	"apigw/internal/app/errreport"
+	"apigw/internal/app/fingerprint"
 	"apigw/internal/app/handler"
+	"apigw/internal/app/limiter"
+	"apigw/internal/app/metrics"
 	"apigw/internal/app/middleware"
+	"apigw/internal/app/openapi"
+	"apigw/internal/app/purchasequeue"
+	"apigw/internal/app/quota"
+	"apigw/internal/app/recording"
+	"apigw/internal/app/waitingroom"
+	"apigw/internal/app/webhook"
 	"apigw/internal/client"
+	"apigw/pkg/utils/clock"
 	"apigw/pkg/utils/crypt/token"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
 )
 
-// SetupRouter configures and returns the HTTP router
-func SetupRouter(
-	cfg *config.Config,
-	userClient *client.UserServiceClient,
-	orderClient *client.OrderServiceClient,
-	redisClient *client.RedisClient,
-	jwtMaker *token.JWTMaker,
-	logger *logrus.Logger,
-) *gin.Engine {
-	// Set Gin mode
-	if cfg.App.Environment == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	}
-
-	router := gin.New()
-
-	// Add middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	router.Use(middleware.CORSMiddleware())
-	router.Use(middleware.ErrorHandlerMiddleware(logger))
-
-	// Add token bucket rate limiter middleware if Redis is available
-	if redisClient != nil {
-		tokenBucketMiddleware := middleware.CreateCustomTokenBucketMiddleware(
-			redisClient.GetClient(),
-			cfg.Redis.TokenBucket.Capacity,
-			cfg.Redis.TokenBucket.RefillRate,
-			cfg.Redis.TokenBucket.RefillInterval,
-			logger,
-		)
-		router.Use(tokenBucketMiddleware)
-		logger.WithFields(logrus.Fields{
-			"capacity":        cfg.Redis.TokenBucket.Capacity,
-			"refill_rate":     cfg.Redis.TokenBucket.RefillRate,
-			"refill_interval": cfg.Redis.TokenBucket.RefillInterval,
-		}).Info("Token bucket rate limiter middleware enabled")
-	} else {
-		logger.Info("Token bucket rate limiter middleware disabled (Redis not available)")
+// Dependencies are the backend clients and the components the routers share, built once
+// and kept across the routers rebuilt on reload. Redis, Cache, Purchases, Webhooks and
+// Recorder may be nil, which disables the features using them; a nil Blocklist, Drainer
+// or Clock is replaced by one local to the router.
+type Dependencies struct {
+	Clients    *client.Registry
+	Redis      *client.RedisClient
+	Cache      *cache.Cache
+	Blocklist  *acl.Blocklist
+	Drainer    *drain.Drainer
+	Purchases  *purchasequeue.Queue
+	Webhooks   *webhook.Dispatcher
+	Recorder   *recording.Recorder
+	TokenMaker *token.JWTMaker
+	Clock      clock.Clock
+	// Fingerprints holds the TLS fingerprints of the connections of the public listeners
+	Fingerprints *fingerprint.Store
+	// Errors reports panics and 5xx responses; nil when error reporting is disabled
+	Errors *errreport.Reporter
+	// BodyLog holds the routes whose bodies are logged, enabled at runtime
+	BodyLog *bodylog.Switch
+	// Limiter holds the mode of the rate limiters as Redis goes down and recovers
+	Limiter *limiter.Watchdog
+}
+
+// withDefaults fills in the optional dependencies the routers cannot do without
+func (d Dependencies) withDefaults(cfg *config.Config, logger *logrus.Logger) Dependencies {
+	if d.Blocklist == nil {
+		d.Blocklist = acl.NewBlocklist(nil, cfg.ACL.KeyPrefix, logger)
 	}
+	if d.Drainer == nil {
+		d.Drainer = drain.New()
+	}
+	if d.Clock == nil {
+		d.Clock = clock.System
+	}
+	if d.BodyLog == nil {
+		d.BodyLog = bodylog.NewSwitch(d.Clock)
+	}
+	if d.Limiter == nil {
+		d.Limiter = limiter.NewWatchdog(cfg.Redis.Watchdog, logger)
+		if d.Redis != nil {
+			d.Limiter.Connected()
+		}
+	}
+	return d
+}
+
+// limiterStore returns where the rate limiters keep their buckets; Redis must be set
+func (d Dependencies) limiterStore() middleware.TokenBucketStore {
+	return middleware.TokenBucketStore{
+		Redis:    d.Redis.GetClient(),
+		Reads:    d.Redis.GetReadClient(),
+		Watchdog: d.Limiter,
+	}
+}
+
+// SetupRouter configures and returns the HTTP router: an engine of NewEngine running the
+// PublicStack, with the routes of users behind the AuthenticatedStack and those of
+// operators behind the AdminStack
+func SetupRouter(cfg *config.Config, deps Dependencies, logger *logrus.Logger) *gin.Engine {
+	deps = deps.withDefaults(cfg, logger)
+
+	router := NewEngine(cfg, logger)
+	router.Use(PublicStack(cfg, deps, logger)...)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -58,35 +102,432 @@ func SetupRouter(
 			"status":    "ok",
 			"service":   cfg.App.Name,
 			"version":   cfg.App.Version,
-			"timestamp": "2024-01-01T00:00:00Z",
+			"timestamp": clock.Timestamp(deps.Clock.Now()),
 		})
 	})
 
+	// Readiness probe and build information
+	healthHandler := handler.NewHealthHandler(deps.Clients, cfg.Server.Readiness.RequireBackends, deps.Drainer, deps.Limiter, logger)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/version", healthHandler.Version)
+
+	// Response cache and request coalescing for GET routes; they run after authentication
+	// in each route group so authenticated responses are never shared between users.
+	// Coalescing comes second so a cache miss costs one upstream call however many
+	// clients ask at once.
+	var invalidator cache.Invalidator = cache.NopInvalidator{}
+	var cached []gin.HandlerFunc
+	if deps.Cache != nil {
+		invalidator = deps.Cache
+		cached = append(cached, middleware.ResponseCacheMiddleware(deps.Cache, cfg.Cache))
+	}
+	if cfg.Coalescing.Enabled {
+		cached = append(cached, middleware.CoalesceMiddleware(cfg.Coalescing))
+	}
+
+	// Order events are published to partner webhooks when they are enabled
+	var publisher webhook.Publisher = webhook.NopPublisher{}
+	if deps.Webhooks != nil {
+		publisher = deps.Webhooks
+	}
+
 	// Create handlers
-	userHandler := handler.NewUserHandler(userClient, logger)
-	orderHandler := handler.NewOrderHandler(orderClient, logger)
+	userHandler := handler.NewUserHandler(deps.Clients.User(), logger)
+	orderHandler := handler.NewOrderHandler(deps.Clients.Order(), invalidator, deps.Purchases, publisher, cfg.Orders, logger)
+	eventHandler := handler.NewEventHandler(deps.Clients.Event(), logger)
+	dashboardHandler := handler.NewDashboardHandler(deps.Clients.User(), deps.Clients.Order(), deps.Clients.Event(), cfg.Dashboard, deps.Clock, logger)
+	avatarHandler := handler.NewAvatarHandler(deps.Clients.User(), cfg.Avatar, logger)
+	errorCatalogHandler := handler.NewErrorCatalogHandler()
+	var idempotencyLocks *client.Locker
+	if deps.Redis != nil {
+		idempotencyLocks = client.NewLocker(deps.Redis.GetClient(), cfg.Redis.LockKeyPrefix+"idempotency:", "idempotency")
+	}
+	paymentHandler := handler.NewPaymentHandler(deps.Clients.Payment(), deps.Clients.Order(), invalidator, publisher, idempotencyLocks, logger)
+	adminHandler := handler.NewAdminHandler(deps.Clients.Event(), deps.Clients.Order(), invalidator, publisher, logger)
 
-	// Create JWT middleware
-	jwtMiddleware := middleware.JWTMiddleware(jwtMaker, logger)
+	// Authentication of the routes of users and of operators
+	authenticated := AuthenticatedStack(deps, logger)
+	adminOnly := AdminStack(deps, logger)
 
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		// User routes (no authentication required)
-		users := api.Group("/users")
-		{
-			users.POST("/register", userHandler.Register)
-			users.POST("/login", userHandler.Login)
-			users.POST("/refresh", userHandler.RefreshToken)
+	// Account recovery endpoints get a dedicated, stricter limiter policy
+	var recoveryLimiters recoveryLimits
+	if deps.Redis != nil {
+		for _, policy := range recoveryPolicies {
+			policyCfg, ok := cfg.Redis.Policies[policy.name]
+			if !ok {
+				continue
+			}
+			limiter := middleware.CreatePolicyTokenBucketMiddleware(
+				deps.limiterStore(), policy.name, policyCfg, policy.keyFunc, logger,
+			)
+			if policy.emailed {
+				recoveryLimiters.emailed = append(recoveryLimiters.emailed, limiter)
+			} else {
+				recoveryLimiters.all = append(recoveryLimiters.all, limiter)
+			}
 		}
+	}
+
+	// Abuse protection: login and registration count failed attempts per email and per
+	// IP, and abuse-prone actions may require a CAPTCHA
+	var bruteForce *middleware.BruteForceGuard
+	if deps.Redis != nil && cfg.BruteForce.Enabled {
+		bruteForce = middleware.NewBruteForceGuard(deps.Redis.GetClient(), cfg.BruteForce, logger)
+	}
+	var captchaGuard *middleware.CaptchaGuard
+	if cfg.Captcha.Enabled {
+		if verifier, err := captcha.New(cfg.Captcha); err != nil {
+			logger.WithError(err).Error("Invalid CAPTCHA settings, CAPTCHA challenges disabled")
+		} else {
+			var captchaRedis redis.UniversalClient
+			if deps.Redis != nil {
+				captchaRedis = deps.Redis.GetClient()
+			}
+			captchaGuard = middleware.NewCaptchaGuard(verifier, captchaRedis, cfg.Captcha, logger)
+			logger.WithField("provider", cfg.Captcha.Provider).Info("CAPTCHA challenges enabled")
+		}
+	}
 
-		// Order routes (authentication required)
-		orders := api.Group("/orders")
-		orders.Use(jwtMiddleware)
+	// High-demand on-sales queue buyers in a waiting room shared through Redis
+	var room *waitingroom.Room
+	if deps.Redis != nil && cfg.WaitingRoom.Enabled {
+		room = waitingroom.New(deps.Redis.GetClient(), cfg.WaitingRoom)
+	}
+	waitingRoomHandler := handler.NewWaitingRoomHandler(room, cfg.WaitingRoom, logger)
+	var waitingRoom gin.HandlerFunc
+	if room != nil {
+		waitingRoom = middleware.WaitingRoomMiddleware(room, cfg.WaitingRoom, logger)
+	}
+	protect := newProtector(bruteForce, waitingRoom, captchaGuard)
+
+	// Metrics and operator routes (admin role required), unless the admin listener
+	// serves them; the route table is filled in once every route is registered
+	routes := NewRouteTable()
+	if !cfg.Server.Admin.Enabled {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+		admin := router.Group("/admin")
+		admin.Use(adminOnly...)
+		registerOperatorRoutes(admin, cfg, deps, routes, logger)
+	}
+
+	// gRPC-Web routes for browser clients
+	if cfg.Server.GRPCWeb.Enabled {
+		grpcWebHandler := handler.NewGRPCWebHandler(deps.Clients.User(), deps.Clients.Order(), logger)
+		grpcWeb := router.Group("/grpc")
+		grpcWeb.Use(middleware.GRPCWebJWTMiddleware(deps.TokenMaker, logger))
 		{
-			orders.POST("/:event_id/purchase", orderHandler.PurchaseTicket)
+			grpcWeb.POST("/:service/:method", grpcWebHandler.Handle)
+		}
+		logger.Info("gRPC-Web endpoint enabled")
+	}
+
+	// Versioned API routes
+	versions := []struct {
+		name     string
+		register func(*gin.RouterGroup)
+	}{
+		{"v1", func(api *gin.RouterGroup) {
+			registerV1Routes(api, userHandler, orderHandler, eventHandler, paymentHandler, waitingRoomHandler, dashboardHandler, avatarHandler, errorCatalogHandler, authenticated, protect, recoveryLimiters, cached)
+			registerAdminRoutes(api, adminHandler, waitingRoomHandler, adminOnly)
+			if deps.Webhooks != nil {
+				registerPartnerRoutes(api, handler.NewWebhookHandler(deps.Webhooks, logger))
+			}
+			if deps.Redis != nil && cfg.Quotas.Enabled {
+				tracker := quota.New(deps.Redis.GetClient(), cfg.Quotas, deps.Clock)
+				registerUsageRoutes(api, handler.NewUsageHandler(tracker, logger))
+			}
+		}},
+		{"v2", func(api *gin.RouterGroup) {
+			registerV2Routes(api, userHandler, orderHandler, eventHandler, paymentHandler, waitingRoomHandler, authenticated, protect, recoveryLimiters, cached)
+		}},
+	}
+	for _, version := range versions {
+		prefix := "/api/" + version.name
+		if !cfg.API.IsVersionEnabled(version.name) {
+			router.Any(prefix+"/*path", middleware.RetiredVersionHandler(version.name))
+			logger.WithField("api_version", version.name).Info("API version disabled")
+			continue
 		}
+
+		api := router.Group(prefix)
+		api.Use(middleware.APIVersionMiddleware(version.name, cfg.API.Versions[version.name], logger))
+		version.register(api)
 	}
 
+	described := DescribeRoutes(router, cfg)
+	routes.Set(described)
+
+	// The document is built from the route table, so the docs routes are registered last
+	// and do not document themselves
+	if cfg.Docs.Enabled {
+		doc := openapi.Build(
+			openapi.Info{Title: cfg.App.Name, Version: cfg.App.Version},
+			described.Routes, handler.Operations, cfg.API.Versions,
+		)
+		docsHandler := handler.NewDocsHandler(doc, cfg.Docs, logger)
+		router.GET(handler.OpenAPIPath, docsHandler.OpenAPI)
+		router.GET("/docs", docsHandler.SwaggerUI)
+	}
 	return router
 }
+
+// registerV1Routes registers the v1 API routes
+func registerV1Routes(
+	api *gin.RouterGroup,
+	userHandler *handler.UserHandler,
+	orderHandler *handler.OrderHandler,
+	eventHandler *handler.EventHandler,
+	paymentHandler *handler.PaymentHandler,
+	waitingRoomHandler *handler.WaitingRoomHandler,
+	dashboardHandler *handler.DashboardHandler,
+	avatarHandler *handler.AvatarHandler,
+	errorCatalogHandler *handler.ErrorCatalogHandler,
+	authenticated []gin.HandlerFunc,
+	protect protectFunc,
+	recoveryLimiters recoveryLimits,
+	cached []gin.HandlerFunc,
+) {
+	// User routes (no authentication required, login and registration protected against abuse)
+	users := api.Group("/users")
+	{
+		users.POST("/register", protect("register", userHandler.Register)...)
+		users.POST("/login", protect("login", userHandler.Login)...)
+		users.POST("/refresh", userHandler.RefreshToken)
+	}
+
+	// Account recovery routes (no authentication required, strictly rate limited)
+	recovery := api.Group("/users")
+	recovery.Use(recoveryLimiters.all...)
+	{
+		recovery.POST("/verify-email/request", recoveryLimiters.limitEmail(userHandler.RequestEmailVerification)...)
+		recovery.POST("/verify-email/confirm", userHandler.ConfirmEmailVerification)
+		recovery.POST("/password-reset/request", recoveryLimiters.limitEmail(protect("password_reset", userHandler.RequestPasswordReset)...)...)
+		recovery.POST("/password-reset/confirm", userHandler.ResetPassword)
+	}
+
+	// Profile routes (authentication required)
+	me := api.Group("/users/me")
+	me.Use(authenticated...)
+	me.Use(cached...)
+	{
+		me.GET("", userHandler.GetProfile)
+		me.PUT("", userHandler.UpdateProfile)
+		me.PUT("/password", userHandler.ChangePassword)
+		me.PUT("/avatar", avatarHandler.UploadAvatar)
+	}
+
+	// Aggregated routes for app clients (authentication required)
+	bff := api.Group("/me")
+	bff.Use(authenticated...)
+	bff.Use(cached...)
+	{
+		bff.GET("/dashboard", dashboardHandler.GetDashboard)
+	}
+
+	// Error catalog (no authentication required)
+	errorCatalog := api.Group("/errors")
+	{
+		errorCatalog.GET("", errorCatalogHandler.ListErrors)
+		errorCatalog.GET("/:code", errorCatalogHandler.GetError)
+	}
+
+	// Event catalog routes (no authentication required)
+	events := api.Group("/events")
+	events.Use(cached...)
+	{
+		events.GET("", eventHandler.ListEvents)
+		events.GET("/:event_id", eventHandler.GetEvent)
+		events.GET("/:event_id/seats", eventHandler.GetSeatMap)
+	}
+
+	// Waiting room status (authentication required, never cached)
+	waitingRoom := api.Group("/events")
+	waitingRoom.Use(authenticated...)
+	{
+		waitingRoom.GET("/:event_id/waiting-room", waitingRoomHandler.GetTicket)
+	}
+
+	// Order routes (authentication required)
+	orders := api.Group("/orders")
+	orders.Use(authenticated...)
+	orders.Use(cached...)
+	{
+		orders.GET("", orderHandler.ListOrders)
+		orders.GET("/:order_id", orderHandler.GetOrder)
+		orders.GET("/:order_id/status", orderHandler.GetPurchaseStatus)
+		orders.DELETE("/:order_id", orderHandler.CancelOrder)
+		orders.POST("/purchase", protect("purchase", orderHandler.PurchaseTicket)...)
+		orders.POST("/purchase-batch", protect("purchase", orderHandler.PurchaseBatch)...)
+		orders.POST("/:event_id/purchase", protect("purchase", orderHandler.PurchaseTicket)...)
+	}
+
+	// Payment routes (authentication required)
+	payments := api.Group("/payments")
+	payments.Use(authenticated...)
+	payments.Use(cached...)
+	{
+		payments.POST("", paymentHandler.CreatePayment)
+		payments.GET("/:payment_id", paymentHandler.GetPayment)
+		payments.POST("/:payment_id/confirm", paymentHandler.ConfirmPayment)
+	}
+}
+
+// registerV2Routes registers the v2 API routes
+func registerV2Routes(
+	api *gin.RouterGroup,
+	userHandler *handler.UserHandler,
+	orderHandler *handler.OrderHandler,
+	eventHandler *handler.EventHandler,
+	paymentHandler *handler.PaymentHandler,
+	waitingRoomHandler *handler.WaitingRoomHandler,
+	authenticated []gin.HandlerFunc,
+	protect protectFunc,
+	recoveryLimiters recoveryLimits,
+	cached []gin.HandlerFunc,
+) {
+	// User routes (no authentication required, login and registration protected against abuse)
+	users := api.Group("/users")
+	{
+		users.POST("/register", protect("register", userHandler.RegisterV2)...)
+		users.POST("/login", protect("login", userHandler.LoginV2)...)
+		users.POST("/refresh", userHandler.RefreshTokenV2)
+	}
+
+	// Account recovery routes (no authentication required, strictly rate limited)
+	recovery := api.Group("/users")
+	recovery.Use(recoveryLimiters.all...)
+	{
+		recovery.POST("/verify-email/request", recoveryLimiters.limitEmail(userHandler.RequestEmailVerification)...)
+		recovery.POST("/verify-email/confirm", userHandler.ConfirmEmailVerification)
+		recovery.POST("/password-reset/request", recoveryLimiters.limitEmail(protect("password_reset", userHandler.RequestPasswordReset)...)...)
+		recovery.POST("/password-reset/confirm", userHandler.ResetPassword)
+	}
+
+	// Profile routes (authentication required)
+	me := api.Group("/users/me")
+	me.Use(authenticated...)
+	me.Use(cached...)
+	{
+		me.GET("", userHandler.GetProfile)
+		me.PUT("", userHandler.UpdateProfile)
+		me.PUT("/password", userHandler.ChangePassword)
+	}
+
+	// Event catalog routes (no authentication required)
+	events := api.Group("/events")
+	events.Use(cached...)
+	{
+		events.GET("", eventHandler.ListEventsV2)
+		events.GET("/:event_id", eventHandler.GetEvent)
+		events.GET("/:event_id/seats", eventHandler.GetSeatMap)
+	}
+
+	// Waiting room status (authentication required, never cached)
+	waitingRoom := api.Group("/events")
+	waitingRoom.Use(authenticated...)
+	{
+		waitingRoom.GET("/:event_id/waiting-room", waitingRoomHandler.GetTicket)
+	}
+
+	// Order routes (authentication required)
+	orders := api.Group("/orders")
+	orders.Use(authenticated...)
+	orders.Use(cached...)
+	{
+		orders.GET("", orderHandler.ListOrdersV2)
+		orders.GET("/:order_id", orderHandler.GetOrder)
+		orders.GET("/:order_id/status", orderHandler.GetPurchaseStatus)
+		orders.DELETE("/:order_id", orderHandler.CancelOrder)
+		orders.POST("/purchase", protect("purchase", orderHandler.PurchaseTicket)...)
+		orders.POST("/purchase-batch", protect("purchase", orderHandler.PurchaseBatch)...)
+		orders.POST("/:event_id/purchase", protect("purchase", orderHandler.PurchaseTicket)...)
+	}
+
+	// Payment routes (authentication required)
+	payments := api.Group("/payments")
+	payments.Use(authenticated...)
+	payments.Use(cached...)
+	{
+		payments.POST("", paymentHandler.CreatePayment)
+		payments.GET("/:payment_id", paymentHandler.GetPayment)
+		payments.POST("/:payment_id/confirm", paymentHandler.ConfirmPayment)
+	}
+}
+
+// registerAdminRoutes registers the operator routes, restricted to the admin role
+func registerAdminRoutes(
+	api *gin.RouterGroup,
+	adminHandler *handler.AdminHandler,
+	waitingRoomHandler *handler.WaitingRoomHandler,
+	adminOnly []gin.HandlerFunc,
+) {
+	admin := api.Group("/admin")
+	admin.Use(adminOnly...)
+	{
+		admin.POST("/events", adminHandler.CreateEvent)
+		admin.PATCH("/events/:event_id", adminHandler.UpdateEvent)
+		admin.POST("/events/:event_id/close", adminHandler.CloseEvent)
+		admin.POST("/events/:event_id/inventory", adminHandler.AdjustInventory)
+		admin.POST("/orders/:order_id/cancel", adminHandler.ForceCancelOrder)
+		admin.GET("/events/:event_id/waiting-room", waitingRoomHandler.GetWaitingRoom)
+		admin.PUT("/events/:event_id/waiting-room", waitingRoomHandler.OpenWaitingRoom)
+		admin.DELETE("/events/:event_id/waiting-room", waitingRoomHandler.CloseWaitingRoom)
+	}
+}
+
+// registerPartnerRoutes registers the routes of partner integrations, which the signature
+// middleware authenticates (webhooks require signing of config.WebhooksPath)
+func registerPartnerRoutes(api *gin.RouterGroup, webhookHandler *handler.WebhookHandler) {
+	webhooks := api.Group("/partner/webhooks")
+	{
+		webhooks.POST("", webhookHandler.CreateWebhook)
+		webhooks.GET("", webhookHandler.ListWebhooks)
+		webhooks.GET("/:webhook_id", webhookHandler.GetWebhook)
+		webhooks.PATCH("/:webhook_id", webhookHandler.UpdateWebhook)
+		webhooks.DELETE("/:webhook_id", webhookHandler.DeleteWebhook)
+		webhooks.GET("/:webhook_id/deliveries", webhookHandler.ListDeliveries)
+		webhooks.GET("/:webhook_id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+	}
+}
+
+// registerUsageRoutes registers the route clients read their quota consumption from, at
+// config.UsagePath (no authentication required, the caller is the client of its quotas)
+func registerUsageRoutes(api *gin.RouterGroup, usageHandler *handler.UsageHandler) {
+	api.GET("/usage", usageHandler.GetUsage)
+}
+
+// recoveryLimits are the limiters of the account recovery routes: all runs on every route,
+// emailed only on the routes requesting an email, the others carrying a token instead
+type recoveryLimits struct {
+	all     []gin.HandlerFunc
+	emailed []gin.HandlerFunc
+}
+
+// limitEmail returns the email limiters followed by the handlers of a route requesting an
+// email
+func (l recoveryLimits) limitEmail(handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	return slices.Concat(l.emailed, handlers)
+}
+
+// protectFunc returns the abuse protection middleware of an action followed by its handler
+type protectFunc func(action string, h gin.HandlerFunc) []gin.HandlerFunc
+
+// newProtector chains the brute-force guard (login and register only), the waiting room
+// (purchases only) and the CAPTCHA guard, any of which may be nil, in front of the
+// handlers of abuse-prone actions. Queued buyers are turned away before being asked to
+// solve a CAPTCHA.
+func newProtector(bruteForce *middleware.BruteForceGuard, waitingRoom gin.HandlerFunc, captchaGuard *middleware.CaptchaGuard) protectFunc {
+	return func(action string, h gin.HandlerFunc) []gin.HandlerFunc {
+		var chain []gin.HandlerFunc
+		if bruteForce != nil && (action == "login" || action == "register") {
+			chain = append(chain, bruteForce.Middleware(action))
+		}
+		if waitingRoom != nil && action == "purchase" {
+			chain = append(chain, waitingRoom)
+		}
+		if captchaGuard != nil {
+			chain = append(chain, captchaGuard.Middleware(action))
+		}
+		return append(chain, h)
+	}
+}
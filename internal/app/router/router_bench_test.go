@@ -0,0 +1,115 @@
+package router
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/client"
+	"apigw/internal/fakebackend"
+	"apigw/pkg/utils/crypt/token"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// benchmarkGateway builds the full router on default settings against a fake backend,
+// with the token bucket rate limiter backed by an in-memory Redis
+func benchmarkGateway(b *testing.B) (http.Handler, string) {
+	b.Helper()
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+	// The shared logger also carries the upstream connection state logs
+	logger := logutils.GetLogger()
+	logger.SetOutput(io.Discard)
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	backend := fakebackend.New(0)
+	if err := backend.Start("127.0.0.1:0"); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(backend.Stop)
+	backend.Route(&cfg.Services)
+
+	redisServer := miniredis.RunT(b)
+	host, port, _ := net.SplitHostPort(redisServer.Addr())
+	cfg.Redis.Enabled = true
+	cfg.Redis.Host = host
+	cfg.Redis.Port, _ = strconv.Atoi(port)
+	// Measure the limiter's cost without ever running out of tokens
+	cfg.Redis.TokenBucket.Capacity = 1 << 30
+	redisClient, err := client.NewRedisClient(&cfg.Redis, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { redisClient.Close() })
+
+	clients, err := client.NewRegistry(client.NewClientFactory(), cfg.Services, client.Overrides{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { clients.Close() })
+
+	maker, err := token.NewJWTTokenMaker(cfg.JWT.SecretKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+	bearer, err := maker.CreateToken("bench-user", "", time.Hour)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	deps := Dependencies{Clients: clients, Redis: redisClient, TokenMaker: maker}
+	return SetupRouter(cfg, deps, logger), bearer
+}
+
+// BenchmarkGateway measures requests through the whole middleware chain (rate limiter,
+// JWT verification, handlers, gRPC calls and JSON encoding) against a fake backend
+func BenchmarkGateway(b *testing.B) {
+	gateway, bearer := benchmarkGateway(b)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		auth   bool
+	}{
+		{name: "GetEvent", method: http.MethodGet, path: "/api/v1/events/evt_1"},
+		{name: "ListEvents", method: http.MethodGet, path: "/api/v1/events?limit=20"},
+		{name: "ListOrders", method: http.MethodGet, path: "/api/v1/orders", auth: true},
+		{name: "Purchase", method: http.MethodPost, path: "/api/v1/orders/purchase", body: `{"eventId":"evt_1","quantity":2}`, auth: true},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+					if tc.body != "" {
+						req.Header.Set("Content-Type", "application/json")
+					}
+					if tc.auth {
+						req.Header.Set("Authorization", "Bearer "+bearer)
+					}
+					w := httptest.NewRecorder()
+					gateway.ServeHTTP(w, req)
+					if w.Code != http.StatusOK {
+						b.Fatalf("%s %s: status %d: %s", tc.method, tc.path, w.Code, w.Body.String())
+					}
+				}
+			})
+		})
+	}
+}
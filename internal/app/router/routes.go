@@ -0,0 +1,283 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/dto"
+	"apigw/internal/app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recoveryPolicies are the limiter policies of the account recovery routes, in the order
+// their middleware runs. Emailed policies only limit the routes requesting an email, which
+// are refused without one.
+var recoveryPolicies = []struct {
+	name    string
+	keyFunc middleware.ClientKeyFunc
+	emailed bool
+}{
+	{"account_recovery_ip", middleware.IPKeyFunc, false},
+	{"account_recovery_email", middleware.RequiredEmailKeyFunc, true},
+}
+
+// xmlBodies are the request DTOs of the routes accepting XML request bodies when they are
+// listed under xml.routes
+var xmlBodies = middleware.XMLBodies{
+	"POST /api/v1/users/register":            func() interface{} { return new(dto.RegisterReq) },
+	"POST /api/v1/users/login":               func() interface{} { return new(dto.LoginReq) },
+	"POST /api/v1/users/refresh":             func() interface{} { return new(dto.RefreshTokenReq) },
+	"POST /api/v1/orders/purchase":           func() interface{} { return new(dto.PurchaseTicketReq) },
+	"POST /api/v1/orders/:event_id/purchase": func() interface{} { return new(dto.PurchaseTicketReq) },
+	"POST /api/v1/orders/purchase-batch":     func() interface{} { return new(dto.PurchaseBatchReq) },
+	"DELETE /api/v1/orders/:order_id":        func() interface{} { return new(dto.CancelOrderReq) },
+}
+
+// handlerUpstreams maps the handler types to the backend services they call
+var handlerUpstreams = map[string][]string{
+	"UserHandler":      {"user_service"},
+	"OrderHandler":     {"order_service"},
+	"EventHandler":     {"event_service"},
+	"PaymentHandler":   {"payment_service", "order_service"},
+	"AdminHandler":     {"event_service", "order_service"},
+	"GRPCWebHandler":   {"user_service", "order_service"},
+	"DashboardHandler": {"user_service", "order_service", "event_service"},
+	"AvatarHandler":    {"user_service"},
+}
+
+// Short names of the middleware the route table recognizes
+const (
+	jwtName         = "middleware.JWTMiddleware"
+	grpcWebJWTName  = "middleware.GRPCWebJWTMiddleware"
+	roleName        = "middleware.RequireRole"
+	tokenBucketName = "middleware.(*TokenBucket).TokenBucketMiddleware"
+	bruteForceName  = "middleware.(*BruteForceGuard).Middleware"
+	waitingRoomName = "middleware.WaitingRoomMiddleware"
+	captchaName     = "middleware.(*CaptchaGuard).Middleware"
+	timeoutName     = "middleware.TimeoutMiddleware"
+	cacheName       = "middleware.ResponseCacheMiddleware"
+	coalesceName    = "middleware.CoalesceMiddleware"
+	signatureName   = "middleware.SignatureMiddleware"
+	xmlName         = "middleware.(*XMLNegotiation).Responses"
+)
+
+var (
+	// funcSuffix matches the suffixes of closures and method values in function names
+	funcSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+	// receiverType matches the receiver type in a method name
+	receiverType = regexp.MustCompile(`\(\*(\w+)\)`)
+	// routeParam matches the parameters and wildcards of a route pattern
+	routeParam = regexp.MustCompile(`[:*][^/]+`)
+)
+
+// probeKey marks the context of a request reading the handler chain of a route
+type probeKey struct{}
+
+// unroutedPath is probed for the router-wide middleware; no route matches it
+const unroutedPath = "/.route-probe"
+
+// routeProbe is the first middleware of the public router. It answers the requests made
+// by DescribeRoutes with the names of the handlers the route runs, without running them.
+func routeProbe(c *gin.Context) {
+	if chain, ok := c.Request.Context().Value(probeKey{}).(*probeResult); ok {
+		chain.route = c.FullPath()
+		chain.handlers = c.HandlerNames()
+		c.Abort()
+	}
+}
+
+// probeResult is the handler chain a probe request matched
+type probeResult struct {
+	route    string
+	handlers []string
+}
+
+// probe returns the short names of the handlers serving method and path, and the route
+// pattern that matched
+func probe(engine *gin.Engine, method, path string) probeResult {
+	var result probeResult
+	ctx := context.WithValue(context.Background(), probeKey{}, &result)
+	req := httptest.NewRequest(method, path, nil).WithContext(ctx)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	for i, name := range result.handlers {
+		result.handlers[i] = shortName(name)
+	}
+	return result
+}
+
+// shortName strips the import path and closure suffixes from a function name, e.g.
+// middleware.JWTMiddleware for apigw/internal/app/middleware.JWTMiddleware.func1
+func shortName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return funcSuffix.ReplaceAllString(name, "")
+}
+
+// DescribeRoutes lists the routes of the public router with the middleware each runs and
+// the settings that apply to it. The probe middleware must be the router's first.
+func DescribeRoutes(engine *gin.Engine, cfg *config.Config) dto.RoutesResp {
+	global := probe(engine, http.MethodGet, unroutedPath).handlers
+	resp := dto.RoutesResp{
+		Middleware: withoutProbe(global),
+		Routes:     []dto.RouteResp{},
+	}
+	globalLimit := contains(global, tokenBucketName)
+
+	for _, info := range engine.Routes() {
+		route := dto.RouteResp{
+			Method:     info.Method,
+			Path:       info.Path,
+			Handler:    shortName(info.Handler),
+			Auth:       []string{},
+			Middleware: []string{},
+		}
+
+		result := probe(engine, info.Method, routeParam.ReplaceAllString(info.Path, "x"))
+		if result.route == info.Path && len(result.handlers) > len(global) {
+			route.Middleware = result.handlers[len(global) : len(result.handlers)-1]
+		}
+		own := route.Middleware
+
+		if contains(own, jwtName) || contains(own, grpcWebJWTName) {
+			route.Auth = append(route.Auth, "jwt")
+		}
+		if contains(own, roleName) {
+			// The router only restricts routes to the admin role
+			route.Auth = append(route.Auth, "role:"+middleware.RoleAdmin)
+		}
+		if cfg.Signing.Enabled && contains(global, signatureName) && cfg.Signing.Required(info.Path) {
+			route.Auth = append(route.Auth, "signature")
+		}
+
+		if globalLimit {
+			route.RateLimits = append(route.RateLimits, rateLimit("global", config.TokenBucketConfig{
+				Capacity:       cfg.Redis.TokenBucket.Capacity,
+				RefillRate:     cfg.Redis.TokenBucket.RefillRate,
+				RefillInterval: cfg.Redis.TokenBucket.RefillInterval,
+			}))
+		}
+		fingerprinted := slices.Contains(cfg.Fingerprint.Routes, info.Method+" "+info.Path) || slices.Contains(cfg.Fingerprint.Routes, info.Path)
+		if globalLimit && cfg.Fingerprint.Enabled && cfg.Fingerprint.RateLimit != (config.TokenBucketConfig{}) && fingerprinted {
+			route.RateLimits = append(route.RateLimits, rateLimit("fingerprint", cfg.Fingerprint.RateLimit))
+		}
+		// The recovery limiters a route runs are the first configured policies, in order
+		limiters := count(own, tokenBucketName)
+		for _, policy := range recoveryPolicies {
+			if limiters == 0 {
+				break
+			}
+			if policyCfg, ok := cfg.Redis.Policies[policy.name]; ok {
+				route.RateLimits = append(route.RateLimits, rateLimit(policy.name, policyCfg))
+				limiters--
+			}
+		}
+
+		for _, p := range []struct{ name, protection string }{
+			{bruteForceName, "brute_force"},
+			{waitingRoomName, "waiting_room"},
+			{captchaName, "captcha"},
+		} {
+			if contains(own, p.name) {
+				route.Protection = append(route.Protection, p.protection)
+			}
+		}
+
+		if contains(global, timeoutName) {
+			if budget := cfg.Server.HTTP.CallBudget(info.Method, info.Path); budget > 0 {
+				route.Timeout = budget.String()
+			}
+		}
+		if info.Method == http.MethodGet {
+			if routeCfg, ok := cfg.Cache.Route(info.Path); ok && contains(own, cacheName) {
+				route.CacheTTL = routeCfg.TTL.String()
+			}
+			route.Coalesced = contains(own, coalesceName)
+		}
+
+		route.XML = contains(global, xmlName) && slices.Contains(cfg.XML.Routes, info.Path)
+
+		if m := receiverType.FindStringSubmatch(route.Handler); m != nil {
+			services := cfg.Services.All()
+			for _, name := range handlerUpstreams[m[1]] {
+				upstream := dto.RouteUpstreamResp{Service: name, Endpoints: []string{}}
+				for _, ep := range services[name].ResolvedEndpoints() {
+					upstream.Endpoints = append(upstream.Endpoints, ep.Address())
+				}
+				route.Upstreams = append(route.Upstreams, upstream)
+			}
+		}
+
+		resp.Routes = append(resp.Routes, route)
+	}
+	return resp
+}
+
+// rateLimit describes a token bucket policy
+func rateLimit(policy string, cfg config.TokenBucketConfig) dto.RouteRateLimitResp {
+	return dto.RouteRateLimitResp{
+		Policy:         policy,
+		Capacity:       cfg.Capacity,
+		RefillRate:     cfg.RefillRate,
+		RefillInterval: cfg.RefillInterval.String(),
+	}
+}
+
+// withoutProbe drops the probe middleware from a chain
+func withoutProbe(chain []string) []string {
+	out := make([]string, 0, len(chain))
+	for _, name := range chain {
+		if name != "router.routeProbe" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// count returns how many times a chain runs the named middleware
+func count(chain []string, name string) int {
+	n := 0
+	for _, handler := range chain {
+		if handler == name {
+			n++
+		}
+	}
+	return n
+}
+
+// contains reports whether a chain runs the named middleware
+func contains(chain []string, name string) bool {
+	return slices.Contains(chain, name)
+}
+
+// RouteTable holds the description of the current public router, which is replaced on
+// every configuration reload
+type RouteTable struct {
+	routes atomic.Pointer[dto.RoutesResp]
+}
+
+// NewRouteTable creates an empty route table
+func NewRouteTable() *RouteTable {
+	return &RouteTable{}
+}
+
+// Set replaces the described routes
+func (t *RouteTable) Set(routes dto.RoutesResp) {
+	t.routes.Store(&routes)
+}
+
+// Routes returns the described routes
+func (t *RouteTable) Routes() dto.RoutesResp {
+	if routes := t.routes.Load(); routes != nil {
+		return *routes
+	}
+	return dto.RoutesResp{Middleware: []string{}, Routes: []dto.RouteResp{}}
+}
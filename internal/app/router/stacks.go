@@ -0,0 +1,243 @@
+package router
+
+import (
+	"apigw/internal/app/acl"
+	"apigw/internal/app/clientip"
+	"apigw/internal/app/config"
+	"apigw/internal/app/faults"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/quota"
+	"apigw/internal/app/region"
+	"apigw/internal/app/signing"
+	"apigw/internal/app/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// NewEngine creates a Gin engine trusting the forwarding headers of the configured
+// proxies only, with the route probe of the route table and the access log, which shows
+// client fingerprints when they are enabled and follows the ecs log format
+func NewEngine(cfg *config.Config, logger *logrus.Logger) *gin.Engine {
+	if cfg.App.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	engine := gin.New()
+
+	// Gin's own resolution of the client address is only used by its access log, everything
+	// else reads the address resolved by ClientIPMiddleware
+	if err := engine.SetTrustedProxies(cfg.Server.HTTP.TrustedProxies); err != nil {
+		engine.SetTrustedProxies(nil)
+	}
+	engine.RemoteIPHeaders = cfg.Server.HTTP.ClientIPHeaders
+
+	// The route probe comes first so /admin/routes can read every route's handler chain
+	engine.Use(routeProbe)
+	// The access log goes to the output of the logger, e.g. its rotated file
+	access := accessLog(cfg, logger)
+	if cfg.Fingerprint.Enabled && cfg.Log.Format != "ecs" {
+		access.Formatter = middleware.AccessLogFormatter
+	}
+	engine.Use(gin.LoggerWithConfig(access))
+	return engine
+}
+
+// accessLog returns the configuration of an access log written to the output of the
+// logger, in the ecs format when logs are
+func accessLog(cfg *config.Config, logger *logrus.Logger) gin.LoggerConfig {
+	access := gin.LoggerConfig{Output: logger.Out}
+	if cfg.Log.Format == "ecs" {
+		access.Formatter = middleware.ECSAccessLogFormatter(cfg.App.Name)
+	}
+	return access
+}
+
+// PublicStack returns the middleware every public route runs, in order: request IDs,
+// client addresses and fingerprints, the request logger, slow request logging, the
+// rendering of errors and panics, error reporting, load shedding, tenants, regions,
+// network ACLs, fault injection, CORS, the request deadline, the global or tenant rate
+// limit and the fingerprint rate limit, body limits, traffic recording, body logging,
+// request signatures and client quotas. Features whose settings are disabled or whose
+// dependencies are nil are left out.
+func PublicStack(cfg *config.Config, deps Dependencies, logger *logrus.Logger) []gin.HandlerFunc {
+	deps = deps.withDefaults(cfg, logger)
+
+	// Only trust forwarding headers set by the configured proxies
+	resolver, err := clientip.NewResolver(cfg.Server.HTTP)
+	if err != nil {
+		logger.WithError(err).Error("Invalid trusted proxies, forwarding headers are ignored")
+		resolver, _ = clientip.NewResolver(config.HTTPConfig{})
+	}
+	stack := []gin.HandlerFunc{
+		middleware.RequestIDMiddleware(),
+		middleware.ClientIPMiddleware(resolver),
+	}
+	if cfg.Fingerprint.Enabled {
+		stack = append(stack, middleware.FingerprintMiddleware(deps.Fingerprints, cfg.Fingerprint))
+	}
+	// Handlers log with the logger of their request, which carries the fields above
+	stack = append(stack, middleware.RequestLoggerMiddleware(logger))
+	// Requests are timed from here on, so slow ones are logged with their stages
+	if cfg.SlowRequests.Enabled {
+		stack = append(stack, middleware.SlowRequestMiddleware(cfg.SlowRequests, logger))
+		logger.WithField("threshold", cfg.SlowRequests.Threshold).Info("Slow request logging enabled")
+	}
+
+	// Legacy partners may exchange XML on selected routes; responses are rendered ahead of
+	// the recovery middleware so that every error reaches them as XML
+	var xmlNegotiation *middleware.XMLNegotiation
+	if cfg.XML.Enabled {
+		xmlNegotiation = middleware.NewXMLNegotiation(cfg.XML, xmlBodies, logger)
+		stack = append(stack, xmlNegotiation.Responses())
+		logger.WithField("routes", len(cfg.XML.Routes)).Info("XML content negotiation enabled")
+	}
+	// Tenants and partners may brand responses, error templates replacing problem details
+	if cfg.Tenants.Enabled || cfg.Signing.Enabled {
+		stack = append(stack, middleware.BrandingMiddleware(cfg, logger))
+	}
+	// Errors are rendered as problem details for clients asking for them, or for every
+	// client when errors.format is problem
+	stack = append(stack,
+		middleware.ProblemDetailsMiddleware(cfg.Errors, logger),
+		middleware.RecoveryMiddleware(cfg.Server.Recovery, cfg.App, logger),
+	)
+	// Panics and 5xx responses are reported before the recovery middleware answers them
+	if deps.Errors != nil {
+		stack = append(stack, middleware.ErrorReportingMiddleware(deps.Errors))
+	}
+
+	// Requests are shed by priority class before any work is spent on them
+	if cfg.LoadShedding.Enabled {
+		stack = append(stack, middleware.LoadSheddingMiddleware(cfg.LoadShedding, deps.Drainer.InFlight, logger))
+		logger.WithFields(logrus.Fields{
+			"max_in_flight": cfg.LoadShedding.MaxInFlight,
+			"classes":       len(cfg.LoadShedding.Classes),
+		}).Info("Load shedding enabled")
+	}
+
+	// The tenant is resolved before anything is scoped to it
+	if cfg.Tenants.Enabled {
+		stack = append(stack, middleware.TenantMiddleware(tenant.NewResolver(cfg.Tenants), logger))
+		logger.WithField("tenants", len(cfg.Tenants.List)).Info("Tenant resolution enabled")
+	}
+	if cfg.Regions.Enabled {
+		stack = append(stack, middleware.RegionMiddleware(region.NewResolver(cfg.Regions), deps.TokenMaker, logger))
+		logger.WithField("regions", len(cfg.Regions.List)).Info("Regional routing enabled")
+	}
+
+	// Network access control lists are evaluated before authentication and rate limiting
+	if cfg.ACL.Enabled {
+		if list, err := acl.Compile(cfg.ACL); err != nil {
+			logger.WithError(err).Error("Invalid network ACLs, access control disabled")
+		} else {
+			stack = append(stack, middleware.ACLMiddleware(list, deps.Blocklist, logger))
+			logger.WithFields(logrus.Fields{
+				"deny":  len(cfg.ACL.Deny),
+				"rules": len(cfg.ACL.Rules),
+			}).Info("Network ACL middleware enabled")
+		}
+	}
+	// Faults are injected for resilience testing outside production, before the request
+	// deadline starts so injected latency does not eat into it
+	if cfg.Faults.Enabled && cfg.App.Environment != "production" {
+		injector := faults.New(cfg.Faults)
+		stack = append(stack, middleware.FaultInjectionMiddleware(injector, logger))
+		logger.WithField("rules", len(cfg.Faults.Rules)).Warn("Fault injection enabled")
+	}
+	stack = append(stack,
+		middleware.CORSMiddleware(),
+		middleware.ErrorHandlerMiddleware(logger),
+		middleware.TimeoutMiddleware(cfg.Server.HTTP),
+	)
+
+	// Add token bucket rate limiter middleware if Redis is available; tenants may have
+	// limits of their own
+	if deps.Redis != nil {
+		global := middleware.CreateCustomTokenBucketMiddleware(
+			deps.limiterStore(),
+			cfg.Redis.TokenBucket.Capacity,
+			cfg.Redis.TokenBucket.RefillRate,
+			cfg.Redis.TokenBucket.RefillInterval,
+			logger,
+		)
+		stack = append(stack, middleware.TenantTokenBucketMiddleware(deps.limiterStore(), cfg.Tenants.Active(), global, logger))
+		logger.WithFields(logrus.Fields{
+			"capacity":        cfg.Redis.TokenBucket.Capacity,
+			"refill_rate":     cfg.Redis.TokenBucket.RefillRate,
+			"refill_interval": cfg.Redis.TokenBucket.RefillInterval,
+		}).Info("Token bucket rate limiter middleware enabled")
+	} else {
+		logger.Info("Token bucket rate limiter middleware disabled (Redis not available)")
+	}
+	// Clients sharing a fingerprint share a bucket on the routes abuse targets, however
+	// many addresses they rotate through
+	if deps.Redis != nil && cfg.Fingerprint.Enabled && cfg.Fingerprint.RateLimit != (config.TokenBucketConfig{}) {
+		stack = append(stack, middleware.CreatePolicyTokenBucketMiddleware(
+			deps.limiterStore(), "fingerprint", cfg.Fingerprint.RateLimit,
+			middleware.FingerprintKeyFunc(cfg.Fingerprint.Routes), logger,
+		))
+		logger.WithField("routes", len(cfg.Fingerprint.Routes)).Info("Fingerprint rate limiter middleware enabled")
+	}
+
+	// Reject oversized and deeply nested request bodies before they are decoded
+	stack = append(stack, middleware.BodyLimitMiddleware(cfg.Server.HTTP.RequestBody, logger))
+
+	// Record sanitized traffic for replays once oversized bodies have been turned away
+	if deps.Recorder != nil && cfg.Recording.Enabled {
+		stack = append(stack, middleware.RecordingMiddleware(deps.Recorder, cfg.Recording))
+	}
+	// Log the bodies of the routes being debugged, redacted, as the handlers see them
+	if cfg.Log.Bodies.Enabled {
+		stack = append(stack, middleware.BodyLoggingMiddleware(cfg.Log.Bodies, deps.BodyLog))
+		logger.WithField("routes", len(cfg.Log.Bodies.Routes)).Warn("Body logging enabled")
+	}
+
+	// Partner route groups only accept HMAC signed requests
+	if cfg.Signing.Enabled {
+		var signingRedis redis.UniversalClient
+		if deps.Redis != nil {
+			signingRedis = deps.Redis.GetClient()
+		}
+		stack = append(stack, middleware.SignatureMiddleware(
+			cfg.Signing,
+			signing.NewKeyring(cfg.Signing, signingRedis),
+			signing.NewReplayGuard(signingRedis, cfg.Signing.KeyPrefix),
+			logger,
+		))
+		logger.WithFields(logrus.Fields{
+			"path_prefixes": cfg.Signing.PathPrefixes,
+			"partners":      len(cfg.Signing.Partners),
+		}).Info("Request signature middleware enabled")
+	}
+
+	// Quotas are counted per partner, user or address, so once partners are known
+	if deps.Redis != nil && cfg.Quotas.Enabled {
+		tracker := quota.New(deps.Redis.GetClient(), cfg.Quotas, deps.Clock)
+		stack = append(stack, middleware.QuotaMiddleware(tracker, deps.TokenMaker, logger))
+		logger.WithFields(logrus.Fields{
+			"daily":   cfg.Quotas.Default.Daily,
+			"monthly": cfg.Quotas.Default.Monthly,
+			"clients": len(cfg.Quotas.Clients),
+		}).Info("Client quota middleware enabled")
+	}
+
+	// XML request bodies are decoded once their signature has been checked
+	if xmlNegotiation != nil {
+		stack = append(stack, xmlNegotiation.Requests())
+	}
+	return stack
+}
+
+// AuthenticatedStack returns the middleware of routes requiring a signed-in user: the
+// verification of their bearer token
+func AuthenticatedStack(deps Dependencies, logger *logrus.Logger) []gin.HandlerFunc {
+	return []gin.HandlerFunc{middleware.JWTMiddleware(deps.TokenMaker, logger)}
+}
+
+// AdminStack returns the middleware of the routes restricted to operators: the
+// verification of their bearer token and of the admin role
+func AdminStack(deps Dependencies, logger *logrus.Logger) []gin.HandlerFunc {
+	return append(AuthenticatedStack(deps, logger), middleware.RequireRole(logger, middleware.RoleAdmin))
+}
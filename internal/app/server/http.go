@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/fingerprint"
+	"apigw/internal/app/metrics"
+)
+
+// NewHTTPServer creates the HTTP server serving handler with the configured timeouts,
+// header limits and protocols. HTTP/2 is negotiated on TLS listeners through ALPN, and
+// h2c additionally accepts HTTP/2 with prior knowledge on plaintext listeners. Requests
+// can look up the TLS fingerprint of their connection in fingerprints, when set.
+func NewHTTPServer(cfg config.HTTPConfig, handler http.Handler, fingerprints *fingerprint.Store) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(cfg.HTTP2.Enabled)
+	protocols.SetUnencryptedHTTP2(cfg.HTTP2.H2C)
+
+	tracker := newConnTracker()
+	srv := &http.Server{
+		Handler:           countProtocols(handler),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		Protocols:         protocols,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.HTTP2.MaxConcurrentStreams,
+		},
+		ConnState: tracker.track,
+	}
+	if fingerprints != nil {
+		srv.ConnContext = fingerprints.ConnContext
+		srv.ConnState = func(conn net.Conn, state http.ConnState) {
+			tracker.track(conn, state)
+			if state == http.StateClosed || state == http.StateHijacked {
+				fingerprints.Forget(conn)
+			}
+		}
+	}
+	return srv
+}
+
+// NewAdminServer creates the HTTP server of the admin listener. It has no write timeout,
+// since CPU profiles and traces stream for as long as they were asked to run, and its
+// connections are left out of the public listener metrics.
+func NewAdminServer(cfg config.HTTPConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// countProtocols counts requests by protocol version
+func countProtocols(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.HTTPRequestsByProtocol.WithLabelValues(r.Proto).Inc()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// connTracker keeps the connection gauges in step with the server's connection states
+type connTracker struct {
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+}
+
+// newConnTracker creates an empty connection tracker
+func newConnTracker() *connTracker {
+	return &connTracker{states: make(map[net.Conn]http.ConnState)}
+}
+
+// track is the server's ConnState hook. Hijacked and closed connections leave the gauges;
+// an HTTP/2 connection stays active while any of its streams is in flight.
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if previous, ok := t.states[conn]; ok {
+		metrics.HTTPConnections.WithLabelValues(previous.String()).Dec()
+	}
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(t.states, conn)
+	default:
+		if state == http.StateNew {
+			metrics.HTTPConnectionsAccepted.Inc()
+		}
+		t.states[conn] = state
+		metrics.HTTPConnections.WithLabelValues(state.String()).Inc()
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/fingerprint"
+	"apigw/internal/app/upgrade"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Listener is an open listen address of the HTTP server
+type Listener struct {
+	net.Listener
+	Config config.ListenerConfig
+}
+
+// Listen opens every configured listener, wrapping TLS listeners with the server's
+// certificate configuration; their handshakes are fingerprinted into fingerprints, when
+// set. When autocert is used with an HTTP-01 challenge address, the returned challenge
+// server must be started by the caller. Sockets inherited from the process being upgraded
+// are taken over instead of opened.
+func Listen(cfg config.HTTPConfig, upgrader *upgrade.Upgrader, fingerprints *fingerprint.Store, logger *logrus.Logger) ([]Listener, *http.Server, error) {
+	var (
+		tlsConfig *tls.Config
+		challenge *http.Server
+	)
+
+	var listeners []Listener
+	closeAll := func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}
+
+	for _, lc := range cfg.ResolvedListeners() {
+		if lc.TLS && tlsConfig == nil {
+			var err error
+			tlsConfig, challenge, err = newTLSConfig(cfg.TLS, cfg.HTTP2.Enabled)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			if fingerprints != nil {
+				tlsConfig = fingerprints.Capture(tlsConfig)
+			}
+		}
+
+		l, err := upgrader.Listen(lc.Network, lc.Address, func() (net.Listener, error) {
+			return listen(lc)
+		})
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		if lc.TLS {
+			l = tls.NewListener(l, tlsConfig)
+		}
+
+		logger.WithFields(logrus.Fields{
+			"network": lc.Network,
+			"address": lc.Address,
+			"tls":     lc.TLS,
+		}).Info("Listener opened")
+		listeners = append(listeners, Listener{Listener: l, Config: lc})
+	}
+
+	return listeners, challenge, nil
+}
+
+// ListenAdmin opens the admin listener. With mtls auth, connections must present a
+// client certificate signed by the configured client CAs.
+func ListenAdmin(cfg config.AdminConfig, upgrader *upgrade.Upgrader, logger *logrus.Logger) (net.Listener, error) {
+	l, err := upgrader.Listen(config.NetworkTCP, cfg.Address, func() (net.Listener, error) {
+		return net.Listen("tcp", cfg.Address)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin address %s: %w", cfg.Address, err)
+	}
+
+	if cfg.TLSEnabled() {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("failed to load admin certificate: %w", err)
+		}
+		tlsConfig := &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		if cfg.Auth == config.AdminAuthMTLS {
+			pem, err := os.ReadFile(cfg.TLS.ClientCAFile)
+			if err != nil {
+				l.Close()
+				return nil, fmt.Errorf("failed to read admin client CAs: %w", err)
+			}
+			tlsConfig.ClientCAs = x509.NewCertPool()
+			if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+				l.Close()
+				return nil, fmt.Errorf("no certificates found in %s", cfg.TLS.ClientCAFile)
+			}
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		l = tls.NewListener(l, tlsConfig)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"address": cfg.Address,
+		"auth":    cfg.Auth,
+		"tls":     cfg.TLSEnabled(),
+	}).Info("Admin listener opened")
+	return l, nil
+}
+
+// listen opens a single TCP or unix socket listener
+func listen(lc config.ListenerConfig) (net.Listener, error) {
+	if lc.Network != config.NetworkUnix {
+		l, err := net.Listen("tcp", lc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", lc.Address, err)
+		}
+		return l, nil
+	}
+
+	// Remove a socket left behind by a previous process that did not shut down cleanly
+	if err := os.Remove(lc.Address); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket %s: %w", lc.Address, err)
+	}
+
+	l, err := net.Listen("unix", lc.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %s: %w", lc.Address, err)
+	}
+
+	if lc.SocketMode != "" {
+		mode, _ := strconv.ParseUint(lc.SocketMode, 8, 32)
+		if err := os.Chmod(lc.Address, os.FileMode(mode)); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("failed to set permissions on unix socket %s: %w", lc.Address, err)
+		}
+	}
+	return l, nil
+}
+
+// newTLSConfig builds the server TLS configuration from certificate files or autocert,
+// offering h2 through ALPN only when HTTP/2 is enabled
+func newTLSConfig(cfg config.ServerTLSConfig, http2 bool) (*tls.Config, *http.Server, error) {
+	var (
+		tlsConfig *tls.Config
+		challenge *http.Server
+	)
+
+	if cfg.Autocert.Enabled {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Autocert.Domains...),
+			Cache:      autocert.DirCache(cfg.Autocert.CacheDir),
+			Email:      cfg.Autocert.Email,
+		}
+		tlsConfig = manager.TLSConfig()
+		if cfg.Autocert.HTTPChallengeAddress != "" {
+			challenge = &http.Server{
+				Addr:    cfg.Autocert.HTTPChallengeAddress,
+				Handler: manager.HTTPHandler(nil),
+			}
+		}
+	} else {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load server certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		}
+	}
+
+	if !http2 {
+		tlsConfig.NextProtos = slices.DeleteFunc(tlsConfig.NextProtos, func(proto string) bool {
+			return proto == "h2"
+		})
+	}
+
+	tlsConfig.MinVersion = tls.VersionTLS12
+	if cfg.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	return tlsConfig, challenge, nil
+}
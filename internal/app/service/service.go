@@ -0,0 +1,113 @@
+// Package service integrates the gateway with the service manager running it: readiness,
+// stop and watchdog notifications for systemd (sd_notify), the service control manager on
+// Windows, and the PID file for init systems that track the process through one
+package service
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Manager reports the lifecycle of the process to the service manager it runs under. Its
+// methods do nothing when the process is not run as a service.
+type Manager struct {
+	systemd *systemdNotifier
+	windows *windowsService
+	logger  *logrus.Logger
+}
+
+// New detects the service manager running the process. Under the Windows service control
+// manager, stop requests are relayed to the channel passed to Notify.
+func New(logger *logrus.Logger) (*Manager, error) {
+	m := &Manager{logger: logger}
+
+	var err error
+	if m.systemd, err = newSystemdNotifier(); err != nil {
+		return nil, err
+	}
+	if m.windows, err = newWindowsService(logger); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case m.systemd != nil:
+		logger.WithField("watchdog", m.systemd.watchdog).Info("Running under systemd")
+	case m.windows != nil:
+		logger.Info("Running as a Windows service")
+	}
+	return m, nil
+}
+
+// Notify relays the stop requests of the service manager to c, next to the signals it is
+// registered for
+func (m *Manager) Notify(c chan<- os.Signal) {
+	if m.windows != nil {
+		m.windows.notify(c)
+	}
+}
+
+// Ready reports that the gateway is serving
+func (m *Manager) Ready() {
+	if m.systemd != nil {
+		m.send("READY=1\nSTATUS=Serving")
+	}
+	if m.windows != nil {
+		m.windows.running()
+	}
+}
+
+// Stopping reports that the gateway is draining before it exits
+func (m *Manager) Stopping() {
+	if m.systemd != nil {
+		m.send("STOPPING=1\nSTATUS=Draining")
+	}
+	if m.windows != nil {
+		m.windows.stopping()
+	}
+}
+
+// HandOver reports that the process pid replaced this one after a binary upgrade, so the
+// service manager tracks it from now on
+func (m *Manager) HandOver(pid int) {
+	if m.systemd != nil {
+		m.send("MAINPID=" + strconv.Itoa(pid) + "\nSTATUS=Handed over to the upgraded process")
+	}
+}
+
+// Watchdog pings the systemd watchdog at half its interval until ctx is done, when the
+// unit enables one. A gateway that stops pinging is restarted.
+func (m *Manager) Watchdog(ctx context.Context) {
+	if m.systemd == nil || m.systemd.watchdog <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(m.systemd.watchdog / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.send("WATCHDOG=1")
+			}
+		}
+	}()
+}
+
+// Close reports that the gateway has stopped
+func (m *Manager) Close() {
+	if m.windows != nil {
+		m.windows.stopped()
+	}
+}
+
+// send writes a notification to systemd, logging failures
+func (m *Manager) send(state string) {
+	if err := m.systemd.notify(state); err != nil {
+		m.logger.WithError(err).Warn("Failed to notify systemd")
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// systemdNotifier sends sd_notify messages to the socket systemd passes in NOTIFY_SOCKET
+type systemdNotifier struct {
+	addr     *net.UnixAddr
+	watchdog time.Duration // Interval the watchdog expects a ping within, 0 when disabled
+}
+
+// newSystemdNotifier returns nil when the process is not run by a systemd notify unit
+func newSystemdNotifier() (*systemdNotifier, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil, nil
+	}
+	// A leading @ denotes a socket in the abstract namespace
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	n := &systemdNotifier{addr: &net.UnixAddr{Name: socket, Net: "unixgram"}}
+
+	if usec := os.Getenv("WATCHDOG_USEC"); usec != "" {
+		v, err := strconv.ParseInt(usec, 10, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid WATCHDOG_USEC %q", usec)
+		}
+		if watchdogTarget(os.Getenv("WATCHDOG_PID")) {
+			n.watchdog = time.Duration(v) * time.Microsecond
+		}
+	}
+	return n, nil
+}
+
+// watchdogTarget reports whether the watchdog WATCHDOG_PID names is this process's. After
+// a binary upgrade the variable still names the process that started this one, which
+// handed its place over to it.
+func watchdogTarget(pid string) bool {
+	if pid == "" {
+		return true
+	}
+	v, err := strconv.Atoi(pid)
+	return err == nil && (v == os.Getpid() || v == os.Getppid())
+}
+
+// notify sends one datagram of newline-separated assignments
+func (n *systemdNotifier) notify(state string) error {
+	conn, err := net.DialUnix("unixgram", nil, n.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the notify socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to write to the notify socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+)
+
+// windowsService runs the gateway under the Windows service control manager
+type windowsService struct {
+	logger *logrus.Logger
+
+	mu   sync.Mutex
+	stop chan<- os.Signal // Receives stop and shutdown requests
+
+	states chan svc.State
+	exit   chan struct{} // Closed once the gateway has stopped
+	done   chan struct{} // Closed once the control dispatcher has returned
+}
+
+// newWindowsService returns nil when the process is not started by the service control
+// manager. Otherwise it connects to it in the background.
+func newWindowsService(logger *logrus.Logger) (*windowsService, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect the Windows service control manager: %w", err)
+	}
+	if !isService {
+		return nil, nil
+	}
+
+	w := &windowsService{
+		logger: logger,
+		states: make(chan svc.State, 1),
+		exit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		// The name is ignored for services running in their own process
+		if err := svc.Run("apigw", w); err != nil {
+			logger.WithError(err).Error("Windows service control dispatcher failed")
+		}
+	}()
+	return w, nil
+}
+
+// Execute reports state changes to the service control manager and relays its stop
+// requests until the gateway has stopped
+func (w *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	for {
+		select {
+		case state := <-w.states:
+			status := svc.Status{State: state}
+			if state == svc.Running {
+				status.Accepts = svc.AcceptStop | svc.AcceptShutdown
+			}
+			changes <- status
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				w.requestStop()
+			}
+		case <-w.exit:
+			return false, 0
+		}
+	}
+}
+
+// notify relays stop requests to c
+func (w *windowsService) notify(c chan<- os.Signal) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stop = c
+}
+
+// requestStop delivers a stop request as an interrupt, dropping it when one is pending
+func (w *windowsService) requestStop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop == nil {
+		w.logger.Warn("Stop requested before the gateway could handle it")
+		return
+	}
+	select {
+	case w.stop <- os.Interrupt:
+	default:
+	}
+}
+
+// running reports that the gateway is serving
+func (w *windowsService) running() {
+	w.setState(svc.Running)
+}
+
+// stopping reports that the gateway is draining
+func (w *windowsService) stopping() {
+	w.setState(svc.StopPending)
+}
+
+// stopped ends Execute, which reports the service as stopped, and waits for the control
+// dispatcher to return
+func (w *windowsService) stopped() {
+	close(w.exit)
+	<-w.done
+}
+
+// setState hands a state change to Execute
+func (w *windowsService) setState(state svc.State) {
+	select {
+	case w.states <- state:
+	case <-w.done:
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package service
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// windowsService is never created outside Windows
+type windowsService struct{}
+
+// newWindowsService returns nil: the process cannot be a Windows service
+func newWindowsService(logger *logrus.Logger) (*windowsService, error) {
+	return nil, nil
+}
+
+// notify does nothing
+func (w *windowsService) notify(c chan<- os.Signal) {}
+
+// running does nothing
+func (w *windowsService) running() {}
+
+// stopping does nothing
+func (w *windowsService) stopping() {}
+
+// stopped does nothing
+func (w *windowsService) stopped() {}
@@ -0,0 +1,90 @@
+package signing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// maxCachedPartners bounds the partners whose Redis keys are cached
+const maxCachedPartners = 1024
+
+// Keyring looks up the signing keys of partners. Keys from the configuration take
+// precedence; the others are read from the Redis hash <key_prefix>keys, whose fields are
+// partner IDs holding a JSON array of keys, and cached for key_cache_ttl so rotations
+// and revocations apply without a restart.
+type Keyring struct {
+	static   map[string][]string
+	redis    redis.UniversalClient
+	key      string
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedKeys
+}
+
+// cachedKeys are the keys of a partner read from Redis; nil secrets cache an unknown partner
+type cachedKeys struct {
+	secrets   []string
+	expiresAt time.Time
+}
+
+// NewKeyring creates a keyring; redisClient may be nil to only use configured keys
+func NewKeyring(cfg config.SigningConfig, redisClient redis.UniversalClient) *Keyring {
+	static := make(map[string][]string, len(cfg.Partners))
+	for _, partner := range cfg.Partners {
+		static[partner.ID] = partner.Secrets
+	}
+	return &Keyring{
+		static:   static,
+		redis:    redisClient,
+		key:      cfg.KeyPrefix + "keys",
+		cacheTTL: cfg.KeyCacheTTL,
+		cache:    make(map[string]cachedKeys),
+	}
+}
+
+// Secrets returns the keys of a partner, or none for an unknown partner
+func (k *Keyring) Secrets(ctx context.Context, partnerID string) ([]string, error) {
+	if secrets, ok := k.static[partnerID]; ok {
+		return secrets, nil
+	}
+	if k.redis == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	k.mu.Lock()
+	cached, ok := k.cache[partnerID]
+	k.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.secrets, nil
+	}
+
+	var secrets []string
+	data, err := k.redis.HGet(ctx, k.key, partnerID).Bytes()
+	switch {
+	case err == redis.Nil:
+	case err != nil:
+		return nil, fmt.Errorf("failed to read signing keys: %w", err)
+	default:
+		if err := codec.Unmarshal(data, &secrets); err != nil {
+			return nil, fmt.Errorf("malformed signing keys of partner %q: %w", partnerID, err)
+		}
+	}
+
+	k.mu.Lock()
+	if len(k.cache) >= maxCachedPartners {
+		// Requests naming random partners must not grow the cache without bound
+		clear(k.cache)
+	}
+	k.cache[partnerID] = cachedKeys{secrets: secrets, expiresAt: now.Add(k.cacheTTL)}
+	k.mu.Unlock()
+	return secrets, nil
+}
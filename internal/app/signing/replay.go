@@ -0,0 +1,61 @@
+package signing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ReplayGuard remembers the signatures seen within the replay window, so a captured
+// request cannot be sent again while its timestamp is still accepted. With Redis the
+// signatures are shared by every replica; without it they are kept in memory.
+type ReplayGuard struct {
+	redis  redis.UniversalClient
+	prefix string
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	nextPrune time.Time
+}
+
+// NewReplayGuard creates a replay guard storing signatures under keyPrefix in Redis;
+// redisClient may be nil to keep them in memory
+func NewReplayGuard(redisClient redis.UniversalClient, keyPrefix string) *ReplayGuard {
+	return &ReplayGuard{
+		redis:  redisClient,
+		prefix: keyPrefix + "seen:",
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// FirstUse records a signature for ttl and reports whether it had not been seen before
+func (g *ReplayGuard) FirstUse(ctx context.Context, signature string, ttl time.Duration) (bool, error) {
+	if g.redis != nil {
+		first, err := g.redis.SetNX(ctx, g.prefix+signature, 1, ttl).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to record signature: %w", err)
+		}
+		return first, nil
+	}
+
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if expiresAt, ok := g.seen[signature]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	// Drop expired signatures from time to time as new ones arrive
+	if now.After(g.nextPrune) {
+		for seen, expiresAt := range g.seen {
+			if !now.Before(expiresAt) {
+				delete(g.seen, seen)
+			}
+		}
+		g.nextPrune = now.Add(ttl)
+	}
+	g.seen[signature] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,100 @@
+// Package startup gates the boot of the gateway on its dependencies, retrying them with
+// exponential backoff so that a dependency being restarted at the same time does not
+// crash-loop the gateway
+package startup
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Gate connects dependencies according to the startup mode
+type Gate struct {
+	cfg    config.StartupConfig
+	logger *logrus.Logger
+}
+
+// NewGate creates a startup gate
+func NewGate(cfg config.StartupConfig, logger *logrus.Logger) *Gate {
+	return &Gate{cfg: cfg, logger: logger}
+}
+
+// Degraded reports whether the gateway starts without the dependencies still unavailable
+// once the startup timeout has passed
+func (g *Gate) Degraded() bool {
+	return g.cfg.Mode == config.StartupDegraded
+}
+
+// Connect calls connect until it succeeds, backing off between attempts, and returns
+// its last error once startup.timeout has passed. In fail mode connect is called once.
+func (g *Gate) Connect(ctx context.Context, dependency string, connect func() error) error {
+	err := connect()
+	if err == nil || g.cfg.Mode == config.StartupFail {
+		setUp(dependency, err == nil)
+		return err
+	}
+	setUp(dependency, false)
+
+	ctx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+	defer cancel()
+	return g.retry(ctx, dependency, err, connect)
+}
+
+// Recover keeps calling connect in the background until it succeeds or ctx is done,
+// then calls onRecovered
+func (g *Gate) Recover(ctx context.Context, dependency string, connect func() error, onRecovered func()) {
+	go func() {
+		if err := g.retry(ctx, dependency, nil, connect); err != nil {
+			return
+		}
+		g.logger.WithField("dependency", dependency).Info("Dependency recovered, leaving degraded mode")
+		onRecovered()
+	}()
+}
+
+// retry calls connect with exponential backoff until it succeeds or ctx is done,
+// returning the last error in the latter case
+func (g *Gate) retry(ctx context.Context, dependency string, err error, connect func() error) error {
+	backoff := g.cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		if err != nil {
+			g.logger.WithFields(logrus.Fields{
+				"dependency": dependency,
+				"attempt":    attempt,
+				"retry_in":   backoff,
+			}).WithError(err).Warn("Dependency unavailable, retrying")
+		}
+
+		// Jitter keeps replicas restarted together from retrying in lockstep
+		wait := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+			return err
+		case <-time.After(wait):
+		}
+
+		if err = connect(); err == nil {
+			setUp(dependency, true)
+			return nil
+		}
+		backoff = min(backoff*2, g.cfg.MaxBackoff)
+	}
+}
+
+// setUp exports whether a dependency is connected
+func setUp(dependency string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	metrics.DependencyUp.WithLabelValues(dependency).Set(value)
+}
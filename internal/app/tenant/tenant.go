@@ -0,0 +1,90 @@
+// Package tenant resolves the tenant a request is made for and carries it in request
+// contexts, so rate limits, backend connections and logs can be scoped to it
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"apigw/internal/app/config"
+)
+
+// ctxKey is the context key of the tenant
+type ctxKey struct{}
+
+// NewContext returns a context carrying the ID of a tenant
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the ID of the tenant a context carries
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Resolver resolves the tenant of requests. Hosts are matched first, the custom domains
+// of tenants exactly and the base domains by their first label, then the header; hosts
+// naming no tenant are ignored, but a header naming an unknown tenant is not.
+type Resolver struct {
+	header      string
+	baseDomains []string
+	hosts       map[string]string
+	ids         map[string]bool
+	fallback    string
+}
+
+// NewResolver creates a resolver of the configured tenants
+func NewResolver(cfg config.TenantsConfig) *Resolver {
+	r := &Resolver{
+		header:   cfg.Header,
+		hosts:    make(map[string]string),
+		ids:      make(map[string]bool, len(cfg.List)),
+		fallback: cfg.Default,
+	}
+	for _, domain := range cfg.BaseDomains {
+		r.baseDomains = append(r.baseDomains, "."+strings.ToLower(domain))
+	}
+	for _, t := range cfg.List {
+		r.ids[t.ID] = true
+		for _, host := range t.Hosts {
+			r.hosts[strings.ToLower(host)] = t.ID
+		}
+	}
+	return r
+}
+
+// Resolve returns the tenant of a request, empty when it has none. ok is false when the
+// header names a tenant that is not configured.
+func (r *Resolver) Resolve(req *http.Request) (id string, ok bool) {
+	if id := r.fromHost(req.Host); id != "" {
+		return id, true
+	}
+	if r.header != "" {
+		if id := strings.TrimSpace(req.Header.Get(r.header)); id != "" {
+			id = strings.ToLower(id)
+			return id, r.ids[id]
+		}
+	}
+	return r.fallback, true
+}
+
+// fromHost returns the tenant a host names, if any
+func (r *Resolver) fromHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if id, ok := r.hosts[host]; ok {
+		return id
+	}
+	for _, domain := range r.baseDomains {
+		label, ok := strings.CutSuffix(host, domain)
+		if ok && r.ids[label] {
+			return label
+		}
+	}
+	return ""
+}
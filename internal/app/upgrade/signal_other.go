@@ -0,0 +1,9 @@
+//go:build !unix
+
+package upgrade
+
+import "os"
+
+// Notify does nothing: binary upgrades rely on SIGUSR2 and descriptor passing, which are
+// only available on unix systems
+func Notify(c chan<- os.Signal) {}
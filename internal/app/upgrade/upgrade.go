@@ -0,0 +1,280 @@
+// Package upgrade replaces the running gateway binary without dropping connections: the
+// process starts its executable again, passing the listening sockets as inherited file
+// descriptors, and drains once the new process reports that it is serving on them
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"apigw/internal/app/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// envListeners lists the inherited listeners as network:address, in file descriptor
+	// order starting at 3
+	envListeners = "APIGW_INHERITED_LISTENERS"
+	// envReadyFD is the descriptor the new process writes to once it is serving
+	envReadyFD = "APIGW_INHERITED_READY_FD"
+
+	// firstInheritedFD is the first descriptor after stdin, stdout and stderr
+	firstInheritedFD = 3
+)
+
+// ErrInProgress is returned when an upgrade is requested while another one is running
+var ErrInProgress = errors.New("an upgrade is already in progress")
+
+// filer is implemented by the TCP and unix socket listeners
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrader hands the listening sockets of the process to its replacement, and takes
+// them over from the process it replaces
+type Upgrader struct {
+	cfg    config.UpgradeConfig
+	logger *logrus.Logger
+
+	mu        sync.Mutex
+	inherited map[string]*os.File // Sockets passed by the previous process, by key
+	ready     *os.File            // Written once serving, when started by an upgrade
+	keys      []string
+	listeners map[string]net.Listener
+	upgrading bool
+}
+
+// New creates an upgrader, picking up the sockets inherited from the previous process
+// when this one was started by an upgrade
+func New(cfg config.UpgradeConfig, logger *logrus.Logger) (*Upgrader, error) {
+	u := &Upgrader{
+		cfg:       cfg,
+		logger:    logger,
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]net.Listener),
+	}
+
+	names := os.Getenv(envListeners)
+	readyFD := os.Getenv(envReadyFD)
+	if readyFD == "" {
+		return u, nil
+	}
+	if names != "" {
+		for i, key := range strings.Split(names, ",") {
+			u.inherited[key] = os.NewFile(uintptr(firstInheritedFD+i), key)
+		}
+	}
+	fd, err := strconv.Atoi(readyFD)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", envReadyFD, readyFD, err)
+	}
+	u.ready = os.NewFile(uintptr(fd), "ready")
+
+	logger.WithFields(logrus.Fields{
+		"parent_pid": os.Getppid(),
+		"listeners":  len(u.inherited),
+	}).Info("Started by a binary upgrade, taking over listeners")
+	return u, nil
+}
+
+// Listen returns the socket inherited for network and address, or opens it with open.
+// Either way the socket is handed to the next process on upgrade.
+func (u *Upgrader) Listen(network, address string, open func() (net.Listener, error)) (net.Listener, error) {
+	key := network + ":" + address
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var l net.Listener
+	if f, ok := u.inherited[key]; ok {
+		delete(u.inherited, key)
+		var err error
+		l, err = net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to take over listener %s: %w", key, err)
+		}
+		u.logger.WithField("listener", key).Info("Listener inherited")
+	} else {
+		var err error
+		if l, err = open(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := u.listeners[key]; !ok {
+		u.keys = append(u.keys, key)
+	}
+	u.listeners[key] = l
+	return l, nil
+}
+
+// Ready tells the previous process, if any, that this one is serving, so it can drain.
+// Inherited sockets the configuration no longer listens on are closed.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, f := range u.inherited {
+		u.logger.WithField("listener", key).Info("Closing inherited listener no longer configured")
+		f.Close()
+		delete(u.inherited, key)
+	}
+
+	if u.ready == nil {
+		return nil
+	}
+	defer func() {
+		u.ready.Close()
+		u.ready = nil
+	}()
+	if _, err := u.ready.Write([]byte{1}); err != nil {
+		return fmt.Errorf("failed to notify the previous process: %w", err)
+	}
+	return nil
+}
+
+// Upgrade starts the executable again with the same arguments, passing it the listening
+// sockets, and waits until it is ready. On success it returns the new process ID and the
+// caller should drain and exit; on failure the new process has been stopped and this one
+// keeps serving.
+func (u *Upgrader) Upgrade() (int, error) {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return 0, ErrInProgress
+	}
+	u.upgrading = true
+	keys := append([]string(nil), u.keys...)
+	listeners := make([]net.Listener, len(keys))
+	for i, key := range keys {
+		listeners[i] = u.listeners[key]
+	}
+	u.mu.Unlock()
+
+	pid, err := u.upgrade(keys, listeners)
+
+	u.mu.Lock()
+	u.upgrading = false
+	u.mu.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// The new process serves the unix sockets now; closing ours must not remove them
+	for _, l := range listeners {
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return pid, nil
+}
+
+// upgrade starts the new process and waits for it to become ready
+func (u *Upgrader) upgrade(keys []string, listeners []net.Listener) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate the executable: %w", err)
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for i, l := range listeners {
+		fl, ok := l.(filer)
+		if !ok {
+			return 0, fmt.Errorf("listener %s cannot be passed to another process", keys[i])
+		}
+		f, err := fl.File()
+		if err != nil {
+			return 0, fmt.Errorf("failed to pass listener %s: %w", keys[i], err)
+		}
+		files = append(files, f)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create the readiness pipe: %w", err)
+	}
+	defer readyR.Close()
+	files = append(files, readyW)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(inheritableEnv(os.Environ()),
+		envListeners+"="+strings.Join(keys, ","),
+		envReadyFD+"="+strconv.Itoa(firstInheritedFD+len(files)-1),
+	)
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", executable, err)
+	}
+	u.logger.WithFields(logrus.Fields{
+		"pid":           cmd.Process.Pid,
+		"executable":    executable,
+		"listeners":     len(keys),
+		"ready_timeout": u.cfg.ReadyTimeout,
+	}).Info("Binary upgrade started, waiting for the new process")
+
+	// Our copies of the descriptors are no longer needed; closing the write end lets the
+	// read below end when the new process exits without becoming ready
+	for _, f := range files {
+		f.Close()
+	}
+	files = nil
+
+	readyErr := make(chan error, 1)
+	go func() {
+		var buf [1]byte
+		if _, err := readyR.Read(buf[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("the new process exited before becoming ready")
+			}
+			readyErr <- err
+			return
+		}
+		readyErr <- nil
+	}()
+
+	timer := time.NewTimer(u.cfg.ReadyTimeout)
+	defer timer.Stop()
+	select {
+	case err = <-readyErr:
+	case <-timer.C:
+		err = fmt.Errorf("the new process was not ready within %s", u.cfg.ReadyTimeout)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, err
+	}
+
+	u.logger.WithField("pid", cmd.Process.Pid).Info("New process is serving, handing over")
+	return cmd.Process.Pid, nil
+}
+
+// inheritableEnv drops the upgrade variables this process was started with
+func inheritableEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envListeners+"=") || strings.HasPrefix(kv, envReadyFD+"=") {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
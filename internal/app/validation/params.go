@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"apigw/internal/app/domains/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ParamError reports path or query parameters rejected before the validation rules apply,
+// such as a word where a number is expected
+type ParamError struct {
+	Violations []errs.FieldViolation
+}
+
+// Error describes the violations, preferring their messages
+func (e *ParamError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		if v.Message != "" {
+			parts = append(parts, v.Message)
+			continue
+		}
+		parts = append(parts, v.Field+" must be a valid "+v.Constraint)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// BindQuery binds the query parameters of a request into obj, a pointer to a struct with
+// form tags, and validates it. Unlike gin's query binding, a parameter that cannot be
+// converted to the type of its field is reported as a violation of that field.
+func BindQuery(c *gin.Context, obj interface{}) error {
+	return bindParams(obj, c.Request.URL.Query(), "form")
+}
+
+// BindURI binds the path parameters of a request into obj, a pointer to a struct with uri
+// tags, and validates it like BindQuery
+func BindURI(c *gin.Context, obj interface{}) error {
+	values := make(map[string][]string, len(c.Params))
+	for _, param := range c.Params {
+		values[param.Key] = []string{param.Value}
+	}
+	return bindParams(obj, values, "uri")
+}
+
+// bindParams maps the values into obj field by field, so that conversion failures are
+// attributed to their field, then validates the struct
+func bindParams(obj interface{}, values map[string][]string, tag string) error {
+	target := reflect.ValueOf(obj).Elem()
+	var violations []errs.FieldViolation
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		// gin maps the field alone with its own conversion rules (time_format, default...)
+		single := reflect.New(reflect.StructOf([]reflect.StructField{{
+			Name: field.Name,
+			Type: field.Type,
+			Tag:  field.Tag,
+		}}))
+		if err := binding.MapFormWithTag(single.Interface(), values, tag); err != nil {
+			violations = append(violations, errs.FieldViolation{
+				Field:      paramName(field, tag),
+				Rule:       "type",
+				Constraint: paramKind(field),
+				Type:       "string",
+			})
+			continue
+		}
+		target.Field(i).Set(single.Elem().Field(0))
+	}
+	if len(violations) > 0 {
+		return &ParamError{Violations: violations}
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// paramName returns the name of the parameter bound into a field
+func paramName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// paramKind returns the type a parameter must have: its time layout for times, or the
+// JSON type of its field
+func paramKind(field reflect.StructField) string {
+	t := field.Type
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		if layout := field.Tag.Get("time_format"); layout != "" {
+			return layout
+		}
+		return time.RFC3339
+	}
+	return jsonKind(t)
+}
@@ -0,0 +1,159 @@
+package validation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"apigw/internal/app/config"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Domain rules usable in binding tags
+const (
+	RuleEventID    = "event_id"    // Event ID in one of validation.event_id_formats
+	RulePhone      = "phone"       // E.164 phone number, e.g. +14155550100
+	RulePassword   = "password"    // New password meeting validation.password
+	RuleResourceID = "resource_id" // Opaque ID of an order, payment or webhook, up to 128 characters
+)
+
+var (
+	ulidPattern       = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$`)
+	uuidPattern       = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	slugPattern       = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+	phonePattern      = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	resourceIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.:_-]{0,127}$`)
+)
+
+// rules holds the settings of the domain rules; they are swapped on configuration reloads
+var rules atomic.Pointer[config.ValidationConfig]
+
+// Configure applies the settings of the domain rules to the requests validated from now on
+func Configure(cfg config.ValidationConfig) {
+	rules.Store(&cfg)
+}
+
+// registerRules registers the domain rules with the validator
+func registerRules(validate *validator.Validate) error {
+	for tag, fn := range map[string]validator.Func{
+		RuleEventID:    validEventID,
+		RulePhone:      validPhone,
+		RulePassword:   validPassword,
+		RuleResourceID: validResourceID,
+	} {
+		if err := validate.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validEventID accepts event IDs in one of the configured formats
+func validEventID(fl validator.FieldLevel) bool {
+	id := fl.Field().String()
+	for _, format := range rules.Load().EventIDFormats {
+		switch format {
+		case config.EventIDULID:
+			if ulidPattern.MatchString(id) {
+				return true
+			}
+		case config.EventIDUUID:
+			if uuidPattern.MatchString(id) {
+				return true
+			}
+		case config.EventIDSlug:
+			if slugPattern.MatchString(id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validPhone accepts E.164 phone numbers
+func validPhone(fl validator.FieldLevel) bool {
+	return phonePattern.MatchString(fl.Field().String())
+}
+
+// validResourceID accepts opaque resource IDs
+func validResourceID(fl validator.FieldLevel) bool {
+	return resourceIDPattern.MatchString(fl.Field().String())
+}
+
+// validPassword accepts passwords meeting the password policy
+func validPassword(fl validator.FieldLevel) bool {
+	policy := rules.Load().Password
+	password := fl.Field().String()
+	if length := len([]rune(password)); length < policy.MinLength || length > policy.MaxLength {
+		return false
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	return (upper || !policy.RequireUpper) &&
+		(lower || !policy.RequireLower) &&
+		(digit || !policy.RequireDigit) &&
+		(symbol || !policy.RequireSymbol)
+}
+
+// RulePattern returns the regular expression values of a domain rule match, or an empty
+// string for the rules a single expression does not capture
+func RulePattern(rule string) string {
+	switch rule {
+	case RulePhone:
+		return phonePattern.String()
+	case RuleResourceID:
+		return resourceIDPattern.String()
+	}
+	return ""
+}
+
+// RuleConstraint describes the constraint of the domain rules, whose tags carry no
+// parameter, for the violations returned to clients and the API documentation
+func RuleConstraint(rule string) string {
+	cfg := rules.Load()
+	if cfg == nil {
+		// Setup has not run
+		return ""
+	}
+	switch rule {
+	case RuleEventID:
+		return strings.Join(cfg.EventIDFormats, "|")
+	case RulePhone:
+		return "e164"
+	case RuleResourceID:
+		return "max_length=128"
+	case RulePassword:
+		policy := cfg.Password
+		parts := []string{"min_length=" + strconv.Itoa(policy.MinLength), "max_length=" + strconv.Itoa(policy.MaxLength)}
+		for _, class := range []struct {
+			required bool
+			name     string
+		}{
+			{policy.RequireUpper, "upper"},
+			{policy.RequireLower, "lower"},
+			{policy.RequireDigit, "digit"},
+			{policy.RequireSymbol, "symbol"},
+		} {
+			if class.required {
+				parts = append(parts, class.name)
+			}
+		}
+		return strings.Join(parts, ",")
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+// Package validation configures the validator behind gin's bindings and translates its
+// failures into the field violations returned to clients.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/domains/errs"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Setup registers the domain rules with the settings of cfg, and names the fields of
+// validation failures after their JSON, form or URI names so violations refer to the fields
+// clients send. It must run before requests are served.
+func Setup(cfg config.ValidationConfig) error {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected binding validator engine")
+	}
+	Configure(cfg)
+	validate.RegisterTagNameFunc(fieldName)
+	return registerRules(validate)
+}
+
+// fieldName returns the name clients know a struct field by
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Violations translates a binding error into the violations of the offending fields. It
+// reports false for errors that concern the request as a whole, such as malformed JSON.
+func Violations(err error) ([]errs.FieldViolation, bool) {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		violations := make([]errs.FieldViolation, 0, len(invalid))
+		for _, fe := range invalid {
+			constraint := fe.Param()
+			if constraint == "" {
+				constraint = RuleConstraint(fe.Tag())
+			}
+			violations = append(violations, errs.FieldViolation{
+				Field:      fieldPath(fe.Namespace()),
+				Rule:       fe.Tag(),
+				Constraint: constraint,
+				Type:       jsonType(fe.Value()),
+			})
+		}
+		return violations, true
+	}
+
+	var invalidParams *ParamError
+	if errors.As(err, &invalidParams) {
+		return invalidParams.Violations, true
+	}
+
+	var mistyped *json.UnmarshalTypeError
+	if errors.As(err, &mistyped) && mistyped.Field != "" {
+		return []errs.FieldViolation{{
+			Field:      decoderPath(mistyped.Field),
+			Rule:       "type",
+			Constraint: jsonKind(mistyped.Type),
+			Type:       mistyped.Value,
+		}}, true
+	}
+	return nil, false
+}
+
+// fieldPath drops the request type from a validator namespace, e.g.
+// PurchaseBatchReq.items[0].quantity becomes items[0].quantity
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// decoderPath writes a JSON decoder field path like the validator does, e.g.
+// items.0.quantity becomes items[0].quantity
+func decoderPath(path string) string {
+	var b strings.Builder
+	for i, segment := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(segment); err == nil {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// jsonType returns the JSON type of a provided value
+func jsonType(value interface{}) string {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "null"
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "null"
+	}
+	return jsonKind(v.Type())
+}
+
+// jsonKind returns the JSON type values of a Go type are encoded as
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		if t.String() == "time.Time" {
+			return "string"
+		}
+		return "object"
+	default:
+		return t.Kind().String()
+	}
+}
@@ -0,0 +1,348 @@
+// Package waitingroom implements the virtual waiting room of high-demand on-sales: while
+// it is open for an event, buyers join a queue and are granted purchase passes in order,
+// at a fixed number per second shared by every gateway replica through Redis
+package waitingroom
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/client"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Ticket states
+const (
+	StatusQueued   = "queued"
+	StatusAdmitted = "admitted"
+	StatusExpired  = "expired"
+)
+
+// maxCatchUp bounds how many seconds of passes are granted at once after nobody polled
+const maxCatchUp = 60
+
+// ErrNotQueued is returned for users who have not joined an event's queue
+var ErrNotQueued = errors.New("not in the waiting room queue")
+
+// tickScript grants the passes due since the last tick, at most maxCatchUp seconds of them
+// and never beyond the users queued, and returns how many users have been admitted. A
+// tick whose fencing token is not above that of the last tick grants nothing: its lock
+// expired before the script ran and a later holder already granted the passes due.
+var tickScript = redis.NewScript(`
+local admitted = tonumber(redis.call("GET", KEYS[2]) or "0")
+if tonumber(ARGV[1]) <= tonumber(redis.call("GET", KEYS[4]) or "0") then
+	return admitted
+end
+redis.call("SET", KEYS[4], ARGV[1], "EX", ARGV[5])
+local now = tonumber(ARGV[2])
+local last = redis.call("GETSET", KEYS[1], now)
+redis.call("EXPIRE", KEYS[1], ARGV[5])
+local elapsed = 1
+if last then
+	elapsed = math.max(now - tonumber(last), 1)
+end
+elapsed = math.min(elapsed, tonumber(ARGV[4]))
+admitted = math.min(admitted + elapsed * tonumber(ARGV[3]), tonumber(redis.call("GET", KEYS[3]) or "0"))
+redis.call("SET", KEYS[2], admitted, "EX", ARGV[5])
+return admitted
+`)
+
+// usePassScript spends one use of a granted pass, unless its uses are spent already
+var usePassScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], "admitted_at") == 0 then
+	return 0
+end
+if tonumber(redis.call("HGET", KEYS[1], "uses") or "0") >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("HINCRBY", KEYS[1], "uses", 1)
+return 1
+`)
+
+// releasePassScript gives back one use of a pass
+var releasePassScript = redis.NewScript(`
+if tonumber(redis.call("HGET", KEYS[1], "uses") or "0") > 0 then
+	redis.call("HINCRBY", KEYS[1], "uses", -1)
+end
+return 1
+`)
+
+// Settings are the waiting room settings of an event
+type Settings struct {
+	Rate       int       // Passes granted per second
+	OpenedAt   time.Time // When the waiting room was opened
+	generation string    // Namespaces the queue of each opening
+}
+
+// Ticket is a user's place in an event's queue
+type Ticket struct {
+	Token         string
+	EventID       string
+	Status        string
+	Position      int64     // Place in the queue, 1 being next; 0 once admitted
+	PassExpiresAt time.Time // Set once admitted
+}
+
+// Stats describes the queue of an event
+type Stats struct {
+	Settings
+	Joined   int64 // Users who joined since the waiting room opened
+	Admitted int64 // Passes granted so far
+}
+
+// Room manages the waiting rooms of every event
+type Room struct {
+	redis redis.UniversalClient
+	cfg   config.WaitingRoomConfig
+	// ticks are held for a second by the replica granting the passes due; their fencing
+	// tokens are kept as long as the queues recording them
+	ticks *client.Locker
+}
+
+// New creates a waiting room manager storing its state under cfg.KeyPrefix
+func New(redisClient redis.UniversalClient, cfg config.WaitingRoomConfig) *Room {
+	return &Room{
+		redis: redisClient,
+		cfg:   cfg,
+		ticks: client.NewLocker(redisClient, cfg.KeyPrefix+"tick:", "waiting_room_tick").WithFenceTTL(cfg.TokenTTL),
+	}
+}
+
+// settingsKey returns the key of an event's waiting room settings. The keys of an event
+// share a hash tag, which keeps them in one Redis Cluster slot.
+func (r *Room) settingsKey(eventID string) string {
+	return r.cfg.KeyPrefix + "{" + eventID + "}:settings"
+}
+
+// queueKey returns the key of one part of the queue of an opening
+func (r *Room) queueKey(eventID string, s Settings, name string) string {
+	return r.cfg.KeyPrefix + "{" + eventID + "}:" + s.generation + ":" + name
+}
+
+// Open opens or updates the waiting room of an event. Reopening a closed waiting room
+// starts a new queue.
+func (r *Room) Open(ctx context.Context, eventID string, rate int) (Settings, error) {
+	current, open, err := r.Settings(ctx, eventID)
+	if err != nil {
+		return Settings{}, err
+	}
+	if open {
+		current.Rate = rate
+		if err := r.redis.HSet(ctx, r.settingsKey(eventID), "rate", rate).Err(); err != nil {
+			return Settings{}, fmt.Errorf("failed to update waiting room: %w", err)
+		}
+		return current, nil
+	}
+
+	s := Settings{Rate: rate, OpenedAt: time.Now().UTC(), generation: newToken()[:8]}
+	err = r.redis.HSet(ctx, r.settingsKey(eventID),
+		"rate", rate,
+		"opened_at", s.OpenedAt.Unix(),
+		"generation", s.generation,
+	).Err()
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to open waiting room: %w", err)
+	}
+	return s, nil
+}
+
+// Close closes the waiting room of an event; its queue expires on its own
+func (r *Room) Close(ctx context.Context, eventID string) (bool, error) {
+	n, err := r.redis.Del(ctx, r.settingsKey(eventID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to close waiting room: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Settings returns the settings of an event's waiting room and whether it is open
+func (r *Room) Settings(ctx context.Context, eventID string) (Settings, bool, error) {
+	values, err := r.redis.HGetAll(ctx, r.settingsKey(eventID)).Result()
+	if err != nil {
+		return Settings{}, false, fmt.Errorf("failed to read waiting room: %w", err)
+	}
+	if len(values) == 0 {
+		return Settings{}, false, nil
+	}
+	rate, _ := strconv.Atoi(values["rate"])
+	openedAt, _ := strconv.ParseInt(values["opened_at"], 10, 64)
+	return Settings{
+		Rate:       rate,
+		OpenedAt:   time.Unix(openedAt, 0).UTC(),
+		generation: values["generation"],
+	}, true, nil
+}
+
+// Stats returns the queue statistics of an open waiting room
+func (r *Room) Stats(ctx context.Context, eventID string, s Settings) (Stats, error) {
+	pipe := r.redis.Pipeline()
+	joined := pipe.Get(ctx, r.queueKey(eventID, s, "seq"))
+	admitted := pipe.Get(ctx, r.queueKey(eventID, s, "admitted"))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Stats{}, fmt.Errorf("failed to read waiting room queue: %w", err)
+	}
+	stats := Stats{Settings: s}
+	stats.Joined, _ = joined.Int64()
+	stats.Admitted, _ = admitted.Int64()
+	return stats, nil
+}
+
+// Enter returns the ticket of a user, joining the queue if they have not yet or their
+// pass expired
+func (r *Room) Enter(ctx context.Context, eventID, userID string, s Settings) (Ticket, error) {
+	ticket, err := r.Ticket(ctx, eventID, userID, s)
+	if err == ErrNotQueued || (err == nil && ticket.Status == StatusExpired) {
+		if err := r.redis.Del(ctx, r.queueKey(eventID, s, "user:"+userID)).Err(); err != nil {
+			return Ticket{}, fmt.Errorf("failed to leave waiting room queue: %w", err)
+		}
+		if err := r.join(ctx, eventID, userID, s); err != nil {
+			return Ticket{}, err
+		}
+		return r.Ticket(ctx, eventID, userID, s)
+	}
+	return ticket, err
+}
+
+// Admit returns the ticket of a user like Enter and, when they hold a pass, spends one of
+// its uses. A pass whose uses are spent has expired, so replaying it joins the queue again.
+func (r *Room) Admit(ctx context.Context, eventID, userID string, s Settings) (Ticket, error) {
+	ticket, err := r.Enter(ctx, eventID, userID, s)
+	if err != nil || ticket.Status != StatusAdmitted {
+		return ticket, err
+	}
+	used, err := usePassScript.Run(ctx, r.redis, []string{r.queueKey(eventID, s, "token:"+ticket.Token)}, r.cfg.PassUses).Bool()
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to use waiting room pass: %w", err)
+	}
+	if used {
+		return ticket, nil
+	}
+	// A concurrent purchase spent the last use first
+	return r.Enter(ctx, eventID, userID, s)
+}
+
+// Release gives back the pass use spent by a purchase that did not go through
+func (r *Room) Release(ctx context.Context, s Settings, ticket Ticket) error {
+	err := releasePassScript.Run(ctx, r.redis, []string{r.queueKey(ticket.EventID, s, "token:"+ticket.Token)}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to release waiting room pass: %w", err)
+	}
+	return nil
+}
+
+// join puts a user at the back of the queue
+func (r *Room) join(ctx context.Context, eventID, userID string, s Settings) error {
+	seq, err := r.redis.Incr(ctx, r.queueKey(eventID, s, "seq")).Result()
+	if err != nil {
+		return fmt.Errorf("failed to join waiting room queue: %w", err)
+	}
+
+	token := newToken()
+	pipe := r.redis.Pipeline()
+	pipe.Expire(ctx, r.queueKey(eventID, s, "seq"), r.cfg.TokenTTL)
+	pipe.HSet(ctx, r.queueKey(eventID, s, "token:"+token), "user", userID, "seq", seq)
+	pipe.Expire(ctx, r.queueKey(eventID, s, "token:"+token), r.cfg.TokenTTL)
+	// A concurrent join of the same user keeps the first ticket; the other place is skipped
+	pipe.SetNX(ctx, r.queueKey(eventID, s, "user:"+userID), token, r.cfg.TokenTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to join waiting room queue: %w", err)
+	}
+	return nil
+}
+
+// Ticket returns the current ticket of a user, granting the passes due first
+func (r *Room) Ticket(ctx context.Context, eventID, userID string, s Settings) (Ticket, error) {
+	token, err := r.redis.Get(ctx, r.queueKey(eventID, s, "user:"+userID)).Result()
+	if err == redis.Nil {
+		return Ticket{}, ErrNotQueued
+	}
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to read waiting room ticket: %w", err)
+	}
+	values, err := r.redis.HGetAll(ctx, r.queueKey(eventID, s, "token:"+token)).Result()
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to read waiting room ticket: %w", err)
+	}
+	seq, err := strconv.ParseInt(values["seq"], 10, 64)
+	if err != nil {
+		return Ticket{}, ErrNotQueued
+	}
+
+	admitted, err := r.advance(ctx, eventID, s)
+	if err != nil {
+		return Ticket{}, err
+	}
+	ticket := Ticket{Token: token, EventID: eventID, Status: StatusQueued}
+	if seq > admitted {
+		ticket.Position = seq - admitted
+		return ticket, nil
+	}
+
+	// The pass is valid for pass_ttl from the first time the user is seen admitted, and
+	// for pass_uses purchases
+	now := time.Now()
+	tokenKey := r.queueKey(eventID, s, "token:"+token)
+	if err := r.redis.HSetNX(ctx, tokenKey, "admitted_at", now.Unix()).Err(); err != nil {
+		return Ticket{}, fmt.Errorf("failed to grant waiting room pass: %w", err)
+	}
+	admittedAt, err := r.redis.HGet(ctx, tokenKey, "admitted_at").Int64()
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to read waiting room pass: %w", err)
+	}
+	ticket.PassExpiresAt = time.Unix(admittedAt, 0).Add(r.cfg.PassTTL).UTC()
+	ticket.Status = StatusAdmitted
+	uses, _ := strconv.Atoi(values["uses"])
+	if !now.Before(ticket.PassExpiresAt) || uses >= r.cfg.PassUses {
+		ticket.Status = StatusExpired
+	}
+	return ticket, nil
+}
+
+// advance grants the passes due since the last time and returns how many users have
+// been admitted. Passes are granted at most once per second, by whichever replica gets
+// the tick lock first, and never beyond the users queued, so an idle queue does not bank
+// passes that a later rush would consume at once.
+func (r *Room) advance(ctx context.Context, eventID string, s Settings) (int64, error) {
+	admittedKey := r.queueKey(eventID, s, "admitted")
+	now := time.Now().Unix()
+
+	// The lock is left to expire, so the next tick comes a second later at the earliest
+	tick, err := r.ticks.TryAcquire(ctx, eventID+":"+s.generation, time.Second)
+	if err != nil && !errors.Is(err, client.ErrLockHeld) {
+		return 0, fmt.Errorf("failed to advance waiting room queue: %w", err)
+	}
+	if err == nil {
+		keys := []string{
+			r.queueKey(eventID, s, "last_tick"),
+			admittedKey,
+			r.queueKey(eventID, s, "seq"),
+			r.queueKey(eventID, s, "tick_fence"),
+		}
+		admitted, err := tickScript.Run(ctx, r.redis, keys,
+			tick.Token, now, s.Rate, maxCatchUp, int64(r.cfg.TokenTTL.Seconds())).Int64()
+		if err != nil {
+			return 0, fmt.Errorf("failed to advance waiting room queue: %w", err)
+		}
+		return admitted, nil
+	}
+
+	admitted, err := r.redis.Get(ctx, admittedKey).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("failed to read waiting room queue: %w", err)
+	}
+	return admitted, nil
+}
+
+// newToken returns a random queue token
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
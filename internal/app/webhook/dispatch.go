@@ -0,0 +1,329 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"apigw/internal/app/buildinfo"
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/signing"
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// publishTimeout bounds scheduling the deliveries of an event, which outlives the
+// request that caused it
+const publishTimeout = 5 * time.Second
+
+// errPrivateAddress is returned when a callback URL resolves to a private address
+var errPrivateAddress = errors.New("webhook URL resolves to a private address")
+
+// Publish schedules the delivery of an event to every webhook subscribed to it. Failures
+// are logged; the request that caused the event is not failed for them.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data interface{}) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+	logger := d.logger.WithField("event_type", eventType)
+
+	webhooks, err := d.all(ctx)
+	if err != nil {
+		logger.WithError(err).Error("Failed to publish webhook event")
+		return
+	}
+
+	now := time.Now().UTC()
+	event := Event{ID: newID("evt_"), Type: eventType, CreatedAt: now, Data: data}
+	payload, err := codec.Marshal(event)
+	if err != nil {
+		logger.WithError(err).Error("Failed to encode webhook event")
+		return
+	}
+
+	pipe := d.redis.Pipeline()
+	scheduled := 0
+	for _, w := range webhooks {
+		if !w.Subscribed(eventType) {
+			continue
+		}
+		delivery := Delivery{
+			ID:            newID("dlv_"),
+			WebhookID:     w.ID,
+			PartnerID:     w.PartnerID,
+			EventID:       event.ID,
+			EventType:     eventType,
+			Status:        StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			Payload:       payload,
+		}
+		if err := d.saveDelivery(ctx, pipe, delivery, d.cfg.DeliveryTTL); err != nil {
+			logger.WithError(err).Error("Failed to schedule webhook delivery")
+			continue
+		}
+		index := d.deliveriesKey(w.ID)
+		pipe.ZAdd(ctx, index, &redis.Z{Score: float64(now.UnixMilli()), Member: delivery.ID})
+		pipe.ZRemRangeByRank(ctx, index, 0, -maxDeliveriesPerWebhook-1)
+		pipe.Expire(ctx, index, d.cfg.DeliveryTTL)
+		pipe.ZAdd(ctx, d.scheduleKey(), &redis.Z{Score: float64(now.UnixMilli()), Member: delivery.ID})
+		scheduled++
+	}
+	if scheduled == 0 {
+		return
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.WithError(err).Error("Failed to schedule webhook deliveries")
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"deliveries": scheduled,
+	}).Debug("Webhook event published")
+}
+
+// Start delivers due deliveries until ctx is done, at most workers at a time. Wait
+// returns once the attempts in progress have finished.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		idle := make(chan struct{}, d.cfg.Workers)
+		for i := 0; i < d.cfg.Workers; i++ {
+			idle <- struct{}{}
+		}
+
+		ticker := time.NewTicker(d.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if len(idle) == 0 {
+				continue
+			}
+			due, err := d.redis.ZRangeByScore(ctx, d.scheduleKey(), &redis.ZRangeBy{
+				Min:   "-inf",
+				Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+				Count: int64(d.cfg.Workers),
+			}).Result()
+			if err != nil {
+				if ctx.Err() == nil {
+					d.logger.WithError(err).Error("Failed to read due webhook deliveries")
+				}
+				continue
+			}
+
+			for _, id := range due {
+				if len(idle) == 0 {
+					break
+				}
+				// Only one replica attempts a delivery at a time; the lock outlives the
+				// attempt, so a replica that stops mid-attempt only delays the retry
+				lock, err := d.locks.TryAcquire(ctx, id, 2*d.cfg.Timeout)
+				if err != nil {
+					continue
+				}
+				<-idle
+				d.wg.Add(1)
+				go func() {
+					defer func() {
+						idle <- struct{}{}
+						d.wg.Done()
+					}()
+					attemptCtx := context.WithoutCancel(ctx)
+					defer lock.Release(attemptCtx)
+					d.attempt(attemptCtx, id, lock.Token)
+				}()
+			}
+		}
+	}()
+}
+
+// Wait waits for the dispatcher to stop
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// attempt makes one delivery attempt under the delivery lock of fence, and schedules the
+// next one on failure
+func (d *Dispatcher) attempt(ctx context.Context, id string, fence int64) {
+	delivery, err := d.delivery(ctx, id)
+	if err == ErrNotFound || (err == nil && delivery.Status != StatusPending) {
+		// Expired or already done
+		d.redis.ZRem(ctx, d.scheduleKey(), id)
+		return
+	}
+	if err != nil {
+		d.logger.WithError(err).WithField("delivery_id", id).Error("Failed to read webhook delivery")
+		return
+	}
+	delivery.Fence = fence
+	logger := d.logger.WithFields(logrus.Fields{
+		"delivery_id": id,
+		"webhook_id":  delivery.WebhookID,
+		"partner_id":  delivery.PartnerID,
+		"event_type":  delivery.EventType,
+	})
+
+	w, err := d.webhook(ctx, delivery.WebhookID)
+	switch {
+	case err == ErrNotFound:
+		d.finish(ctx, delivery, StatusFailed, 0, "webhook deleted", logger)
+		return
+	case err != nil:
+		logger.WithError(err).Error("Failed to read webhook")
+		return
+	case !w.Active:
+		d.finish(ctx, delivery, StatusFailed, 0, "webhook disabled", logger)
+		return
+	}
+
+	delivery.Attempts++
+	status, sendErr := d.send(ctx, w, delivery)
+	if sendErr == nil {
+		metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, "succeeded").Inc()
+		d.finish(ctx, delivery, StatusSucceeded, status, "", logger)
+		return
+	}
+
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, "failed").Inc()
+		logger.WithError(sendErr).WithField("attempts", delivery.Attempts).Warn("Webhook delivery failed, giving up")
+		d.finish(ctx, delivery, StatusFailed, status, sendErr.Error(), logger)
+		return
+	}
+
+	metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, "retried").Inc()
+	now := time.Now().UTC()
+	delivery.ResponseStatus = status
+	delivery.LastError = sendErr.Error()
+	delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	delivery.UpdatedAt = now
+	logger.WithError(sendErr).WithFields(logrus.Fields{
+		"attempts":     delivery.Attempts,
+		"next_attempt": delivery.NextAttemptAt,
+	}).Info("Webhook delivery failed, retrying")
+
+	saved, err := d.saveAttempt(ctx, delivery)
+	if err != nil || !saved {
+		d.dropAttempt(err, "Failed to reschedule webhook delivery", logger)
+		return
+	}
+	if err := d.redis.ZAdd(ctx, d.scheduleKey(), &redis.Z{Score: float64(delivery.NextAttemptAt.UnixMilli()), Member: id}).Err(); err != nil {
+		logger.WithError(err).Error("Failed to reschedule webhook delivery")
+	}
+}
+
+// finish records the final outcome of a delivery and removes it from the schedule
+func (d *Dispatcher) finish(ctx context.Context, delivery Delivery, status string, responseStatus int, lastError string, logger *logrus.Entry) {
+	delivery.Status = status
+	delivery.ResponseStatus = responseStatus
+	delivery.LastError = lastError
+	delivery.NextAttemptAt = time.Time{}
+	delivery.UpdatedAt = time.Now().UTC()
+
+	saved, err := d.saveAttempt(ctx, delivery)
+	if err != nil || !saved {
+		d.dropAttempt(err, "Failed to record webhook delivery", logger)
+		return
+	}
+	if err := d.redis.ZRem(ctx, d.scheduleKey(), delivery.ID).Err(); err != nil {
+		logger.WithError(err).Error("Failed to record webhook delivery")
+	}
+}
+
+// dropAttempt logs the outcome of an attempt that was not recorded: the delivery lock
+// expired during the attempt and the replica holding it next recorded its own, or
+// saving it failed
+func (d *Dispatcher) dropAttempt(err error, message string, logger *logrus.Entry) {
+	if err != nil {
+		logger.WithError(err).Error(message)
+		return
+	}
+	logger.Warn("Webhook delivery lock expired during the attempt, outcome superseded by a later attempt")
+}
+
+// send posts a delivery to its webhook and returns the response status. Only 2xx
+// responses are successful; redirects are not followed.
+func (d *Dispatcher) send(ctx context.Context, w Webhook, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "apigw-webhooks/"+buildinfo.Get().Version)
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+signing.Sign(w.Secret, timestamp+"."+string(delivery.Payload)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the attempt following the given number of attempts:
+// initial_backoff doubling with every attempt, up to max_backoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.InitialBackoff
+	for i := 1; i < attempts && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxBackoff)
+}
+
+// newHTTPClient creates the client deliveries are sent with. Unless allow_private_networks
+// is set it refuses to connect to loopback, private and link-local addresses, wherever the
+// callback host resolves to at the time.
+func newHTTPClient(cfg config.WebhooksConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // The address checks apply to the callback host itself
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,249 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"apigw/pkg/utils/codec"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Create registers a webhook for a partner, generating its secret when none is given
+func (d *Dispatcher) Create(ctx context.Context, w Webhook) (Webhook, error) {
+	if err := d.ValidateURL(w.URL); err != nil {
+		return Webhook{}, err
+	}
+	count, err := d.redis.SCard(ctx, d.partnerKey(w.PartnerID)).Result()
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if count >= int64(d.cfg.MaxEndpoints) {
+		return Webhook{}, ErrLimitReached
+	}
+
+	now := time.Now().UTC()
+	w.ID = newID("wh_")
+	if w.Secret == "" {
+		w.Secret = NewSecret()
+	}
+	w.Active = true
+	w.CreatedAt = now
+	w.UpdatedAt = now
+	if err := d.save(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// save writes a webhook and indexes it under its partner
+func (d *Dispatcher) save(ctx context.Context, w Webhook) error {
+	data, err := codec.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook: %w", err)
+	}
+	pipe := d.redis.Pipeline()
+	pipe.HSet(ctx, d.webhooksKey(), w.ID, data)
+	pipe.SAdd(ctx, d.partnerKey(w.PartnerID), w.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return nil
+}
+
+// Get returns a webhook of a partner
+func (d *Dispatcher) Get(ctx context.Context, partnerID, id string) (Webhook, error) {
+	w, err := d.webhook(ctx, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if w.PartnerID != partnerID {
+		return Webhook{}, ErrNotFound
+	}
+	return w, nil
+}
+
+// webhook returns a webhook of any partner
+func (d *Dispatcher) webhook(ctx context.Context, id string) (Webhook, error) {
+	data, err := d.redis.HGet(ctx, d.webhooksKey(), id).Bytes()
+	if err == redis.Nil {
+		return Webhook{}, ErrNotFound
+	}
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to read webhook: %w", err)
+	}
+	var w Webhook
+	if err := codec.Unmarshal(data, &w); err != nil {
+		return Webhook{}, fmt.Errorf("malformed webhook %q: %w", id, err)
+	}
+	return w, nil
+}
+
+// List returns the webhooks of a partner, oldest first
+func (d *Dispatcher) List(ctx context.Context, partnerID string) ([]Webhook, error) {
+	ids, err := d.redis.SMembers(ctx, d.partnerKey(partnerID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	webhooks := make([]Webhook, 0, len(ids))
+	if len(ids) == 0 {
+		return webhooks, nil
+	}
+	values, err := d.redis.HMGet(ctx, d.webhooksKey(), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var w Webhook
+		if err := codec.Unmarshal([]byte(data), &w); err == nil && w.PartnerID == partnerID {
+			webhooks = append(webhooks, w)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// all returns every webhook
+func (d *Dispatcher) all(ctx context.Context) ([]Webhook, error) {
+	values, err := d.redis.HVals(ctx, d.webhooksKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	webhooks := make([]Webhook, 0, len(values))
+	for _, data := range values {
+		var w Webhook
+		if err := codec.Unmarshal([]byte(data), &w); err == nil {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+// Update replaces the settings of a webhook of a partner with those update returns
+func (d *Dispatcher) Update(ctx context.Context, partnerID, id string, update func(*Webhook)) (Webhook, error) {
+	w, err := d.Get(ctx, partnerID, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	update(&w)
+	if err := d.ValidateURL(w.URL); err != nil {
+		return Webhook{}, err
+	}
+	w.UpdatedAt = time.Now().UTC()
+	if err := d.save(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// Delete removes a webhook of a partner; its pending deliveries fail when next attempted
+func (d *Dispatcher) Delete(ctx context.Context, partnerID, id string) error {
+	if _, err := d.Get(ctx, partnerID, id); err != nil {
+		return err
+	}
+	pipe := d.redis.Pipeline()
+	pipe.HDel(ctx, d.webhooksKey(), id)
+	pipe.SRem(ctx, d.partnerKey(partnerID), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// Deliveries returns the most recent deliveries of a webhook of a partner, newest first
+func (d *Dispatcher) Deliveries(ctx context.Context, partnerID, webhookID string, limit int) ([]Delivery, error) {
+	if _, err := d.Get(ctx, partnerID, webhookID); err != nil {
+		return nil, err
+	}
+	ids, err := d.redis.ZRevRange(ctx, d.deliveriesKey(webhookID), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	deliveries := make([]Delivery, 0, len(ids))
+	for _, id := range ids {
+		delivery, err := d.delivery(ctx, id)
+		if err == ErrNotFound {
+			continue // Expired
+		}
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// Delivery returns a delivery of a webhook of a partner
+func (d *Dispatcher) Delivery(ctx context.Context, partnerID, webhookID, id string) (Delivery, error) {
+	delivery, err := d.delivery(ctx, id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if delivery.PartnerID != partnerID || delivery.WebhookID != webhookID {
+		return Delivery{}, ErrNotFound
+	}
+	return delivery, nil
+}
+
+// delivery reads a delivery
+func (d *Dispatcher) delivery(ctx context.Context, id string) (Delivery, error) {
+	data, err := d.redis.Get(ctx, d.deliveryKey(id)).Bytes()
+	if err == redis.Nil {
+		return Delivery{}, ErrNotFound
+	}
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to read delivery: %w", err)
+	}
+	var delivery Delivery
+	if err := codec.Unmarshal(data, &delivery); err != nil {
+		return Delivery{}, fmt.Errorf("malformed delivery %q: %w", id, err)
+	}
+	return delivery, nil
+}
+
+// saveDelivery writes a delivery, keeping the expiry it was created with
+func (d *Dispatcher) saveDelivery(ctx context.Context, pipe redis.Pipeliner, delivery Delivery, expiration time.Duration) error {
+	data, err := codec.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery: %w", err)
+	}
+	pipe.Set(ctx, d.deliveryKey(delivery.ID), data, expiration)
+	return nil
+}
+
+// saveAttemptScript writes a delivery unless the one stored was recorded under a later
+// delivery lock, keeping its expiry
+var saveAttemptScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current then
+	local fence = cjson.decode(current)["fence"]
+	if fence and tonumber(fence) > tonumber(ARGV[1]) then
+		return 0
+	end
+end
+redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+return 1
+`)
+
+// saveAttempt writes a delivery attempted under the delivery lock of its fencing token,
+// and reports whether it was written: it is not once a replica that acquired the lock
+// after ours expired has recorded its own attempt
+func (d *Dispatcher) saveAttempt(ctx context.Context, delivery Delivery) (bool, error) {
+	data, err := codec.Marshal(delivery)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode delivery: %w", err)
+	}
+	saved, err := saveAttemptScript.Run(ctx, d.redis, []string{d.deliveryKey(delivery.ID)}, delivery.Fence, data).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to save delivery: %w", err)
+	}
+	return saved == 1, nil
+}
@@ -0,0 +1,214 @@
+// Package webhook delivers order events to the callback URLs partners register. Webhooks
+// and deliveries are kept in Redis, so every replica publishes to and delivers from the
+// same schedule; failed deliveries are retried with exponential backoff.
+//
+// A delivery is a POST of the JSON event, signed like partner requests are:
+//
+//	X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, TIMESTAMP + "." + body))
+//
+// with the Unix timestamp in X-Webhook-Timestamp.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/client"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Event types
+const (
+	EventOrderCompleted = "order.completed"
+	EventOrderCancelled = "order.cancelled"
+)
+
+// EventTypes lists the events webhooks can subscribe to
+var EventTypes = []string{EventOrderCompleted, EventOrderCancelled}
+
+// Delivery states
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Delivery headers
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxDeliveriesPerWebhook bounds the deliveries listed for a webhook
+const maxDeliveriesPerWebhook = 500
+
+var (
+	// ErrNotFound is returned for unknown webhooks and deliveries, including those of
+	// another partner
+	ErrNotFound = errors.New("webhook not found")
+	// ErrLimitReached is returned when a partner registers more than max_endpoints webhooks
+	ErrLimitReached = errors.New("webhook limit reached")
+	// ErrInvalidURL is returned for callback URLs deliveries may not be sent to
+	ErrInvalidURL = errors.New("invalid webhook URL")
+)
+
+// Webhook is a callback URL registered by a partner
+type Webhook struct {
+	ID        string    `json:"id"`
+	PartnerID string    `json:"partnerId"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Subscribed reports whether the webhook receives an event type
+func (w Webhook) Subscribed(eventType string) bool {
+	return w.Active && slices.Contains(w.Events, eventType)
+}
+
+// Event is the body of a delivery
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// OrderData is the data of the order events
+type OrderData struct {
+	OrderID   string `json:"orderId"`
+	EventID   string `json:"eventId,omitempty"`
+	UserID    string `json:"userId"`
+	Status    string `json:"status"`
+	PaymentID string `json:"paymentId,omitempty"` // Set for order.completed
+	Refunded  bool   `json:"refunded,omitempty"`  // Set for order.cancelled
+}
+
+// Delivery is the delivery of an event to a webhook
+type Delivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhookId"`
+	PartnerID string `json:"partnerId"`
+	EventID   string `json:"eventId"`
+	EventType string `json:"eventType"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	// ResponseStatus is the HTTP status of the last attempt, 0 when no response was received
+	ResponseStatus int       `json:"responseStatus"`
+	LastError      string    `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time `json:"nextAttemptAt"` // Zero once succeeded or failed
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	// Payload is the event body, sent unchanged by every attempt
+	Payload []byte `json:"payload"`
+	// Fence is the fencing token of the delivery lock the last recorded attempt was made
+	// under; attempts made under an earlier lock are not recorded over it
+	Fence int64 `json:"fence,omitempty"`
+}
+
+// Publisher publishes events to the webhooks subscribed to them; handlers use it without
+// knowing whether webhooks are enabled
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, data interface{})
+}
+
+// NopPublisher is used when webhooks are disabled
+type NopPublisher struct{}
+
+// Publish does nothing
+func (NopPublisher) Publish(context.Context, string, interface{}) {}
+
+// Dispatcher manages the webhooks of partners and delivers events to them
+type Dispatcher struct {
+	redis  redis.UniversalClient
+	cfg    config.WebhooksConfig
+	client *http.Client
+	// locks are held by the replica attempting a delivery; their fencing tokens are kept
+	// as long as the deliveries recording them
+	locks  *client.Locker
+	logger *logrus.Logger
+	wg     sync.WaitGroup
+}
+
+// New creates a dispatcher storing webhooks and deliveries under cfg.KeyPrefix
+func New(redisClient redis.UniversalClient, cfg config.WebhooksConfig, logger *logrus.Logger) *Dispatcher {
+	return &Dispatcher{
+		redis:  redisClient,
+		cfg:    cfg,
+		client: newHTTPClient(cfg),
+		locks:  client.NewLocker(redisClient, cfg.KeyPrefix+"lock:", "webhook_delivery").WithFenceTTL(cfg.DeliveryTTL),
+		logger: logger,
+	}
+}
+
+// webhooksKey returns the key of the hash of every webhook by ID
+func (d *Dispatcher) webhooksKey() string {
+	return d.cfg.KeyPrefix + "endpoints"
+}
+
+// partnerKey returns the key of the set of a partner's webhook IDs
+func (d *Dispatcher) partnerKey(partnerID string) string {
+	return d.cfg.KeyPrefix + "partner:" + partnerID
+}
+
+// deliveryKey returns the key of a delivery
+func (d *Dispatcher) deliveryKey(id string) string {
+	return d.cfg.KeyPrefix + "delivery:" + id
+}
+
+// deliveriesKey returns the key of the deliveries of a webhook, scored by creation time
+func (d *Dispatcher) deliveriesKey(webhookID string) string {
+	return d.cfg.KeyPrefix + "deliveries:" + webhookID
+}
+
+// scheduleKey returns the key of the pending deliveries, scored by their next attempt
+func (d *Dispatcher) scheduleKey() string {
+	return d.cfg.KeyPrefix + "schedule"
+}
+
+// ValidateURL checks that deliveries may be sent to a callback URL: https unless
+// allow_http is set, with a host and without credentials. Private addresses are
+// rejected when the delivery connects, as the host may resolve to one later.
+func (d *Dispatcher) ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ErrInvalidURL
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && d.cfg.AllowHTTP) {
+		return fmt.Errorf("%w: scheme must be https", ErrInvalidURL)
+	}
+	if u.Hostname() == "" || u.User != nil || u.Fragment != "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// newID returns a random identifier with a prefix
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
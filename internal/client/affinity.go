@@ -0,0 +1,101 @@
+package client
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/connectivity"
+)
+
+// affinityBalancerName is the gRPC balancing policy of services with sticky routing: the
+// replicas a target resolves to are picked by the routing key of each call, and calls
+// without one are spread round robin
+const affinityBalancerName = "apigw_affinity"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(affinityBalancerName, affinityPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+// affinityPickerBuilder builds the affinity picker of the ready replicas
+type affinityPickerBuilder struct{}
+
+// Build implements base.PickerBuilder
+func (affinityPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &affinityPicker{}
+	for sc, scInfo := range info.ReadySCs {
+		p.replicas = append(p.replicas, affinityReplica{addr: scInfo.Address.Addr, subConn: sc})
+	}
+	return p
+}
+
+// affinityReplica is a ready replica of a target
+type affinityReplica struct {
+	addr    string
+	subConn balancer.SubConn
+}
+
+// affinityPicker pins each routing key to the replica ranking highest for it. A replica
+// leaving only moves the keys it held, and a replica joining only takes its share.
+type affinityPicker struct {
+	replicas []affinityReplica
+	next     atomic.Uint32
+}
+
+// Pick implements balancer.Picker
+func (p *affinityPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	key, ok := RoutingKeyFromContext(info.Ctx)
+	if !ok {
+		n := p.next.Add(1)
+		return balancer.PickResult{SubConn: p.replicas[int(n)%len(p.replicas)].subConn}, nil
+	}
+
+	best := 0
+	var bestScore uint64
+	for i, r := range p.replicas {
+		if score := rendezvousHash(key, r.addr); i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return balancer.PickResult{SubConn: p.replicas[best].subConn}, nil
+}
+
+// pickSticky picks the endpoint of a routing key by weighted rendezvous hashing, so each
+// endpoint holds a share of keys proportional to its weight and changing one endpoint
+// only moves the keys it gains or loses. Endpoints failing to connect are passed over
+// while another one is usable.
+func (p *ServiceConn) pickSticky(key string) *weightedConn {
+	var best *weightedConn
+	bestScore, bestUsable := 0.0, false
+	for i := range p.conns {
+		wc := &p.conns[i]
+		state := wc.conn.GetState()
+		usable := state != connectivity.TransientFailure && state != connectivity.Shutdown
+
+		// -weight/ln(u) for u uniform in (0, 1) is the weighted rendezvous score
+		u := (float64(rendezvousHash(key, wc.conn.Target())>>11) + 0.5) / (1 << 53)
+		score := -float64(wc.weight) / math.Log(u)
+		if best == nil || (usable && !bestUsable) || (usable == bestUsable && score > bestScore) {
+			best, bestScore, bestUsable = wc, score, usable
+		}
+	}
+	return best
+}
+
+// rendezvousHash returns the rank of a node for a routing key. FNV-1a is finished with
+// the splitmix64 mixer, as nodes often differ in their last characters only.
+func rendezvousHash(key, node string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(node))
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
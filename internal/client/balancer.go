@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/metrics"
+	"apigw/internal/app/region"
+	"apigw/internal/app/tenant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+)
+
+// routingKeyCtxKey is the context key for the sticky routing key
+type routingKeyCtxKey struct{}
+
+// WithRoutingKey returns a context carrying the key used for sticky endpoint selection
+func WithRoutingKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, routingKeyCtxKey{}, key)
+}
+
+// RoutingKeyFromContext returns the sticky routing key stored in the context
+func RoutingKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(routingKeyCtxKey{}).(string)
+	return key, ok && key != ""
+}
+
+// weightedConn is a gRPC connection to a single weighted endpoint
+type weightedConn struct {
+	name   string
+	weight int
+	conn   *grpc.ClientConn
+}
+
+// ServiceConn is the connection to a backend service. It implements
+// grpc.ClientConnInterface, distributing calls across the service's weighted endpoints.
+type ServiceConn struct {
+	name        string
+	conns       []weightedConn
+	totalWeight int
+	sticky      bool
+	shadow      *shadowMirror
+	// tenants holds the connections to the upstreams of tenants overriding the service's
+	tenants map[string]*ServiceConn
+	// regions holds the connections to the clusters of regions overriding the service's,
+	// and failover the region taking over from each while its clusters are unreachable
+	regions  map[string]*ServiceConn
+	failover map[string]string
+
+	// stopMonitor ends the connectivity monitoring started by the factory
+	stopMonitor context.CancelFunc
+	beenReady   atomic.Bool
+}
+
+// dialEndpoint creates a gRPC connection to a single address or target of a service.
+// A target resolving to several replicas is balanced with the service's policy.
+func dialEndpoint(cfg *config.ServiceConfig, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if scheme, _, ok := strings.Cut(address, "://"); ok && resolver.Get(scheme) == nil {
+		return nil, fmt.Errorf("no resolver registered for target scheme %q", scheme)
+	}
+
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := cfg.LoadBalancing
+	if policy == "" {
+		policy = config.LoadBalancingRoundRobin
+	}
+	// Sticky routing also pins users to the replicas a target resolves to
+	if cfg.StickyRouting {
+		policy = affinityBalancerName
+	}
+
+	var callOpts []grpc.CallOption
+	if cfg.GRPC.WaitForReady {
+		callOpts = append(callOpts, grpc.WaitForReady(true))
+	}
+	if cfg.GRPC.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(cfg.GRPC.MaxRecvMsgSize))
+	}
+	if cfg.GRPC.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(cfg.GRPC.MaxSendMsgSize))
+	}
+	if cfg.GRPC.Compression == config.CompressionGzip {
+		callOpts = append(callOpts, grpc.UseCompressor(gzip.Name))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, policy)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.GRPC.KeepaliveTime,
+			Timeout:             cfg.GRPC.KeepaliveTimeout,
+			PermitWithoutStream: cfg.GRPC.KeepalivePermitWithoutStream,
+		}),
+	}, opts...)
+
+	return grpc.NewClient(address, opts...)
+}
+
+// Invoke implements grpc.ClientConnInterface
+func (p *ServiceConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return p.Pick(ctx).Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface
+func (p *ServiceConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.Pick(ctx).NewStream(ctx, desc, method, opts...)
+}
+
+// Pick selects an endpoint connection, honouring sticky routing when a routing key is
+// present; calls of tenants with an upstream of their own are sent to it, then calls of
+// regions to the clusters of the region
+func (p *ServiceConn) Pick(ctx context.Context) *grpc.ClientConn {
+	if id, ok := tenant.FromContext(ctx); ok {
+		if tc, ok := p.tenants[id]; ok {
+			return tc.Pick(ctx)
+		}
+	}
+	if id, ok := region.FromContext(ctx); ok {
+		if rc := p.regionConn(id); rc != nil {
+			return rc.Pick(ctx)
+		}
+	}
+	if len(p.conns) == 1 {
+		return p.conns[0].conn
+	}
+
+	if key, ok := RoutingKeyFromContext(ctx); ok && p.sticky {
+		return p.pickSticky(key).conn
+	}
+
+	slot := rand.Intn(p.totalWeight)
+	for _, wc := range p.conns {
+		if slot < wc.weight {
+			return wc.conn
+		}
+		slot -= wc.weight
+	}
+	return p.conns[len(p.conns)-1].conn
+}
+
+// regionConn returns the connection to the clusters of a region, or of the first region
+// along its failover chain whose clusters are reachable. nil sends the call to the
+// upstream of the service, for regions not overriding it or when the chain runs out.
+func (p *ServiceConn) regionConn(id string) *ServiceConn {
+	from := id
+	for hops := 0; id != "" && hops <= len(p.regions); hops++ {
+		rc, ok := p.regions[id]
+		if !ok {
+			break
+		}
+		if rc.Reachable() {
+			if id != from {
+				metrics.RegionFailovers.WithLabelValues(p.name, from, id).Inc()
+			}
+			return rc
+		}
+		id = p.failover[id]
+	}
+	if _, ok := p.regions[from]; ok {
+		metrics.RegionFailovers.WithLabelValues(p.name, from, "shared").Inc()
+	}
+	return nil
+}
+
+// Close closes all endpoint connections
+func (p *ServiceConn) Close() error {
+	if p.stopMonitor != nil {
+		p.stopMonitor()
+	}
+
+	var firstErr error
+	for _, wc := range p.conns {
+		if err := wc.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if p.shadow != nil {
+		if err := p.shadow.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, tc := range p.tenants {
+		if err := tc.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, rc := range p.regions {
+		if err := rc.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,124 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"apigw/internal/app/config"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/resolver"
+)
+
+// consulWaitTime bounds a single Consul blocking query
+const consulWaitTime = 5 * time.Minute
+
+// consulBuilder resolves consul:///service[?tag=...] targets to the passing instances of a service
+type consulBuilder struct {
+	cfg    config.ConsulDiscoveryConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+// newConsulBuilder creates the Consul resolver builder
+func newConsulBuilder(cfg config.ConsulDiscoveryConfig, logger *logrus.Logger) *consulBuilder {
+	return &consulBuilder{cfg: cfg, client: &http.Client{}, logger: logger}
+}
+
+// Scheme implements resolver.Builder
+func (b *consulBuilder) Scheme() string {
+	return config.DiscoverySchemeConsul
+}
+
+// Build implements resolver.Builder
+func (b *consulBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	service := target.Endpoint()
+	if service == "" {
+		return nil, fmt.Errorf("consul target %q has no service name", target.URL.String())
+	}
+	tag := target.URL.Query().Get("tag")
+
+	return startWatch(cc, target.URL.String(), b.logger, func(ctx context.Context, update func([]string)) error {
+		var index uint64
+		for ctx.Err() == nil {
+			addrs, newIndex, err := b.healthy(ctx, service, tag, index)
+			if err != nil {
+				return err
+			}
+			// Consul may reset the index, in which case the watch starts over
+			if newIndex < index {
+				newIndex = 0
+			}
+			index = newIndex
+			update(addrs)
+		}
+		return nil
+	}), nil
+}
+
+// healthy returns the addresses of the passing instances of a service, blocking until
+// they change past index when index is positive
+func (b *consulBuilder) healthy(ctx context.Context, service, tag string, index uint64) ([]string, uint64, error) {
+	query := url.Values{"passing": {"true"}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if b.cfg.Datacenter != "" {
+		query.Set("dc", b.cfg.Datacenter)
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", consulWaitTime.String())
+	}
+	endpoint := strings.TrimSuffix(b.cfg.Address, "/") + "/v1/health/service/" + url.PathEscape(service) + "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if b.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", b.cfg.Token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("consul returned status %d", resp.StatusCode)
+	}
+
+	var entries []struct {
+		Node struct {
+			Address string `json:"Address"`
+		} `json:"Node"`
+		Service struct {
+			Address string `json:"Address"`
+			Port    int    `json:"Port"`
+		} `json:"Service"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+
+	addrs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// Instances registered without an address run on their node's address
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)))
+	}
+
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	return addrs, newIndex, nil
+}
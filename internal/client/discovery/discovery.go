@@ -0,0 +1,111 @@
+// Package discovery provides gRPC resolvers that discover backend addresses at runtime
+// from the Consul catalog (consul:///service) and Kubernetes Endpoints
+// (kubernetes:///service.namespace:port), re-resolving when membership changes.
+package discovery
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"apigw/internal/app/config"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/resolver"
+)
+
+// retryDelay is the pause before re-establishing a failed watch
+const retryDelay = 5 * time.Second
+
+// Register registers the resolvers of the enabled discovery backends; it must be
+// called before the service clients are created
+func Register(cfg config.DiscoveryConfig, logger *logrus.Logger) error {
+	if cfg.Consul.Enabled {
+		resolver.Register(newConsulBuilder(cfg.Consul, logger))
+		logger.WithField("address", cfg.Consul.Address).Info("Consul service discovery enabled")
+	}
+	if cfg.Kubernetes.Enabled {
+		builder, err := newKubernetesBuilder(cfg.Kubernetes, logger)
+		if err != nil {
+			return err
+		}
+		resolver.Register(builder)
+		logger.WithField("api_server", cfg.Kubernetes.APIServer).Info("Kubernetes service discovery enabled")
+	}
+	return nil
+}
+
+// watchFunc watches a discovery backend, calling update with every new address set,
+// until the context is cancelled or the watch fails. Returning nil restarts the watch immediately.
+type watchFunc func(ctx context.Context, update func(addrs []string)) error
+
+// watchResolver is a gRPC resolver fed by a background watch
+type watchResolver struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startWatch runs the watch until the resolver is closed, pushing address changes to
+// the connection and retrying failed watches
+func startWatch(cc resolver.ClientConn, target string, logger *logrus.Logger, watch watchFunc) *watchResolver {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &watchResolver{cancel: cancel, done: make(chan struct{})}
+
+	var last []string
+	update := func(addrs []string) {
+		sort.Strings(addrs)
+		if last != nil && slices.Equal(addrs, last) {
+			return
+		}
+		last = addrs
+
+		state := resolver.State{}
+		for _, addr := range addrs {
+			state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+		}
+		logger.WithFields(logrus.Fields{
+			"target":    target,
+			"addresses": addrs,
+		}).Info("Discovered backend addresses")
+		// An empty address set is reported as an error by the balancer; calls fail until members return
+		_ = cc.UpdateState(state)
+	}
+
+	go func() {
+		defer close(r.done)
+		for ctx.Err() == nil {
+			err := watch(ctx, update)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+			logger.WithError(err).WithField("target", target).Warn("Service discovery watch failed")
+			cc.ReportError(err)
+			if !sleepContext(ctx, retryDelay) {
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// ResolveNow implements resolver.Resolver; addresses are pushed by the watch
+func (r *watchResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close implements resolver.Resolver
+func (r *watchResolver) Close() {
+	r.cancel()
+	<-r.done
+}
+
+// sleepContext waits for the duration, returning false if the context is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,195 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"apigw/internal/app/config"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/resolver"
+)
+
+// kubernetesBuilder resolves kubernetes:///service[.namespace]:port targets to the ready
+// addresses of a service's Endpoints. The port is a container port number or a named port.
+type kubernetesBuilder struct {
+	cfg    config.KubernetesDiscoveryConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+// kubernetesEndpoints is the subset of a core/v1 Endpoints object used for discovery
+type kubernetesEndpoints struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+// newKubernetesBuilder creates the Kubernetes resolver builder, trusting the cluster CA when present
+func newKubernetesBuilder(cfg config.KubernetesDiscoveryConfig, logger *logrus.Logger) (*kubernetesBuilder, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read kubernetes CA file: %w", err)
+		}
+		if err == nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificates found in kubernetes CA file %s", cfg.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &kubernetesBuilder{
+		cfg:    cfg,
+		client: &http.Client{Transport: transport},
+		logger: logger,
+	}, nil
+}
+
+// Scheme implements resolver.Builder
+func (b *kubernetesBuilder) Scheme() string {
+	return config.DiscoverySchemeKubernetes
+}
+
+// Build implements resolver.Builder
+func (b *kubernetesBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	hostPort := target.Endpoint()
+	service, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes target %q must be service[.namespace]:port: %w", target.URL.String(), err)
+	}
+	namespace := b.cfg.Namespace
+	if name, ns, ok := strings.Cut(service, "."); ok {
+		service, namespace = name, ns
+	}
+
+	return startWatch(cc, target.URL.String(), b.logger, func(ctx context.Context, update func([]string)) error {
+		return b.watch(ctx, namespace, service, port, update)
+	}), nil
+}
+
+// watch lists the service's Endpoints and then follows the watch stream until it ends
+func (b *kubernetesBuilder) watch(ctx context.Context, namespace, service, port string, update func([]string)) error {
+	resp, err := b.get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/endpoints/%s", url.PathEscape(namespace), url.PathEscape(service)))
+	if err != nil {
+		return err
+	}
+	var endpoints kubernetesEndpoints
+	err = json.NewDecoder(resp.Body).Decode(&endpoints)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode kubernetes endpoints: %w", err)
+	}
+	update(endpoints.addresses(port))
+
+	query := url.Values{
+		"watch":           {"true"},
+		"fieldSelector":   {"metadata.name=" + service},
+		"resourceVersion": {endpoints.Metadata.ResourceVersion},
+	}
+	resp, err = b.get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/endpoints?%s", url.PathEscape(namespace), query.Encode()))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event struct {
+			Type   string              `json:"type"`
+			Object kubernetesEndpoints `json:"object"`
+		}
+		if err := decoder.Decode(&event); err != nil {
+			// The API server ends watches periodically; the next watch lists again
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("kubernetes watch stream failed: %w", err)
+		}
+
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			update(event.Object.addresses(port))
+		case "DELETED":
+			update([]string{})
+		case "ERROR":
+			// Typically an expired resource version; the retry lists again
+			return errors.New("kubernetes watch returned an error event")
+		}
+	}
+}
+
+// get sends an authenticated GET request to the API server
+func (b *kubernetesBuilder) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(b.cfg.APIServer, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The token is read on every request because projected service account tokens rotate
+	if b.cfg.TokenFile != "" {
+		if token, err := os.ReadFile(b.cfg.TokenFile); err == nil {
+			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("kubernetes API returned status %d for %s", resp.StatusCode, path)
+	}
+	return resp, nil
+}
+
+// addresses returns the ready addresses serving the port, given as a number or a port name;
+// an empty port matches subsets exposing a single port
+func (e kubernetesEndpoints) addresses(port string) []string {
+	addrs := []string{}
+	for _, subset := range e.Subsets {
+		target := ""
+		if _, err := strconv.Atoi(port); err == nil {
+			target = port
+		} else {
+			for _, p := range subset.Ports {
+				if p.Name == port || (port == "" && len(subset.Ports) == 1) {
+					target = strconv.Itoa(p.Port)
+				}
+			}
+		}
+		if target == "" {
+			continue
+		}
+		for _, addr := range subset.Addresses {
+			addrs = append(addrs, net.JoinHostPort(addr.IP, target))
+		}
+	}
+	return addrs
+}
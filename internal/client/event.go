@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+)
+
+// EventServiceClient represents a client for the event service
+type EventServiceClient struct {
+	conn *ServiceConn
+}
+
+// NewEventServiceClient creates a new event service client
+func NewEventServiceClient(cfg *config.EventServiceConfig) (*EventServiceClient, error) {
+	conn, err := NewClientFactory().Dial(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to event service: %w", err)
+	}
+
+	return &EventServiceClient{
+		conn: conn,
+	}, nil
+}
+
+// client returns a stub over the service connection, which selects an endpoint per call
+func (c *EventServiceClient) client() pb.EventServiceClient {
+	return pb.NewEventServiceClient(c.conn)
+}
+
+// Close closes the gRPC connections
+func (c *EventServiceClient) Close() error {
+	return c.conn.Close()
+}
+
+// ListEvents searches the event catalog
+func (c *EventServiceClient) ListEvents(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	return c.client().ListEvents(ctx, req)
+}
+
+// GetEvent returns a single event
+func (c *EventServiceClient) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.GetEventResponse, error) {
+	return c.client().GetEvent(ctx, req)
+}
+
+// GetSeatMap returns seat availability for an event
+func (c *EventServiceClient) GetSeatMap(ctx context.Context, req *pb.GetSeatMapRequest) (*pb.GetSeatMapResponse, error) {
+	return c.client().GetSeatMap(ctx, req)
+}
+
+// CreateEvent creates a new event
+func (c *EventServiceClient) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.CreateEventResponse, error) {
+	return c.client().CreateEvent(ctx, req)
+}
+
+// UpdateEvent partially updates an event
+func (c *EventServiceClient) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error) {
+	return c.client().UpdateEvent(ctx, req)
+}
+
+// CloseEvent closes sales for an event
+func (c *EventServiceClient) CloseEvent(ctx context.Context, req *pb.CloseEventRequest) (*pb.CloseEventResponse, error) {
+	return c.client().CloseEvent(ctx, req)
+}
+
+// AdjustInventory adds or removes tickets from an event's inventory
+func (c *EventServiceClient) AdjustInventory(ctx context.Context, req *pb.AdjustInventoryRequest) (*pb.AdjustInventoryResponse, error) {
+	return c.client().AdjustInventory(ctx, req)
+}
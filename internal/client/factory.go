@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"apigw/internal/app/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ClientFactory builds connections to backend services from their configuration,
+// applying keepalive, TLS, load balancing and interceptors uniformly
+type ClientFactory struct {
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	dialOptions        []grpc.DialOption
+	stateObservers     []StateObserver
+}
+
+// StateObserver is told of the connectivity state transitions of the endpoints of a
+// service
+type StateObserver func(service, endpoint string, from, to connectivity.State)
+
+// NewClientFactory creates a client factory without extra interceptors
+func NewClientFactory() *ClientFactory {
+	return &ClientFactory{}
+}
+
+// UseUnary adds unary interceptors to every connection built afterwards; the first one is outermost
+func (f *ClientFactory) UseUnary(interceptors ...grpc.UnaryClientInterceptor) *ClientFactory {
+	f.unaryInterceptors = append(f.unaryInterceptors, interceptors...)
+	return f
+}
+
+// UseStream adds stream interceptors to every connection built afterwards; the first one is outermost
+func (f *ClientFactory) UseStream(interceptors ...grpc.StreamClientInterceptor) *ClientFactory {
+	f.streamInterceptors = append(f.streamInterceptors, interceptors...)
+	return f
+}
+
+// OnStateChange adds observers of the connectivity state transitions of the endpoints
+// of every connection built afterwards
+func (f *ClientFactory) OnStateChange(observers ...StateObserver) *ClientFactory {
+	f.stateObservers = append(f.stateObservers, observers...)
+	return f
+}
+
+// WithDialOptions adds dial options to every connection built afterwards
+func (f *ClientFactory) WithDialOptions(opts ...grpc.DialOption) *ClientFactory {
+	f.dialOptions = append(f.dialOptions, opts...)
+	return f
+}
+
+// Dial connects to every configured endpoint of a service
+func (f *ClientFactory) Dial(cfg *config.ServiceConfig) (*ServiceConn, error) {
+	conn := &ServiceConn{name: cfg.Name, sticky: cfg.StickyRouting}
+
+	opts := append([]grpc.DialOption(nil), f.dialOptions...)
+	unary := append([]grpc.UnaryClientInterceptor(nil), f.unaryInterceptors...)
+	if cfg.Shadow.Enabled {
+		shadow, err := newShadowMirror(cfg)
+		if err != nil {
+			return nil, err
+		}
+		conn.shadow = shadow
+		// Innermost, so only calls that reach the upstream are mirrored
+		unary = append(unary, shadow.UnaryClientInterceptor())
+	}
+	if len(unary) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(unary...))
+	}
+	if len(f.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(f.streamInterceptors...))
+	}
+
+	for _, ep := range cfg.ResolvedEndpoints() {
+		if ep.Weight <= 0 {
+			continue
+		}
+
+		address := ep.Address()
+		cc, err := dialEndpoint(cfg, address, opts...)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to %s endpoint %s: %w", cfg.Name, address, err)
+		}
+
+		conn.conns = append(conn.conns, weightedConn{name: ep.Name, weight: ep.Weight, conn: cc})
+		conn.totalWeight += ep.Weight
+	}
+
+	if len(conn.conns) == 0 {
+		conn.Close()
+		return nil, fmt.Errorf("no endpoints with positive weight configured for %s", cfg.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	conn.stopMonitor = cancel
+	conn.monitor(ctx, cfg.Name, f.stateObservers)
+
+	return conn, nil
+}
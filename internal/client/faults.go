@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"apigw/internal/app/faults"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FaultInterceptor injects the faults of the upstream rules into a share of the unary
+// backend calls: the latency is waited out within the call's deadline, then the call
+// fails with the rule's code, or with Unavailable as a reset connection does, without
+// reaching the backend.
+func FaultInterceptor(injector *faults.Injector, logger *logrus.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := injectFault(ctx, injector, method, logger); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// FaultStreamInterceptor injects the faults of the upstream rules into a share of the
+// streaming backend calls when they are opened
+func FaultStreamInterceptor(injector *faults.Injector, logger *logrus.Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := injectFault(ctx, injector, method, logger); err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// injectFault applies the fault picked for a call, returning the error it fails with
+func injectFault(ctx context.Context, injector *faults.Injector, method string, logger *logrus.Logger) error {
+	fault, ok := injector.Upstream(method)
+	if !ok {
+		return nil
+	}
+	logger.WithFields(logrus.Fields{
+		"grpc_method": method,
+		"kind":        fault.Kind(),
+		"latency":     fault.Latency.String(),
+	}).Info("Injecting upstream fault")
+
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	switch {
+	case fault.Reset:
+		return status.Error(codes.Unavailable, "connection reset by fault injection")
+	case fault.Code != codes.OK:
+		return status.Errorf(fault.Code, "fault injected into %s", method)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+
+	pb "apigw/client/proto"
+
+	"google.golang.org/grpc"
+)
+
+//go:generate mockgen -destination=mocks/services.go -package=mocks apigw/internal/client UserService,OrderService,EventService,PaymentService
+
+// UserService is the user service API the handlers depend on
+type UserService interface {
+	Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error)
+	Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error)
+	RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error)
+	GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error)
+	UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error)
+	ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error)
+	RequestEmailVerification(ctx context.Context, req *pb.RequestEmailVerificationRequest) (*pb.RequestEmailVerificationResponse, error)
+	ConfirmEmailVerification(ctx context.Context, req *pb.ConfirmEmailVerificationRequest) (*pb.ConfirmEmailVerificationResponse, error)
+	RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error)
+	ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error)
+	UploadAvatar(ctx context.Context) (grpc.ClientStreamingClient[pb.UploadAvatarRequest, pb.UploadAvatarResponse], error)
+}
+
+// OrderService is the order service API the handlers depend on
+type OrderService interface {
+	PurchaseTicket(ctx context.Context, req *pb.PurchaseRequest) (*pb.PurchaseResponse, error)
+	ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error)
+	GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error)
+	CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error)
+}
+
+// EventService is the event service API the handlers depend on
+type EventService interface {
+	ListEvents(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error)
+	GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.GetEventResponse, error)
+	GetSeatMap(ctx context.Context, req *pb.GetSeatMapRequest) (*pb.GetSeatMapResponse, error)
+	CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.CreateEventResponse, error)
+	UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error)
+	CloseEvent(ctx context.Context, req *pb.CloseEventRequest) (*pb.CloseEventResponse, error)
+	AdjustInventory(ctx context.Context, req *pb.AdjustInventoryRequest) (*pb.AdjustInventoryResponse, error)
+}
+
+// PaymentService is the payment service API the handlers depend on
+type PaymentService interface {
+	CreatePaymentIntent(ctx context.Context, req *pb.CreatePaymentIntentRequest) (*pb.CreatePaymentIntentResponse, error)
+	ConfirmPayment(ctx context.Context, req *pb.ConfirmPaymentRequest) (*pb.ConfirmPaymentResponse, error)
+	GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.GetPaymentResponse, error)
+}
+
+// Compile-time checks that the gRPC clients implement the service interfaces
+var (
+	_ UserService    = (*UserServiceClient)(nil)
+	_ OrderService   = (*OrderServiceClient)(nil)
+	_ EventService   = (*EventServiceClient)(nil)
+	_ PaymentService = (*PaymentServiceClient)(nil)
+)
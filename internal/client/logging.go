@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/region"
+	"apigw/internal/app/tenant"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// redactedValue replaces the value of redacted payload fields
+const redactedValue = "[REDACTED]"
+
+// clientErrorCodes are failures caused by the caller, logged as warnings rather than errors
+var clientErrorCodes = map[codes.Code]bool{
+	codes.Canceled:           true,
+	codes.InvalidArgument:    true,
+	codes.NotFound:           true,
+	codes.AlreadyExists:      true,
+	codes.PermissionDenied:   true,
+	codes.Unauthenticated:    true,
+	codes.FailedPrecondition: true,
+	codes.OutOfRange:         true,
+}
+
+// LoggingInterceptor logs every backend call with its method, status code and duration.
+// At debug level the redacted request and response of a sampled share of calls are included.
+// Calls are logged with the structured logger, as every request makes some.
+func LoggingInterceptor(cfg config.GRPCLogConfig, logger *logrus.Logger) grpc.UnaryClientInterceptor {
+	redactFields := make([]string, 0, len(cfg.RedactFields))
+	for _, field := range cfg.RedactFields {
+		redactFields = append(redactFields, normalizeFieldName(field))
+	}
+	structured := logutils.Structured(logutils.ForModule(logger, logutils.ModuleGRPC))
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		code := status.Code(err)
+
+		level, message := logutils.LevelInfo, "gRPC call completed"
+		switch {
+		case err == nil:
+		case clientErrorCodes[code]:
+			level, message = logutils.LevelWarn, "gRPC call failed"
+		default:
+			level, message = logutils.LevelError, "gRPC call failed"
+		}
+		if !structured.Enabled(ctx, level) {
+			return err
+		}
+
+		attrs := make([]logutils.Attr, 0, 9)
+		attrs = append(attrs,
+			logutils.String("grpc_method", method),
+			logutils.String("grpc_code", code.String()),
+			logutils.Milliseconds("duration_ms", time.Since(start)),
+			logutils.String("target", cc.Target()),
+		)
+		if id, ok := tenant.FromContext(ctx); ok {
+			attrs = append(attrs, logutils.String("tenant_id", id))
+		}
+		if id, ok := region.FromContext(ctx); ok {
+			attrs = append(attrs, logutils.String("region", id))
+		}
+
+		if structured.Enabled(ctx, logutils.LevelDebug) && rand.Float64()*100 < cfg.PayloadSamplePercentage {
+			attrs = append(attrs, logutils.String("request", redactPayload(req, redactFields)))
+			if err == nil {
+				attrs = append(attrs, logutils.String("response", redactPayload(reply, redactFields)))
+			}
+		}
+		if err != nil {
+			attrs = append(attrs, logutils.Err(err))
+		}
+		structured.LogAttrs(ctx, level, message, attrs...)
+		return err
+	}
+}
+
+// redactPayload renders a message as JSON with the values of matching fields masked
+func redactPayload(msg any, redactFields []string) string {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return ""
+	}
+	raw, err := protojson.Marshal(m)
+	if err != nil {
+		return ""
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return ""
+	}
+	redacted, _ := json.Marshal(redactValue(payload, redactFields))
+	return string(redacted)
+}
+
+// redactValue masks the fields of a decoded JSON value whose names match a redacted field
+func redactValue(value interface{}, redactFields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isRedactedField(key, redactFields) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field, redactFields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, redactFields)
+		}
+	}
+	return value
+}
+
+// isRedactedField reports whether a field name contains one of the redacted names
+func isRedactedField(name string, redactFields []string) bool {
+	normalized := normalizeFieldName(name)
+	for _, field := range redactFields {
+		if field != "" && strings.Contains(normalized, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFieldName lower-cases a field name and drops underscores so that
+// snake_case and camelCase names compare equal
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
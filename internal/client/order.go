@@ -6,47 +6,51 @@ import (
 
 	pb "apigw/client/proto"
 	"apigw/internal/app/config"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/keepalive"
 )
 
 // TicketServiceClient represents a client for the ticket service
 type OrderServiceClient struct {
-	client pb.OrderServiceClient
-	conn   *grpc.ClientConn
+	conn *ServiceConn
 }
 
 // NewOrderServiceClient creates a new order service client
 func NewOrderServiceClient(cfg *config.OrderServiceConfig) (*OrderServiceClient, error) {
-	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	conn, err := grpc.NewClient(address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                cfg.GRPC.KeepaliveTime,
-			Timeout:             cfg.GRPC.KeepaliveTimeout,
-			PermitWithoutStream: cfg.GRPC.KeepalivePermitWithoutStream,
-		}),
-	)
+	conn, err := NewClientFactory().Dial(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to ticket service: %w", err)
 	}
 
-	client := pb.NewOrderServiceClient(conn)
-
 	return &OrderServiceClient{
-		client: client,
-		conn:   conn,
+		conn: conn,
 	}, nil
 }
 
-// Close closes the gRPC connection
+// client returns a stub over the service connection, which selects an endpoint per call
+func (c *OrderServiceClient) client() pb.OrderServiceClient {
+	return pb.NewOrderServiceClient(c.conn)
+}
+
+// Close closes the gRPC connections
 func (c *OrderServiceClient) Close() error {
 	return c.conn.Close()
 }
 
 // PurchaseTicket purchases a ticket for the specified event and user
 func (c *OrderServiceClient) PurchaseTicket(ctx context.Context, req *pb.PurchaseRequest) (*pb.PurchaseResponse, error) {
-	return c.client.PurchaseTicket(ctx, req)
+	return c.client().PurchaseTicket(ctx, req)
+}
+
+// ListOrders lists the orders of a user
+func (c *OrderServiceClient) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	return c.client().ListOrders(ctx, req)
+}
+
+// GetOrder returns a single order
+func (c *OrderServiceClient) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	return c.client().GetOrder(ctx, req)
+}
+
+// CancelOrder cancels an order and refunds it when allowed
+func (c *OrderServiceClient) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
+	return c.client().CancelOrder(ctx, req)
 }
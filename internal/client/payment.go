@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+)
+
+// PaymentServiceClient represents a client for the payment service
+type PaymentServiceClient struct {
+	conn *ServiceConn
+}
+
+// NewPaymentServiceClient creates a new payment service client
+func NewPaymentServiceClient(cfg *config.PaymentServiceConfig) (*PaymentServiceClient, error) {
+	conn, err := NewClientFactory().Dial(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to payment service: %w", err)
+	}
+
+	return &PaymentServiceClient{
+		conn: conn,
+	}, nil
+}
+
+// client returns a stub over the service connection, which selects an endpoint per call
+func (c *PaymentServiceClient) client() pb.PaymentServiceClient {
+	return pb.NewPaymentServiceClient(c.conn)
+}
+
+// Close closes the gRPC connections
+func (c *PaymentServiceClient) Close() error {
+	return c.conn.Close()
+}
+
+// CreatePaymentIntent creates a payment intent for an order
+func (c *PaymentServiceClient) CreatePaymentIntent(ctx context.Context, req *pb.CreatePaymentIntentRequest) (*pb.CreatePaymentIntentResponse, error) {
+	return c.client().CreatePaymentIntent(ctx, req)
+}
+
+// ConfirmPayment confirms a payment intent
+func (c *PaymentServiceClient) ConfirmPayment(ctx context.Context, req *pb.ConfirmPaymentRequest) (*pb.ConfirmPaymentResponse, error) {
+	return c.client().ConfirmPayment(ctx, req)
+}
+
+// GetPayment returns the status of a payment
+func (c *PaymentServiceClient) GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.GetPaymentResponse, error) {
+	return c.client().GetPayment(ctx, req)
+}
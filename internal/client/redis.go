@@ -2,8 +2,8 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
-	"time"
 
 	"apigw/internal/app/config"
 
@@ -13,52 +13,173 @@ import (
 
 // RedisClient represents a Redis client wrapper
 type RedisClient struct {
-	client *redis.Client
+	client redis.UniversalClient
+	// reads reads from the replicas of the primary; nil reads from the primary
+	reads  redis.UniversalClient
 	logger *logrus.Logger
 }
 
-// NewRedisClient creates a new Redis client
+// NewRedisClient creates a new Redis client for a standalone server, a Sentinel-managed
+// master or a Cluster, depending on the configured mode
 func NewRedisClient(cfg *config.RedisConfig, logger *logrus.Logger) (*RedisClient, error) {
 	if !cfg.Enabled {
 		return nil, fmt.Errorf("Redis is not enabled")
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		DB:           cfg.DB,
-		DialTimeout:  5 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
-		PoolSize:     10,
-		MinIdleConns: 5,
-	})
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.ResolvedAddrs(),
+		DB:               cfg.DB,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		MasterName:       cfg.MasterName,
+		MaxRetries:       cfg.MaxRetries,
+		MinRetryBackoff:  cfg.MinRetryBackoff,
+		MaxRetryBackoff:  cfg.MaxRetryBackoff,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		PoolTimeout:      cfg.PoolTimeout,
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := redisTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	var client redis.UniversalClient
+	switch cfg.Mode {
+	case config.RedisModeSentinel:
+		client = redis.NewFailoverClient(opts.Failover())
+	case config.RedisModeCluster:
+		client = redis.NewClusterClient(opts.Cluster())
+	default:
+		client = redis.NewClient(opts.Simple())
+	}
 
 	// Test the connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	logger.WithFields(logrus.Fields{
-		"host": cfg.Host,
-		"port": cfg.Port,
-		"db":   cfg.DB,
+		"mode":      cfg.Mode,
+		"addrs":     opts.Addrs,
+		"db":        cfg.DB,
+		"tls":       cfg.TLS.Enabled,
+		"read_from": cfg.ReadFrom,
 	}).Info("Redis client connected successfully")
 
-	return &RedisClient{
+	rc := &RedisClient{
 		client: client,
 		logger: logger,
-	}, nil
+	}
+	if cfg.ReadFrom == config.RedisReadFromReplicas {
+		rc.reads = newReplicaClient(cfg, opts)
+		// Replicas that are down do not keep the gateway from starting: reads fall back
+		// to the primary until they are back
+		if err := rc.reads.Ping(ctx).Err(); err != nil {
+			logger.WithError(err).Warn("Redis replicas unreachable, reading from the primary until they are back")
+		}
+	}
+	return rc, nil
+}
+
+// newReplicaClient creates the client reading from the replicas of the primary: the
+// replicas listed, spread over by key, in standalone mode, a replica picked by the
+// sentinels in sentinel mode, and the replicas of every shard in cluster mode
+func newReplicaClient(cfg *config.RedisConfig, opts *redis.UniversalOptions) redis.UniversalClient {
+	switch cfg.Mode {
+	case config.RedisModeSentinel:
+		failover := opts.Failover()
+		failover.SlaveOnly = true
+		return redis.NewFailoverClient(failover)
+	case config.RedisModeCluster:
+		cluster := opts.Cluster()
+		cluster.ReadOnly = true
+		return redis.NewClusterClient(cluster)
+	default:
+		replicas := make(map[string]string, len(cfg.Replicas))
+		for _, addr := range cfg.Replicas {
+			replicas[addr] = addr
+		}
+		return redis.NewRing(&redis.RingOptions{
+			Addrs:           replicas,
+			Username:        opts.Username,
+			Password:        opts.Password,
+			DB:              opts.DB,
+			MaxRetries:      opts.MaxRetries,
+			MinRetryBackoff: opts.MinRetryBackoff,
+			MaxRetryBackoff: opts.MaxRetryBackoff,
+			DialTimeout:     opts.DialTimeout,
+			ReadTimeout:     opts.ReadTimeout,
+			WriteTimeout:    opts.WriteTimeout,
+			PoolSize:        opts.PoolSize,
+			MinIdleConns:    opts.MinIdleConns,
+			PoolTimeout:     opts.PoolTimeout,
+			TLSConfig:       opts.TLSConfig,
+		})
+	}
+}
+
+// redisTLSConfig builds the TLS configuration for Redis connections
+func redisTLSConfig(cfg config.RedisTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis CA file: %w", err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
 }
 
 // GetClient returns the underlying Redis client
-func (rc *RedisClient) GetClient() *redis.Client {
+func (rc *RedisClient) GetClient() redis.UniversalClient {
 	return rc.client
 }
 
-// Close closes the Redis connection
+// GetReadClient returns the client the rate limiters read their buckets from: the
+// replicas when redis.read_from is replicas, the primary otherwise
+func (rc *RedisClient) GetReadClient() redis.UniversalClient {
+	if rc.reads == nil {
+		return rc.client
+	}
+	return rc.reads
+}
+
+// Close closes the Redis connections
 func (rc *RedisClient) Close() error {
-	return rc.client.Close()
+	err := rc.client.Close()
+	if rc.reads != nil {
+		if readErr := rc.reads.Close(); err == nil {
+			err = readErr
+		}
+	}
+	return err
 }
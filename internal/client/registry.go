@@ -0,0 +1,156 @@
+package client
+
+import (
+	"fmt"
+
+	"apigw/internal/app/config"
+)
+
+// Service names in the registry, matching their keys under services in the configuration
+const (
+	UserServiceName    = "user_service"
+	OrderServiceName   = "order_service"
+	EventServiceName   = "event_service"
+	PaymentServiceName = "payment_service"
+)
+
+// Registry holds one connection per configured backend service and the typed clients
+// built on them. Handlers resolve their clients from it.
+type Registry struct {
+	conns map[string]*ServiceConn
+
+	user    *UserServiceClient
+	order   *OrderServiceClient
+	event   *EventServiceClient
+	payment *PaymentServiceClient
+}
+
+// Overrides are the upstreams of tenants and regions replacing those of services for
+// their calls
+type Overrides struct {
+	Tenants []config.TenantConfig
+	Regions []config.RegionConfig
+}
+
+// NewRegistry connects to every service in the configuration through the factory, and to
+// the upstreams of tenants and regions overriding them; calls of those tenants and regions
+// are sent to theirs
+func NewRegistry(factory *ClientFactory, services config.ServicesConfig, overrides Overrides) (*Registry, error) {
+	r := &Registry{conns: make(map[string]*ServiceConn)}
+
+	all := services.All()
+	for _, name := range services.Names() {
+		svc := all[name]
+		conn, err := factory.Dial(&svc)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to connect to %s: %w", name, err)
+		}
+		r.conns[name] = conn
+
+		for _, t := range overrides.Tenants {
+			tenantSvc, ok := t.Service(name, svc)
+			if !ok {
+				continue
+			}
+			tc, err := factory.Dial(&tenantSvc)
+			if err != nil {
+				r.Close()
+				return nil, fmt.Errorf("failed to connect to %s of tenant %s: %w", name, t.ID, err)
+			}
+			if conn.tenants == nil {
+				conn.tenants = make(map[string]*ServiceConn)
+			}
+			conn.tenants[t.ID] = tc
+		}
+
+		conn.failover = make(map[string]string, len(overrides.Regions))
+		for _, region := range overrides.Regions {
+			conn.failover[region.ID] = region.Failover
+			regionSvc, ok := region.Service(name, svc)
+			if !ok {
+				continue
+			}
+			rc, err := factory.Dial(&regionSvc)
+			if err != nil {
+				r.Close()
+				return nil, fmt.Errorf("failed to connect to %s of region %s: %w", name, region.ID, err)
+			}
+			if conn.regions == nil {
+				conn.regions = make(map[string]*ServiceConn)
+			}
+			conn.regions[region.ID] = rc
+		}
+	}
+
+	r.user = &UserServiceClient{conn: r.conns[UserServiceName]}
+	r.order = &OrderServiceClient{conn: r.conns[OrderServiceName]}
+	r.event = &EventServiceClient{conn: r.conns[EventServiceName]}
+	r.payment = &PaymentServiceClient{conn: r.conns[PaymentServiceName]}
+	return r, nil
+}
+
+// Conn returns the connection to a service by name, for building clients of
+// services without a typed wrapper
+func (r *Registry) Conn(name string) (*ServiceConn, bool) {
+	conn, ok := r.conns[name]
+	return conn, ok
+}
+
+// States returns the connectivity state of every endpoint of every service, and of the
+// upstreams of tenants and regions as <service>@<tenant or region>
+func (r *Registry) States() map[string][]EndpointState {
+	states := make(map[string][]EndpointState, len(r.conns))
+	for name, conn := range r.conns {
+		states[name] = conn.States()
+		for id, tc := range conn.tenants {
+			states[name+"@"+id] = tc.States()
+		}
+		for id, rc := range conn.regions {
+			states[name+"@"+id] = rc.States()
+		}
+	}
+	return states
+}
+
+// BeenReady reports whether every service has had a ready connection since startup; the
+// upstreams of tenants and regions are not waited for
+func (r *Registry) BeenReady() bool {
+	for _, conn := range r.conns {
+		if !conn.BeenReady() {
+			return false
+		}
+	}
+	return true
+}
+
+// User returns the user service client
+func (r *Registry) User() *UserServiceClient {
+	return r.user
+}
+
+// Order returns the order service client
+func (r *Registry) Order() *OrderServiceClient {
+	return r.order
+}
+
+// Event returns the event service client
+func (r *Registry) Event() *EventServiceClient {
+	return r.event
+}
+
+// Payment returns the payment service client
+func (r *Registry) Payment() *PaymentServiceClient {
+	return r.payment
+}
+
+// Close closes every service connection
+func (r *Registry) Close() error {
+	var firstErr error
+	for _, conn := range r.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"apigw/internal/app/config"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// shadowMirror duplicates a share of unary calls to a shadow upstream
+type shadowMirror struct {
+	service    string
+	conn       *grpc.ClientConn
+	percentage float64
+	timeout    time.Duration
+	logger     *logrus.Logger
+}
+
+// newShadowMirror dials the shadow upstream of a service
+func newShadowMirror(cfg *config.ServiceConfig) (*shadowMirror, error) {
+	address := fmt.Sprintf("%s:%d", cfg.Shadow.Host, cfg.Shadow.Port)
+	conn, err := dialEndpoint(cfg, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s shadow %s: %w", cfg.Name, address, err)
+	}
+
+	return &shadowMirror{
+		service:    cfg.Name,
+		conn:       conn,
+		percentage: cfg.Shadow.Percentage,
+		timeout:    cfg.Shadow.Timeout,
+		logger:     logutils.GetLogger(),
+	}, nil
+}
+
+// UnaryClientInterceptor mirrors sampled calls to the shadow upstream
+func (m *shadowMirror) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if rand.Float64()*100 < m.percentage {
+			m.mirror(ctx, method, req, reply)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// mirror sends a copy of the call to the shadow upstream in the background,
+// discarding the response and only logging failures
+func (m *shadowMirror) mirror(ctx context.Context, method string, req, reply any) {
+	reqMsg, ok := req.(proto.Message)
+	if !ok {
+		return
+	}
+	replyMsg, ok := reply.(proto.Message)
+	if !ok {
+		return
+	}
+
+	shadowReq := proto.Clone(reqMsg)
+	shadowReply := replyMsg.ProtoReflect().New().Interface()
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	go func() {
+		// Detach from the client request so shadow calls never affect its lifetime
+		shadowCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md.Copy()), m.timeout)
+		defer cancel()
+
+		if err := m.conn.Invoke(shadowCtx, method, shadowReq, shadowReply); err != nil {
+			m.logger.WithError(err).WithFields(logrus.Fields{
+				"service": m.service,
+				"method":  method,
+			}).Warn("Shadow request failed")
+		}
+	}()
+}
+
+// Close closes the shadow connection
+func (m *shadowMirror) Close() error {
+	return m.conn.Close()
+}
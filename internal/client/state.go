@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+
+	"apigw/internal/app/metrics"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connectivityStates lists the states exported per endpoint
+var connectivityStates = []connectivity.State{
+	connectivity.Idle,
+	connectivity.Connecting,
+	connectivity.Ready,
+	connectivity.TransientFailure,
+	connectivity.Shutdown,
+}
+
+// EndpointState is the connectivity state of one endpoint of a service
+type EndpointState struct {
+	Endpoint string `json:"endpoint"`
+	State    string `json:"state"`
+}
+
+// States returns the current connectivity state of every endpoint
+func (p *ServiceConn) States() []EndpointState {
+	states := make([]EndpointState, 0, len(p.conns))
+	for _, wc := range p.conns {
+		states = append(states, EndpointState{
+			Endpoint: wc.endpoint(),
+			State:    wc.conn.GetState().String(),
+		})
+	}
+	return states
+}
+
+// Ready reports whether at least one endpoint has a ready connection
+func (p *ServiceConn) Ready() bool {
+	for _, wc := range p.conns {
+		if wc.conn.GetState() == connectivity.Ready {
+			return true
+		}
+	}
+	return false
+}
+
+// Reachable reports whether an endpoint is not failing to connect; endpoints that are
+// idle or connecting are given the benefit of the doubt
+func (p *ServiceConn) Reachable() bool {
+	for _, wc := range p.conns {
+		if state := wc.conn.GetState(); state != connectivity.TransientFailure && state != connectivity.Shutdown {
+			return true
+		}
+	}
+	return false
+}
+
+// BeenReady reports whether the service has had a ready connection since it was dialled
+func (p *ServiceConn) BeenReady() bool {
+	return p.beenReady.Load()
+}
+
+// endpoint identifies the endpoint by name, falling back to its target
+func (wc weightedConn) endpoint() string {
+	if wc.name != "" {
+		return wc.name
+	}
+	return wc.conn.Target()
+}
+
+// monitor connects every endpoint eagerly and follows its connectivity state until
+// the connection is closed, logging transitions, exporting them as metrics and telling
+// observers. Idle connections are reconnected so failures surface before the next call.
+func (p *ServiceConn) monitor(ctx context.Context, service string, observers []StateObserver) {
+	logger := logutils.GetLogger()
+	for _, wc := range p.conns {
+		go func(wc weightedConn) {
+			endpoint := wc.endpoint()
+			fields := logrus.Fields{"service": service, "endpoint": endpoint}
+
+			state := wc.conn.GetState()
+			wc.conn.Connect()
+			for {
+				for _, s := range connectivityStates {
+					value := 0.0
+					if s == state {
+						value = 1
+					}
+					metrics.UpstreamState.WithLabelValues(service, endpoint, s.String()).Set(value)
+				}
+				if state == connectivity.Ready {
+					p.beenReady.Store(true)
+				}
+				if state == connectivity.Idle {
+					wc.conn.Connect()
+				}
+
+				if !wc.conn.WaitForStateChange(ctx, state) {
+					return
+				}
+				newState := wc.conn.GetState()
+				metrics.UpstreamStateTransitions.WithLabelValues(service, endpoint, newState.String()).Inc()
+
+				entry := logger.WithFields(fields).WithFields(logrus.Fields{"from": state.String(), "to": newState.String()})
+				switch newState {
+				case connectivity.TransientFailure:
+					entry.Warn("Upstream connection state changed")
+				case connectivity.Ready:
+					entry.Info("Upstream connection state changed")
+				default:
+					entry.Debug("Upstream connection state changed")
+				}
+				for _, observe := range observers {
+					observe(service, endpoint, state, newState)
+				}
+				state = newState
+			}
+		}(wc)
+	}
+}
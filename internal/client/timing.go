@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"apigw/pkg/utils/timing"
+
+	"google.golang.org/grpc"
+)
+
+// TimingInterceptor adds the duration of every backend call to the upstream time of the
+// request making it, when the request is timed
+func TimingInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		timings := timing.FromContext(ctx)
+		if timings == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		timings.Since(timing.Upstream, start)
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"apigw/internal/app/config"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// transportCredentials returns TLS credentials for a service, or plaintext when TLS is disabled
+func transportCredentials(cfg *config.ServiceConfig) (credentials.TransportCredentials, error) {
+	if !cfg.TLS.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.TLS.ServerName,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+	}
+
+	if cfg.TLS.CAFile != "" {
+		pool, err := loadCertPool(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s CA bundle: %w", cfg.Name, err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s client certificate: %w", cfg.Name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// loadCertPool reads a PEM bundle of CA certificates
+func loadCertPool(path string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -8,55 +8,87 @@ import (
 	"apigw/internal/app/config"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/keepalive"
 )
 
 // UserServiceClient represents a client for the user service
 type UserServiceClient struct {
-	client pb.UserServiceClient
-	conn   *grpc.ClientConn
+	conn *ServiceConn
 }
 
 // NewUserServiceClient creates a new user service client
 func NewUserServiceClient(cfg *config.UserServiceConfig) (*UserServiceClient, error) {
-	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	conn, err := grpc.NewClient(address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                cfg.GRPC.KeepaliveTime,
-			Timeout:             cfg.GRPC.KeepaliveTimeout,
-			PermitWithoutStream: cfg.GRPC.KeepalivePermitWithoutStream,
-		}),
-	)
+	conn, err := NewClientFactory().Dial(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to user service: %w", err)
 	}
 
-	client := pb.NewUserServiceClient(conn)
-
 	return &UserServiceClient{
-		client: client,
-		conn:   conn,
+		conn: conn,
 	}, nil
 }
 
-// Close closes the gRPC connection
+// client returns a stub over the service connection, which selects an endpoint per call
+func (c *UserServiceClient) client() pb.UserServiceClient {
+	return pb.NewUserServiceClient(c.conn)
+}
+
+// Close closes the gRPC connections
 func (c *UserServiceClient) Close() error {
 	return c.conn.Close()
 }
 
 // Register registers a new user
 func (c *UserServiceClient) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
-	return c.client.Register(ctx, req)
+	return c.client().Register(ctx, req)
 }
 
 // Login authenticates a user
 func (c *UserServiceClient) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
-	return c.client.Login(ctx, req)
+	return c.client().Login(ctx, req)
 }
 
 // RefreshToken refreshes an access token
 func (c *UserServiceClient) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
-	return c.client.RefreshToken(ctx, req)
+	return c.client().RefreshToken(ctx, req)
+}
+
+// GetProfile returns a user's profile
+func (c *UserServiceClient) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
+	return c.client().GetProfile(ctx, req)
+}
+
+// UpdateProfile partially updates a user's profile
+func (c *UserServiceClient) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	return c.client().UpdateProfile(ctx, req)
+}
+
+// ChangePassword changes a user's password
+func (c *UserServiceClient) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	return c.client().ChangePassword(ctx, req)
+}
+
+// RequestEmailVerification sends an email verification link
+func (c *UserServiceClient) RequestEmailVerification(ctx context.Context, req *pb.RequestEmailVerificationRequest) (*pb.RequestEmailVerificationResponse, error) {
+	return c.client().RequestEmailVerification(ctx, req)
+}
+
+// ConfirmEmailVerification confirms an email verification token
+func (c *UserServiceClient) ConfirmEmailVerification(ctx context.Context, req *pb.ConfirmEmailVerificationRequest) (*pb.ConfirmEmailVerificationResponse, error) {
+	return c.client().ConfirmEmailVerification(ctx, req)
+}
+
+// RequestPasswordReset sends a password reset link
+func (c *UserServiceClient) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	return c.client().RequestPasswordReset(ctx, req)
+}
+
+// ResetPassword completes a password reset
+func (c *UserServiceClient) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	return c.client().ResetPassword(ctx, req)
+}
+
+// UploadAvatar opens a stream uploading the avatar of a user; the first message carries
+// the metadata and the following ones the image
+func (c *UserServiceClient) UploadAvatar(ctx context.Context) (grpc.ClientStreamingClient[pb.UploadAvatarRequest, pb.UploadAvatarResponse], error) {
+	return pb.NewAvatarServiceClient(c.conn).UploadAvatar(ctx)
 }
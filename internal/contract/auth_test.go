@@ -0,0 +1,73 @@
+package contract_test
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"apigw/internal/app/router"
+	"apigw/internal/app/signing"
+)
+
+// pathParam matches the parameters of route patterns, e.g. :order_id
+var pathParam = regexp.MustCompile(`:[a-z_]+`)
+
+// TestRoutesRefuseUnauthorizedCallers derives from the route table what each route
+// requires of its callers and checks that a caller without it is refused before any
+// backend is called
+func TestRoutesRefuseUnauthorizedCallers(t *testing.T) {
+	g := newGateway(t)
+	routes := router.DescribeRoutes(g.engine, g.cfg).Routes
+	for _, route := range routes {
+		route := route
+		path := pathParam.ReplaceAllString(route.Path, "x_1")
+		for _, auth := range route.Auth {
+			var as principal
+			var status int
+			switch auth {
+			case "jwt":
+				as, status = anonymous, http.StatusUnauthorized
+			case "role:admin":
+				as, status = user, http.StatusForbidden
+			case "signature":
+				// Unsigned, with a valid token all the same
+				as, status = user, http.StatusUnauthorized
+			default:
+				t.Fatalf("route %s %s: unknown auth %q", route.Method, route.Path, auth)
+			}
+
+			t.Run(route.Method+" "+route.Path+" "+auth, func(t *testing.T) {
+				g := newGateway(t)
+				w := g.do(request{method: route.Method, path: path, as: as})
+				if w.Code != status {
+					t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body.String())
+				}
+				if calls := g.backend.Calls(""); len(calls) > 0 {
+					t.Errorf("the backend was called: %s", calls[0].Method)
+				}
+			})
+		}
+	}
+}
+
+// TestSignatureReplays checks a signed request cannot be sent again, whichever way its
+// signature is written
+func TestSignatureReplays(t *testing.T) {
+	g := newGateway(t)
+	header := http.Header{}
+	for name, value := range signing.SignRequest(partnerID, partnerSecret, http.MethodGet, "/api/v1/partner/webhooks", nil) {
+		header.Set(name, value)
+	}
+	g.mustDo(request{method: http.MethodGet, path: "/api/v1/partner/webhooks", header: header}, http.StatusOK)
+
+	signature := header.Get(signing.HeaderSignature)
+	for _, variant := range []string{signature, strings.ToUpper(signature), "sha256=" + signature, "sha256=" + strings.ToUpper(signature)} {
+		replay := header.Clone()
+		replay.Set(signing.HeaderSignature, variant)
+		body := decode(t, g.do(request{method: http.MethodGet, path: "/api/v1/partner/webhooks", header: replay}))
+		if body["code"] != "SIGNATURE_REPLAYED" {
+			t.Errorf("replay signed %q: %v, want SIGNATURE_REPLAYED", variant, body)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Package contract runs the gateway against in-process fakes of the backend services
+// that answer from scenarios: the responses and statuses the real services were recorded
+// returning, keyed by gRPC method. The fakes implement every service of client/proto
+// generically from the registered descriptors and record the calls they receive, so tests
+// can check both what the gateway sends and how it maps what it gets back. The same fakes
+// back the dev stub mode, in which the gateway runs standalone.
+package contract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	"apigw/internal/app/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// Registers the services and messages of the backends
+	_ "apigw/client/proto"
+)
+
+// bufferSize is the size of the in-memory connection buffers
+const bufferSize = 1 << 20
+
+// maxCalls bounds the calls a backend remembers, the oldest being forgotten first, so that
+// long dev stub sessions do not grow without end
+const maxCalls = 1000
+
+// Call is a call the backend received
+type Call struct {
+	Method   string // e.g. user.UserService/Login
+	Metadata metadata.MD
+	// Requests holds the request message, or every message of client streams
+	Requests []proto.Message
+}
+
+// Request returns the (first) request message of the call
+func (c Call) Request() proto.Message {
+	if len(c.Requests) == 0 {
+		return nil
+	}
+	return c.Requests[0]
+}
+
+// Backend serves every backend service on an in-memory listener, and on the TCP ones of
+// Listen, answering from a scenario. Methods the scenario has no reply for answer
+// Unimplemented.
+type Backend struct {
+	server   *grpc.Server
+	listener *bufconn.Listener
+
+	mu       sync.Mutex
+	scenario Scenario
+	calls    []Call
+	issue    TokenIssuer
+	// lastUserID is the user the last token was issued to
+	lastUserID string
+}
+
+// TokenIssuer creates an access token the gateway accepts for a user
+type TokenIssuer func(userID string) (string, error)
+
+// NewBackend starts a backend answering from scenario
+func NewBackend(scenario Scenario) *Backend {
+	b := &Backend{
+		listener: bufconn.Listen(bufferSize),
+		scenario: scenario.Clone(),
+	}
+	b.server = grpc.NewServer(grpc.UnknownServiceHandler(b.handle))
+	go b.server.Serve(b.listener)
+	return b
+}
+
+// DialOption routes the connections of a client factory to the backend, whatever the
+// configured endpoints
+func (b *Backend) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return b.listener.DialContext(ctx)
+	})
+}
+
+// Listen serves the backend on a TCP address too, e.g. "127.0.0.1:0", for gateways that
+// dial it over the network rather than with DialOption; see RouteServices
+func (b *Backend) Listen(addr string) (*net.TCPAddr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	go b.server.Serve(l)
+	return l.Addr().(*net.TCPAddr), nil
+}
+
+// RouteServices returns the services with every one of them pointed at a backend
+// listening on addr, dialed without TLS and without a shadow upstream
+func RouteServices(services config.ServicesConfig, addr *net.TCPAddr) config.ServicesConfig {
+	v := reflect.ValueOf(&services).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		svc, ok := v.Field(i).Addr().Interface().(*config.ServiceConfig)
+		if !ok {
+			continue
+		}
+		svc.Host = addr.IP.String()
+		svc.Port = addr.Port
+		svc.Target = ""
+		svc.Endpoints = nil
+		svc.TLS = config.ServiceTLSConfig{}
+		svc.Shadow = config.ShadowConfig{}
+	}
+	return services
+}
+
+// StubServices returns the services with every one of them pointed at a backend: a
+// passthrough target that is never resolved, dialed without TLS and without a shadow
+// upstream. Connections must be made with the backend's DialOption.
+func StubServices(services config.ServicesConfig) config.ServicesConfig {
+	v := reflect.ValueOf(&services).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		svc, ok := v.Field(i).Addr().Interface().(*config.ServiceConfig)
+		if !ok {
+			continue
+		}
+		svc.Target = "passthrough:///" + svc.Name
+		svc.Endpoints = nil
+		svc.TLS = config.ServiceTLSConfig{}
+		svc.Shadow = config.ShadowConfig{}
+	}
+	return services
+}
+
+// IssueTokens makes the backend replace the access tokens of its responses, e.g. those of
+// user.UserService/Login, with tokens created by issue, so that they authenticate against
+// the gateway. A token is issued to the user of the response, or to the user of the last
+// token when the response has none, as with refreshed tokens.
+func (b *Backend) IssueTokens(issue TokenIssuer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.issue = issue
+}
+
+// Play replaces the scenario the backend answers from
+func (b *Backend) Play(scenario Scenario) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scenario = scenario.Clone()
+}
+
+// Set replaces the reply to a method
+func (b *Backend) Set(method string, reply Reply) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scenario[method] = reply
+}
+
+// Calls returns the latest calls received for a method, or every call when method is empty
+func (b *Backend) Calls(method string) []Call {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var calls []Call
+	for _, call := range b.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the received calls
+func (b *Backend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = nil
+}
+
+// Close stops serving and closes the listeners
+func (b *Backend) Close() {
+	b.server.Stop()
+}
+
+// handle answers any call: it decodes the request messages with the method's descriptor,
+// records the call and sends the scenario's reply
+func (b *Backend) handle(_ interface{}, stream grpc.ServerStream) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	method := strings.TrimPrefix(fullMethod, "/")
+	desc, err := methodDescriptor(method)
+	if err != nil {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+
+	call := Call{Method: method}
+	call.Metadata, _ = metadata.FromIncomingContext(stream.Context())
+	for {
+		req, err := newMessage(desc.Input())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := stream.RecvMsg(req); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		call.Requests = append(call.Requests, req)
+		if !desc.IsStreamingClient() {
+			break
+		}
+	}
+
+	b.mu.Lock()
+	if len(b.calls) == maxCalls {
+		b.calls = append(b.calls[:0], b.calls[1:]...)
+	}
+	b.calls = append(b.calls, call)
+	reply, ok := b.scenario[method]
+	b.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "the scenario has no reply to %s", method)
+	}
+
+	resp, err := reply.decode(desc)
+	if err != nil {
+		return err
+	}
+	if err := b.issueTokens(resp); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.SendMsg(resp)
+}
+
+// issueTokens replaces the access token of a response when tokens are issued
+func (b *Backend) issueTokens(resp proto.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.issue == nil {
+		return nil
+	}
+	msg := resp.ProtoReflect()
+	tokenField := msg.Descriptor().Fields().ByName("access_token")
+	if tokenField == nil || tokenField.Kind() != protoreflect.StringKind || !msg.Has(tokenField) {
+		return nil
+	}
+
+	userID := b.lastUserID
+	if userField := msg.Descriptor().Fields().ByName("user"); userField != nil && userField.Message() != nil && msg.Has(userField) {
+		user := msg.Get(userField).Message()
+		if idField := user.Descriptor().Fields().ByName("id"); idField != nil && user.Has(idField) {
+			userID = user.Get(idField).String()
+		}
+	}
+	if userID == "" {
+		return nil
+	}
+
+	accessToken, err := b.issue(userID)
+	if err != nil {
+		return fmt.Errorf("failed to issue a token to %s: %w", userID, err)
+	}
+	msg.Set(tokenField, protoreflect.ValueOfString(accessToken))
+	b.lastUserID = userID
+	return nil
+}
+
+// methodDescriptor looks up a method, e.g. user.UserService/Login, in the registered
+// services
+func methodDescriptor(method string) (protoreflect.MethodDescriptor, error) {
+	service, name, ok := strings.Cut(method, "/")
+	if !ok {
+		return nil, fmt.Errorf("malformed method %q", method)
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %q", service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %q of %s", name, service)
+	}
+	return md, nil
+}
+
+// newMessage creates an empty message of a registered type
+func newMessage(desc protoreflect.MessageDescriptor) (proto.Message, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		return nil, fmt.Errorf("unregistered message %s", desc.FullName())
+	}
+	return mt.New().Interface(), nil
+}
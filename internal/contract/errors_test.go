@@ -0,0 +1,143 @@
+package contract_test
+
+import (
+	"net/http"
+	"testing"
+
+	"apigw/internal/contract"
+
+	"google.golang.org/grpc/codes"
+)
+
+// getEvent is the request the mapping of backend failures is checked through
+var getEvent = request{method: http.MethodGet, path: "/api/v1/events/evt_1001"}
+
+// TestBackendStatusesMapToHTTPErrors checks the error every status code of the backend
+// is answered with. Client errors carry the backend's message; server errors never do.
+func TestBackendStatusesMapToHTTPErrors(t *testing.T) {
+	const backendMessage = "event evt_1001 is locked by job 42"
+	tests := []struct {
+		code    codes.Code
+		status  int
+		errCode string
+		message string // the backend's when empty
+	}{
+		{codes.InvalidArgument, http.StatusBadRequest, "INVALID_ARGUMENT", ""},
+		{codes.NotFound, http.StatusNotFound, "RESOURCE_NOT_FOUND", ""},
+		{codes.AlreadyExists, http.StatusConflict, "RESOURCE_ALREADY_EXISTS", ""},
+		{codes.PermissionDenied, http.StatusForbidden, "PERMISSION_DENIED", ""},
+		{codes.Unauthenticated, http.StatusUnauthorized, "UNAUTHENTICATED", ""},
+		{codes.ResourceExhausted, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", ""},
+		{codes.FailedPrecondition, http.StatusBadRequest, "PRECONDITION_FAILED", ""},
+		{codes.Aborted, http.StatusConflict, "OPERATION_ABORTED", ""},
+		{codes.OutOfRange, http.StatusBadRequest, "OUT_OF_RANGE", ""},
+		{codes.DeadlineExceeded, http.StatusRequestTimeout, "REQUEST_TIMEOUT", ""},
+		{codes.Unimplemented, http.StatusNotImplemented, "METHOD_NOT_IMPLEMENTED", "The operation is not available"},
+		{codes.Internal, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "Internal server error"},
+		{codes.Unavailable, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Service temporarily unavailable"},
+		{codes.DataLoss, http.StatusInternalServerError, "DATA_LOSS", "Internal server error"},
+		{codes.Unknown, http.StatusInternalServerError, "UNKNOWN_ERROR", "Internal server error"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.code.String(), func(t *testing.T) {
+			g := newGateway(t)
+			g.backend.Set("event.EventService/GetEvent", contract.Failf(tt.code, backendMessage))
+
+			body := g.mustDo(getEvent, tt.status)
+			if body["code"] != tt.errCode {
+				t.Errorf("code %v, want %s", body["code"], tt.errCode)
+			}
+			message := tt.message
+			if message == "" {
+				message = backendMessage
+			}
+			if body["message"] != message {
+				t.Errorf("message %q, want %q", body["message"], message)
+			}
+		})
+	}
+}
+
+// TestBackendErrorDetails checks the details of backend statuses clients get to see
+func TestBackendErrorDetails(t *testing.T) {
+	t.Run("field violations", func(t *testing.T) {
+		g := newGateway(t)
+		g.backend.Set("event.EventService/GetEvent", contract.FailWith(codes.InvalidArgument, "invalid event",
+			contract.BadRequest("ticket_types[1].unit_price", "must be positive")))
+
+		body := g.mustDo(getEvent, http.StatusBadRequest)
+		want := map[string]interface{}{
+			"details.0.field":   "ticketTypes[1].unitPrice",
+			"details.0.rule":    "backend",
+			"details.0.message": "must be positive",
+		}
+		for path, value := range want {
+			if got, _ := lookup(body, path); got != value {
+				t.Errorf("%s is %v, want %v", path, got, value)
+			}
+		}
+	})
+
+	t.Run("retry delay", func(t *testing.T) {
+		g := newGateway(t)
+		g.backend.Set("event.EventService/GetEvent", contract.FailWith(codes.Unavailable, "warming up", contract.RetryInfo(30)))
+
+		w := g.do(getEvent)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body.String())
+		}
+		if got := w.Header().Get("Retry-After"); got != "30" {
+			t.Errorf("Retry-After %q, want 30", got)
+		}
+	})
+
+	t.Run("quota violations", func(t *testing.T) {
+		g := newGateway(t)
+		g.backend.Set("event.EventService/GetEvent", contract.FailWith(codes.ResourceExhausted, "too many lookups",
+			contract.QuotaFailure("user:usr_1001", "100 lookups a minute"), contract.RetryInfo(12)))
+
+		body := g.mustDo(getEvent, http.StatusTooManyRequests)
+		want := map[string]interface{}{
+			"details.quota_violations.0.subject":     "user:usr_1001",
+			"details.quota_violations.0.description": "100 lookups a minute",
+			"details.retry_after":                    float64(12),
+		}
+		for path, value := range want {
+			if got, _ := lookup(body, path); got != value {
+				t.Errorf("%s is %v, want %v", path, got, value)
+			}
+		}
+	})
+}
+
+// TestHandlerSpecificErrors checks the errors routes answer instead of the generic mapping
+func TestHandlerSpecificErrors(t *testing.T) {
+	t.Run("order not refundable", func(t *testing.T) {
+		g := newGateway(t)
+		g.backend.Set("order.OrderService/CancelOrder", contract.Failf(codes.FailedPrecondition, "order is shipped"))
+
+		body := g.mustDo(request{method: http.MethodDelete, path: "/api/v1/orders/ord_1001", as: user}, http.StatusConflict)
+		if body["code"] != "ORDER_NOT_REFUNDABLE" {
+			t.Errorf("code %v, want ORDER_NOT_REFUNDABLE", body["code"])
+		}
+	})
+
+	// Orders of other users do not exist as far as the caller can tell
+	for _, r := range []request{
+		{method: http.MethodGet, path: "/api/v1/orders/ord_1001", as: stranger},
+		{method: http.MethodDelete, path: "/api/v1/orders/ord_1001", as: stranger},
+	} {
+		r := r
+		t.Run("ownership "+r.method, func(t *testing.T) {
+			g := newGateway(t)
+			body := g.mustDo(r, http.StatusNotFound)
+			if body["code"] != "RESOURCE_NOT_FOUND" {
+				t.Errorf("code %v, want RESOURCE_NOT_FOUND", body["code"])
+			}
+			if calls := g.backend.Calls("order.OrderService/CancelOrder"); len(calls) > 0 {
+				t.Error("the order of another user was cancelled")
+			}
+		})
+	}
+}
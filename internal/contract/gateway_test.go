@@ -0,0 +1,259 @@
+package contract_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/purchasequeue"
+	"apigw/internal/app/router"
+	"apigw/internal/app/signing"
+	"apigw/internal/app/validation"
+	"apigw/internal/app/webhook"
+	"apigw/internal/client"
+	"apigw/internal/contract"
+	"apigw/pkg/utils/crypt/token"
+	logutils "apigw/pkg/utils/log"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// Principals the requests of the tests are made as
+type principal int
+
+const (
+	anonymous principal = iota
+	user                // usr_1001, who owns the orders and payments of the default fixture
+	stranger            // usr_2002, who owns nothing
+	admin               // adm_1 with the admin role
+	partner             // the acme partner, signing its requests
+)
+
+// userIDs are the user IDs of the principals with a token
+var userIDs = map[principal]string{user: "usr_1001", stranger: "usr_2002", admin: "adm_1"}
+
+const (
+	partnerID     = "acme"
+	partnerSecret = "acme-signing-secret-0123456789abcdef"
+	// partnerPrefix is the route group only signed requests may use
+	partnerPrefix = "/api/v1/partner"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+	logutils.GetLogger().SetOutput(io.Discard)
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		panic(err)
+	}
+	if err := validation.Setup(cfg.Validation); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// gateway is the full router of the gateway on default settings, with Redis in memory
+// and the backend services faked by a contract backend
+type gateway struct {
+	t       *testing.T
+	cfg     *config.Config
+	engine  *gin.Engine
+	backend *contract.Backend
+	// webhooks is the dispatcher of the partner webhook routes
+	webhooks *webhook.Dispatcher
+	tokens   map[principal]string
+	// vars are substituted for {name} in request paths, e.g. IDs created by a setup step
+	vars map[string]string
+}
+
+// newGateway builds a gateway whose backend answers from the default fixture
+func newGateway(t *testing.T) *gateway {
+	t.Helper()
+	logger := logutils.GetLogger()
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Signing.Enabled = true
+	cfg.Signing.PathPrefixes = []string{partnerPrefix}
+	cfg.Signing.Partners = []config.PartnerKeyConfig{{ID: partnerID, Secrets: []string{partnerSecret}}}
+	// Purchases asking for it are queued, but the queue is not worked
+	cfg.Orders.AsyncPurchase.Enabled = true
+	cfg.Quotas.Enabled = true
+	cfg.Log.Bodies.Enabled = true
+
+	scenario, err := contract.Fixture("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := contract.NewBackend(scenario)
+	t.Cleanup(backend.Close)
+
+	redisServer := miniredis.RunT(t)
+	host, port, _ := net.SplitHostPort(redisServer.Addr())
+	cfg.Redis.Enabled = true
+	cfg.Redis.Host = host
+	cfg.Redis.Port, _ = strconv.Atoi(port)
+	redisClient, err := client.NewRedisClient(&cfg.Redis, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redisClient.Close() })
+
+	services := contract.StubServices(cfg.Services)
+	clients, err := client.NewRegistry(client.NewClientFactory().WithDialOptions(backend.DialOption()), services, client.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { clients.Close() })
+
+	maker, err := token.NewJWTTokenMaker(cfg.JWT.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := make(map[principal]string)
+	for p, userID := range userIDs {
+		role := ""
+		if p == admin {
+			role = "admin"
+		}
+		if tokens[p], err = maker.CreateToken(userID, role, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purchases := purchasequeue.New(redisClient.GetClient(), cfg.Orders.AsyncPurchase, logger)
+	webhooks := webhook.New(redisClient.GetClient(), cfg.Webhooks, logger)
+	engine := router.SetupRouter(cfg, router.Dependencies{
+		Clients:    clients,
+		Redis:      redisClient,
+		Purchases:  purchases,
+		Webhooks:   webhooks,
+		TokenMaker: maker,
+	}, logger)
+	return &gateway{
+		t:        t,
+		cfg:      cfg,
+		engine:   engine,
+		backend:  backend,
+		webhooks: webhooks,
+		tokens:   tokens,
+		vars:     make(map[string]string),
+	}
+}
+
+// request is a request to the gateway
+type request struct {
+	method      string
+	path        string // {name} is replaced by the gateway's vars
+	as          principal
+	body        string
+	contentType string // application/json when there is a body and none is set
+	header      http.Header
+}
+
+// do serves a request
+func (g *gateway) do(r request) *httptest.ResponseRecorder {
+	g.t.Helper()
+	path := r.path
+	for name, value := range g.vars {
+		path = strings.ReplaceAll(path, "{"+name+"}", value)
+	}
+
+	req := httptest.NewRequest(r.method, path, strings.NewReader(r.body))
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	if r.body != "" {
+		contentType := r.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch r.as {
+	case anonymous:
+	case partner:
+		for name, value := range signing.SignRequest(partnerID, partnerSecret, r.method, path, []byte(r.body)) {
+			req.Header.Set(name, value)
+		}
+	default:
+		req.Header.Set("Authorization", "Bearer "+g.tokens[r.as])
+	}
+
+	w := httptest.NewRecorder()
+	g.engine.ServeHTTP(w, req)
+	return w
+}
+
+// mustDo serves a request that must succeed with the given status and returns its body
+func (g *gateway) mustDo(r request, status int) map[string]interface{} {
+	g.t.Helper()
+	w := g.do(r)
+	if w.Code != status {
+		g.t.Fatalf("%s %s: status %d, want %d: %s", r.method, r.path, w.Code, status, w.Body.String())
+	}
+	return decode(g.t, w)
+}
+
+// decode decodes a JSON object response; an empty body decodes to nil
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	if w.Body.Len() == 0 {
+		return nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+// lookup returns the value at a dotted path of a decoded JSON document, e.g. order.status
+// or events.0.id
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// multipart returns a multipart/form-data body holding a file part
+func multipart(field, fileName string, content []byte) (body, contentType string) {
+	const boundary = "contract-boundary"
+	var b bytes.Buffer
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString(`Content-Disposition: form-data; name="` + field + `"; filename="` + fileName + `"` + "\r\n")
+	b.WriteString("Content-Type: application/octet-stream\r\n\r\n")
+	b.Write(content)
+	b.WriteString("\r\n--" + boundary + "--\r\n")
+	return b.String(), "multipart/form-data; boundary=" + boundary
+}
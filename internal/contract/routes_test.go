@@ -0,0 +1,640 @@
+package contract_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/router"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// pngImage is the smallest content detected as a PNG image
+var pngImage = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+// routeCase is the contract of a route: a request on the default fixture, the status it is
+// answered with, the backend methods it reaches and fields of its response
+type routeCase struct {
+	route string // method and pattern as the route table describes them
+	request
+	setup  func(g *gateway)
+	status int
+	calls  []string
+	want   map[string]interface{} // response fields by dotted path
+}
+
+// avatarBody is the multipart body of avatar uploads
+var avatarBody, avatarContentType = multipart("avatar", "me.png", pngImage)
+
+var routeCases = []routeCase{
+	// Gateway
+	{route: "GET /health", request: request{path: "/health"}, status: http.StatusOK, want: map[string]interface{}{"status": "ok"}},
+	{route: "GET /readyz", request: request{path: "/readyz"}, status: http.StatusOK},
+	{route: "GET /version", request: request{path: "/version"}, status: http.StatusOK},
+	{route: "GET /metrics", request: request{path: "/metrics"}, status: http.StatusOK},
+	{route: "GET /openapi.json", request: request{path: "/openapi.json"}, status: http.StatusOK, want: map[string]interface{}{"openapi": "3.0.3"}},
+	{route: "GET /docs", request: request{path: "/docs"}, status: http.StatusOK},
+
+	// Operator routes
+	{route: "GET /admin/config", request: request{path: "/admin/config", as: admin}, status: http.StatusOK},
+	{route: "GET /admin/routes", request: request{path: "/admin/routes", as: admin}, status: http.StatusOK},
+	{route: "GET /admin/acl", request: request{path: "/admin/acl", as: admin}, status: http.StatusOK},
+	{
+		route:   "POST /admin/acl/blocklist",
+		request: request{path: "/admin/acl/blocklist", as: admin, body: `{"network":"203.0.113.0/24","reason":"credential stuffing"}`},
+		status:  http.StatusCreated,
+		want:    map[string]interface{}{"network": "203.0.113.0/24"},
+	},
+	{
+		route:   "DELETE /admin/acl/blocklist",
+		request: request{path: "/admin/acl/blocklist?network=203.0.113.0/24", as: admin},
+		setup: func(g *gateway) {
+			g.mustDo(request{method: http.MethodPost, path: "/admin/acl/blocklist", as: admin, body: `{"network":"203.0.113.0/24","reason":"credential stuffing"}`}, http.StatusCreated)
+		},
+		status: http.StatusNoContent,
+	},
+	{route: "GET /admin/drain", request: request{path: "/admin/drain", as: admin}, status: http.StatusOK},
+	{route: "POST /admin/drain", request: request{path: "/admin/drain", as: admin}, status: http.StatusAccepted},
+	{route: "GET /admin/body-logging", request: request{path: "/admin/body-logging", as: admin}, status: http.StatusOK},
+	{
+		route:   "POST /admin/body-logging",
+		request: request{path: "/admin/body-logging", as: admin, body: `{"route":"POST /api/v1/orders/purchase","reason":"partner checkout","ttl":"30m"}`},
+		status:  http.StatusCreated,
+		want:    map[string]interface{}{"route": "POST /api/v1/orders/purchase"},
+	},
+	{
+		route:   "DELETE /admin/body-logging",
+		request: request{path: "/admin/body-logging?route=POST%20/api/v1/orders/purchase", as: admin},
+		setup: func(g *gateway) {
+			g.mustDo(request{method: http.MethodPost, path: "/admin/body-logging", as: admin, body: `{"route":"POST /api/v1/orders/purchase","reason":"partner checkout"}`}, http.StatusCreated)
+		},
+		status: http.StatusNoContent,
+	},
+
+	// Users
+	{
+		route:   "POST /api/v1/users/register",
+		request: request{path: "/api/v1/users/register", body: `{"username":"ada","email":"ada@example.com","password":"Analytical1"}`},
+		status:  http.StatusCreated,
+		calls:   []string{"user.UserService/Register"},
+		want:    map[string]interface{}{"accessToken": "backend-access-token"},
+	},
+	{
+		route:   "POST /api/v1/users/login",
+		request: request{path: "/api/v1/users/login", body: `{"email":"ada@example.com","password":"Analytical1"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/Login"},
+		want:    map[string]interface{}{"refreshToken": "backend-refresh-token"},
+	},
+	{
+		route:   "POST /api/v1/users/refresh",
+		request: request{path: "/api/v1/users/refresh", body: `{"refreshToken":"backend-refresh-token"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/RefreshToken"},
+		want:    map[string]interface{}{"accessToken": "backend-access-token-2"},
+	},
+	{
+		route:   "POST /api/v1/users/verify-email/request",
+		request: request{path: "/api/v1/users/verify-email/request", body: `{"email":"ada@example.com"}`},
+		status:  http.StatusAccepted,
+		calls:   []string{"user.UserService/RequestEmailVerification"},
+	},
+	{
+		route:   "POST /api/v1/users/verify-email/confirm",
+		request: request{path: "/api/v1/users/verify-email/confirm", body: `{"token":"verification-token"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/ConfirmEmailVerification"},
+		want:    map[string]interface{}{"id": "usr_1001"},
+	},
+	{
+		route:   "POST /api/v1/users/password-reset/request",
+		request: request{path: "/api/v1/users/password-reset/request", body: `{"email":"ada@example.com"}`},
+		status:  http.StatusAccepted,
+		calls:   []string{"user.UserService/RequestPasswordReset"},
+	},
+	{
+		route:   "POST /api/v1/users/password-reset/confirm",
+		request: request{path: "/api/v1/users/password-reset/confirm", body: `{"token":"reset-token","newPassword":"Difference2"}`},
+		status:  http.StatusNoContent,
+		calls:   []string{"user.UserService/ResetPassword"},
+	},
+	{
+		route:   "GET /api/v1/users/me",
+		request: request{path: "/api/v1/users/me", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/GetProfile"},
+		want:    map[string]interface{}{"id": "usr_1001", "displayName": "Ada Lovelace"},
+	},
+	{
+		route:   "PUT /api/v1/users/me",
+		request: request{path: "/api/v1/users/me", as: user, body: `{"displayName":"Ada King"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/UpdateProfile"},
+		want:    map[string]interface{}{"displayName": "Ada King"},
+	},
+	{
+		route:   "PUT /api/v1/users/me/password",
+		request: request{path: "/api/v1/users/me/password", as: user, body: `{"currentPassword":"Analytical1","newPassword":"Difference2"}`},
+		status:  http.StatusNoContent,
+		calls:   []string{"user.UserService/ChangePassword"},
+	},
+	{
+		route:   "PUT /api/v1/users/me/avatar",
+		request: request{path: "/api/v1/users/me/avatar", as: user, body: avatarBody, contentType: avatarContentType},
+		status:  http.StatusOK,
+		calls:   []string{"user.AvatarService/UploadAvatar"},
+	},
+	{
+		route:   "GET /api/v1/me/dashboard",
+		request: request{path: "/api/v1/me/dashboard", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/GetProfile", "order.OrderService/ListOrders", "event.EventService/ListEvents"},
+	},
+	{
+		route:   "POST /api/v2/users/register",
+		request: request{path: "/api/v2/users/register", body: `{"username":"ada","email":"ada@example.com","password":"Analytical1"}`},
+		status:  http.StatusCreated,
+		calls:   []string{"user.UserService/Register"},
+	},
+	{
+		route:   "POST /api/v2/users/login",
+		request: request{path: "/api/v2/users/login", body: `{"email":"ada@example.com","password":"Analytical1"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/Login"},
+	},
+	{
+		route:   "POST /api/v2/users/refresh",
+		request: request{path: "/api/v2/users/refresh", body: `{"refresh_token":"backend-refresh-token"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/RefreshToken"},
+	},
+	{
+		route:   "POST /api/v2/users/verify-email/request",
+		request: request{path: "/api/v2/users/verify-email/request", body: `{"email":"ada@example.com"}`},
+		status:  http.StatusAccepted,
+		calls:   []string{"user.UserService/RequestEmailVerification"},
+	},
+	{
+		route:   "POST /api/v2/users/verify-email/confirm",
+		request: request{path: "/api/v2/users/verify-email/confirm", body: `{"token":"verification-token"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/ConfirmEmailVerification"},
+	},
+	{
+		route:   "POST /api/v2/users/password-reset/request",
+		request: request{path: "/api/v2/users/password-reset/request", body: `{"email":"ada@example.com"}`},
+		status:  http.StatusAccepted,
+		calls:   []string{"user.UserService/RequestPasswordReset"},
+	},
+	{
+		route:   "POST /api/v2/users/password-reset/confirm",
+		request: request{path: "/api/v2/users/password-reset/confirm", body: `{"token":"reset-token","newPassword":"Difference2"}`},
+		status:  http.StatusNoContent,
+		calls:   []string{"user.UserService/ResetPassword"},
+	},
+	{
+		route:   "GET /api/v2/users/me",
+		request: request{path: "/api/v2/users/me", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/GetProfile"},
+	},
+	{
+		route:   "PUT /api/v2/users/me",
+		request: request{path: "/api/v2/users/me", as: user, body: `{"displayName":"Ada King"}`},
+		status:  http.StatusOK,
+		calls:   []string{"user.UserService/UpdateProfile"},
+	},
+	{
+		route:   "PUT /api/v2/users/me/password",
+		request: request{path: "/api/v2/users/me/password", as: user, body: `{"currentPassword":"Analytical1","newPassword":"Difference2"}`},
+		status:  http.StatusNoContent,
+		calls:   []string{"user.UserService/ChangePassword"},
+	},
+
+	// Error catalog
+	{route: "GET /api/v1/errors", request: request{path: "/api/v1/errors"}, status: http.StatusOK},
+	{route: "GET /api/v1/errors/:code", request: request{path: "/api/v1/errors/INVALID_EVENT_ID"}, status: http.StatusOK, want: map[string]interface{}{"status": 400.0}},
+
+	// Quota usage
+	{route: "GET /api/v1/usage", request: request{path: "/api/v1/usage", as: user}, status: http.StatusOK, want: map[string]interface{}{"client": "user:usr_1001"}},
+
+	// Events
+	{
+		route:   "GET /api/v1/events",
+		request: request{path: "/api/v1/events?category=concert&limit=2"},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/ListEvents"},
+		want:    map[string]interface{}{"events.0.id": "evt_1001", "events.1.status": "closed"},
+	},
+	{
+		route:   "GET /api/v1/events/:event_id",
+		request: request{path: "/api/v1/events/evt_1001"},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/GetEvent"},
+		want:    map[string]interface{}{"id": "evt_1001", "availableTickets": 120.0},
+	},
+	{
+		route:   "GET /api/v1/events/:event_id/seats",
+		request: request{path: "/api/v1/events/evt_1001/seats"},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/GetSeatMap"},
+	},
+	{
+		route:   "GET /api/v1/events/:event_id/waiting-room",
+		request: request{path: "/api/v1/events/evt_1001/waiting-room", as: user},
+		setup:   joinWaitingRoom,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "GET /api/v2/events",
+		request: request{path: "/api/v2/events?limit=2"},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/ListEvents"},
+		want:    map[string]interface{}{"data.0.id": "evt_1001", "pagination.next_cursor": "page-2"},
+	},
+	{
+		route:   "GET /api/v2/events/:event_id",
+		request: request{path: "/api/v2/events/evt_1001"},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/GetEvent"},
+	},
+	{
+		route:   "GET /api/v2/events/:event_id/seats",
+		request: request{path: "/api/v2/events/evt_1001/seats"},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/GetSeatMap"},
+	},
+	{
+		route:   "GET /api/v2/events/:event_id/waiting-room",
+		request: request{path: "/api/v2/events/evt_1001/waiting-room", as: user},
+		setup:   joinWaitingRoom,
+		status:  http.StatusOK,
+	},
+
+	// Orders
+	{
+		route:   "GET /api/v1/orders",
+		request: request{path: "/api/v1/orders?status=confirmed", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/ListOrders"},
+		want:    map[string]interface{}{"orders.0.id": "ord_1001", "nextCursor": "page-2"},
+	},
+	{
+		route:   "GET /api/v1/orders/:order_id",
+		request: request{path: "/api/v1/orders/ord_1001", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/GetOrder"},
+		want:    map[string]interface{}{"id": "ord_1001", "status": "confirmed"},
+	},
+	{
+		route:   "GET /api/v1/orders/:order_id/status",
+		request: request{path: "/api/v1/orders/{reference}/status", as: user},
+		setup:   queuePurchase,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "DELETE /api/v1/orders/:order_id",
+		request: request{path: "/api/v1/orders/ord_1001", as: user, body: `{"reason":"cannot attend"}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/GetOrder", "order.OrderService/CancelOrder"},
+		want:    map[string]interface{}{"refunded": true},
+	},
+	{
+		route:   "POST /api/v1/orders/purchase",
+		request: request{path: "/api/v1/orders/purchase", as: user, body: `{"eventId":"evt_1001","quantity":2}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/PurchaseTicket"},
+	},
+	{
+		route:   "POST /api/v1/orders/purchase-batch",
+		request: request{path: "/api/v1/orders/purchase-batch", as: user, body: `{"items":[{"eventId":"evt_1001","quantity":1},{"eventId":"evt_1002","quantity":1}]}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/PurchaseTicket"},
+	},
+	{
+		route:   "POST /api/v1/orders/:event_id/purchase",
+		request: request{path: "/api/v1/orders/evt_1001/purchase", as: user, body: `{"quantity":1}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/PurchaseTicket"},
+	},
+	{
+		route:   "GET /api/v2/orders",
+		request: request{path: "/api/v2/orders", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/ListOrders"},
+		want:    map[string]interface{}{"data.1.id": "ord_1002"},
+	},
+	{
+		route:   "GET /api/v2/orders/:order_id",
+		request: request{path: "/api/v2/orders/ord_1001", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/GetOrder"},
+	},
+	{
+		route:   "GET /api/v2/orders/:order_id/status",
+		request: request{path: "/api/v2/orders/{reference}/status", as: user},
+		setup:   queuePurchase,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "DELETE /api/v2/orders/:order_id",
+		request: request{path: "/api/v2/orders/ord_1001", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/CancelOrder"},
+	},
+	{
+		route:   "POST /api/v2/orders/purchase",
+		request: request{path: "/api/v2/orders/purchase", as: user, body: `{"eventId":"evt_1001","quantity":2}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/PurchaseTicket"},
+	},
+	{
+		route:   "POST /api/v2/orders/purchase-batch",
+		request: request{path: "/api/v2/orders/purchase-batch", as: user, body: `{"items":[{"eventId":"evt_1001","quantity":1}]}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/PurchaseTicket"},
+	},
+	{
+		route:   "POST /api/v2/orders/:event_id/purchase",
+		request: request{path: "/api/v2/orders/evt_1001/purchase", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/PurchaseTicket"},
+	},
+
+	// Payments
+	{
+		route:   "POST /api/v1/payments",
+		request: request{path: "/api/v1/payments", as: user, body: `{"orderId":"ord_1001","paymentMethod":"card"}`},
+		status:  http.StatusCreated,
+		calls:   []string{"order.OrderService/GetOrder", "payment.PaymentService/CreatePaymentIntent"},
+		want:    map[string]interface{}{"id": "pay_1001", "clientSecret": "pay_1001_secret"},
+	},
+	{
+		route:   "GET /api/v1/payments/:payment_id",
+		request: request{path: "/api/v1/payments/pay_1001", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"payment.PaymentService/GetPayment"},
+		want:    map[string]interface{}{"amountCents": 25800.0, "status": "succeeded"},
+	},
+	{
+		route:   "POST /api/v1/payments/:payment_id/confirm",
+		request: request{path: "/api/v1/payments/pay_1001/confirm", as: user, body: `{"paymentMethodToken":"tok_visa"}`},
+		status:  http.StatusOK,
+		calls:   []string{"payment.PaymentService/ConfirmPayment"},
+	},
+	{
+		route:   "POST /api/v2/payments",
+		request: request{path: "/api/v2/payments", as: user, body: `{"orderId":"ord_1001","paymentMethod":"wallet"}`},
+		status:  http.StatusCreated,
+		calls:   []string{"payment.PaymentService/CreatePaymentIntent"},
+	},
+	{
+		route:   "GET /api/v2/payments/:payment_id",
+		request: request{path: "/api/v2/payments/pay_1001", as: user},
+		status:  http.StatusOK,
+		calls:   []string{"payment.PaymentService/GetPayment"},
+	},
+	{
+		route:   "POST /api/v2/payments/:payment_id/confirm",
+		request: request{path: "/api/v2/payments/pay_1001/confirm", as: user, body: `{"paymentMethodToken":"tok_visa"}`},
+		status:  http.StatusOK,
+		calls:   []string{"payment.PaymentService/ConfirmPayment"},
+	},
+
+	// Admin
+	{
+		route: "POST /api/v1/admin/events",
+		request: request{path: "/api/v1/admin/events", as: admin, body: `{"name":"Jazz Night","category":"concert","venue":"Blue Room",` +
+			`"startsAt":"2030-07-01T20:00:00Z","endsAt":"2030-07-01T23:00:00Z","availableTickets":300}`},
+		status: http.StatusCreated,
+		calls:  []string{"event.EventService/CreateEvent"},
+		want:   map[string]interface{}{"id": "evt_2001"},
+	},
+	{
+		route:   "PATCH /api/v1/admin/events/:event_id",
+		request: request{path: "/api/v1/admin/events/evt_1001", as: admin, body: `{"name":"Symphony No. 9 (matinee)"}`},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/UpdateEvent"},
+	},
+	{
+		route:   "POST /api/v1/admin/events/:event_id/close",
+		request: request{path: "/api/v1/admin/events/evt_1001/close", as: admin, body: `{"reason":"venue unavailable"}`},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/CloseEvent"},
+		want:    map[string]interface{}{"status": "closed"},
+	},
+	{
+		route:   "POST /api/v1/admin/events/:event_id/inventory",
+		request: request{path: "/api/v1/admin/events/evt_1001/inventory", as: admin, body: `{"delta":50,"reason":"extra row"}`},
+		status:  http.StatusOK,
+		calls:   []string{"event.EventService/AdjustInventory"},
+		want:    map[string]interface{}{"availableTickets": 170.0},
+	},
+	{
+		route:   "POST /api/v1/admin/orders/:order_id/cancel",
+		request: request{path: "/api/v1/admin/orders/ord_1001/cancel", as: admin, body: `{"reason":"fraud"}`},
+		status:  http.StatusOK,
+		calls:   []string{"order.OrderService/CancelOrder"},
+	},
+	{
+		route:   "GET /api/v1/admin/events/:event_id/waiting-room",
+		request: request{path: "/api/v1/admin/events/evt_1001/waiting-room", as: admin},
+		setup:   openWaitingRoom,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "PUT /api/v1/admin/events/:event_id/waiting-room",
+		request: request{path: "/api/v1/admin/events/evt_1001/waiting-room", as: admin, body: `{"rate":20}`},
+		status:  http.StatusOK,
+	},
+	{
+		route:   "DELETE /api/v1/admin/events/:event_id/waiting-room",
+		request: request{path: "/api/v1/admin/events/evt_1001/waiting-room", as: admin},
+		setup:   openWaitingRoom,
+		status:  http.StatusNoContent,
+	},
+
+	// Partner webhooks
+	{
+		route:   "POST /api/v1/partner/webhooks",
+		request: request{path: "/api/v1/partner/webhooks", as: partner, body: `{"url":"https://acme.example.com/hooks","events":["order.cancelled"]}`},
+		status:  http.StatusCreated,
+		want:    map[string]interface{}{"url": "https://acme.example.com/hooks"},
+	},
+	{
+		route:   "GET /api/v1/partner/webhooks",
+		request: request{path: "/api/v1/partner/webhooks", as: partner},
+		setup:   createWebhook,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "GET /api/v1/partner/webhooks/:webhook_id",
+		request: request{path: "/api/v1/partner/webhooks/{webhook_id}", as: partner},
+		setup:   createWebhook,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "PATCH /api/v1/partner/webhooks/:webhook_id",
+		request: request{path: "/api/v1/partner/webhooks/{webhook_id}", as: partner, body: `{"active":false}`},
+		setup:   createWebhook,
+		status:  http.StatusOK,
+		want:    map[string]interface{}{"active": false},
+	},
+	{
+		route:   "DELETE /api/v1/partner/webhooks/:webhook_id",
+		request: request{path: "/api/v1/partner/webhooks/{webhook_id}", as: partner},
+		setup:   createWebhook,
+		status:  http.StatusNoContent,
+	},
+	{
+		route:   "GET /api/v1/partner/webhooks/:webhook_id/deliveries",
+		request: request{path: "/api/v1/partner/webhooks/{webhook_id}/deliveries", as: partner},
+		setup:   createDelivery,
+		status:  http.StatusOK,
+	},
+	{
+		route:   "GET /api/v1/partner/webhooks/:webhook_id/deliveries/:delivery_id",
+		request: request{path: "/api/v1/partner/webhooks/{webhook_id}/deliveries/{delivery_id}", as: partner},
+		setup:   createDelivery,
+		status:  http.StatusOK,
+		want:    map[string]interface{}{"eventType": "order.cancelled"},
+	},
+}
+
+// openWaitingRoom opens the waiting room of evt_1001
+func openWaitingRoom(g *gateway) {
+	g.mustDo(request{method: http.MethodPut, path: "/api/v1/admin/events/evt_1001/waiting-room", as: admin}, http.StatusOK)
+}
+
+// joinWaitingRoom opens the waiting room of evt_1001 and lets the user in with a purchase,
+// which the room admits while it is empty
+func joinWaitingRoom(g *gateway) {
+	openWaitingRoom(g)
+	g.mustDo(request{method: http.MethodPost, path: "/api/v1/orders/evt_1001/purchase", as: user}, http.StatusOK)
+}
+
+// queuePurchase queues a purchase of the user, setting the reference var
+func queuePurchase(g *gateway) {
+	body := g.mustDo(request{
+		method: http.MethodPost,
+		path:   "/api/v1/orders/purchase",
+		as:     user,
+		body:   `{"eventId":"evt_1001","quantity":1}`,
+		header: http.Header{"Prefer": {"respond-async"}},
+	}, http.StatusAccepted)
+	g.vars["reference"] = fmt.Sprint(body["reference"])
+}
+
+// createWebhook registers a webhook of the partner for cancelled orders, setting the
+// webhook_id var
+func createWebhook(g *gateway) {
+	body := g.mustDo(request{
+		method: http.MethodPost,
+		path:   "/api/v1/partner/webhooks",
+		as:     partner,
+		body:   `{"url":"https://acme.example.com/hooks","events":["order.cancelled"]}`,
+	}, http.StatusCreated)
+	g.vars["webhook_id"] = fmt.Sprint(body["id"])
+}
+
+// createDelivery registers a webhook and cancels an order so that a delivery is scheduled,
+// setting the webhook_id and delivery_id vars
+func createDelivery(g *gateway) {
+	createWebhook(g)
+	g.mustDo(request{method: http.MethodDelete, path: "/api/v1/orders/ord_1001", as: user}, http.StatusOK)
+	deliveries, err := g.webhooks.Deliveries(context.Background(), partnerID, g.vars["webhook_id"], 1)
+	if err != nil || len(deliveries) == 0 {
+		g.t.Fatalf("no delivery was scheduled: %v", err)
+	}
+	g.vars["delivery_id"] = deliveries[0].ID
+}
+
+// TestEveryRouteHasAContract fails when a route is registered without a contract, so new
+// routes cannot ship untested
+func TestEveryRouteHasAContract(t *testing.T) {
+	g := newGateway(t)
+
+	covered := make(map[string]bool, len(routeCases))
+	for _, tc := range routeCases {
+		covered[tc.route] = true
+	}
+	registered := make(map[string]bool)
+	for _, route := range router.DescribeRoutes(g.engine, g.cfg).Routes {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if !covered[key] {
+			t.Errorf("route %s has no contract case", key)
+		}
+	}
+	for key := range covered {
+		if !registered[key] {
+			t.Errorf("contract case for %s, which is not registered", key)
+		}
+	}
+}
+
+// TestRoutes plays the contract of every route against a fresh gateway
+func TestRoutes(t *testing.T) {
+	for _, tc := range routeCases {
+		tc := tc
+		t.Run(tc.route, func(t *testing.T) {
+			g := newGateway(t)
+			if tc.setup != nil {
+				tc.setup(g)
+				g.backend.Reset()
+			}
+
+			r := tc.request
+			r.method, _, _ = strings.Cut(tc.route, " ")
+			w := g.do(r)
+			if w.Code != tc.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tc.status, w.Body.String())
+			}
+			if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+				body := decode(t, w)
+				for path, want := range tc.want {
+					if got, ok := lookup(body, path); !ok || got != want {
+						t.Errorf("%s = %v, want %v in %s", path, got, want, w.Body.String())
+					}
+				}
+			} else if len(tc.want) > 0 {
+				t.Errorf("response is %s, not JSON", w.Header().Get("Content-Type"))
+			}
+			for _, method := range tc.calls {
+				if len(g.backend.Calls(method)) == 0 {
+					t.Errorf("%s was not called", method)
+				}
+			}
+		})
+	}
+}
+
+// TestRequestsCarryTheCaller checks what the gateway sends the backends on behalf of an
+// authenticated user: the user's ID from the token, never one from the request
+func TestRequestsCarryTheCaller(t *testing.T) {
+	g := newGateway(t)
+	g.mustDo(request{method: http.MethodGet, path: "/api/v1/orders/ord_1001", as: user}, http.StatusOK)
+	g.mustDo(request{method: http.MethodPost, path: "/api/v1/orders/purchase", as: user, body: `{"eventId":"evt_1001","seatIds":["A-1-1"],"userId":"usr_2002"}`}, http.StatusOK)
+
+	calls := g.backend.Calls("order.OrderService/GetOrder")
+	if len(calls) != 1 {
+		t.Fatalf("%d GetOrder calls, want 1", len(calls))
+	}
+	want := &pb.GetOrderRequest{OrderId: "ord_1001", UserId: "usr_1001"}
+	if got := calls[0].Request(); !proto.Equal(got, want) {
+		t.Errorf("GetOrder request %v, want %v", got, want)
+	}
+
+	calls = g.backend.Calls("order.OrderService/PurchaseTicket")
+	if len(calls) != 1 {
+		t.Fatalf("%d PurchaseTicket calls, want 1", len(calls))
+	}
+	purchase := calls[0].Request().(*pb.PurchaseRequest)
+	if purchase.GetUserId() != "usr_1001" || purchase.GetEventId() != "evt_1001" || len(purchase.GetSeatIds()) != 1 {
+		t.Errorf("PurchaseTicket request %v", purchase)
+	}
+}
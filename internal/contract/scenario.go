@@ -0,0 +1,243 @@
+package contract
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Reply is what the backend answers to a method. Both forms are protojson, as recorded
+// from the real services.
+type Reply struct {
+	// Response is the response message
+	Response json.RawMessage `json:"response,omitempty"`
+	// Status is a google.rpc.Status the call fails with, details included; it wins over
+	// Response
+	Status json.RawMessage `json:"status,omitempty"`
+}
+
+// Scenario holds the replies of the backend by method, e.g. user.UserService/Login
+type Scenario map[string]Reply
+
+// Fixture loads a scenario shipped with the package, e.g. default
+func Fixture(name string) (Scenario, error) {
+	data, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown fixture %q: %w", name, err)
+	}
+	return parseScenario(name, data)
+}
+
+// LoadScenario loads a scenario from a JSON file mapping methods to replies
+func LoadScenario(file string) (Scenario, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseScenario(file, data)
+}
+
+// DevScenario returns the scenario of the dev stub mode: the default fixture with the
+// replies of the files merged over it in order
+func DevScenario(files ...string) (Scenario, error) {
+	scenario, err := Fixture("default")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		overrides, err := LoadScenario(file)
+		if err != nil {
+			return nil, err
+		}
+		scenario = scenario.Merge(overrides)
+	}
+	return scenario, nil
+}
+
+// parseScenario decodes a scenario and checks every reply against its method, so that a
+// broken fixture fails when it is loaded rather than when it is played
+func parseScenario(name string, data []byte) (Scenario, error) {
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", name, err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", name, err)
+	}
+	return scenario, nil
+}
+
+// Validate checks that every method exists and every reply decodes into its response type
+func (s Scenario) Validate() error {
+	methods := make([]string, 0, len(s))
+	for method := range s {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		desc, err := methodDescriptor(method)
+		if err != nil {
+			return err
+		}
+		reply := s[method]
+		if len(reply.Status) > 0 {
+			_, err = reply.status()
+		} else {
+			_, err = reply.message(desc)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the scenario that can be changed independently
+func (s Scenario) Clone() Scenario {
+	clone := make(Scenario, len(s))
+	for method, reply := range s {
+		clone[method] = reply
+	}
+	return clone
+}
+
+// With returns a copy of the scenario with the reply to a method replaced
+func (s Scenario) With(method string, reply Reply) Scenario {
+	clone := s.Clone()
+	clone[method] = reply
+	return clone
+}
+
+// Merge returns a copy of the scenario with the replies of other added, replacing those
+// to the same methods
+func (s Scenario) Merge(other Scenario) Scenario {
+	merged := s.Clone()
+	for method, reply := range other {
+		merged[method] = reply
+	}
+	return merged
+}
+
+// Respond is the reply of a successful call
+func Respond(msg proto.Message) Reply {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		panic(fmt.Sprintf("contract: cannot encode %T: %v", msg, err))
+	}
+	return Reply{Response: data}
+}
+
+// Fail is the reply of a failed call
+func Fail(st *status.Status) Reply {
+	data, err := protojson.Marshal(st.Proto())
+	if err != nil {
+		panic(fmt.Sprintf("contract: cannot encode status %v: %v", st, err))
+	}
+	return Reply{Status: data}
+}
+
+// Failf is the reply of a call failing with a code and message and no details
+func Failf(code codes.Code, format string, args ...interface{}) Reply {
+	return Fail(status.Newf(code, format, args...))
+}
+
+// FailWith is the reply of a call failing with a code, a message and details such as
+// BadRequest and RetryInfo
+func FailWith(code codes.Code, message string, details ...proto.Message) Reply {
+	st := &spb.Status{Code: int32(code), Message: message}
+	for _, detail := range details {
+		packed, err := anypb.New(detail)
+		if err != nil {
+			panic(fmt.Sprintf("contract: cannot encode detail %T: %v", detail, err))
+		}
+		st.Details = append(st.Details, packed)
+	}
+	return Fail(status.FromProto(st))
+}
+
+// BadRequest is the detail of a status listing invalid request fields, as field and
+// description pairs
+func BadRequest(fieldDescriptions ...string) *errdetails.BadRequest {
+	detail := &errdetails.BadRequest{}
+	for i := 0; i+1 < len(fieldDescriptions); i += 2 {
+		detail.FieldViolations = append(detail.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldDescriptions[i],
+			Description: fieldDescriptions[i+1],
+		})
+	}
+	return detail
+}
+
+// RetryInfo is the detail of a status telling when the call may be retried
+func RetryInfo(seconds int64) *errdetails.RetryInfo {
+	return &errdetails.RetryInfo{RetryDelay: &durationpb.Duration{Seconds: seconds}}
+}
+
+// QuotaFailure is the detail of a status listing exceeded quotas, as subject and
+// description pairs
+func QuotaFailure(subjectDescriptions ...string) *errdetails.QuotaFailure {
+	detail := &errdetails.QuotaFailure{}
+	for i := 0; i+1 < len(subjectDescriptions); i += 2 {
+		detail.Violations = append(detail.Violations, &errdetails.QuotaFailure_Violation{
+			Subject:     subjectDescriptions[i],
+			Description: subjectDescriptions[i+1],
+		})
+	}
+	return detail
+}
+
+// decode materializes the reply to a method: the response message, or the status error
+func (r Reply) decode(desc protoreflect.MethodDescriptor) (proto.Message, error) {
+	if len(r.Status) > 0 {
+		st, err := r.status()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, st.Err()
+	}
+	resp, err := r.message(desc)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+// status decodes the status of a failed call
+func (r Reply) status() (*status.Status, error) {
+	var st spb.Status
+	if err := protojson.Unmarshal(r.Status, &st); err != nil {
+		return nil, fmt.Errorf("invalid status: %w", err)
+	}
+	return status.FromProto(&st), nil
+}
+
+// message decodes the response message of a successful call
+func (r Reply) message(desc protoreflect.MethodDescriptor) (proto.Message, error) {
+	resp, err := newMessage(desc.Output())
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Response) > 0 {
+		if err := protojson.Unmarshal(r.Response, resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	return resp, nil
+}
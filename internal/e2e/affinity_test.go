@@ -0,0 +1,51 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"apigw/internal/app/config"
+	"apigw/internal/contract"
+)
+
+// TestStickyRouting checks every call of a user reaches the same of several order
+// service instances when sticky routing is enabled
+func TestStickyRouting(t *testing.T) {
+	scenario, err := contract.DevScenario()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var instances []*contract.Backend
+	var endpoints []config.EndpointConfig
+	for _, name := range []string{"a", "b", "c"} {
+		instance := contract.NewBackend(scenario)
+		t.Cleanup(instance.Close)
+		addr, err := instance.Listen("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		instances = append(instances, instance)
+		endpoints = append(endpoints, config.EndpointConfig{Name: name, Host: addr.IP.String(), Port: addr.Port, Weight: 1})
+	}
+
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Services.OrderService.Endpoints = endpoints
+		cfg.Services.OrderService.StickyRouting = true
+	})
+	token := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	const calls = 12
+	for i := 0; i < calls; i++ {
+		env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token).Expect(t, http.StatusOK)
+	}
+	for i, instance := range instances {
+		switch n := len(instance.Calls("order.OrderService/GetOrder")); n {
+		case 0, calls:
+		default:
+			t.Errorf("instance %s received %d of the user's %d calls", endpoints[i].Name, n, calls)
+		}
+	}
+}
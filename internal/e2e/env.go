@@ -0,0 +1,293 @@
+// Package e2e boots the real gateway on a random port, with Redis and the backend services
+// faked in process, for end-to-end tests of whole user flows over HTTP. It needs neither
+// Docker nor network access, so the tests run wherever go test does, in CI or not.
+//
+// Redis is miniredis, which runs the gateway's Lua scripts in its own interpreter. The
+// tests built with the e2e_redis tag run the main flows against a real Redis at
+// E2E_REDIS_ADDR instead; see StartRedis.
+//
+// Every request of a test is traced in its log: the response status and request ID, and
+// the backend calls the gateway made, as are the gateway's own logs. They are shown for
+// failed tests and with go test -v.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/gateway"
+	"apigw/internal/contract"
+	"apigw/pkg/utils/crypt/token"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// jwtSecret signs the access tokens the fake user service issues
+const jwtSecret = "e2e-jwt-secret-0123456789abcdef0123"
+
+// bootTimeout bounds the startup of the gateway
+const bootTimeout = 10 * time.Second
+
+// Env is a running gateway and the fakes it depends on
+type Env struct {
+	// URL is the base URL of the gateway, e.g. http://127.0.0.1:41234
+	URL    string
+	Config *config.Config
+	// Redis is the in-memory Redis of the gateway; nil when it runs against a real one
+	Redis   *miniredis.Miniredis
+	Backend *contract.Backend
+
+	t      testing.TB
+	client *http.Client
+}
+
+// Response is a response of the gateway
+type Response struct {
+	Status int
+	Header http.Header
+	// Body is the decoded JSON body, nil when the body is not a JSON object
+	Body map[string]interface{}
+	Raw  []byte
+}
+
+// Start boots a gateway on default settings: listening on a random port of 127.0.0.1,
+// with rate limiting and asynchronous purchases in an in-memory Redis, and the services
+// answering from the default contract scenario over TCP. Logins return tokens the gateway
+// accepts. configure adjusts the configuration before the boot. The gateway is drained
+// and shut down when the test ends.
+func Start(t testing.TB, configure ...func(*config.Config)) *Env {
+	t.Helper()
+	redis := miniredis.RunT(t)
+	env := boot(t, func(cfg *config.Config) {
+		cfg.Redis.Host = redis.Host()
+		fmt.Sscan(redis.Port(), &cfg.Redis.Port)
+	}, configure)
+	env.Redis = redis
+	return env
+}
+
+// StartRedis boots a gateway like Start, but against the real Redis at addr, whose
+// database db is flushed first: it must be a disposable instance.
+func StartRedis(t testing.TB, addr string, db int, configure ...func(*config.Config)) *Env {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("invalid Redis address %q: %v", addr, err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr, DB: db})
+	defer rdb.Close()
+	if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to flush Redis at %s: %v", addr, err)
+	}
+	return boot(t, func(cfg *config.Config) {
+		cfg.Redis.Host = host
+		fmt.Sscan(port, &cfg.Redis.Port)
+		cfg.Redis.DB = db
+	}, configure)
+}
+
+// boot starts a gateway with Redis set up by useRedis, then adjusted by configure
+func boot(t testing.TB, useRedis func(*config.Config), configure []func(*config.Config)) *Env {
+	t.Helper()
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	scenario, err := contract.DevScenario()
+	if err != nil {
+		t.Fatalf("failed to load the default scenario: %v", err)
+	}
+	backend := contract.NewBackend(scenario)
+	t.Cleanup(backend.Close)
+	backendAddr, err := backend.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	maker, err := token.NewJWTTokenMaker(jwtSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.IssueTokens(func(userID string) (string, error) {
+		return maker.CreateToken(userID, "", time.Hour)
+	})
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatalf("failed to load the default configuration: %v", err)
+	}
+	cfg.JWT.SecretKey = jwtSecret
+	cfg.Server.HTTP.Host = "127.0.0.1"
+	cfg.Server.HTTP.Port = 0
+	cfg.Server.HTTP.DrainDelay = 0
+	cfg.Server.Admin.Enabled = false
+	cfg.Server.GRPC.Enabled = false
+	cfg.Redis.Enabled = true
+	useRedis(cfg)
+	cfg.Orders.AsyncPurchase.Enabled = true
+	cfg.Orders.AsyncPurchase.RetryBackoff = 10 * time.Millisecond
+	cfg.Services = contract.RouteServices(cfg.Services, backendAddr)
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(newTestWriter(t))
+
+	app, err := gateway.New(cfg, logger)
+	if err != nil {
+		t.Fatalf("failed to start the gateway: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	t.Cleanup(func() {
+		app.Stop()
+		if err := <-done; err != nil {
+			t.Errorf("gateway shutdown: %v", err)
+		}
+		app.Close()
+	})
+
+	select {
+	case <-app.Serving():
+	case err := <-done:
+		done <- err
+		t.Fatalf("gateway stopped while starting: %v", err)
+	case <-time.After(bootTimeout):
+		t.Fatal("gateway did not start serving")
+	}
+	return &Env{
+		URL:     "http://" + app.Addrs()[0].String(),
+		Config:  cfg,
+		Backend: backend,
+		t:       t,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Do sends a request to the gateway; body is encoded as JSON unless it is a string, and
+// bearer authenticates the request when it is not empty. The request and the backend
+// calls it made are traced in the test log.
+func (e *Env) Do(method, path string, body interface{}, bearer string, header ...string) *Response {
+	e.t.Helper()
+	var data []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		data = []byte(b)
+	default:
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			e.t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, e.URL+path, bytes.NewReader(data))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	before := len(e.Backend.Calls(""))
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	var methods []string
+	if calls := e.Backend.Calls(""); len(calls) > before {
+		for _, call := range calls[before:] {
+			methods = append(methods, call.Method)
+		}
+	}
+	e.t.Logf("%s %s: %d (request %s), backend calls: [%s]",
+		method, path, resp.StatusCode, resp.Header.Get("X-Request-ID"), strings.Join(methods, " "))
+
+	r := &Response{Status: resp.StatusCode, Header: resp.Header, Raw: raw}
+	json.Unmarshal(raw, &r.Body)
+	return r
+}
+
+// Expect fails the test unless the response has the status
+func (r *Response) Expect(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.Status != status {
+		t.Fatalf("status %d, want %d: %s", r.Status, status, r.Raw)
+	}
+	return r
+}
+
+// String returns a field of the body as a string
+func (r *Response) String(field string) string {
+	value, ok := r.Body[field]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Eventually polls fn until it returns true or the timeout expires
+func (e *Env) Eventually(timeout time.Duration, fn func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if fn() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// testWriter writes the gateway's logs to the test log until the test has ended; logs
+// of goroutines outliving the test are dropped
+type testWriter struct {
+	mu    sync.Mutex
+	t     testing.TB
+	ended bool
+}
+
+func newTestWriter(t testing.TB) *testWriter {
+	w := &testWriter{t: t}
+	t.Cleanup(func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.ended = true
+	})
+	return w
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.ended {
+		w.t.Log(strings.TrimRight(string(p), "\n"))
+	}
+	return len(p), nil
+}
@@ -0,0 +1,553 @@
+package e2e
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+	"apigw/internal/contract"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+)
+
+// TestPurchaseFlow follows a new user from registration to a purchase forwarded to the
+// order service by the asynchronous purchase workers
+func TestPurchaseFlow(t *testing.T) {
+	env := Start(t)
+
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+
+	login := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK)
+	accessToken := login.String("accessToken")
+	if accessToken == "" {
+		t.Fatalf("login returned no access token: %s", login.Raw)
+	}
+
+	env.Do(http.MethodGet, "/api/v1/users/me", nil, accessToken).Expect(t, http.StatusOK)
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusOK)
+
+	queued := env.Do(http.MethodPost, "/api/v1/orders/purchase",
+		map[string]interface{}{"eventId": "evt_1001", "quantity": 2}, accessToken,
+		"Prefer", "respond-async",
+	).Expect(t, http.StatusAccepted)
+	reference := queued.String("reference")
+	if reference == "" {
+		t.Fatalf("queued purchase has no reference: %s", queued.Raw)
+	}
+
+	var status *Response
+	done := env.Eventually(5*time.Second, func() bool {
+		status = env.Do(http.MethodGet, "/api/v1/orders/"+reference+"/status", nil, accessToken).
+			Expect(t, http.StatusOK)
+		return status.String("status") == "succeeded" || status.String("status") == "failed"
+	})
+	if !done || status.String("status") != "succeeded" {
+		t.Fatalf("purchase did not succeed: %s", status.Raw)
+	}
+
+	calls := env.Backend.Calls("order.OrderService/PurchaseTicket")
+	if len(calls) != 1 {
+		t.Fatalf("PurchaseTicket called %d times, want 1", len(calls))
+	}
+	req := calls[0].Request().(*pb.PurchaseRequest)
+	if req.GetUserId() != "usr_1001" || req.GetEventId() != "evt_1001" || req.GetQuantity() != 2 {
+		t.Errorf("PurchaseTicket request = %v, want usr_1001 buying 2 tickets of evt_1001", req)
+	}
+
+	// Only the owner may follow a purchase
+	env.Do(http.MethodGet, "/api/v1/orders/"+reference+"/status", nil, "").Expect(t, http.StatusUnauthorized)
+}
+
+// TestPurchaseTakeover checks purchases a stopped replica left behind are taken over: one
+// still queued is forwarded, one left processing, whose call the order service may have
+// received, is failed rather than purchased twice
+func TestPurchaseTakeover(t *testing.T) {
+	var prefix string
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Orders.AsyncPurchase.ClaimIdle = 50 * time.Millisecond
+		prefix = cfg.Orders.AsyncPurchase.KeyPrefix
+
+		// A replica read both purchases, started the call of one and stopped
+		ctx := context.Background()
+		rdb := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)})
+		defer rdb.Close()
+		rdb.XGroupCreateMkStream(ctx, prefix+"stream", "workers", "0")
+		for ref, status := range map[string]string{"pur_processing": "processing", "pur_queued": "queued"} {
+			rdb.HSet(ctx, prefix+"job:"+ref, "user_id", "usr_1001", "event_id", "evt_1001", "status", status, "attempts", 1)
+			rdb.XAdd(ctx, &redis.XAddArgs{
+				Stream: prefix + "stream",
+				Values: map[string]interface{}{"ref": ref, "purchase": `{"userId":"usr_1001","eventId":"evt_1001","quantity":1}`},
+			})
+		}
+		rdb.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "workers", Consumer: "stopped", Streams: []string{prefix + "stream", ">"}})
+		// Idle for claim_idle by the time the workers first look for abandoned purchases
+		time.Sleep(cfg.Orders.AsyncPurchase.ClaimIdle)
+	})
+
+	status := func(ref string) string {
+		return env.Redis.HGet(prefix+"job:"+ref, "status")
+	}
+	done := env.Eventually(5*time.Second, func() bool {
+		return status("pur_processing") == "failed" && status("pur_queued") == "succeeded"
+	})
+	if !done {
+		t.Fatalf("statuses = %s and %s, want failed and succeeded", status("pur_processing"), status("pur_queued"))
+	}
+	if code := env.Redis.HGet(prefix+"job:pur_processing", "error_code"); code != "PURCHASE_OUTCOME_UNKNOWN" {
+		t.Errorf("error code = %q, want PURCHASE_OUTCOME_UNKNOWN", code)
+	}
+	if calls := env.Backend.Calls("order.OrderService/PurchaseTicket"); len(calls) != 1 {
+		t.Errorf("PurchaseTicket called %d times, want 1", len(calls))
+	}
+}
+
+// TestPaymentIdempotencyLock checks a payment is turned away while a request with the
+// same Idempotency-Key holds its lock, and that the lock is released once served
+func TestPaymentIdempotencyLock(t *testing.T) {
+	env := Start(t)
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+	accessToken := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	const idempotencyKey = "checkout-ord_1001"
+	sum := sha256.Sum256([]byte(idempotencyKey))
+	lockKey := env.Config.Redis.LockKeyPrefix + "idempotency:{usr_1001:" + hex.EncodeToString(sum[:]) + "}"
+	payment := map[string]string{"orderId": "ord_1001", "paymentMethod": "card"}
+
+	// Another replica is serving the same payment
+	env.Redis.Set(lockKey, "1")
+	env.Do(http.MethodPost, "/api/v1/payments", payment, accessToken, "Idempotency-Key", idempotencyKey).Expect(t, http.StatusConflict)
+	if calls := env.Backend.Calls("payment.PaymentService/CreatePaymentIntent"); len(calls) != 0 {
+		t.Fatalf("CreatePaymentIntent called %d times while the key was locked, want 0", len(calls))
+	}
+
+	env.Redis.Del(lockKey)
+	env.Do(http.MethodPost, "/api/v1/payments", payment, accessToken, "Idempotency-Key", idempotencyKey).Expect(t, http.StatusCreated)
+	if env.Redis.Exists(lockKey) {
+		t.Error("idempotency lock still held once the payment was created")
+	}
+}
+
+// TestConfirmPaymentOwnership checks a payment of another user is not confirmed: the
+// gateway answers 404 without asking the payment service to confirm it
+func TestConfirmPaymentOwnership(t *testing.T) {
+	env := Start(t)
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+	accessToken := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	env.Backend.Set("payment.PaymentService/GetPayment", contract.Respond(&pb.GetPaymentResponse{
+		Payment: &pb.Payment{Id: "pay_2002", OrderId: "ord_2002", UserId: "usr_2002"},
+	}))
+	env.Do(http.MethodPost, "/api/v1/payments/pay_2002/confirm", map[string]string{
+		"paymentMethodToken": "tok_visa",
+	}, accessToken).Expect(t, http.StatusNotFound)
+	if calls := env.Backend.Calls("payment.PaymentService/ConfirmPayment"); len(calls) != 0 {
+		t.Errorf("ConfirmPayment called %d times, want 0", len(calls))
+	}
+}
+
+// TestRateLimit checks the token bucket kept in Redis turns clients away once empty
+func TestRateLimit(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Redis.TokenBucket.Capacity = 3
+		cfg.Redis.TokenBucket.RefillRate = 1
+		cfg.Redis.TokenBucket.RefillInterval = time.Hour
+	})
+
+	for i := 0; i < 3; i++ {
+		env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusOK)
+	}
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusTooManyRequests)
+
+	var buckets []string
+	for _, key := range env.Redis.Keys() {
+		if strings.HasPrefix(key, "token_bucket:tokens:") {
+			buckets = append(buckets, key)
+		}
+	}
+	if len(buckets) != 1 {
+		t.Errorf("token buckets in Redis = %v, want the one of the client", buckets)
+	}
+}
+
+// TestRateLimitReplicas checks the token buckets are read from the replicas and written to
+// the primary, and read from the primary while the replicas are down
+func TestRateLimitReplicas(t *testing.T) {
+	replica := miniredis.RunT(t)
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Redis.ReadFrom = config.RedisReadFromReplicas
+		cfg.Redis.Replicas = []string{replica.Addr()}
+		cfg.Redis.TokenBucket.Capacity = 3
+		cfg.Redis.TokenBucket.RefillRate = 0.001
+		cfg.Redis.TokenBucket.RefillInterval = time.Hour
+	})
+
+	// The replica holds an empty bucket the primary does not
+	replica.Set("token_bucket:tokens:ip:127.0.0.1", "0")
+	replica.Set("token_bucket:last_refill:ip:127.0.0.1", strconv.FormatInt(time.Now().Unix(), 10))
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusTooManyRequests)
+
+	replica.Close()
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusOK)
+	if tokens, err := env.Redis.Get("token_bucket:tokens:ip:127.0.0.1"); err != nil || tokens != "2" {
+		t.Errorf("tokens on the primary = %q (%v), want 2", tokens, err)
+	}
+}
+
+// TestRateLimitRedisOutage checks the token buckets are kept in memory while Redis is
+// down, and in Redis again once it recovers
+func TestRateLimitRedisOutage(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Server.HTTP.TrustedProxies = []string{"127.0.0.1/32"}
+		cfg.Redis.Watchdog.Interval = 10 * time.Millisecond
+		cfg.Redis.Watchdog.FailureThreshold = 1
+		cfg.Redis.Watchdog.Fallback = config.LimiterFallbackLocal
+		cfg.Redis.TokenBucket.Capacity = 20
+		cfg.Redis.TokenBucket.RefillRate = 0.001
+		cfg.Redis.TokenBucket.RefillInterval = time.Hour
+	})
+	// The probes of the mode are limited apart from the client
+	const client = "203.0.113.1"
+	mode := func(want string) func() bool {
+		return func() bool {
+			return env.Do(http.MethodGet, "/readyz", nil, "").String("rate_limiter") == want
+		}
+	}
+	if !env.Eventually(5*time.Second, mode("redis")) {
+		t.Fatal("rate limiter not in redis mode")
+	}
+
+	env.Redis.Close()
+	if !env.Eventually(5*time.Second, mode("local")) {
+		t.Fatal("rate limiter not in local mode while Redis is down")
+	}
+	for i := 0; i < 20; i++ {
+		env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "X-Forwarded-For", client).Expect(t, http.StatusOK)
+	}
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "X-Forwarded-For", client).Expect(t, http.StatusTooManyRequests)
+
+	if err := env.Redis.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if !env.Eventually(5*time.Second, mode("redis")) {
+		t.Fatal("rate limiter not back in redis mode once Redis recovered")
+	}
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "X-Forwarded-For", client).Expect(t, http.StatusOK)
+	if tokens, err := env.Redis.Get("token_bucket:tokens:ip:" + client); err != nil || tokens != "19" {
+		t.Errorf("tokens in Redis = %q (%v), want 19", tokens, err)
+	}
+}
+
+// TestFingerprintRateLimit checks clients sharing a fingerprint share a bucket whatever
+// address they come from, and that other clients keep theirs
+func TestFingerprintRateLimit(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Server.HTTP.TrustedProxies = []string{"127.0.0.1/32"}
+		cfg.Fingerprint.Enabled = true
+		cfg.Fingerprint.RateLimit = config.TokenBucketConfig{Capacity: 2, RefillRate: 0.001, RefillInterval: time.Hour}
+		cfg.Fingerprint.Routes = []string{"GET /api/v1/events/:event_id"}
+	})
+
+	const bot = "stuffer/1.0"
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "User-Agent", bot, "X-Forwarded-For", ip).Expect(t, http.StatusOK)
+	}
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "User-Agent", bot, "X-Forwarded-For", "203.0.113.3").Expect(t, http.StatusTooManyRequests)
+
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "User-Agent", "browser/2.0", "X-Forwarded-For", "203.0.113.3").Expect(t, http.StatusOK)
+	env.Do(http.MethodGet, "/api/v1/events", nil, "", "User-Agent", bot, "X-Forwarded-For", "203.0.113.4").Expect(t, http.StatusOK)
+}
+
+// TestOnSaleProfile checks the rate limit of an open on-sale window replaces the global
+// one, and that the waiting rooms of its events are opened
+func TestOnSaleProfile(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.OnSale.Enabled = true
+		cfg.OnSale.Profiles = []config.OnSaleProfileConfig{{
+			Name:      "friday-drop",
+			Schedule:  "* * * * *",
+			Duration:  time.Hour,
+			RateLimit: config.TokenBucketConfig{Capacity: 2, RefillRate: 1, RefillInterval: time.Hour},
+			WaitingRoom: config.OnSaleWaitingRoomConfig{
+				Events: []string{"evt_1001"},
+				Rate:   5,
+			},
+		}}
+	})
+
+	for i := 0; i < 2; i++ {
+		env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusOK)
+	}
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusTooManyRequests)
+
+	settings := env.Config.WaitingRoom.KeyPrefix + "{evt_1001}:settings"
+	deadline := time.Now().Add(5 * time.Second)
+	for !env.Redis.Exists(settings) {
+		if time.Now().After(deadline) {
+			t.Fatal("waiting room of the on-sale profile was not opened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rate := env.Redis.HGet(settings, "rate"); rate != "5" {
+		t.Errorf("waiting room rate = %q, want 5", rate)
+	}
+}
+
+// TestWaitingRoomPurchaseBody checks the events of a purchase are found anywhere in its
+// body, and that a purchase whose body cannot be parsed is refused rather than let past
+// the waiting room
+func TestWaitingRoomPurchaseBody(t *testing.T) {
+	env := Start(t)
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+	accessToken := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	// A waiting room admitting nobody
+	env.Redis.HSet(env.Config.WaitingRoom.KeyPrefix+"{evt_1001}:settings",
+		"rate", "0", "opened_at", strconv.FormatInt(time.Now().Unix(), 10), "generation", "e2e")
+
+	padded := `{"note":"` + strings.Repeat("x", 100<<10) + `","eventId":"evt_1001","quantity":1}`
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", padded, accessToken).Expect(t, http.StatusTooManyRequests)
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", `{"eventId":"evt_1001",`, accessToken).Expect(t, http.StatusBadRequest)
+
+	if calls := env.Backend.Calls("order.OrderService/PurchaseTicket"); len(calls) != 0 {
+		t.Errorf("PurchaseTicket called %d times, want 0", len(calls))
+	}
+}
+
+// TestWaitingRoomStaleTick checks a tick whose fencing token is not above that of the
+// last tick grants no passes, while the ticks of other rooms still do
+func TestWaitingRoomStaleTick(t *testing.T) {
+	env := Start(t)
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+	accessToken := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	prefix := env.Config.WaitingRoom.KeyPrefix
+	for _, eventID := range []string{"evt_1001", "evt_1002"} {
+		env.Redis.HSet(prefix+"{"+eventID+"}:settings",
+			"rate", "100", "opened_at", strconv.FormatInt(time.Now().Unix(), 10), "generation", "e2e")
+	}
+	// A tick recorded under a later lock than any the gateway will acquire
+	env.Redis.Set(prefix+"{evt_1001}:e2e:tick_fence", strconv.FormatInt(1<<40, 10))
+
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", map[string]any{
+		"eventId":  "evt_1001",
+		"quantity": 1,
+	}, accessToken).Expect(t, http.StatusTooManyRequests)
+	if admitted, _ := env.Redis.Get(prefix + "{evt_1001}:e2e:admitted"); admitted != "" {
+		t.Errorf("evt_1001 admitted = %q, want none", admitted)
+	}
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", map[string]any{
+		"eventId":  "evt_1002",
+		"quantity": 1,
+	}, accessToken).Expect(t, http.StatusOK)
+}
+
+// TestWaitingRoomPassUses checks a pass lets one purchase through, that a purchase which
+// fails gives its use back, and that replaying a spent pass queues again
+func TestWaitingRoomPassUses(t *testing.T) {
+	env := Start(t)
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+	accessToken := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	env.Redis.HSet(env.Config.WaitingRoom.KeyPrefix+"{evt_1001}:settings",
+		"rate", "100", "opened_at", strconv.FormatInt(time.Now().Unix(), 10), "generation", "e2e")
+	purchase := map[string]any{"eventId": "evt_1001", "quantity": 1}
+
+	scenario, err := contract.DevScenario()
+	if err != nil {
+		t.Fatalf("failed to load the default scenario: %v", err)
+	}
+	env.Backend.Set("order.OrderService/PurchaseTicket", contract.Failf(codes.Unavailable, "order service down"))
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", purchase, accessToken).Expect(t, http.StatusServiceUnavailable)
+
+	env.Backend.Set("order.OrderService/PurchaseTicket", scenario["order.OrderService/PurchaseTicket"])
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", purchase, accessToken).Expect(t, http.StatusOK)
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", purchase, accessToken).Expect(t, http.StatusTooManyRequests)
+}
+
+// TestCaptchaAbuseHeuristic checks a client is asked for a CAPTCHA once it exceeds the
+// requests of an on_abuse action, and that its counter lives under the configured prefix
+// with the window as expiry
+func TestCaptchaAbuseHeuristic(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Captcha.Enabled = true
+		cfg.Captcha.SecretKey = "e2e"
+		cfg.Captcha.Actions = map[string]string{"register": config.CaptchaOnAbuse}
+		cfg.Captcha.Abuse = config.CaptchaAbuseConfig{Requests: 2, Window: time.Minute}
+		cfg.Captcha.KeyPrefix = "e2e:captcha:"
+	})
+
+	register := map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}
+	for i := 0; i < 2; i++ {
+		env.Do(http.MethodPost, "/api/v1/users/register", register, "").Expect(t, http.StatusCreated)
+	}
+	env.Do(http.MethodPost, "/api/v1/users/register", register, "").Expect(t, http.StatusPreconditionRequired)
+
+	key := "e2e:captcha:register:ip:127.0.0.1"
+	if ttl := env.Redis.TTL(key); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL of %s = %v, want the window", key, ttl)
+	}
+}
+
+// TestBruteForcePaddedLogin checks failed logins are counted against the email however
+// far into the body it is, and that a login whose body cannot be parsed is refused
+func TestBruteForcePaddedLogin(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.BruteForce.Email = config.BruteForceLimits{LockoutAfter: 3}
+		cfg.BruteForce.IP = config.BruteForceLimits{LockoutAfter: 100}
+	})
+	env.Backend.Set("user.UserService/Login", contract.Failf(codes.Unauthenticated, "invalid credentials"))
+
+	padded := `{"note":"` + strings.Repeat("x", 100<<10) + `","email":"ada@example.com","password":"Guessed1"}`
+	for i := 0; i < 3; i++ {
+		env.Do(http.MethodPost, "/api/v1/users/login", padded, "").Expect(t, http.StatusUnauthorized)
+	}
+	env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusTooManyRequests)
+
+	env.Do(http.MethodPost, "/api/v1/users/login", `{"email":"grace@example.com",`, "").Expect(t, http.StatusBadRequest)
+}
+
+// TestBruteForceCaptchaRefusals checks logins refused by the CAPTCHA guard never reach the
+// handler and so do not count as failed attempts
+func TestBruteForceCaptchaRefusals(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"success":false}`)
+	}))
+	t.Cleanup(provider.Close)
+
+	env := Start(t, func(cfg *config.Config) {
+		cfg.BruteForce.Email = config.BruteForceLimits{LockoutAfter: 2}
+		cfg.BruteForce.IP = config.BruteForceLimits{LockoutAfter: 100}
+		cfg.Captcha.Enabled = true
+		cfg.Captcha.SecretKey = "e2e"
+		cfg.Captcha.VerifyURL = provider.URL
+		cfg.Captcha.Actions = map[string]string{"login": config.CaptchaAlways}
+	})
+
+	login := map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}
+	for i := 0; i < 3; i++ {
+		env.Do(http.MethodPost, "/api/v1/users/login", login, "", "X-Captcha-Token", "unsolved").Expect(t, http.StatusForbidden)
+	}
+	if failures, _ := env.Redis.Get(env.Config.BruteForce.KeyPrefix + "{email:ada@example.com}:failures"); failures != "" {
+		t.Errorf("failures = %q, want none", failures)
+	}
+}
+
+// TestRecoveryEmailLimit checks account recovery requests are limited per email however
+// far into the body it is, that requests without an email are refused, and that the
+// confirmations, which carry a token instead, are not
+func TestRecoveryEmailLimit(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Redis.Policies["account_recovery_email"] = config.TokenBucketConfig{
+			Capacity:       2,
+			RefillRate:     0.001,
+			RefillInterval: time.Hour,
+		}
+	})
+
+	padded := `{"note":"` + strings.Repeat("x", 100<<10) + `","email":"ada@example.com"}`
+	for i := 0; i < 2; i++ {
+		env.Do(http.MethodPost, "/api/v1/users/password-reset/request", padded, "").Expect(t, http.StatusAccepted)
+	}
+	env.Do(http.MethodPost, "/api/v1/users/password-reset/request", padded, "").Expect(t, http.StatusTooManyRequests)
+	env.Do(http.MethodPost, "/api/v1/users/verify-email/request", `{"note":"no email"}`, "").Expect(t, http.StatusBadRequest)
+	env.Do(http.MethodPost, "/api/v1/users/verify-email/request", `{"email":"ada@example.com",`, "").Expect(t, http.StatusBadRequest)
+
+	env.Do(http.MethodPost, "/api/v1/users/verify-email/confirm", map[string]string{
+		"token": "verification-token",
+	}, "").Expect(t, http.StatusOK)
+	if calls := env.Backend.Calls("user.UserService/RequestEmailVerification"); len(calls) != 0 {
+		t.Errorf("RequestEmailVerification called %d times, want 0", len(calls))
+	}
+}
+
+// TestQuota checks a client is turned away once its daily quota is used up, and that
+// the usage route reports its consumption without counting itself
+func TestQuota(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Quotas.Enabled = true
+		cfg.Quotas.Anonymous = config.QuotaLimitsConfig{Daily: 2}
+	})
+
+	for i := 0; i < 2; i++ {
+		env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusOK)
+	}
+	refused := env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusTooManyRequests)
+	if refused.String("code") != "DAILY_QUOTA_EXCEEDED" || refused.Header.Get("Retry-After") == "" {
+		t.Errorf("refused call = %s with Retry-After %q, want DAILY_QUOTA_EXCEEDED with a delay",
+			refused.Raw, refused.Header.Get("Retry-After"))
+	}
+
+	usage := env.Do(http.MethodGet, "/api/v1/usage", nil, "").Expect(t, http.StatusOK)
+	windows, _ := usage.Body["windows"].([]interface{})
+	if len(windows) != 2 {
+		t.Fatalf("usage = %s, want the daily and monthly windows", usage.Raw)
+	}
+	daily, _ := windows[0].(map[string]interface{})
+	if daily["window"] != "daily" || daily["used"] != 2.0 || daily["remaining"] != 0.0 {
+		t.Errorf("daily usage = %v, want 2 calls used and none remaining", daily)
+	}
+}
@@ -0,0 +1,142 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPCCaptcha checks the gRPC server refuses the actions requiring a CAPTCHA, which
+// cannot be answered over gRPC, however few attempts the caller made, and serves the others
+func TestGRPCCaptcha(t *testing.T) {
+	env, conn := startGRPC(t, func(cfg *config.Config) {
+		cfg.Captcha.Enabled = true
+		cfg.Captcha.Actions = map[string]string{
+			"register": config.CaptchaOnAbuse,
+			"purchase": config.CaptchaAlways,
+		}
+	})
+	users, orders := pb.NewUserServiceClient(conn), pb.NewOrderServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := users.Register(ctx, &pb.RegisterRequest{Username: "ada", Email: "ada@example.com", Password: "Analytical1"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Register: %v, want ResourceExhausted", err)
+	}
+
+	login, err := users.Login(ctx, &pb.LoginRequest{Email: "ada@example.com", Password: "Analytical1"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	authenticated := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+login.GetAccessToken())
+	_, err = orders.PurchaseTicket(authenticated, &pb.PurchaseRequest{EventId: "evt_1001", Quantity: 1})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("PurchaseTicket: %v, want ResourceExhausted", err)
+	}
+
+	for _, method := range []string{"user.UserService/Register", "order.OrderService/PurchaseTicket"} {
+		if calls := env.Backend.Calls(method); len(calls) != 0 {
+			t.Errorf("%s called %d times, want 0", method, len(calls))
+		}
+	}
+}
+
+// TestGRPCValidation checks registrations and logins are validated like the HTTP bodies,
+// password policy included, before reaching the user service
+func TestGRPCValidation(t *testing.T) {
+	env, conn := startGRPC(t)
+	users := pb.NewUserServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := users.Register(ctx, &pb.RegisterRequest{Username: "ada", Email: "ada@example.com", Password: "short"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Register with a weak password: %v, want InvalidArgument", err)
+	}
+	_, err = users.Login(ctx, &pb.LoginRequest{Email: "not an email", Password: "Analytical1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Login with an invalid email: %v, want InvalidArgument", err)
+	}
+	for _, method := range []string{"user.UserService/Register", "user.UserService/Login"} {
+		if calls := env.Backend.Calls(method); len(calls) != 0 {
+			t.Errorf("%s called %d times, want 0", method, len(calls))
+		}
+	}
+
+	if _, err := users.Register(ctx, &pb.RegisterRequest{Username: "ada", Email: "ada@example.com", Password: "Analytical1"}); err != nil {
+		t.Errorf("Register: %v", err)
+	}
+}
+
+// TestGRPCNetworkACL checks calls from networks on the runtime blocklist are refused
+// before reaching the services
+func TestGRPCNetworkACL(t *testing.T) {
+	_, conn := startGRPC(t, func(cfg *config.Config) {
+		cfg.ACL.Enabled = true
+
+		// Blocked through the admin API of another replica
+		rdb := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)})
+		defer rdb.Close()
+		rdb.HSet(context.Background(), cfg.ACL.KeyPrefix+"blocklist", "127.0.0.1/32", `{"network":"127.0.0.1/32","reason":"e2e"}`)
+	})
+	users := pb.NewUserServiceClient(conn)
+
+	_, err := users.Login(context.Background(), &pb.LoginRequest{Email: "ada@example.com", Password: "Analytical1"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Login from a blocked network: %v, want PermissionDenied", err)
+	}
+}
+
+// startGRPC starts the gateway with its gRPC server on a free port and connects to it
+func startGRPC(t *testing.T, configure ...func(*config.Config)) (*Env, *grpc.ClientConn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	env := Start(t, append([]func(*config.Config){func(cfg *config.Config) {
+		cfg.Server.GRPC.Enabled = true
+		cfg.Server.GRPC.Address = address
+	}}, configure...)...)
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return env, conn
+}
+
+// TestGRPCWebUnauthenticated checks gRPC-Web calls without a valid access token are
+// answered trailers-only with the Unauthenticated status, which gRPC-Web clients parse
+func TestGRPCWebUnauthenticated(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Server.GRPCWeb.Enabled = true
+	})
+
+	for _, bearer := range []string{"", "not-a-token"} {
+		resp := env.Do(http.MethodPost, "/grpc/order.OrderService/PurchaseTicket", "\x00\x00\x00\x00\x00", bearer,
+			"Content-Type", "application/grpc-web+proto").Expect(t, http.StatusOK)
+		if code := resp.Header.Get("grpc-status"); code != "16" {
+			t.Errorf("grpc-status = %q, want 16", code)
+		}
+		if resp.Header.Get("grpc-message") == "" || len(resp.Raw) != 0 {
+			t.Errorf("grpc-message %q and body %q, want a message and no body", resp.Header.Get("grpc-message"), resp.Raw)
+		}
+	}
+	if calls := env.Backend.Calls("order.OrderService/PurchaseTicket"); len(calls) != 0 {
+		t.Errorf("PurchaseTicket called %d times, want 0", len(calls))
+	}
+}
@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+)
+
+// TestErrorReporting checks 5xx responses are reported to the error reporting service
+// with the context of their request
+func TestErrorReporting(t *testing.T) {
+	var mu sync.Mutex
+	var envelopes []string
+	sentry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/api/42/envelope/" && strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			mu.Lock()
+			envelopes = append(envelopes, string(body))
+			mu.Unlock()
+		}
+	}))
+	t.Cleanup(sentry.Close)
+
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Observability.Sentry.Enabled = true
+		cfg.Observability.Sentry.DSN = "http://public@" + sentry.Listener.Addr().String() + "/42"
+		cfg.Faults.Enabled = true
+		cfg.Faults.Rules = []config.FaultRuleConfig{{Route: "GET /api/v1/events/:event_id", Percentage: 100, Status: http.StatusInternalServerError}}
+	})
+
+	env.Do(http.MethodGet, "/api/v1/events", nil, "").Expect(t, http.StatusOK)
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+		Expect(t, http.StatusInternalServerError)
+
+	reported := env.Eventually(2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(envelopes) > 0
+	})
+	if !reported {
+		t.Fatal("5xx response not reported")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(envelopes) != 1 {
+		t.Fatalf("%d events reported, want the 5xx response only", len(envelopes))
+	}
+	for _, want := range []string{`"route":"/api/v1/events/:event_id"`, `"status":"500"`, `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`, `"environment":"development"`} {
+		if !strings.Contains(envelopes[0], want) {
+			t.Errorf("event %s, want %s", envelopes[0], want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//go:build e2e_redis
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// The flows below run against the real Redis at E2E_REDIS_ADDR, e.g. localhost:6379, so
+// the gateway's Lua scripts run in Redis itself rather than in miniredis. Database
+// E2E_REDIS_DB (15 by default) is flushed before every test.
+
+// startRedis boots a gateway against the Redis of the environment, skipping the test
+// when none is configured
+func startRedis(t *testing.T, configure ...func(*config.Config)) *Env {
+	t.Helper()
+	addr := os.Getenv("E2E_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("E2E_REDIS_ADDR is not set")
+	}
+	db := 15
+	if value := os.Getenv("E2E_REDIS_DB"); value != "" {
+		var err error
+		if db, err = strconv.Atoi(value); err != nil {
+			t.Fatalf("invalid E2E_REDIS_DB %q: %v", value, err)
+		}
+	}
+	return StartRedis(t, addr, db, configure...)
+}
+
+// redisClient returns a client of the Redis the gateway uses
+func redisClient(t *testing.T, env *Env) *redis.Client {
+	t.Helper()
+	rdb := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("%s:%d", env.Config.Redis.Host, env.Config.Redis.Port),
+		DB:   env.Config.Redis.DB,
+	})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+// loginAda registers and logs in a user, returning their access token
+func loginAda(t *testing.T, env *Env) string {
+	t.Helper()
+	env.Do(http.MethodPost, "/api/v1/users/register", map[string]string{
+		"username": "ada",
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusCreated)
+	return env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+}
+
+// TestRedisPurchaseFlow follows a purchase through the queue kept in Redis streams
+func TestRedisPurchaseFlow(t *testing.T) {
+	env := startRedis(t)
+	accessToken := loginAda(t, env)
+
+	queued := env.Do(http.MethodPost, "/api/v1/orders/purchase",
+		map[string]interface{}{"eventId": "evt_1001", "quantity": 2}, accessToken,
+		"Prefer", "respond-async",
+	).Expect(t, http.StatusAccepted)
+	reference := queued.String("reference")
+
+	var status *Response
+	done := env.Eventually(5*time.Second, func() bool {
+		status = env.Do(http.MethodGet, "/api/v1/orders/"+reference+"/status", nil, accessToken).
+			Expect(t, http.StatusOK)
+		return status.String("status") == "succeeded" || status.String("status") == "failed"
+	})
+	if !done || status.String("status") != "succeeded" {
+		t.Fatalf("purchase did not succeed: %s", status.Raw)
+	}
+	if calls := env.Backend.Calls("order.OrderService/PurchaseTicket"); len(calls) != 1 {
+		t.Errorf("PurchaseTicket called %d times, want 1", len(calls))
+	}
+}
+
+// TestRedisRateLimit checks the token bucket script turns clients away once empty
+func TestRedisRateLimit(t *testing.T) {
+	env := startRedis(t, func(cfg *config.Config) {
+		cfg.Redis.TokenBucket.Capacity = 3
+		cfg.Redis.TokenBucket.RefillRate = 1
+		cfg.Redis.TokenBucket.RefillInterval = time.Hour
+	})
+
+	for i := 0; i < 3; i++ {
+		env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusOK)
+	}
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "").Expect(t, http.StatusTooManyRequests)
+}
+
+// TestRedisWaitingRoom checks the tick and pass scripts: a buyer is admitted once passes
+// are granted, and their pass lets a single purchase through
+func TestRedisWaitingRoom(t *testing.T) {
+	env := startRedis(t)
+	accessToken := loginAda(t, env)
+
+	err := redisClient(t, env).HSet(context.Background(), env.Config.WaitingRoom.KeyPrefix+"{evt_1001}:settings",
+		"rate", 100, "opened_at", time.Now().Unix(), "generation", "e2e").Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	purchase := map[string]any{"eventId": "evt_1001", "quantity": 1}
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", purchase, accessToken).Expect(t, http.StatusOK)
+	env.Do(http.MethodPost, "/api/v1/orders/purchase", purchase, accessToken).Expect(t, http.StatusTooManyRequests)
+}
@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"apigw/internal/app/config"
+	"apigw/internal/contract"
+)
+
+// TestRegionRouting checks the calls of a region reach its order service, and fail over
+// to the region behind it once that service is down
+func TestRegionRouting(t *testing.T) {
+	scenario, err := contract.DevScenario()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eu, us := contract.NewBackend(scenario), contract.NewBackend(scenario)
+	t.Cleanup(eu.Close)
+	t.Cleanup(us.Close)
+	euAddr, err := eu.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	usAddr, err := us.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Regions.Enabled = true
+		cfg.Regions.CountryHeader = "CF-IPCountry"
+		cfg.Regions.List = []config.RegionConfig{
+			{
+				ID:        "eu",
+				Countries: []string{"DE"},
+				Failover:  "us",
+				Services: map[string]config.ServiceOverrideConfig{
+					"order_service": {Host: euAddr.IP.String(), Port: euAddr.Port},
+				},
+			},
+			{
+				ID: "us",
+				Services: map[string]config.ServiceOverrideConfig{
+					"order_service": {Host: usAddr.IP.String(), Port: usAddr.Port},
+				},
+			},
+		}
+	})
+	token := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	const getOrder = "order.OrderService/GetOrder"
+	env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token, "X-Region", "eu").Expect(t, http.StatusOK)
+	env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token, "CF-IPCountry", "DE").Expect(t, http.StatusOK)
+	if calls := eu.Calls(getOrder); len(calls) != 2 {
+		t.Errorf("eu order service received %d calls, want 2", len(calls))
+	}
+
+	env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token, "X-Region", "us").Expect(t, http.StatusOK)
+	if calls := us.Calls(getOrder); len(calls) != 1 {
+		t.Errorf("us order service received %d calls, want 1", len(calls))
+	}
+	env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token).Expect(t, http.StatusOK)
+	if calls := env.Backend.Calls(getOrder); len(calls) != 1 {
+		t.Errorf("shared order service received %d calls, want 1", len(calls))
+	}
+
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "X-Region", "apac").Expect(t, http.StatusBadRequest)
+
+	// The connection to eu only fails once a call finds it gone
+	eu.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(us.Calls(getOrder)) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("calls of eu did not fail over to us")
+		}
+		env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token, "X-Region", "eu")
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"apigw/internal/app/config"
+	"apigw/internal/contract"
+)
+
+// TestTenantRouting checks the calls of a tenant with an order service of its own reach
+// it, and those of other requests the shared one
+func TestTenantRouting(t *testing.T) {
+	scenario, err := contract.DevScenario()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := contract.NewBackend(scenario)
+	t.Cleanup(acme.Close)
+	addr, err := acme.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Tenants.Enabled = true
+		cfg.Tenants.List = []config.TenantConfig{{
+			ID: "acme",
+			Services: map[string]config.ServiceOverrideConfig{
+				"order_service": {Host: addr.IP.String(), Port: addr.Port},
+			},
+		}}
+	})
+	token := env.Do(http.MethodPost, "/api/v1/users/login", map[string]string{
+		"email":    "ada@example.com",
+		"password": "Analytical1",
+	}, "").Expect(t, http.StatusOK).String("accessToken")
+
+	env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token, "X-Tenant-ID", "acme").Expect(t, http.StatusOK)
+	if calls := acme.Calls("order.OrderService/GetOrder"); len(calls) != 1 {
+		t.Errorf("tenant order service received %d calls, want 1", len(calls))
+	}
+	if calls := env.Backend.Calls("order.OrderService/GetOrder"); len(calls) != 0 {
+		t.Errorf("shared order service received %d calls of the tenant", len(calls))
+	}
+
+	env.Do(http.MethodGet, "/api/v1/orders/ord_1001", nil, token).Expect(t, http.StatusOK)
+	if calls := env.Backend.Calls("order.OrderService/GetOrder"); len(calls) != 1 {
+		t.Errorf("shared order service received %d calls, want 1", len(calls))
+	}
+
+	env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "X-Tenant-ID", "globex").Expect(t, http.StatusBadRequest)
+}
+
+// TestTenantBranding checks the responses of a tenant carry its headers and its errors its
+// template, problem details included, and that other requests are left alone
+func TestTenantBranding(t *testing.T) {
+	env := Start(t, func(cfg *config.Config) {
+		cfg.Tenants.Enabled = true
+		cfg.Tenants.List = []config.TenantConfig{{
+			ID: "acme",
+			Branding: config.BrandingConfig{
+				Headers:       map[string]string{"X-Powered-By": "Acme Tickets"},
+				ErrorTemplate: `{"status":"error","reason":{{json .Code}},"tenant":{{json .Tenant}}}`,
+			},
+		}}
+	})
+
+	ok := env.Do(http.MethodGet, "/api/v1/events/evt_1001", nil, "", "X-Tenant-ID", "acme").Expect(t, http.StatusOK)
+	if ok.Header.Get("X-Powered-By") != "Acme Tickets" {
+		t.Errorf("tenant response X-Powered-By = %q, want Acme Tickets", ok.Header.Get("X-Powered-By"))
+	}
+
+	for _, accept := range []string{"application/json", "application/problem+json"} {
+		refused := env.Do(http.MethodGet, "/api/v1/users/me", nil, "", "X-Tenant-ID", "acme", "Accept", accept).
+			Expect(t, http.StatusUnauthorized)
+		if refused.String("status") != "error" || refused.String("reason") == "" || refused.String("tenant") != "acme" {
+			t.Errorf("tenant error accepting %s = %s, want the tenant's template", accept, refused.Raw)
+		}
+		if refused.Header.Get("X-Powered-By") != "Acme Tickets" || refused.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("tenant error headers = %v, want branded JSON", refused.Header)
+		}
+	}
+
+	plain := env.Do(http.MethodGet, "/api/v1/users/me", nil, "").Expect(t, http.StatusUnauthorized)
+	if plain.String("code") == "" || plain.Header.Get("X-Powered-By") != "" {
+		t.Errorf("untenanted error = %s with X-Powered-By %q, want the gateway's", plain.Raw, plain.Header.Get("X-Powered-By"))
+	}
+}
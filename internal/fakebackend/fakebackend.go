@@ -0,0 +1,240 @@
+package fakebackend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	pb "apigw/client/proto"
+	"apigw/internal/app/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves the user, order, event and payment services with canned responses on a
+// single gRPC listener, so the gateway can be load tested and benchmarked without the
+// real backends. Every call waits Latency before it is answered.
+type Server struct {
+	Latency time.Duration
+
+	grpc     *grpc.Server
+	listener net.Listener
+}
+
+// New creates a fake backend answering after latency
+func New(latency time.Duration) *Server {
+	s := &Server{Latency: latency, grpc: grpc.NewServer()}
+	pb.RegisterUserServiceServer(s.grpc, &userService{server: s})
+	pb.RegisterOrderServiceServer(s.grpc, &orderService{server: s})
+	pb.RegisterEventServiceServer(s.grpc, &eventService{server: s})
+	pb.RegisterPaymentServiceServer(s.grpc, &paymentService{server: s})
+	return s
+}
+
+// Start listens on addr (e.g. "127.0.0.1:0") and serves in the background
+func (s *Server) Start(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.listener = l
+	go s.grpc.Serve(l)
+	return nil
+}
+
+// Addr returns the address the backend listens on
+func (s *Server) Addr() *net.TCPAddr {
+	return s.listener.Addr().(*net.TCPAddr)
+}
+
+// Stop stops serving and closes the listener
+func (s *Server) Stop() {
+	s.grpc.Stop()
+}
+
+// Route points every service of the gateway configuration at the fake backend
+func (s *Server) Route(services *config.ServicesConfig) {
+	addr := s.Addr()
+	for _, svc := range []*config.ServiceConfig{
+		&services.UserService,
+		&services.OrderService,
+		&services.EventService,
+		&services.PaymentService,
+	} {
+		svc.Host = addr.IP.String()
+		svc.Port = addr.Port
+		svc.Target = ""
+		svc.Endpoints = nil
+		svc.Shadow.Enabled = false
+		svc.TLS.Enabled = false
+	}
+}
+
+// wait simulates the backend's processing time, returning early when the call is cancelled
+func (s *Server) wait(ctx context.Context) error {
+	if s.Latency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(s.Latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startsAt is the start time of every fake event
+var startsAt = time.Date(2030, time.June, 1, 19, 0, 0, 0, time.UTC)
+
+// fakeEvent returns the canned event with the given ID
+func fakeEvent(id string) *pb.Event {
+	return &pb.Event{
+		Id:               id,
+		Name:             "Load Test Live " + id,
+		Description:      "A fake event served by the load test backend",
+		Category:         "concert",
+		Venue:            "Test Arena",
+		StartsAt:         timestamppb.New(startsAt),
+		EndsAt:           timestamppb.New(startsAt.Add(3 * time.Hour)),
+		AvailableTickets: 5000,
+	}
+}
+
+// fakeOrder returns the canned order with the given ID
+func fakeOrder(id, userID string) *pb.Order {
+	created := timestamppb.New(startsAt.Add(-30 * 24 * time.Hour))
+	return &pb.Order{
+		Id:        id,
+		EventId:   "evt_1",
+		UserId:    userID,
+		Status:    pb.Order_CONFIRMED,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+// userService is the fake user service
+type userService struct {
+	pb.UnimplementedUserServiceServer
+	server *Server
+}
+
+func (s *userService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.LoginResponse{
+		User:         &pb.User{Id: "user_1", Email: req.Email, Username: "loadtest"},
+		AccessToken:  "fake-access-token",
+		RefreshToken: "fake-refresh-token",
+	}, nil
+}
+
+func (s *userService) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.GetProfileResponse{
+		User: &pb.User{Id: req.UserId, Email: "loadtest@example.com", Username: "loadtest"},
+	}, nil
+}
+
+// orderService is the fake order service
+type orderService struct {
+	pb.UnimplementedOrderServiceServer
+	server *Server
+}
+
+func (s *orderService) PurchaseTicket(ctx context.Context, _ *pb.PurchaseRequest) (*pb.PurchaseResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.PurchaseResponse{Status: pb.PurchaseResponse_QUEUED}, nil
+}
+
+func (s *orderService) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	resp := &pb.ListOrdersResponse{NextPageToken: "fake-cursor"}
+	for i := int32(0); i < req.PageSize; i++ {
+		resp.Orders = append(resp.Orders, fakeOrder(fmt.Sprintf("ord_%d", i+1), req.UserId))
+	}
+	return resp, nil
+}
+
+func (s *orderService) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.GetOrderResponse{Order: fakeOrder(req.OrderId, req.UserId)}, nil
+}
+
+// eventService is the fake event service
+type eventService struct {
+	pb.UnimplementedEventServiceServer
+	server *Server
+}
+
+func (s *eventService) ListEvents(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	resp := &pb.ListEventsResponse{NextPageToken: "fake-cursor"}
+	for i := int32(0); i < req.PageSize; i++ {
+		resp.Events = append(resp.Events, fakeEvent(fmt.Sprintf("evt_%d", i+1)))
+	}
+	return resp, nil
+}
+
+func (s *eventService) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.GetEventResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.GetEventResponse{Event: fakeEvent(req.EventId)}, nil
+}
+
+func (s *eventService) GetSeatMap(ctx context.Context, req *pb.GetSeatMapRequest) (*pb.GetSeatMapResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	resp := &pb.GetSeatMapResponse{EventId: req.EventId}
+	for row := 0; row < 10; row++ {
+		for number := int32(1); number <= 20; number++ {
+			resp.Seats = append(resp.Seats, &pb.Seat{
+				Id:         fmt.Sprintf("A-%c-%d", 'A'+row, number),
+				Section:    "A",
+				Row:        string(rune('A' + row)),
+				Number:     number,
+				Tier:       "standard",
+				PriceCents: 8900,
+			})
+		}
+	}
+	return resp, nil
+}
+
+// paymentService is the fake payment service
+type paymentService struct {
+	pb.UnimplementedPaymentServiceServer
+	server *Server
+}
+
+func (s *paymentService) GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.GetPaymentResponse, error) {
+	if err := s.server.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.GetPaymentResponse{Payment: &pb.Payment{
+		Id:          req.PaymentId,
+		OrderId:     "ord_1",
+		UserId:      req.UserId,
+		AmountCents: 8900,
+		Currency:    "EUR",
+		Status:      pb.Payment_SUCCEEDED,
+		CreatedAt:   timestamppb.New(startsAt.Add(-30 * 24 * time.Hour)),
+	}}, nil
+}
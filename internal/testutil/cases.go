@@ -0,0 +1,35 @@
+package testutil
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Case is a table-driven handler test: a request, the calls it should make to the mocked
+// services M, and the golden file of its response, testdata/<test>/<case>.golden.json
+type Case[M any] struct {
+	Name    string
+	Request *Request
+	// Expect sets the expectations of the mocks the request should call
+	Expect func(mocks M)
+	// Headers are the response headers recorded in the golden file, e.g. Retry-After
+	Headers []string
+}
+
+// Run runs every case as a subtest: setUp builds the handler under test and its mocks
+// for the subtest, e.g. with a gomock controller of it, and the response of the request
+// is compared with the golden file of the case
+func Run[M any](t *testing.T, cases []Case[M], setUp func(t *testing.T) (http.Handler, M)) {
+	t.Helper()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			h, mocks := setUp(t)
+			if tc.Expect != nil {
+				tc.Expect(mocks)
+			}
+			rec := tc.Request.Serve(t, h)
+			AssertGolden(t, rec, t.Name(), tc.Headers...)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package testutil
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"apigw/internal/app/config"
+	"apigw/internal/app/middleware"
+	"apigw/internal/app/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	setUpOnce sync.Once
+	setUpErr  error
+	logger    *logrus.Logger
+)
+
+// Logger returns the logger of handlers under test, which discards its output
+func Logger() *logrus.Logger {
+	setUp()
+	return logger
+}
+
+// NewEngine returns a Gin engine with the middleware handlers rely on: request IDs, the
+// request logger and the rendering of the errors they record. Request validation uses the
+// default rules. Routes are registered with the patterns of the router so path parameters
+// bind.
+func NewEngine(t testing.TB) *gin.Engine {
+	t.Helper()
+	if setUp(); setUpErr != nil {
+		t.Fatalf("failed to set up request validation: %v", setUpErr)
+	}
+	engine := gin.New()
+	engine.Use(middleware.RequestIDMiddleware())
+	engine.Use(middleware.RequestLoggerMiddleware(logger))
+	engine.Use(middleware.ErrorHandlerMiddleware(logger))
+	return engine
+}
+
+// setUp configures Gin, the logger and request validation for every test of the binary
+func setUp() {
+	setUpOnce.Do(func() {
+		gin.SetMode(gin.TestMode)
+		logger = logrus.New()
+		logger.SetOutput(io.Discard)
+
+		cfg, err := config.LoadConfig("")
+		if err != nil {
+			setUpErr = err
+			return
+		}
+		setUpErr = validation.Setup(cfg.Validation)
+	})
+}
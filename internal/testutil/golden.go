@@ -0,0 +1,110 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites the golden files with the responses of the run instead of comparing
+var update = flag.Bool("update", false, "rewrite the golden files of handler tests")
+
+// goldenDir holds the golden files, next to the tests of the package
+const goldenDir = "testdata"
+
+// masked replaces the values of Masked fields in golden files
+const masked = "<masked>"
+
+// Masked are the response fields whose values change between runs
+var Masked = []string{"request_id", "requestId", "timestamp"}
+
+// golden is the content of a golden file
+type golden struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// AssertGolden compares a response, its status, the named headers and its body, with the
+// golden file testdata/<name>.golden.json. JSON bodies are compared as indented JSON
+// with the values of Masked fields replaced; other bodies as text.
+func AssertGolden(t testing.TB, rec *httptest.ResponseRecorder, name string, headers ...string) {
+	t.Helper()
+	got := golden{Status: rec.Code}
+	for _, header := range headers {
+		if got.Headers == nil {
+			got.Headers = make(map[string]string)
+		}
+		got.Headers[header] = rec.Header().Get(header)
+	}
+	if rec.Body.Len() > 0 {
+		var body interface{}
+		decoder := json.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err == nil {
+			got.Body = mask(body)
+		} else {
+			got.Body = rec.Body.String()
+		}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(got); err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	data := buf.Bytes()
+
+	file := filepath.Join(goldenDir, filepath.FromSlash(name)+".golden.json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("missing golden file, run go test -update to create it: %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("response differs from %s (go test -update rewrites it)\n--- got\n%s--- want\n%s", file, data, want)
+	}
+}
+
+// mask replaces the values of Masked fields of a decoded JSON value
+func mask(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isMasked(key) {
+				v[key] = masked
+			} else {
+				v[key] = mask(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = mask(item)
+		}
+	}
+	return value
+}
+
+// isMasked reports whether a field is one of Masked
+func isMasked(key string) bool {
+	for _, field := range Masked {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
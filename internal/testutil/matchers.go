@@ -0,0 +1,26 @@
+package testutil
+
+import (
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoEq matches a backend request equal to want by proto.Equal; gomock.Eq compares
+// the internal state of messages too, which differs once a message has been marshaled
+func ProtoEq(want proto.Message) gomock.Matcher {
+	return protoMatcher{want: want}
+}
+
+type protoMatcher struct {
+	want proto.Message
+}
+
+func (m protoMatcher) Matches(x interface{}) bool {
+	got, ok := x.(proto.Message)
+	return ok && proto.Equal(got, m.want)
+}
+
+func (m protoMatcher) String() string {
+	return "equals " + prototext.Format(m.want)
+}
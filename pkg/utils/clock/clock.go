@@ -0,0 +1,75 @@
+// Package clock abstracts the current time, so that the timestamps the gateway reports
+// come from one place and tests can freeze them.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time
+type Clock interface {
+	// Now returns the current time. Readings of the system clock carry a monotonic
+	// reading, so durations between them are immune to wall clock changes.
+	Now() time.Time
+	// Since returns the time elapsed since t
+	Since(t time.Time) time.Duration
+}
+
+// System is the clock of the operating system
+var System Clock = systemClock{}
+
+// systemClock reads the operating system clock
+type systemClock struct{}
+
+// Now returns the current local time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Since returns the time elapsed since t
+func (systemClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Frozen is a clock that only moves when told to
+type Frozen struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFrozen creates a clock frozen at now
+func NewFrozen(now time.Time) *Frozen {
+	return &Frozen{now: now}
+}
+
+// Now returns the time the clock is frozen at
+func (f *Frozen) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the time elapsed between t and the time the clock is frozen at
+func (f *Frozen) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Set moves the clock to now
+func (f *Frozen) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance moves the clock forward by d
+func (f *Frozen) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Timestamp formats a time the way responses report it: RFC 3339 in UTC
+func Timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
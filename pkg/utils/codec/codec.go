@@ -0,0 +1,78 @@
+// Package codec is the JSON implementation used by the gateway. The library is chosen
+// at build time with the same tags gin uses, so request binding, c.JSON and this package
+// always agree: the default build uses encoding/json, -tags jsoniter selects
+// json-iterator and -tags go_json selects goccy/go-json.
+package codec
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Encoder writes JSON values to a stream
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads JSON values from a stream
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// maxPooledBuffer keeps buffers grown by unusually large responses out of the pool
+const maxPooledBuffer = 64 << 10
+
+// bufferPool holds the buffers responses are encoded into
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// jsonContentType matches the content type of gin's c.JSON responses
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// JSON renders a value like gin's c.JSON, encoding it into a pooled buffer instead of
+// allocating a new byte slice for every response: c.Render(status, codec.JSON{Data: v})
+type JSON struct {
+	Data interface{}
+}
+
+// Render implements render.Render
+func (r JSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := encodeTo(buf, r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteContentType implements render.Render
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = jsonContentType
+	}
+}
+
+// NewEncoder returns an encoder writing to w
+func NewEncoder(w io.Writer) Encoder {
+	return newEncoder(w)
+}
+
+// NewDecoder returns a decoder reading from r
+func NewDecoder(r io.Reader) Decoder {
+	return newDecoder(r)
+}
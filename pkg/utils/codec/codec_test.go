@@ -0,0 +1,143 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"apigw/internal/app/domains/dto"
+
+	"github.com/gin-gonic/gin/render"
+)
+
+// Benchmarks compare the build's codec with encoding/json on typical response DTOs.
+// Run them once per build tag to compare libraries:
+//
+//	go test -run '^$' -bench . -benchmem ./pkg/utils/codec
+//	go test -run '^$' -bench . -benchmem -tags jsoniter ./pkg/utils/codec
+//	go test -run '^$' -bench . -benchmem -tags go_json ./pkg/utils/codec
+
+// discardWriter is a response writer that drops the body
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// eventPage returns a full page of events as served by GET /events
+func eventPage() dto.ListEventsResp {
+	startsAt := time.Date(2025, 6, 1, 19, 30, 0, 0, time.UTC)
+	events := make([]dto.EventResp, 100)
+	for i := range events {
+		events[i] = dto.EventResp{
+			ID:               fmt.Sprintf("evt-%06d", i),
+			Name:             "Summer Open Air Festival",
+			Description:      "Three stages, forty artists and a fireworks finale over the lake.",
+			Category:         "music",
+			Venue:            "Lakeside Park Arena",
+			StartsAt:         startsAt,
+			EndsAt:           startsAt.Add(5 * time.Hour),
+			AvailableTickets: 1250,
+			Status:           "on_sale",
+		}
+	}
+	return dto.ListEventsResp{Events: events, NextCursor: "eyJvZmZzZXQiOjEwMH0"}
+}
+
+// seatMap returns the seat map of a large venue as served by GET /events/:event_id/seats
+func seatMap() dto.SeatMapResp {
+	seats := make([]dto.SeatResp, 5000)
+	for i := range seats {
+		seats[i] = dto.SeatResp{
+			ID:         fmt.Sprintf("S%02d-R%02d-%03d", i/1000, i/50%20, i%50),
+			Section:    fmt.Sprintf("S%02d", i/1000),
+			Row:        fmt.Sprintf("R%02d", i/50%20),
+			Number:     int32(i % 50),
+			Tier:       "standard",
+			Status:     "available",
+			PriceCents: 8900,
+		}
+	}
+	return dto.SeatMapResp{EventID: "evt-000001", Seats: seats}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		data interface{}
+	}{
+		{"EventPage", eventPage()},
+		{"SeatMap", seatMap()},
+	} {
+		b.Run(tc.name+"/encoding_json", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := json.Marshal(tc.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(tc.name+"/codec", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Marshal(tc.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data, err := json.Marshal(eventPage())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("EventPage/encoding_json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var page dto.ListEventsResp
+			if err := json.Unmarshal(data, &page); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("EventPage/codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var page dto.ListEventsResp
+			if err := Unmarshal(data, &page); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkRender compares gin's c.JSON renderer with the pooled renderer
+func BenchmarkRender(b *testing.B) {
+	page := eventPage()
+
+	b.Run("EventPage/gin", func(b *testing.B) {
+		b.ReportAllocs()
+		w := &discardWriter{header: http.Header{}}
+		for i := 0; i < b.N; i++ {
+			if err := (render.JSON{Data: page}).Render(w); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("EventPage/pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		w := &discardWriter{header: http.Header{}}
+		for i := 0; i < b.N; i++ {
+			if err := (JSON{Data: page}).Render(w); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
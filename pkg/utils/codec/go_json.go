@@ -0,0 +1,33 @@
+//go:build go_json
+
+package codec
+
+import (
+	"bytes"
+	"io"
+
+	gojson "github.com/goccy/go-json"
+)
+
+// Name identifies the JSON library the gateway was built with
+const Name = "go-json"
+
+var (
+	// Marshal returns the JSON encoding of v
+	Marshal = gojson.Marshal
+	// Unmarshal parses JSON data into v
+	Unmarshal = gojson.Unmarshal
+)
+
+func newEncoder(w io.Writer) Encoder { return gojson.NewEncoder(w) }
+
+func newDecoder(r io.Reader) Decoder { return gojson.NewDecoder(r) }
+
+// encodeTo appends the encoding of v to buf without the newline Encode adds
+func encodeTo(buf *bytes.Buffer, v interface{}) error {
+	if err := gojson.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
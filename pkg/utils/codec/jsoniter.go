@@ -0,0 +1,39 @@
+//go:build jsoniter
+
+package codec
+
+import (
+	"bytes"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Name identifies the JSON library the gateway was built with
+const Name = "jsoniter"
+
+var api = jsoniter.ConfigCompatibleWithStandardLibrary
+
+var (
+	// Marshal returns the JSON encoding of v
+	Marshal = api.Marshal
+	// Unmarshal parses JSON data into v
+	Unmarshal = api.Unmarshal
+)
+
+func newEncoder(w io.Writer) Encoder { return api.NewEncoder(w) }
+
+func newDecoder(r io.Reader) Decoder { return api.NewDecoder(r) }
+
+// encodeTo appends the encoding of v to buf; the stream is borrowed from jsoniter's
+// pool and encodes into its own reused buffer
+func encodeTo(buf *bytes.Buffer, v interface{}) error {
+	stream := api.BorrowStream(nil)
+	defer api.ReturnStream(stream)
+	stream.WriteVal(v)
+	if stream.Error != nil {
+		return stream.Error
+	}
+	buf.Write(stream.Buffer())
+	return nil
+}
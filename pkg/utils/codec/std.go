@@ -0,0 +1,32 @@
+//go:build !jsoniter && !go_json
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Name identifies the JSON library the gateway was built with
+const Name = "encoding/json"
+
+var (
+	// Marshal returns the JSON encoding of v
+	Marshal = json.Marshal
+	// Unmarshal parses JSON data into v
+	Unmarshal = json.Unmarshal
+)
+
+func newEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+
+func newDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+// encodeTo appends the encoding of v to buf without the newline Encode adds
+func encodeTo(buf *bytes.Buffer, v interface{}) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
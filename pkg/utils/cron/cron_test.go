@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 9, 31, 0, 0, time.UTC)},
+		{"0 10 * * *", time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2026, 3, 5, 9, 30, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2026, 3, 4, 9, 40, 0, 0, time.UTC)},
+		{"0 10 * * FRI", time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)},
+		{"0 10 * * 7", time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan-jun *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"15 8,20 * * 1-5", time.Date(2026, 3, 4, 20, 15, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 12 20 * MON", time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tc := range cases {
+		schedule, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tc.want) {
+			t.Errorf("Next(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	schedule, err := Parse("0 10 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := schedule.Next(time.Date(2026, 3, 4, 9, 45, 0, 0, kolkata))
+	if want := time.Date(2026, 3, 4, 10, 0, 0, 0, kolkata); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package token
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -47,3 +48,19 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 
 	return payload, nil
 }
+
+// CreateToken signs a token for a user that expires after duration. Tokens are issued
+// by the user service; the gateway only creates them for tooling such as the load
+// generator and tests.
+func (maker *JWTMaker) CreateToken(userID, role string, duration time.Duration) (string, error) {
+	now := time.Now()
+	payload := &Payload{
+		UserID: userID,
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString([]byte(maker.secretKey))
+}
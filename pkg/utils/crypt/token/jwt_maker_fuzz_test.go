@@ -0,0 +1,90 @@
+package token
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fuzzSecretKey = "fuzz-secret-key-0123456789abcdef0123"
+
+// FuzzVerifyToken checks malformed tokens are rejected with ErrInvalidToken rather than
+// panicking, and that no token is accepted without the signature of the secret key
+func FuzzVerifyToken(f *testing.F) {
+	maker, err := NewJWTTokenMaker(fuzzSecretKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+	valid, err := maker.CreateToken("usr_1001", "admin", time.Hour)
+	if err != nil {
+		f.Fatal(err)
+	}
+	expired, err := maker.CreateToken("usr_1001", "", -time.Minute)
+	if err != nil {
+		f.Fatal(err)
+	}
+	other, err := (&JWTMaker{secretKey: strings.Repeat("x", 32)}).CreateToken("usr_1001", "admin", time.Hour)
+	if err != nil {
+		f.Fatal(err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, &Payload{UserID: "usr_1001"}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		f.Fatal(err)
+	}
+	segment := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	for _, seed := range []string{
+		valid,
+		expired,
+		other,
+		unsigned,
+		valid[:len(valid)/2],
+		valid + ".",
+		strings.Replace(valid, ".", "..", 1),
+		"",
+		".",
+		"..",
+		"not-a-token",
+		segment(`{"alg":"HS256"}`) + "." + segment(`{"user_id":1}`) + ".",
+		segment(`{"alg":"HS256","typ":"JWT"}`) + "." + segment(`{"exp":"tomorrow"}`) + "." + segment("sig"),
+		segment(`{"alg":"RS256"}`) + "." + segment(`{}`) + "." + segment("sig"),
+		segment(`[]`) + "." + segment(`null`) + "." + segment(""),
+		segment(strings.Repeat("[", 10000)) + ".e30.",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, tokenString string) {
+		payload, err := maker.VerifyToken(tokenString)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("VerifyToken(%q) error = %v, want ErrInvalidToken", tokenString, err)
+			}
+			return
+		}
+		if payload == nil {
+			t.Fatalf("VerifyToken(%q) accepted the token without a payload", tokenString)
+		}
+		// An accepted token must carry a valid HMAC signature of the secret key
+		parts := strings.Split(tokenString, ".")
+		if len(parts) != 3 {
+			t.Fatalf("VerifyToken(%q) accepted a token of %d segments", tokenString, len(parts))
+		}
+		signature, err := jwt.NewParser().DecodeSegment(parts[2])
+		if err != nil {
+			t.Fatalf("VerifyToken(%q) accepted an undecodable signature", tokenString)
+		}
+		header, _, err := jwt.NewParser().ParseUnverified(tokenString, &Payload{})
+		if err != nil {
+			t.Fatalf("VerifyToken(%q) accepted an unparsable token: %v", tokenString, err)
+		}
+		if err := header.Method.Verify(parts[0]+"."+parts[1], signature, []byte(fuzzSecretKey)); err != nil {
+			t.Fatalf("VerifyToken(%q) accepted a token not signed with the secret key: %v", tokenString, err)
+		}
+	})
+}
@@ -7,5 +7,8 @@ import (
 // Payload represents the JWT payload
 type Payload struct {
 	UserID string `json:"user_id"`
+	Role   string `json:"role,omitempty"`
+	// Region is the home region of the user, whose backend clusters serve their requests
+	Region string `json:"region,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ContextKey is the gin context key of the logger of a request
+const ContextKey = "logger"
+
+// userIDKey is the gin context key of the authenticated user, set once the request's
+// logger was attached
+const userIDKey = "user_id"
+
+// FromContext returns the logger of a request, with the fields identifying it: its ID,
+// method, path, route, client address and authenticated user. ctx is the gin context of
+// the request; without a logger attached the logger instance is returned.
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry, ok := ctx.Value(ContextKey).(*logrus.Entry)
+	if !ok {
+		entry = logrus.NewEntry(GetLogger())
+	}
+	if userID, _ := ctx.Value(userIDKey).(string); userID != "" {
+		if _, set := entry.Data[userIDKey]; !set {
+			entry = entry.WithField(userIDKey, userID)
+		}
+	}
+	return entry
+}
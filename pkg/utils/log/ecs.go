@@ -0,0 +1,119 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ECSVersion is the version of the Elastic Common Schema lines of the ecs format follow
+const ECSVersion = "8.11.0"
+
+// durationField is the field durations are logged in, as milliseconds
+const durationField = "duration_ms"
+
+// ecsKeys maps the fields logged across the gateway to their ECS names, or their
+// OpenTelemetry ones where ECS has none; other fields are kept as they are
+var ecsKeys = map[string]string{
+	"method":        "http.request.method",
+	"path":          "url.path",
+	"route":         "http.route",
+	"status":        "http.response.status_code",
+	"ip":            "client.ip",
+	"request_id":    "http.request.id",
+	"user_id":       "user.id",
+	"tenant_id":     "organization.id",
+	"trace_id":      "trace.id",
+	"span_id":       "span.id",
+	"user_agent":    "user_agent.original",
+	logrus.ErrorKey: "error.message",
+}
+
+// ECSKey returns the ECS name of a field
+func ECSKey(field string) string {
+	if key, ok := ecsKeys[field]; ok {
+		return key
+	}
+	return field
+}
+
+// ecsValue returns the value of a field as ECS expects it: durations in nanoseconds
+// under event.duration and errors as their message
+func ecsValue(field string, value any) (string, any) {
+	if field == durationField {
+		if ms, ok := value.(float64); ok {
+			return "event.duration", int64(ms * float64(time.Millisecond))
+		}
+	}
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	return ECSKey(field), value
+}
+
+// ecsFormatter formats entries as Elastic Common Schema JSON lines
+type ecsFormatter struct {
+	service string
+}
+
+// NewECSFormatter returns the formatter of the ecs format; service is the service.name
+// of its lines, left out when empty
+func NewECSFormatter(service string) logrus.Formatter {
+	return &ecsFormatter{service: service}
+}
+
+// Format formats an entry as an ECS JSON line
+func (f *ecsFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	line := make(map[string]any, len(entry.Data)+5)
+	for field, value := range entry.Data {
+		key, value := ecsValue(field, value)
+		line[key] = value
+	}
+	line["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	line["log.level"] = entry.Level.String()
+	line["message"] = entry.Message
+	line["ecs.version"] = ECSVersion
+	if f.service != "" {
+		line["service.name"] = f.service
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		raw, err := json.Marshal(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal fields to JSON: %w", err)
+		}
+		return append(raw, '\n'), nil
+	}
+	if err := json.NewEncoder(b).Encode(line); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// ecsReplaceAttr renames the attributes of the slog backend as the ecs format does
+func ecsReplaceAttr(groups []string, a Attr) Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.String("@timestamp", a.Value.Time().UTC().Format(time.RFC3339Nano))
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(Level); ok {
+			return slog.String("log.level", logrusLevel(level).String())
+		}
+		return a
+	case slog.MessageKey:
+		a.Key = "message"
+		return a
+	}
+	key, value := ecsValue(a.Key, a.Value.Any())
+	if key == a.Key && a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	return slog.Any(key, value)
+}
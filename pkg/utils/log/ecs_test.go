@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestECSFormat checks both backends write the fields of the gateway under their ECS
+// names, with durations in nanoseconds and the ECS version and service
+func TestECSFormat(t *testing.T) {
+	var logrusOut, slogOut bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logrusOut)
+	logger.SetFormatter(NewECSFormatter("apigw"))
+	logger.WithFields(logrus.Fields{
+		"method":      "GET",
+		"path":        "/api/v1/events",
+		"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+		"duration_ms": 1.5,
+	}).WithError(errors.New("unavailable")).Warn("gRPC call failed")
+
+	slog.New(newSlogHandler(Options{Format: "ecs", Service: "apigw"}, &slogOut, LevelInfo)).LogAttrs(
+		context.Background(), LevelWarn, "gRPC call failed",
+		String("method", "GET"),
+		String("path", "/api/v1/events"),
+		String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+		Milliseconds("duration_ms", 1500*time.Microsecond),
+		Err(errors.New("unavailable")),
+	)
+
+	for name, out := range map[string][]byte{"logrus": logrusOut.Bytes(), "slog": slogOut.Bytes()} {
+		var line map[string]any
+		if err := json.Unmarshal(out, &line); err != nil {
+			t.Fatalf("%s line %s: %v", name, out, err)
+		}
+		want := map[string]any{
+			"log.level":           "warning",
+			"message":             "gRPC call failed",
+			"http.request.method": "GET",
+			"url.path":            "/api/v1/events",
+			"trace.id":            "4bf92f3577b34da6a3ce929d0e0e4736",
+			"event.duration":      float64(1500000),
+			"error.message":       "unavailable",
+			"ecs.version":         ECSVersion,
+			"service.name":        "apigw",
+		}
+		for key, value := range want {
+			if line[key] != value {
+				t.Errorf("%s line %s: %s = %v, want %v", name, out, key, line[key], value)
+			}
+		}
+		if _, ok := line["@timestamp"]; !ok {
+			t.Errorf("%s line %s: no @timestamp", name, out)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"apigw/pkg/utils/pool"
+)
+
+// maxPooledFields keeps maps that grew unusually large out of the pool
+const maxPooledFields = 16
+
+// fieldsPool reuses the field maps built for request logs. logrus copies the fields
+// into the entry, so a map can be released as soon as the entry has been logged.
+var fieldsPool = pool.New(
+	func() logrus.Fields {
+		return make(logrus.Fields, maxPooledFields)
+	},
+	func(fields logrus.Fields) bool {
+		if len(fields) > maxPooledFields {
+			return false
+		}
+		clear(fields)
+		return true
+	},
+)
+
+// AcquireFields returns an empty field map from the pool; release it with ReleaseFields
+// once the entry using it has been logged
+func AcquireFields() logrus.Fields {
+	return fieldsPool.Get()
+}
+
+// ReleaseFields returns a field map obtained from AcquireFields to the pool
+func ReleaseFields(fields logrus.Fields) {
+	fieldsPool.Put(fields)
+}
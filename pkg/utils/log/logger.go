@@ -25,10 +25,12 @@ func InitLogger() error {
 	logger.SetLevel(logLevel)
 
 	// Set log format
-	format := os.Getenv("LOG_FORMAT")
-	if format == "json" {
+	switch os.Getenv("LOG_FORMAT") {
+	case "json":
 		logger.SetFormatter(&logrus.JSONFormatter{})
-	} else {
+	case "ecs":
+		logger.SetFormatter(NewECSFormatter(""))
+	default:
 		logger.SetFormatter(&logrus.TextFormatter{
 			FullTimestamp: true,
 		})
@@ -56,3 +58,17 @@ func GetLogger() *logrus.Logger {
 	}
 	return logger
 }
+
+// SetLevel changes the log level of the logger instance at runtime, and of the modules
+// without a level of their own
+func SetLevel(level string) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	GetLogger().SetLevel(logLevel)
+	modules.mu.Lock()
+	syncModules()
+	modules.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,28 @@
+package log
+
+import "strings"
+
+// MaskEmail hides the local part of an email address, keeping its first character
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return MaskString(email)
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// MaskPhone hides all but the last two digits of a phone number
+func MaskPhone(phone string) string {
+	if len(phone) <= 2 {
+		return MaskString(phone)
+	}
+	return "***" + phone[len(phone)-2:]
+}
+
+// MaskString fully hides a sensitive value while indicating whether it was set
+func MaskString(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "***"
+}
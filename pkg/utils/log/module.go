@@ -0,0 +1,168 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Modules whose lines may be logged at a level of their own
+const (
+	ModuleRateLimiter = "rate_limiter" // Token buckets and quotas
+	ModuleAuth        = "auth"         // Token and signature verification
+	ModuleGRPC        = "grpc"         // Backend gRPC clients
+	// ModuleHandlers logs the lines of requests logged with the logger of their request:
+	// handlers, and the middleware around them not in a module of its own
+	ModuleHandlers = "handlers"
+)
+
+// Modules lists the modules whose level can be set
+var Modules = []string{ModuleRateLimiter, ModuleAuth, ModuleGRPC, ModuleHandlers}
+
+// moduleKey identifies the logger of a module derived from a logger
+type moduleKey struct {
+	parent *logrus.Logger
+	module string
+}
+
+// modules holds the levels set for modules and the loggers derived for them. A module
+// logger writes where its parent does, at the level of its module, or at the level of its
+// parent when its module has none.
+var modules = struct {
+	mu      sync.Mutex
+	levels  map[string]logrus.Level
+	loggers map[moduleKey]*logrus.Logger
+	parents map[*logrus.Logger]*logrus.Logger
+}{
+	levels:  make(map[string]logrus.Level),
+	loggers: make(map[moduleKey]*logrus.Logger),
+	parents: make(map[*logrus.Logger]*logrus.Logger),
+}
+
+// ForModule returns the logger of a module: a logger sharing the output, formatter and
+// hooks of logger, at the level set for the module. Modules without a level follow the
+// level of their parent, as Configure and SetLevel set it. ForModule returns the same
+// logger for the same module, and loggers of modules derive from their parent.
+func ForModule(logger *logrus.Logger, module string) *logrus.Logger {
+	modules.mu.Lock()
+	defer modules.mu.Unlock()
+	if parent, ok := modules.parents[logger]; ok {
+		logger = parent
+	}
+	key := moduleKey{parent: logger, module: module}
+	if derived, ok := modules.loggers[key]; ok {
+		return derived
+	}
+	derived := &logrus.Logger{
+		Out:          logger.Out,
+		Hooks:        logger.Hooks,
+		Formatter:    logger.Formatter,
+		ReportCaller: logger.ReportCaller,
+		ExitFunc:     logger.ExitFunc,
+		Level:        moduleLevel(logger, module),
+	}
+	modules.loggers[key] = derived
+	modules.parents[derived] = logger
+	return derived
+}
+
+// SetModuleLevels sets the levels of modules, by module; modules left out follow the
+// level of their parent logger again
+func SetModuleLevels(levels map[string]string) error {
+	parsed := make(map[string]logrus.Level, len(levels))
+	for module, level := range levels {
+		if !KnownModule(module) {
+			return fmt.Errorf("unknown log module %q", module)
+		}
+		l, err := logrus.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("log module %s: %w", module, err)
+		}
+		parsed[module] = l
+	}
+	modules.mu.Lock()
+	defer modules.mu.Unlock()
+	modules.levels = parsed
+	syncModules()
+	return nil
+}
+
+// KnownModule reports whether a level can be set for a module
+func KnownModule(module string) bool {
+	i := sort.SearchStrings(sortedModules, module)
+	return i < len(sortedModules) && sortedModules[i] == module
+}
+
+// sortedModules are the modules in order, for KnownModule
+var sortedModules = func() []string {
+	sorted := append([]string(nil), Modules...)
+	sort.Strings(sorted)
+	return sorted
+}()
+
+// refreshModules applies the level, output, formatter and hooks of their parent to the
+// loggers of modules, once their parent was reconfigured
+func refreshModules() {
+	modules.mu.Lock()
+	defer modules.mu.Unlock()
+	for key, derived := range modules.loggers {
+		derived.SetOutput(key.parent.Out)
+		derived.SetFormatter(key.parent.Formatter)
+		derived.ReplaceHooks(key.parent.Hooks)
+	}
+	syncModules()
+}
+
+// syncModules applies the levels of modules, or of their parent, to their loggers; the
+// caller holds modules.mu
+func syncModules() {
+	for key, derived := range modules.loggers {
+		derived.SetLevel(moduleLevel(key.parent, key.module))
+	}
+}
+
+// moduleLevel returns the level of the logger of a module; the caller holds modules.mu
+func moduleLevel(parent *logrus.Logger, module string) logrus.Level {
+	if level, ok := modules.levels[module]; ok {
+		return level
+	}
+	return parent.GetLevel()
+}
+
+// moduleParent returns the logger the logger of a module derives from, or nil
+func moduleParent(logger *logrus.Logger) *logrus.Logger {
+	modules.mu.Lock()
+	defer modules.mu.Unlock()
+	return modules.parents[logger]
+}
+
+// moduleHandler logs the lines of a module through the handler of its parent, at the
+// level of the module
+type moduleHandler struct {
+	next  Handler
+	level slog.Leveler
+}
+
+// Enabled reports whether the module logs lines of a level
+func (h *moduleHandler) Enabled(_ context.Context, level Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle logs a line through the handler of the parent
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler adding attributes to every line
+func (h *moduleHandler) WithAttrs(attrs []Attr) Handler {
+	return &moduleHandler{next: h.next.WithAttrs(attrs), level: h.level}
+}
+
+// WithGroup returns a handler grouping the attributes that follow
+func (h *moduleHandler) WithGroup(name string) Handler {
+	return &moduleHandler{next: h.next.WithGroup(name), level: h.level}
+}
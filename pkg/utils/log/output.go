@@ -0,0 +1,189 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Options configures the logger
+type Options struct {
+	Level       string
+	Backend     string // Backend of the structured logger: logrus or slog
+	Format      string // text, json or ecs
+	Service     string // service.name of the lines of the ecs format
+	Output      string // stdout, stderr or file
+	File        string // Written when Output is file
+	ErrorOutput string // stderr or file; empty keeps error lines with the others only
+	ErrorFile   string // Written when ErrorOutput is file
+	Rotation    Rotation
+	Sampling    Sampling
+	// Modules sets the levels of modules, by module; modules left out log at Level
+	Modules map[string]string
+}
+
+// Rotation configures the rotation of log files
+type Rotation struct {
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+	LocalTime  bool
+}
+
+// Configure applies opts to the logger instance, replacing the setup of InitLogger, and
+// sets up the structured logger of its backend. The returned closer closes the log files
+// once nothing logs anymore.
+func Configure(opts Options) (io.Closer, error) {
+	logger := GetLogger()
+	if opts.Level != "" {
+		level, err := logrus.ParseLevel(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetLevel(level)
+	}
+	if err := SetModuleLevels(opts.Modules); err != nil {
+		return nil, err
+	}
+
+	var formatter logrus.Formatter = &logrus.TextFormatter{FullTimestamp: true}
+	switch opts.Format {
+	case "json":
+		formatter = &logrus.JSONFormatter{}
+	case "ecs":
+		formatter = NewECSFormatter(opts.Service)
+	}
+
+	var closers closers
+	out, err := openOutput(opts.Output, opts.File, opts.Rotation, &closers)
+	if err != nil {
+		return nil, err
+	}
+	var hook *errorHook
+	var errOut io.Writer
+	if opts.ErrorOutput != "" {
+		errOut, err = openOutput(opts.ErrorOutput, opts.ErrorFile, opts.Rotation, &closers)
+		if err != nil {
+			closers.Close()
+			return nil, err
+		}
+		// The error stream samples its lines apart, as hooks run outside the logger's lock
+		var errFormatter logrus.Formatter = formatter
+		if opts.Sampling.Enabled() {
+			errFormatter = newSamplingFormatter(formatter, opts.Sampling)
+		}
+		hook = &errorHook{out: errOut, formatter: errFormatter}
+	}
+
+	if opts.Sampling.Enabled() {
+		formatter = newSamplingFormatter(formatter, opts.Sampling)
+	}
+	logger.SetFormatter(formatter)
+	logger.SetOutput(out)
+	hooks := make(logrus.LevelHooks)
+	for level, levelHooks := range logger.Hooks {
+		for _, h := range levelHooks {
+			if _, ok := h.(*errorHook); !ok {
+				hooks[level] = append(hooks[level], h)
+			}
+		}
+	}
+	if hook != nil {
+		for _, level := range hook.Levels() {
+			hooks[level] = append(hooks[level], hook)
+		}
+	}
+	logger.ReplaceHooks(hooks)
+	refreshModules()
+
+	// The slog backend writes to the same streams, sampled apart and at the logger's level
+	if opts.Backend != "slog" {
+		structured.Store(nil)
+		return closers, nil
+	}
+	level := logrusLeveler{logger: logger}
+	handler := newSlogHandler(opts, out, level)
+	if opts.Sampling.Enabled() {
+		handler = &samplingHandler{sampler: newSampler(opts.Sampling), next: handler}
+	}
+	if errOut != nil {
+		errHandler := newSlogHandler(opts, errOut, level)
+		if opts.Sampling.Enabled() {
+			errHandler = &samplingHandler{sampler: newSampler(opts.Sampling), next: errHandler}
+		}
+		handler = &errorStreamHandler{next: handler, errors: errHandler}
+	}
+	structured.Store(&structuredLogger{logrus: logger, logger: slog.New(handler)})
+	return closers, nil
+}
+
+// openOutput opens a log stream, rotated when it is a file
+func openOutput(output, path string, rotation Rotation, closers *closers) (io.Writer, error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "file":
+		if path == "" {
+			return nil, errors.New("log file path is required")
+		}
+		file := &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    rotation.MaxSizeMB,
+			MaxAge:     rotation.MaxAgeDays,
+			MaxBackups: rotation.MaxBackups,
+			Compress:   rotation.Compress,
+			LocalTime:  rotation.LocalTime,
+		}
+		// Open the file now, so a path that cannot be written fails at startup
+		if _, err := file.Write(nil); err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		*closers = append(*closers, file)
+		return file, nil
+	default:
+		return nil, fmt.Errorf("unknown log output %q", output)
+	}
+}
+
+// errorHook writes error lines to a stream of their own as well
+type errorHook struct {
+	out       io.Writer
+	formatter logrus.Formatter
+}
+
+// Levels returns the levels written to the error stream
+func (h *errorHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire writes an entry to the error stream
+func (h *errorHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil || len(line) == 0 {
+		return err
+	}
+	_, err = h.out.Write(line)
+	return err
+}
+
+// closers closes the log files
+type closers []io.Closer
+
+// Close closes every file, returning the first error
+func (c closers) Close() error {
+	var first error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
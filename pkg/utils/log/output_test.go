@@ -0,0 +1,43 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestConfigureFiles checks log lines go to the log file and error lines to the error
+// file as well
+func TestConfigureFiles(t *testing.T) {
+	dir := t.TempDir()
+	path, errorPath := filepath.Join(dir, "apigw.log"), filepath.Join(dir, "error.log")
+	files, err := Configure(Options{
+		Level:       "info",
+		Format:      "json",
+		Output:      "file",
+		File:        path,
+		ErrorOutput: "file",
+		ErrorFile:   errorPath,
+		Rotation:    Rotation{MaxSizeMB: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		files.Close()
+		Configure(Options{})
+	})
+
+	GetLogger().Info("booking opened")
+	GetLogger().Error("booking failed")
+
+	all, _ := os.ReadFile(path)
+	if !strings.Contains(string(all), `"msg":"booking opened"`) || !strings.Contains(string(all), `"msg":"booking failed"`) {
+		t.Errorf("log file = %s, want both lines as JSON", all)
+	}
+	errors, _ := os.ReadFile(errorPath)
+	if strings.Contains(string(errors), "booking opened") || !strings.Contains(string(errors), `"msg":"booking failed"`) {
+		t.Errorf("error file = %s, want the error line only", errors)
+	}
+}
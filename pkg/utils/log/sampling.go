@@ -0,0 +1,165 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SuppressedField counts the identical lines left out since the previous one logged
+const SuppressedField = "suppressed"
+
+// maxSampledKeys bounds the messages sampled at once; lines beyond it are logged as is
+const maxSampledKeys = 4096
+
+// Sampling configures the sampling of identical log lines, those with the same level,
+// message and key fields
+type Sampling struct {
+	Interval   time.Duration // Counts are reset every interval; zero disables sampling
+	Initial    int           // Identical lines logged per interval before sampling
+	Thereafter int           // Then one identical line in Thereafter; 0 or 1 logs them all
+	// RateLimit caps the identical warn and error lines logged per interval, sampled
+	// ones included; 0 leaves them uncapped
+	RateLimit int
+	KeyFields []string // Fields whose values tell lines with the same message apart
+}
+
+// Enabled reports whether lines are sampled
+func (s Sampling) Enabled() bool {
+	return s.Interval > 0
+}
+
+// sampleCount is the count of the lines of a key in the current interval
+type sampleCount struct {
+	seen       int
+	logged     int
+	suppressed int // Since the previous line logged, across intervals
+}
+
+// sampler decides which identical lines are logged; it is shared by the logrus formatter
+// and the slog handler sampling lines
+type sampler struct {
+	sampling Sampling
+	now      func() time.Time
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]*sampleCount
+}
+
+// newSampler creates a sampler
+func newSampler(sampling Sampling) *sampler {
+	return &sampler{
+		sampling: sampling,
+		now:      time.Now,
+		counts:   make(map[string]*sampleCount),
+	}
+}
+
+// samplingFormatter leaves out identical lines beyond the sampling, counting those left
+// out on the next line logged
+type samplingFormatter struct {
+	*sampler
+	next logrus.Formatter
+}
+
+// newSamplingFormatter samples the lines formatted by next
+func newSamplingFormatter(next logrus.Formatter, sampling Sampling) *samplingFormatter {
+	return &samplingFormatter{sampler: newSampler(sampling), next: next}
+}
+
+// Format formats the entry when it is sampled in, and returns nothing otherwise
+func (f *samplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	suppressed, ok := f.sample(entry)
+	if !ok {
+		return nil, nil
+	}
+	if suppressed > 0 {
+		// The entry may be formatted for hooks concurrently; count on a copy
+		dup := *entry
+		dup.Data = make(logrus.Fields, len(entry.Data)+1)
+		for k, v := range entry.Data {
+			dup.Data[k] = v
+		}
+		dup.Data[SuppressedField] = suppressed
+		entry = &dup
+	}
+	return f.next.Format(entry)
+}
+
+// sample reports whether an entry is logged, and how many identical lines were left out
+// since the previous one
+func (f *samplingFormatter) sample(entry *logrus.Entry) (int, bool) {
+	return f.allow(entry.Level, entry.Message, func(field string) (any, bool) {
+		v, ok := entry.Data[field]
+		return v, ok
+	})
+}
+
+// allow reports whether a line is logged, and how many identical lines were left out
+// since the previous one; field looks up the value of a key field of the line
+func (s *sampler) allow(level logrus.Level, message string, field func(string) (any, bool)) (int, bool) {
+	// The last words of the process are never left out
+	if level <= logrus.FatalLevel {
+		return 0, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.start) >= s.sampling.Interval {
+		s.start = now
+		for key, count := range s.counts {
+			if count.suppressed == 0 {
+				delete(s.counts, key)
+				continue
+			}
+			count.seen, count.logged = 0, 0
+		}
+	}
+
+	key := s.key(level, message, field)
+	count, ok := s.counts[key]
+	if !ok {
+		if len(s.counts) >= maxSampledKeys {
+			return 0, true
+		}
+		count = &sampleCount{}
+		s.counts[key] = count
+	}
+	count.seen++
+
+	sampledOut := count.seen > s.sampling.Initial && s.sampling.Thereafter > 1 &&
+		(count.seen-s.sampling.Initial)%s.sampling.Thereafter != 0
+	limited := s.sampling.RateLimit > 0 && level <= logrus.WarnLevel &&
+		count.logged >= s.sampling.RateLimit
+	if sampledOut || limited {
+		count.suppressed++
+		return 0, false
+	}
+	count.logged++
+	suppressed := count.suppressed
+	count.suppressed = 0
+	return suppressed, true
+}
+
+// key returns the sampling key of a line: its level, message and key fields
+func (s *sampler) key(level logrus.Level, message string, field func(string) (any, bool)) string {
+	if len(s.sampling.KeyFields) == 0 {
+		return level.String() + "\x00" + message
+	}
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(0)
+	b.WriteString(message)
+	for _, name := range s.sampling.KeyFields {
+		b.WriteByte(0)
+		if v, ok := field(name); ok {
+			fmt.Fprint(&b, v)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestSampling checks identical lines are logged one in Thereafter once Initial were,
+// with the count of those left out, and that warn and error lines are capped
+func TestSampling(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	f := newSamplingFormatter(&logrus.JSONFormatter{}, Sampling{
+		Interval:   time.Second,
+		Initial:    2,
+		Thereafter: 3,
+		RateLimit:  3,
+	})
+	f.now = func() time.Time { return now }
+
+	logged := func(level logrus.Level, message string) (int, bool) {
+		return f.sample(&logrus.Entry{Level: level, Message: message, Data: logrus.Fields{}})
+	}
+
+	var got []bool
+	var suppressed []int
+	for i := 0; i < 8; i++ {
+		n, ok := logged(logrus.InfoLevel, "cache miss")
+		got = append(got, ok)
+		if ok {
+			suppressed = append(suppressed, n)
+		}
+	}
+	want := []bool{true, true, false, false, true, false, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("info lines logged = %v, want %v", got, want)
+		}
+	}
+	if suppressed[2] != 2 || suppressed[3] != 2 {
+		t.Errorf("suppressed counts = %v, want 2 before each sampled line", suppressed)
+	}
+
+	// Warn and error lines are capped whatever the sampling
+	f.sampling = Sampling{Interval: time.Second, Thereafter: 1, RateLimit: 3}
+	for i := 0; i < 3; i++ {
+		if _, ok := logged(logrus.ErrorLevel, "backend unavailable"); !ok {
+			t.Fatalf("error line %d left out, want the first 3 logged", i)
+		}
+	}
+	if _, ok := logged(logrus.ErrorLevel, "backend unavailable"); ok {
+		t.Error("4th error line logged, want it capped")
+	}
+	if _, ok := logged(logrus.FatalLevel, "backend unavailable"); !ok {
+		t.Error("fatal line left out")
+	}
+
+	// The count of lines left out carries over to the next interval
+	now = now.Add(time.Second)
+	if n, ok := logged(logrus.ErrorLevel, "backend unavailable"); !ok || n != 1 {
+		t.Errorf("first error line of the next interval = (%d, %v), want logged with 1 suppressed", n, ok)
+	}
+}
@@ -0,0 +1,110 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+)
+
+// newSlogHandler returns the handler of the slog backend, writing lines to out in the
+// format of opts at the level of level. Levels keep slog's names (INFO, WARN, ...) but in
+// the ecs format: renaming them as logrus does would cost an allocation per line.
+func newSlogHandler(opts Options, out io.Writer, level slog.Leveler) Handler {
+	switch opts.Format {
+	case "ecs":
+		handler := slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level, ReplaceAttr: ecsReplaceAttr})
+		attrs := []Attr{slog.String("ecs.version", ECSVersion)}
+		if opts.Service != "" {
+			attrs = append(attrs, slog.String("service.name", opts.Service))
+		}
+		return handler.WithAttrs(attrs)
+	case "json":
+		return slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level})
+	default:
+		return slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+	}
+}
+
+// samplingHandler leaves out identical lines beyond the sampling, counting those left
+// out on the next line logged
+type samplingHandler struct {
+	*sampler
+	next  Handler
+	attrs []Attr // Attributes of With, read for key fields
+}
+
+// Enabled reports whether the next handler logs lines of a level
+func (h *samplingHandler) Enabled(ctx context.Context, level Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle logs a line when it is sampled in
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	suppressed, ok := h.allow(logrusLevel(r.Level), r.Message, func(field string) (any, bool) {
+		var value any
+		found := false
+		r.Attrs(func(a Attr) bool {
+			if a.Key == field {
+				value, found = a.Value.Any(), true
+			}
+			return !found
+		})
+		for i := len(h.attrs) - 1; !found && i >= 0; i-- {
+			if h.attrs[i].Key == field {
+				value, found = h.attrs[i].Value.Any(), true
+			}
+		}
+		return value, found
+	})
+	if !ok {
+		return nil
+	}
+	if suppressed > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int(SuppressedField, suppressed))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler adding attributes to every line, sampled alike
+func (h *samplingHandler) WithAttrs(attrs []Attr) Handler {
+	return &samplingHandler{sampler: h.sampler, next: h.next.WithAttrs(attrs), attrs: append(slices.Clip(h.attrs), attrs...)}
+}
+
+// WithGroup returns a handler grouping the attributes that follow, sampled alike
+func (h *samplingHandler) WithGroup(name string) Handler {
+	return &samplingHandler{sampler: h.sampler, next: h.next.WithGroup(name), attrs: h.attrs}
+}
+
+// errorStreamHandler writes error lines to the error stream as well
+type errorStreamHandler struct {
+	next   Handler
+	errors Handler
+}
+
+// Enabled reports whether lines of a level are logged
+func (h *errorStreamHandler) Enabled(ctx context.Context, level Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle logs a line, and writes it to the error stream when it is an error
+func (h *errorStreamHandler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.next.Handle(ctx, r)
+	if r.Level >= LevelError {
+		if errStream := h.errors.Handle(ctx, r); err == nil {
+			err = errStream
+		}
+	}
+	return err
+}
+
+// WithAttrs returns a handler adding attributes to the lines of both streams
+func (h *errorStreamHandler) WithAttrs(attrs []Attr) Handler {
+	return &errorStreamHandler{next: h.next.WithAttrs(attrs), errors: h.errors.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler grouping the attributes that follow in both streams
+func (h *errorStreamHandler) WithGroup(name string) Handler {
+	return &errorStreamHandler{next: h.next.WithGroup(name), errors: h.errors.WithGroup(name)}
+}
@@ -0,0 +1,200 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is the structured logger of hot paths. Lines are logged with typed attributes,
+// e.g. logger.LogAttrs(ctx, log.LevelInfo, "gRPC call completed", log.String("grpc_method",
+// method)), which the slog backend formats without the field maps and entries logrus
+// allocates per line. Handler is the interface behind it: log.backend picks slog's own
+// handler, or the logrus adapter logging through the logrus logger.
+type Logger = slog.Logger
+
+// Handler writes the lines of a Logger
+type Handler = slog.Handler
+
+// Attr is an attribute of a structured line
+type Attr = slog.Attr
+
+// Level is the level of a structured line
+type Level = slog.Level
+
+// Levels of structured lines
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
+)
+
+// String returns a string attribute
+func String(key, value string) Attr { return slog.String(key, value) }
+
+// Int returns an int attribute
+func Int(key string, value int) Attr { return slog.Int(key, value) }
+
+// Int64 returns an int64 attribute
+func Int64(key string, value int64) Attr { return slog.Int64(key, value) }
+
+// Float64 returns a float64 attribute
+func Float64(key string, value float64) Attr { return slog.Float64(key, value) }
+
+// Bool returns a bool attribute
+func Bool(key string, value bool) Attr { return slog.Bool(key, value) }
+
+// Time returns a time attribute
+func Time(key string, value time.Time) Attr { return slog.Time(key, value) }
+
+// Any returns an attribute of any value
+func Any(key string, value any) Attr { return slog.Any(key, value) }
+
+// Err returns the attribute of an error, under the key logrus logs errors with
+func Err(err error) Attr { return slog.Any(logrus.ErrorKey, err) }
+
+// Milliseconds returns a duration attribute in milliseconds, as durations are logged
+func Milliseconds(key string, d time.Duration) Attr {
+	return slog.Float64(key, float64(d.Microseconds())/1000)
+}
+
+// structuredLogger is the structured logger Configure set up with the slog backend, with
+// the logrus logger whose outputs it shares
+type structuredLogger struct {
+	logrus *logrus.Logger
+	logger *Logger
+}
+
+// structured is the structured logger of the slog backend, nil with the logrus backend
+var structured atomic.Pointer[structuredLogger]
+
+// Structured returns the structured logger writing where logger does: the slog backend
+// when Configure set logger, or the parent of the module logger, up with it, or else an
+// adapter logging through logger
+func Structured(logger *logrus.Logger) *Logger {
+	if s := structured.Load(); s != nil {
+		if s.logrus == logger {
+			return s.logger
+		}
+		if moduleParent(logger) == s.logrus {
+			return slog.New(&moduleHandler{next: s.logger.Handler(), level: logrusLeveler{logger: logger}})
+		}
+	}
+	return slog.New(NewLogrusHandler(logger))
+}
+
+// logrusLevel returns the logrus level of a structured level
+func logrusLevel(level Level) logrus.Level {
+	switch {
+	case level >= LevelError:
+		return logrus.ErrorLevel
+	case level >= LevelWarn:
+		return logrus.WarnLevel
+	case level >= LevelInfo:
+		return logrus.InfoLevel
+	case level >= LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
+
+// logrusLeveler follows the level of a logrus logger, so levels changed at runtime apply
+// to the slog backend too
+type logrusLeveler struct {
+	logger *logrus.Logger
+}
+
+// Level returns the structured level of the logrus logger
+func (l logrusLeveler) Level() Level {
+	switch l.logger.GetLevel() {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return LevelError
+	case logrus.WarnLevel:
+		return LevelWarn
+	case logrus.InfoLevel:
+		return LevelInfo
+	case logrus.DebugLevel:
+		return LevelDebug
+	default:
+		return LevelDebug - 4
+	}
+}
+
+// logrusHandler is the logrus adapter: it logs structured lines through a logrus logger,
+// its formatter, hooks and sampling included
+type logrusHandler struct {
+	logger *logrus.Logger
+	attrs  []Attr // Attributes of With, their keys prefixed with their groups
+	prefix string // Prefix of the groups of WithGroup, e.g. "payload."
+}
+
+// NewLogrusHandler returns the handler logging through a logrus logger
+func NewLogrusHandler(logger *logrus.Logger) Handler {
+	return &logrusHandler{logger: logger}
+}
+
+// Enabled reports whether the logrus logger logs lines of a level
+func (h *logrusHandler) Enabled(_ context.Context, level Level) bool {
+	return h.logger.IsLevelEnabled(logrusLevel(level))
+}
+
+// Handle logs a line as a logrus entry
+func (h *logrusHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(logrus.Fields, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		addField(fields, "", a)
+	}
+	r.Attrs(func(a Attr) bool {
+		addField(fields, h.prefix, a)
+		return true
+	})
+	entry := logrus.NewEntry(h.logger)
+	entry.Data = fields
+	entry.Time = r.Time
+	entry.Log(logrusLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler adding attributes to every line
+func (h *logrusHandler) WithAttrs(attrs []Attr) Handler {
+	dup := *h
+	dup.attrs = make([]Attr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(dup.attrs, h.attrs)
+	for _, a := range attrs {
+		dup.attrs = append(dup.attrs, Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &dup
+}
+
+// WithGroup returns a handler prefixing the keys of the attributes that follow
+func (h *logrusHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	dup := *h
+	dup.prefix = h.prefix + name + "."
+	return &dup
+}
+
+// addField adds an attribute to logrus fields, those of groups under prefixed keys
+func addField(fields logrus.Fields, prefix string, a Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addField(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
@@ -0,0 +1,116 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestStructuredBackends checks the slog backend writes to the configured streams with
+// logrus level names, and the logrus adapter logs attributes as fields
+func TestStructuredBackends(t *testing.T) {
+	dir := t.TempDir()
+	path, errorPath := filepath.Join(dir, "apigw.log"), filepath.Join(dir, "error.log")
+	files, err := Configure(Options{
+		Level:       "info",
+		Backend:     "slog",
+		Format:      "json",
+		Output:      "file",
+		File:        path,
+		ErrorOutput: "file",
+		ErrorFile:   errorPath,
+		Rotation:    Rotation{MaxSizeMB: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		files.Close()
+		Configure(Options{})
+	})
+
+	ctx := context.Background()
+	logger := Structured(GetLogger())
+	logger.LogAttrs(ctx, LevelDebug, "cache hit")
+	logger.LogAttrs(ctx, LevelWarn, "rate limited", String("client_id", "ip:203.0.113.1"))
+	logger.LogAttrs(ctx, LevelError, "backend down", Err(errors.New("unavailable")))
+
+	all, _ := os.ReadFile(path)
+	for _, want := range []string{`"level":"WARN","msg":"rate limited","client_id":"ip:203.0.113.1"`, `"error":"unavailable"`} {
+		if !strings.Contains(string(all), want) {
+			t.Errorf("log file = %s, want %s", all, want)
+		}
+	}
+	if strings.Contains(string(all), "cache hit") {
+		t.Errorf("log file = %s, want debug lines left out", all)
+	}
+	errorLines, _ := os.ReadFile(errorPath)
+	if strings.Contains(string(errorLines), "rate limited") || !strings.Contains(string(errorLines), `"msg":"backend down"`) {
+		t.Errorf("error file = %s, want the error line only", errorLines)
+	}
+
+	// Other loggers, and every logger with the logrus backend, log through logrus
+	var buf bytes.Buffer
+	other := logrus.New()
+	other.SetOutput(&buf)
+	other.SetFormatter(&logrus.JSONFormatter{})
+	Structured(other).With(String("route", "/api/v1/events")).WithGroup("call").
+		LogAttrs(ctx, LevelInfo, "gRPC call completed", Milliseconds("duration_ms", 1500*time.Microsecond))
+	for _, want := range []string{`"level":"info"`, `"route":"/api/v1/events"`, `"call.duration_ms":1.5`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("logrus line = %s, want %s", buf.String(), want)
+		}
+	}
+}
+
+// TestModuleLevels checks modules log at their own level, through both backends, and
+// follow the level of their parent once their level is unset
+func TestModuleLevels(t *testing.T) {
+	var buf bytes.Buffer
+	parent := logrus.New()
+	parent.SetOutput(&buf)
+	parent.SetLevel(logrus.InfoLevel)
+	if err := SetModuleLevels(map[string]string{ModuleRateLimiter: "debug", ModuleGRPC: "warn"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetModuleLevels(nil) })
+
+	limiter := ForModule(parent, ModuleRateLimiter)
+	if ForModule(parent, ModuleRateLimiter) != limiter || ForModule(limiter, ModuleRateLimiter) != limiter {
+		t.Error("ForModule derived another logger for the same module")
+	}
+	parent.Debug("access path")
+	limiter.Debug("bucket refilled")
+	limiter.WithField("request_id", "req-1").Debug("bucket checked")
+	Structured(ForModule(parent, ModuleGRPC)).LogAttrs(context.Background(), LevelInfo, "gRPC call completed")
+	Structured(ForModule(parent, ModuleHandlers)).LogAttrs(context.Background(), LevelInfo, "order placed")
+
+	out := buf.String()
+	for _, want := range []string{"bucket refilled", "bucket checked", "request_id=req-1", "order placed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log = %s, want %s", out, want)
+		}
+	}
+	for _, unwanted := range []string{"access path", "gRPC call completed"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("log = %s, want %s left out", out, unwanted)
+		}
+	}
+
+	if err := SetModuleLevels(map[string]string{"limiter": "debug"}); err == nil {
+		t.Error("SetModuleLevels accepted an unknown module")
+	}
+	if err := SetModuleLevels(nil); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.IsLevelEnabled(logrus.DebugLevel) {
+		t.Error("module logs at debug once its level is unset, want the level of its parent")
+	}
+}
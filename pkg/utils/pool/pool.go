@@ -0,0 +1,35 @@
+package pool
+
+import "sync"
+
+// Pool is a typed sync.Pool for request-scoped values that are allocated on every
+// request. T should be pointer-shaped (a pointer, map or channel) so that pooling does
+// not allocate itself. Values are reset before they are returned to the pool, so Get
+// always hands out a value in its initial state.
+type Pool[T any] struct {
+	pool  sync.Pool
+	reset func(T) bool
+}
+
+// New creates a pool allocating values with newFn. reset clears a value before it is
+// pooled again and reports whether it should be kept at all, so that values which grew
+// unusually large during a request are left to the garbage collector instead.
+func New[T any](newFn func() T, reset func(T) bool) *Pool[T] {
+	return &Pool[T]{
+		pool:  sync.Pool{New: func() any { return newFn() }},
+		reset: reset,
+	}
+}
+
+// Get returns a value from the pool, allocating one when the pool is empty
+func (p *Pool[T]) Get() T {
+	return p.pool.Get().(T)
+}
+
+// Put resets a value and returns it to the pool. The value must not be used afterwards.
+func (p *Pool[T]) Put(v T) {
+	if p.reset != nil && !p.reset(v) {
+		return
+	}
+	p.pool.Put(v)
+}
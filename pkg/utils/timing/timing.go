@@ -0,0 +1,76 @@
+// Package timing breaks the latency of a request down by stage. The timings travel in the
+// context of the request, so the middleware, handlers and backend clients along its way
+// each add the time spent in their stage.
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Stages of a request
+const (
+	Auth          = "auth"          // Token and signature verification
+	RateLimit     = "rate_limit"    // Rate limit and quota checks
+	Upstream      = "upstream"      // Backend calls, summed when made concurrently
+	Serialization = "serialization" // Rendering of response bodies
+)
+
+// contextKey is the context key of the timings of a request
+type contextKey struct{}
+
+// Timings holds the time a request spent in each stage; the methods of a nil Timings do
+// nothing, so stages are timed whether or not the request is
+type Timings struct {
+	mu     sync.Mutex
+	stages map[string]time.Duration
+}
+
+// New creates empty timings
+func New() *Timings {
+	return &Timings{stages: make(map[string]time.Duration, 4)}
+}
+
+// NewContext returns a context carrying timings
+func NewContext(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the timings of a context, nil when the request is not timed
+func FromContext(ctx context.Context) *Timings {
+	t, _ := ctx.Value(contextKey{}).(*Timings)
+	return t
+}
+
+// Add adds time spent in a stage
+func (t *Timings) Add(stage string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.stages[stage] += d
+	t.mu.Unlock()
+}
+
+// Since adds the time spent in a stage since start
+func (t *Timings) Since(stage string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.Add(stage, time.Since(start))
+}
+
+// Stages returns the time spent in each stage timed
+func (t *Timings) Stages() map[string]time.Duration {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stages := make(map[string]time.Duration, len(t.stages))
+	for stage, d := range t.stages {
+		stages[stage] = d
+	}
+	return stages
+}